@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/services"
+	"log"
+	"os"
+)
+
+// defaultSeedHospital is used when SEED_ADMIN_HOSPITAL is not set.
+const defaultSeedHospital = "Hospital A"
+
+// The seed command migrates the schema, seeds the default hospitals and optionally creates
+// an admin account, then exits. Run it before starting the server with AUTO_MIGRATE=false.
+// With -down it instead rolls back the latest versioned migration; the baseline migration also needs -force.
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	down := flag.Bool("down", false, "roll back the latest migration instead of migrating and seeding")
+	force := flag.Bool("force", false, "with -down, also roll back an irreversible migration such as the baseline")
+	flag.Parse()
+
+	log.Println("Seeding Hospital Middleware database...")
+
+	// 1. Load Configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("FATAL: Could not load configuration: %v", err)
+	}
+
+	// 2. Connect and migrate; AUTO_MIGRATE only concerns the server
+	if err := database.Open(cfg); err != nil {
+		log.Fatalf("FATAL: Could not connect to database: %v", err)
+	}
+	if *down {
+		migration, err := database.RollbackMigration(database.GetDB(), *force)
+		if err != nil {
+			log.Fatalf("FATAL: Could not roll back migration: %v", err)
+		}
+		log.Printf("Rolled back migration %d_%s.", migration.Version, migration.Name)
+		return
+	}
+	if err := database.Migrate(cfg); err != nil {
+		log.Fatalf("FATAL: Could not migrate database: %v", err)
+	}
+
+	// 3. Create the admin account
+	username := os.Getenv("SEED_ADMIN_USERNAME")
+	if username == "" {
+		log.Println("SEED_ADMIN_USERNAME is not set; skipping admin creation.")
+		log.Println("Seeding complete.")
+		return
+	}
+	hospital := os.Getenv("SEED_ADMIN_HOSPITAL")
+	if hospital == "" {
+		hospital = defaultSeedHospital
+	}
+
+	repo := database.NewPostgresRepository()
+	created, err := services.BootstrapAdmin(repo, cfg.PasswordPolicy, username, os.Getenv("SEED_ADMIN_PASSWORD"), hospital)
+	if err != nil {
+		log.Fatalf("FATAL: Could not create admin: %v", err)
+	}
+	if created {
+		log.Printf("Created admin %s for %s.", username, hospital)
+	} else {
+		log.Printf("Staff %s already exists; leaving it unchanged.", username)
+	}
+	log.Println("Seeding complete.")
+}
@@ -1,20 +1,39 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"hospital-middleware/internal/api"
+	"hospital-middleware/internal/background"
 	"hospital-middleware/internal/config"
 	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/jobs"
 	"hospital-middleware/internal/services"
+	"hospital-middleware/internal/storage"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// Build information, set with -ldflags "-X main.version=..." by make build.
+var (
+	version   = "dev"
+	buildDate = "unknown"
+	gitCommit = "unknown"
+)
+
+// shutdownTimeout bounds how long in-flight requests and background jobs get to finish on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	// Set log flags
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	log.Println("Starting Hospital Middleware Service...")
+	log.Printf("Starting Hospital Middleware Service version %s (commit %s, built %s)...", version, gitCommit, buildDate)
 
 	// 1. Load Configuration
 	cfg, err := config.Load()
@@ -22,6 +41,12 @@ func main() {
 		log.Fatalf("FATAL: Could not load configuration: %v", err)
 		os.Exit(1) // Ensure exit on fatal error
 	}
+	if problems := config.Validate(cfg); len(problems) > 0 {
+		for _, problem := range problems {
+			log.Printf("FATAL: Invalid configuration: %s", problem)
+		}
+		os.Exit(1)
+	}
 	log.Println("Configuration loaded successfully.")
 
 	// 2. Initialize Database Connection
@@ -41,18 +66,94 @@ func main() {
 	// }() // GORM handles connection pooling, explicit closing might not be needed here.
 
 	// 3. Initialize Services (like Auth Service)
-	services.InitializeAuthService(cfg)
+	if err := services.InitializeAuthService(cfg); err != nil {
+		log.Fatalf("FATAL: Could not initialize auth service: %v", err)
+		os.Exit(1)
+	}
+	publisher, err := services.NewEventPublisher(cfg.EventBrokerURL)
+	if err != nil {
+		log.Fatalf("FATAL: Could not initialize event publisher: %v", err)
+		os.Exit(1)
+	}
+	services.SetEventPublisher(publisher)
 	log.Println("Services initialized.")
 
-	// 4. Setup Gin Router
-	router := api.SetupRouter()
+	// 4. Initialize Document Storage
+	blobs, err := storage.NewLocalDiskStore(cfg.DocumentStorageDir)
+	if err != nil {
+		log.Fatalf("FATAL: Could not initialize document storage: %v", err)
+		os.Exit(1)
+	}
+	log.Printf("Document storage initialized at %s.", cfg.DocumentStorageDir)
+
+	// 5. Setup Gin Router
+	repo := database.NewPostgresRepository()
+	writers := services.NewAsyncWriters(repo)
+	router := api.SetupRouter(repo, blobs, cfg, writers)
 	log.Println("HTTP router setup complete.")
 
-	// 5. Start HTTP Server
+	// 6. Start Background Cleanup
+	scheduler := background.NewScheduler(background.SystemClock{})
+	scheduler.Register(background.PurgeExpiredRevokedTokens(repo, cfg.CleanupInterval))
+	scheduler.Register(background.PurgeOldSearchHistory(repo, cfg.SearchHistoryRetentionDays, cfg.CleanupInterval))
+	scheduler.Register(background.EnqueuePatientAccessLogPurge(repo, cfg.AccessLogRetentionDays, cfg.CleanupInterval))
+	scheduler.Register(background.EnqueueRetentionPurge(repo, cfg.PatientRetentionDays, cfg.RevokedTokenRetentionDays, cfg.CleanupInterval))
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+	go func() {
+		scheduler.Run(schedulerCtx)
+		close(schedulerDone)
+	}()
+
+	// 7. Start Background Job Workers; job types register their handlers here
+	jobPool := jobs.NewPool(repo, cfg.JobWorkers, jobs.PollInterval, jobs.RetryBackoff)
+	jobPool.Register(background.PurgeAccessLogJob, background.PurgePatientAccessLog(repo))
+	jobPool.Register(background.RetentionPurgeJob, background.RetentionPurge(repo, blobs, cfg.RetentionPurgeBatchSize))
+	jobPool.Start()
+
+	// 8. Start HTTP Server
 	serverAddr := fmt.Sprintf(":%s", cfg.ServerPort)
-	log.Printf("Starting server on %s", serverAddr)
-	if err := router.Run(serverAddr); err != nil {
-		log.Fatalf("FATAL: Could not start server: %v", err)
-		os.Exit(1)
+	srv := &http.Server{Addr: serverAddr, Handler: router}
+	// Shutdown waits for open connections, so end the long-lived update streams
+	srv.RegisterOnShutdown(services.ClosePatientUpdates)
+	go func() {
+		log.Printf("Starting server on %s", serverAddr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("FATAL: Could not start server: %v", err)
+		}
+	}()
+
+	// 9. Graceful Shutdown on SIGINT/SIGTERM
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down...")
+
+	// Requests and jobs drain side by side within the same deadline. Jobs still running when it
+	// passes are cancelled and returned to the queue for the next start.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	jobsStopped := make(chan error, 1)
+	go func() {
+		jobsStopped <- jobPool.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error during HTTP server shutdown: %v", err)
+	}
+	if err := <-jobsStopped; err != nil {
+		log.Printf("Background jobs did not finish in time and were returned to the queue: %v", err)
+	}
+	stopScheduler()
+	<-schedulerDone
+	// No request is left to record accesses, so the access trail is complete once these are written
+	writers.AccessLog.Wait()
+	// Delivers the webhooks of the last requests, retries and dead letters included, within the deadline
+	if err := writers.Webhooks.Drain(shutdownCtx); err != nil {
+		log.Printf("Webhook deliveries did not finish in time and were dropped: %v", err)
+	}
+	// Sends the events still queued from the last requests
+	if err := publisher.Close(); err != nil {
+		log.Printf("Error closing event publisher: %v", err)
 	}
+	log.Println("Server stopped.")
 }
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateAdmissionHandler admits a patient of the staff's hospital to a ward.
+// A patient who is already admitted must be discharged first.
+func (h *Handler) CreateAdmissionHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.AdmissionCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for admission: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	ward := strings.TrimSpace(req.Ward)
+	if ward == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "ward cannot be blank"))
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+
+	admittedAt := time.Now()
+	if req.AdmittedAt != nil {
+		admittedAt = *req.AdmittedAt
+	}
+	admission := &models.Admission{
+		PatientID:        patient.ID,
+		HospitalID:       patient.HospitalID,
+		AdmittingStaffID: claims.UserID,
+		Ward:             ward,
+		BedNumber:        strings.TrimSpace(req.BedNumber),
+		AdmittedAt:       admittedAt,
+		Status:           models.AdmissionStatusAdmitted,
+	}
+	if err := h.repo.CreateAdmission(admission); err != nil {
+		if database.IsUniqueViolation(err) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeAlreadyAdmitted, "Patient is already admitted; discharge the current admission first"))
+			return
+		}
+		log.Printf("Error admitting patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to create admission"))
+		return
+	}
+
+	log.Printf("Patient %d admitted to ward %s by staff %s", patient.ID, admission.Ward, claims.Username)
+	c.JSON(http.StatusCreated, admission)
+}
+
+// DischargeAdmissionHandler ends a patient's current admission.
+func (h *Handler) DischargeAdmissionHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	admissionID, ok := parseIDParam(c, "admission_id")
+	if !ok {
+		return
+	}
+
+	var req models.AdmissionDischargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for discharge: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	admission, err := h.repo.GetAdmission(patientID, admissionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeAdmissionNotFound, "Admission not found"))
+			return
+		}
+		log.Printf("Error loading admission %d: %v", admissionID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading admission"))
+		return
+	}
+	if admission.Status != models.AdmissionStatusAdmitted {
+		apperror.HandleError(c, apperror.Conflict(apperror.CodeAdmissionDischarged, "Admission is already discharged"))
+		return
+	}
+
+	dischargedAt := time.Now()
+	if req.DischargedAt != nil {
+		dischargedAt = *req.DischargedAt
+	}
+	if dischargedAt.Before(admission.AdmittedAt) {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeAdmissionDischargeBefore, "discharged_at cannot be before admitted_at"))
+		return
+	}
+	admission.DischargedAt = &dischargedAt
+	admission.DischargeReason = strings.TrimSpace(req.DischargeReason)
+
+	if err := h.repo.DischargeAdmission(admission); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Discharged by a concurrent request since it was loaded
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeAdmissionDischarged, "Admission is already discharged"))
+			return
+		}
+		log.Printf("Error discharging admission %d: %v", admissionID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to discharge admission"))
+		return
+	}
+
+	log.Printf("Admission %d of patient %d discharged by staff %s", admission.ID, patientID, claims.Username)
+	c.JSON(http.StatusOK, admission)
+}
+
+// ListAdmissionsHandler returns a patient's admissions, newest first, paginated.
+func (h *Handler) ListAdmissionsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	admissions, total, err := h.repo.ListAdmissionsByPatient(patientID, claims.HospitalID, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error listing admissions for patient %d: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing admissions"))
+		return
+	}
+	if admissions == nil {
+		admissions = []models.Admission{}
+	}
+
+	setPaginationLinks(c, pagination, total)
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:     admissions,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	})
+}
+
+// WardCensusHandler returns the patients currently admitted to a ward of the staff's hospital.
+func (h *Handler) WardCensusHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	ward := strings.TrimSpace(c.Param("ward"))
+	if ward == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "ward cannot be blank"))
+		return
+	}
+
+	entries, err := h.repo.ListCurrentWardAdmissions(claims.HospitalID, ward)
+	if err != nil {
+		log.Printf("Error loading census of ward %s in hospital %d: %v", ward, claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading ward census"))
+		return
+	}
+	if entries == nil {
+		entries = []models.WardCensusEntry{}
+	}
+	for i := range entries {
+		entries[i].Patient = patientForRole(entries[i].Patient, claims)
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UpsertAllergyHandler records an allergy for a patient. If the patient already has an entry
+// for the same substance (case-insensitive) it is updated instead of duplicated.
+func (h *Handler) UpsertAllergyHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.AllergyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for allergy: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	substance := strings.TrimSpace(req.Substance)
+	if substance == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "substance cannot be blank"))
+		return
+	}
+
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	allergy := &models.Allergy{
+		PatientID: patientID,
+		Substance: substance,
+		Reaction:  req.Reaction,
+		Severity:  req.Severity,
+		NotedBy:   claims.UserID,
+		NotedAt:   time.Now(),
+	}
+	created, err := h.repo.UpsertAllergy(allergy)
+	if err != nil {
+		log.Printf("Error saving allergy %q for patient %d: %v", allergy.Substance, patientID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to save allergy"))
+		return
+	}
+
+	if created {
+		c.JSON(http.StatusCreated, allergy)
+		return
+	}
+	c.JSON(http.StatusOK, allergy)
+}
+
+// ListAllergiesHandler returns all allergies recorded for a patient.
+func (h *Handler) ListAllergiesHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	allergies, err := h.repo.ListAllergiesByPatient(patientID)
+	if err != nil {
+		log.Printf("Error listing allergies for patient %d: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing allergies"))
+		return
+	}
+	if allergies == nil {
+		allergies = []models.Allergy{}
+	}
+	c.JSON(http.StatusOK, allergies)
+}
+
+// UpdateAllergyHandler replaces the details of an existing allergy.
+func (h *Handler) UpdateAllergyHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	allergyID, ok := parseIDParam(c, "allergy_id")
+	if !ok {
+		return
+	}
+
+	var req models.AllergyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for allergy update: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	substance := strings.TrimSpace(req.Substance)
+	if substance == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "substance cannot be blank"))
+		return
+	}
+
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	allergy, err := h.repo.GetAllergyByID(patientID, allergyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeAllergyNotFound, "Allergy not found"))
+			return
+		}
+		log.Printf("Error loading allergy %d: %v", allergyID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading allergy"))
+		return
+	}
+
+	allergy.Substance = substance
+	allergy.Reaction = req.Reaction
+	allergy.Severity = req.Severity
+	allergy.NotedBy = claims.UserID
+	allergy.NotedAt = time.Now()
+
+	if err := h.repo.UpdateAllergy(allergy); err != nil {
+		if database.IsUniqueViolation(err) {
+			// Renaming onto a substance the patient already has an entry for
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeAllergyExists, "Patient already has an allergy entry for this substance"))
+			return
+		}
+		log.Printf("Error updating allergy %d: %v", allergyID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to update allergy"))
+		return
+	}
+	c.JSON(http.StatusOK, allergy)
+}
+
+// DeleteAllergyHandler removes an allergy from a patient.
+func (h *Handler) DeleteAllergyHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	allergyID, ok := parseIDParam(c, "allergy_id")
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	if err := h.repo.DeleteAllergy(patientID, allergyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeAllergyNotFound, "Allergy not found"))
+			return
+		}
+		log.Printf("Error deleting allergy %d: %v", allergyID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to delete allergy"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
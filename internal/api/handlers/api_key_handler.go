@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateAPIKeyHandler issues an API key for the admin's hospital. Admin only.
+// The key is returned once; only its hash is stored.
+func (h *Handler) CreateAPIKeyHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	created, err := services.CreateAPIKey(h.repo, claims.HospitalID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrAPIKeyExpiryInPast) {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeAPIKeyExpiryInPast, err.Error()))
+			return
+		}
+		log.Printf("Error creating API key for hospital %d: %v", claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to create API key"))
+		return
+	}
+
+	log.Printf("API key %d (%s) created for hospital %d by %s", created.ID, created.Description, claims.HospitalID, claims.Username)
+	c.JSON(http.StatusCreated, created)
+}
+
+// RevokeAPIKeyHandler deactivates an API key of the admin's hospital. Admin only.
+func (h *Handler) RevokeAPIKeyHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	keyID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RevokeAPIKey(keyID, claims.HospitalID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Keys of other hospitals are reported the same as missing ones
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeAPIKeyNotFound, "API key not found"))
+			return
+		}
+		log.Printf("Error revoking API key %d: %v", keyID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to revoke API key"))
+		return
+	}
+
+	log.Printf("API key %d revoked by %s", keyID, claims.Username)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListPatientAuditHandler returns a patient's audit trail, newest first, paginated. Admin only.
+func (h *Handler) ListPatientAuditHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	entries, total, err := h.repo.ListAuditLogsByPatient(patientID, claims.HospitalID, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error listing audit trail for patient %d: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing audit trail"))
+		return
+	}
+	if entries == nil {
+		entries = []models.AuditLog{}
+	}
+
+	setPaginationLinks(c, pagination, total)
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:     entries,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/api/middleware"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyTokenHandler checks the bearer token for gateways and other internal services and
+// returns its minimal claims, or 401. Only the signature and expiry are checked: it never
+// touches the database, so a token revoked by logout still verifies until it expires.
+func VerifyTokenHandler(c *gin.Context) {
+	// The answer depends on the caller's token, so it must not be shared through a cache
+	c.Header("Cache-Control", "no-store")
+
+	tokenString, ok := middleware.BearerToken(c.GetHeader("Authorization"))
+	if !ok {
+		apperror.HandleError(c, apperror.Unauthorized(apperror.CodeAuthRequired, "Bearer token required"))
+		return
+	}
+	claims, err := services.ValidateToken(tokenString)
+	if err != nil {
+		apperror.HandleError(c, apperror.Unauthorized(apperror.CodeInvalidToken, err.Error()))
+		return
+	}
+	// Enrollment-only tokens cannot call the API, so they are not vouched for either
+	if claims.TwoFactorEnrollment {
+		log.Printf("Token verify: enrollment-only token of %s rejected", claims.Username)
+		apperror.HandleError(c, apperror.Unauthorized(apperror.CodeTwoFactorEnrollmentRequired, "Two-factor enrollment required"))
+		return
+	}
+
+	response := models.TokenVerifyResponse{
+		UserID:     claims.UserID,
+		Username:   claims.Username,
+		HospitalID: claims.HospitalID,
+		Role:       claims.Role,
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Time
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RecordConsentHandler records a patient's decision to grant or revoke a consent, witnessed by
+// the calling staff member. Earlier decisions are kept; the newest one is in effect.
+func (h *Handler) RecordConsentHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.ConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for consent: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	consent := &models.Consent{
+		PatientID:      patient.ID,
+		HospitalID:     patient.HospitalID,
+		ConsentType:    req.ConsentType,
+		Granted:        *req.Granted,
+		StaffWitnessID: claims.UserID,
+	}
+	if consent.Granted {
+		consent.GrantedAt = &now
+	} else {
+		consent.RevokedAt = &now
+	}
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionConsentRecorded,
+		Details:    fmt.Sprintf("%s granted=%t", consent.ConsentType, consent.Granted),
+	}
+	if err := h.repo.CreateConsent(consent, audit); err != nil {
+		log.Printf("Error recording %s consent for patient %d: %v", consent.ConsentType, patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to record consent"))
+		return
+	}
+
+	log.Printf("Consent %s=%t recorded for patient %d by %s", consent.ConsentType, consent.Granted, patient.ID, claims.Username)
+	if consent.ConsentType == models.ConsentTypeDataSharing {
+		h.searchCache.Invalidate(patient.HospitalID) // The decision is now the patient's consent status
+	}
+	c.JSON(http.StatusCreated, consent)
+}
+
+// UpdatePatientConsentHandler sets a patient's overall consent status, which patient exports
+// and consented_only searches go by. The status is recorded as a data sharing Consent witnessed
+// by the admin, as if through RecordConsentHandler. Admin only. Honors If-Match like UpdatePatientHandler.
+func (h *Handler) UpdatePatientConsentHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PatientConsentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+	if !patientPreconditionMet(c, patient, claims) {
+		return
+	}
+
+	now := time.Now()
+	version := strings.TrimSpace(req.ConsentVersion)
+	consent := &models.Consent{
+		PatientID:      patient.ID,
+		HospitalID:     patient.HospitalID,
+		ConsentType:    models.ConsentTypeDataSharing,
+		Granted:        req.ConsentStatus == models.ConsentStatusGranted,
+		StaffWitnessID: claims.UserID,
+		CreatedAt:      now,
+	}
+	if consent.Granted {
+		consent.GrantedAt = &now
+	} else {
+		consent.RevokedAt = &now
+	}
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionConsentUpdated,
+		Details:    fmt.Sprintf("from=%s to=%s version=%s", patient.ConsentStatus, req.ConsentStatus, version),
+	}
+	if err := h.repo.UpdatePatientConsent(consent, version, audit); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodePatientMoved, "Patient was moved by another request; reload and try again"))
+			return
+		}
+		log.Printf("Error updating consent of patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to update consent"))
+		return
+	}
+	patient.ConsentStatus = req.ConsentStatus
+	patient.ConsentUpdatedAt = &now
+	patient.ConsentVersion = version
+
+	log.Printf("Consent of patient %d set to %s by %s", patient.ID, patient.ConsentStatus, claims.Username)
+	h.searchCache.Invalidate(patient.HospitalID)
+	services.PublishPatientUpdate(models.PatientUpdate{Operation: models.PatientUpdateUpdated, PatientID: patient.ID, HospitalID: patient.HospitalID})
+	h.notifyPatientWebhooks(models.WebhookEventPatientUpdated, patient.HospitalID, *patient)
+	services.PublishPatientEvent(models.PatientEvent{
+		Event:         models.WebhookEventPatientUpdated,
+		HospitalID:    patient.HospitalID,
+		PatientID:     patient.ID,
+		ChangedFields: []string{"consent_status", "consent_updated_at", "consent_version"},
+	})
+	c.JSON(http.StatusOK, patientForRole(*patient, claims))
+}
+
+// ListConsentsHandler returns every consent decision recorded for a patient, newest first.
+func (h *Handler) ListConsentsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	consents, err := h.repo.ListConsentsByPatient(patientID)
+	if err != nil {
+		log.Printf("Error listing consents for patient %d: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing consents"))
+		return
+	}
+	if consents == nil {
+		consents = []models.Consent{}
+	}
+	c.JSON(http.StatusOK, consents)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// icd10SearchLimit caps how many ICD-10 codes a lookup returns; clients refine q to narrow it.
+const icd10SearchLimit = 50
+
+// CreateDiagnosisHandler records an ICD-10 diagnosis for a patient of the staff's hospital.
+// Codes are checked against the ICD-10 reference table by its foreign key.
+func (h *Handler) CreateDiagnosisHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PatientDiagnosisRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for diagnosis: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(req.ICD10Code))
+	if code == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "icd10_code cannot be blank"))
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+
+	diagnosedAt := time.Now()
+	if req.DiagnosedAt != nil {
+		diagnosedAt = *req.DiagnosedAt
+	}
+	diagnosis := &models.PatientDiagnosis{
+		PatientID:     patient.ID,
+		HospitalID:    patient.HospitalID,
+		StaffID:       claims.UserID,
+		ICD10Code:     code,
+		DiagnosisType: req.DiagnosisType,
+		DiagnosedAt:   diagnosedAt,
+	}
+	if err := h.repo.CreatePatientDiagnosis(diagnosis); err != nil {
+		if database.IsForeignKeyViolation(err) {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeUnknownICD10Code, "Unknown ICD-10 code: "+code))
+			return
+		}
+		log.Printf("Error recording diagnosis %s for patient %d: %v", code, patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to record diagnosis"))
+		return
+	}
+
+	log.Printf("Diagnosis %s recorded for patient %d by staff %s", code, patient.ID, claims.Username)
+	c.JSON(http.StatusCreated, diagnosis)
+}
+
+// ListDiagnosesHandler returns a patient's diagnoses, newest first, paginated.
+func (h *Handler) ListDiagnosesHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	diagnoses, total, err := h.repo.ListPatientDiagnoses(patientID, claims.HospitalID, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error listing diagnoses for patient %d: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing diagnoses"))
+		return
+	}
+	if diagnoses == nil {
+		diagnoses = []models.PatientDiagnosis{}
+	}
+
+	setPaginationLinks(c, pagination, total)
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:     diagnoses,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	})
+}
+
+// SearchICD10Handler looks up ICD-10 codes by code prefix or by words of the description.
+func (h *Handler) SearchICD10Handler(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "q is required"))
+		return
+	}
+
+	codes, err := h.repo.SearchICD10Codes(q, icd10SearchLimit)
+	if err != nil {
+		log.Printf("Error searching ICD-10 codes for %q: %v", q, err)
+		apperror.HandleError(c, apperror.Internal("Database error searching ICD-10 codes"))
+		return
+	}
+	if codes == nil {
+		codes = []models.ICD10Code{}
+	}
+	c.JSON(http.StatusOK, codes)
+}
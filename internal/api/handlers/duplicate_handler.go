@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDuplicatePatientsHandler returns a hospital's likely-duplicate patients, grouped into
+// clusters that share a national ID or an English name and date of birth, paginated by cluster.
+// The hospital is the admin's own unless hospital_id names another, which only super admins may.
+// Admin only. It only reports; nothing is merged.
+func (h *Handler) ListDuplicatePatientsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	hospitalID := claims.HospitalID
+	if raw := c.Query("hospital_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || id == 0 {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "hospital_id must be a positive integer"))
+			return
+		}
+		hospitalID = uint(id)
+	}
+	if !claims.CanAdministerHospital(hospitalID) {
+		log.Printf("Admin %s (hospital %d) denied duplicate report of hospital %d", claims.Username, claims.HospitalID, hospitalID)
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeHospitalAccessDenied, "Admins can only manage their own hospital"))
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	clusters, total, err := h.repo.ListDuplicateClusters(hospitalID, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error listing duplicate patients of hospital %d: %v", hospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing duplicate patients"))
+		return
+	}
+	if clusters == nil {
+		clusters = []models.DuplicateCluster{}
+	}
+
+	setPaginationLinks(c, pagination, total)
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:     clusters,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	})
+}
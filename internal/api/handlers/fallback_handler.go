@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/api/middleware"
+	"hospital-middleware/pkg/apperror"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeErrorResponse is the body of 404 responses for requests that match no route.
+type routeErrorResponse struct {
+	apperror.ErrorResponse
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	RequestID string `json:"request_id"`
+}
+
+// methodNotAllowedResponse is the body of 405 responses, listing the methods the path supports.
+type methodNotAllowedResponse struct {
+	routeErrorResponse
+	AllowedMethods []string `json:"allowed_methods"`
+}
+
+// NotFoundHandler answers requests that match no route, echoing what was asked for
+// so a 404 can be traced in the logs by its request ID.
+func NotFoundHandler(c *gin.Context) {
+	c.JSON(http.StatusNotFound, routeErrorResponse{
+		ErrorResponse: apperror.Response(c, apperror.CodeRouteNotFound, "resource not found"),
+		Method:        c.Request.Method,
+		Path:          c.Request.URL.Path,
+		RequestID:     middleware.RequestIDFromContext(c),
+	})
+}
+
+// MethodNotAllowedHandler answers requests for a known path with an unsupported method.
+// Gin has already set the Allow header; the same methods are listed in the body.
+func MethodNotAllowedHandler(c *gin.Context) {
+	allowed := []string{}
+	if header := c.Writer.Header().Get("Allow"); header != "" {
+		allowed = strings.Split(header, ", ")
+	}
+	c.JSON(http.StatusMethodNotAllowed, methodNotAllowedResponse{
+		routeErrorResponse: routeErrorResponse{
+			ErrorResponse: apperror.Response(c, apperror.CodeMethodNotAllowed, "method not allowed"),
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.Path,
+			RequestID:     middleware.RequestIDFromContext(c),
+		},
+		AllowedMethods: allowed,
+	})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/api/middleware"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/internal/storage"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Handler groups the HTTP handlers and the dependencies they share.
+type Handler struct {
+	repo     database.PatientRepository
+	blobs    storage.BlobStore
+	cfg      *config.Config
+	configs  *services.HospitalConfigCache
+	webhooks *services.WebhookDispatcher
+	// Records who read which patient, in the background
+	accessLog *services.AccessLogger
+	// Results of searches within the staff's own hospital; nil unless SEARCH_CACHE is set
+	searchCache *services.SearchCache
+	// Renders patient summary PDFs; nil unless SUMMARY_FONT_PATH is set
+	summaries *services.SummaryRenderer
+}
+
+// NewHandler creates a Handler backed by the given repository and blob store. Work that outlives
+// a request goes to writers, which the caller waits for on shutdown.
+func NewHandler(repo database.PatientRepository, blobs storage.BlobStore, cfg *config.Config, writers *services.AsyncWriters) *Handler {
+	return &Handler{
+		repo:        repo,
+		blobs:       blobs,
+		cfg:         cfg,
+		configs:     services.NewHospitalConfigCache(repo, services.HospitalConfigTTL),
+		webhooks:    writers.Webhooks,
+		accessLog:   writers.AccessLog,
+		searchCache: newSearchCache(cfg),
+		summaries:   newSummaryRenderer(cfg),
+	}
+}
+
+// newSearchCache returns the search cache SEARCH_CACHE selects, or nil when caching is off.
+func newSearchCache(cfg *config.Config) *services.SearchCache {
+	switch cfg.SearchCache {
+	case config.SearchCacheMemory:
+		return services.NewSearchCache(services.NewMemoryCache(services.MemoryCacheMaxEntries), cfg.SearchCacheTTL)
+	case config.SearchCacheRedis:
+		cache, err := services.NewRedisCache(cfg.RedisURL)
+		if err != nil {
+			log.Printf("Search cache disabled: %v", err)
+			return nil
+		}
+		return services.NewSearchCache(cache, cfg.SearchCacheTTL)
+	}
+	return nil
+}
+
+// claimsFromContext returns the JWT claims stored by the AuthRequired middleware.
+// On failure it writes the error response and returns false.
+func claimsFromContext(c *gin.Context) (*services.Claims, bool) {
+	claimsInterface, exists := c.Get(middleware.ContextKeyClaims)
+	if !exists {
+		log.Println("Error: Claims not found in context. Middleware might be missing.")
+		apperror.HandleError(c, apperror.Unauthorized(apperror.CodeAuthRequired, "Authentication required (claims not found)"))
+		return nil, false
+	}
+
+	claims, ok := claimsInterface.(*services.Claims)
+	if !ok {
+		log.Println("Error: Could not assert claims type.")
+		apperror.HandleError(c, apperror.Internal("Internal server error processing authentication"))
+		return nil, false
+	}
+	return claims, true
+}
+
+// staffAccountClaims is claimsFromContext for endpoints tied to a staff account, such as saved
+// searches, which API key clients do not have. On failure it writes the error response.
+func staffAccountClaims(c *gin.Context) (*services.Claims, bool) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return nil, false
+	}
+	if claims.APIKeyID != 0 {
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeStaffAccountRequired, "Only available to staff accounts"))
+		return nil, false
+	}
+	return claims, true
+}
+
+// databaseRetryAfter is how long clients are asked to wait when the database is unavailable.
+const databaseRetryAfter = 5 * time.Second
+
+// databaseError builds the error for a failed database call. Transient failures, such as a lost
+// connection or a timeout, are answered with 503 and a Retry-After so the client knows to retry;
+// anything else is a 500 with message.
+func databaseError(err error, message string) error {
+	if database.IsTransient(err) {
+		return apperror.Unavailable(apperror.CodeDatabaseUnavailable, "Database temporarily unavailable, please retry", databaseRetryAfter)
+	}
+	return apperror.Internal(message)
+}
+
+// invalidRequestError builds the 400 error for a request that failed binding. Validation failures
+// list each offending field by its JSON name under "details"; malformed JSON only gets "error".
+func invalidRequestError(req interface{}, err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error())
+	}
+	reqType := reflect.TypeOf(req)
+	for reqType.Kind() == reflect.Ptr {
+		reqType = reqType.Elem()
+	}
+	fields := make(map[string]string, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		name := fieldErr.Field()
+		if field, ok := reqType.FieldByName(fieldErr.StructField()); ok {
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+				name = tag
+			}
+		}
+		fields[name] = fieldErrorMessage(fieldErr)
+	}
+	return &apperror.ValidationError{Code: apperror.CodeInvalidBody, Message: "Invalid request body", Fields: fields}
+}
+
+// fieldErrorMessage describes a failed validation rule in words.
+func fieldErrorMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fieldErr.Param()), ", ")
+	case "max":
+		return "must be at most " + fieldErr.Param() + " characters"
+	default:
+		return "failed the " + fieldErr.Tag() + " check"
+	}
+}
+
+// parseIDParam parses a positive numeric path parameter.
+// On failure it writes a 400 response and returns false.
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidPathParam, "Invalid "+name+" parameter"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// bindPagination reads page and page_size from the query string, applying the configured
+// default page size and clamping larger requests to the configured maximum.
+// On failure it writes a 400 response and returns false.
+func (h *Handler) bindPagination(c *gin.Context) (models.PaginationQuery, bool) {
+	var p models.PaginationQuery
+	if err := c.ShouldBindQuery(&p); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "Invalid pagination parameters: "+err.Error()))
+		return p, false
+	}
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PageSize < 1 {
+		p.PageSize = h.cfg.DefaultPageSize
+	}
+	if p.PageSize > h.cfg.MaxPageSize {
+		p.PageSize = h.cfg.MaxPageSize
+	}
+	return p, true
+}
+
+// setPaginationLinks sets an RFC 8288 Link header with the first, prev, next and last pages,
+// for clients that page by following links instead of reading the response envelope.
+// The links keep the request's path and query and only change page and page_size.
+func setPaginationLinks(c *gin.Context, p models.PaginationQuery, total int64) {
+	lastPage := 1
+	if total > 0 {
+		lastPage = int((total + int64(p.PageSize) - 1) / int64(p.PageSize))
+	}
+
+	pageURL := func(page int) string {
+		u := *c.Request.URL
+		query := u.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("page_size", strconv.Itoa(p.PageSize))
+		u.RawQuery = query.Encode()
+		return u.RequestURI()
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(1))}
+	if p.Page > 1 {
+		// Past the end, prev leads back to the last page that has results
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(min(p.Page-1, lastPage))))
+	}
+	if p.Page < lastPage {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(p.Page+1)))
+	}
+	links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(lastPage)))
+	c.Header("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadyHandler reports whether the service can do useful work. Unlike /health, which only
+// shows the process is up, it fails with 503 when tokens cannot be signed and validated.
+func ReadyHandler(c *gin.Context) {
+	if err := services.CheckTokenSigning(); err != nil {
+		log.Printf("Readiness check failed: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "NOT READY", "checks": gin.H{"jwt_signing": "failed"}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "READY", "checks": gin.H{"jwt_signing": "ok"}})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHospitalConfigHandler returns a hospital's runtime settings. Admin only.
+func (h *Handler) GetHospitalConfigHandler(c *gin.Context) {
+	hospitalID, ok := h.adminHospitalParam(c)
+	if !ok {
+		return
+	}
+
+	// Read straight from the database so admins always see the stored values
+	cfg, err := h.repo.GetHospitalConfig(hospitalID)
+	if err != nil {
+		writeHospitalConfigError(c, hospitalID, err)
+		return
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// UpdateHospitalConfigHandler replaces a hospital's runtime settings. Admin only.
+func (h *Handler) UpdateHospitalConfigHandler(c *gin.Context) {
+	hospitalID, ok := h.adminHospitalParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.HospitalConfigUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	for _, key := range req.ExtraFieldKeys {
+		if !models.ValidExtraFieldKey(key) {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidExtraFieldKey, fmt.Sprintf("extra field key %q must be up to 40 lowercase letters, digits and underscores, starting with a letter", key)))
+			return
+		}
+	}
+
+	// Ensures the hospital exists before writing its config
+	current, err := h.repo.GetHospitalConfig(hospitalID)
+	if err != nil {
+		writeHospitalConfigError(c, hospitalID, err)
+		return
+	}
+	extraFieldKeys := current.ExtraFieldKeys
+	if req.ExtraFieldKeys != nil {
+		extraFieldKeys = models.StringList(req.ExtraFieldKeys)
+	}
+
+	cfg := &models.HospitalConfig{
+		HospitalID:        hospitalID,
+		MaxSearchResults:  *req.MaxSearchResults,
+		HNPrefix:          *req.HNPrefix,
+		HNPaddingLength:   *req.HNPaddingLength,
+		SearchMinCriteria: *req.SearchMinCriteria,
+		TwoFactorRequired: *req.TwoFactorRequired,
+		ExtraFieldKeys:    extraFieldKeys,
+	}
+	if err := h.repo.SaveHospitalConfig(cfg); err != nil {
+		log.Printf("Error saving config for hospital %d: %v", hospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to save hospital config"))
+		return
+	}
+	h.configs.Invalidate(hospitalID) // New settings apply to the next request, not after the TTL
+
+	log.Printf("Hospital %d config updated: %+v", hospitalID, *cfg)
+	c.JSON(http.StatusOK, cfg)
+}
+
+// adminHospitalParam parses the :id hospital parameter and checks the admin belongs to that hospital.
+// Super admins may manage any hospital.
+func (h *Handler) adminHospitalParam(c *gin.Context) (uint, bool) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return 0, false
+	}
+	hospitalID, ok := parseIDParam(c, "id")
+	if !ok {
+		return 0, false
+	}
+	if !claims.CanAdministerHospital(hospitalID) {
+		log.Printf("Admin %s (hospital %d) denied access to hospital %d config", claims.Username, claims.HospitalID, hospitalID)
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeHospitalAccessDenied, "Admins can only manage their own hospital"))
+		return 0, false
+	}
+	return hospitalID, true
+}
+
+func writeHospitalConfigError(c *gin.Context, hospitalID uint, err error) {
+	if errors.Is(err, database.ErrHospitalNotFound) {
+		apperror.HandleError(c, apperror.NotFound(apperror.CodeHospitalNotFound, "Hospital not found"))
+		return
+	}
+	log.Printf("Error loading config for hospital %d: %v", hospitalID, err)
+	apperror.HandleError(c, apperror.Internal("Database error loading hospital config"))
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetHospitalFeatureHandler switches a rollout feature on or off for a hospital. Admin only.
+// Other servers pick the change up within a minute.
+func (h *Handler) SetHospitalFeatureHandler(c *gin.Context) {
+	hospitalID, ok := h.adminHospitalParam(c)
+	if !ok {
+		return
+	}
+	feature := c.Param("feature")
+	if !models.KnownFeatures[feature] {
+		apperror.HandleError(c, apperror.NotFound(apperror.CodeUnknownFeature, "Unknown feature: "+feature))
+		return
+	}
+
+	var req models.HospitalFeatureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, invalidRequestError(&req, err))
+		return
+	}
+
+	features, err := h.repo.SetHospitalFeature(hospitalID, feature, *req.Enabled)
+	if err != nil {
+		if errors.Is(err, database.ErrHospitalNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeHospitalNotFound, "Hospital not found"))
+			return
+		}
+		log.Printf("Error setting feature %s of hospital %d: %v", feature, hospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Database error updating hospital features"))
+		return
+	}
+	claims, _ := claimsFromContext(c)
+	log.Printf("Feature %s of hospital %d set to %t by %s", feature, hospitalID, *req.Enabled, claims.Username)
+	c.JSON(http.StatusOK, models.HospitalFeaturesResponse{HospitalID: hospitalID, Features: features})
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListHospitalsHandler returns the public list of hospitals. Does not require authentication.
+func (h *Handler) ListHospitalsHandler(c *gin.Context) {
+	hospitals, err := h.repo.ListHospitals()
+	if err != nil {
+		log.Printf("Error listing hospitals: %v", err)
+		apperror.HandleError(c, apperror.Internal("Database error listing hospitals"))
+		return
+	}
+
+	// Map to the public summary so internal columns are never exposed
+	summaries := make([]models.HospitalSummary, 0, len(hospitals))
+	for _, hospital := range hospitals {
+		summaries = append(summaries, models.HospitalSummary{ID: hospital.ID, Name: hospital.Name, Code: hospital.Code})
+	}
+	c.JSON(http.StatusOK, summaries)
+}
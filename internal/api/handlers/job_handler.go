@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListJobsHandler returns background jobs of every hospital, newest first, paginated and
+// optionally filtered with ?status=. Jobs are not scoped to a hospital, so only super admins may
+// list them.
+func (h *Handler) ListJobsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	if !claims.IsSuperAdmin() {
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeSuperAdminRequired, "Super admin privileges required"))
+		return
+	}
+	status := c.Query("status")
+	if status != "" && !models.ValidJobStatus(status) {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "status must be one of queued, running, succeeded, failed"))
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	jobs, total, err := h.repo.ListJobs(status, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error listing background jobs: %v", err)
+		apperror.HandleError(c, databaseError(err, "Database error listing jobs"))
+		return
+	}
+	if jobs == nil {
+		jobs = []models.Job{}
+	}
+
+	setPaginationLinks(c, pagination, total)
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:     jobs,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	})
+}
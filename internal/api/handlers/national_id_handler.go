@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"hospital-middleware/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateNationalIDsHandler checks a list of Thai national IDs against their check digits, so a
+// registration kiosk can catch typos before creating records. Nothing is looked up or stored.
+// Lists longer than NATIONAL_ID_VALIDATE_MAX are rejected.
+func (h *Handler) ValidateNationalIDsHandler(c *gin.Context) {
+	var req models.NationalIDValidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, invalidRequestError(&req, err))
+		return
+	}
+	if len(req.IDs) > h.cfg.NationalIDValidateMax {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody,
+			fmt.Sprintf("ids may list at most %d national IDs", h.cfg.NationalIDValidateMax)))
+		return
+	}
+
+	results := make([]models.NationalIDValidation, len(req.IDs))
+	for i, id := range req.IDs {
+		reason := utils.ValidateThaiNationalID(id)
+		results[i] = models.NationalIDValidation{ID: id, Valid: reason == "", Reason: reason}
+	}
+	c.JSON(http.StatusOK, models.NationalIDValidationResponse{Results: results})
+}
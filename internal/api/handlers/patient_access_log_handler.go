@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordPatientAccess adds an access log entry for each patient the request read, naming the
+// endpoint by its route, e.g. "GET /api/v1/patient/:id". The entries are written in the background.
+func (h *Handler) recordPatientAccess(c *gin.Context, claims *services.Claims, patients ...models.Patient) {
+	endpoint := c.Request.Method + " " + c.FullPath()
+	now := time.Now()
+	var apiKeyID *uint
+	if claims.APIKeyID != 0 {
+		apiKeyID = &claims.APIKeyID
+	}
+	entries := make([]models.PatientAccessLog, len(patients))
+	for i, patient := range patients {
+		entries[i] = models.PatientAccessLog{
+			StaffID:    claims.UserID,
+			APIKeyID:   apiKeyID,
+			PatientID:  patient.ID,
+			HospitalID: patient.HospitalID,
+			Endpoint:   endpoint,
+			AccessedAt: now,
+		}
+	}
+	h.accessLog.Record(entries)
+}
+
+// recordSearchAccess logs the patients a search returned, unless ACCESS_LOG_SEARCH_RESULTS is off.
+func (h *Handler) recordSearchAccess(c *gin.Context, claims *services.Claims, patients []models.Patient) {
+	if h.cfg.AccessLogSearchResults {
+		h.recordPatientAccess(c, claims, patients...)
+	}
+}
+
+// ListPatientAccessLogHandler returns who read a patient of the admin's hospital and when,
+// newest first, paginated.
+func (h *Handler) ListPatientAccessLogHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	entries, total, err := h.repo.ListPatientAccessLog(patientID, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error listing access log of patient %d: %v", patientID, err)
+		apperror.HandleError(c, databaseError(err, "Database error listing access log"))
+		return
+	}
+	writeAccessLogPage(c, pagination, entries, total)
+}
+
+// ListStaffAccessLogHandler returns the patients a staff member of the admin's hospital read and
+// when, newest first, paginated.
+func (h *Handler) ListStaffAccessLogHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	staffID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	entries, total, err := h.repo.ListStaffAccessLog(staffID, claims.HospitalID, offset, pagination.PageSize)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeStaffNotFound, "Staff member not found"))
+			return
+		}
+		log.Printf("Error listing access log of staff %d: %v", staffID, err)
+		apperror.HandleError(c, databaseError(err, "Database error listing access log"))
+		return
+	}
+	writeAccessLogPage(c, pagination, entries, total)
+}
+
+func writeAccessLogPage(c *gin.Context, pagination models.PaginationQuery, entries []models.PatientAccessLog, total int64) {
+	if entries == nil {
+		entries = []models.PatientAccessLog{}
+	}
+	setPaginationLinks(c, pagination, total)
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:     entries,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	})
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchHNSearchHandler looks up patients of the staff's hospital by a list of up to MaxBatchHNs
+// HNs, for lab systems matching many results at once. The response maps every requested HN to its
+// patient, or to null when the hospital has no patient with that HN.
+func (h *Handler) BatchHNSearchHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	var req models.PatientHNBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, invalidRequestError(&req, err))
+		return
+	}
+
+	patients, err := h.repo.GetPatientsByHNList(req.HNs, claims.HospitalID)
+	if err != nil {
+		log.Printf("Error looking up %d HNs in hospital %d: %v", len(req.HNs), claims.HospitalID, err)
+		apperror.HandleError(c, databaseError(err, "Database error looking up patients"))
+		return
+	}
+
+	results := make(map[string]*models.Patient, len(req.HNs))
+	for _, hn := range req.HNs {
+		results[hn] = nil
+	}
+	for _, patient := range patients {
+		shown := patientForRole(patient, claims)
+		results[patient.PatientHN] = &shown
+	}
+	h.recordPatientAccess(c, claims, patients...)
+	c.JSON(http.StatusOK, results)
+}
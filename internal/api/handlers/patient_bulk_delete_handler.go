@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteHospitalPatientsHandler soft-deletes every patient of one hospital, for wiping test and
+// integration environments, so it is refused when APP_ENV is production. Admin only. The hospital
+// defaults to the admin's own; only super admins may name another with hospital_id. confirm=true is
+// required so a stray request cannot wipe data.
+func (h *Handler) DeleteHospitalPatientsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	if h.cfg.AppEnv == config.AppEnvProduction {
+		log.Printf("Admin %s (hospital %d) denied deleting every patient: disabled in production", claims.Username, claims.HospitalID)
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeBulkDeleteDisabled, "Deleting all of a hospital's patients is disabled in production"))
+		return
+	}
+	if c.Query("confirm") != "true" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeBulkDeleteNotConfirmed, "Deleting all of a hospital's patients requires confirm=true"))
+		return
+	}
+
+	hospitalID := claims.HospitalID
+	if raw := c.Query("hospital_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || id == 0 {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "Invalid hospital_id parameter"))
+			return
+		}
+		hospitalID = uint(id)
+	}
+	if !claims.CanAdministerHospital(hospitalID) {
+		log.Printf("Admin %s (hospital %d) denied deleting the patients of hospital %d", claims.Username, claims.HospitalID, hospitalID)
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeHospitalAccessDenied, "Admins can only manage their own hospital"))
+		return
+	}
+
+	deleted, err := h.repo.SoftDeletePatientsByHospital(hospitalID)
+	if err != nil {
+		log.Printf("Error deleting patients of hospital %d: %v", hospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Database error deleting patients"))
+		return
+	}
+
+	log.Printf("Admin %s soft-deleted %d patient(s) of hospital %d", claims.Username, deleted, hospitalID)
+	if deleted > 0 {
+		h.searchCache.Invalidate(hospitalID)
+		services.PublishPatientUpdate(models.PatientUpdate{Operation: models.PatientUpdateDeleted, HospitalID: hospitalID})
+		h.webhooks.Dispatch(models.WebhookPayload{
+			Event:      models.WebhookEventPatientDeleted,
+			Timestamp:  time.Now().UTC(),
+			HospitalID: hospitalID,
+			Deleted:    deleted,
+		})
+		services.PublishPatientEvent(models.PatientEvent{Event: models.WebhookEventPatientDeleted, HospitalID: hospitalID, Deleted: deleted})
+	}
+	c.JSON(http.StatusOK, models.PatientBulkDeleteResponse{HospitalID: hospitalID, Deleted: deleted})
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead is the allowance on top of the file size for multipart boundaries and headers.
+const multipartOverhead = 1 << 20
+
+// allowedDocumentTypes are the sniffed content types accepted for upload.
+var allowedDocumentTypes = map[string]bool{
+	models.DocumentContentTypePDF:  true,
+	models.DocumentContentTypeJPEG: true,
+	models.DocumentContentTypePNG:  true,
+}
+
+// UploadPatientDocumentHandler stores a multipart "file" upload for a patient.
+// The content type is sniffed from the bytes rather than trusted from the client.
+func (h *Handler) UploadPatientDocumentHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+
+	maxBytes := h.cfg.DocumentMaxBytes
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apperror.HandleError(c, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeFileTooLarge, fmt.Sprintf("Document exceeds %d bytes", maxBytes)))
+			return
+		}
+		apperror.HandleError(c, apperror.Validation(apperror.CodeFileRequired, "A multipart \"file\" field is required"))
+		return
+	}
+	if fileHeader.Size > maxBytes {
+		apperror.HandleError(c, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeFileTooLarge, fmt.Sprintf("Document exceeds %d bytes", maxBytes)))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("Error opening uploaded document for patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeFileUnreadable, "Could not read uploaded file"))
+		return
+	}
+	defer file.Close()
+
+	// DetectContentType looks at no more than the first 512 bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		log.Printf("Error reading uploaded document for patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeFileUnreadable, "Could not read uploaded file"))
+		return
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !allowedDocumentTypes[contentType] {
+		apperror.HandleError(c, apperror.New(http.StatusUnsupportedMediaType, apperror.CodeUnsupportedDocumentType, "Only PDF, JPEG and PNG documents are accepted"))
+		return
+	}
+
+	storageKey, err := newDocumentStorageKey(patient.ID)
+	if err != nil {
+		log.Printf("Error generating storage key for patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to store document"))
+		return
+	}
+	hash := sha256.New()
+	size, err := h.blobs.Put(storageKey, io.TeeReader(io.MultiReader(bytes.NewReader(head), file), hash))
+	if err != nil {
+		log.Printf("Error writing document blob %s: %v", storageKey, err)
+		apperror.HandleError(c, apperror.Internal("Failed to store document"))
+		return
+	}
+
+	doc := &models.PatientDocument{
+		PatientID:   patient.ID,
+		HospitalID:  patient.HospitalID,
+		Filename:    filepath.Base(fileHeader.Filename),
+		ContentType: contentType,
+		SizeBytes:   size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  storageKey,
+		UploadedBy:  claims.UserID,
+	}
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionDocumentUploaded,
+		Details:    "filename=" + doc.Filename,
+	}
+	if err := h.repo.CreatePatientDocument(doc, audit); err != nil {
+		log.Printf("Error saving document metadata for patient %d: %v", patient.ID, err)
+		if delErr := h.blobs.Delete(storageKey); delErr != nil {
+			log.Printf("Error removing orphaned document blob %s: %v", storageKey, delErr)
+		}
+		apperror.HandleError(c, apperror.Internal("Failed to store document"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, doc)
+}
+
+// ListPatientDocumentsHandler returns a patient's document metadata, newest first, paginated.
+func (h *Handler) ListPatientDocumentsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	docs, total, err := h.repo.ListPatientDocuments(patientID, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error listing documents for patient %d: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing documents"))
+		return
+	}
+	if docs == nil {
+		docs = []models.PatientDocument{}
+	}
+
+	setPaginationLinks(c, pagination, total)
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:     docs,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	})
+}
+
+// DownloadPatientDocumentHandler streams a document's bytes as an attachment.
+func (h *Handler) DownloadPatientDocumentHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	documentID, ok := parseIDParam(c, "document_id")
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+	doc, ok := h.loadPatientDocument(c, patientID, documentID)
+	if !ok {
+		return
+	}
+
+	content, err := h.blobs.Get(doc.StorageKey)
+	if err != nil {
+		log.Printf("Error opening blob %s for document %d: %v", doc.StorageKey, doc.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to read document"))
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, doc.SizeBytes, doc.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": doc.Filename}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeletePatientDocumentHandler removes a document. The route is restricted to admins.
+func (h *Handler) DeletePatientDocumentHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	documentID, ok := parseIDParam(c, "document_id")
+	if !ok {
+		return
+	}
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+	doc, ok := h.loadPatientDocument(c, patientID, documentID)
+	if !ok {
+		return
+	}
+
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionDocumentDeleted,
+		Details:    "filename=" + doc.Filename,
+	}
+	if err := h.repo.DeletePatientDocument(doc, audit); err != nil {
+		log.Printf("Error deleting document %d: %v", doc.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to delete document"))
+		return
+	}
+	// Metadata is gone, so a blob left behind here is unreachable rather than dangling
+	if err := h.blobs.Delete(doc.StorageKey); err != nil {
+		log.Printf("Error removing blob %s for deleted document %d: %v", doc.StorageKey, doc.ID, err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// loadPatientDocument fetches a document of the patient, writing 404/500 responses on failure.
+func (h *Handler) loadPatientDocument(c *gin.Context, patientID, documentID uint) (*models.PatientDocument, bool) {
+	doc, err := h.repo.GetPatientDocument(patientID, documentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeDocumentNotFound, "Document not found"))
+			return nil, false
+		}
+		log.Printf("Error loading document %d: %v", documentID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading document"))
+		return nil, false
+	}
+	return doc, true
+}
+
+// newDocumentStorageKey returns a random blob key grouped under the patient.
+func newDocumentStorageKey(patientID uint) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("patients/%d/%s", patientID, hex.EncodeToString(buf)), nil
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Patient export formats, chosen with the format query parameter.
+const (
+	patientExportFormatJSON   = "json"
+	patientExportFormatNDJSON = "ndjson"
+)
+
+// patientExportFlushEvery is how many NDJSON rows are buffered before they are flushed to the client.
+const patientExportFlushEvery = 500
+
+// ExportPatientsHandler returns every patient of the admin's hospital matching the search
+// filters, including created_from and created_to. Admin only. Unlike search, no criteria are
+// required and the hospital's result cap does not apply.
+// With format=ndjson the patients are streamed one JSON object per line instead of as one array,
+// so exports of any size use constant memory.
+func (h *Handler) ExportPatientsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var searchQuery models.PatientSearchQuery
+	if err := bindSearchQuery(c, &searchQuery); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "Invalid query parameters: "+err.Error()))
+		return
+	}
+	if !validateSearchQuery(c, &searchQuery, h.dateLocation()) {
+		return
+	}
+	searchQuery.ExcludeConsentDenied = h.cfg.EnforceConsentOnExport
+
+	switch format := c.DefaultQuery("format", patientExportFormatJSON); format {
+	case patientExportFormatJSON:
+	case patientExportFormatNDJSON:
+		h.streamPatientExport(c, &searchQuery, claims.HospitalID, claims.Username)
+		return
+	default:
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "format must be one of: json, ndjson"))
+		return
+	}
+
+	patients, err := h.repo.SearchPatients(&searchQuery, claims.HospitalID, 0)
+	if err != nil {
+		log.Printf("Error exporting patients of hospital %d: %v", claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Database error during patient export"))
+		return
+	}
+	if patients == nil {
+		patients = []models.Patient{}
+	}
+
+	log.Printf("Patient export by %s: %d patient(s) from hospital %d", claims.Username, len(patients), claims.HospitalID)
+	c.JSON(http.StatusOK, patients)
+}
+
+// streamPatientExport writes the matching patients as newline-delimited JSON, flushing every
+// patientExportFlushEvery rows. It stops reading from the database as soon as the client goes away.
+func (h *Handler) streamPatientExport(c *gin.Context, searchQuery *models.PatientSearchQuery, hospitalID uint, username string) {
+	ctx := c.Request.Context()
+	buf := bufio.NewWriter(c.Writer)
+	encoder := json.NewEncoder(buf)
+	flush := func() error {
+		if err := buf.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	// As with the staff export, nothing is written before the first row so an up-front
+	// database error still gets a JSON error response.
+	started := false
+	start := func() {
+		started = true
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+	}
+
+	count := 0
+	err := h.repo.StreamPatients(ctx, searchQuery, hospitalID, func(patient *models.Patient) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if !started {
+			start()
+		}
+		if err := encoder.Encode(patient); err != nil {
+			return err
+		}
+		count++
+		if count%patientExportFlushEvery == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			log.Printf("Patient export by %s stopped after %d row(s): client disconnected", username, count)
+			c.Abort()
+			return
+		}
+		log.Printf("Error streaming patients of hospital %d after %d row(s): %v", hospitalID, count, err)
+		if !started {
+			apperror.HandleError(c, apperror.Internal("Database error during patient export"))
+			return
+		}
+		_ = flush()
+		c.Abort() // Headers are already sent; the client sees a short stream
+		return
+	}
+
+	if !started { // No matches: an empty, but successful, stream
+		start()
+	}
+	if err := flush(); err != nil {
+		log.Printf("Error writing patient export for hospital %d: %v", hospitalID, err)
+		return
+	}
+	log.Printf("Patient export (ndjson) by %s: %d patient(s) from hospital %d", username, count, hospitalID)
+}
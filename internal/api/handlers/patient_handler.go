@@ -1,30 +1,31 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"hospital-middleware/internal/api/middleware"
+	"hospital-middleware/internal/config"
 	"hospital-middleware/internal/database"
 	"hospital-middleware/internal/models"
 	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"hospital-middleware/pkg/utils"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // SearchPatientHandler handles searching for patients. Requires authentication.
-func SearchPatientHandler(c *gin.Context) {
+func (h *Handler) SearchPatientHandler(c *gin.Context) {
 	// 1. Get Claims from context (set by AuthRequired middleware)
-	claimsInterface, exists := c.Get(middleware.ContextKeyClaims)
-	if !exists {
-		log.Println("Error in SearchPatientHandler: Claims not found in context. Middleware might be missing.")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required (claims not found)"})
-		return
-	}
-
-	claims, ok := claimsInterface.(*services.Claims)
+	claims, ok := claimsFromContext(c)
 	if !ok {
-		log.Println("Error in SearchPatientHandler: Could not assert claims type.")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error processing authentication"})
 		return
 	}
 
@@ -34,31 +35,524 @@ func SearchPatientHandler(c *gin.Context) {
 
 	// 2. Bind Query Parameters
 	var searchQuery models.PatientSearchQuery
-	if err := c.ShouldBindQuery(&searchQuery); err != nil {
+	if err := bindSearchQuery(c, &searchQuery); err != nil {
 		log.Printf("Error binding query parameters for patient search: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters: " + err.Error()})
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "Invalid query parameters: "+err.Error()))
 		return
 	}
 
 	// Log the received search query
 	log.Printf("Search query parameters: %+v", searchQuery)
 
-	// 3. Perform Search using Database function
-	// Pass the search criteria and the staff's hospital ID for filtering
-	patients, err := database.SearchPatients(&searchQuery, staffHospitalID)
+	h.searchPatients(c, claims, &searchQuery, c.Request.URL.RawQuery)
+}
+
+// bindSearchQuery binds the search parameters of the query string, including extra.<key> criteria.
+func bindSearchQuery(c *gin.Context, searchQuery *models.PatientSearchQuery) error {
+	if err := c.ShouldBindQuery(searchQuery); err != nil {
+		return err
+	}
+	return searchQuery.BindExtra(c.Request.URL.Query())
+}
+
+// searchPatients validates a bound search query, runs it against the staff's hospital (with
+// cross_hospital, its consortiums; for super admins passing hospital_id, several hospitals) and
+// writes the results, trimmed to the patient fields listed in the fields parameter when it is given.
+// rawQuery is what the search history records.
+func (h *Handler) searchPatients(c *gin.Context, claims *services.Claims, searchQuery *models.PatientSearchQuery, rawQuery string) {
+	staffHospitalID := claims.HospitalID
+	if !validateSearchQuery(c, searchQuery, h.dateLocation()) {
+		return
+	}
+	fields, err := models.ParsePatientFields(c.Query("fields"))
+	if err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidFields, "Invalid fields: "+err.Error()))
+		return
+	}
+	// An empty query would return the whole hospital
+	if searchQuery.CriteriaCount() == 0 {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeTooFewCriteria, "at least one search criterion required"))
+		return
+	}
+
+	// Consortium-wide search is for admins, who answer for the hospital's access to shared records
+	if searchQuery.CrossHospital && !claims.IsAdmin() {
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeAdminRequired, "Admin privileges required for cross_hospital search"))
+		return
+	}
+	if searchQuery.CrossHospital && !h.repo.IsFeatureEnabled(staffHospitalID, models.FeatureCrossHospitalSearch) {
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeCrossHospitalDisabled, "cross_hospital search is not enabled for this hospital"))
+		return
+	}
+
+	// 3. Apply the hospital's search settings
+	hospitalConfig, err := h.configs.Get(staffHospitalID)
 	if err != nil {
-		log.Printf("Error searching patients in database for hospital %d: %v", staffHospitalID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error during patient search"})
+		log.Printf("Error loading hospital config %d for patient search: %v", staffHospitalID, err)
+		apperror.HandleError(c, databaseError(err, "Failed to load hospital settings"))
+		return
+	}
+	if searchQuery.CriteriaCount() < hospitalConfig.SearchMinCriteria {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeTooFewCriteria, fmt.Sprintf("At least %d search criteria are required", hospitalConfig.SearchMinCriteria)))
+		return
+	}
+	limit := h.searchResultLimit(hospitalConfig)
+
+	// Super admins may widen the scope with hospital_id=all or a comma-separated list.
+	// Everyone else is always scoped to their own hospital, whatever they pass.
+	if scope := c.Query("hospital_id"); scope != "" {
+		if claims.CanSearchAllHospitals() {
+			h.searchAcrossHospitals(c, claims, searchQuery, scope, fields, limit, rawQuery)
+			return
+		}
+		log.Printf("Ignoring hospital_id=%q from %s: cross-hospital search not permitted", scope, claims.Username)
+	}
+	if pagedSearch(c) {
+		h.searchPatientsPage(c, claims, searchQuery, fields, rawQuery)
 		return
 	}
 
-	// 4. Return Results
-	log.Printf("Found %d patients matching criteria for hospital %d", len(patients), staffHospitalID)
-	if len(patients) == 0 {
+	// 4. Perform Search using Database function
+	// Pass the search criteria and the staff's hospital ID for filtering.
+	// One row beyond the limit is fetched to tell whether the results were cut off.
+	// Cross-hospital results are not cached: invalidation is per hospital, and they span several.
+	var patients []models.Patient
+	cached := false
+	if !searchQuery.CrossHospital {
+		patients, cached = h.cachedSearch(c, staffHospitalID, searchQuery, limit+1)
+	}
+	if !cached {
+		var err error
+		patients, err = h.repo.SearchPatients(searchQuery, staffHospitalID, limit+1)
+		if err != nil {
+			log.Printf("Error searching patients in database for hospital %d: %v", staffHospitalID, err)
+			apperror.HandleError(c, databaseError(err, "Database error during patient search"))
+			return
+		}
+		if h.searchCache != nil && !searchQuery.CrossHospital {
+			h.searchCache.Set(staffHospitalID, searchQuery, limit+1, patients)
+		}
+	}
+	truncated := len(patients) > limit
+	if truncated {
+		patients = patients[:limit]
+	}
+	if patients == nil {
 		// Return empty list, not an error, if no patients match
-		c.JSON(http.StatusOK, []models.Patient{})
+		patients = []models.Patient{}
+	}
+
+	// 5. Return Results
+	log.Printf("Found %d patients matching criteria for hospital %d (truncated: %t)", len(patients), staffHospitalID, truncated)
+	h.recordSearch(claims, rawQuery, len(patients))
+	h.recordSearchAccess(c, claims, patients)
+	for i := range patients {
+		patients[i] = patientForRole(patients[i], claims)
+	}
+	writePatientSearchResults(c, patients, len(patients), truncated, fields, h.explainSearch(c, claims, searchQuery, staffHospitalID, limit+1))
+}
+
+// pagedSearch reports whether the client asked for the search results by page, with page or
+// page_size. Paged results are counted and linked like the other lists instead of cut off at the
+// search limit.
+func pagedSearch(c *gin.Context) bool {
+	return c.Query("page") != "" || c.Query("page_size") != ""
+}
+
+// searchPatientsPage writes one page of a search of the staff's hospital (with cross_hospital, its
+// consortiums). Pages are neither cached nor explained.
+func (h *Handler) searchPatientsPage(c *gin.Context, claims *services.Claims, searchQuery *models.PatientSearchQuery, fields []string, rawQuery string) {
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+	offset := (pagination.Page - 1) * pagination.PageSize
+	patients, total, err := h.repo.SearchPatientsPage(searchQuery, claims.HospitalID, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error searching patients in database for hospital %d: %v", claims.HospitalID, err)
+		apperror.HandleError(c, databaseError(err, "Database error during patient search"))
+		return
+	}
+	if patients == nil {
+		patients = []models.Patient{}
+	}
+
+	h.recordSearch(claims, rawQuery, len(patients))
+	h.recordSearchAccess(c, claims, patients)
+	for i := range patients {
+		patients[i] = patientForRole(patients[i], claims)
+	}
+	writePatientSearchPage(c, patients, len(patients), pagination, total, fields)
+}
+
+// debugExplainHeader asks for the PostgreSQL plan of a patient search in its response.
+const debugExplainHeader = "X-Debug-Explain"
+
+// explainSearch returns the plan of the search when an admin asked for it with X-Debug-Explain: true,
+// or nil. The header is ignored in production. Failing to explain is logged, not answered with an error.
+func (h *Handler) explainSearch(c *gin.Context, claims *services.Claims, searchQuery *models.PatientSearchQuery, hospitalID uint, limit int) json.RawMessage {
+	if h.cfg.AppEnv == config.AppEnvProduction || !claims.IsAdmin() {
+		return nil
+	}
+	if explain, _ := strconv.ParseBool(c.GetHeader(debugExplainHeader)); !explain {
+		return nil
+	}
+	plan, err := h.repo.ExplainSearchPatients(searchQuery, hospitalID, limit)
+	if err != nil {
+		log.Printf("Error explaining patient search for %s: %v", claims.Username, err)
+		return nil
+	}
+	return plan
+}
+
+// cachedSearch returns the cached results of the search when the search cache is on and has them,
+// and reports the lookup in the Cache-Status header.
+func (h *Handler) cachedSearch(c *gin.Context, hospitalID uint, searchQuery *models.PatientSearchQuery, limit int) ([]models.Patient, bool) {
+	if h.searchCache == nil {
+		return nil, false
+	}
+	patients, ok := h.searchCache.Get(hospitalID, searchQuery, limit)
+	if ok {
+		c.Header("Cache-Status", "hit")
+	} else {
+		c.Header("Cache-Status", "miss")
+	}
+	return patients, ok
+}
+
+// searchResultLimit returns how many patients a search may return: the hospital's
+// max_search_results when it sets one, but never more than the server-wide SearchMaxResults.
+func (h *Handler) searchResultLimit(hospitalConfig models.HospitalConfig) int {
+	limit := h.cfg.SearchMaxResults
+	if hospitalConfig.MaxSearchResults > 0 && hospitalConfig.MaxSearchResults < limit {
+		limit = hospitalConfig.MaxSearchResults
+	}
+	return limit
+}
+
+// newPatientSearchResponse wraps count search results, adding a hint when they were truncated.
+func newPatientSearchResponse(data interface{}, count int, truncated bool) models.PatientSearchResponse {
+	response := models.PatientSearchResponse{Data: data, Count: count, Truncated: truncated}
+	if truncated {
+		response.Hint = fmt.Sprintf("Only the first %d matches are shown; add search criteria to narrow the results", count)
+	}
+	return response
+}
+
+// validateSearchQuery checks the combinations binding cannot express, and normalizes the tags.
+// On failure it writes a 400 response and returns false.
+func validateSearchQuery(c *gin.Context, searchQuery *models.PatientSearchQuery, loc *time.Location) bool {
+	if searchQuery.PhoneNumber != nil && *searchQuery.PhoneNumber != "" && searchQuery.PhoneSuffix != nil && *searchQuery.PhoneSuffix != "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeConflictingCriteria, "phone_number and phone_suffix cannot be combined"))
+		return false
+	}
+	if searchQuery.PatientHN != nil && *searchQuery.PatientHN != "" && searchQuery.PatientHNPrefix != nil && *searchQuery.PatientHNPrefix != "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeConflictingCriteria, "patient_hn and patient_hn_prefix cannot be combined"))
+		return false
+	}
+	if searchQuery.BirthYear != nil {
+		if searchQuery.DateOfBirth != nil && *searchQuery.DateOfBirth != "" {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeConflictingCriteria, "date_of_birth and birth_year cannot be combined"))
+			return false
+		}
+		if year := *searchQuery.BirthYear; year < models.MinBirthYear || year > time.Now().Year() {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidCriterion, fmt.Sprintf("birth_year must be between %d and %d", models.MinBirthYear, time.Now().Year())))
+			return false
+		}
+	}
+	for i, tag := range searchQuery.Tags {
+		if searchQuery.Tags[i] = models.NormalizeTagName(tag); searchQuery.Tags[i] == "" {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidCriterion, fmt.Sprintf("tag %q must contain a letter or digit", tag)))
+			return false
+		}
+	}
+	if field := searchQuery.TooManyIdentifierValues(); field != "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidCriterion, fmt.Sprintf("%s lists more than %d values", field, models.MaxIdentifierValues)))
+		return false
+	}
+	if _, _, err := searchQuery.CreatedRange(loc); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidCriterion, err.Error()))
+		return false
+	}
+	return true
+}
+
+// searchAcrossHospitals runs a search over several hospitals and labels each row with its hospital name.
+func (h *Handler) searchAcrossHospitals(c *gin.Context, claims *services.Claims, searchQuery *models.PatientSearchQuery, scope string, fields []string, limit int, rawQuery string) {
+	hospitalIDs, err := parseHospitalScope(scope)
+	if err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidHospitalScope, err.Error()))
+		return
+	}
+
+	log.Printf("Cross-hospital patient search by %s (scope: %s)", claims.Username, scope)
+	var patients []models.PatientWithHospital
+	var pagination models.PaginationQuery
+	var total int64
+	paged := pagedSearch(c)
+	if paged {
+		var ok bool
+		if pagination, ok = h.bindPagination(c); !ok {
+			return
+		}
+		offset := (pagination.Page - 1) * pagination.PageSize
+		patients, total, err = h.repo.SearchPatientsAcrossHospitalsPage(searchQuery, hospitalIDs, claims.HospitalID, offset, pagination.PageSize)
+	} else {
+		patients, err = h.repo.SearchPatientsAcrossHospitals(searchQuery, hospitalIDs, claims.HospitalID, limit+1)
+	}
+	if err != nil {
+		log.Printf("Error searching patients across hospitals (scope %s): %v", scope, err)
+		apperror.HandleError(c, databaseError(err, "Database error during patient search"))
 		return
 	}
+	truncated := !paged && len(patients) > limit
+	if truncated {
+		patients = patients[:limit]
+	}
+	if patients == nil {
+		patients = []models.PatientWithHospital{}
+	}
+	h.recordSearch(claims, rawQuery, len(patients))
+	found := make([]models.Patient, len(patients))
+	for i := range patients {
+		found[i] = patients[i].Patient
+	}
+	h.recordSearchAccess(c, claims, found)
+	for i := range patients {
+		patients[i].Patient = patientForRole(patients[i].Patient, claims)
+	}
+	if paged {
+		writePatientSearchPage(c, patients, len(patients), pagination, total, fields)
+		return
+	}
+	writePatientSearchResults(c, patients, len(patients), truncated, fields, nil)
+}
+
+// writePatientSearchResults writes search results, given as a slice, keeping only the requested
+// fields of each patient when fields is non-nil. A non-nil explain is added as the query plan.
+func writePatientSearchResults(c *gin.Context, results interface{}, count int, truncated bool, fields []string, explain json.RawMessage) {
+	results, ok := selectSearchFields(c, results, fields)
+	if !ok {
+		return
+	}
+	response := newPatientSearchResponse(results, count, truncated)
+	response.Explain = explain
+	c.JSON(http.StatusOK, response)
+}
+
+// writePatientSearchPage writes a page of search results like writePatientSearchResults, with the
+// paging metadata and Link header of the other paginated lists.
+func writePatientSearchPage(c *gin.Context, results interface{}, count int, p models.PaginationQuery, total int64, fields []string) {
+	results, ok := selectSearchFields(c, results, fields)
+	if !ok {
+		return
+	}
+	setPaginationLinks(c, p, total)
+	c.JSON(http.StatusOK, models.PatientSearchResponse{Data: results, Count: count, Page: p.Page, PageSize: p.PageSize, Total: &total})
+}
+
+// selectSearchFields keeps only the requested fields of each search result when fields is non-nil.
+// On failure it writes a 500 response and returns false.
+func selectSearchFields(c *gin.Context, results interface{}, fields []string) (interface{}, bool) {
+	if fields == nil {
+		return results, true
+	}
+	selected, err := selectFields(results, fields)
+	if err != nil {
+		log.Printf("Error selecting fields %v of search results: %v", fields, err)
+		apperror.HandleError(c, apperror.Internal("Failed to encode search results"))
+		return nil, false
+	}
+	return selected, true
+}
+
+// selectFields re-encodes a slice of records as JSON objects holding only the given fields.
+func selectFields(records interface{}, fields []string) ([]map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &objects); err != nil {
+		return nil, err
+	}
+	selected := make([]map[string]json.RawMessage, len(objects))
+	for i, object := range objects {
+		selected[i] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := object[field]; ok {
+				selected[i][field] = value
+			}
+		}
+	}
+	return selected, nil
+}
+
+// recordSearch adds the search, given as its query string, to the staff member's search history.
+// A failure is logged but does not fail the search.
+func (h *Handler) recordSearch(claims *services.Claims, rawQuery string, resultCount int) {
+	entry := &models.SearchHistory{
+		StaffID:     claims.UserID,
+		HospitalID:  claims.HospitalID,
+		Query:       rawQuery,
+		ResultCount: resultCount,
+	}
+	if err := h.repo.RecordSearch(entry); err != nil {
+		log.Printf("Error recording search history for %s: %v", claims.Username, err)
+	}
+}
+
+// parseHospitalScope parses the hospital_id search parameter: "all" (nil result) or a comma-separated list of IDs.
+func parseHospitalScope(scope string) ([]uint, error) {
+	if strings.EqualFold(scope, "all") {
+		return nil, nil
+	}
+	var ids []uint
+	for _, part := range strings.Split(scope, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid hospital_id %q: use \"all\" or a comma-separated list of IDs", scope)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// GetPatientHandler returns a single patient from the staff's hospital.
+// Related records can be embedded with include=allergies.
+func (h *Handler) GetPatientHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+	response := models.PatientDetailResponse{Patient: patientForRole(*patient, claims)}
+
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "":
+			continue
+		case "allergies":
+			allergies, err := h.repo.ListAllergiesByPatient(patientID)
+			if err != nil {
+				log.Printf("Error loading allergies for patient %d: %v", patientID, err)
+				apperror.HandleError(c, apperror.Internal("Database error loading allergies"))
+				return
+			}
+			if allergies == nil {
+				allergies = []models.Allergy{}
+			}
+			response.Allergies = &allergies
+		default:
+			apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidFields, "Unsupported include value: "+include))
+			return
+		}
+	}
+
+	h.recordPatientView(claims, patient)
+	h.recordPatientAccess(c, claims, *patient)
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPatientByNationalIDHandler returns the patient of the staff's hospital with the national ID,
+// for integrations that know nothing else about the patient. The ID must pass its check digit;
+// separators such as dashes are ignored.
+func (h *Handler) GetPatientByNationalIDHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	nationalID := c.Param("nid")
+	if reason := utils.ValidateThaiNationalID(nationalID); reason != "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidPathParam, "Invalid national ID: "+reason))
+		return
+	}
+
+	patient, err := h.repo.GetPatientByNationalID(utils.NationalIDDigits(nationalID), claims.HospitalID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			apperror.HandleError(c, apperror.NotFound(apperror.CodePatientNotFound, "Patient not found"))
+		case errors.Is(err, database.ErrNationalIDNotUnique):
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeNationalIDNotUnique,
+				"Several patients share this national ID; search by national_id to list them"))
+		default:
+			log.Printf("Error loading patient by national ID in hospital %d: %v", claims.HospitalID, err)
+			apperror.HandleError(c, databaseError(err, "Database error loading patient"))
+		}
+		return
+	}
+
+	h.recordPatientView(claims, patient)
+	h.recordPatientAccess(c, claims, *patient)
+	c.JSON(http.StatusOK, models.PatientDetailResponse{Patient: patientForRole(*patient, claims)})
+}
+
+// patientPreconditionMet checks an If-Match header against the ETag GET /patient/:id, without
+// include, gives the caller for the patient, so a client only overwrites the version it last read.
+// Without the header every write is allowed. On a mismatch it writes 412 with the current tag
+// and returns false.
+func patientPreconditionMet(c *gin.Context, patient *models.Patient, claims *services.Claims) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+	body, err := json.Marshal(models.PatientDetailResponse{Patient: patientForRole(*patient, claims)})
+	if err != nil {
+		log.Printf("Error encoding patient %d for If-Match: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to check If-Match"))
+		return false
+	}
+	etag := middleware.BodyETag(body)
+	if middleware.IfMatchSatisfied(ifMatch, etag) {
+		return true
+	}
+	c.Header("ETag", etag)
+	apperror.HandleError(c, apperror.New(http.StatusPreconditionFailed, apperror.CodePatientChanged, "Patient has changed since it was fetched; reload and try again"))
+	return false
+}
+
+// recordPatientView adds the patient to the staff member's recently viewed list.
+// API keys have no list, and a failure is logged but does not fail the request.
+func (h *Handler) recordPatientView(claims *services.Claims, patient *models.Patient) {
+	if claims.APIKeyID != 0 {
+		return
+	}
+	view := &models.RecentlyViewed{
+		StaffID:    claims.UserID,
+		PatientID:  patient.ID,
+		HospitalID: patient.HospitalID,
+		ViewedAt:   time.Now(),
+	}
+	if err := h.repo.RecordPatientView(view); err != nil {
+		log.Printf("Error recording view of patient %d by %s: %v", patient.ID, claims.Username, err)
+	}
+}
+
+// maskedDigits is how many trailing characters of a masked identifier stay visible.
+const maskedDigits = 4
+
+// patientForRole maps a patient to what the caller's role may see.
+// Viewers get national ID, passport ID and phone number masked down to the last few characters.
+func patientForRole(patient models.Patient, claims *services.Claims) models.Patient {
+	if !claims.IsViewer() {
+		return patient
+	}
+	return maskPatientIdentifiers(patient)
+}
 
-	c.JSON(http.StatusOK, patients)
+// maskPatientIdentifiers hides all but the last digits of the national ID, passport ID and phone number.
+func maskPatientIdentifiers(patient models.Patient) models.Patient {
+	patient.NationalID = utils.MaskAllButLast(patient.NationalID, maskedDigits)
+	patient.PassportID = utils.MaskAllButLast(patient.PassportID, maskedDigits)
+	patient.PhoneNumber = utils.MaskAllButLast(patient.PhoneNumber, maskedDigits)
+	return patient
 }
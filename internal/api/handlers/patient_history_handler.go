@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ImportPatientHistoryHandler reads a legacy plain-text history export from a multipart "file"
+// upload and compares what it finds with the patient record. Nothing is changed; the mismatches
+// are applied with ApplyPatientHistoryHandler once staff have checked them. Viewers are compared
+// against the masked record, so the diff neither shows nor confirms identifiers they cannot read.
+func (h *Handler) ImportPatientHistoryHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, models.HistoryImportMaxBytes+multipartOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apperror.HandleError(c, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeFileTooLarge, "History file is too large"))
+			return
+		}
+		apperror.HandleError(c, apperror.Validation(apperror.CodeFileRequired, "A multipart \"file\" field is required"))
+		return
+	}
+	if fileHeader.Size > models.HistoryImportMaxBytes {
+		apperror.HandleError(c, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeFileTooLarge, "History file is too large"))
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(fileHeader.Filename), ".txt") {
+		apperror.HandleError(c, apperror.New(http.StatusUnsupportedMediaType, apperror.CodeUnsupportedHistoryFile, "Only .txt history files are accepted"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("Error opening history import for patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeFileUnreadable, "Could not read uploaded file"))
+		return
+	}
+	defer file.Close()
+
+	values, err := services.ParsePatientHistory(file)
+	if err != nil {
+		if errors.Is(err, services.ErrHistoryImportNotText) {
+			apperror.HandleError(c, apperror.New(http.StatusUnsupportedMediaType, apperror.CodeUnsupportedHistoryFile, "History file must be UTF-8 text"))
+			return
+		}
+		log.Printf("Error reading history import for patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeFileUnreadable, "Could not read uploaded file"))
+		return
+	}
+	visible := patientForRole(*patient, claims)
+	c.JSON(http.StatusOK, services.DiffPatientHistory(&visible, values, h.dateLocation()))
+}
+
+// ApplyPatientHistoryHandler writes the confirmed mismatches of a history import diff to the
+// patient. A change whose current value no longer matches the record is rejected with 409, so
+// a diff made before someone else's edit cannot overwrite it. Current values are compared as the
+// caller's role sees them, the way ImportPatientHistoryHandler showed them.
+func (h *Handler) ApplyPatientHistoryHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PatientHistoryApplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, invalidRequestError(&req, err))
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+	visible := patientForRole(*patient, claims)
+	updates, err := services.PatientHistoryUpdates(&visible, req.Changes, h.dateLocation())
+	if err != nil {
+		if errors.Is(err, services.ErrHistoryChangeStale) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeHistoryStale, "Patient has changed since the history was imported: "+err.Error()))
+			return
+		}
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidHistoryChange, err.Error()))
+		return
+	}
+
+	columns := make([]string, 0, len(req.Changes))
+	for _, change := range req.Changes {
+		columns = append(columns, change.Field)
+	}
+	sort.Strings(columns)
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionHistoryImported,
+		Details:    "fields=" + strings.Join(columns, ","),
+	}
+	if err := h.repo.UpdatePatientFields(patient.ID, patient.HospitalID, updates, audit); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodePatientMoved, "Patient was moved by another request; reload and try again"))
+			return
+		}
+		log.Printf("Error applying history import to patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to update patient"))
+		return
+	}
+	updated, ok := h.loadPatientInHospital(c, patient.ID, patient.HospitalID)
+	if !ok {
+		return
+	}
+
+	h.searchCache.Invalidate(updated.HospitalID)
+	services.PublishPatientUpdate(models.PatientUpdate{Operation: models.PatientUpdateUpdated, PatientID: updated.ID, HospitalID: updated.HospitalID})
+	h.notifyPatientWebhooks(models.WebhookEventPatientUpdated, updated.HospitalID, *updated)
+	services.PublishPatientEvent(models.PatientEvent{Event: models.WebhookEventPatientUpdated, HospitalID: updated.HospitalID, PatientID: updated.ID, ChangedFields: columns})
+	log.Printf("History import applied to patient %d (%s) by %s", updated.ID, strings.Join(columns, ", "), claims.Username)
+	c.JSON(http.StatusOK, patientForRole(*updated, claims))
+}
+
+// dateLocation is the configured TIMEZONE, in which dates of birth are stored and date-only
+// query parameters name a day.
+func (h *Handler) dateLocation() *time.Location {
+	location, err := time.LoadLocation(h.cfg.Timezone)
+	if err != nil {
+		return time.UTC // Config validation rejects unknown zones, so only a bare test config gets here
+	}
+	return location
+}
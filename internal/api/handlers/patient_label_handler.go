@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreatePatientLabelHandler defines a label for the admin's hospital. Admin only.
+func (h *Handler) CreatePatientLabelHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.PatientLabelCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	name, ok := labelName(c, req.Name)
+	if !ok {
+		return
+	}
+
+	label := &models.PatientLabel{
+		HospitalID: claims.HospitalID,
+		Name:       name,
+		Color:      strings.ToLower(req.Color),
+	}
+	if err := h.repo.CreatePatientLabel(label); err != nil {
+		if database.IsUniqueViolation(err) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeLabelNameTaken, "A label with this name already exists"))
+			return
+		}
+		log.Printf("Error creating label %q for hospital %d: %v", name, claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to create label"))
+		return
+	}
+
+	log.Printf("Label %d (%s) created for hospital %d by %s", label.ID, label.Name, claims.HospitalID, claims.Username)
+	c.JSON(http.StatusCreated, label)
+}
+
+// ListPatientLabelsHandler returns the labels of the admin's hospital. Admin only.
+func (h *Handler) ListPatientLabelsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	labels, err := h.repo.ListPatientLabels(claims.HospitalID)
+	if err != nil {
+		log.Printf("Error listing labels of hospital %d: %v", claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to list labels"))
+		return
+	}
+	if labels == nil {
+		labels = []models.PatientLabel{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": labels, "count": len(labels)})
+}
+
+// GetPatientLabelHandler returns one label of the admin's hospital. Admin only.
+func (h *Handler) GetPatientLabelHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	label, ok := h.loadPatientLabel(c, claims, "id")
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, label)
+}
+
+// UpdatePatientLabelHandler renames or recolors a label of the admin's hospital. Admin only.
+// Patients keep the label.
+func (h *Handler) UpdatePatientLabelHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.PatientLabelUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	label, ok := h.loadPatientLabel(c, claims, "id")
+	if !ok {
+		return
+	}
+
+	if req.Name != nil {
+		name, ok := labelName(c, *req.Name)
+		if !ok {
+			return
+		}
+		label.Name = name
+	}
+	if req.Color != nil {
+		label.Color = strings.ToLower(*req.Color)
+	}
+	if err := h.repo.UpdatePatientLabel(label); err != nil {
+		if database.IsUniqueViolation(err) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeLabelNameTaken, "A label with this name already exists"))
+			return
+		}
+		log.Printf("Error updating label %d: %v", label.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to update label"))
+		return
+	}
+
+	h.searchCache.Invalidate(claims.HospitalID) // Cached results carry the old name and color
+	log.Printf("Label %d updated by %s", label.ID, claims.Username)
+	c.JSON(http.StatusOK, label)
+}
+
+// DeletePatientLabelHandler removes a label of the admin's hospital from every patient and
+// then deletes it. Admin only.
+func (h *Handler) DeletePatientLabelHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	labelID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeletePatientLabel(labelID, claims.HospitalID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeLabelNotFound, "Label not found"))
+			return
+		}
+		log.Printf("Error deleting label %d: %v", labelID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to delete label"))
+		return
+	}
+
+	h.searchCache.Invalidate(claims.HospitalID)
+	log.Printf("Label %d deleted by %s", labelID, claims.Username)
+	c.Status(http.StatusNoContent)
+}
+
+// AssignPatientLabelHandler attaches a label of the staff's hospital to a patient of the same
+// hospital. Assigning a label the patient already has succeeds without change.
+func (h *Handler) AssignPatientLabelHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+	// Labels of other hospitals are reported as missing, so they can never be attached
+	label, ok := h.loadPatientLabel(c, claims, "label_id")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.AssignPatientLabel(patientID, label.ID); err != nil {
+		log.Printf("Error assigning label %d to patient %d: %v", label.ID, patientID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to assign label"))
+		return
+	}
+
+	h.searchCache.Invalidate(claims.HospitalID)
+	log.Printf("Label %d assigned to patient %d by %s", label.ID, patientID, claims.Username)
+	c.Status(http.StatusNoContent)
+}
+
+// UnassignPatientLabelHandler detaches a label from a patient of the staff's hospital.
+func (h *Handler) UnassignPatientLabelHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	labelID, ok := parseIDParam(c, "label_id")
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	if err := h.repo.UnassignPatientLabel(patientID, labelID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeLabelNotAssigned, "Patient does not have this label"))
+			return
+		}
+		log.Printf("Error removing label %d from patient %d: %v", labelID, patientID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to remove label"))
+		return
+	}
+
+	h.searchCache.Invalidate(claims.HospitalID)
+	log.Printf("Label %d removed from patient %d by %s", labelID, patientID, claims.Username)
+	c.Status(http.StatusNoContent)
+}
+
+// loadPatientLabel fetches the label named by the path parameter from the caller's hospital.
+// On failure it writes the error response and returns false.
+func (h *Handler) loadPatientLabel(c *gin.Context, claims *services.Claims, param string) (*models.PatientLabel, bool) {
+	labelID, ok := parseIDParam(c, param)
+	if !ok {
+		return nil, false
+	}
+	label, err := h.repo.GetPatientLabel(labelID, claims.HospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Labels of other hospitals are reported the same as missing ones
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeLabelNotFound, "Label not found"))
+			return nil, false
+		}
+		log.Printf("Error loading label %d: %v", labelID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to load label"))
+		return nil, false
+	}
+	return label, true
+}
+
+// labelName trims a label name and checks it is not blank. On failure it writes a 400 response.
+func labelName(c *gin.Context, raw string) (string, bool) {
+	name := strings.TrimSpace(raw)
+	if name == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "name must not be blank"))
+		return "", false
+	}
+	return name, true
+}
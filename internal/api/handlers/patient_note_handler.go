@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreatePatientNoteHandler adds a note to a patient and records it in the audit trail.
+func (h *Handler) CreatePatientNoteHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PatientNoteCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for patient note: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	body, ok := validNoteBody(c, req.Body)
+	if !ok {
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+
+	note := &models.PatientNote{
+		PatientID:  patient.ID,
+		HospitalID: patient.HospitalID,
+		AuthorID:   claims.UserID,
+		Body:       body,
+		Pinned:     req.Pinned,
+		IsPrivate:  req.IsPrivate,
+	}
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionNoteCreated,
+	}
+	if err := h.repo.CreatePatientNote(note, audit); err != nil {
+		log.Printf("Error creating note for patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to create note"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// validNoteBody trims a note body and checks it is neither empty nor too long.
+// On failure it writes a 400 response and returns false.
+func validNoteBody(c *gin.Context, raw string) (string, bool) {
+	body := strings.TrimSpace(raw)
+	if body == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidNoteBody, "Note body cannot be empty"))
+		return "", false
+	}
+	if utf8.RuneCountInString(body) > models.MaxPatientNoteLength {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidNoteBody, fmt.Sprintf("Note body exceeds %d characters", models.MaxPatientNoteLength)))
+		return "", false
+	}
+	return body, true
+}
+
+// ListPatientNotesHandler returns a patient's notes, pinned first then newest, paginated.
+// Other staff members' private notes are not included.
+func (h *Handler) ListPatientNotesHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	notes, total, err := h.repo.ListPatientNotes(patientID, claims.UserID, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error listing notes for patient %d: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing notes"))
+		return
+	}
+	if notes == nil {
+		notes = []models.PatientNote{}
+	}
+
+	setPaginationLinks(c, pagination, total)
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:     notes,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	})
+}
+
+// UpdatePatientNoteHandler changes a note's body, pinned flag or privacy. Only the author may edit a note.
+func (h *Handler) UpdatePatientNoteHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patient, note, ok := h.loadNoteFromPath(c, claims)
+	if !ok {
+		return
+	}
+
+	if note.AuthorID != claims.UserID {
+		log.Printf("Staff %d denied editing note %d authored by %d", claims.UserID, note.ID, note.AuthorID)
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeNoteNotAuthor, "Only the author can edit this note"))
+		return
+	}
+
+	var req models.PatientNoteUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for patient note update: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	if req.Body != nil {
+		body, ok := validNoteBody(c, *req.Body)
+		if !ok {
+			return
+		}
+		note.Body = body
+	}
+	if req.Pinned != nil {
+		note.Pinned = *req.Pinned
+	}
+	if req.IsPrivate != nil {
+		note.IsPrivate = *req.IsPrivate
+	}
+
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionNoteUpdated,
+	}
+	if err := h.repo.UpdatePatientNote(note, audit); err != nil {
+		log.Printf("Error updating note %d: %v", note.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to update note"))
+		return
+	}
+	c.JSON(http.StatusOK, note)
+}
+
+// DeletePatientNoteHandler deletes a note. Only the note's author or an admin may delete it.
+func (h *Handler) DeletePatientNoteHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patient, note, ok := h.loadNoteFromPath(c, claims)
+	if !ok {
+		return
+	}
+
+	if note.AuthorID != claims.UserID && !claims.IsAdmin() {
+		log.Printf("Staff %d denied deleting note %d authored by %d", claims.UserID, note.ID, note.AuthorID)
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeNoteNotAuthor, "Only the author or an admin can delete this note"))
+		return
+	}
+
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionNoteDeleted,
+		Details:    fmt.Sprintf("author_id=%d", note.AuthorID),
+	}
+	if err := h.repo.DeletePatientNote(note, audit); err != nil {
+		log.Printf("Error deleting note %d: %v", note.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to delete note"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// loadNoteFromPath loads the patient and note named by the :id and :note_id path parameters.
+// Notes the caller may not see, including other staff members' private notes, are reported as 404.
+// On failure it writes the error response and returns false.
+func (h *Handler) loadNoteFromPath(c *gin.Context, claims *services.Claims) (*models.Patient, *models.PatientNote, bool) {
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, nil, false
+	}
+	noteID, ok := parseIDParam(c, "note_id")
+	if !ok {
+		return nil, nil, false
+	}
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return nil, nil, false
+	}
+
+	note, err := h.repo.GetPatientNote(patientID, noteID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Error loading note %d: %v", noteID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading note"))
+		return nil, nil, false
+	}
+	if err != nil || !note.VisibleTo(claims.UserID) {
+		apperror.HandleError(c, apperror.NotFound(apperror.CodeNoteNotFound, "Note not found"))
+		return nil, nil, false
+	}
+	return patient, note, true
+}
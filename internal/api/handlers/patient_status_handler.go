@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UpdatePatientStatusHandler moves a patient of the staff's hospital to a new lifecycle status.
+// Changes the lifecycle does not allow, such as leaving deceased, are rejected with 422.
+// Honors If-Match like UpdatePatientHandler.
+func (h *Handler) UpdatePatientStatusHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PatientStatusUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	if req.DeceasedAt != nil && req.Status != models.PatientStatusDeceased {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidDeceasedAt, "deceased_at can only be given with status deceased"))
+		return
+	}
+	if req.DeceasedAt != nil && req.DeceasedAt.After(time.Now()) {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidDeceasedAt, "deceased_at cannot be in the future"))
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+	if !patientPreconditionMet(c, patient, claims) {
+		return
+	}
+	previousStatus := patient.Status
+	if err := services.ValidatePatientStatusTransition(previousStatus, req.Status); err != nil {
+		apperror.HandleError(c, apperror.New(http.StatusUnprocessableEntity, apperror.CodeInvalidStatusTransition, err.Error()))
+		return
+	}
+
+	patient.Status = req.Status
+	patient.Deceased = req.Status == models.PatientStatusDeceased
+	if patient.Deceased {
+		deceasedAt := time.Now()
+		if req.DeceasedAt != nil {
+			deceasedAt = *req.DeceasedAt
+		}
+		if patient.DateOfBirth != nil && deceasedAt.Before(*patient.DateOfBirth) {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidDeceasedAt, "deceased_at cannot be before date_of_birth"))
+			return
+		}
+		patient.DeceasedAt = &deceasedAt
+	}
+
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionPatientStatusChanged,
+		Details:    fmt.Sprintf("from=%s to=%s", previousStatus, req.Status),
+	}
+	if err := h.repo.UpdatePatientStatus(patient, previousStatus, audit); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodePatientStatusChanged, "Patient status was changed by another request; reload and try again"))
+			return
+		}
+		log.Printf("Error updating status of patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to update patient status"))
+		return
+	}
+
+	log.Printf("Patient %d status changed from %s to %s by %s", patient.ID, previousStatus, patient.Status, claims.Username)
+	h.searchCache.Invalidate(patient.HospitalID)
+	services.PublishPatientUpdate(models.PatientUpdate{Operation: models.PatientUpdateUpdated, PatientID: patient.ID, HospitalID: patient.HospitalID})
+	h.notifyPatientWebhooks(models.WebhookEventPatientUpdated, patient.HospitalID, *patient)
+	changed := []string{"status", "deceased"}
+	if patient.Deceased {
+		changed = append(changed, "deceased_at")
+	}
+	services.PublishPatientEvent(models.PatientEvent{Event: models.WebhookEventPatientUpdated, HospitalID: patient.HospitalID, PatientID: patient.ID, ChangedFields: changed})
+	c.JSON(http.StatusOK, patientForRole(*patient, claims))
+}
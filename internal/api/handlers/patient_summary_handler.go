@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newSummaryRenderer returns the patient summary renderer SUMMARY_FONT_PATH configures, or nil
+// when summaries are off or the font cannot be loaded.
+func newSummaryRenderer(cfg *config.Config) *services.SummaryRenderer {
+	if cfg.SummaryFontPath == "" {
+		return nil
+	}
+	renderer, err := services.NewSummaryRenderer(cfg.SummaryFontPath)
+	if err != nil {
+		log.Printf("Patient summaries disabled: %v", err)
+		return nil
+	}
+	return renderer
+}
+
+// GetPatientSummaryHandler returns a one-page printable PDF of a patient's demographics,
+// allergies and most recent visits. Identifiers are masked for viewers as in GET /patient/:id.
+// Rendering that takes longer than SUMMARY_TIMEOUT_SECONDS is abandoned with 504.
+func (h *Handler) GetPatientSummaryHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if h.summaries == nil {
+		apperror.HandleError(c, apperror.New(http.StatusServiceUnavailable, apperror.CodeSummaryUnavailable, "Patient summaries are not configured on this server"))
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+	allergies, err := h.repo.ListAllergiesByPatient(patient.ID)
+	if err != nil {
+		log.Printf("Error loading allergies for summary of patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading allergies"))
+		return
+	}
+	visits, totalVisits, err := h.repo.ListVisitsByPatient(patient.ID, patient.HospitalID, 0, services.SummaryMaxVisits)
+	if err != nil {
+		log.Printf("Error loading visits for summary of patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading visits"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.cfg.SummaryTimeout)
+	defer cancel()
+	pdf, err := h.summaries.Render(ctx, services.PatientSummary{
+		Patient:     patientForRole(*patient, claims),
+		Allergies:   allergies,
+		Visits:      visits,
+		TotalVisits: totalVisits,
+		GeneratedAt: time.Now(),
+		GeneratedBy: claims.Username,
+		Location:    h.dateLocation(),
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("Summary of patient %d took longer than %v", patient.ID, h.cfg.SummaryTimeout)
+			apperror.HandleError(c, apperror.New(http.StatusGatewayTimeout, apperror.CodeSummaryTimeout, "Rendering the patient summary took too long"))
+			return
+		}
+		log.Printf("Error rendering summary of patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to render patient summary"))
+		return
+	}
+
+	h.recordPatientView(claims, patient)
+	h.recordPatientAccess(c, claims, *patient)
+	filename := fmt.Sprintf("patient_%s_summary.pdf", filenameSafe(patient.PatientHN))
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AddPatientTagHandler tags a patient of the staff's hospital, creating the tag on first use.
+// Adding a tag the patient already has succeeds without change.
+func (h *Handler) AddPatientTagHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PatientTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	name, ok := tagName(c, req.Name)
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	tag, err := h.repo.AddPatientTag(patientID, claims.HospitalID, name)
+	if err != nil {
+		log.Printf("Error tagging patient %d with %q: %v", patientID, name, err)
+		apperror.HandleError(c, apperror.Internal("Failed to add tag"))
+		return
+	}
+
+	h.searchCache.Invalidate(claims.HospitalID)
+	log.Printf("Tag %s added to patient %d by %s", tag.Name, patientID, claims.Username)
+	c.JSON(http.StatusOK, tag)
+}
+
+// RemovePatientTagHandler removes a tag from a patient of the staff's hospital. The tag stays
+// defined for the hospital.
+func (h *Handler) RemovePatientTagHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	name, ok := tagName(c, c.Param("tag"))
+	if !ok {
+		return
+	}
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	if err := h.repo.RemovePatientTag(patientID, claims.HospitalID, name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeTagNotAssigned, "Patient does not have this tag"))
+			return
+		}
+		log.Printf("Error removing tag %q from patient %d: %v", name, patientID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to remove tag"))
+		return
+	}
+
+	h.searchCache.Invalidate(claims.HospitalID)
+	log.Printf("Tag %s removed from patient %d by %s", name, patientID, claims.Username)
+	c.Status(http.StatusNoContent)
+}
+
+// ListTagsHandler returns the tags of the staff's hospital in name order, each with the number
+// of patients carrying it.
+func (h *Handler) ListTagsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	tags, err := h.repo.ListTagCounts(claims.HospitalID)
+	if err != nil {
+		log.Printf("Error listing tags of hospital %d: %v", claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to list tags"))
+		return
+	}
+	if tags == nil {
+		tags = []models.TagCount{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tags, "count": len(tags)})
+}
+
+// DeleteTagHandler removes a tag of the admin's hospital from every patient and then deletes it.
+// Admin only.
+func (h *Handler) DeleteTagHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	name, ok := tagName(c, c.Param("tag"))
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteTag(claims.HospitalID, name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeTagNotFound, "Tag not found"))
+			return
+		}
+		log.Printf("Error deleting tag %q of hospital %d: %v", name, claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to delete tag"))
+		return
+	}
+
+	h.searchCache.Invalidate(claims.HospitalID)
+	log.Printf("Tag %s of hospital %d deleted by %s", name, claims.HospitalID, claims.Username)
+	c.Status(http.StatusNoContent)
+}
+
+// tagName normalizes a tag name and checks it is neither empty nor too long. On failure it
+// writes a 400 response.
+func tagName(c *gin.Context, raw string) (string, bool) {
+	name := models.NormalizeTagName(raw)
+	if name == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidTagName, "tag name must contain a letter or digit"))
+		return "", false
+	}
+	if len([]rune(name)) > models.MaxTagNameLength {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidTagName, fmt.Sprintf("tag name must be at most %d characters", models.MaxTagNameLength)))
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TransferPatientHandler moves a patient to another hospital. The route is restricted to admins,
+// and the caller must administer both the patient's current hospital and the target.
+func (h *Handler) TransferPatientHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PatientTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	patient, err := h.repo.GetPatientByID(patientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodePatientNotFound, "Patient not found"))
+			return
+		}
+		log.Printf("Error loading patient %d for transfer: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading patient"))
+		return
+	}
+	// Same response as any other lookup of a patient outside the caller's reach
+	if !claims.CanAdministerHospital(patient.HospitalID) {
+		log.Printf("Transfer denied: %s cannot administer patient %d's hospital %d", claims.Username, patientID, patient.HospitalID)
+		apperror.HandleError(c, apperror.NotFound(apperror.CodePatientNotFound, "Patient not found"))
+		return
+	}
+
+	sourceHospitalID := patient.HospitalID
+	if req.TargetHospitalID == sourceHospitalID {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeAlreadyInHospital, "Patient is already in the target hospital"))
+		return
+	}
+	if !claims.CanAdministerHospital(req.TargetHospitalID) {
+		log.Printf("Transfer denied: %s cannot administer target hospital %d", claims.Username, req.TargetHospitalID)
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeHospitalAccessDenied, "Admin rights over the target hospital are required"))
+		return
+	}
+	if _, err := h.repo.GetHospitalByID(req.TargetHospitalID); err != nil {
+		if errors.Is(err, database.ErrHospitalNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeHospitalNotFound, "Target hospital not found"))
+			return
+		}
+		log.Printf("Error loading hospital %d for transfer: %v", req.TargetHospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading hospital"))
+		return
+	}
+
+	audit := &models.AuditLog{
+		HospitalID: req.TargetHospitalID, // Filed under the hospital that now owns the patient's history
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionPatientTransferred,
+		Details:    fmt.Sprintf("from_hospital_id=%d to_hospital_id=%d", sourceHospitalID, req.TargetHospitalID),
+	}
+	if err := h.repo.TransferPatient(patient, req.TargetHospitalID, audit); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodePatientMoved, "Patient was moved by another request; reload and try again"))
+			return
+		}
+		log.Printf("Error transferring patient %d to hospital %d: %v", patient.ID, req.TargetHospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to transfer patient"))
+		return
+	}
+
+	log.Printf("Patient %d transferred from hospital %d to %d by %s", patient.ID, sourceHospitalID, req.TargetHospitalID, claims.Username)
+	for _, hospitalID := range []uint{sourceHospitalID, req.TargetHospitalID} {
+		h.searchCache.Invalidate(hospitalID)
+		services.PublishPatientUpdate(models.PatientUpdate{Operation: models.PatientUpdateTransferred, PatientID: patient.ID, HospitalID: hospitalID})
+		h.notifyPatientWebhooks(models.WebhookEventPatientUpdated, hospitalID, *patient)
+	}
+	// One event, under the hospital that now owns the patient
+	services.PublishPatientEvent(models.PatientEvent{Event: models.WebhookEventPatientUpdated, HospitalID: req.TargetHospitalID, PatientID: patient.ID, ChangedFields: []string{"hospital_id"}})
+	c.JSON(http.StatusOK, patient)
+}
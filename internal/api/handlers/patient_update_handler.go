@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UpdatePatientHandler changes the editable details of a patient of the staff's hospital.
+// Only the fields present in the body are written; an empty string clears a field.
+// Extra fields are merged into the patient's, and must use keys the hospital permits.
+// With If-Match, the update only goes ahead if the patient still has the ETag the client last
+// got from GET /patient/:id; otherwise it is rejected with 412.
+func (h *Handler) UpdatePatientHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.PatientUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, invalidRequestError(&req, err))
+		return
+	}
+
+	if req.BloodType == nil && req.Nationality == nil && req.MaritalStatus == nil &&
+		req.PreferredLanguage == nil && req.PreferredContactMethod == nil && req.DoNotContact == nil && len(req.Extra) == 0 {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeNoFieldsToUpdate, "No fields to update"))
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+	if !patientPreconditionMet(c, patient, claims) {
+		return
+	}
+	var extra models.ExtraFields
+	if len(req.Extra) > 0 {
+		if extra, ok = h.mergeExtraFields(c, patient, req.Extra); !ok {
+			return
+		}
+	}
+
+	// Each given field is written to its column and, once saved, to the loaded patient for the response
+	fields := []struct {
+		column string
+		value  *string
+		target **string
+	}{
+		{"blood_type", req.BloodType, &patient.BloodType},
+		{"nationality", req.Nationality, &patient.Nationality},
+		{"marital_status", req.MaritalStatus, &patient.MaritalStatus},
+		{"preferred_language", req.PreferredLanguage, &patient.PreferredLanguage},
+		{"preferred_contact_method", req.PreferredContactMethod, &patient.PreferredContactMethod},
+	}
+	updates := map[string]interface{}{}
+	var columns []string
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		var value *string
+		if trimmed := strings.TrimSpace(*field.value); trimmed != "" {
+			value = &trimmed
+		}
+		updates[field.column] = value
+		columns = append(columns, field.column)
+	}
+	if req.DoNotContact != nil {
+		updates["do_not_contact"] = *req.DoNotContact
+		columns = append(columns, "do_not_contact")
+	}
+	if extra != nil {
+		updates["extra"] = extra
+		columns = append(columns, "extra")
+	}
+	audit := &models.AuditLog{
+		HospitalID: patient.HospitalID,
+		PatientID:  patient.ID,
+		StaffID:    claims.UserID,
+		Action:     models.AuditActionPatientUpdated,
+		Details:    "fields=" + strings.Join(columns, ","),
+	}
+	if err := h.repo.UpdatePatientFields(patient.ID, patient.HospitalID, updates, audit); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodePatientMoved, "Patient was moved by another request; reload and try again"))
+			return
+		}
+		log.Printf("Error updating patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to update patient"))
+		return
+	}
+	for _, field := range fields {
+		if value, ok := updates[field.column]; ok {
+			*field.target = value.(*string)
+		}
+	}
+	if req.DoNotContact != nil {
+		patient.DoNotContact = *req.DoNotContact
+	}
+	if extra != nil {
+		patient.Extra = extra
+	}
+
+	h.searchCache.Invalidate(patient.HospitalID)
+	services.PublishPatientUpdate(models.PatientUpdate{Operation: models.PatientUpdateUpdated, PatientID: patient.ID, HospitalID: patient.HospitalID})
+	h.notifyPatientWebhooks(models.WebhookEventPatientUpdated, patient.HospitalID, *patient)
+	services.PublishPatientEvent(models.PatientEvent{Event: models.WebhookEventPatientUpdated, HospitalID: patient.HospitalID, PatientID: patient.ID, ChangedFields: columns})
+	log.Printf("Patient %d updated (%s) by %s", patient.ID, strings.Join(columns, ", "), claims.Username)
+	c.JSON(http.StatusOK, patientForRole(*patient, claims))
+}
+
+// mergeExtraFields applies the requested extra fields to a copy of the patient's, removing those
+// given an empty value. On an unpermitted key or an oversized result it writes a 400 response.
+func (h *Handler) mergeExtraFields(c *gin.Context, patient *models.Patient, changes models.ExtraFields) (models.ExtraFields, bool) {
+	hospitalConfig, err := h.configs.Get(patient.HospitalID)
+	if err != nil {
+		log.Printf("Error loading hospital config %d for patient update: %v", patient.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to load hospital settings"))
+		return nil, false
+	}
+	permitted := make(map[string]bool, len(hospitalConfig.ExtraFieldKeys))
+	for _, key := range hospitalConfig.ExtraFieldKeys {
+		permitted[key] = true
+	}
+	var unknown []string
+	for key := range changes {
+		if !permitted[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidExtraFields, "Extra fields not permitted for this hospital: "+strings.Join(unknown, ", ")))
+		return nil, false
+	}
+
+	merged := models.ExtraFields{}
+	for key, value := range patient.Extra {
+		merged[key] = value
+	}
+	for key, value := range changes {
+		if value = strings.TrimSpace(value); value == "" {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	if encoded, err := json.Marshal(merged); err != nil || len(encoded) > models.MaxExtraFieldsBytes {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidExtraFields, fmt.Sprintf("Extra fields may take at most %d bytes as JSON", models.MaxExtraFieldsBytes)))
+		return nil, false
+	}
+	return merged, true
+}
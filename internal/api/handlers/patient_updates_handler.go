@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/services"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patientUpdatesKeepAlive is how often an idle update stream gets a comment line, so proxies
+// don't close it for inactivity.
+const patientUpdatesKeepAlive = 15 * time.Second
+
+// PatientUpdatesHandler streams changes to the patients of the staff's hospital as server-sent
+// events. Each "patient" event carries a models.PatientUpdate; the stream runs until the client
+// disconnects or the server shuts down.
+func (h *Handler) PatientUpdatesHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	updates, unsubscribe := services.SubscribePatientUpdates(claims.HospitalID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx buffering the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	log.Printf("Staff %s subscribed to patient updates of hospital %d", claims.Username, claims.HospitalID)
+	keepAlive := time.NewTicker(patientUpdatesKeepAlive)
+	defer keepAlive.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update, open := <-updates:
+			if !open {
+				return false
+			}
+			c.SSEvent("patient", update)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRecentlyViewedHandler returns the patients of the caller's hospital they opened most
+// recently, newest first, up to models.MaxRecentlyViewed.
+func (h *Handler) ListRecentlyViewedHandler(c *gin.Context) {
+	claims, ok := staffAccountClaims(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.repo.ListRecentlyViewed(claims.UserID, claims.HospitalID)
+	if err != nil {
+		log.Printf("Error listing recently viewed patients for %s: %v", claims.Username, err)
+		apperror.HandleError(c, apperror.Internal("Failed to list recently viewed patients"))
+		return
+	}
+	if entries == nil {
+		entries = []models.RecentlyViewedPatient{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries, "count": len(entries)})
+}
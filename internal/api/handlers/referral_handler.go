@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateReferralHandler refers a patient of the staff's hospital to another hospital.
+func (h *Handler) CreateReferralHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReferralCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for referral: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "reason cannot be blank"))
+		return
+	}
+	if req.TargetHospitalID == claims.HospitalID {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeReferralToOwnHospital, "Cannot refer a patient to their own hospital"))
+		return
+	}
+
+	patient, ok := h.loadPatientInHospital(c, req.PatientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+	if _, err := h.repo.GetHospitalByID(req.TargetHospitalID); err != nil {
+		if errors.Is(err, database.ErrHospitalNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeHospitalNotFound, "Target hospital not found"))
+			return
+		}
+		log.Printf("Error loading hospital %d for referral: %v", req.TargetHospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading hospital"))
+		return
+	}
+
+	referral := &models.Referral{
+		SourceHospitalID: patient.HospitalID,
+		TargetHospitalID: req.TargetHospitalID,
+		PatientID:        patient.ID,
+		ReferringStaffID: claims.UserID,
+		Reason:           reason,
+		Priority:         req.Priority,
+		Status:           models.ReferralStatusPending,
+	}
+	if err := h.repo.CreateReferral(referral); err != nil {
+		log.Printf("Error referring patient %d to hospital %d: %v", patient.ID, req.TargetHospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to create referral"))
+		return
+	}
+
+	log.Printf("Patient %d referred from hospital %d to %d by %s", patient.ID, referral.SourceHospitalID, referral.TargetHospitalID, claims.Username)
+	c.JSON(http.StatusCreated, referral)
+}
+
+// GetReferralHandler returns a referral to staff of its source or target hospital.
+func (h *Handler) GetReferralHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	referralID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	referral, ok := h.loadReferral(c, referralID)
+	if !ok {
+		return
+	}
+	if referral.SourceHospitalID != claims.HospitalID && referral.TargetHospitalID != claims.HospitalID {
+		apperror.HandleError(c, apperror.NotFound(apperror.CodeReferralNotFound, "Referral not found"))
+		return
+	}
+	c.JSON(http.StatusOK, referral)
+}
+
+// AcceptReferralHandler accepts a pending referral on behalf of its target hospital.
+func (h *Handler) AcceptReferralHandler(c *gin.Context) {
+	h.decideReferral(c, models.ReferralStatusAccepted)
+}
+
+// RejectReferralHandler rejects a pending referral on behalf of its target hospital.
+func (h *Handler) RejectReferralHandler(c *gin.Context) {
+	h.decideReferral(c, models.ReferralStatusRejected)
+}
+
+// decideReferral moves a pending referral to status. Only staff of the target hospital may decide;
+// the source hospital gets 403, any other hospital 404.
+func (h *Handler) decideReferral(c *gin.Context, status string) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	referralID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	referral, ok := h.loadReferral(c, referralID)
+	if !ok {
+		return
+	}
+	switch claims.HospitalID {
+	case referral.TargetHospitalID:
+	case referral.SourceHospitalID:
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeReferralNotTarget, "Only the target hospital can accept or reject a referral"))
+		return
+	default:
+		apperror.HandleError(c, apperror.NotFound(apperror.CodeReferralNotFound, "Referral not found"))
+		return
+	}
+	if referral.Status != models.ReferralStatusPending {
+		apperror.HandleError(c, apperror.Conflict(apperror.CodeReferralDecided, "Referral is already "+referral.Status))
+		return
+	}
+
+	if err := h.repo.UpdateReferralStatus(referral, models.ReferralStatusPending, status); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Decided by a concurrent request since it was loaded
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeReferralDecided, "Referral was already decided; reload and try again"))
+			return
+		}
+		log.Printf("Error updating referral %d to %s: %v", referral.ID, status, err)
+		apperror.HandleError(c, apperror.Internal("Failed to update referral"))
+		return
+	}
+	referral.Status = status
+
+	log.Printf("Referral %d %s by %s", referral.ID, status, claims.Username)
+	c.JSON(http.StatusOK, referral)
+}
+
+// loadReferral fetches a referral, writing 404 or 500 and returning false when it can't.
+func (h *Handler) loadReferral(c *gin.Context, referralID uint) (*models.Referral, bool) {
+	referral, err := h.repo.GetReferral(referralID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeReferralNotFound, "Referral not found"))
+			return nil, false
+		}
+		log.Printf("Error loading referral %d: %v", referralID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading referral"))
+		return nil, false
+	}
+	return referral, true
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetMonthlyReportHandler returns the management summary of a month (?year=&month=) of the
+// admin's hospital; super admins may pick another with ?hospital_id=. A cached report generated
+// after the month ended is returned as is; any other is generated again and cached.
+func (h *Handler) GetMonthlyReportHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	var query models.MonthlyReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "Invalid report parameters: "+err.Error()))
+		return
+	}
+	hospitalID := claims.HospitalID
+	if raw := c.Query("hospital_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || id == 0 {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "hospital_id must be a positive integer"))
+			return
+		}
+		hospitalID = uint(id)
+	}
+	if !claims.CanAdministerHospital(hospitalID) {
+		log.Printf("Admin %s (hospital %d) denied monthly report of hospital %d", claims.Username, claims.HospitalID, hospitalID)
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeHospitalAccessDenied, "Admins can only manage their own hospital"))
+		return
+	}
+	start := time.Date(query.Year, time.Month(query.Month), 1, 0, 0, 0, 0, h.dateLocation())
+	if start.After(time.Now()) {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "The report month has not started yet"))
+		return
+	}
+
+	cached, err := h.repo.GetCachedReport(hospitalID, query.Year, query.Month)
+	switch {
+	case err == nil && !cached.GeneratedAt.Before(start.AddDate(0, 1, 0)):
+		c.JSON(http.StatusOK, cached.Report)
+		return
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		// The report can still be generated
+		log.Printf("Error loading cached report of hospital %d for %d-%02d: %v", hospitalID, query.Year, query.Month, err)
+	}
+
+	report, err := h.repo.GenerateMonthlyReport(hospitalID, start)
+	if err != nil {
+		log.Printf("Error generating report of hospital %d for %d-%02d: %v", hospitalID, query.Year, query.Month, err)
+		apperror.HandleError(c, databaseError(err, "Database error generating the report"))
+		return
+	}
+	err = h.repo.SaveCachedReport(&models.CachedReport{
+		HospitalID:  hospitalID,
+		Year:        query.Year,
+		Month:       query.Month,
+		Report:      *report,
+		GeneratedAt: report.GeneratedAt,
+	})
+	if err != nil {
+		log.Printf("Error caching report of hospital %d for %d-%02d: %v", hospitalID, query.Year, query.Month, err)
+	}
+	c.JSON(http.StatusOK, report)
+}
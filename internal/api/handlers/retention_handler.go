@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetentionDryRunHandler reports what the retention purge would delete if it ran now, without
+// deleting anything. The purge spans every hospital, so only super admins may ask.
+func (h *Handler) RetentionDryRunHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	if !claims.IsSuperAdmin() {
+		apperror.HandleError(c, apperror.Forbidden(apperror.CodeSuperAdminRequired, "Super admin privileges required"))
+		return
+	}
+
+	cutoffs := models.NewRetentionCutoffs(time.Now(), h.cfg.PatientRetentionDays, h.cfg.RevokedTokenRetentionDays)
+	report, err := h.repo.CountRetentionPurge(cutoffs)
+	if err != nil {
+		log.Printf("Error counting records past retention: %v", err)
+		apperror.HandleError(c, databaseError(err, "Database error counting records past retention"))
+		return
+	}
+	report.DryRun = true
+	c.JSON(http.StatusOK, report)
+}
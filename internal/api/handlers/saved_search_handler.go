@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// CreateSavedSearchHandler saves a named patient search for the caller.
+// The parameters are checked the same way as on GET /patient/search.
+func (h *Handler) CreateSavedSearchHandler(c *gin.Context) {
+	claims, ok := staffAccountClaims(c)
+	if !ok {
+		return
+	}
+
+	var req models.SavedSearchCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "name must not be blank"))
+		return
+	}
+	if unknown := req.QueryParams.UnknownFields(); len(unknown) > 0 {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidCriterion, "Unknown search parameters: "+strings.Join(unknown, ", ")))
+		return
+	}
+	var searchQuery models.PatientSearchQuery
+	if err := bindSearchParams(req.QueryParams, &searchQuery); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "Invalid query parameters: "+err.Error()))
+		return
+	}
+	if !validateSearchQuery(c, &searchQuery, h.dateLocation()) {
+		return
+	}
+	if searchQuery.CriteriaCount() == 0 {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeTooFewCriteria, "at least one search criterion required"))
+		return
+	}
+
+	search := &models.SavedSearch{
+		StaffID:     claims.UserID,
+		HospitalID:  claims.HospitalID,
+		Name:        req.Name,
+		QueryParams: req.QueryParams,
+	}
+	if err := h.repo.CreateSavedSearch(search); err != nil {
+		switch {
+		case errors.Is(err, database.ErrSavedSearchLimit):
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeSavedSearchLimitReached, fmt.Sprintf("At most %d saved searches are allowed; delete one first", models.MaxSavedSearchesPerStaff)))
+		case database.IsUniqueViolation(err):
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeSavedSearchNameTaken, "A saved search with this name already exists"))
+		default:
+			log.Printf("Error saving search for %s: %v", claims.Username, err)
+			apperror.HandleError(c, apperror.Internal("Failed to save search"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// ListSavedSearchesHandler returns the caller's saved searches.
+func (h *Handler) ListSavedSearchesHandler(c *gin.Context) {
+	claims, ok := staffAccountClaims(c)
+	if !ok {
+		return
+	}
+
+	searches, err := h.repo.ListSavedSearches(claims.UserID)
+	if err != nil {
+		log.Printf("Error listing saved searches for %s: %v", claims.Username, err)
+		apperror.HandleError(c, apperror.Internal("Failed to list saved searches"))
+		return
+	}
+	if searches == nil {
+		searches = []models.SavedSearch{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": searches, "count": len(searches)})
+}
+
+// DeleteSavedSearchHandler removes one of the caller's saved searches.
+func (h *Handler) DeleteSavedSearchHandler(c *gin.Context) {
+	claims, ok := staffAccountClaims(c)
+	if !ok {
+		return
+	}
+	searchID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteSavedSearch(searchID, claims.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeSavedSearchNotFound, "Saved search not found"))
+			return
+		}
+		log.Printf("Error deleting saved search %d: %v", searchID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to delete saved search"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RunSavedSearchHandler runs one of the caller's saved searches with the current hospital
+// settings, returning the same response as GET /patient/search.
+func (h *Handler) RunSavedSearchHandler(c *gin.Context) {
+	claims, ok := staffAccountClaims(c)
+	if !ok {
+		return
+	}
+	searchID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	search, err := h.repo.GetSavedSearch(searchID, claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeSavedSearchNotFound, "Saved search not found"))
+			return
+		}
+		log.Printf("Error loading saved search %d: %v", searchID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to load saved search"))
+		return
+	}
+
+	// Checked again in case the search fields changed since it was saved
+	var searchQuery models.PatientSearchQuery
+	if unknown := search.QueryParams.UnknownFields(); len(unknown) > 0 {
+		apperror.HandleError(c, apperror.New(http.StatusUnprocessableEntity, apperror.CodeSavedSearchInvalid,
+			"Saved search uses parameters that no longer exist: "+strings.Join(unknown, ", ")))
+		return
+	}
+	if err := bindSearchParams(search.QueryParams, &searchQuery); err != nil {
+		apperror.HandleError(c, apperror.New(http.StatusUnprocessableEntity, apperror.CodeSavedSearchInvalid, "Saved search is no longer valid: "+err.Error()))
+		return
+	}
+
+	log.Printf("Saved search %d (%s) run by %s", search.ID, search.Name, claims.Username)
+	h.searchPatients(c, claims, &searchQuery, searchParamsQuery(search.QueryParams))
+}
+
+// bindSearchParams binds saved search parameters into a PatientSearchQuery as if they had been
+// sent in the query string, applying the same binding rules.
+func bindSearchParams(params models.SearchParams, searchQuery *models.PatientSearchQuery) error {
+	form := make(map[string][]string, len(params))
+	for key, value := range params {
+		form[key] = []string{value}
+	}
+	if err := binding.MapFormWithTag(searchQuery, form, "form"); err != nil {
+		return err
+	}
+	if err := searchQuery.BindExtra(form); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(searchQuery)
+}
+
+// searchParamsQuery encodes saved search parameters as the query string the search history records.
+func searchParamsQuery(params models.SearchParams) string {
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Staff export limits. Rows are flushed to the client every staffExportFlushEvery rows.
+const (
+	staffExportMaxRows    = 10000
+	staffExportFlushEvery = 100
+)
+
+// staffExportColumns is the CSV header. The password hash is never exported.
+var staffExportColumns = []string{"id", "username", "hospital_name", "role", "created_at", "last_login_at", "is_active"}
+
+// ExportStaffHandler streams the staff of the admin's hospital as a CSV download. Admin only.
+// At most staffExportMaxRows accounts are exported, in ID order.
+func (h *Handler) ExportStaffHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	hospital, err := h.repo.GetHospitalByID(claims.HospitalID)
+	if err != nil {
+		if errors.Is(err, database.ErrHospitalNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeHospitalNotFound, "Hospital not found"))
+			return
+		}
+		log.Printf("Error loading hospital %d for staff export: %v", claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Database error during staff export"))
+		return
+	}
+
+	// Nothing is written until the first row arrives, so a query that fails up front
+	// still gets a proper JSON error instead of a truncated file.
+	writer := csv.NewWriter(c.Writer)
+	started := false
+	start := func() error {
+		started = true
+		filename := fmt.Sprintf("staff_%s_%s.csv", filenameSafe(hospital.Code), time.Now().Format("2006-01-02"))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+		return writer.Write(staffExportColumns)
+	}
+
+	count := 0
+	err = h.repo.StreamStaffByHospital(claims.HospitalID, staffExportMaxRows, func(staff *models.Staff) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(staffExportRecord(staff)); err != nil {
+			return err
+		}
+		count++
+		if count%staffExportFlushEvery == 0 {
+			writer.Flush()
+			c.Writer.Flush()
+		}
+		return writer.Error()
+	})
+	if err != nil {
+		log.Printf("Error exporting staff of hospital %d after %d row(s): %v", claims.HospitalID, count, err)
+		if !started {
+			apperror.HandleError(c, apperror.Internal("Database error during staff export"))
+			return
+		}
+		writer.Flush()
+		c.Abort() // Headers are already sent; the client sees a short file
+		return
+	}
+
+	if !started { // No staff: still send the header row
+		if err := start(); err != nil {
+			log.Printf("Error writing staff export header: %v", err)
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("Error writing staff export for hospital %d: %v", claims.HospitalID, err)
+		return
+	}
+	log.Printf("Staff export by %s: %d row(s) from hospital %d", claims.Username, count, claims.HospitalID)
+}
+
+// staffExportRecord formats one staff member in staffExportColumns order.
+func staffExportRecord(staff *models.Staff) []string {
+	lastLogin := ""
+	if staff.LastLoginAt != nil {
+		lastLogin = staff.LastLoginAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatUint(uint64(staff.ID), 10),
+		staff.Username,
+		staff.HospitalName,
+		staff.Role,
+		staff.CreatedAt.UTC().Format(time.RFC3339),
+		lastLogin,
+		strconv.FormatBool(staff.IsActive),
+	}
+}
+
+// filenameSafe replaces anything but letters, digits, '-' and '_' so s can be used in a download name.
+func filenameSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -2,39 +2,97 @@ package handlers
 
 import (
 	"errors"
+	"hospital-middleware/internal/api/middleware"
 	"hospital-middleware/internal/database"
 	"hospital-middleware/internal/models"
 	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
 	"hospital-middleware/pkg/utils"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// CreateStaffHandler handles the creation of a new staff member.
-func CreateStaffHandler(c *gin.Context) {
+// usernameTakenResponse is the 409 for a taken username on the admin route. It names the existing
+// account so the admin can link to it.
+type usernameTakenResponse struct {
+	apperror.ErrorResponse
+	ExistingStaff models.StaffSummary `json:"existing_staff"`
+}
+
+// canSeeStaff reports whether the caller is authenticated as an admin of the staff member's hospital.
+// The public routes run without authentication and so never qualify.
+func canSeeStaff(c *gin.Context, staff *models.Staff) bool {
+	claimsInterface, exists := c.Get(middleware.ContextKeyClaims)
+	if !exists {
+		return false
+	}
+	claims, ok := claimsInterface.(*services.Claims)
+	return ok && claims.CanAdministerHospital(staff.HospitalID)
+}
+
+// enrollmentRequiredResponse is the 403 for a correct login that must enroll in two-factor
+// authentication first. The token only unlocks enrollment.
+type enrollmentRequiredResponse struct {
+	apperror.ErrorResponse
+	EnrollmentToken string `json:"enrollment_token"`
+}
+
+// twoFactorRequiredResponse is the 401 for a login that needs a two-factor code.
+type twoFactorRequiredResponse struct {
+	apperror.ErrorResponse
+	TwoFactorRequired bool `json:"two_factor_required"`
+}
+
+// passwordPolicyResponse is the 400 for a password that breaks the policy, listing every violation.
+type passwordPolicyResponse struct {
+	apperror.ErrorResponse
+	Violations []string `json:"violations"`
+}
+
+// CreateStaffHandler handles the creation of a new staff member. It serves both the public signup
+// route and the admin route; only on the latter is the account behind a taken username disclosed.
+func (h *Handler) CreateStaffHandler(c *gin.Context) {
 	var req models.StaffCreateRequest
 
 	// Bind JSON request body to the struct
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("Error binding JSON for staff creation: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
 		return
 	}
 
-	// Check if username already exists
-	_, err := database.FindStaffByUsername(req.Username)
+	if rejectWeakPassword(c, req.Password) {
+		return
+	}
+
+	// Check if username already exists; usernames are compared ignoring case
+	existing, err := h.repo.FindStaffByUsername(req.Username)
 	if err == nil {
 		// User found, username already exists
 		log.Printf("Attempt to create staff with existing username: %s", req.Username)
-		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+		if !canSeeStaff(c, existing) {
+			// Anyone may call the public route, so it must not reveal who holds a username
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeUsernameTaken, "Username already exists"))
+			return
+		}
+		// Tell the admin who it is so they can link to that account
+		c.JSON(http.StatusConflict, usernameTakenResponse{
+			ErrorResponse: apperror.Response(c, apperror.CodeUsernameTaken, "Username already exists"),
+			ExistingStaff: models.StaffSummary{
+				ID:           existing.ID,
+				Username:     existing.Username,
+				HospitalName: existing.HospitalName,
+			},
+		})
 		return
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		// Other database error occurred
 		log.Printf("Database error checking username %s: %v", req.Username, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error checking username"})
+		apperror.HandleError(c, databaseError(err, "Database error checking username"))
 		return
 	}
 
@@ -42,15 +100,19 @@ func CreateStaffHandler(c *gin.Context) {
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		log.Printf("Error hashing password for user %s: %v", req.Username, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+		apperror.HandleError(c, apperror.Internal("Failed to process password"))
 		return
 	}
 
 	// Get Hospital ID from name
-	hospitalID, err := database.GetHospitalIDByName(req.Hospital)
+	hospitalID, err := h.repo.GetHospitalIDByName(req.Hospital)
 	if err != nil {
 		log.Printf("Error finding hospital ID for name '%s': %v", req.Hospital, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hospital specified: " + err.Error()})
+		if !errors.Is(err, database.ErrHospitalNotFound) {
+			apperror.HandleError(c, databaseError(err, "Database error looking up hospital"))
+			return
+		}
+		apperror.HandleError(c, apperror.Validation(apperror.CodeUnknownHospital, "Invalid hospital specified: "+err.Error()))
 		return
 	}
 
@@ -60,12 +122,23 @@ func CreateStaffHandler(c *gin.Context) {
 		PasswordHash: hashedPassword,
 		HospitalID:   hospitalID,
 		HospitalName: req.Hospital,
+		Role:         models.RoleStaff, // Elevated roles are granted by an admin, never at signup
 	}
 
 	// Save to database
-	if err := database.CreateStaff(newStaff); err != nil {
+	if err := h.repo.CreateStaff(newStaff); err != nil {
+		if database.IsForeignKeyViolation(err) {
+			// The hospital was removed after it was looked up
+			apperror.HandleError(c, apperror.Validation(apperror.CodeUnknownHospital, "invalid hospital"))
+			return
+		}
+		if database.IsUniqueViolation(err) {
+			// Another request took the username after it was checked
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeUsernameTaken, "Username already exists"))
+			return
+		}
 		log.Printf("Error creating staff %s in database: %v", req.Username, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create staff member"})
+		apperror.HandleError(c, databaseError(err, "Failed to create staff member"))
 		return
 	}
 
@@ -77,27 +150,233 @@ func CreateStaffHandler(c *gin.Context) {
 }
 
 // LoginStaffHandler handles staff login attempts.
-func LoginStaffHandler(c *gin.Context) {
+func (h *Handler) LoginStaffHandler(c *gin.Context) {
 	var req models.StaffLoginRequest
 
 	// Bind JSON request body
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("Error binding JSON for staff login: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
 		return
 	}
 
 	// Authenticate and generate token
-	token, staff, err := services.AuthenticateStaff(req)
+	issued, staff, err := services.AuthenticateStaff(h.repo, h.configs, req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()}) // Ex. "invalid username or password", "invalid hospital"
+		switch {
+		case errors.Is(err, services.ErrUnknownHospital):
+			// The hospital itself doesn't exist, so this is a client error rather than a failed login
+			apperror.HandleError(c, apperror.Validation(apperror.CodeUnknownHospital, err.Error()))
+			return
+		case errors.Is(err, services.ErrAccountDisabled):
+			apperror.HandleError(c, apperror.Forbidden(apperror.CodeAccountDisabled, err.Error()))
+			return
+		case errors.Is(err, services.ErrTwoFactorEnrollmentRequired):
+			// The password was right, but the only thing this token unlocks is enrollment
+			c.JSON(http.StatusForbidden, enrollmentRequiredResponse{
+				ErrorResponse:   apperror.Response(c, apperror.CodeTwoFactorEnrollmentRequired, err.Error()),
+				EnrollmentToken: issued.Token,
+			})
+			return
+		case errors.Is(err, services.ErrTwoFactorCodeRequired):
+			c.JSON(http.StatusUnauthorized, twoFactorRequiredResponse{
+				ErrorResponse:     apperror.Response(c, apperror.CodeTwoFactorCodeRequired, err.Error()),
+				TwoFactorRequired: true,
+			})
+			return
+		case errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, services.ErrInvalidTwoFactorCode):
+			apperror.HandleError(c, apperror.Unauthorized(apperror.CodeInvalidCredentials, err.Error())) // Ex. "invalid username or password", also for a wrong hospital
+			return
+		}
+		// An outage is not a failed login; the client should retry rather than re-enter the password
+		log.Printf("Error during login of %s: %v", req.Username, err)
+		apperror.HandleError(c, databaseError(err, "Error during login"))
 		return
 	}
 
 	// Return token and basic staff info
 	response := models.StaffLoginResponse{
-		Token: token,
-		Staff: *staff, // Dereference pointer, password hash is already cleared in AuthenticateStaff
+		Token:     issued.Token,
+		ExpiresAt: issued.ExpiresAt,
+		ExpiresIn: int64(time.Until(issued.ExpiresAt).Round(time.Second) / time.Second),
+		Staff:     *staff, // Dereference pointer, password hash is already cleared in AuthenticateStaff
 	}
 	c.JSON(http.StatusOK, response)
 }
+
+// ChangePasswordHandler lets the logged-in staff member replace their password.
+func (h *Handler) ChangePasswordHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.StaffChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for password change: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	staff, err := h.repo.FindStaffByUsername(claims.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.Unauthorized(apperror.CodeStaffAccountGone, "Staff account no longer exists"))
+			return
+		}
+		log.Printf("Database error loading staff %s for password change: %v", claims.Username, err)
+		apperror.HandleError(c, databaseError(err, "Database error loading staff"))
+		return
+	}
+
+	if !utils.CheckPasswordHash(req.CurrentPassword, staff.PasswordHash) {
+		log.Printf("Password change rejected: wrong current password for user %s", staff.Username)
+		apperror.HandleError(c, apperror.Unauthorized(apperror.CodeCurrentPasswordIncorrect, "Current password is incorrect"))
+		return
+	}
+
+	if rejectWeakPassword(c, req.NewPassword) {
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		log.Printf("Error hashing new password for user %s: %v", staff.Username, err)
+		apperror.HandleError(c, apperror.Internal("Failed to process password"))
+		return
+	}
+
+	if err := h.repo.UpdateStaffPassword(staff.ID, hashedPassword); err != nil {
+		log.Printf("Error updating password for user %s: %v", staff.Username, err)
+		apperror.HandleError(c, databaseError(err, "Failed to update password"))
+		return
+	}
+
+	log.Printf("Password changed for user: %s", staff.Username)
+	c.Status(http.StatusNoContent)
+}
+
+// LogoutHandler revokes the caller's token so it is rejected until it would have expired anyway.
+func (h *Handler) LogoutHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeTokenNotRevocable, "Token cannot be revoked; it will expire on its own"))
+		return
+	}
+
+	revoked := &models.RevokedToken{
+		JTI:       claims.ID,
+		StaffID:   claims.UserID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if err := h.repo.RevokeToken(revoked); err != nil {
+		log.Printf("Error revoking token for user %s: %v", claims.Username, err)
+		apperror.HandleError(c, databaseError(err, "Failed to log out"))
+		return
+	}
+
+	log.Printf("User %s logged out", claims.Username)
+	c.Status(http.StatusNoContent)
+}
+
+// UpdateStaffRoleHandler changes the role of a staff member of the admin's own hospital.
+// The new role takes effect at the staff member's next login.
+func (h *Handler) UpdateStaffRoleHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	staffID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.StaffRoleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	staff, err := h.repo.UpdateStaffRole(staffID, claims.HospitalID, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// Staff of other hospitals are reported the same as missing ones
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeStaffNotFound, "Staff member not found"))
+		case errors.Is(err, database.ErrLastAdmin):
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeLastAdmin, err.Error()))
+		default:
+			log.Printf("Error updating role of staff %d: %v", staffID, err)
+			apperror.HandleError(c, databaseError(err, "Failed to update role"))
+		}
+		return
+	}
+
+	log.Printf("Role of staff %s set to %s by %s", staff.Username, staff.Role, claims.Username)
+	c.JSON(http.StatusOK, staff)
+}
+
+// EnrollTwoFactorHandler generates a TOTP secret for the logged-in staff member.
+// It accepts enrollment-only tokens so staff of hospitals that require 2FA can get set up.
+func (h *Handler) EnrollTwoFactorHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := services.StartTwoFactorEnrollment(h.repo, claims.Username)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeTwoFactorAlreadyEnabled, err.Error()))
+			return
+		}
+		log.Printf("Error starting two-factor enrollment for %s: %v", claims.Username, err)
+		apperror.HandleError(c, apperror.Internal("Failed to start two-factor enrollment"))
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTwoFactorHandler enables two-factor authentication once the staff member proves their authenticator works.
+func (h *Handler) ConfirmTwoFactorHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	err := services.ConfirmTwoFactorEnrollment(h.repo, claims.Username, req.Code)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		apperror.HandleError(c, apperror.Conflict(apperror.CodeTwoFactorAlreadyEnabled, err.Error()))
+	case errors.Is(err, services.ErrTwoFactorNotStarted), errors.Is(err, services.ErrInvalidTwoFactorCode):
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidTwoFactorCode, err.Error()))
+	default:
+		log.Printf("Error confirming two-factor enrollment for %s: %v", claims.Username, err)
+		apperror.HandleError(c, apperror.Internal("Failed to confirm two-factor enrollment"))
+	}
+}
+
+// rejectWeakPassword writes a 400 listing every password policy violation.
+// It returns true when the password was rejected.
+func rejectWeakPassword(c *gin.Context, password string) bool {
+	violations := services.CheckPasswordPolicy(password)
+	if len(violations) == 0 {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, passwordPolicyResponse{
+		ErrorResponse: apperror.Response(c, apperror.CodePasswordPolicy, "password policy violated"),
+		Violations:    violations,
+	})
+	return true
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// staffImportMaxBytes caps the upload. 500 rows of username,password,hospital,role fit comfortably.
+const staffImportMaxBytes = 1 << 20
+
+// ImportStaffHandler creates staff accounts from a multipart "file" CSV upload. Admin only.
+// Bad rows are skipped and listed in the response; the rest are still imported. With
+// ?dry_run=true the file is checked the same way, including against the database, but
+// nothing is saved.
+func (h *Handler) ImportStaffHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "dry_run must be true or false"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, staffImportMaxBytes+multipartOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apperror.HandleError(c, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeFileTooLarge, "Import file is too large"))
+			return
+		}
+		apperror.HandleError(c, apperror.Validation(apperror.CodeFileRequired, "A multipart \"file\" field is required"))
+		return
+	}
+	if fileHeader.Size > staffImportMaxBytes {
+		apperror.HandleError(c, apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeFileTooLarge, "Import file is too large"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("Error opening staff import upload from %s: %v", claims.Username, err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeFileUnreadable, "Could not read uploaded file"))
+		return
+	}
+	defer file.Close()
+
+	result, err := services.ImportStaffCSV(h.repo, claims, file, dryRun)
+	if err != nil {
+		if errors.Is(err, services.ErrStaffImportTooManyRows) || errors.Is(err, services.ErrStaffImportInvalidCSV) {
+			apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidStaffImportCSV, err.Error()))
+			return
+		}
+		log.Printf("Error importing staff for %s: %v", claims.Username, err)
+		apperror.HandleError(c, apperror.Internal("Failed to import staff"))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
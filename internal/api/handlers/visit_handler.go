@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateVisitHandler records a visit for a patient in the staff's hospital.
+func (h *Handler) CreateVisitHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.VisitCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("Error binding JSON for visit creation: %v", err)
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+
+	// The visit inherits the patient's hospital, which must be the caller's hospital
+	patient, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID)
+	if !ok {
+		return
+	}
+
+	admittedAt := time.Now()
+	if req.AdmittedAt != nil {
+		admittedAt = *req.AdmittedAt
+	}
+	if req.DischargedAt != nil && req.DischargedAt.Before(admittedAt) {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeVisitDischargeBefore, "discharged_at cannot be before admitted_at"))
+		return
+	}
+
+	visit := &models.Visit{
+		PatientID:     patient.ID,
+		HospitalID:    patient.HospitalID,
+		VisitNumber:   req.VisitNumber,
+		AdmittedAt:    admittedAt,
+		DischargedAt:  req.DischargedAt,
+		Department:    req.Department,
+		AttendingNote: req.AttendingNote,
+	}
+	if err := h.repo.CreateVisit(visit); err != nil {
+		if database.IsUniqueViolation(err) {
+			apperror.HandleError(c, apperror.Conflict(apperror.CodeVisitNumberTaken, "A visit with this visit number already exists"))
+			return
+		}
+		log.Printf("Error creating visit for patient %d: %v", patient.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to create visit"))
+		return
+	}
+
+	log.Printf("Visit %s recorded for patient %d by staff %s", visit.VisitNumber, patient.ID, claims.Username)
+	c.JSON(http.StatusCreated, visit)
+}
+
+// ListPatientVisitsHandler returns a patient's visits, newest first, paginated.
+func (h *Handler) ListPatientVisitsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	patientID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	pagination, ok := h.bindPagination(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := h.loadPatientInHospital(c, patientID, claims.HospitalID); !ok {
+		return
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	visits, total, err := h.repo.ListVisitsByPatient(patientID, claims.HospitalID, offset, pagination.PageSize)
+	if err != nil {
+		log.Printf("Error listing visits for patient %d: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing visits"))
+		return
+	}
+	if visits == nil {
+		visits = []models.Visit{}
+	}
+
+	setPaginationLinks(c, pagination, total)
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:     visits,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	})
+}
+
+// ListDailyVisitsHandler returns every visit admitted to the staff's hospital on the given day.
+func (h *Handler) ListDailyVisitsHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "date query parameter is required (YYYY-MM-DD)"))
+		return
+	}
+	day, err := time.ParseInLocation("2006-01-02", dateStr, h.dateLocation())
+	if err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidQuery, "Invalid date format, expected YYYY-MM-DD"))
+		return
+	}
+
+	visits, err := h.repo.ListVisitsByDate(claims.HospitalID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("Error listing visits for hospital %d on %s: %v", claims.HospitalID, dateStr, err)
+		apperror.HandleError(c, apperror.Internal("Database error listing visits"))
+		return
+	}
+	if visits == nil {
+		visits = []models.Visit{}
+	}
+	c.JSON(http.StatusOK, visits)
+}
+
+// loadPatientInHospital fetches a patient and checks it belongs to the given hospital.
+// Patients of other hospitals are reported as not found so their existence isn't leaked.
+// On failure it writes the error response and returns false.
+func (h *Handler) loadPatientInHospital(c *gin.Context, patientID, hospitalID uint) (*models.Patient, bool) {
+	patient, err := h.repo.GetPatientByID(patientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodePatientNotFound, "Patient not found"))
+			return nil, false
+		}
+		log.Printf("Error loading patient %d: %v", patientID, err)
+		apperror.HandleError(c, apperror.Internal("Database error loading patient"))
+		return nil, false
+	}
+	if patient.HospitalID != hospitalID {
+		log.Printf("Access denied: patient %d belongs to hospital %d, caller is from hospital %d", patientID, patient.HospitalID, hospitalID)
+		apperror.HandleError(c, apperror.NotFound(apperror.CodePatientNotFound, "Patient not found"))
+		return nil, false
+	}
+	return patient, true
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateWebhookHandler registers a webhook for the admin's hospital. Admin only.
+// The signing secret is returned once; a random one is generated when none is given.
+func (h *Handler) CreateWebhookHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	if !validWebhookURL(c, req.URL) {
+		return
+	}
+	secret := req.Secret
+	if secret == "" {
+		generated, err := services.NewWebhookSecret()
+		if err != nil {
+			log.Printf("Error generating webhook secret: %v", err)
+			apperror.HandleError(c, apperror.Internal("Failed to create webhook"))
+			return
+		}
+		secret = generated
+	}
+
+	webhook := &models.Webhook{
+		HospitalID: claims.HospitalID,
+		URL:        req.URL,
+		Secret:     secret,
+		Events:     models.StringList(req.Events),
+		Active:     req.Active == nil || *req.Active,
+	}
+	if err := h.repo.CreateWebhook(webhook); err != nil {
+		log.Printf("Error creating webhook for hospital %d: %v", claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to create webhook"))
+		return
+	}
+
+	log.Printf("Webhook %d (%s) created for hospital %d by %s", webhook.ID, webhook.URL, claims.HospitalID, claims.Username)
+	c.JSON(http.StatusCreated, models.WebhookCreateResponse{Webhook: *webhook, Secret: secret})
+}
+
+// ListWebhooksHandler returns the webhooks of the admin's hospital. Admin only.
+func (h *Handler) ListWebhooksHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.repo.ListWebhooks(claims.HospitalID)
+	if err != nil {
+		log.Printf("Error listing webhooks of hospital %d: %v", claims.HospitalID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to list webhooks"))
+		return
+	}
+	if webhooks == nil {
+		webhooks = []models.Webhook{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": webhooks, "count": len(webhooks)})
+}
+
+// GetWebhookHandler returns one webhook of the admin's hospital. Admin only.
+func (h *Handler) GetWebhookHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	webhook, ok := h.loadWebhook(c, claims)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhookHandler changes the fields given in the body of a webhook of the admin's hospital. Admin only.
+func (h *Handler) UpdateWebhookHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	if req.URL != nil && !validWebhookURL(c, *req.URL) {
+		return
+	}
+	webhook, ok := h.loadWebhook(c, claims)
+	if !ok {
+		return
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+	}
+	if req.Events != nil {
+		webhook.Events = models.StringList(req.Events)
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	if err := h.repo.UpdateWebhook(webhook); err != nil {
+		log.Printf("Error updating webhook %d: %v", webhook.ID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to update webhook"))
+		return
+	}
+
+	log.Printf("Webhook %d updated by %s", webhook.ID, claims.Username)
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhookHandler removes a webhook of the admin's hospital. Admin only.
+func (h *Handler) DeleteWebhookHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+	webhookID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteWebhook(webhookID, claims.HospitalID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeWebhookNotFound, "Webhook not found"))
+			return
+		}
+		log.Printf("Error deleting webhook %d: %v", webhookID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to delete webhook"))
+		return
+	}
+
+	log.Printf("Webhook %d deleted by %s", webhookID, claims.Username)
+	c.Status(http.StatusNoContent)
+}
+
+// TestWebhookHandler sends a webhook.test payload to a webhook of the admin's hospital and reports
+// the status its receiver answered with. Inactive webhooks can be tested too. Admin only.
+func (h *Handler) TestWebhookHandler(c *gin.Context) {
+	claims, ok := claimsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidBody, "Invalid request body: "+err.Error()))
+		return
+	}
+	webhook, err := h.repo.GetWebhook(req.WebhookID, claims.HospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeWebhookNotFound, "Webhook not found"))
+			return
+		}
+		log.Printf("Error loading webhook %d: %v", req.WebhookID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to load webhook"))
+		return
+	}
+
+	statusCode, err := h.webhooks.SendTest(*webhook)
+	if statusCode == 0 {
+		// No response at all, so there is no remote status to report
+		log.Printf("Test delivery to webhook %d failed: %v", webhook.ID, err)
+		apperror.HandleError(c, apperror.New(http.StatusBadGateway, apperror.CodeWebhookUnreachable, "Webhook receiver could not be reached"))
+		return
+	}
+
+	log.Printf("Test delivery to webhook %d by %s answered %d", webhook.ID, claims.Username, statusCode)
+	c.JSON(http.StatusOK, models.WebhookTestResponse{WebhookID: webhook.ID, StatusCode: statusCode, Delivered: err == nil})
+}
+
+// loadWebhook fetches the webhook named by the id path parameter from the caller's hospital.
+// On failure it writes the error response and returns false.
+func (h *Handler) loadWebhook(c *gin.Context, claims *services.Claims) (*models.Webhook, bool) {
+	webhookID, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	webhook, err := h.repo.GetWebhook(webhookID, claims.HospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Webhooks of other hospitals are reported the same as missing ones
+			apperror.HandleError(c, apperror.NotFound(apperror.CodeWebhookNotFound, "Webhook not found"))
+			return nil, false
+		}
+		log.Printf("Error loading webhook %d: %v", webhookID, err)
+		apperror.HandleError(c, apperror.Internal("Failed to load webhook"))
+		return nil, false
+	}
+	return webhook, true
+}
+
+// validWebhookURL checks that a webhook URL is http or https. On failure it writes a 400 response.
+func validWebhookURL(c *gin.Context, raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		apperror.HandleError(c, apperror.Validation(apperror.CodeInvalidWebhookURL, "url must be an http or https URL"))
+		return false
+	}
+	return true
+}
+
+// notifyPatientWebhooks queues the event for the hospital's webhooks, with the patient's
+// identifiers masked as they are for viewers.
+func (h *Handler) notifyPatientWebhooks(event string, hospitalID uint, patient models.Patient) {
+	snapshot := maskPatientIdentifiers(patient)
+	h.webhooks.Dispatch(models.WebhookPayload{
+		Event:      event,
+		Timestamp:  time.Now().UTC(),
+		HospitalID: hospitalID,
+		Patient:    &snapshot,
+	})
+}
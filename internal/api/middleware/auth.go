@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"errors"
 	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
 	"log"
 	"net/http"
 	"strings"
@@ -14,29 +16,90 @@ const (
 	ContextKeyClaims = "userClaims"
 )
 
-// AuthRequired is a middleware function to verify JWT token.
-func AuthRequired() gin.HandlerFunc {
+// RevocationChecker reports whether a token was revoked by logout.
+// database.PatientRepository satisfies it.
+type RevocationChecker interface {
+	IsTokenRevoked(jti string) (bool, error)
+}
+
+// CredentialStore is what AuthRequired needs to check bearer tokens and API keys.
+// database.PatientRepository satisfies it.
+type CredentialStore interface {
+	RevocationChecker
+	services.APIKeyStore
+}
+
+// HospitalChecker reports whether a hospital still exists.
+// services.HospitalExistenceCache satisfies it.
+type HospitalChecker interface {
+	HospitalExists(hospitalID uint) (bool, error)
+}
+
+// HeaderAPIKey carries an API key, the alternative to a bearer token for machine clients.
+const HeaderAPIKey = "X-API-Key"
+
+// AuthRequired is a middleware function to verify JWT token, or an API key sent in X-API-Key
+// when there is no Authorization header.
+// Enrollment-only tokens issued during two-factor enrollment are rejected, as are revoked tokens.
+// With a non-nil hospitals, so are tokens and API keys whose hospital no longer exists.
+func AuthRequired(credentials CredentialStore, hospitals HospitalChecker) gin.HandlerFunc {
+	return authenticate(credentials, hospitals, false)
+}
+
+// EnrollmentAuthRequired is AuthRequired that also accepts enrollment-only tokens.
+// It guards the endpoints staff use to set up two-factor authentication, so API keys are not accepted.
+func EnrollmentAuthRequired(credentials CredentialStore, hospitals HospitalChecker) gin.HandlerFunc {
+	return authenticate(credentials, hospitals, true)
+}
+
+func authenticate(credentials CredentialStore, hospitals HospitalChecker, allowEnrollment bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
+			if apiKey := c.GetHeader(HeaderAPIKey); apiKey != "" && !allowEnrollment {
+				authenticateAPIKey(c, credentials, hospitals, apiKey)
+				return
+			}
 			log.Println("Auth middleware: Missing Authorization header")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apperror.Response(c, apperror.CodeAuthRequired, "Authorization header required"))
 			return
 		}
 
-		// Expecting "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		tokenString, ok := BearerToken(authHeader)
+		if !ok {
 			log.Println("Auth middleware: Invalid Authorization header format")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apperror.Response(c, apperror.CodeInvalidAuthHeader, "Invalid authorization header format"))
 			return
 		}
 
-		tokenString := parts[1]
 		claims, err := services.ValidateToken(tokenString)
 		if err != nil {
 			log.Printf("Auth middleware: Token validation failed - %v", err)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()}) // e.g., "token is expired" or "invalid token"
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apperror.Response(c, apperror.CodeInvalidToken, err.Error())) // e.g., "token is expired" or "invalid token"
+			return
+		}
+
+		// Tokens issued before token IDs were introduced have no jti and cannot be revoked
+		if claims.ID != "" {
+			revoked, err := credentials.IsTokenRevoked(claims.ID)
+			if err != nil {
+				log.Printf("Auth middleware: Error checking revocation for token of %s: %v", claims.Username, err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, apperror.Response(c, apperror.CodeInternal, "Failed to verify token"))
+				return
+			}
+			if revoked {
+				log.Printf("Auth middleware: Revoked token used by %s", claims.Username)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, apperror.Response(c, apperror.CodeTokenRevoked, "token has been revoked"))
+				return
+			}
+		}
+
+		if claims.TwoFactorEnrollment && !allowEnrollment {
+			log.Printf("Auth middleware: Enrollment-only token used by %s outside enrollment", claims.Username)
+			c.AbortWithStatusJSON(http.StatusForbidden, apperror.Response(c, apperror.CodeTwoFactorEnrollmentRequired, "Two-factor enrollment required"))
+			return
+		}
+		if !hospitalStillExists(c, hospitals, claims.HospitalID) {
 			return
 		}
 
@@ -47,3 +110,78 @@ func AuthRequired() gin.HandlerFunc {
 		c.Next() // Proceed to the next handler
 	}
 }
+
+// BearerToken extracts the token from an Authorization header of the form "Bearer <token>".
+func BearerToken(authHeader string) (string, bool) {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// authenticateAPIKey verifies an X-API-Key header and stores the claims of its hospital
+// under the same context key as token claims.
+func authenticateAPIKey(c *gin.Context, keys services.APIKeyStore, hospitals HospitalChecker, apiKey string) {
+	claims, err := services.AuthenticateAPIKey(keys, apiKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKey), errors.Is(err, services.ErrAPIKeyExpired), errors.Is(err, services.ErrAPIKeyRevoked):
+			log.Printf("Auth middleware: API key rejected - %v", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apperror.Response(c, apperror.CodeInvalidAPIKey, err.Error()))
+		default:
+			log.Printf("Auth middleware: Error checking API key: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, apperror.Response(c, apperror.CodeInternal, "Failed to verify API key"))
+		}
+		return
+	}
+	if !hospitalStillExists(c, hospitals, claims.HospitalID) {
+		return
+	}
+
+	c.Set(ContextKeyClaims, claims)
+	log.Printf("Auth middleware: API key %d (Hospital: %d) authorized", claims.APIKeyID, claims.HospitalID)
+	c.Next()
+}
+
+// hospitalStillExists checks, when hospital validation is on, that the hospital a token or API key
+// was issued for has not been deleted since. Otherwise it aborts the request and returns false.
+func hospitalStillExists(c *gin.Context, hospitals HospitalChecker, hospitalID uint) bool {
+	if hospitals == nil {
+		return true
+	}
+	exists, err := hospitals.HospitalExists(hospitalID)
+	if err != nil {
+		log.Printf("Auth middleware: Error checking hospital %d: %v", hospitalID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, apperror.Response(c, apperror.CodeInternal, "Failed to verify token"))
+		return false
+	}
+	if !exists {
+		log.Printf("Auth middleware: Credentials of deleted hospital %d used", hospitalID)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, apperror.Response(c, apperror.CodeHospitalNoLongerValid, "hospital no longer valid"))
+		return false
+	}
+	return true
+}
+
+// AdminRequired is a middleware function that only lets admins through.
+// It must run after AuthRequired, which stores the claims it checks.
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claimsInterface, exists := c.Get(ContextKeyClaims)
+		claims, ok := claimsInterface.(*services.Claims)
+		if !exists || !ok {
+			log.Println("Admin middleware: Claims not found in context. AuthRequired might be missing.")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apperror.Response(c, apperror.CodeAuthRequired, "Authentication required"))
+			return
+		}
+
+		if !claims.IsAdmin() {
+			log.Printf("Admin middleware: User %s (ID: %d) denied, role %q", claims.Username, claims.UserID, claims.Role)
+			c.AbortWithStatusJSON(http.StatusForbidden, apperror.Response(c, apperror.CodeAdminRequired, "Admin privileges required"))
+			return
+		}
+
+		c.Next()
+	}
+}
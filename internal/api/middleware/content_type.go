@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"hospital-middleware/pkg/apperror"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireJSONContentType rejects POST, PUT and PATCH requests whose body is not declared as
+// application/json with 415. Requests without a body pass, as do unmatched routes, which get 404.
+// multipartRoutes lists route patterns (as reported by c.FullPath) that take file uploads instead.
+func RequireJSONContentType(multipartRoutes ...string) gin.HandlerFunc {
+	multipart := make(map[string]bool, len(multipartRoutes))
+	for _, route := range multipartRoutes {
+		multipart[route] = true
+	}
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		if route == "" || !hasBody(c.Request) {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		switch {
+		case err == nil && mediaType == "application/json":
+		case err == nil && mediaType == "multipart/form-data" && multipart[route]:
+		default:
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, apperror.Response(c, apperror.CodeUnsupportedMediaType, "Content-Type must be application/json"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasBody reports whether the request carries a body, either sized or chunked.
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || (r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody)
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETagger holds back the handler's response and tags a 200 with an ETag, the SHA-256 of the body.
+// A request whose If-None-Match lists that tag gets 304 Not Modified with no body instead.
+// The body is the serialized record, updated_at included, so the tag changes with every update.
+// Other statuses are passed through unchanged.
+func ETagger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		buffered := &bufferedResponseWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = buffered
+		c.Next()
+		c.Writer = buffered.ResponseWriter
+
+		if buffered.status != http.StatusOK {
+			c.Writer.WriteHeader(buffered.status)
+			writeBody(c, buffered.body.Bytes())
+			return
+		}
+
+		etag := BodyETag(buffered.body.Bytes())
+		c.Header("ETag", etag)
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			c.Writer.WriteHeader(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+		c.Writer.WriteHeader(http.StatusOK)
+		writeBody(c, buffered.body.Bytes())
+	}
+}
+
+// BodyETag returns the strong ETag of a response body: its quoted SHA-256.
+func BodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// IfMatchSatisfied reports whether an If-Match header allows a write to a resource whose current
+// tag is etag. RFC 9110 requires strong comparison here, so weak tags never match; "*" matches
+// any existing resource.
+func IfMatchSatisfied(ifMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBody sends a held-back body, logging a failure since the status is already out.
+func writeBody(c *gin.Context, body []byte) {
+	c.Writer.WriteHeaderNow()
+	if _, err := c.Writer.Write(body); err != nil {
+		log.Printf("Error writing response for %s: %v", c.Request.URL.Path, err)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing weakly as RFC 9110 requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedResponseWriter collects the status and body instead of sending them, so they can be
+// inspected once the handler is done. Headers still go straight to the underlying writer.
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int)              { w.status = code }
+func (w *bufferedResponseWriter) WriteHeaderNow()                   {}
+func (w *bufferedResponseWriter) Write(data []byte) (int, error)    { return w.body.Write(data) }
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) { return w.body.WriteString(s) }
+func (w *bufferedResponseWriter) Status() int                       { return w.status }
+func (w *bufferedResponseWriter) Size() int                         { return w.body.Len() }
+func (w *bufferedResponseWriter) Written() bool                     { return w.body.Len() > 0 }
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader carries the request ID in both directions.
+	RequestIDHeader = "X-Request-ID"
+	// ContextKeyRequestID is the key used to store the request ID in the Gin context.
+	ContextKeyRequestID = "requestID"
+
+	maxRequestIDLength = 64
+)
+
+// RequestID tags every request with an ID, echoed in the X-Request-ID response header.
+// A well-formed ID sent by the client (e.g. from a proxy) is kept; otherwise a random one is generated.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(ContextKeyRequestID, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestIDFromContext returns the ID set by RequestID, or "" if the middleware did not run.
+func RequestIDFromContext(c *gin.Context) string {
+	return c.GetString(ContextKeyRequestID)
+}
+
+// validRequestID accepts short IDs made of letters, digits, '-', '_' and '.', so a client
+// cannot inject arbitrary text into logs or response headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		log.Printf("Error generating request ID: %v", err)
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
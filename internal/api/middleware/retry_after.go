@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"hospital-middleware/pkg/apperror"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetryLaterResponse is the body of every 429 response. Its code is apperror.CodeRateLimited or
+// apperror.CodeAccountLocked, so clients can tell the two cases apart.
+type RetryLaterResponse struct {
+	apperror.ErrorResponse
+	RetryAfterSeconds int `json:"retry_after_seconds"`
+}
+
+// AbortWithRetryAfter aborts the request with 429 Too Many Requests, telling the client how long
+// to wait both in the Retry-After header and in the body. Rate limiting and account lockout both
+// respond through it so the front end can show one countdown. The wait is rounded as by
+// apperror.RetryAfterSeconds.
+func AbortWithRetryAfter(c *gin.Context, code apperror.Code, message string, retryAfter time.Duration) {
+	seconds := apperror.RetryAfterSeconds(retryAfter)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, RetryLaterResponse{
+		ErrorResponse:     apperror.Response(c, code, message),
+		RetryAfterSeconds: seconds,
+	})
+}
@@ -1,43 +1,185 @@
 package api
 
 import (
+	"expvar"
 	"hospital-middleware/internal/api/handlers"
 	"hospital-middleware/internal/api/middleware"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/internal/storage"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRouter configures the Gin router with all application routes.
-func SetupRouter() *gin.Engine {
-	// gin.SetMode(gin.ReleaseMode) // Uncomment for production
+// The repository and blob store are injected into the handlers so tests can supply their own, and
+// the async writers so the server can wait for them on shutdown.
+func SetupRouter(repo database.PatientRepository, blobs storage.BlobStore, cfg *config.Config, writers *services.AsyncWriters) *gin.Engine {
+	// Release mode also keeps Gin from printing the route table at startup
+	gin.SetMode(cfg.GinMode())
 	router := gin.Default()
+	router.HandleMethodNotAllowed = true
+	router.Use(middleware.RequestID()) // Global, so the NoRoute and NoMethod handlers see it too
+	h := handlers.NewHandler(repo, blobs, cfg, writers)
+	var hospitals middleware.HospitalChecker // nil leaves deleted hospitals' tokens valid until they expire
+	if cfg.ValidateHospitalOnRequest {
+		hospitals = services.NewHospitalExistenceCache(repo, services.HospitalExistenceTTL)
+	}
 
 	// Health Check Endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "UP"})
 	})
+	router.GET("/ready", handlers.ReadyHandler)
+	// expvar counters, including event_publish_failures, for admins; at the root like the health checks
+	router.GET("/metrics", middleware.AuthRequired(repo, hospitals), middleware.AdminRequired(), gin.WrapH(expvar.Handler()))
 
-	apiV1 := router.Group("/api/v1")
+	// Relocatable with API_BASE_PATH; the health checks and metrics above stay at the root
+	basePath := cfg.APIBasePath
+	if basePath == "" {
+		basePath = config.DefaultAPIBasePath
+	}
+	// Registered before any group so every JSON endpoint is covered; the upload routes take multipart forms
+	uploadPrefix := strings.TrimSuffix(basePath, "/") // A base path of "/" must not produce "//patient"
+	router.Use(middleware.RequireJSONContentType(
+		uploadPrefix+"/patient/:id/documents",
+		uploadPrefix+"/patient/:id/history/import",
+		uploadPrefix+"/admin/staff/import",
+	))
+	apiV1 := router.Group(basePath)
 	{
 		staffGroup := apiV1.Group("/staff")
 		{
-			staffGroup.POST("/create", handlers.CreateStaffHandler)
-			staffGroup.POST("/login", handlers.LoginStaffHandler)
+			staffGroup.POST("/create", h.CreateStaffHandler)
+			staffGroup.POST("/login", h.LoginStaffHandler)
+			staffGroup.POST("/logout", middleware.AuthRequired(repo, hospitals), h.LogoutHandler)
+			staffGroup.POST("/change-password", middleware.AuthRequired(repo, hospitals), h.ChangePasswordHandler)
+			staffGroup.PUT("/:id/role", middleware.AuthRequired(repo, hospitals), middleware.AdminRequired(), h.UpdateStaffRoleHandler)
+			staffGroup.POST("/2fa/enroll", middleware.EnrollmentAuthRequired(repo, hospitals), h.EnrollTwoFactorHandler)
+			staffGroup.POST("/2fa/confirm", middleware.EnrollmentAuthRequired(repo, hospitals), h.ConfirmTwoFactorHandler)
+			staffGroup.POST("/saved-searches", middleware.AuthRequired(repo, hospitals), h.CreateSavedSearchHandler)
+			staffGroup.GET("/saved-searches", middleware.AuthRequired(repo, hospitals), h.ListSavedSearchesHandler)
+			staffGroup.DELETE("/saved-searches/:id", middleware.AuthRequired(repo, hospitals), h.DeleteSavedSearchHandler)
+			staffGroup.GET("/saved-searches/:id/run", middleware.AuthRequired(repo, hospitals), h.RunSavedSearchHandler) // Accepts hospital_id like /patient/search
+			staffGroup.GET("/recently-viewed", middleware.AuthRequired(repo, hospitals), h.ListRecentlyViewedHandler)
 		}
 
+		// For gateways: checks a bearer token's signature and expiry only, with no database lookup
+		apiV1.GET("/auth/verify", handlers.VerifyTokenHandler)
+
+		// Public: needed by the login screen before a token exists
+		apiV1.GET("/hospitals", h.ListHospitalsHandler)
+
 		patientGroup := apiV1.Group("/patient")
 		{
 			// Apply authentication middleware ONLY to routes that require login
-			patientGroup.Use(middleware.AuthRequired()) // Apply to all routes within this group
-			patientGroup.GET("/search", handlers.SearchPatientHandler)
+			patientGroup.Use(middleware.AuthRequired(repo, hospitals)) // Apply to all routes within this group
+			patientGroup.DELETE("", middleware.AdminRequired(), h.DeleteHospitalPatientsHandler)
+			patientGroup.GET("/search", h.SearchPatientHandler)
+			patientGroup.POST("/search/batch-hn", h.BatchHNSearchHandler)
+			patientGroup.GET("/export", middleware.AdminRequired(), h.ExportPatientsHandler)
+			patientGroup.GET("/duplicates", middleware.AdminRequired(), h.ListDuplicatePatientsHandler)
+			patientGroup.GET("/tags", h.ListTagsHandler)
+			patientGroup.GET("/updates", h.PatientUpdatesHandler)
+			patientGroup.GET("/by-national-id/:nid", h.GetPatientByNationalIDHandler)
+			patientGroup.GET("/:id", middleware.ETagger(), h.GetPatientHandler) // ?include=allergies
+			patientGroup.PATCH("/:id", h.UpdatePatientHandler)
+			patientGroup.POST("/:id/transfer", middleware.AdminRequired(), h.TransferPatientHandler)
+			patientGroup.PATCH("/:id/status", h.UpdatePatientStatusHandler)
+			patientGroup.POST("/:id/visits", h.CreateVisitHandler)
+			patientGroup.GET("/:id/visits", h.ListPatientVisitsHandler)
+			patientGroup.POST("/:id/admissions", h.CreateAdmissionHandler)
+			patientGroup.GET("/:id/admissions", h.ListAdmissionsHandler)
+			patientGroup.PUT("/:id/admissions/:admission_id/discharge", h.DischargeAdmissionHandler)
+			patientGroup.POST("/:id/diagnoses", h.CreateDiagnosisHandler)
+			patientGroup.GET("/:id/diagnoses", h.ListDiagnosesHandler)
+			patientGroup.POST("/:id/allergies", h.UpsertAllergyHandler)
+			patientGroup.GET("/:id/allergies", h.ListAllergiesHandler)
+			patientGroup.PUT("/:id/allergies/:allergy_id", h.UpdateAllergyHandler)
+			patientGroup.DELETE("/:id/allergies/:allergy_id", h.DeleteAllergyHandler)
+			patientGroup.POST("/:id/consent", h.RecordConsentHandler)
+			patientGroup.GET("/:id/consent", h.ListConsentsHandler)
+			patientGroup.PUT("/:id/consent", middleware.AdminRequired(), h.UpdatePatientConsentHandler)
+			patientGroup.POST("/:id/notes", h.CreatePatientNoteHandler)
+			patientGroup.GET("/:id/notes", h.ListPatientNotesHandler)
+			patientGroup.PUT("/:id/notes/:note_id", h.UpdatePatientNoteHandler)
+			patientGroup.DELETE("/:id/notes/:note_id", h.DeletePatientNoteHandler)
+			patientGroup.POST("/:id/documents", h.UploadPatientDocumentHandler)
+			patientGroup.GET("/:id/documents", h.ListPatientDocumentsHandler)
+			patientGroup.GET("/:id/documents/:document_id/download", h.DownloadPatientDocumentHandler)
+			patientGroup.DELETE("/:id/documents/:document_id", middleware.AdminRequired(), h.DeletePatientDocumentHandler)
+			patientGroup.POST("/:id/labels/:label_id", h.AssignPatientLabelHandler)
+			patientGroup.DELETE("/:id/labels/:label_id", h.UnassignPatientLabelHandler)
+			patientGroup.POST("/:id/tags", h.AddPatientTagHandler)
+			patientGroup.DELETE("/:id/tags/:tag", h.RemovePatientTagHandler)
+			patientGroup.POST("/:id/history/import", h.ImportPatientHistoryHandler)
+			patientGroup.PATCH("/:id/history/import", h.ApplyPatientHistoryHandler)
+			patientGroup.GET("/:id/summary.pdf", h.GetPatientSummaryHandler)
+			patientGroup.GET("/:id/audit", middleware.AdminRequired(), h.ListPatientAuditHandler)
+		}
+
+		adminGroup := apiV1.Group("/admin")
+		{
+			adminGroup.Use(middleware.AuthRequired(repo, hospitals), middleware.AdminRequired())
+			adminGroup.GET("/hospital/:id/config", h.GetHospitalConfigHandler)
+			adminGroup.PUT("/hospital/:id/config", h.UpdateHospitalConfigHandler)
+			adminGroup.PUT("/hospital/:id/features/:feature", h.SetHospitalFeatureHandler)
+			adminGroup.POST("/staff", h.CreateStaffHandler) // Like /staff/create, but a 409 names the existing account
+			adminGroup.POST("/staff/import", h.ImportStaffHandler)
+			adminGroup.GET("/staff/export", h.ExportStaffHandler)
+			adminGroup.GET("/jobs", h.ListJobsHandler)
+			adminGroup.GET("/retention/dry-run", h.RetentionDryRunHandler)
+			adminGroup.GET("/patient/duplicates", h.ListDuplicatePatientsHandler) // Also at /patient/duplicates; ?hospital_id= for super admins
+			adminGroup.GET("/reports/monthly", h.GetMonthlyReportHandler)         // ?year=&month=; ?hospital_id= for super admins
+			adminGroup.GET("/patient/:id/access-log", h.ListPatientAccessLogHandler)
+			adminGroup.GET("/staff/:id/access-log", h.ListStaffAccessLogHandler)
+			adminGroup.POST("/api-keys", h.CreateAPIKeyHandler)
+			adminGroup.DELETE("/api-keys/:id", h.RevokeAPIKeyHandler)
+			adminGroup.POST("/webhooks", h.CreateWebhookHandler)
+			adminGroup.GET("/webhooks", h.ListWebhooksHandler)
+			adminGroup.GET("/webhooks/:id", h.GetWebhookHandler)
+			adminGroup.PUT("/webhooks/:id", h.UpdateWebhookHandler)
+			adminGroup.DELETE("/webhooks/:id", h.DeleteWebhookHandler)
+			adminGroup.POST("/webhook/test", h.TestWebhookHandler)
+			adminGroup.POST("/label", h.CreatePatientLabelHandler)
+			adminGroup.GET("/label", h.ListPatientLabelsHandler)
+			adminGroup.GET("/label/:id", h.GetPatientLabelHandler)
+			adminGroup.PUT("/label/:id", h.UpdatePatientLabelHandler)
+			adminGroup.DELETE("/label/:id", h.DeletePatientLabelHandler)
+			adminGroup.DELETE("/tag/:tag", h.DeleteTagHandler)
+		}
+
+		visitGroup := apiV1.Group("/visits")
+		{
+			visitGroup.Use(middleware.AuthRequired(repo, hospitals))
+			visitGroup.GET("", h.ListDailyVisitsHandler) // ?date=YYYY-MM-DD
+		}
+
+		apiV1.GET("/icd10", middleware.AuthRequired(repo, hospitals), h.SearchICD10Handler) // ?q=
+		apiV1.POST("/utils/validate-national-ids", middleware.AuthRequired(repo, hospitals), h.ValidateNationalIDsHandler)
+
+		referralGroup := apiV1.Group("/referral")
+		{
+			referralGroup.Use(middleware.AuthRequired(repo, hospitals))
+			referralGroup.POST("", h.CreateReferralHandler)
+			referralGroup.GET("/:id", h.GetReferralHandler)
+			referralGroup.PUT("/:id/accept", h.AcceptReferralHandler)
+			referralGroup.PUT("/:id/reject", h.RejectReferralHandler)
+		}
+
+		wardGroup := apiV1.Group("/ward")
+		{
+			wardGroup.Use(middleware.AuthRequired(repo, hospitals))
+			wardGroup.GET("/:ward/current", h.WardCensusHandler)
 		}
 	}
 
-	// Handle 404 Not Found routes
-	router.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Resource not found"})
-	})
+	// Handle 404 Not Found routes and 405 for known paths with the wrong method
+	router.NoRoute(handlers.NotFoundHandler)
+	router.NoMethod(handlers.MethodNotAllowedHandler)
 
 	return router
 }
@@ -0,0 +1,153 @@
+package background
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/jobs"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/storage"
+	"log"
+	"time"
+)
+
+// PurgeExpiredRevokedTokens returns a task that deletes revocations of tokens that have expired.
+// An expired token is rejected by its signature check anyway, so the row is no longer needed.
+func PurgeExpiredRevokedTokens(repo database.PatientRepository, interval time.Duration) Task {
+	return Task{
+		Name:     "PurgeExpiredRevokedTokens",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			deleted, err := repo.DeleteExpiredRevokedTokens(now)
+			if err != nil {
+				return err
+			}
+			log.Printf("Purged %d expired revoked token(s)", deleted)
+			return nil
+		},
+	}
+}
+
+// PurgeOldSearchHistory returns a task that deletes search history older than retentionDays.
+func PurgeOldSearchHistory(repo database.PatientRepository, retentionDays int, interval time.Duration) Task {
+	return Task{
+		Name:     "PurgeOldSearchHistory",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			deleted, err := repo.DeleteSearchHistoryBefore(now.AddDate(0, 0, -retentionDays))
+			if err != nil {
+				return err
+			}
+			log.Printf("Purged %d search history row(s) older than %d days", deleted, retentionDays)
+			return nil
+		},
+	}
+}
+
+// PurgeAccessLogJob is the type of the background job that deletes old patient access log entries.
+const PurgeAccessLogJob = "purge_patient_access_log"
+
+// accessLogPurge is the payload of a PurgeAccessLogJob. The cutoff is fixed when the job is
+// queued, so a retry deletes the same entries.
+type accessLogPurge struct {
+	Before time.Time `json:"before"`
+}
+
+// EnqueuePatientAccessLogPurge returns a task that queues a PurgeAccessLogJob for entries older
+// than retentionDays. The purge itself runs on a job worker, which retries it if it fails.
+func EnqueuePatientAccessLogPurge(repo database.PatientRepository, retentionDays int, interval time.Duration) Task {
+	return Task{
+		Name:     "EnqueuePatientAccessLogPurge",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			_, err := jobs.Enqueue(repo, PurgeAccessLogJob, accessLogPurge{Before: now.AddDate(0, 0, -retentionDays)}, 0)
+			return err
+		},
+	}
+}
+
+// PurgePatientAccessLog returns the handler of PurgeAccessLogJob.
+func PurgePatientAccessLog(repo database.PatientRepository) jobs.Handler {
+	return func(_ context.Context, payload json.RawMessage) error {
+		var purge accessLogPurge
+		if err := json.Unmarshal(payload, &purge); err != nil {
+			return fmt.Errorf("decoding %s payload: %w", PurgeAccessLogJob, err)
+		}
+		deleted, err := repo.DeletePatientAccessLogBefore(purge.Before)
+		if err != nil {
+			return err
+		}
+		log.Printf("Purged %d patient access log row(s) from before %s", deleted, purge.Before.Format(time.RFC3339))
+		return nil
+	}
+}
+
+// RetentionPurgeJob is the type of the background job that deletes records past their retention
+// period: soft-deleted patients with their records, and old logout revocations.
+const RetentionPurgeJob = "retention_purge"
+
+// EnqueueRetentionPurge returns a task that queues a RetentionPurgeJob with the cutoffs of the
+// retention periods, in days. The cutoffs are fixed when the job is queued, so a retry deletes
+// the same records.
+func EnqueueRetentionPurge(repo database.PatientRepository, patientDays, revokedTokenDays int, interval time.Duration) Task {
+	return Task{
+		Name:     "EnqueueRetentionPurge",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			_, err := jobs.Enqueue(repo, RetentionPurgeJob, models.NewRetentionCutoffs(now, patientDays, revokedTokenDays), 0)
+			return err
+		},
+	}
+}
+
+// RetentionPurge returns the handler of RetentionPurgeJob. It deletes batchSize rows per
+// transaction until nothing past the cutoffs is left, so a failed or interrupted run keeps the
+// batches it finished. Documents of purged patients are removed from blobs; a blob that cannot be
+// removed is logged, as its row is gone.
+func RetentionPurge(repo database.PatientRepository, blobs storage.BlobStore, batchSize int) jobs.Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var cutoffs models.RetentionCutoffs
+		if err := json.Unmarshal(payload, &cutoffs); err != nil {
+			return fmt.Errorf("decoding %s payload: %w", RetentionPurgeJob, err)
+		}
+
+		var patients int64
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			deleted, storageKeys, err := repo.PurgeSoftDeletedPatients(cutoffs.PatientsDeletedBefore, batchSize)
+			if err != nil {
+				return err
+			}
+			for _, key := range storageKeys {
+				if err := blobs.Delete(key); err != nil {
+					log.Printf("Error deleting document blob %s of a purged patient: %v", key, err)
+				}
+			}
+			patients += deleted
+			if deleted < int64(batchSize) {
+				break
+			}
+		}
+		log.Printf("Purged %d patient(s) soft-deleted before %s", patients, cutoffs.PatientsDeletedBefore.Format(time.RFC3339))
+
+		var tokens int64
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			deleted, err := repo.PurgeRevokedTokens(cutoffs, batchSize)
+			if err != nil {
+				return err
+			}
+			tokens += deleted
+			if deleted < int64(batchSize) {
+				break
+			}
+		}
+		log.Printf("Purged %d revoked token(s) from before %s", tokens, cutoffs.TokensRevokedBefore.Format(time.RFC3339))
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+// Package background runs periodic maintenance tasks alongside the HTTP server.
+package background
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Clock tells tasks what time it is. Tests substitute a fixed clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock backed by time.Now.
+type SystemClock struct{}
+
+// Now returns the current time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Task is a unit of periodic work. Run receives the scheduler clock's current time.
+type Task struct {
+	Name     string
+	Interval time.Duration
+	Run      func(now time.Time) error
+}
+
+// Scheduler runs registered tasks on their own intervals until its context is cancelled.
+type Scheduler struct {
+	clock Clock
+	tasks []Task
+}
+
+// NewScheduler creates a Scheduler that reads the time from clock.
+func NewScheduler(clock Clock) *Scheduler {
+	return &Scheduler{clock: clock}
+}
+
+// Register adds a task. Tasks must be registered before Run is called.
+func (s *Scheduler) Register(task Task) {
+	s.tasks = append(s.tasks, task)
+}
+
+// Run starts every task and blocks until ctx is cancelled and all in-flight runs have finished.
+// Each task runs once immediately, so a frequently restarted server still gets its cleanup,
+// and then once per interval.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, task := range s.tasks {
+		wg.Add(1)
+		go func(task Task) {
+			defer wg.Done()
+			s.loop(ctx, task)
+		}(task)
+	}
+	log.Printf("Background scheduler started with %d task(s)", len(s.tasks))
+	wg.Wait()
+	log.Println("Background scheduler stopped")
+}
+
+func (s *Scheduler) loop(ctx context.Context, task Task) {
+	ticker := time.NewTicker(task.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := task.Run(s.clock.Now()); err != nil {
+			log.Printf("Background task %s failed: %v", task.Name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
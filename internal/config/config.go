@@ -1,10 +1,17 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+	_ "time/tzdata" // TIMEZONE must load even where the system has no zoneinfo, as in the Alpine image
 
 	"github.com/joho/godotenv"
 )
@@ -16,10 +23,77 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
-	DBSSLMode  string
+	DBSSLMode  string // One of the DBSSLMode constants
 	JWTSecret  string
 	JWTExpiry  time.Duration
+	// Optional JWT_KEYS, secrets by key ID, which replace JWT_SECRET so the signing key can be rotated.
+	// New tokens are signed with JWTKeyID; tokens of every listed key still validate.
+	JWTKeys    map[string]string
+	JWTKeyID   string
 	ServerPort string
+	// Path the API routes are served under; /health and /ready stay at the root
+	APIBasePath string
+	AppEnv      string // One of the AppEnv constants; decides the Gin mode
+	AutoMigrate bool   // Whether the server migrates the schema on start; off when the seed command does it
+	Timezone    string // IANA time zone of the database session and of dates of birth in searches
+
+	// Optional client certificate and key, and the CA the server certificate is checked against
+	DBSSLCert     string
+	DBSSLKey      string
+	DBSSLRootCert string
+
+	DBReplicaDSN string // Optional read replica for patient searches and staff lookups; "" reads everything from the primary
+
+	EventBrokerURL string // NATS server patient change events are published to; "" publishes nothing
+
+	PasswordPolicy PasswordPolicy
+
+	DocumentStorageDir string // Root directory of the local-disk document store
+	DocumentMaxBytes   int64  // Largest accepted document upload
+
+	DefaultPageSize int // Used by list endpoints when page_size is not given
+	MaxPageSize     int // Larger page_size values are clamped to this, not rejected
+
+	SearchMaxResults int // Hard cap on patient search results; hospitals may configure a lower one
+
+	NationalIDValidateMax int // Most IDs one POST /utils/validate-national-ids request may check
+
+	SearchCache    string        // One of the SearchCache constants; "" caches nothing
+	SearchCacheTTL time.Duration // How long a cached search result is served
+	RedisURL       string        // redis://[:password@]host:port[/db], for SEARCH_CACHE=redis
+
+	EnforceConsentOnExport bool // Leave patients who denied consent out of patient exports
+
+	ValidateHospitalOnRequest bool // Reject tokens and API keys whose hospital no longer exists
+
+	AutoGenerateHN bool // Give patients created without an HN one made from their hospital's code
+
+	ICD10CodesPath string // CSV loaded into the empty ICD-10 table at migration; "" uses the bundled starter set
+
+	SummaryFontPath string        // TrueType font with Thai glyphs embedded in patient summary PDFs; "" turns summaries off
+	SummaryTimeout  time.Duration // Longest a patient summary PDF may take to render
+
+	JobWorkers int // Background job workers; each runs one job at a time
+
+	CleanupInterval            time.Duration // How often the background cleanup tasks run
+	SearchHistoryRetentionDays int           // Search history older than this is purged
+
+	AccessLogSearchResults bool // Log every patient a search returns, not only patients opened on their own
+	AccessLogRetentionDays int  // Patient access log entries older than this are purged
+
+	PatientRetentionDays      int // Soft-deleted patients are hard-deleted, with their records, this long after deletion
+	RevokedTokenRetentionDays int // Logout revocations older than this are purged once their token expired
+	RetentionPurgeBatchSize   int // Rows one retention purge transaction deletes
+}
+
+// PasswordPolicy describes the rules a new staff password must satisfy.
+// A zero value accepts any password.
+type PasswordPolicy struct {
+	MinLength          int
+	RequireUppercase   bool
+	RequireLowercase   bool
+	RequireDigit       bool
+	RequireSpecialChar bool
 }
 
 // Load loads configuration from environment variables or a .env file.
@@ -37,22 +111,138 @@ func Load() (*Config, error) {
 		jwtExpiryHours = 24
 	}
 
-	cfg := &Config{
-		DBHost:     getEnv("DB_HOST", "db"), // Default to docker-compose service name
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "password"),
-		DBName:     getEnv("DB_NAME", "hospital_db"),
-		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
-		JWTSecret:  getEnv("JWT_SECRET", "a_very_secret_key"),
-		JWTExpiry:  time.Hour * time.Duration(jwtExpiryHours),
-		ServerPort: getEnv("SERVER_PORT", "8080"), // Port the Go app listens on internally
+	jwtKeys, err := parseJWTKeys(getEnv("JWT_KEYS", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	defaultPageSize, err := getEnvPositiveInt("DEFAULT_PAGE_SIZE", 20)
+	if err != nil {
+		return nil, err
+	}
+	maxPageSize, err := getEnvPositiveInt("MAX_PAGE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+	if defaultPageSize > maxPageSize {
+		return nil, fmt.Errorf("DEFAULT_PAGE_SIZE (%d) must not exceed MAX_PAGE_SIZE (%d)", defaultPageSize, maxPageSize)
+	}
+
+	searchMaxResults, err := getEnvPositiveInt("SEARCH_MAX_RESULTS", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	nationalIDValidateMax, err := getEnvPositiveInt("NATIONAL_ID_VALIDATE_MAX", 500)
+	if err != nil {
+		return nil, err
+	}
+
+	searchCacheTTLSeconds, err := getEnvPositiveInt("SEARCH_CACHE_TTL_SECONDS", 15)
+	if err != nil {
+		return nil, err
+	}
+
+	summaryTimeoutSeconds, err := getEnvPositiveInt("SUMMARY_TIMEOUT_SECONDS", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	jobWorkers, err := getEnvPositiveInt("JOB_WORKERS", 2)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanupIntervalHours, err := getEnvPositiveInt("CLEANUP_INTERVAL_HOURS", 24)
+	if err != nil {
+		return nil, err
+	}
+	searchHistoryRetentionDays, err := getEnvPositiveInt("SEARCH_HISTORY_RETENTION_DAYS", 90)
+	if err != nil {
+		return nil, err
+	}
+	accessLogRetentionDays, err := getEnvPositiveInt("ACCESS_LOG_RETENTION_DAYS", 1825)
+	if err != nil {
+		return nil, err
+	}
+	patientRetentionDays, err := getEnvPositiveInt("PATIENT_RETENTION_DAYS", 2557)
+	if err != nil {
+		return nil, err
+	}
+	revokedTokenRetentionDays, err := getEnvPositiveInt("REVOKED_TOKEN_RETENTION_DAYS", 30)
+	if err != nil {
+		return nil, err
+	}
+	retentionPurgeBatchSize, err := getEnvPositiveInt("RETENTION_PURGE_BATCH_SIZE", 500)
+	if err != nil {
+		return nil, err
 	}
 
-	// Basic validation
-	if cfg.JWTSecret == "a_very_secret_key" {
-		log.Println("WARNING: JWT_SECRET is set to the default insecure value. Set a strong secret in your environment.")
+	apiBasePath, err := normalizeBasePath(getEnv("API_BASE_PATH", DefaultAPIBasePath))
+	if err != nil {
+		return nil, err
+	}
+
+	appEnv, err := resolveAppEnv(getEnv("APP_ENV", ""), getEnv("GIN_MODE", ""))
+	if err != nil {
+		return nil, err
 	}
+
+	cfg := &Config{
+		DBHost:      getEnv("DB_HOST", "db"), // Default to docker-compose service name
+		DBPort:      getEnv("DB_PORT", "5432"),
+		DBUser:      getEnv("DB_USER", "postgres"),
+		DBPassword:  getEnv("DB_PASSWORD", "password"),
+		DBName:      getEnv("DB_NAME", "hospital_db"),
+		DBSSLMode:   getEnv("DB_SSLMODE", DBSSLModeDisable),
+		AutoMigrate: getEnvBool("AUTO_MIGRATE", true),
+		Timezone:    getEnv("TIMEZONE", DefaultTimezone),
+		JWTSecret:   getEnv("JWT_SECRET", defaultJWTSecret),
+		JWTExpiry:   time.Hour * time.Duration(jwtExpiryHours),
+		JWTKeys:     jwtKeys,
+		JWTKeyID:    getEnv("JWT_KEY_ID", ""),
+		ServerPort:  getEnv("SERVER_PORT", "8080"), // Port the Go app listens on internally
+		APIBasePath: apiBasePath,
+		AppEnv:      appEnv,
+		PasswordPolicy: PasswordPolicy{
+			MinLength:          getEnvInt("PASSWORD_MIN_LENGTH", 8),
+			RequireUppercase:   getEnvBool("PASSWORD_REQUIRE_UPPERCASE", false),
+			RequireLowercase:   getEnvBool("PASSWORD_REQUIRE_LOWERCASE", false),
+			RequireDigit:       getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSpecialChar: getEnvBool("PASSWORD_REQUIRE_SPECIAL_CHAR", false),
+		},
+		DocumentStorageDir:    getEnv("DOCUMENT_STORAGE_PATH", getEnv("DOCUMENT_STORAGE_DIR", "data/documents")), // _DIR is the older name
+		DocumentMaxBytes:      int64(getEnvInt("DOCUMENT_MAX_SIZE_MB", 20)) << 20,
+		DefaultPageSize:       defaultPageSize,
+		MaxPageSize:           maxPageSize,
+		SearchMaxResults:      searchMaxResults,
+		NationalIDValidateMax: nationalIDValidateMax,
+		SearchCache:           getEnv("SEARCH_CACHE", SearchCacheNone),
+		SearchCacheTTL:        time.Second * time.Duration(searchCacheTTLSeconds),
+		RedisURL:              getEnv("REDIS_URL", ""),
+		ICD10CodesPath:        getEnv("ICD10_CODES_PATH", ""),
+		DBSSLCert:             getEnv("DB_SSL_CERT", ""),
+		DBSSLKey:              getEnv("DB_SSL_KEY", ""),
+		DBSSLRootCert:         getEnv("DB_SSL_ROOT_CERT", ""),
+		DBReplicaDSN:          getEnv("DB_REPLICA_DSN", ""),
+		EventBrokerURL:        getEnv("EVENT_BROKER_URL", ""),
+		SummaryFontPath:       getEnv("SUMMARY_FONT_PATH", ""),
+		SummaryTimeout:        time.Second * time.Duration(summaryTimeoutSeconds),
+
+		JobWorkers:                 jobWorkers,
+		CleanupInterval:            time.Hour * time.Duration(cleanupIntervalHours),
+		SearchHistoryRetentionDays: searchHistoryRetentionDays,
+		AccessLogSearchResults:     getEnvBool("ACCESS_LOG_SEARCH_RESULTS", true),
+		AccessLogRetentionDays:     accessLogRetentionDays,
+		PatientRetentionDays:       patientRetentionDays,
+		RevokedTokenRetentionDays:  revokedTokenRetentionDays,
+		RetentionPurgeBatchSize:    retentionPurgeBatchSize,
+		EnforceConsentOnExport:     getEnvBool("ENFORCE_CONSENT_ON_EXPORT", false),
+		ValidateHospitalOnRequest:  getEnvBool("VALIDATE_HOSPITAL_ON_REQUEST", false),
+		AutoGenerateHN:             getEnvBool("AUTO_GENERATE_HN", false),
+	}
+
+	// Basic validation. Values the server cannot run with are rejected by Validate.
 	if cfg.DBPassword == "password" {
 		log.Println("WARNING: DB_PASSWORD is set to a weak default value. Set a strong password in your environment.")
 	}
@@ -60,6 +250,184 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// MinJWTSecretLength is the shortest JWT secret Validate accepts. HS256 keys should be at least 256 bits.
+const MinJWTSecretLength = 32
+
+// Validate checks the values the server cannot run without and returns one message per problem,
+// so a misconfigured deployment reports everything at once instead of failing later at runtime.
+// An empty result means the configuration is usable.
+func Validate(cfg *Config) []string {
+	var problems []string
+	if len(cfg.JWTKeys) == 0 {
+		if len(cfg.JWTSecret) < MinJWTSecretLength {
+			problems = append(problems, fmt.Sprintf("JWT_SECRET must be at least %d characters long, got %d", MinJWTSecretLength, len(cfg.JWTSecret)))
+		}
+	} else {
+		if _, ok := cfg.JWTKeys[cfg.JWTKeyID]; !ok {
+			problems = append(problems, fmt.Sprintf("JWT_KEY_ID must name one of the JWT_KEYS, got %q", cfg.JWTKeyID))
+		}
+		keyIDs := make([]string, 0, len(cfg.JWTKeys))
+		for keyID := range cfg.JWTKeys {
+			keyIDs = append(keyIDs, keyID)
+		}
+		sort.Strings(keyIDs)
+		for _, keyID := range keyIDs {
+			if secret := cfg.JWTKeys[keyID]; len(secret) < MinJWTSecretLength {
+				problems = append(problems, fmt.Sprintf("JWT_KEYS secret %q must be at least %d characters long, got %d", keyID, MinJWTSecretLength, len(secret)))
+			}
+		}
+	}
+	if port, err := strconv.Atoi(cfg.ServerPort); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("SERVER_PORT must be a port number between 1 and 65535, got %q", cfg.ServerPort))
+	}
+	if strings.TrimSpace(cfg.DBHost) == "" {
+		problems = append(problems, "DB_HOST must not be empty")
+	}
+	switch cfg.DBSSLMode {
+	case DBSSLModeDisable, DBSSLModeRequire, DBSSLModeVerifyCA, DBSSLModeVerifyFull:
+	default:
+		problems = append(problems, fmt.Sprintf("DB_SSLMODE must be one of disable, require, verify-ca or verify-full, got %q", cfg.DBSSLMode))
+	}
+	if (cfg.DBSSLCert == "") != (cfg.DBSSLKey == "") {
+		problems = append(problems, "DB_SSL_CERT and DB_SSL_KEY must be set together")
+	}
+	if cfg.JWTExpiry <= 0 {
+		problems = append(problems, fmt.Sprintf("JWT_EXPIRY_HOURS must be positive, got %v", cfg.JWTExpiry))
+	}
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil || cfg.Timezone == "" {
+		problems = append(problems, fmt.Sprintf("TIMEZONE must be an IANA time zone such as Asia/Bangkok, got %q", cfg.Timezone))
+	}
+	switch cfg.SearchCache {
+	case SearchCacheNone, SearchCacheMemory:
+	case SearchCacheRedis:
+		if u, err := url.Parse(cfg.RedisURL); err != nil || u.Scheme != "redis" || u.Hostname() == "" {
+			problems = append(problems, fmt.Sprintf("REDIS_URL must be a redis://host:port URL when SEARCH_CACHE is redis, got %q", cfg.RedisURL))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("SEARCH_CACHE must be memory, redis or empty, got %q", cfg.SearchCache))
+	}
+	if cfg.EventBrokerURL != "" {
+		if u, err := url.Parse(cfg.EventBrokerURL); err != nil || u.Scheme != "nats" || u.Hostname() == "" {
+			problems = append(problems, fmt.Sprintf("EVENT_BROKER_URL must be a nats://host:port URL, got %q", cfg.EventBrokerURL))
+		}
+	}
+	return problems
+}
+
+// Accepted DB_SSLMODE values. verify-ca checks the server certificate against DB_SSL_ROOT_CERT
+// (or the system roots); verify-full also checks that it names DB_HOST.
+const (
+	DBSSLModeDisable    = "disable"
+	DBSSLModeRequire    = "require"
+	DBSSLModeVerifyCA   = "verify-ca"
+	DBSSLModeVerifyFull = "verify-full"
+)
+
+// Accepted SEARCH_CACHE values.
+const (
+	SearchCacheNone   = ""
+	SearchCacheMemory = "memory" // Per server instance
+	SearchCacheRedis  = "redis"  // Shared by every instance using REDIS_URL
+)
+
+// DefaultTimezone is used when TIMEZONE is not set.
+const DefaultTimezone = "Asia/Bangkok"
+
+// DefaultAPIBasePath is where the API is served when API_BASE_PATH is not set.
+const DefaultAPIBasePath = "/api/v1"
+
+// normalizeBasePath checks that an API base path is absolute and drops any trailing slash,
+// so "/hospital/" and "/hospital" serve the same routes. "/" serves the API at the root.
+func normalizeBasePath(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("API_BASE_PATH must start with \"/\", got %q", path)
+	}
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed, nil
+	}
+	return "/", nil
+}
+
+// Deployment environments accepted in APP_ENV.
+const (
+	AppEnvDevelopment = "development"
+	AppEnvTest        = "test"
+	AppEnvProduction  = "production"
+)
+
+// resolveAppEnv validates APP_ENV. When it is unset, the environment is inferred from GIN_MODE,
+// so deployments that only set GIN_MODE=release keep running in release mode.
+func resolveAppEnv(appEnv, ginMode string) (string, error) {
+	switch appEnv {
+	case AppEnvDevelopment, AppEnvTest, AppEnvProduction:
+		return appEnv, nil
+	case "":
+	default:
+		return "", fmt.Errorf("APP_ENV must be one of development, test, production, got %q", appEnv)
+	}
+	switch ginMode {
+	case "release":
+		return AppEnvProduction, nil
+	case "test":
+		return AppEnvTest, nil
+	}
+	return AppEnvDevelopment, nil
+}
+
+// GinMode returns the Gin mode for the environment: release in production, test in test,
+// and debug otherwise.
+func (c *Config) GinMode() string {
+	switch c.AppEnv {
+	case AppEnvProduction:
+		return "release"
+	case AppEnvTest:
+		return "test"
+	}
+	return "debug"
+}
+
+// defaultJWTSecret is the fallback JWT_SECRET, only fit for local development.
+const defaultJWTSecret = "a_very_secret_key"
+
+// JWTSecretInsecure reports whether the JWT secret is empty or the built-in default.
+// Tokens still sign and verify, but anyone who knows the default can forge them.
+// JWT_SECRET is not used when JWT_KEYS is set, so it cannot be insecure then.
+func (c *Config) JWTSecretInsecure() bool {
+	return len(c.JWTKeys) == 0 && (c.JWTSecret == "" || c.JWTSecret == defaultJWTSecret)
+}
+
+// DefaultJWTKeyID is the key ID of JWT_SECRET. Tokens without a kid header, issued before key IDs
+// existed, are validated with the key of this ID, so listing the old JWT_SECRET under it in
+// JWT_KEYS keeps them valid while switching over.
+const DefaultJWTKeyID = "default"
+
+// JWTSigningKeys returns the secrets tokens are validated with, by key ID, and the ID of the one
+// new tokens are signed with. Without JWT_KEYS, JWT_SECRET is the only key, under DefaultJWTKeyID.
+func (c *Config) JWTSigningKeys() (map[string]string, string) {
+	if len(c.JWTKeys) == 0 {
+		return map[string]string{DefaultJWTKeyID: c.JWTSecret}, DefaultJWTKeyID
+	}
+	return c.JWTKeys, c.JWTKeyID
+}
+
+// parseJWTKeys reads JWT_KEYS, a JSON object of key IDs to secrets such as
+// {"2024-06": "...", "2025-01": "..."}. Unset or empty means no keys.
+func parseJWTKeys(raw string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var keys map[string]string
+	if err := json.Unmarshal([]byte(raw), &keys); err != nil {
+		return nil, fmt.Errorf("JWT_KEYS must be a JSON object of key IDs to secrets: %w", err)
+	}
+	for keyID := range keys {
+		if keyID == "" {
+			return nil, errors.New("JWT_KEYS must not have an empty key ID")
+		}
+	}
+	return keys, nil
+}
+
 // Helper function to get environment variables or return a default value.
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -67,3 +435,36 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvInt reads an integer environment variable, falling back to the default when unset or invalid.
+func getEnvInt(key string, fallback int) int {
+	valueStr := getEnv(key, strconv.Itoa(fallback))
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		log.Printf("Invalid %s value: %s. Using default %d.", key, valueStr, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool reads a boolean environment variable, falling back to the default when unset or invalid.
+func getEnvBool(key string, fallback bool) bool {
+	valueStr := getEnv(key, strconv.FormatBool(fallback))
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		log.Printf("Invalid %s value: %s. Using default %t.", key, valueStr, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvPositiveInt reads a required-to-be-positive integer. Unlike getEnvInt, a bad value
+// is a startup error rather than a silent fallback.
+func getEnvPositiveInt(key string, fallback int) (int, error) {
+	valueStr := getEnv(key, strconv.Itoa(fallback))
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", key, valueStr)
+	}
+	return value, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Admission Specific Functions ---
+
+// CreateAdmission inserts a new admission. It fails with a unique violation if the patient is already admitted.
+func CreateAdmission(admission *models.Admission) error {
+	return DB.Create(admission).Error
+}
+
+// GetAdmission retrieves a single admission belonging to the given patient.
+func GetAdmission(patientID, admissionID uint) (*models.Admission, error) {
+	var admission models.Admission
+	result := DB.Where("patient_id = ?", patientID).First(&admission, admissionID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &admission, nil
+}
+
+// DischargeAdmission marks an admission as discharged with the time and reason set on it.
+// It returns gorm.ErrRecordNotFound if the admission is no longer active.
+func DischargeAdmission(admission *models.Admission) error {
+	result := DB.Model(&models.Admission{}).
+		Where("id = ? AND status = ?", admission.ID, models.AdmissionStatusAdmitted).
+		Updates(map[string]interface{}{
+			"status":           models.AdmissionStatusDischarged,
+			"discharged_at":    admission.DischargedAt,
+			"discharge_reason": admission.DischargeReason,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	admission.Status = models.AdmissionStatusDischarged
+	return nil
+}
+
+// ListAdmissionsByPatient returns a page of a patient's admissions, newest first, along with the total count.
+func ListAdmissionsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.Admission, int64, error) {
+	var admissions []models.Admission
+	var total int64
+
+	dbQuery := DB.Model(&models.Admission{}).Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).Session(&gorm.Session{})
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := dbQuery.Order("admitted_at DESC, id DESC").Offset(offset).Limit(limit).Find(&admissions)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return admissions, total, nil
+}
+
+// ListCurrentWardAdmissions returns the patients currently admitted to a ward of the hospital,
+// ordered by bed number, each with their admission.
+func ListCurrentWardAdmissions(hospitalID uint, ward string) ([]models.WardCensusEntry, error) {
+	var admissions []models.Admission
+	result := DB.Where("hospital_id = ? AND ward = ? AND discharged_at IS NULL", hospitalID, ward).
+		Order("bed_number ASC, admitted_at ASC").
+		Find(&admissions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(admissions) == 0 {
+		return []models.WardCensusEntry{}, nil
+	}
+
+	patientIDs := make([]uint, len(admissions))
+	for i, admission := range admissions {
+		patientIDs[i] = admission.PatientID
+	}
+	var patients []models.Patient
+	if err := DB.Where("id IN ?", patientIDs).Find(&patients).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Patient, len(patients))
+	for _, patient := range patients {
+		byID[patient.ID] = patient
+	}
+
+	entries := make([]models.WardCensusEntry, 0, len(admissions))
+	for _, admission := range admissions {
+		patient, ok := byID[admission.PatientID]
+		if !ok {
+			continue // Patient was deleted while still admitted
+		}
+		entries = append(entries, models.WardCensusEntry{Admission: admission, Patient: patient})
+	}
+	return entries, nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Allergy Specific Functions ---
+
+// UpsertAllergy records an allergy, updating the existing entry when the patient already has
+// one for the same substance (case-insensitive). It reports whether a new row was created.
+// A single INSERT ... ON CONFLICT against idx_allergies_patient_substance keeps concurrent upserts
+// of the same substance from racing; xmax is zero only on a row this statement inserted.
+func UpsertAllergy(allergy *models.Allergy) (bool, error) {
+	var result struct {
+		ID       uint
+		Inserted bool
+	}
+	err := DB.Raw(`INSERT INTO allergies (patient_id, substance, reaction, severity, noted_by, noted_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (patient_id, LOWER(substance)) DO UPDATE SET
+			substance = EXCLUDED.substance,
+			reaction = EXCLUDED.reaction,
+			severity = EXCLUDED.severity,
+			noted_by = EXCLUDED.noted_by,
+			noted_at = EXCLUDED.noted_at
+		RETURNING id, (xmax = 0) AS inserted`,
+		allergy.PatientID, allergy.Substance, allergy.Reaction, allergy.Severity, allergy.NotedBy, allergy.NotedAt).
+		Scan(&result).Error
+	if err != nil {
+		return false, err
+	}
+	allergy.ID = result.ID
+	return result.Inserted, nil
+}
+
+// ListAllergiesByPatient returns all allergies recorded for a patient.
+func ListAllergiesByPatient(patientID uint) ([]models.Allergy, error) {
+	var allergies []models.Allergy
+	result := DB.Where("patient_id = ?", patientID).Order("substance ASC").Find(&allergies)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return allergies, nil
+}
+
+// GetAllergyByID retrieves a single allergy for the given patient.
+func GetAllergyByID(patientID, allergyID uint) (*models.Allergy, error) {
+	var allergy models.Allergy
+	result := DB.Where("patient_id = ?", patientID).First(&allergy, allergyID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &allergy, nil
+}
+
+// UpdateAllergy saves changes to an existing allergy.
+func UpdateAllergy(allergy *models.Allergy) error {
+	return DB.Save(allergy).Error
+}
+
+// DeleteAllergy removes an allergy from the given patient.
+func DeleteAllergy(patientID, allergyID uint) error {
+	result := DB.Where("patient_id = ?", patientID).Delete(&models.Allergy{}, allergyID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
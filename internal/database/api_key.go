@@ -0,0 +1,42 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- API Key Specific Functions ---
+
+// CreateAPIKey stores a new API key.
+func CreateAPIKey(key *models.APIKey) error {
+	return DB.Create(key).Error
+}
+
+// FindAPIKeyByPrefix returns the key with the given public prefix, active or not.
+func FindAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := DB.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// TouchAPIKey records when a key was last used.
+func TouchAPIKey(id uint, usedAt time.Time) error {
+	return DB.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+// RevokeAPIKey deactivates a key of the hospital. Keys of other hospitals are reported as not found.
+// Revoking an already revoked key succeeds.
+func RevokeAPIKey(id, hospitalID uint) error {
+	result := DB.Model(&models.APIKey{}).Where("id = ? AND hospital_id = ?", id, hospitalID).Update("is_active", false)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
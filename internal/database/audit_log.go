@@ -0,0 +1,25 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Audit Log Specific Functions ---
+
+// ListAuditLogsByPatient returns a page of a patient's audit trail, newest first, with the total count.
+func ListAuditLogsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.AuditLog, int64, error) {
+	var entries []models.AuditLog
+	var total int64
+
+	dbQuery := DB.Model(&models.AuditLog{}).Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).Session(&gorm.Session{})
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := dbQuery.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return entries, total, nil
+}
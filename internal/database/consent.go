@@ -0,0 +1,69 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Consent Specific Functions ---
+
+// CreateConsent records a consent decision together with its audit entry. A data sharing
+// decision also becomes the patient's consent status.
+func CreateConsent(consent *models.Consent, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(consent).Error; err != nil {
+			return err
+		}
+		if consent.ConsentType == models.ConsentTypeDataSharing {
+			if err := tx.Model(&models.Patient{}).Where("id = ?", consent.PatientID).
+				Updates(map[string]interface{}{"consent_status": consent.Status(), "consent_updated_at": consent.CreatedAt}).Error; err != nil {
+				return err
+			}
+		}
+		audit.EntityType = "consent"
+		audit.EntityID = consent.ID
+		return tx.Create(audit).Error
+	})
+}
+
+// UpdatePatientConsent sets a patient's consent status from a witnessed data sharing decision,
+// recording the decision and the audit entry in the same transaction, so the status always
+// matches the latest data sharing consent. It returns gorm.ErrRecordNotFound if the patient is
+// no longer in the consent's hospital.
+func UpdatePatientConsent(consent *models.Consent, version string, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(consent).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.Patient{}).Where("id = ? AND hospital_id = ?", consent.PatientID, consent.HospitalID).
+			Updates(map[string]interface{}{"consent_status": consent.Status(), "consent_updated_at": consent.CreatedAt, "consent_version": version})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		audit.EntityType = "patient"
+		audit.EntityID = consent.PatientID
+		return tx.Create(audit).Error
+	})
+}
+
+// ListConsentsByPatient returns every consent decision recorded for a patient, newest first.
+func ListConsentsByPatient(patientID uint) ([]models.Consent, error) {
+	var consents []models.Consent
+	result := DB.Where("patient_id = ?", patientID).Order("id DESC").Find(&consents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return consents, nil
+}
+
+// sharesDataWith limits a patients query to the patients viewerHospitalID may see: its own, and
+// other hospitals' patients unless they refused data sharing. Patients with no recorded decision
+// are shared, as they were before consents were tracked. Like buildConsortiumPatientSearch it goes
+// by patients.consent_status, which CreateConsent keeps equal to the latest data sharing decision.
+func sharesDataWith(dbQuery *gorm.DB, viewerHospitalID uint) *gorm.DB {
+	return dbQuery.Where("patients.hospital_id = ? OR patients.consent_status <> ?", viewerHospitalID, models.ConsentStatusDenied)
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// bundledICD10Codes is a small starter set of common codes, used when no ICD-10 file is configured.
+// Production deployments should point ICD10_CODES_PATH at the full Thai edition (ICD-10-TM).
+//
+//go:embed data/icd10_codes.csv
+var bundledICD10Codes string
+
+// icd10LoadBatchSize is how many codes are inserted per statement when loading the table.
+const icd10LoadBatchSize = 1000
+
+// --- ICD-10 and Diagnosis Specific Functions ---
+
+// loadICD10Codes fills the ICD-10 reference table from a "code,description" CSV the first time
+// the schema is migrated. The file at path is used when set, otherwise the bundled starter set.
+// A table that already has codes is left alone, so the load runs once per database.
+func loadICD10Codes(db *gorm.DB, path string) error {
+	var existing int64
+	if err := db.Model(&models.ICD10Code{}).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	var source io.Reader = strings.NewReader(bundledICD10Codes)
+	sourceName := "bundled starter set"
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open ICD-10 file: %w", err)
+		}
+		defer file.Close()
+		source, sourceName = file, path
+	}
+
+	codes, err := parseICD10Codes(source)
+	if err != nil {
+		return fmt.Errorf("failed to parse ICD-10 codes from %s: %w", sourceName, err)
+	}
+	if err := db.CreateInBatches(codes, icd10LoadBatchSize).Error; err != nil {
+		return err
+	}
+	log.Printf("Loaded %d ICD-10 codes from %s", len(codes), sourceName)
+	return nil
+}
+
+// parseICD10Codes reads a CSV with a "code,description" header. Codes are stored upper-case.
+func parseICD10Codes(r io.Reader) ([]models.ICD10Code, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(header[0]), "code") || !strings.EqualFold(strings.TrimSpace(header[1]), "description") {
+		return nil, fmt.Errorf("expected header \"code,description\", got %q", strings.Join(header, ","))
+	}
+
+	var codes []models.ICD10Code
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		code := strings.ToUpper(strings.TrimSpace(record[0]))
+		if code == "" {
+			continue
+		}
+		codes = append(codes, models.ICD10Code{Code: code, Description: strings.TrimSpace(record[1])})
+	}
+	return codes, nil
+}
+
+// SearchICD10Codes finds codes starting with q or whose code and description match its words,
+// ordered by code. Matching words use full-text search over idx_icd10_codes_search.
+func SearchICD10Codes(q string, limit int) ([]models.ICD10Code, error) {
+	var codes []models.ICD10Code
+	result := DB.Where("code LIKE ? || '%'"+likeEscapeClause, escapeLike(strings.ToUpper(q))).
+		Or("to_tsvector('simple', code || ' ' || description) @@ plainto_tsquery('simple', ?)", q).
+		Order("code ASC").
+		Limit(limit).
+		Find(&codes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return codes, nil
+}
+
+// CreatePatientDiagnosis inserts a diagnosis. An unknown ICD-10 code fails with a foreign key violation.
+func CreatePatientDiagnosis(diagnosis *models.PatientDiagnosis) error {
+	return DB.Create(diagnosis).Error
+}
+
+// ListPatientDiagnoses returns a page of a patient's diagnoses, newest first, with their ICD-10
+// descriptions, along with the total count.
+func ListPatientDiagnoses(patientID, hospitalID uint, offset, limit int) ([]models.PatientDiagnosis, int64, error) {
+	var diagnoses []models.PatientDiagnosis
+	var total int64
+
+	dbQuery := DB.Model(&models.PatientDiagnosis{}).Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).Session(&gorm.Session{})
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := dbQuery.Preload("ICD10").Order("diagnosed_at DESC, id DESC").Offset(offset).Limit(limit).Find(&diagnoses)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return diagnoses, total, nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"fmt"
+	"hospital-middleware/internal/models"
+	"strconv"
+	"strings"
+)
+
+// --- Duplicate Patient Specific Functions ---
+
+// duplicateClustersSQL lists the duplicate clusters of a hospital as match type, match key and a
+// comma-separated list of patient IDs. Dates of birth are compared as dates in the session time zone.
+const duplicateClustersSQL = `
+SELECT ? AS match_type, national_id AS match_key, string_agg(id::text, ',' ORDER BY id) AS patient_ids
+FROM patients
+WHERE hospital_id = ? AND deleted_at IS NULL AND national_id <> ''
+GROUP BY national_id
+HAVING COUNT(*) > 1
+UNION ALL
+SELECT ?, lower(trim(first_name_en)) || ' ' || lower(trim(last_name_en)) || ' ' || to_char(date_of_birth::date, 'YYYY-MM-DD'),
+	string_agg(id::text, ',' ORDER BY id)
+FROM patients
+WHERE hospital_id = ? AND deleted_at IS NULL AND date_of_birth IS NOT NULL AND trim(first_name_en) <> '' AND trim(last_name_en) <> ''
+GROUP BY lower(trim(first_name_en)), lower(trim(last_name_en)), date_of_birth::date
+HAVING COUNT(*) > 1`
+
+// ListDuplicateClusters returns a page of the hospital's likely-duplicate patient clusters, ordered
+// by match type and key, with the total number of clusters.
+func ListDuplicateClusters(hospitalID uint, offset, limit int) ([]models.DuplicateCluster, int64, error) {
+	args := []interface{}{models.DuplicateMatchNationalID, hospitalID, models.DuplicateMatchNameAndBirthDate, hospitalID}
+
+	var total int64
+	if err := readDB().Raw("SELECT COUNT(*) FROM ("+duplicateClustersSQL+") AS clusters", args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		MatchType  string
+		MatchKey   string
+		PatientIDs string
+	}
+	pageArgs := append(args, limit, offset)
+	err := readDB().Raw("SELECT * FROM ("+duplicateClustersSQL+") AS clusters ORDER BY match_type, match_key LIMIT ? OFFSET ?", pageArgs...).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	clusters := make([]models.DuplicateCluster, len(rows))
+	var allIDs []uint
+	for i, row := range rows {
+		clusters[i] = models.DuplicateCluster{MatchType: row.MatchType, MatchKey: row.MatchKey}
+		for _, raw := range strings.Split(row.PatientIDs, ",") {
+			id, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("parsing duplicate cluster patient id %q: %w", raw, err)
+			}
+			clusters[i].PatientIDs = append(clusters[i].PatientIDs, uint(id))
+			allIDs = append(allIDs, uint(id))
+		}
+	}
+	if len(allIDs) == 0 {
+		return clusters, total, nil
+	}
+
+	var patients []models.Patient
+	if err := readDB().Where("id IN ? AND hospital_id = ?", allIDs, hospitalID).Find(&patients).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]models.Patient, len(patients))
+	for _, patient := range patients {
+		byID[patient.ID] = patient
+	}
+	for i := range clusters {
+		for _, id := range clusters[i].PatientIDs {
+			if patient, ok := byID[id]; ok {
+				clusters[i].Patients = append(clusters[i].Patients, patient)
+			}
+		}
+	}
+	return clusters, total, nil
+}
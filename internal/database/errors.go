@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PostgreSQL SQLSTATEs for constraint violations.
+const (
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+)
+
+// connectionExceptionClass is the SQLSTATE class of errors about the connection itself.
+const connectionExceptionClass = "08"
+
+// transientSQLStates are the SQLSTATEs outside the connection exception class of failures that
+// may succeed if retried: conflicts with other transactions, an overloaded server, queries that
+// ran into statement_timeout, and a server that is shutting down or starting up.
+var transientSQLStates = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"55P03": true, // lock_not_available
+	"57014": true, // query_canceled
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// IsTransient reports whether err is a database failure that may succeed if retried, such as a
+// lost connection or a timeout. Anything else, like a constraint violation or a syntax error,
+// will fail again however often it is retried.
+func IsTransient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	if pgconn.Timeout(err) || pgconn.SafeToRetry(err) {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, connectionExceptionClass) || transientSQLStates[pgErr.Code])
+}
+
+// IsUniqueViolation reports whether err was caused by a unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+// IsForeignKeyViolation reports whether err was caused by a foreign key constraint violation.
+func IsForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode
+}
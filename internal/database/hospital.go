@@ -0,0 +1,155 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultHospitals are seeded on startup so existing staff and patient hospital IDs stay valid.
+var defaultHospitals = []models.Hospital{
+	{ID: 1, Name: "Hospital A", Code: "HA"},
+	{ID: 2, Name: "Hospital B", Code: "HB"},
+}
+
+// --- Hospital Specific Functions ---
+
+// seedHospitals inserts the default hospitals if they don't exist yet.
+func seedHospitals(db *gorm.DB) error {
+	for _, hospital := range defaultHospitals {
+		h := hospital
+		if err := db.Where(models.Hospital{ID: h.ID}).FirstOrCreate(&h).Error; err != nil {
+			return fmt.Errorf("failed to seed hospital %s: %w", h.Name, err)
+		}
+	}
+	// Explicit IDs don't advance the serial sequence, so move it past the seeded rows
+	err := db.Exec("SELECT setval(pg_get_serial_sequence('hospitals', 'id'), (SELECT MAX(id) FROM hospitals))").Error
+	if err != nil {
+		return fmt.Errorf("failed to reset hospital id sequence: %w", err)
+	}
+	log.Printf("Seeded %d default hospitals", len(defaultHospitals))
+	return nil
+}
+
+// ListHospitals returns all hospitals ordered by ID.
+func ListHospitals() ([]models.Hospital, error) {
+	var hospitals []models.Hospital
+	result := DB.Order("id ASC").Find(&hospitals)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return hospitals, nil
+}
+
+// GetHospitalByID returns a hospital, or ErrHospitalNotFound if there is none with that ID.
+func GetHospitalByID(id uint) (*models.Hospital, error) {
+	var hospital models.Hospital
+	result := DB.First(&hospital, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: id %d", ErrHospitalNotFound, id)
+		}
+		return nil, result.Error
+	}
+	return &hospital, nil
+}
+
+// GetHospitalIDByName looks up a hospital's ID by its exact name.
+func GetHospitalIDByName(hospitalName string) (uint, error) {
+	var hospital models.Hospital
+	result := DB.Select("id").Where("name = ?", hospitalName).First(&hospital)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("%w: %s", ErrHospitalNotFound, hospitalName)
+		}
+		return 0, result.Error
+	}
+	return hospital.ID, nil
+}
+
+// ConsortiumHospitalIDs returns, in ID order, the hospitals sharing a consortium with hospitalID,
+// hospitalID itself included even when it belongs to none.
+func ConsortiumHospitalIDs(hospitalID uint) ([]uint, error) {
+	var ids []uint
+	consortiums := DB.Model(&models.ConsortiumMembership{}).Select("consortium_id").Where("hospital_id = ?", hospitalID)
+	result := DB.Model(&models.ConsortiumMembership{}).
+		Distinct("hospital_id").
+		Where("consortium_id IN (?)", consortiums).
+		Order("hospital_id ASC").
+		Pluck("hospital_id", &ids)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(ids) == 0 { // Not in any consortium
+		return []uint{hospitalID}, nil
+	}
+	return ids, nil
+}
+
+// featureCacheTTL is how long IsFeatureEnabled trusts a hospital's features before reloading
+// them. A feature switched on another server takes up to this long to reach this one.
+const featureCacheTTL = time.Minute
+
+type cachedHospitalFeatures struct {
+	features  models.HospitalFeatures
+	expiresAt time.Time
+}
+
+var featureCache = struct {
+	sync.Mutex
+	entries map[uint]cachedHospitalFeatures
+}{entries: make(map[uint]cachedHospitalFeatures)}
+
+// IsFeatureEnabled reports whether the hospital has the feature switched on. Features are cached
+// per hospital for featureCacheTTL. When they cannot be loaded the feature counts as off.
+func IsFeatureEnabled(hospitalID uint, feature string) bool {
+	featureCache.Lock()
+	entry, ok := featureCache.entries[hospitalID]
+	featureCache.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.features[feature]
+	}
+
+	var hospital models.Hospital
+	if err := DB.Select("id", "features").First(&hospital, hospitalID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Error loading features of hospital %d: %v", hospitalID, err)
+			return false // Not cached, so the next call tries again
+		}
+	}
+	cacheHospitalFeatures(hospitalID, hospital.Features)
+	return hospital.Features[feature]
+}
+
+// SetHospitalFeature switches a feature on or off for a hospital and returns all of its features.
+// Only the one key is written, so concurrent changes to other features are kept.
+func SetHospitalFeature(hospitalID uint, feature string, enabled bool) (models.HospitalFeatures, error) {
+	result := DB.Model(&models.Hospital{}).Where("id = ?", hospitalID).Updates(map[string]interface{}{
+		"features":   gorm.Expr("jsonb_set(features, ARRAY[?]::text[], to_jsonb(?::boolean))", feature, enabled),
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: id %d", ErrHospitalNotFound, hospitalID)
+	}
+
+	var hospital models.Hospital
+	if err := DB.Select("id", "features").First(&hospital, hospitalID).Error; err != nil {
+		return nil, err
+	}
+	cacheHospitalFeatures(hospitalID, hospital.Features)
+	return hospital.Features, nil
+}
+
+func cacheHospitalFeatures(hospitalID uint, features models.HospitalFeatures) {
+	featureCache.Lock()
+	featureCache.entries[hospitalID] = cachedHospitalFeatures{features: features, expiresAt: time.Now().Add(featureCacheTTL)}
+	featureCache.Unlock()
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Hospital Config Specific Functions ---
+
+// GetHospitalConfig returns a hospital's config, or the defaults if none was saved yet.
+// It returns ErrHospitalNotFound when the hospital itself doesn't exist.
+func GetHospitalConfig(hospitalID uint) (*models.HospitalConfig, error) {
+	var hospitalCount int64
+	if err := DB.Model(&models.Hospital{}).Where("id = ?", hospitalID).Count(&hospitalCount).Error; err != nil {
+		return nil, err
+	}
+	if hospitalCount == 0 {
+		return nil, fmt.Errorf("%w: id %d", ErrHospitalNotFound, hospitalID)
+	}
+
+	var cfg models.HospitalConfig
+	result := DB.Where("hospital_id = ?", hospitalID).First(&cfg)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		cfg = models.DefaultHospitalConfig(hospitalID)
+		return &cfg, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &cfg, nil
+}
+
+// SaveHospitalConfig inserts or replaces a hospital's config.
+func SaveHospitalConfig(cfg *models.HospitalConfig) error {
+	return DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hospital_id"}},
+		UpdateAll: true,
+	}).Create(cfg).Error
+}
@@ -0,0 +1,657 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"time"
+)
+
+// PatientRepository abstracts the persistence operations used by the API layer.
+// Handlers and services depend on this interface rather than the package-level
+// functions so they can be unit tested against a mock without a live database.
+type PatientRepository interface {
+	// Staff
+	CreateStaff(staff *models.Staff) error
+	FindStaffByUsername(username string) (*models.Staff, error)
+	FindExistingUsernames(usernames []string) ([]string, error)
+	CreateStaffBatch(staff []models.Staff) error
+	RehearseStaffBatch(staff []models.Staff) error
+	RecordStaffLogin(staffID uint, at time.Time) error
+	StreamStaffByHospital(hospitalID uint, limit int, fn func(*models.Staff) error) error
+	UpdateStaffPassword(staffID uint, passwordHash string) error
+	UpdateStaffRole(staffID, hospitalID uint, role string) (*models.Staff, error)
+	SetStaffTOTPSecret(staffID uint, secret string) error
+	EnableStaffTOTP(staffID uint, step int64) error
+	ClaimStaffTOTPStep(staffID uint, step int64) (bool, error)
+
+	// Patient
+	CreatePatient(patient *models.Patient) error
+	GetPatientByID(id uint) (*models.Patient, error)
+	GetPatientByNationalID(nationalID string, hospitalID uint) (*models.Patient, error)
+	GetPatientsByHNList(hns []string, hospitalID uint) ([]models.Patient, error)
+	TransferPatient(patient *models.Patient, targetHospitalID uint, audit *models.AuditLog) error
+	UpdatePatientFields(patientID, hospitalID uint, updates map[string]interface{}, audit *models.AuditLog) error
+	UpdatePatientStatus(patient *models.Patient, previousStatus string, audit *models.AuditLog) error
+	SoftDeletePatientsByHospital(hospitalID uint) (int64, error)
+	SearchPatients(query *models.PatientSearchQuery, hospitalID uint, limit int) ([]models.Patient, error)
+	ExplainSearchPatients(query *models.PatientSearchQuery, hospitalID uint, limit int) (json.RawMessage, error)
+	SearchPatientsAcrossHospitals(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint, limit int) ([]models.PatientWithHospital, error)
+	SearchPatientsPage(query *models.PatientSearchQuery, hospitalID uint, offset, limit int) ([]models.Patient, int64, error)
+	SearchPatientsAcrossHospitalsPage(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint, offset, limit int) ([]models.PatientWithHospital, int64, error)
+	StreamPatients(ctx context.Context, query *models.PatientSearchQuery, hospitalID uint, fn func(*models.Patient) error) error
+
+	// Visit
+	CreateVisit(visit *models.Visit) error
+	ListVisitsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.Visit, int64, error)
+	ListVisitsByDate(hospitalID uint, start, end time.Time) ([]models.Visit, error)
+
+	// Admission
+	CreateAdmission(admission *models.Admission) error
+	GetAdmission(patientID, admissionID uint) (*models.Admission, error)
+	DischargeAdmission(admission *models.Admission) error
+	ListAdmissionsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.Admission, int64, error)
+	ListCurrentWardAdmissions(hospitalID uint, ward string) ([]models.WardCensusEntry, error)
+
+	// Referral
+	CreateReferral(referral *models.Referral) error
+	GetReferral(id uint) (*models.Referral, error)
+	UpdateReferralStatus(referral *models.Referral, from, to string) error
+
+	// Diagnosis
+	CreatePatientDiagnosis(diagnosis *models.PatientDiagnosis) error
+	ListPatientDiagnoses(patientID, hospitalID uint, offset, limit int) ([]models.PatientDiagnosis, int64, error)
+	SearchICD10Codes(q string, limit int) ([]models.ICD10Code, error)
+
+	// Allergy
+	UpsertAllergy(allergy *models.Allergy) (bool, error)
+	ListAllergiesByPatient(patientID uint) ([]models.Allergy, error)
+	GetAllergyByID(patientID, allergyID uint) (*models.Allergy, error)
+	UpdateAllergy(allergy *models.Allergy) error
+	DeleteAllergy(patientID, allergyID uint) error
+
+	// Consent
+	CreateConsent(consent *models.Consent, audit *models.AuditLog) error
+	UpdatePatientConsent(consent *models.Consent, version string, audit *models.AuditLog) error
+	ListConsentsByPatient(patientID uint) ([]models.Consent, error)
+
+	// Patient Note
+	CreatePatientNote(note *models.PatientNote, audit *models.AuditLog) error
+	ListPatientNotes(patientID, viewerID uint, offset, limit int) ([]models.PatientNote, int64, error)
+	GetPatientNote(patientID, noteID uint) (*models.PatientNote, error)
+	UpdatePatientNote(note *models.PatientNote, audit *models.AuditLog) error
+	DeletePatientNote(note *models.PatientNote, audit *models.AuditLog) error
+
+	// Patient Document
+	CreatePatientDocument(doc *models.PatientDocument, audit *models.AuditLog) error
+	ListPatientDocuments(patientID uint, offset, limit int) ([]models.PatientDocument, int64, error)
+	GetPatientDocument(patientID, documentID uint) (*models.PatientDocument, error)
+	DeletePatientDocument(doc *models.PatientDocument, audit *models.AuditLog) error
+
+	// Audit Log
+	ListAuditLogsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.AuditLog, int64, error)
+
+	// Revoked Token
+	RevokeToken(token *models.RevokedToken) error
+	IsTokenRevoked(jti string) (bool, error)
+	DeleteExpiredRevokedTokens(before time.Time) (int64, error)
+
+	// Background Job
+	CreateJob(job *models.Job) error
+	ClaimJob(types []string, now, staleBefore time.Time) (*models.Job, error)
+	CompleteJob(id uint, now time.Time) error
+	RetryJob(id uint, lastError string, runAt time.Time) error
+	FailJob(id uint, lastError string, now time.Time) error
+	ReleaseJob(id uint) error
+	ListJobs(status string, offset, limit int) ([]models.Job, int64, error)
+
+	// Report
+	GenerateMonthlyReport(hospitalID uint, start time.Time) (*models.MonthlyReport, error)
+	GetCachedReport(hospitalID uint, year, month int) (*models.CachedReport, error)
+	SaveCachedReport(cached *models.CachedReport) error
+
+	// API Key
+	CreateAPIKey(key *models.APIKey) error
+	FindAPIKeyByPrefix(prefix string) (*models.APIKey, error)
+	TouchAPIKey(id uint, usedAt time.Time) error
+	RevokeAPIKey(id, hospitalID uint) error
+
+	// Search History
+	RecordSearch(entry *models.SearchHistory) error
+	DeleteSearchHistoryBefore(before time.Time) (int64, error)
+
+	// Saved Search
+	CreateSavedSearch(search *models.SavedSearch) error
+	ListSavedSearches(staffID uint) ([]models.SavedSearch, error)
+	GetSavedSearch(id, staffID uint) (*models.SavedSearch, error)
+	DeleteSavedSearch(id, staffID uint) error
+
+	// Webhook
+	CreateWebhook(webhook *models.Webhook) error
+	ListWebhooks(hospitalID uint) ([]models.Webhook, error)
+	GetWebhook(id, hospitalID uint) (*models.Webhook, error)
+	UpdateWebhook(webhook *models.Webhook) error
+	DeleteWebhook(id, hospitalID uint) error
+	ListActiveWebhooks(hospitalID uint, event string) ([]models.Webhook, error)
+	CreateWebhookDeadLetter(entry *models.WebhookDeadLetter) error
+
+	// Recently Viewed
+	RecordPatientView(view *models.RecentlyViewed) error
+	ListRecentlyViewed(staffID, hospitalID uint) ([]models.RecentlyViewedPatient, error)
+
+	// Patient Access Log
+	RecordPatientAccess(entries []models.PatientAccessLog) error
+	ListPatientAccessLog(patientID uint, offset, limit int) ([]models.PatientAccessLog, int64, error)
+	ListStaffAccessLog(staffID, hospitalID uint, offset, limit int) ([]models.PatientAccessLog, int64, error)
+	DeletePatientAccessLogBefore(before time.Time) (int64, error)
+
+	// Data Retention
+	CountRetentionPurge(cutoffs models.RetentionCutoffs) (models.RetentionPurgeReport, error)
+	PurgeSoftDeletedPatients(before time.Time, limit int) (int64, []string, error)
+	PurgeRevokedTokens(cutoffs models.RetentionCutoffs, limit int) (int64, error)
+
+	// Duplicate Patients
+	ListDuplicateClusters(hospitalID uint, offset, limit int) ([]models.DuplicateCluster, int64, error)
+
+	// Patient Label
+	CreatePatientLabel(label *models.PatientLabel) error
+	ListPatientLabels(hospitalID uint) ([]models.PatientLabel, error)
+	GetPatientLabel(id, hospitalID uint) (*models.PatientLabel, error)
+	UpdatePatientLabel(label *models.PatientLabel) error
+	DeletePatientLabel(id, hospitalID uint) error
+	AssignPatientLabel(patientID, labelID uint) error
+	UnassignPatientLabel(patientID, labelID uint) error
+
+	// Patient Tag
+	AddPatientTag(patientID, hospitalID uint, name string) (*models.Tag, error)
+	RemovePatientTag(patientID, hospitalID uint, name string) error
+	ListTagCounts(hospitalID uint) ([]models.TagCount, error)
+	DeleteTag(hospitalID uint, name string) error
+
+	// Hospital
+	GetHospitalIDByName(hospitalName string) (uint, error)
+	GetHospitalByID(id uint) (*models.Hospital, error)
+	ListHospitals() ([]models.Hospital, error)
+	IsFeatureEnabled(hospitalID uint, feature string) bool
+	SetHospitalFeature(hospitalID uint, feature string, enabled bool) (models.HospitalFeatures, error)
+
+	// Hospital Config
+	GetHospitalConfig(hospitalID uint) (*models.HospitalConfig, error)
+	SaveHospitalConfig(cfg *models.HospitalConfig) error
+}
+
+// PostgresRepository is the PatientRepository backed by the global GORM connection.
+// It delegates to the package-level functions so both call styles share one implementation.
+type PostgresRepository struct{}
+
+// NewPostgresRepository returns a repository using the connection initialized by Connect.
+func NewPostgresRepository() *PostgresRepository {
+	return &PostgresRepository{}
+}
+
+// Compile-time check that PostgresRepository satisfies PatientRepository.
+var _ PatientRepository = (*PostgresRepository)(nil)
+
+func (r *PostgresRepository) CreateStaff(staff *models.Staff) error {
+	return CreateStaff(staff)
+}
+
+func (r *PostgresRepository) FindStaffByUsername(username string) (*models.Staff, error) {
+	return FindStaffByUsername(username)
+}
+
+func (r *PostgresRepository) FindExistingUsernames(usernames []string) ([]string, error) {
+	return FindExistingUsernames(usernames)
+}
+
+func (r *PostgresRepository) CreateStaffBatch(staff []models.Staff) error {
+	return CreateStaffBatch(staff)
+}
+
+func (r *PostgresRepository) RehearseStaffBatch(staff []models.Staff) error {
+	return RehearseStaffBatch(staff)
+}
+
+func (r *PostgresRepository) RecordStaffLogin(staffID uint, at time.Time) error {
+	return RecordStaffLogin(staffID, at)
+}
+
+func (r *PostgresRepository) StreamStaffByHospital(hospitalID uint, limit int, fn func(*models.Staff) error) error {
+	return StreamStaffByHospital(hospitalID, limit, fn)
+}
+
+func (r *PostgresRepository) UpdateStaffPassword(staffID uint, passwordHash string) error {
+	return UpdateStaffPassword(staffID, passwordHash)
+}
+
+func (r *PostgresRepository) UpdateStaffRole(staffID, hospitalID uint, role string) (*models.Staff, error) {
+	return UpdateStaffRole(staffID, hospitalID, role)
+}
+
+func (r *PostgresRepository) SetStaffTOTPSecret(staffID uint, secret string) error {
+	return SetStaffTOTPSecret(staffID, secret)
+}
+
+func (r *PostgresRepository) EnableStaffTOTP(staffID uint, step int64) error {
+	return EnableStaffTOTP(staffID, step)
+}
+
+func (r *PostgresRepository) ClaimStaffTOTPStep(staffID uint, step int64) (bool, error) {
+	return ClaimStaffTOTPStep(staffID, step)
+}
+
+func (r *PostgresRepository) CreatePatient(patient *models.Patient) error {
+	return CreatePatient(patient)
+}
+
+func (r *PostgresRepository) GetPatientByID(id uint) (*models.Patient, error) {
+	return GetPatientByID(id)
+}
+
+func (r *PostgresRepository) GetPatientByNationalID(nationalID string, hospitalID uint) (*models.Patient, error) {
+	return GetPatientByNationalID(nationalID, hospitalID)
+}
+
+func (r *PostgresRepository) GetPatientsByHNList(hns []string, hospitalID uint) ([]models.Patient, error) {
+	return GetPatientsByHNList(hns, hospitalID)
+}
+
+func (r *PostgresRepository) SoftDeletePatientsByHospital(hospitalID uint) (int64, error) {
+	return SoftDeletePatientsByHospital(hospitalID)
+}
+
+func (r *PostgresRepository) UpdatePatientFields(patientID, hospitalID uint, updates map[string]interface{}, audit *models.AuditLog) error {
+	return UpdatePatientFields(patientID, hospitalID, updates, audit)
+}
+
+func (r *PostgresRepository) UpdatePatientStatus(patient *models.Patient, previousStatus string, audit *models.AuditLog) error {
+	return UpdatePatientStatus(patient, previousStatus, audit)
+}
+
+func (r *PostgresRepository) TransferPatient(patient *models.Patient, targetHospitalID uint, audit *models.AuditLog) error {
+	return TransferPatient(patient, targetHospitalID, audit)
+}
+
+func (r *PostgresRepository) SearchPatients(query *models.PatientSearchQuery, hospitalID uint, limit int) ([]models.Patient, error) {
+	return SearchPatients(query, hospitalID, limit)
+}
+
+func (r *PostgresRepository) ExplainSearchPatients(query *models.PatientSearchQuery, hospitalID uint, limit int) (json.RawMessage, error) {
+	return ExplainSearchPatients(query, hospitalID, limit)
+}
+
+func (r *PostgresRepository) SearchPatientsPage(query *models.PatientSearchQuery, hospitalID uint, offset, limit int) ([]models.Patient, int64, error) {
+	return SearchPatientsPage(query, hospitalID, offset, limit)
+}
+
+func (r *PostgresRepository) SearchPatientsAcrossHospitalsPage(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint, offset, limit int) ([]models.PatientWithHospital, int64, error) {
+	return SearchPatientsAcrossHospitalsPage(query, hospitalIDs, viewerHospitalID, offset, limit)
+}
+
+func (r *PostgresRepository) SearchPatientsAcrossHospitals(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint, limit int) ([]models.PatientWithHospital, error) {
+	return SearchPatientsAcrossHospitals(query, hospitalIDs, viewerHospitalID, limit)
+}
+
+func (r *PostgresRepository) StreamPatients(ctx context.Context, query *models.PatientSearchQuery, hospitalID uint, fn func(*models.Patient) error) error {
+	return StreamPatients(ctx, query, hospitalID, fn)
+}
+
+func (r *PostgresRepository) GetHospitalIDByName(hospitalName string) (uint, error) {
+	return GetHospitalIDByName(hospitalName)
+}
+
+func (r *PostgresRepository) GetHospitalByID(id uint) (*models.Hospital, error) {
+	return GetHospitalByID(id)
+}
+
+func (r *PostgresRepository) ListHospitals() ([]models.Hospital, error) {
+	return ListHospitals()
+}
+
+func (r *PostgresRepository) IsFeatureEnabled(hospitalID uint, feature string) bool {
+	return IsFeatureEnabled(hospitalID, feature)
+}
+
+func (r *PostgresRepository) SetHospitalFeature(hospitalID uint, feature string, enabled bool) (models.HospitalFeatures, error) {
+	return SetHospitalFeature(hospitalID, feature, enabled)
+}
+
+func (r *PostgresRepository) GetHospitalConfig(hospitalID uint) (*models.HospitalConfig, error) {
+	return GetHospitalConfig(hospitalID)
+}
+
+func (r *PostgresRepository) SaveHospitalConfig(cfg *models.HospitalConfig) error {
+	return SaveHospitalConfig(cfg)
+}
+
+func (r *PostgresRepository) CreateVisit(visit *models.Visit) error {
+	return CreateVisit(visit)
+}
+
+func (r *PostgresRepository) ListVisitsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.Visit, int64, error) {
+	return ListVisitsByPatient(patientID, hospitalID, offset, limit)
+}
+
+func (r *PostgresRepository) ListVisitsByDate(hospitalID uint, start, end time.Time) ([]models.Visit, error) {
+	return ListVisitsByDate(hospitalID, start, end)
+}
+
+func (r *PostgresRepository) CreateAdmission(admission *models.Admission) error {
+	return CreateAdmission(admission)
+}
+
+func (r *PostgresRepository) GetAdmission(patientID, admissionID uint) (*models.Admission, error) {
+	return GetAdmission(patientID, admissionID)
+}
+
+func (r *PostgresRepository) DischargeAdmission(admission *models.Admission) error {
+	return DischargeAdmission(admission)
+}
+
+func (r *PostgresRepository) ListAdmissionsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.Admission, int64, error) {
+	return ListAdmissionsByPatient(patientID, hospitalID, offset, limit)
+}
+
+func (r *PostgresRepository) ListCurrentWardAdmissions(hospitalID uint, ward string) ([]models.WardCensusEntry, error) {
+	return ListCurrentWardAdmissions(hospitalID, ward)
+}
+
+func (r *PostgresRepository) CreateReferral(referral *models.Referral) error {
+	return CreateReferral(referral)
+}
+
+func (r *PostgresRepository) GetReferral(id uint) (*models.Referral, error) {
+	return GetReferral(id)
+}
+
+func (r *PostgresRepository) UpdateReferralStatus(referral *models.Referral, from, to string) error {
+	return UpdateReferralStatus(referral, from, to)
+}
+
+func (r *PostgresRepository) CreatePatientDiagnosis(diagnosis *models.PatientDiagnosis) error {
+	return CreatePatientDiagnosis(diagnosis)
+}
+
+func (r *PostgresRepository) ListPatientDiagnoses(patientID, hospitalID uint, offset, limit int) ([]models.PatientDiagnosis, int64, error) {
+	return ListPatientDiagnoses(patientID, hospitalID, offset, limit)
+}
+
+func (r *PostgresRepository) SearchICD10Codes(q string, limit int) ([]models.ICD10Code, error) {
+	return SearchICD10Codes(q, limit)
+}
+
+func (r *PostgresRepository) UpsertAllergy(allergy *models.Allergy) (bool, error) {
+	return UpsertAllergy(allergy)
+}
+
+func (r *PostgresRepository) ListAllergiesByPatient(patientID uint) ([]models.Allergy, error) {
+	return ListAllergiesByPatient(patientID)
+}
+
+func (r *PostgresRepository) GetAllergyByID(patientID, allergyID uint) (*models.Allergy, error) {
+	return GetAllergyByID(patientID, allergyID)
+}
+
+func (r *PostgresRepository) UpdateAllergy(allergy *models.Allergy) error {
+	return UpdateAllergy(allergy)
+}
+
+func (r *PostgresRepository) DeleteAllergy(patientID, allergyID uint) error {
+	return DeleteAllergy(patientID, allergyID)
+}
+
+func (r *PostgresRepository) CreateConsent(consent *models.Consent, audit *models.AuditLog) error {
+	return CreateConsent(consent, audit)
+}
+
+func (r *PostgresRepository) UpdatePatientConsent(consent *models.Consent, version string, audit *models.AuditLog) error {
+	return UpdatePatientConsent(consent, version, audit)
+}
+
+func (r *PostgresRepository) ListConsentsByPatient(patientID uint) ([]models.Consent, error) {
+	return ListConsentsByPatient(patientID)
+}
+
+func (r *PostgresRepository) CreatePatientNote(note *models.PatientNote, audit *models.AuditLog) error {
+	return CreatePatientNote(note, audit)
+}
+
+func (r *PostgresRepository) ListPatientNotes(patientID, viewerID uint, offset, limit int) ([]models.PatientNote, int64, error) {
+	return ListPatientNotes(patientID, viewerID, offset, limit)
+}
+
+func (r *PostgresRepository) GetPatientNote(patientID, noteID uint) (*models.PatientNote, error) {
+	return GetPatientNote(patientID, noteID)
+}
+
+func (r *PostgresRepository) UpdatePatientNote(note *models.PatientNote, audit *models.AuditLog) error {
+	return UpdatePatientNote(note, audit)
+}
+
+func (r *PostgresRepository) DeletePatientNote(note *models.PatientNote, audit *models.AuditLog) error {
+	return DeletePatientNote(note, audit)
+}
+
+func (r *PostgresRepository) CreatePatientDocument(doc *models.PatientDocument, audit *models.AuditLog) error {
+	return CreatePatientDocument(doc, audit)
+}
+
+func (r *PostgresRepository) ListPatientDocuments(patientID uint, offset, limit int) ([]models.PatientDocument, int64, error) {
+	return ListPatientDocuments(patientID, offset, limit)
+}
+
+func (r *PostgresRepository) GetPatientDocument(patientID, documentID uint) (*models.PatientDocument, error) {
+	return GetPatientDocument(patientID, documentID)
+}
+
+func (r *PostgresRepository) DeletePatientDocument(doc *models.PatientDocument, audit *models.AuditLog) error {
+	return DeletePatientDocument(doc, audit)
+}
+
+func (r *PostgresRepository) ListAuditLogsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.AuditLog, int64, error) {
+	return ListAuditLogsByPatient(patientID, hospitalID, offset, limit)
+}
+
+func (r *PostgresRepository) RevokeToken(token *models.RevokedToken) error {
+	return RevokeToken(token)
+}
+
+func (r *PostgresRepository) IsTokenRevoked(jti string) (bool, error) {
+	return IsTokenRevoked(jti)
+}
+
+func (r *PostgresRepository) DeleteExpiredRevokedTokens(before time.Time) (int64, error) {
+	return DeleteExpiredRevokedTokens(before)
+}
+
+func (r *PostgresRepository) CreateJob(job *models.Job) error {
+	return CreateJob(job)
+}
+
+func (r *PostgresRepository) ClaimJob(types []string, now, staleBefore time.Time) (*models.Job, error) {
+	return ClaimJob(types, now, staleBefore)
+}
+
+func (r *PostgresRepository) CompleteJob(id uint, now time.Time) error {
+	return CompleteJob(id, now)
+}
+
+func (r *PostgresRepository) RetryJob(id uint, lastError string, runAt time.Time) error {
+	return RetryJob(id, lastError, runAt)
+}
+
+func (r *PostgresRepository) FailJob(id uint, lastError string, now time.Time) error {
+	return FailJob(id, lastError, now)
+}
+
+func (r *PostgresRepository) ReleaseJob(id uint) error {
+	return ReleaseJob(id)
+}
+
+func (r *PostgresRepository) ListJobs(status string, offset, limit int) ([]models.Job, int64, error) {
+	return ListJobs(status, offset, limit)
+}
+
+func (r *PostgresRepository) GenerateMonthlyReport(hospitalID uint, start time.Time) (*models.MonthlyReport, error) {
+	return GenerateMonthlyReport(hospitalID, start)
+}
+
+func (r *PostgresRepository) GetCachedReport(hospitalID uint, year, month int) (*models.CachedReport, error) {
+	return GetCachedReport(hospitalID, year, month)
+}
+
+func (r *PostgresRepository) SaveCachedReport(cached *models.CachedReport) error {
+	return SaveCachedReport(cached)
+}
+
+func (r *PostgresRepository) CreateAPIKey(key *models.APIKey) error {
+	return CreateAPIKey(key)
+}
+
+func (r *PostgresRepository) FindAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	return FindAPIKeyByPrefix(prefix)
+}
+
+func (r *PostgresRepository) TouchAPIKey(id uint, usedAt time.Time) error {
+	return TouchAPIKey(id, usedAt)
+}
+
+func (r *PostgresRepository) RevokeAPIKey(id, hospitalID uint) error {
+	return RevokeAPIKey(id, hospitalID)
+}
+
+func (r *PostgresRepository) RecordSearch(entry *models.SearchHistory) error {
+	return RecordSearch(entry)
+}
+
+func (r *PostgresRepository) DeleteSearchHistoryBefore(before time.Time) (int64, error) {
+	return DeleteSearchHistoryBefore(before)
+}
+
+func (r *PostgresRepository) CreateSavedSearch(search *models.SavedSearch) error {
+	return CreateSavedSearch(search)
+}
+
+func (r *PostgresRepository) ListSavedSearches(staffID uint) ([]models.SavedSearch, error) {
+	return ListSavedSearches(staffID)
+}
+
+func (r *PostgresRepository) GetSavedSearch(id, staffID uint) (*models.SavedSearch, error) {
+	return GetSavedSearch(id, staffID)
+}
+
+func (r *PostgresRepository) DeleteSavedSearch(id, staffID uint) error {
+	return DeleteSavedSearch(id, staffID)
+}
+
+func (r *PostgresRepository) CreateWebhook(webhook *models.Webhook) error {
+	return CreateWebhook(webhook)
+}
+
+func (r *PostgresRepository) ListWebhooks(hospitalID uint) ([]models.Webhook, error) {
+	return ListWebhooks(hospitalID)
+}
+
+func (r *PostgresRepository) GetWebhook(id, hospitalID uint) (*models.Webhook, error) {
+	return GetWebhook(id, hospitalID)
+}
+
+func (r *PostgresRepository) UpdateWebhook(webhook *models.Webhook) error {
+	return UpdateWebhook(webhook)
+}
+
+func (r *PostgresRepository) DeleteWebhook(id, hospitalID uint) error {
+	return DeleteWebhook(id, hospitalID)
+}
+
+func (r *PostgresRepository) ListActiveWebhooks(hospitalID uint, event string) ([]models.Webhook, error) {
+	return ListActiveWebhooks(hospitalID, event)
+}
+
+func (r *PostgresRepository) CreateWebhookDeadLetter(entry *models.WebhookDeadLetter) error {
+	return CreateWebhookDeadLetter(entry)
+}
+
+func (r *PostgresRepository) RecordPatientView(view *models.RecentlyViewed) error {
+	return RecordPatientView(view)
+}
+
+func (r *PostgresRepository) ListRecentlyViewed(staffID, hospitalID uint) ([]models.RecentlyViewedPatient, error) {
+	return ListRecentlyViewed(staffID, hospitalID)
+}
+
+func (r *PostgresRepository) RecordPatientAccess(entries []models.PatientAccessLog) error {
+	return RecordPatientAccess(entries)
+}
+
+func (r *PostgresRepository) ListPatientAccessLog(patientID uint, offset, limit int) ([]models.PatientAccessLog, int64, error) {
+	return ListPatientAccessLog(patientID, offset, limit)
+}
+
+func (r *PostgresRepository) ListStaffAccessLog(staffID, hospitalID uint, offset, limit int) ([]models.PatientAccessLog, int64, error) {
+	return ListStaffAccessLog(staffID, hospitalID, offset, limit)
+}
+
+func (r *PostgresRepository) DeletePatientAccessLogBefore(before time.Time) (int64, error) {
+	return DeletePatientAccessLogBefore(before)
+}
+
+func (r *PostgresRepository) CountRetentionPurge(cutoffs models.RetentionCutoffs) (models.RetentionPurgeReport, error) {
+	return CountRetentionPurge(cutoffs)
+}
+
+func (r *PostgresRepository) PurgeSoftDeletedPatients(before time.Time, limit int) (int64, []string, error) {
+	return PurgeSoftDeletedPatients(before, limit)
+}
+
+func (r *PostgresRepository) PurgeRevokedTokens(cutoffs models.RetentionCutoffs, limit int) (int64, error) {
+	return PurgeRevokedTokens(cutoffs, limit)
+}
+
+func (r *PostgresRepository) ListDuplicateClusters(hospitalID uint, offset, limit int) ([]models.DuplicateCluster, int64, error) {
+	return ListDuplicateClusters(hospitalID, offset, limit)
+}
+
+func (r *PostgresRepository) CreatePatientLabel(label *models.PatientLabel) error {
+	return CreatePatientLabel(label)
+}
+
+func (r *PostgresRepository) ListPatientLabels(hospitalID uint) ([]models.PatientLabel, error) {
+	return ListPatientLabels(hospitalID)
+}
+
+func (r *PostgresRepository) GetPatientLabel(id, hospitalID uint) (*models.PatientLabel, error) {
+	return GetPatientLabel(id, hospitalID)
+}
+
+func (r *PostgresRepository) UpdatePatientLabel(label *models.PatientLabel) error {
+	return UpdatePatientLabel(label)
+}
+
+func (r *PostgresRepository) DeletePatientLabel(id, hospitalID uint) error {
+	return DeletePatientLabel(id, hospitalID)
+}
+
+func (r *PostgresRepository) AssignPatientLabel(patientID, labelID uint) error {
+	return AssignPatientLabel(patientID, labelID)
+}
+
+func (r *PostgresRepository) UnassignPatientLabel(patientID, labelID uint) error {
+	return UnassignPatientLabel(patientID, labelID)
+}
+
+func (r *PostgresRepository) AddPatientTag(patientID, hospitalID uint, name string) (*models.Tag, error) {
+	return AddPatientTag(patientID, hospitalID, name)
+}
+
+func (r *PostgresRepository) RemovePatientTag(patientID, hospitalID uint, name string) error {
+	return RemovePatientTag(patientID, hospitalID, name)
+}
+
+func (r *PostgresRepository) ListTagCounts(hospitalID uint) ([]models.TagCount, error) {
+	return ListTagCounts(hospitalID)
+}
+
+func (r *PostgresRepository) DeleteTag(hospitalID uint, name string) error {
+	return DeleteTag(hospitalID, name)
+}
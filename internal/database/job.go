@@ -0,0 +1,109 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Background Job Specific Functions ---
+
+// CreateJob adds a job to the queue.
+func CreateJob(job *models.Job) error {
+	return DB.Create(job).Error
+}
+
+// ClaimJob hands the next due job of one of types to a worker, or returns nil when there is none.
+// Jobs still running but claimed before staleBefore are claimed again, since their worker is
+// presumed dead. Claiming marks the job running and counts the attempt; SKIP LOCKED lets several
+// workers and servers claim at once without handing out the same job twice.
+func ClaimJob(types []string, now, staleBefore time.Time) (*models.Job, error) {
+	var job models.Job
+	claimed := false
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("type IN ?", types).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.JobStatusQueued, now, models.JobStatusRunning, staleBefore).
+			Order("run_at, id").
+			Limit(1).
+			Find(&job)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LockedAt = &now
+		claimed = true
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_at": now,
+		}).Error
+	})
+	if err != nil || !claimed {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CompleteJob marks a claimed job succeeded.
+func CompleteJob(id uint, now time.Time) error {
+	return DB.Model(&models.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      models.JobStatusSucceeded,
+		"locked_at":   nil,
+		"last_error":  "",
+		"finished_at": now,
+	}).Error
+}
+
+// RetryJob puts a claimed job that failed back in the queue, due again at runAt.
+func RetryJob(id uint, lastError string, runAt time.Time) error {
+	return DB.Model(&models.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.JobStatusQueued,
+		"locked_at":  nil,
+		"last_error": lastError,
+		"run_at":     runAt,
+	}).Error
+}
+
+// FailJob marks a claimed job failed for good.
+func FailJob(id uint, lastError string, now time.Time) error {
+	return DB.Model(&models.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      models.JobStatusFailed,
+		"locked_at":   nil,
+		"last_error":  lastError,
+		"finished_at": now,
+	}).Error
+}
+
+// ReleaseJob returns a claimed job to the queue without counting the attempt, for jobs a
+// shutting-down worker could not finish.
+func ReleaseJob(id uint) error {
+	return DB.Model(&models.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    models.JobStatusQueued,
+		"locked_at": nil,
+		"attempts":  gorm.Expr("GREATEST(attempts - 1, 0)"),
+	}).Error
+}
+
+// ListJobs returns a page of jobs, newest first, with the total. An empty status lists all.
+func ListJobs(status string, offset, limit int) ([]models.Job, int64, error) {
+	var jobs []models.Job
+	var total int64
+
+	dbQuery := DB.Model(&models.Job{})
+	if status != "" {
+		dbQuery = dbQuery.Where("status = ?", status)
+	}
+	dbQuery = dbQuery.Session(&gorm.Session{})
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := dbQuery.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
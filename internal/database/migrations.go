@@ -0,0 +1,204 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles holds the versioned migrations, named <version>_<name>.up.sql and <version>_<name>.down.sql.
+// Statements end with a semicolon at the end of a line.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationFileName matches the name of a migration file.
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change with the SQL that applies it and the SQL that reverts it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	// Irreversible migrations are only rolled back when forced; see RollbackMigration.
+	Irreversible bool
+}
+
+// baselineMigrationVersion is the first migration. Everything later builds on it, so rolling it
+// back is never routine and RollbackMigration refuses to without force.
+const baselineMigrationVersion = 1
+
+// LoadMigrations returns the embedded migrations in version order. Every migration must come
+// with both an up and a down file, and versions must be unique.
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2], Irreversible: version == baselineMigrationVersion}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitStatements splits a migration file into its statements, dropping comment-only lines.
+func splitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// execMigrationSQL runs the statements of a migration file one by one.
+func execMigrationSQL(tx *gorm.DB, sql string) error {
+	for _, statement := range splitStatements(sql) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyMigrations applies the migrations that have not been applied yet, in version order and
+// each in its own transaction, and returns how many it applied.
+func ApplyMigrations(db *gorm.DB) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if err := db.AutoMigrate(&models.SchemaMigration{}); err != nil {
+		return 0, fmt.Errorf("failed to create the migrations table: %w", err)
+	}
+	var applied []int
+	if err := db.Model(&models.SchemaMigration{}).Pluck("version", &applied).Error; err != nil {
+		return 0, err
+	}
+	done := make(map[int]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		if done[migration.Version] {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := execMigrationSQL(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Create(&models.SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+		count++
+	}
+	return count, nil
+}
+
+// Errors returned by RollbackMigration.
+var (
+	ErrNoMigrationApplied    = errors.New("no migration has been applied")
+	ErrIrreversibleMigration = errors.New("migration is irreversible; roll it back with force")
+)
+
+// RollbackMigration reverts the most recently applied migration with its down file and returns it.
+// An irreversible migration is left in place with ErrIrreversibleMigration unless force is set, so
+// repeating a routine rollback stops at the baseline instead of working through it.
+func RollbackMigration(db *gorm.DB, force bool) (*Migration, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&models.SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create the migrations table: %w", err)
+	}
+	var latest models.SchemaMigration
+	err = db.Order("version DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoMigrationApplied
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range migrations {
+		migration := &migrations[i]
+		if migration.Version != latest.Version {
+			continue
+		}
+		if migration.Irreversible && !force {
+			return nil, fmt.Errorf("%w: %d_%s", ErrIrreversibleMigration, migration.Version, migration.Name)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := execMigrationSQL(tx, migration.Down); err != nil {
+				return err
+			}
+			return tx.Delete(&models.SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("Rolled back migration %d_%s", migration.Version, migration.Name)
+		return migration, nil
+	}
+	return nil, fmt.Errorf("applied migration %d_%s has no migration file", latest.Version, latest.Name)
+}
@@ -0,0 +1,19 @@
+-- Drops the indexes of 0001_expression_indexes.up.sql. The tables belong to AutoMigrate and are left alone.
+
+DROP INDEX IF EXISTS idx_icd10_codes_search;
+DROP INDEX IF EXISTS idx_patient_labels_hospital_name;
+DROP INDEX IF EXISTS idx_allergies_patient_substance;
+DROP INDEX IF EXISTS idx_admissions_active_patient;
+DROP INDEX IF EXISTS idx_patients_last_name_th_folded_pattern;
+DROP INDEX IF EXISTS idx_patients_middle_name_th_folded_pattern;
+DROP INDEX IF EXISTS idx_patients_first_name_th_folded_pattern;
+DROP INDEX IF EXISTS idx_patients_last_name_en_pattern;
+DROP INDEX IF EXISTS idx_patients_last_name_th_pattern;
+DROP INDEX IF EXISTS idx_patients_middle_name_en_pattern;
+DROP INDEX IF EXISTS idx_patients_middle_name_th_pattern;
+DROP INDEX IF EXISTS idx_patients_first_name_en_pattern;
+DROP INDEX IF EXISTS idx_patients_first_name_th_pattern;
+DROP INDEX IF EXISTS idx_patients_extra;
+DROP INDEX IF EXISTS idx_patients_patient_hn_pattern;
+DROP INDEX IF EXISTS idx_patients_phone_reversed;
+DROP INDEX IF EXISTS idx_staffs_username_normalized;
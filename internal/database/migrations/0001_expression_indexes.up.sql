@@ -0,0 +1,29 @@
+-- What the GORM tags can't express: expression, operator-class and partial indexes. AutoMigrate
+-- creates the tables and the tagged indexes first; see Migrate.
+
+-- Case-insensitive usernames; the server backfills username_normalized before this runs
+CREATE UNIQUE INDEX IF NOT EXISTS idx_staffs_username_normalized ON staffs (username_normalized);
+
+-- text_pattern_ops lets LIKE 'prefix%' use the index regardless of the database collation
+CREATE INDEX IF NOT EXISTS idx_patients_phone_reversed ON patients (reverse(phone_number) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_patient_hn_pattern ON patients (patient_hn text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_extra ON patients USING gin (extra jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_first_name_th_pattern ON patients (first_name_th text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_first_name_en_pattern ON patients (first_name_en text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_middle_name_th_pattern ON patients (middle_name_th text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_middle_name_en_pattern ON patients (middle_name_en text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_last_name_th_pattern ON patients (last_name_th text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_last_name_en_pattern ON patients (last_name_en text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_first_name_th_folded_pattern ON patients (first_name_th_folded text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_middle_name_th_folded_pattern ON patients (middle_name_th_folded text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_last_name_th_folded_pattern ON patients (last_name_th_folded text_pattern_ops);
+
+-- One active admission per patient
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admissions_active_patient ON admissions (patient_id) WHERE status = 'admitted';
+
+-- Case-insensitive uniqueness of allergy substances and label names
+CREATE UNIQUE INDEX IF NOT EXISTS idx_allergies_patient_substance ON allergies (patient_id, LOWER(substance));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patient_labels_hospital_name ON patient_labels (hospital_id, LOWER(name));
+
+-- Full-text search of the ICD-10 reference table
+CREATE INDEX IF NOT EXISTS idx_icd10_codes_search ON icd10_codes USING GIN (to_tsvector('simple', code || ' ' || description));
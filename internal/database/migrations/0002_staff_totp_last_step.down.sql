@@ -0,0 +1 @@
+ALTER TABLE "staffs" DROP COLUMN IF EXISTS "totp_last_step";
@@ -0,0 +1,2 @@
+-- Newest TOTP time step accepted for each staff member; codes at or below it are refused as replays.
+ALTER TABLE "staffs" ADD COLUMN IF NOT EXISTS "totp_last_step" bigint NOT NULL DEFAULT 0;
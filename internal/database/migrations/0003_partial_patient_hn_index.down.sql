@@ -0,0 +1,3 @@
+-- Fails while a live patient shares its HN with a soft-deleted one; purge the deleted rows first.
+DROP INDEX IF EXISTS idx_hospital_hn;
+CREATE UNIQUE INDEX idx_hospital_hn ON patients (patient_hn);
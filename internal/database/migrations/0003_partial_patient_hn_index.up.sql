@@ -0,0 +1,5 @@
+-- Soft-deleted patients keep their HN, so a unique index over every row stops the HN from being
+-- used again once its patient is deleted. Only live patients need distinct HNs. The GORM tag
+-- creates this shape on new databases; older ones have the index over every row.
+DROP INDEX IF EXISTS idx_hospital_hn;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_hospital_hn ON patients (patient_hn) WHERE deleted_at IS NULL;
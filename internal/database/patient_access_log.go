@@ -0,0 +1,56 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- Patient Access Log Specific Functions ---
+
+// accessLogBatchSize bounds how many access log rows one INSERT writes, for searches returning
+// many patients.
+const accessLogBatchSize = 500
+
+// RecordPatientAccess stores access log entries.
+func RecordPatientAccess(entries []models.PatientAccessLog) error {
+	return DB.CreateInBatches(entries, accessLogBatchSize).Error
+}
+
+// ListPatientAccessLog returns a page of who read the patient, newest first, with the total count.
+func ListPatientAccessLog(patientID uint, offset, limit int) ([]models.PatientAccessLog, int64, error) {
+	return listPatientAccessLog(DB.Where("patient_id = ?", patientID), offset, limit)
+}
+
+// ListStaffAccessLog returns a page of the patient reads of a staff member of the hospital,
+// newest first, with the total count. It returns gorm.ErrRecordNotFound when the hospital has no
+// such staff member.
+func ListStaffAccessLog(staffID, hospitalID uint, offset, limit int) ([]models.PatientAccessLog, int64, error) {
+	var staff models.Staff
+	if err := DB.Select("id").Where("hospital_id = ?", hospitalID).First(&staff, staffID).Error; err != nil {
+		return nil, 0, err
+	}
+	return listPatientAccessLog(DB.Where("staff_id = ?", staffID), offset, limit)
+}
+
+func listPatientAccessLog(filter *gorm.DB, offset, limit int) ([]models.PatientAccessLog, int64, error) {
+	var entries []models.PatientAccessLog
+	var total int64
+
+	dbQuery := filter.Model(&models.PatientAccessLog{}).Session(&gorm.Session{})
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := dbQuery.Order("accessed_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return entries, total, nil
+}
+
+// DeletePatientAccessLogBefore removes access log entries recorded before the cutoff.
+func DeletePatientAccessLogBefore(before time.Time) (int64, error) {
+	result := DB.Where("accessed_at < ?", before).Delete(&models.PatientAccessLog{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Patient Document Specific Functions ---
+
+// CreatePatientDocument inserts document metadata and its audit entry in a single transaction.
+func CreatePatientDocument(doc *models.PatientDocument, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(doc).Error; err != nil {
+			return err
+		}
+		audit.EntityType = "patient_document"
+		audit.EntityID = doc.ID
+		return tx.Create(audit).Error
+	})
+}
+
+// ListPatientDocuments returns a page of a patient's documents, newest first, with the total count.
+func ListPatientDocuments(patientID uint, offset, limit int) ([]models.PatientDocument, int64, error) {
+	var docs []models.PatientDocument
+	var total int64
+
+	dbQuery := DB.Model(&models.PatientDocument{}).Where("patient_id = ?", patientID).Session(&gorm.Session{})
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := dbQuery.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&docs)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return docs, total, nil
+}
+
+// GetPatientDocument retrieves a single document belonging to the given patient.
+func GetPatientDocument(patientID, documentID uint) (*models.PatientDocument, error) {
+	var doc models.PatientDocument
+	result := DB.Where("patient_id = ?", patientID).First(&doc, documentID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &doc, nil
+}
+
+// DeletePatientDocument removes document metadata and records the audit entry in a single transaction.
+func DeletePatientDocument(doc *models.PatientDocument, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(doc).Error; err != nil {
+			return err
+		}
+		audit.EntityType = "patient_document"
+		audit.EntityID = doc.ID
+		return tx.Create(audit).Error
+	})
+}
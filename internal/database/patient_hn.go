@@ -0,0 +1,68 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+// autoGenerateHN is set by Open from AUTO_GENERATE_HN.
+var autoGenerateHN bool
+
+// hnGenerationAttempts is how many generated HNs a create tries before giving up, each one
+// after another create took the last.
+const hnGenerationAttempts = 5
+
+// createPatientWithGeneratedHN inserts a patient with the next free HN of its hospital, the
+// hospital's code and a sequence number such as HA-000042, in one transaction. Another create
+// may take the same HN between reading the highest one and inserting; the unique index then
+// rejects the insert and the next number is tried. patient.PatientHN is left empty on failure.
+func createPatientWithGeneratedHN(patient *models.Patient) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var hospital models.Hospital
+		if err := tx.Select("code").First(&hospital, patient.HospitalID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: id %d", ErrHospitalNotFound, patient.HospitalID)
+			}
+			return err
+		}
+
+		for attempt := 1; ; attempt++ {
+			hn, err := nextPatientHN(tx, hospital.Code)
+			if err != nil {
+				return err
+			}
+			patient.PatientHN = hn
+			// A savepoint, so a rejected insert doesn't abort the whole transaction
+			err = tx.Transaction(func(tx *gorm.DB) error {
+				return tx.Create(patient).Error
+			})
+			if err == nil || !IsUniqueViolation(err) || attempt == hnGenerationAttempts {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		patient.PatientHN = ""
+	}
+	return err
+}
+
+// nextPatientHN returns the HN after the highest generated one with the hospital's code. HNs are
+// unique across hospitals and deleted patients keep theirs, so patients transferred away and
+// soft-deleted ones are counted too. Numbers too long for a bigint are ignored.
+func nextPatientHN(tx *gorm.DB, hospitalCode string) (string, error) {
+	prefix := hospitalCode + "-"
+	var highest int64
+	err := tx.Unscoped().Model(&models.Patient{}).
+		Where("patient_hn ~ ?", "^"+regexp.QuoteMeta(prefix)+"[0-9]{1,18}$").
+		Select("COALESCE(MAX(CAST(substr(patient_hn, char_length(?) + 1) AS bigint)), 0)", prefix).
+		Scan(&highest).Error
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%06d", prefix, highest+1), nil
+}
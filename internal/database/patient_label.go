@@ -0,0 +1,76 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Patient Label Specific Functions ---
+
+// CreatePatientLabel stores a new label.
+func CreatePatientLabel(label *models.PatientLabel) error {
+	return DB.Create(label).Error
+}
+
+// ListPatientLabels returns the hospital's labels in name order.
+func ListPatientLabels(hospitalID uint) ([]models.PatientLabel, error) {
+	var labels []models.PatientLabel
+	err := DB.Where("hospital_id = ?", hospitalID).Order("LOWER(name) ASC, id ASC").Find(&labels).Error
+	return labels, err
+}
+
+// GetPatientLabel returns a label of the hospital. Labels of other hospitals are reported as not found.
+func GetPatientLabel(id, hospitalID uint) (*models.PatientLabel, error) {
+	var label models.PatientLabel
+	if err := DB.Where("id = ? AND hospital_id = ?", id, hospitalID).First(&label).Error; err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+// UpdatePatientLabel saves every field of a label loaded with GetPatientLabel.
+func UpdatePatientLabel(label *models.PatientLabel) error {
+	return DB.Save(label).Error
+}
+
+// DeletePatientLabel removes a label of the hospital along with its assignments.
+// Labels of other hospitals are reported as not found.
+func DeletePatientLabel(id, hospitalID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// Assignments first, as they reference the label
+		ownLabel := tx.Model(&models.PatientLabel{}).Select("id").Where("id = ? AND hospital_id = ?", id, hospitalID)
+		if err := tx.Where("label_id IN (?)", ownLabel).Delete(&models.PatientLabelAssignment{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND hospital_id = ?", id, hospitalID).Delete(&models.PatientLabel{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// AssignPatientLabel attaches a label to a patient. Assigning a label the patient already has is a no-op.
+// Callers check that both belong to the same hospital.
+func AssignPatientLabel(patientID, labelID uint) error {
+	assignment := &models.PatientLabelAssignment{PatientID: patientID, LabelID: labelID}
+	return DB.Clauses(clause.OnConflict{DoNothing: true}).Create(assignment).Error
+}
+
+// UnassignPatientLabel detaches a label from a patient, returning gorm.ErrRecordNotFound when
+// the patient does not have it.
+func UnassignPatientLabel(patientID, labelID uint) error {
+	result := DB.Where("patient_id = ? AND label_id = ?", patientID, labelID).Delete(&models.PatientLabelAssignment{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
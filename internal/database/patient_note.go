@@ -0,0 +1,74 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Patient Note Specific Functions ---
+
+// CreatePatientNote inserts a note and its audit entry in a single transaction.
+func CreatePatientNote(note *models.PatientNote, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(note).Error; err != nil {
+			return err
+		}
+		audit.EntityType = "patient_note"
+		audit.EntityID = note.ID
+		return tx.Create(audit).Error
+	})
+}
+
+// ListPatientNotes returns a page of the patient's notes that viewerID may see, pinned first then
+// newest, with the total count. Other staff members' private notes are left out.
+func ListPatientNotes(patientID, viewerID uint, offset, limit int) ([]models.PatientNote, int64, error) {
+	var notes []models.PatientNote
+	var total int64
+
+	dbQuery := DB.Model(&models.PatientNote{}).
+		Where("patient_id = ? AND (is_private = ? OR author_id = ?)", patientID, false, viewerID).
+		Session(&gorm.Session{})
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := dbQuery.Order("pinned DESC, created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&notes)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return notes, total, nil
+}
+
+// GetPatientNote retrieves a single note belonging to the given patient.
+func GetPatientNote(patientID, noteID uint) (*models.PatientNote, error) {
+	var note models.PatientNote
+	result := DB.Where("patient_id = ?", patientID).First(&note, noteID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &note, nil
+}
+
+// UpdatePatientNote saves changes to a note and records the audit entry in a single transaction.
+func UpdatePatientNote(note *models.PatientNote, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(note).Error; err != nil {
+			return err
+		}
+		audit.EntityType = "patient_note"
+		audit.EntityID = note.ID
+		return tx.Create(audit).Error
+	})
+}
+
+// DeletePatientNote soft-deletes a note and records the audit entry in a single transaction.
+func DeletePatientNote(note *models.PatientNote, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(note).Error; err != nil {
+			return err
+		}
+		audit.EntityType = "patient_note"
+		audit.EntityID = note.ID
+		return tx.Create(audit).Error
+	})
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Patient Tag Specific Functions ---
+
+// AddPatientTag tags a patient of the hospital, creating the hospital's tag on first use.
+// Tagging a patient with a tag it already has is a no-op. name must already be normalized, and
+// callers check that the patient belongs to the hospital.
+func AddPatientTag(patientID, hospitalID uint, name string) (*models.Tag, error) {
+	tag := models.Tag{HospitalID: hospitalID, Name: name}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// DoNothing leaves a concurrently created tag in place, so it is read back either way
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Tag{HospitalID: hospitalID, Name: name}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("hospital_id = ? AND name = ?", hospitalID, name).First(&tag).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.PatientTag{PatientID: patientID, TagID: tag.ID}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// RemovePatientTag removes a tag of the hospital from a patient, returning gorm.ErrRecordNotFound
+// when the patient does not have it. The tag itself stays, even with no patients left.
+func RemovePatientTag(patientID, hospitalID uint, name string) error {
+	tagID := DB.Model(&models.Tag{}).Select("id").Where("hospital_id = ? AND name = ?", hospitalID, name)
+	result := DB.Where("patient_id = ? AND tag_id IN (?)", patientID, tagID).Delete(&models.PatientTag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListTagCounts returns the hospital's tags in name order, each with how many of the hospital's
+// patients carry it. Soft-deleted patients are not counted.
+func ListTagCounts(hospitalID uint) ([]models.TagCount, error) {
+	var counts []models.TagCount
+	err := DB.Table("tags").
+		Select("tags.id, tags.name, COUNT(patients.id) AS patient_count").
+		Joins("LEFT JOIN patient_tags ON patient_tags.tag_id = tags.id").
+		Joins("LEFT JOIN patients ON patients.id = patient_tags.patient_id AND patients.deleted_at IS NULL").
+		Where("tags.hospital_id = ?", hospitalID).
+		Group("tags.id, tags.name").
+		Order("tags.name ASC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// DeleteTag removes a tag from the hospital and from every patient carrying it, in one transaction.
+// It returns gorm.ErrRecordNotFound when the hospital has no such tag.
+func DeleteTag(hospitalID uint, name string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Where("hospital_id = ? AND name = ?", hospitalID, name).First(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.PatientTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+}
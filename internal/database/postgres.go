@@ -1,33 +1,103 @@
 package database
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"hospital-middleware/internal/config"
 	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
 )
 
 // DB is the global database connection instance.
 var DB *gorm.DB
 
-// Connect initializes the database connection using GORM.
+// ErrHospitalNotFound is returned when a hospital name does not map to a known hospital.
+var ErrHospitalNotFound = errors.New("hospital not found")
+
+// ErrLastAdmin is returned by UpdateStaffRole when the change would leave a hospital without an active admin.
+var ErrLastAdmin = errors.New("cannot remove last admin")
+
+// ErrNationalIDNotUnique is returned by GetPatientByNationalID when several patients of the hospital
+// share the national ID.
+var ErrNationalIDNotUnique = errors.New("national ID matches several patients")
+
+// Connect initializes the database connection using GORM and migrates the schema. With
+// cfg.AutoMigrate off it only checks that every table exists, so a server started against a
+// database nobody migrated fails at once rather than on its first request.
 func Connect(cfg *config.Config) error {
+	if err := Open(cfg); err != nil {
+		return err
+	}
+	if !cfg.AutoMigrate {
+		log.Println("AUTO_MIGRATE is off; skipping database migrations")
+		return VerifySchema()
+	}
+	return Migrate(cfg)
+}
+
+// schemaModels are the models whose tables Migrate creates and VerifySchema requires.
+var schemaModels = []interface{}{
+	&models.Hospital{}, &models.Staff{}, &models.Patient{}, &models.Visit{}, &models.Admission{}, &models.Referral{}, &models.ICD10Code{}, &models.PatientDiagnosis{}, &models.Allergy{}, &models.Consent{}, &models.PatientNote{}, &models.PatientDocument{}, &models.AuditLog{}, &models.HospitalConfig{}, &models.RevokedToken{}, &models.SearchHistory{}, &models.APIKey{}, &models.SavedSearch{}, &models.Webhook{}, &models.WebhookDeadLetter{}, &models.RecentlyViewed{}, &models.PatientLabel{}, &models.PatientLabelAssignment{}, &models.ConsortiumMembership{}, &models.Tag{}, &models.PatientTag{}, &models.Job{}, &models.CachedReport{}, &models.PatientAccessLog{},
+}
+
+// SchemaTables returns the names of the tables of the schema models. It needs no connection.
+func SchemaTables() ([]string, error) {
+	tables := make([]string, 0, len(schemaModels))
+	cache := &sync.Map{}
+	for _, model := range schemaModels {
+		parsed, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the table of %T: %w", model, err)
+		}
+		tables = append(tables, parsed.Table)
+	}
+	return tables, nil
+}
+
+// VerifySchema checks that the table of every model exists. Columns are not compared: it catches
+// a database that was never migrated, not one a release behind.
+func VerifySchema() error {
+	tables, err := SchemaTables()
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, table := range tables {
+		if !DB.Migrator().HasTable(table) {
+			missing = append(missing, table)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("AUTO_MIGRATE is off but the database has no %s table(s); migrate it with the seed command (go run ./cmd/seed) or set AUTO_MIGRATE=true",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Open initializes the database connection using GORM without touching the schema.
+func Open(cfg *config.Config) error {
 	var err error
 	log.Printf("Connecting to database %s on %s:%s...", cfg.DBName, cfg.DBHost, cfg.DBPort)
-	log.Printf("DEBUG: Using configuration: %+v", cfg)
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=Asia/Bangkok", // Adjust TimeZone if needed
-		cfg.DBHost,
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBName,
-		cfg.DBPort,
-		cfg.DBSSLMode,
-	)
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid TIMEZONE %q: %w", cfg.Timezone, err)
+	}
+	dateLocation = location
+	autoGenerateHN = cfg.AutoGenerateHN
+	dsn := DSN(cfg)
 
 	// Configure GORM logger
 	newLogger := logger.New(
@@ -50,18 +120,201 @@ func Connect(cfg *config.Config) error {
 
 	log.Println("Database connection successfully established")
 
+	if cfg.DBReplicaDSN == "" {
+		replicaEnabled = false
+		log.Println("No read replica configured; searches read from the primary")
+		return nil
+	}
+	// Registered under a name rather than globally, so only queries that ask for the replica use it
+	err = DB.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{postgres.Open(cfg.DBReplicaDSN)},
+	}, replicaResolver))
+	if err != nil {
+		return fmt.Errorf("failed to connect to read replica: %w", err)
+	}
+	replicaEnabled = true
+	log.Println("Read replica in use for patient searches and staff lookups")
+	return nil
+}
+
+// DSN builds the keyword/value connection string for the primary database, including the SSL
+// certificate paths that are set. Values are quoted, so passwords and paths may contain spaces.
+func DSN(cfg *config.Config) string {
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
+		dsnValue(cfg.DBHost),
+		dsnValue(cfg.DBUser),
+		dsnValue(cfg.DBPassword),
+		dsnValue(cfg.DBName),
+		dsnValue(cfg.DBPort),
+		dsnValue(cfg.DBSSLMode),
+		dsnValue(cfg.Timezone),
+	)
+	for _, param := range []struct{ key, value string }{
+		{"sslcert", cfg.DBSSLCert},
+		{"sslkey", cfg.DBSSLKey},
+		{"sslrootcert", cfg.DBSSLRootCert},
+	} {
+		if param.value != "" {
+			dsn += " " + param.key + "=" + dsnValue(param.value)
+		}
+	}
+	return dsn
+}
+
+// dsnValue quotes a connection string value, escaping backslashes and single quotes.
+func dsnValue(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + value + "'"
+}
+
+// dateLocation is the configured TIMEZONE, set by Open. Dates of birth in searches name a day
+// in this zone, the same zone the database session reads date literals in.
+var dateLocation = time.UTC
+
+// replicaResolver names the dbresolver configuration that holds the read replica.
+const replicaResolver = "read_replica"
+
+// replicaEnabled is set by Open when DB_REPLICA_DSN is configured.
+var replicaEnabled bool
+
+// readDB returns the connection for read-only queries that tolerate replication lag.
+// It is the read replica when one is configured and the primary otherwise.
+func readDB() *gorm.DB {
+	if !replicaEnabled {
+		return DB
+	}
+	return DB.Clauses(dbresolver.Use(replicaResolver), dbresolver.Read)
+}
+
+// Migrate brings the schema up to date with AutoMigrate and the versioned migration files,
+// backfills new columns and seeds the default hospitals. Every step is idempotent, so it is safe
+// to run on each start or from the seed command.
+func Migrate(cfg *config.Config) error {
 	// Auto-migrate the schema
 	// Create tables, columns, and indexes based on GORM models.
 	log.Println("Running database migrations...")
-	err = DB.AutoMigrate(&models.Staff{}, &models.Patient{})
+	err := DB.AutoMigrate(schemaModels...)
 	if err != nil {
 		return fmt.Errorf("failed to auto-migrate database schema: %w", err)
 	}
+	if err := migrateStaffUsernames(DB); err != nil {
+		return fmt.Errorf("failed to migrate staff usernames: %w", err)
+	}
+	// The migration files hold what AutoMigrate can't express, such as expression and partial indexes
+	if _, err := ApplyMigrations(DB); err != nil {
+		return err
+	}
+	if err := loadICD10Codes(DB, cfg.ICD10CodesPath); err != nil {
+		return fmt.Errorf("failed to load ICD-10 codes: %w", err)
+	}
+	if err := backfillRecordHospitals(DB); err != nil {
+		return fmt.Errorf("failed to backfill hospital IDs: %w", err)
+	}
+	if err := backfillDeceasedFlags(DB); err != nil {
+		return fmt.Errorf("failed to backfill deceased flags: %w", err)
+	}
+	if err := backfillThaiNames(DB); err != nil {
+		return fmt.Errorf("failed to backfill normalized Thai names: %w", err)
+	}
+	if err := backfillConsentStatus(DB); err != nil {
+		return fmt.Errorf("failed to backfill consent statuses: %w", err)
+	}
+	if err := seedHospitals(DB); err != nil {
+		return err
+	}
 	log.Println("Database migrations completed.")
 
 	return nil
 }
 
+// migrateStaffUsernames fills in the normalized username of staff created before the column
+// existed, then makes it unique. Usernames that differ only in case cannot both keep their
+// accounts, so they are reported for an operator to rename rather than guessed at.
+func migrateStaffUsernames(db *gorm.DB) error {
+	if err := db.Exec("UPDATE staffs SET username_normalized = lower(username) WHERE username_normalized = ''").Error; err != nil {
+		return err
+	}
+	var clashes []string
+	if err := db.Raw(`SELECT string_agg(username, ', ' ORDER BY username) FROM staffs
+		GROUP BY username_normalized HAVING count(*) > 1`).Scan(&clashes).Error; err != nil {
+		return err
+	}
+	if len(clashes) > 0 {
+		return fmt.Errorf("usernames differing only in case must be renamed first: %s", strings.Join(clashes, "; "))
+	}
+	return db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_staffs_username_normalized ON staffs (username_normalized)").Error
+}
+
+// backfillDeceasedFlags sets the deceased flag on patients marked deceased before the column existed.
+// Deceased is a final status, so the flag never needs clearing.
+func backfillDeceasedFlags(db *gorm.DB) error {
+	return db.Model(&models.Patient{}).Unscoped().
+		Where("status = ? AND NOT deceased", models.PatientStatusDeceased).
+		UpdateColumn("deceased", true).Error
+}
+
+// backfillConsentStatus brings the consent status of patients in line with their latest data
+// sharing consent, for decisions recorded before CreateConsent kept the two together. Patients
+// with no recorded decision keep the status they have.
+func backfillConsentStatus(db *gorm.DB) error {
+	return db.Exec(`UPDATE patients SET consent_status = latest.status, consent_updated_at = latest.created_at
+		FROM (SELECT DISTINCT ON (patient_id) patient_id, created_at,
+				CASE WHEN granted THEN ? ELSE ? END AS status
+			FROM consents WHERE consent_type = ? ORDER BY patient_id, id DESC) AS latest
+		WHERE patients.id = latest.patient_id AND patients.consent_status <> latest.status`,
+		models.ConsentStatusGranted, models.ConsentStatusDenied, models.ConsentTypeDataSharing).Error
+}
+
+// backfillRecordHospitals sets hospital_id on patient records created before the column existed,
+// using the hospital the patient belongs to.
+func backfillRecordHospitals(db *gorm.DB) error {
+	for _, table := range []string{"patient_notes", "patient_documents"} {
+		statement := fmt.Sprintf(`UPDATE %[1]s SET hospital_id = patients.hospital_id
+			FROM patients WHERE patients.id = %[1]s.patient_id AND %[1]s.hospital_id = 0`, table)
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// thaiNameBackfillBatchSize is how many patients backfillThaiNames updates per transaction.
+const thaiNameBackfillBatchSize = 1000
+
+// backfillThaiNames normalizes the Thai names of patients saved before the folded columns existed,
+// which BeforeSave has kept up to date since. Deleted patients are included.
+func backfillThaiNames(db *gorm.DB) error {
+	var patients []models.Patient
+	result := db.Unscoped().Model(&models.Patient{}).
+		Select("id", "first_name_th", "middle_name_th", "last_name_th").
+		Where("first_name_th_folded IS NULL").
+		FindInBatches(&patients, thaiNameBackfillBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range patients {
+				patient := &patients[i]
+				patient.NormalizeThaiNames()
+				// UpdateColumns skips the hooks and leaves updated_at alone
+				if err := tx.Unscoped().Model(patient).UpdateColumns(map[string]interface{}{
+					"first_name_th":         patient.FirstNameTH,
+					"middle_name_th":        patient.MiddleNameTH,
+					"last_name_th":          patient.LastNameTH,
+					"first_name_th_folded":  patient.FirstNameTHFolded,
+					"middle_name_th_folded": patient.MiddleNameTHFolded,
+					"last_name_th_folded":   patient.LastNameTHFolded,
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Normalized Thai names of %d patients", result.RowsAffected)
+	}
+	return nil
+}
+
 // GetDB returns the initialized database connection instance.
 func GetDB() *gorm.DB {
 	return DB
@@ -75,107 +328,661 @@ func CreateStaff(staff *models.Staff) error {
 	return result.Error
 }
 
-// FindStaffByUsername retrieves a staff member by their username.
+// FindStaffByUsername retrieves a staff member by their username, ignoring case.
 func FindStaffByUsername(username string) (*models.Staff, error) {
 	var staff models.Staff
-	result := DB.Where("username = ?", username).First(&staff)
+	result := readDB().Where("username_normalized = ?", models.NormalizeUsername(username)).First(&staff)
 	if result.Error != nil {
 		return nil, result.Error // Could be gorm.ErrRecordNotFound or other DB error
 	}
 	return &staff, nil
 }
 
+// FindExistingUsernames returns which of the given usernames are already taken, ignoring case.
+// The taken ones are returned normalized with models.NormalizeUsername.
+func FindExistingUsernames(usernames []string) ([]string, error) {
+	var existing []string
+	if len(usernames) == 0 {
+		return existing, nil
+	}
+	normalized := make([]string, len(usernames))
+	for i, username := range usernames {
+		normalized[i] = models.NormalizeUsername(username)
+	}
+	err := DB.Model(&models.Staff{}).Where("username_normalized IN ?", normalized).Pluck("username_normalized", &existing).Error
+	return existing, err
+}
+
+// CreateStaffBatch inserts several staff members in a single statement, so either all are saved or none are.
+func CreateStaffBatch(staff []models.Staff) error {
+	return DB.Create(&staff).Error
+}
+
+// errStaffBatchRehearsed rolls back the transaction of RehearseStaffBatch.
+var errStaffBatchRehearsed = errors.New("staff batch rehearsed")
+
+// RehearseStaffBatch inserts staff like CreateStaffBatch inside a transaction that is always
+// rolled back, so database constraints are checked without saving anything.
+func RehearseStaffBatch(staff []models.Staff) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&staff).Error; err != nil {
+			return err
+		}
+		return errStaffBatchRehearsed
+	})
+	if errors.Is(err, errStaffBatchRehearsed) {
+		return nil
+	}
+	return err
+}
+
+// RecordStaffLogin stores the time of a staff member's latest successful login.
+func RecordStaffLogin(staffID uint, at time.Time) error {
+	return DB.Model(&models.Staff{}).Where("id = ?", staffID).Update("last_login_at", at).Error
+}
+
+// StreamStaffByHospital calls fn for each staff member of the hospital in ID order, at most limit
+// of them, reading rows one at a time instead of loading the whole list.
+func StreamStaffByHospital(hospitalID uint, limit int, fn func(*models.Staff) error) error {
+	rows, err := DB.Model(&models.Staff{}).Where("hospital_id = ?", hospitalID).Order("id ASC").Limit(limit).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var staff models.Staff
+		if err := DB.ScanRows(rows, &staff); err != nil {
+			return err
+		}
+		if err := fn(&staff); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UpdateStaffPassword replaces the stored password hash for a staff member.
+func UpdateStaffPassword(staffID uint, passwordHash string) error {
+	result := DB.Model(&models.Staff{}).Where("id = ?", staffID).Update("password_hash", passwordHash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetStaffTOTPSecret stores a new TOTP secret and disables two-factor authentication until it is confirmed.
+func SetStaffTOTPSecret(staffID uint, secret string) error {
+	result := DB.Model(&models.Staff{}).Where("id = ?", staffID).
+		Updates(map[string]interface{}{"totp_secret": secret, "totp_enabled": false, "totp_last_step": 0})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// EnableStaffTOTP turns on two-factor authentication for a staff member with a confirmed secret.
+// step is the time step of the confirming code, which can't then be used again to log in.
+func EnableStaffTOTP(staffID uint, step int64) error {
+	result := DB.Model(&models.Staff{}).Where("id = ?", staffID).
+		Updates(map[string]interface{}{"totp_enabled": true, "totp_last_step": step})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ClaimStaffTOTPStep records step as the staff member's newest accepted TOTP time step.
+// It reports false when a code from that step or a later one was already accepted; the
+// conditional update makes two concurrent logins with the same code unable to both succeed.
+func ClaimStaffTOTPStep(staffID uint, step int64) (bool, error) {
+	result := DB.Model(&models.Staff{}).Where("id = ? AND totp_last_step < ?", staffID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateStaffRole changes the role of a staff member of the given hospital and returns the updated record.
+// It returns gorm.ErrRecordNotFound when the staff member is not in that hospital, and ErrLastAdmin
+// when it would demote the hospital's only active admin.
+func UpdateStaffRole(staffID, hospitalID uint, role string) (*models.Staff, error) {
+	var staff models.Staff
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// Locking the hospital's admins makes concurrent demotions wait, so two admins can't each
+		// demote the other believing one remains
+		var adminIDs []uint
+		if err := tx.Model(&models.Staff{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("hospital_id = ? AND role = ? AND is_active = ?", hospitalID, models.RoleAdmin, true).
+			Pluck("id", &adminIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("hospital_id = ?", hospitalID).First(&staff, staffID).Error; err != nil {
+			return err
+		}
+		if staff.Role == models.RoleAdmin && role != models.RoleAdmin && staff.IsActive && len(adminIDs) <= 1 {
+			return ErrLastAdmin
+		}
+		staff.Role = role
+		return tx.Model(&staff).Update("role", role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
 // --- Patient Specific Functions ---
 
+// CreatePatient inserts a patient. When AUTO_GENERATE_HN is on, a patient without a PatientHN is
+// given a generated one; see createPatientWithGeneratedHN.
 func CreatePatient(patient *models.Patient) error {
+	if patient.PatientHN == "" && autoGenerateHN {
+		return createPatientWithGeneratedHN(patient)
+	}
 	result := DB.Create(patient)
 	return result.Error
 }
 
+// GetPatientByID retrieves a patient by primary key.
+func GetPatientByID(id uint) (*models.Patient, error) {
+	var patient models.Patient
+	result := DB.First(&patient, id)
+	if result.Error != nil {
+		return nil, result.Error // Could be gorm.ErrRecordNotFound or other DB error
+	}
+	return &patient, nil
+}
+
+// GetPatientByNationalID retrieves the hospital's patient with the national ID. It returns
+// gorm.ErrRecordNotFound when there is none and ErrNationalIDNotUnique when there are several.
+func GetPatientByNationalID(nationalID string, hospitalID uint) (*models.Patient, error) {
+	var patients []models.Patient
+	result := DB.Where("national_id = ? AND hospital_id = ?", nationalID, hospitalID).Order("id").Limit(2).Find(&patients)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	switch len(patients) {
+	case 0:
+		return nil, gorm.ErrRecordNotFound
+	case 1:
+		return &patients[0], nil
+	default:
+		return nil, ErrNationalIDNotUnique
+	}
+}
+
+// GetPatientsByHNList retrieves the hospital's patients whose HN is in the list, in no particular
+// order. HNs without a patient are left out.
+func GetPatientsByHNList(hns []string, hospitalID uint) ([]models.Patient, error) {
+	var patients []models.Patient
+	result := readDB().Where("patient_hn IN (?) AND hospital_id = ?", hns, hospitalID).Find(&patients)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return patients, nil
+}
+
+// SoftDeletePatientsByHospital soft-deletes every patient of the hospital and returns how many were deleted.
+func SoftDeletePatientsByHospital(hospitalID uint) (int64, error) {
+	result := DB.Where("hospital_id = ?", hospitalID).Delete(&models.Patient{})
+	return result.RowsAffected, result.Error
+}
+
+// UpdatePatientStatus saves a patient's new status, deceased flag and deceased time and records the audit entry in
+// one transaction. It returns gorm.ErrRecordNotFound if the status changed since the patient was loaded.
+func UpdatePatientStatus(patient *models.Patient, previousStatus string, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Patient{}).
+			Where("id = ? AND status = ?", patient.ID, previousStatus).
+			Updates(map[string]interface{}{"status": patient.Status, "deceased_at": patient.DeceasedAt})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		audit.EntityType = "patient"
+		audit.EntityID = patient.ID
+		return tx.Create(audit).Error
+	})
+}
+
+// UpdatePatientFields applies column updates to a patient and records the audit entry in one transaction.
+// It returns gorm.ErrRecordNotFound if the patient is no longer in the given hospital.
+func UpdatePatientFields(patientID, hospitalID uint, updates map[string]interface{}, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Patient{}).Where("id = ? AND hospital_id = ?", patientID, hospitalID).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		audit.EntityType = "patient"
+		audit.EntityID = patientID
+		return tx.Create(audit).Error
+	})
+}
+
+// TransferPatient moves a patient to another hospital and records the audit entry in one transaction.
+// It returns gorm.ErrRecordNotFound if the patient is no longer in the hospital it was loaded from.
+func TransferPatient(patient *models.Patient, targetHospitalID uint, audit *models.AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Patient{}).
+			Where("id = ? AND hospital_id = ?", patient.ID, patient.HospitalID).
+			Update("hospital_id", targetHospitalID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		audit.EntityType = "patient"
+		audit.EntityID = patient.ID
+		if err := tx.Create(audit).Error; err != nil {
+			return err
+		}
+		patient.HospitalID = targetHospitalID
+		return nil
+	})
+}
+
 // SearchPatients searches for patients based on criteria and hospital ID.
-func SearchPatients(query *models.PatientSearchQuery, hospitalID uint) ([]models.Patient, error) {
+// A positive limit caps the number of results; zero returns every match.
+// With query.CrossHospital it also searches the other hospitals of hospitalID's consortiums,
+// returning only their patients who granted data sharing, each with SourceHospitalName set.
+func SearchPatients(query *models.PatientSearchQuery, hospitalID uint, limit int) ([]models.Patient, error) {
 	var patients []models.Patient
-	dbQuery := DB.Model(&models.Patient{}).Where("hospital_id = ?", hospitalID)
-
-	if query.NationalID != nil && *query.NationalID != "" {
-		dbQuery = dbQuery.Where("national_id = ?", *query.NationalID)
-	}
-	if query.PassportID != nil && *query.PassportID != "" {
-		dbQuery = dbQuery.Where("passport_id = ?", *query.PassportID)
-	}
-
-	// Handle First Name Search (Thai and English, Combined)
-	if (query.FirstNameTH != nil && *query.FirstNameTH != "") && (query.FirstNameEN != nil && *query.FirstNameEN != "") {
-		nameLikeTH := "%" + *query.FirstNameTH + "%"
-		nameLikeEN := "%" + *query.FirstNameEN + "%"
-		dbQuery = dbQuery.Where("first_name_th LIKE ? OR first_name_en LIKE ?", nameLikeTH, nameLikeEN)
-	} else if query.FirstNameTH != nil && *query.FirstNameTH != "" {
-		nameLike := "%" + *query.FirstNameTH + "%"
-		dbQuery = dbQuery.Where("first_name_th LIKE ?", nameLike)
-	} else if query.FirstNameEN != nil && *query.FirstNameEN != "" {
-		nameLike := "%" + *query.FirstNameEN + "%"
-		dbQuery = dbQuery.Where("first_name_en LIKE ?", nameLike)
-	}
-
-	// Handle Middle Name Search (Thai and English, Combined)
-	if (query.MiddleNameTH != nil && *query.MiddleNameTH != "") && (query.MiddleNameEN != nil && *query.MiddleNameEN != "") {
-		nameLikeTH := "%" + *query.MiddleNameTH + "%"
-		nameLikeEN := "%" + *query.MiddleNameEN + "%"
-		dbQuery = dbQuery.Where("middle_name_th LIKE ? OR middle_name_en LIKE ?", nameLikeTH, nameLikeEN)
-	} else if query.MiddleNameTH != nil && *query.MiddleNameTH != "" {
-		nameLike := "%" + *query.MiddleNameTH + "%"
-		dbQuery = dbQuery.Where("middle_name_th LIKE ?", nameLike)
-	} else if query.MiddleNameEN != nil && *query.MiddleNameEN != "" {
-		nameLike := "%" + *query.MiddleNameEN + "%"
-		dbQuery = dbQuery.Where("middle_name_en LIKE ?", nameLike)
-	}
-
-	// Handle Last Name Search (Thai and English, Combined)
-	if (query.LastNameTH != nil && *query.LastNameTH != "") && (query.LastNameEN != nil && *query.LastNameEN != "") {
-		nameLikeTH := "%" + *query.LastNameTH + "%"
-		nameLikeEN := "%" + *query.LastNameEN + "%"
-		dbQuery = dbQuery.Where("last_name_th LIKE ? OR last_name_en LIKE ?", nameLikeTH, nameLikeEN)
-	} else if query.LastNameTH != nil && *query.LastNameTH != "" {
-		nameLike := "%" + *query.LastNameTH + "%"
-		dbQuery = dbQuery.Where("last_name_th LIKE ?", nameLike)
-	} else if query.LastNameEN != nil && *query.LastNameEN != "" {
-		nameLike := "%" + *query.LastNameEN + "%"
-		dbQuery = dbQuery.Where("last_name_en LIKE ?", nameLike)
+	dbQuery, err := buildSearchPatients(query, hospitalID, limit)
+	if err != nil {
+		return nil, err
+	}
+	result := dbQuery.Preload("Labels").Preload("Tags").Find(&patients)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return patients, nil
+}
+
+// ExplainSearchPatients returns the PostgreSQL plan, as EXPLAIN (FORMAT JSON) gives it, of the
+// query SearchPatients runs for the same arguments. The query itself is not run.
+func ExplainSearchPatients(query *models.PatientSearchQuery, hospitalID uint, limit int) (json.RawMessage, error) {
+	dbQuery, err := buildSearchPatients(query, hospitalID, limit)
+	if err != nil {
+		return nil, err
+	}
+	// A dry run builds the SQL and its bind variables without sending them
+	stmt := dbQuery.Session(&gorm.Session{DryRun: true}).Find(&[]models.Patient{}).Statement
+	var plan string
+	if err := readDB().Raw("EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).Row().Scan(&plan); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(plan), nil
+}
+
+// buildSearchPatients builds the patient query of SearchPatients, without its preloads.
+func buildSearchPatients(query *models.PatientSearchQuery, hospitalID uint, limit int) (*gorm.DB, error) {
+	dbQuery := buildPatientSearch(query, hospitalID)
+	if query.CrossHospital {
+		hospitalIDs, err := ConsortiumHospitalIDs(hospitalID)
+		if err != nil {
+			return nil, err
+		}
+		dbQuery = buildConsortiumPatientSearch(query, hospitalIDs, hospitalID)
+	}
+	if limit > 0 {
+		dbQuery = dbQuery.Order("id ASC").Limit(limit)
+	}
+	return dbQuery, nil
+}
+
+// SearchPatientsPage is SearchPatients limited to one page of results, ordered by ID,
+// along with the total number of matches.
+func SearchPatientsPage(query *models.PatientSearchQuery, hospitalID uint, offset, limit int) ([]models.Patient, int64, error) {
+	var patients []models.Patient
+
+	dbQuery, err := buildSearchPatients(query, hospitalID, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Session makes the built query safe to reuse for both the count and the page
+	dbQuery = dbQuery.Session(&gorm.Session{})
+	total, err := countMatches(dbQuery)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := dbQuery.Order("id ASC").Offset(offset).Limit(limit).Preload("Labels").Preload("Tags").Find(&patients)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return patients, total, nil
+}
+
+// SearchPatientsAcrossHospitals searches patients in the given hospitals, or in every hospital when
+// hospitalIDs is nil, resolving each row's hospital name. Patients of hospitals other than
+// viewerHospitalID who refused data sharing are left out. A positive limit caps the number of results.
+func SearchPatientsAcrossHospitals(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint, limit int) ([]models.PatientWithHospital, error) {
+	var patients []models.PatientWithHospital
+	dbQuery := buildSearchPatientsAcrossHospitals(query, hospitalIDs, viewerHospitalID).Order("patients.id ASC")
+	if limit > 0 {
+		dbQuery = dbQuery.Limit(limit)
+	}
+	if err := dbQuery.Find(&patients).Error; err != nil {
+		return nil, err
+	}
+	return patients, nil
+}
+
+// SearchPatientsAcrossHospitalsPage is SearchPatientsAcrossHospitals limited to one page of results,
+// along with the total number of matches.
+func SearchPatientsAcrossHospitalsPage(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint, offset, limit int) ([]models.PatientWithHospital, int64, error) {
+	var patients []models.PatientWithHospital
+	dbQuery := buildSearchPatientsAcrossHospitals(query, hospitalIDs, viewerHospitalID).Session(&gorm.Session{})
+	total, err := countMatches(dbQuery)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := dbQuery.Order("patients.id ASC").Offset(offset).Limit(limit).Find(&patients).Error; err != nil {
+		return nil, 0, err
+	}
+	return patients, total, nil
+}
+
+// buildSearchPatientsAcrossHospitals builds the unordered query of SearchPatientsAcrossHospitals.
+func buildSearchPatientsAcrossHospitals(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint) *gorm.DB {
+	dbQuery := readDB().Table("patients").
+		Select("patients.*, hospitals.name AS hospital_name").
+		Joins("JOIN hospitals ON hospitals.id = patients.hospital_id")
+	if hospitalIDs != nil {
+		dbQuery = dbQuery.Where("patients.hospital_id IN ?", hospitalIDs)
+	}
+	dbQuery = sharesDataWith(dbQuery, viewerHospitalID)
+	return applyPatientSearchCriteria(dbQuery, query)
+}
+
+// countMatches counts the rows dbQuery returns. Counting over it as a subquery keeps a custom
+// SELECT list, which Count would otherwise try to turn into COUNT(...), out of the way.
+func countMatches(dbQuery *gorm.DB) (int64, error) {
+	var total int64
+	err := readDB().Table("(?) AS matches", dbQuery).Count(&total).Error
+	return total, err
+}
+
+// StreamPatients calls fn for each patient of the hospital matching the search criteria in ID order,
+// reading rows one at a time instead of loading every match. The query is cancelled when ctx is done.
+func StreamPatients(ctx context.Context, query *models.PatientSearchQuery, hospitalID uint, fn func(*models.Patient) error) error {
+	rows, err := buildPatientSearch(query, hospitalID).WithContext(ctx).Order("id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var patient models.Patient
+		if err := DB.ScanRows(rows, &patient); err != nil {
+			return err
+		}
+		if err := fn(&patient); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// buildPatientSearch applies the search criteria and hospital scope to a patient query.
+func buildPatientSearch(query *models.PatientSearchQuery, hospitalID uint) *gorm.DB {
+	dbQuery := readDB().Model(&models.Patient{}).Where("hospital_id = ?", hospitalID)
+	return applyPatientSearchCriteria(dbQuery, query)
+}
+
+// buildConsortiumPatientSearch is buildPatientSearch over the given hospitals. Patients of hospitals
+// other than viewerHospitalID must have granted data sharing (their consent status, as for
+// sharesDataWith, but opt-in), and get their hospital's name as source_hospital_name.
+func buildConsortiumPatientSearch(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint) *gorm.DB {
+	dbQuery := readDB().Model(&models.Patient{}).
+		Select(`patients.*, CASE WHEN patients.hospital_id <> ? THEN
+			(SELECT hospitals.name FROM hospitals WHERE hospitals.id = patients.hospital_id) END AS source_hospital_name`, viewerHospitalID).
+		Where("patients.hospital_id IN ?", hospitalIDs).
+		Where("patients.hospital_id = ? OR patients.consent_status = ?", viewerHospitalID, models.ConsentStatusGranted)
+	return applyPatientSearchCriteria(dbQuery, query)
+}
+
+// applyIdentifierCriterion matches column against a single value, or any value of a comma-separated list.
+func applyIdentifierCriterion(dbQuery *gorm.DB, column string, value *string) *gorm.DB {
+	if value == nil || *value == "" {
+		return dbQuery
+	}
+	values := models.IdentifierValues(*value)
+	if len(values) == 1 {
+		return dbQuery.Where(column+" = ?", values[0])
+	}
+	return dbQuery.Where(column+" IN ?", values)
+}
+
+// applyPatientSearchCriteria adds a WHERE clause for every provided search field.
+// Column names are unqualified, so callers joining other tables must not select clashing columns.
+func applyPatientSearchCriteria(dbQuery *gorm.DB, query *models.PatientSearchQuery) *gorm.DB {
+	// Identifiers pin down a patient, so they narrow the search whatever the match mode
+	dbQuery = applyIdentifierCriterion(dbQuery, "national_id", query.NationalID)
+	dbQuery = applyIdentifierCriterion(dbQuery, "passport_id", query.PassportID)
+	dbQuery = applyIdentifierCriterion(dbQuery, "patient_hn", query.PatientHN)
+	if query.PatientHNPrefix != nil && *query.PatientHNPrefix != "" {
+		// Anchored so idx_patients_patient_hn_pattern applies; escaped so "%" in the input cannot widen it
+		dbQuery = dbQuery.Where("patient_hn LIKE ? || '%'"+likeEscapeClause, escapeLike(*query.PatientHNPrefix))
+	}
+
+	dbQuery = combinePredicates(dbQuery, patientSearchPredicates(query), query.MatchMode())
+
+	// Deceased and transferred patients only show up when asked for
+	if !query.IncludeInactive {
+		dbQuery = dbQuery.Where("patients.status NOT IN ?", []string{models.PatientStatusDeceased, models.PatientStatusTransferred})
+	} else if query.ExcludeDeceased {
+		dbQuery = dbQuery.Where("patients.deceased = ?", false)
+	}
+
+	if query.ConsentedOnly {
+		dbQuery = dbQuery.Where("patients.consent_status = ?", models.ConsentStatusGranted)
+	}
+	if query.DoNotContact != nil {
+		dbQuery = dbQuery.Where("patients.do_not_contact = ?", *query.DoNotContact)
+	}
+	if len(query.Extra) > 0 {
+		// Containment, so idx_patients_extra applies. A map of strings always encodes.
+		criteria, _ := json.Marshal(query.Extra)
+		dbQuery = dbQuery.Where("patients.extra @> ?::jsonb", string(criteria))
+	}
+	if len(query.Tags) > 0 {
+		// Every tag must match: patients qualify when they have as many of the named tags as there are distinct names
+		distinct := make(map[string]bool, len(query.Tags))
+		for _, tag := range query.Tags {
+			distinct[tag] = true
+		}
+		dbQuery = dbQuery.Where(`patients.id IN (SELECT patient_tags.patient_id FROM patient_tags
+			JOIN tags ON tags.id = patient_tags.tag_id WHERE tags.name IN ?
+			GROUP BY patient_tags.patient_id HAVING COUNT(DISTINCT tags.name) = ?)`, query.Tags, len(distinct))
+	}
+	if query.LabelID != nil {
+		dbQuery = dbQuery.Where("patients.id IN (SELECT patient_id FROM patient_label_assignments WHERE label_id = ?)", *query.LabelID)
+	}
+	if query.ExcludeConsentDenied {
+		dbQuery = dbQuery.Where("patients.consent_status <> ?", models.ConsentStatusDenied)
+	}
+
+	// Qualified because hospitals, joined by SearchPatientsAcrossHospitals, has created_at too.
+	// Handlers reject bad ranges before searching.
+	if from, before, err := query.CreatedRange(dateLocation); err == nil {
+		if from != nil {
+			dbQuery = dbQuery.Where("patients.created_at >= ?", *from)
+		}
+		if before != nil {
+			dbQuery = dbQuery.Where("patients.created_at < ?", *before)
+		}
+	} else {
+		log.Printf("Warning: Ignoring invalid created range: %v", err)
+	}
+
+	return dbQuery
+}
+
+// searchPredicate is one SQL condition of a patient search with its arguments.
+type searchPredicate struct {
+	sql  string
+	args []interface{}
+}
+
+// patientSearchPredicates returns a condition for each provided name, birth date and contact
+// criterion: the ones the match mode combines.
+func patientSearchPredicates(query *models.PatientSearchQuery) []searchPredicate {
+	var predicates []searchPredicate
+	add := func(sql string, args ...interface{}) {
+		predicates = append(predicates, searchPredicate{sql: sql, args: args})
+	}
+
+	// Names: when both the Thai and English form of a name are given, either may match.
+	// Thai names are stored in NFC, so the terms are too; normalize_thai compares folded forms.
+	mode := query.NameMatchMode()
+	thaiColumn := func(column string) string {
+		if query.NormalizeThai {
+			return column + "_folded"
+		}
+		return column
+	}
+	for _, name := range []struct {
+		columnTH, columnEN string
+		valueTH, valueEN   *string
+	}{
+		{thaiColumn("first_name_th"), "first_name_en", query.FirstNameTH, query.FirstNameEN},
+		{thaiColumn("middle_name_th"), "middle_name_en", query.MiddleNameTH, query.MiddleNameEN},
+		{thaiColumn("last_name_th"), "last_name_en", query.LastNameTH, query.LastNameEN},
+	} {
+		var conditions []string
+		var args []interface{}
+		if valueTH := thaiSearchTerm(name.valueTH, query.NormalizeThai); valueTH != nil && *valueTH != "" {
+			condition, arg := nameMatchCondition(name.columnTH, *valueTH, mode)
+			conditions, args = append(conditions, condition), append(args, arg)
+		}
+		if name.valueEN != nil && *name.valueEN != "" {
+			condition, arg := nameMatchCondition(name.columnEN, *name.valueEN, mode)
+			conditions, args = append(conditions, condition), append(args, arg)
+		}
+		if len(conditions) > 0 {
+			add(strings.Join(conditions, " OR "), args...)
+		}
 	}
 
 	if query.DateOfBirth != nil && *query.DateOfBirth != "" {
-		// Assuming YYYY-MM-DD format from query
-		dob, err := time.Parse("2006-01-02", *query.DateOfBirth)
-		if err == nil {
-			dbQuery = dbQuery.Where("date_of_birth = ?", dob)
+		// The whole day rather than its first instant, so a date of birth stored at any time of
+		// that day matches
+		if from, before, err := query.DateOfBirthRange(dateLocation); err == nil {
+			add("date_of_birth >= ? AND date_of_birth < ?", from, before)
 		} else {
 			log.Printf("Warning: Invalid date format for date_of_birth: %s", *query.DateOfBirth)
 		}
 	}
+	if from, before, ok := query.BirthYearRange(dateLocation); ok {
+		// A range rather than EXTRACT(YEAR ...) so an index on date_of_birth stays usable
+		add("date_of_birth >= ? AND date_of_birth < ?", from, before)
+	}
 	if query.PhoneNumber != nil && *query.PhoneNumber != "" {
-		dbQuery = dbQuery.Where("phone_number = ?", *query.PhoneNumber)
+		add("phone_number = ?", *query.PhoneNumber)
+	}
+	if query.PhoneSuffix != nil && *query.PhoneSuffix != "" {
+		// Same rows as phone_number LIKE '%' || suffix, but as a prefix match on the
+		// reversed number so idx_patients_phone_reversed can serve it. Reversed before
+		// escaping, so the escape characters stay in front of what they escape.
+		add("reverse(phone_number) LIKE ? || '%'"+likeEscapeClause, escapeLike(reverseString(*query.PhoneSuffix)))
 	}
 	if query.Email != nil && *query.Email != "" {
-		dbQuery = dbQuery.Where("email = ?", *query.Email)
+		add("email = ?", *query.Email)
+	}
+	if query.BloodType != nil && *query.BloodType != "" {
+		add("blood_type = ?", *query.BloodType)
+	}
+	if query.Nationality != nil && *query.Nationality != "" {
+		add("nationality = ?", *query.Nationality)
 	}
+	if query.PreferredLanguage != nil && *query.PreferredLanguage != "" {
+		add("preferred_language = ?", *query.PreferredLanguage)
+	}
+	return predicates
+}
 
-	result := dbQuery.Find(&patients)
-	if result.Error != nil {
-		return nil, result.Error
+// combinePredicates adds the predicates to the query, all of them required with match=all or
+// any one of them with match=any.
+func combinePredicates(dbQuery *gorm.DB, predicates []searchPredicate, match string) *gorm.DB {
+	if len(predicates) == 0 {
+		return dbQuery
 	}
+	if match != models.SearchMatchAny {
+		for _, p := range predicates {
+			dbQuery = dbQuery.Where(p.sql, p.args...)
+		}
+		return dbQuery
+	}
+	conditions := make([]string, len(predicates))
+	var args []interface{}
+	for i, p := range predicates {
+		conditions[i] = "(" + p.sql + ")"
+		args = append(args, p.args...)
+	}
+	return dbQuery.Where(strings.Join(conditions, " OR "), args...)
+}
 
-	return patients, nil
+// thaiSearchTerm returns a Thai name search term in the form of the column it is compared with:
+// NFC, or folded when fold is set.
+func thaiSearchTerm(value *string, fold bool) *string {
+	if value == nil {
+		return nil
+	}
+	term := utils.NormalizeThai(*value)
+	if fold {
+		term = utils.FoldThai(*value)
+	}
+	return &term
+}
+
+// likeEscaper escapes LIKE wildcards using Postgres' default escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeEscapeClause follows every LIKE whose pattern went through escapeLike. Backslash is
+// already the default, but naming it keeps the pattern literal if the default ever changes.
+const likeEscapeClause = ` ESCAPE '\'`
+
+// escapeLike makes value match literally inside a LIKE pattern.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
 }
 
-// --- Helper Function (Example for getting Hospital ID by Name) ---
-func GetHospitalIDByName(hospitalName string) (uint, error) {
-	// I know this is a stupid way to do it, but let it be for now. I want to finish the basic functionality first.
-	if hospitalName == "Hospital A" { // Example
-		return 1, nil
+// reverseString reverses value by character, like Postgres' reverse().
+func reverseString(value string) string {
+	runes := []rune(value)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
 	}
-	if hospitalName == "Hospital B" { // Example
-		return 2, nil
+	return string(runes)
+}
+
+// nameMatchCondition returns the SQL condition and its argument for matching column against value.
+func nameMatchCondition(column, value, mode string) (string, string) {
+	switch mode {
+	case models.NameMatchExact:
+		return column + " = ?", value
+	case models.NameMatchPrefix:
+		return column + " LIKE ? || '%'" + likeEscapeClause, escapeLike(value) // Anchored, so the text_pattern_ops index applies
+	default:
+		return column + " LIKE ?" + likeEscapeClause, "%" + escapeLike(value) + "%"
 	}
-	// If no mapping found, return 0 or an error
-	return 0, fmt.Errorf("hospital not found: %s", hospitalName)
 }
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Recently Viewed Specific Functions ---
+
+// RecordPatientView marks the patient as just viewed by the staff member and evicts the views
+// beyond the newest models.MaxRecentlyViewed.
+func RecordPatientView(view *models.RecentlyViewed) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "staff_id"}, {Name: "patient_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"hospital_id", "viewed_at"}),
+		}).Create(view).Error
+		if err != nil {
+			return err
+		}
+		keep := tx.Model(&models.RecentlyViewed{}).Select("patient_id").
+			Where("staff_id = ?", view.StaffID).Order("viewed_at DESC").Limit(models.MaxRecentlyViewed)
+		return tx.Where("staff_id = ? AND patient_id NOT IN (?)", view.StaffID, keep).Delete(&models.RecentlyViewed{}).Error
+	})
+}
+
+// ListRecentlyViewed returns the patients of the hospital the staff member viewed most recently,
+// newest first. Patients since deleted or moved to another hospital are left out.
+func ListRecentlyViewed(staffID, hospitalID uint) ([]models.RecentlyViewedPatient, error) {
+	var entries []models.RecentlyViewedPatient
+	err := DB.Table("recently_viewed").
+		Select("recently_viewed.patient_id, recently_viewed.viewed_at, patients.patient_hn, patients.first_name_th, patients.last_name_th, patients.first_name_en, patients.last_name_en").
+		Joins("JOIN patients ON patients.id = recently_viewed.patient_id AND patients.deleted_at IS NULL").
+		Where("recently_viewed.staff_id = ? AND patients.hospital_id = ?", staffID, hospitalID).
+		Order("recently_viewed.viewed_at DESC").
+		Limit(models.MaxRecentlyViewed).
+		Scan(&entries).Error
+	return entries, err
+}
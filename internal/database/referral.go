@@ -0,0 +1,37 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Referral Specific Functions ---
+
+// CreateReferral inserts a new referral.
+func CreateReferral(referral *models.Referral) error {
+	return DB.Create(referral).Error
+}
+
+// GetReferral retrieves a referral by ID.
+func GetReferral(id uint) (*models.Referral, error) {
+	var referral models.Referral
+	result := DB.First(&referral, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &referral, nil
+}
+
+// UpdateReferralStatus moves a referral from one status to another.
+// It returns gorm.ErrRecordNotFound if the referral is no longer in the from status.
+func UpdateReferralStatus(referral *models.Referral, from, to string) error {
+	result := DB.Model(referral).Where("status = ?", from).Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
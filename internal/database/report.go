@@ -0,0 +1,102 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// --- Report Specific Functions ---
+
+// monthlyReportSQL computes every figure of a monthly report in one statement. Search fields are
+// the names of the non-empty parameters in the recorded query strings, counted once per search.
+// Registrations count soft-deleted patients too, as they were registered all the same.
+const monthlyReportSQL = `
+WITH registrations AS (
+	SELECT count(*) AS n FROM patients
+	WHERE hospital_id = @hospital AND created_at >= @start AND created_at < @end
+), admitted AS (
+	SELECT count(*) AS n FROM admissions
+	WHERE hospital_id = @hospital AND admitted_at >= @start AND admitted_at < @end
+), discharged AS (
+	SELECT count(*) AS n, (avg(extract(epoch FROM discharged_at - admitted_at)) / 86400)::float8 AS avg_days
+	FROM admissions
+	WHERE hospital_id = @hospital AND discharged_at >= @start AND discharged_at < @end
+), searches AS (
+	SELECT id, query FROM search_history
+	WHERE hospital_id = @hospital AND searched_at >= @start AND searched_at < @end
+), search_fields AS (
+	SELECT split_part(param, '=', 1) AS field, count(DISTINCT searches.id) AS n
+	FROM searches, regexp_split_to_table(searches.query, '&') AS param
+	WHERE split_part(param, '=', 2) <> ''
+	GROUP BY 1
+)
+SELECT registrations.n AS new_registrations,
+	admitted.n AS admissions,
+	discharged.n AS discharges,
+	discharged.avg_days AS avg_length_of_stay_days,
+	(SELECT count(*) FROM searches) AS total_searches_performed,
+	(SELECT COALESCE(json_object_agg(field, n), '{}') FROM search_fields)::text AS search_fields
+FROM registrations, admitted, discharged`
+
+// monthlyReportRow is the row monthlyReportSQL returns.
+type monthlyReportRow struct {
+	NewRegistrations       int64
+	Admissions             int64
+	Discharges             int64
+	AvgLengthOfStayDays    *float64
+	TotalSearchesPerformed int64
+	SearchFields           string // JSON object of counts by parameter name
+}
+
+// GenerateMonthlyReport computes the report of a hospital for the month starting at start,
+// midnight on the first in the configured TIMEZONE. It reads from the replica when there is one.
+func GenerateMonthlyReport(hospitalID uint, start time.Time) (*models.MonthlyReport, error) {
+	generatedAt := time.Now()
+	var row monthlyReportRow
+	err := readDB().Raw(monthlyReportSQL, map[string]interface{}{
+		"hospital": hospitalID,
+		"start":    start,
+		"end":      start.AddDate(0, 1, 0),
+	}).Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	var fieldCounts map[string]int64
+	if err := json.Unmarshal([]byte(row.SearchFields), &fieldCounts); err != nil {
+		return nil, fmt.Errorf("decoding search field counts: %w", err)
+	}
+	return &models.MonthlyReport{
+		HospitalID:             hospitalID,
+		Year:                   start.Year(),
+		Month:                  int(start.Month()),
+		NewRegistrations:       row.NewRegistrations,
+		Admissions:             row.Admissions,
+		Discharges:             row.Discharges,
+		AvgLengthOfStayDays:    row.AvgLengthOfStayDays,
+		TotalSearchesPerformed: row.TotalSearchesPerformed,
+		TopSearchFieldsUsed:    models.SearchFieldHistogram(fieldCounts),
+		GeneratedAt:            generatedAt,
+	}, nil
+}
+
+// GetCachedReport returns the cached report of a hospital's month, or gorm.ErrRecordNotFound.
+func GetCachedReport(hospitalID uint, year, month int) (*models.CachedReport, error) {
+	var cached models.CachedReport
+	result := DB.Where("hospital_id = ? AND year = ? AND month = ?", hospitalID, year, month).First(&cached)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &cached, nil
+}
+
+// SaveCachedReport inserts or replaces the cached report of its hospital's month.
+func SaveCachedReport(cached *models.CachedReport) error {
+	return DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hospital_id"}, {Name: "year"}, {Name: "month"}},
+		DoUpdates: clause.AssignmentColumns([]string{"report", "generated_at"}),
+	}).Create(cached).Error
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- Data Retention Specific Functions ---
+
+// purgedPatientRecords are the records deleted along with a purged patient. The patient access
+// log is left to its own retention period.
+var purgedPatientRecords = []interface{}{
+	&models.Visit{}, &models.Admission{}, &models.PatientDiagnosis{}, &models.Allergy{}, &models.Consent{},
+	&models.PatientNote{}, &models.PatientDocument{}, &models.PatientLabelAssignment{}, &models.PatientTag{},
+	&models.RecentlyViewed{}, &models.Referral{}, &models.AuditLog{},
+}
+
+// CountRetentionPurge counts the records a retention purge with the cutoffs would delete.
+func CountRetentionPurge(cutoffs models.RetentionCutoffs) (models.RetentionPurgeReport, error) {
+	report := models.RetentionPurgeReport{RetentionCutoffs: cutoffs}
+	if err := purgeablePatients(DB, cutoffs.PatientsDeletedBefore).Count(&report.Patients).Error; err != nil {
+		return report, err
+	}
+	if err := purgeableRevokedTokens(DB, cutoffs).Count(&report.RevokedTokens).Error; err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// PurgeSoftDeletedPatients hard-deletes up to limit patients soft-deleted before the cutoff, with
+// their records, in one transaction. It returns how many patients it deleted and the storage keys
+// of their documents, whose blobs the caller removes.
+func PurgeSoftDeletedPatients(before time.Time, limit int) (int64, []string, error) {
+	var deleted int64
+	var storageKeys []string
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets two servers purge side by side without waiting on each other
+		var ids []uint
+		err := purgeablePatients(tx, before).Order("id").Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		if err := tx.Model(&models.PatientDocument{}).Where("patient_id IN ?", ids).Pluck("storage_key", &storageKeys).Error; err != nil {
+			return err
+		}
+		for _, record := range purgedPatientRecords {
+			if err := tx.Unscoped().Where("patient_id IN ?", ids).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Patient{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return deleted, storageKeys, nil
+}
+
+// PurgeRevokedTokens deletes up to limit revocations made before the cutoff whose token had
+// expired by cutoffs.AsOf. A revocation of a token still valid is kept, or the token would work again.
+func PurgeRevokedTokens(cutoffs models.RetentionCutoffs, limit int) (int64, error) {
+	batch := purgeableRevokedTokens(DB, cutoffs).Select("id").Order("id").Limit(limit)
+	result := DB.Where("id IN (?)", batch).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
+
+func purgeablePatients(db *gorm.DB, before time.Time) *gorm.DB {
+	return db.Unscoped().Model(&models.Patient{}).Where("deleted_at < ?", before)
+}
+
+func purgeableRevokedTokens(db *gorm.DB, cutoffs models.RetentionCutoffs) *gorm.DB {
+	return db.Model(&models.RevokedToken{}).
+		Where("created_at < ? AND expires_at < ?", cutoffs.TokensRevokedBefore, cutoffs.AsOf)
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// --- Revoked Token Specific Functions ---
+
+// RevokeToken stores a token's ID so it is rejected until it expires. Revoking twice is a no-op.
+func RevokeToken(token *models.RevokedToken) error {
+	return DB.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+// IsTokenRevoked reports whether a token ID has been revoked.
+func IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	if err := DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteExpiredRevokedTokens removes revocations for tokens that expired before the cutoff.
+func DeleteExpiredRevokedTokens(before time.Time) (int64, error) {
+	result := DB.Where("expires_at < ?", before).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
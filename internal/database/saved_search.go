@@ -0,0 +1,64 @@
+package database
+
+import (
+	"errors"
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSavedSearchLimit is returned by CreateSavedSearch when the staff member already has
+// models.MaxSavedSearchesPerStaff saved searches.
+var ErrSavedSearchLimit = errors.New("saved search limit reached")
+
+// --- Saved Search Specific Functions ---
+
+// CreateSavedSearch stores a saved search unless its owner is already at the limit.
+func CreateSavedSearch(search *models.SavedSearch) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// Locking the owner's row makes concurrent saves by the same staff member count one at a time
+		var staff models.Staff
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&staff, search.StaffID).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.SavedSearch{}).Where("staff_id = ?", search.StaffID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= models.MaxSavedSearchesPerStaff {
+			return ErrSavedSearchLimit
+		}
+		return tx.Create(search).Error
+	})
+}
+
+// ListSavedSearches returns a staff member's saved searches, oldest first.
+func ListSavedSearches(staffID uint) ([]models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	err := DB.Where("staff_id = ?", staffID).Order("created_at ASC, id ASC").Find(&searches).Error
+	return searches, err
+}
+
+// GetSavedSearch returns a saved search owned by the staff member.
+// Other staff members' searches are reported as not found.
+func GetSavedSearch(id, staffID uint) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	if err := DB.Where("id = ? AND staff_id = ?", id, staffID).First(&search).Error; err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
+
+// DeleteSavedSearch removes a saved search owned by the staff member.
+// Other staff members' searches are reported as not found.
+func DeleteSavedSearch(id, staffID uint) error {
+	result := DB.Where("id = ? AND staff_id = ?", id, staffID).Delete(&models.SavedSearch{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+	"time"
+)
+
+// --- Search History Specific Functions ---
+
+// RecordSearch stores one patient search.
+func RecordSearch(entry *models.SearchHistory) error {
+	return DB.Create(entry).Error
+}
+
+// DeleteSearchHistoryBefore removes searches made before the cutoff.
+func DeleteSearchHistoryBefore(before time.Time) (int64, error) {
+	result := DB.Where("searched_at < ?", before).Delete(&models.SearchHistory{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"hospital-middleware/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// --- Visit Specific Functions ---
+
+// CreateVisit inserts a new visit record.
+func CreateVisit(visit *models.Visit) error {
+	result := DB.Create(visit)
+	return result.Error
+}
+
+// ListVisitsByPatient returns a page of a patient's visits, newest first, along with the total count.
+func ListVisitsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.Visit, int64, error) {
+	var visits []models.Visit
+	var total int64
+
+	dbQuery := DB.Model(&models.Visit{}).Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).Session(&gorm.Session{})
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := dbQuery.Order("admitted_at DESC").Offset(offset).Limit(limit).Find(&visits)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	return visits, total, nil
+}
+
+// ListVisitsByDate returns every visit admitted to the hospital within [start, end).
+func ListVisitsByDate(hospitalID uint, start, end time.Time) ([]models.Visit, error) {
+	var visits []models.Visit
+	result := DB.Where("hospital_id = ? AND admitted_at >= ? AND admitted_at < ?", hospitalID, start, end).
+		Order("admitted_at ASC").
+		Find(&visits)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return visits, nil
+}
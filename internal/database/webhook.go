@@ -0,0 +1,65 @@
+package database
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// --- Webhook Specific Functions ---
+
+// CreateWebhook stores a new webhook.
+func CreateWebhook(webhook *models.Webhook) error {
+	return DB.Create(webhook).Error
+}
+
+// ListWebhooks returns the hospital's webhooks, oldest first.
+func ListWebhooks(hospitalID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := DB.Where("hospital_id = ?", hospitalID).Order("id ASC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// GetWebhook returns a webhook of the hospital. Webhooks of other hospitals are reported as not found.
+func GetWebhook(id, hospitalID uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := DB.Where("id = ? AND hospital_id = ?", id, hospitalID).First(&webhook).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook saves every field of a webhook loaded with GetWebhook.
+func UpdateWebhook(webhook *models.Webhook) error {
+	return DB.Save(webhook).Error
+}
+
+// DeleteWebhook removes a webhook of the hospital. Webhooks of other hospitals are reported as not found.
+// Its dead letters are kept.
+func DeleteWebhook(id, hospitalID uint) error {
+	result := DB.Where("id = ? AND hospital_id = ?", id, hospitalID).Delete(&models.Webhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListActiveWebhooks returns the hospital's active webhooks subscribed to the event.
+func ListActiveWebhooks(hospitalID uint, event string) ([]models.Webhook, error) {
+	subscribed, err := json.Marshal([]string{event})
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []models.Webhook
+	err = DB.Where("hospital_id = ? AND active AND events @> ?", hospitalID, string(subscribed)).Find(&webhooks).Error
+	return webhooks, err
+}
+
+// CreateWebhookDeadLetter records a delivery that could not be made.
+func CreateWebhookDeadLetter(entry *models.WebhookDeadLetter) error {
+	return DB.Create(entry).Error
+}
@@ -0,0 +1,220 @@
+// Package jobs runs background work from a persistent queue. Work is added with Enqueue and run by
+// a Pool of workers, each job by the Handler registered for its type. Jobs run at least once: a
+// failed job is retried with exponential backoff until it runs out of attempts, and a job whose
+// server died mid-run is claimed again once JobLease has passed, so handlers must be safe to repeat.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Queue settings.
+const (
+	DefaultMaxAttempts = 5
+	PollInterval       = time.Second      // How often an idle worker looks for due jobs
+	RetryBackoff       = 30 * time.Second // Wait before the first retry, doubling for each one after
+	MaxRetryBackoff    = time.Hour
+	// JobLease is how long a job may run before it is presumed abandoned and claimed again.
+	JobLease = time.Hour
+)
+
+// Store is the part of the repository the queue needs. database.PatientRepository satisfies it.
+type Store interface {
+	CreateJob(job *models.Job) error
+	ClaimJob(types []string, now, staleBefore time.Time) (*models.Job, error)
+	CompleteJob(id uint, now time.Time) error
+	RetryJob(id uint, lastError string, runAt time.Time) error
+	FailJob(id uint, lastError string, now time.Time) error
+	ReleaseJob(id uint) error
+}
+
+// Handler runs one job with its payload. A returned error fails the attempt. ctx is cancelled
+// when the server shuts down before the job finished; the job is then returned to the queue.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Enqueue adds a job of jobType with payload encoded as JSON, due at once. maxAttempts below one
+// uses DefaultMaxAttempts.
+func Enqueue(store Store, jobType string, payload interface{}, maxAttempts int) (*models.Job, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s job payload: %w", jobType, err)
+	}
+	if maxAttempts < 1 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     models.JobPayload(body),
+		Status:      models.JobStatusQueued,
+		MaxAttempts: maxAttempts,
+		RunAt:       time.Now(),
+	}
+	if err := store.CreateJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Pool is a fixed number of workers running queued jobs of the registered types.
+type Pool struct {
+	store        Store
+	workers      int
+	pollInterval time.Duration
+	backoff      time.Duration
+	handlers     map[string]Handler
+
+	stopClaiming context.CancelFunc // Stops workers from taking new jobs
+	cancelJobs   context.CancelFunc // Cancels the jobs still running
+	wg           sync.WaitGroup
+}
+
+// NewPool returns a pool of workers that look for due jobs every pollInterval and wait backoff,
+// doubling each time, before retrying a failed job.
+func NewPool(store Store, workers int, pollInterval, backoff time.Duration) *Pool {
+	return &Pool{
+		store:        store,
+		workers:      workers,
+		pollInterval: pollInterval,
+		backoff:      backoff,
+		handlers:     make(map[string]Handler),
+	}
+}
+
+// Register sets the handler of a job type. Handlers must be registered before Start; jobs of
+// types without one stay queued.
+func (p *Pool) Register(jobType string, handler Handler) {
+	p.handlers[jobType] = handler
+}
+
+// Start starts the workers. A pool without handlers starts none.
+func (p *Pool) Start() {
+	claimCtx, stopClaiming := context.WithCancel(context.Background())
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	p.stopClaiming, p.cancelJobs = stopClaiming, cancelJobs
+	if len(p.handlers) == 0 {
+		log.Println("No background job types registered; job workers not started")
+		return
+	}
+
+	types := make([]string, 0, len(p.handlers))
+	for jobType := range p.handlers {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.work(claimCtx, jobCtx, types)
+		}()
+	}
+	log.Printf("Started %d background job worker(s) for %v", p.workers, types)
+}
+
+// Shutdown stops the workers from taking new jobs and waits for the running ones to finish.
+// If ctx ends first, the running jobs are cancelled and returned to the queue, and Shutdown
+// returns ctx's error once their handlers have returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	if p.stopClaiming == nil {
+		return nil // Never started
+	}
+	p.stopClaiming()
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		p.cancelJobs()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// work claims and runs jobs until claimCtx is cancelled, sleeping pollInterval whenever the queue
+// has nothing due.
+func (p *Pool) work(claimCtx, jobCtx context.Context, types []string) {
+	for claimCtx.Err() == nil {
+		now := time.Now()
+		job, err := p.store.ClaimJob(types, now, now.Add(-JobLease))
+		if err != nil {
+			log.Printf("Error claiming background job: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-claimCtx.Done():
+			case <-time.After(p.pollInterval):
+			}
+			continue
+		}
+		p.run(jobCtx, job)
+	}
+}
+
+// run runs a claimed job and records how it went.
+func (p *Pool) run(ctx context.Context, job *models.Job) {
+	if job.Attempts > job.MaxAttempts {
+		// Reclaimed after its worker died on the last attempt
+		p.record(job, "fail", p.store.FailJob(job.ID, "abandoned by its worker after the last attempt", time.Now()))
+		return
+	}
+
+	err := p.call(ctx, job)
+	switch {
+	case err == nil:
+		p.record(job, "complete", p.store.CompleteJob(job.ID, time.Now()))
+	case ctx.Err() != nil:
+		log.Printf("Background job %d (%s) interrupted by shutdown; returning it to the queue", job.ID, job.Type)
+		p.record(job, "release", p.store.ReleaseJob(job.ID))
+	case job.Attempts >= job.MaxAttempts:
+		log.Printf("Background job %d (%s) failed for good after %d attempt(s): %v", job.ID, job.Type, job.Attempts, err)
+		p.record(job, "fail", p.store.FailJob(job.ID, err.Error(), time.Now()))
+	default:
+		delay := p.retryDelay(job.Attempts)
+		log.Printf("Background job %d (%s) failed (attempt %d of %d), retrying in %v: %v", job.ID, job.Type, job.Attempts, job.MaxAttempts, delay, err)
+		p.record(job, "retry", p.store.RetryJob(job.ID, err.Error(), time.Now().Add(delay)))
+	}
+}
+
+// call runs the job's handler, turning a panic into an error so one bad job cannot take the
+// worker down with it.
+func (p *Pool) call(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	handler, ok := p.handlers[job.Type]
+	if !ok {
+		return errors.New("no handler registered for job type " + job.Type)
+	}
+	return handler(ctx, json.RawMessage(job.Payload))
+}
+
+// retryDelay is the wait before retrying after the given attempt: backoff, doubling for each
+// attempt after the first, up to MaxRetryBackoff.
+func (p *Pool) retryDelay(attempt int) time.Duration {
+	delay := p.backoff
+	for i := 1; i < attempt && delay < MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, MaxRetryBackoff)
+}
+
+func (p *Pool) record(job *models.Job, action string, err error) {
+	if err != nil {
+		log.Printf("Error recording %s of background job %d (%s): %v", action, job.ID, job.Type, err)
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Admission statuses.
+const (
+	AdmissionStatusAdmitted   = "admitted"
+	AdmissionStatusDischarged = "discharged"
+)
+
+// Admission represents an inpatient stay. A patient has at most one admission with status "admitted".
+type Admission struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	PatientID        uint       `json:"patient_id" gorm:"index;not null"`
+	HospitalID       uint       `json:"hospital_id" gorm:"not null;index:idx_admissions_hospital_ward,priority:1"` // Inherited from the patient
+	AdmittingStaffID uint       `json:"admitting_staff_id" gorm:"not null"`
+	Ward             string     `json:"ward" gorm:"not null;index:idx_admissions_hospital_ward,priority:2"`
+	BedNumber        string     `json:"bed_number"`
+	AdmittedAt       time.Time  `json:"admitted_at" gorm:"not null"`
+	DischargedAt     *time.Time `json:"discharged_at"`
+	DischargeReason  string     `json:"discharge_reason"`
+	Status           string     `json:"status" gorm:"not null;default:admitted"` // "admitted", "discharged"
+}
+
+// AdmissionCreateRequest represents the input for admitting a patient.
+type AdmissionCreateRequest struct {
+	Ward       string     `json:"ward" binding:"required"`
+	BedNumber  string     `json:"bed_number"`
+	AdmittedAt *time.Time `json:"admitted_at"` // Defaults to now when omitted
+}
+
+// AdmissionDischargeRequest represents the input for discharging a patient.
+type AdmissionDischargeRequest struct {
+	DischargeReason string     `json:"discharge_reason" binding:"required"`
+	DischargedAt    *time.Time `json:"discharged_at"` // Defaults to now when omitted
+}
+
+// WardCensusEntry is a current admission together with the admitted patient.
+type WardCensusEntry struct {
+	Admission Admission `json:"admission"`
+	Patient   Patient   `json:"patient"`
+}
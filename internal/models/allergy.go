@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Allergy severity levels.
+const (
+	AllergySeverityMild     = "mild"
+	AllergySeverityModerate = "moderate"
+	AllergySeveritySevere   = "severe"
+)
+
+// Allergy represents a substance a patient is allergic to.
+// A patient has at most one entry per substance (compared case-insensitively).
+type Allergy struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PatientID uint      `json:"patient_id" gorm:"index;not null"`
+	Substance string    `json:"substance" gorm:"not null"`
+	Reaction  string    `json:"reaction"`
+	Severity  string    `json:"severity" gorm:"not null"` // "mild", "moderate", "severe"
+	NotedBy   uint      `json:"noted_by" gorm:"not null"` // Staff ID who recorded the allergy
+	NotedAt   time.Time `json:"noted_at" gorm:"not null"`
+}
+
+// AllergyRequest represents the input for recording or updating an allergy.
+type AllergyRequest struct {
+	Substance string `json:"substance" binding:"required"`
+	Reaction  string `json:"reaction"`
+	Severity  string `json:"severity" binding:"required,oneof=mild moderate severe"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// APIKey lets a machine client, such as a billing system, call the API for one hospital
+// without a staff login. Only a bcrypt hash of the secret is stored; the key itself is shown once.
+type APIKey struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	HospitalID  uint       `json:"hospital_id" gorm:"not null;index"`
+	Prefix      string     `json:"prefix" gorm:"uniqueIndex;not null"` // Public part of the key, used to find the row to verify against
+	KeyHash     string     `json:"-" gorm:"not null"`
+	Description string     `json:"description" gorm:"not null"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	ExpiresAt   *time.Time `json:"expires_at"` // Nil keys never expire
+	IsActive    bool       `json:"is_active" gorm:"not null;default:true"`
+}
+
+// Usable reports whether the key is active and unexpired at now.
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.IsActive && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// APIKeyCreateRequest is the body of POST /admin/api-keys.
+type APIKeyCreateRequest struct {
+	Description string     `json:"description" binding:"required,max=255"`
+	ExpiresAt   *time.Time `json:"expires_at"` // Optional; must be in the future
+}
+
+// APIKeyCreateResponse returns the new key. Key is the only time the secret is available.
+type APIKeyCreateResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Audit actions recorded against patients.
+const (
+	AuditActionNoteCreated = "note_created"
+	AuditActionNoteUpdated = "note_updated"
+	AuditActionNoteDeleted = "note_deleted"
+
+	AuditActionDocumentUploaded = "document_uploaded"
+	AuditActionDocumentDeleted  = "document_deleted"
+
+	AuditActionPatientTransferred   = "patient_transferred"
+	AuditActionPatientStatusChanged = "patient_status_changed"
+	AuditActionPatientUpdated       = "patient_updated"
+	AuditActionHistoryImported      = "history_imported"
+
+	AuditActionConsentRecorded = "consent_recorded"
+	AuditActionConsentUpdated  = "consent_updated"
+)
+
+// AuditLog is an append-only record of a change made to a patient's data.
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	HospitalID uint      `json:"hospital_id" gorm:"index;not null"`
+	PatientID  uint      `json:"patient_id" gorm:"index;not null"`
+	StaffID    uint      `json:"staff_id" gorm:"not null"` // Who performed the action
+	Action     string    `json:"action" gorm:"not null"`
+	EntityType string    `json:"entity_type"` // e.g. "patient_note", "patient_document"
+	EntityID   uint      `json:"entity_id"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
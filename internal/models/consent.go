@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Consent types a patient can grant or refuse.
+const (
+	ConsentTypeDataSharing = "data_sharing" // Sharing records with other hospitals
+	ConsentTypeTreatment   = "treatment"
+	ConsentTypeResearch    = "research"
+)
+
+// Patient consent statuses, the summary kept on Patient.ConsentStatus.
+const (
+	ConsentStatusGranted = "granted"
+	ConsentStatusDenied  = "denied"
+	ConsentStatusUnknown = "unknown" // Default for patients never asked
+)
+
+// PatientConsentUpdateRequest sets a patient's overall consent status. It is recorded as a data
+// sharing Consent, so only a decision can be set; unknown is where patients start.
+type PatientConsentUpdateRequest struct {
+	ConsentStatus  string `json:"consent_status" binding:"required,oneof=granted denied"`
+	ConsentVersion string `json:"consent_version" binding:"max=50"`
+}
+
+// Consent is one recorded consent decision. Decisions are never edited: a revocation is a new
+// row with Granted false, and the latest row of a type is the patient's current decision.
+type Consent struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	PatientID      uint       `json:"patient_id" gorm:"index:idx_consents_patient_type;not null"`
+	HospitalID     uint       `json:"hospital_id" gorm:"index;not null"`
+	ConsentType    string     `json:"consent_type" gorm:"index:idx_consents_patient_type;not null"`
+	Granted        bool       `json:"granted" gorm:"not null"`
+	GrantedAt      *time.Time `json:"granted_at"` // Set when Granted
+	RevokedAt      *time.Time `json:"revoked_at"` // Set when not Granted
+	StaffWitnessID uint       `json:"staff_witness_id" gorm:"not null"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Status is the patient consent status this decision amounts to.
+func (c *Consent) Status() string {
+	if c.Granted {
+		return ConsentStatusGranted
+	}
+	return ConsentStatusDenied
+}
+
+// ConsentRequest records a patient's consent decision.
+// Granted is a pointer so an omitted value is rejected rather than read as a refusal.
+type ConsentRequest struct {
+	ConsentType string `json:"consent_type" binding:"required,oneof=data_sharing treatment research"`
+	Granted     *bool  `json:"granted" binding:"required"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Diagnosis types.
+const (
+	DiagnosisTypePrimary   = "primary"
+	DiagnosisTypeSecondary = "secondary"
+	DiagnosisTypeWorking   = "working"
+)
+
+// ICD10Code is an entry of the read-only ICD-10 reference table, loaded during migration.
+type ICD10Code struct {
+	Code        string `json:"code" gorm:"primaryKey"`
+	Description string `json:"description" gorm:"not null"`
+}
+
+// TableName pins the table name the patient_diagnoses foreign key refers to.
+func (ICD10Code) TableName() string {
+	return "icd10_codes"
+}
+
+// PatientDiagnosis records an ICD-10 diagnosis made for a patient.
+type PatientDiagnosis struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	PatientID     uint       `json:"patient_id" gorm:"index;not null"`
+	HospitalID    uint       `json:"hospital_id" gorm:"index;not null"` // Inherited from the patient
+	StaffID       uint       `json:"staff_id" gorm:"not null"`          // Who made the diagnosis
+	ICD10Code     string     `json:"icd10_code" gorm:"not null;index"`
+	ICD10         *ICD10Code `json:"icd10,omitempty" gorm:"foreignKey:ICD10Code;references:Code;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	DiagnosisType string     `json:"diagnosis_type" gorm:"not null"` // "primary", "secondary", "working"
+	DiagnosedAt   time.Time  `json:"diagnosed_at" gorm:"not null"`
+}
+
+// PatientDiagnosisRequest represents the input for recording a diagnosis.
+type PatientDiagnosisRequest struct {
+	ICD10Code     string     `json:"icd10_code" binding:"required"`
+	DiagnosisType string     `json:"diagnosis_type" binding:"required,oneof=primary secondary working"`
+	DiagnosedAt   *time.Time `json:"diagnosed_at"` // Defaults to now when omitted
+}
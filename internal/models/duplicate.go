@@ -0,0 +1,17 @@
+package models
+
+// How the patients of a DuplicateCluster match.
+const (
+	DuplicateMatchNationalID       = "national_id"
+	DuplicateMatchNameAndBirthDate = "name_and_date_of_birth" // English first and last name, ignoring case, and date of birth
+)
+
+// DuplicateCluster is a group of patients of one hospital that are likely the same person.
+// MatchKey is the shared value: the national ID, or "first last YYYY-MM-DD" in lower case.
+// A patient matching on both counts appears in one cluster of each type.
+type DuplicateCluster struct {
+	MatchType  string    `json:"match_type"`
+	MatchKey   string    `json:"match_key"`
+	PatientIDs []uint    `json:"patient_ids"` // In ID order, ready to hand to a merge
+	Patients   []Patient `json:"patients"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Hospital represents a hospital that staff and patients belong to.
+type Hospital struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null"`
+	Code      string    `json:"code" gorm:"uniqueIndex;not null"` // Short code, e.g. "HA"
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Features switched on or off for the hospital while they are rolled out; see Feature*
+	Features HospitalFeatures `json:"features" gorm:"type:jsonb;not null;default:'{}'"`
+}
+
+// Features that are rolled out hospital by hospital. A feature a hospital has no entry for is off.
+const (
+	// Admins searching with cross_hospital=true across their consortiums
+	FeatureCrossHospitalSearch = "cross_hospital_search"
+)
+
+// KnownFeatures are the features admins can switch, so a mistyped name is rejected rather than stored.
+var KnownFeatures = map[string]bool{
+	FeatureCrossHospitalSearch: true,
+}
+
+// HospitalFeatures maps feature names to whether they are enabled, stored in the
+// hospitals.features jsonb column.
+type HospitalFeatures map[string]bool
+
+// Value stores the features as a JSON object.
+func (f HospitalFeatures) Value() (driver.Value, error) {
+	if f == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the features back from a JSON object.
+func (f *HospitalFeatures) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	case nil:
+		*f = nil
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into HospitalFeatures", value)
+}
+
+// HospitalFeatureRequest is the body of PUT /admin/hospital/:id/features/:feature.
+type HospitalFeatureRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// HospitalFeaturesResponse lists a hospital's features after one was switched.
+type HospitalFeaturesResponse struct {
+	HospitalID uint             `json:"hospital_id"`
+	Features   HospitalFeatures `json:"features"`
+}
+
+// HospitalSummary is the public view of a hospital, safe to expose before login.
+type HospitalSummary struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+// ConsortiumMembership places a hospital in a consortium. Admins of hospitals sharing a consortium
+// can search each other's patients with cross_hospital=true, once their hospital has the
+// cross_hospital_search feature. A consortium is only its ID; a
+// hospital may belong to several.
+type ConsortiumMembership struct {
+	ConsortiumID uint      `json:"consortium_id" gorm:"primaryKey"`
+	HospitalID   uint      `json:"hospital_id" gorm:"primaryKey;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Only declared so AutoMigrate adds the foreign key; never loaded
+	Hospital *Hospital `json:"-" gorm:"foreignKey:HospitalID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
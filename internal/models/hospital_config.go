@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// HospitalConfig holds the per-hospital settings admins can change at runtime.
+// Hospitals without a row use DefaultHospitalConfig.
+type HospitalConfig struct {
+	HospitalID        uint      `json:"hospital_id" gorm:"primaryKey;autoIncrement:false"`
+	MaxSearchResults  int       `json:"max_search_results" gorm:"not null;default:0"` // 0 means only the server-wide cap applies
+	HNPrefix          string    `json:"hn_prefix" gorm:"not null;default:''"`
+	HNPaddingLength   int       `json:"hn_padding_length" gorm:"not null;default:0"`
+	SearchMinCriteria int       `json:"search_min_criteria" gorm:"not null;default:0"`
+	TwoFactorRequired bool      `json:"two_factor_required" gorm:"not null;default:false"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	// Keys patients of the hospital may have in their extra fields
+	ExtraFieldKeys StringList `json:"extra_field_keys" gorm:"type:jsonb;not null;default:'[]'"`
+}
+
+// DefaultHospitalConfig returns the settings used before an admin configures a hospital.
+// They match the behaviour from before hospital configs existed.
+func DefaultHospitalConfig(hospitalID uint) HospitalConfig {
+	return HospitalConfig{HospitalID: hospitalID, ExtraFieldKeys: StringList{}}
+}
+
+// HospitalConfigUpdateRequest replaces every setting of a hospital's config, except that
+// extra_field_keys may be left out.
+type HospitalConfigUpdateRequest struct {
+	MaxSearchResults  *int    `json:"max_search_results" binding:"required,min=0"`
+	HNPrefix          *string `json:"hn_prefix" binding:"required,max=10"`
+	HNPaddingLength   *int    `json:"hn_padding_length" binding:"required,min=0,max=20"`
+	SearchMinCriteria *int    `json:"search_min_criteria" binding:"required,min=0,max=11"`
+	TwoFactorRequired *bool   `json:"two_factor_required" binding:"required"`
+	// Omitted keeps the current keys. Patients keep values under keys that are dropped.
+	ExtraFieldKeys []string `json:"extra_field_keys" binding:"omitempty,max=50"`
+}
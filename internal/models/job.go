@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Job statuses. A job is queued until a worker claims it, running while the worker has it, and
+// back to queued when it fails and has attempts left. It ends succeeded or, once every attempt
+// has failed, failed.
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// ValidJobStatus reports whether status is one of the JobStatus* constants.
+func ValidJobStatus(status string) bool {
+	switch status {
+	case JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed:
+		return true
+	}
+	return false
+}
+
+// Job is a unit of background work in the persistent queue. Type picks the handler that runs it.
+type Job struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Type        string     `json:"type" gorm:"not null;index"`
+	Payload     JobPayload `json:"payload" gorm:"type:jsonb;not null"`
+	Status      string     `json:"status" gorm:"not null;index:idx_jobs_status_run_at"` // One of the JobStatus* constants
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`                  // Counted when a worker claims the job
+	MaxAttempts int        `json:"max_attempts" gorm:"not null"`
+	RunAt       time.Time  `json:"run_at" gorm:"not null;index:idx_jobs_status_run_at"` // Not claimed before this time
+	LockedAt    *time.Time `json:"locked_at,omitempty"`                                 // When the running attempt was claimed
+	LastError   string     `json:"last_error,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// JobPayload is the JSON input of a job, stored as jsonb and shown as-is in JSON.
+type JobPayload json.RawMessage
+
+// Value stores the payload, or JSON null when it is empty.
+func (p JobPayload) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return "null", nil
+	}
+	return string(p), nil
+}
+
+// Scan reads a jsonb payload.
+func (p *JobPayload) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*p = append(JobPayload(nil), v...)
+	case string:
+		*p = JobPayload(v)
+	case nil:
+		*p = nil
+	default:
+		return fmt.Errorf("cannot scan %T into JobPayload", value)
+	}
+	return nil
+}
+
+// MarshalJSON writes the payload as-is, or null when it is empty.
+func (p JobPayload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+// UnmarshalJSON keeps a copy of the payload.
+func (p *JobPayload) UnmarshalJSON(data []byte) error {
+	*p = append(JobPayload(nil), data...)
+	return nil
+}
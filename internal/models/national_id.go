@@ -0,0 +1,19 @@
+package models
+
+// NationalIDValidationRequest is the body of POST /utils/validate-national-ids.
+type NationalIDValidationRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// NationalIDValidation is the result for one ID of a NationalIDValidationRequest. Reason says
+// why an invalid ID was rejected and is empty for valid ones.
+type NationalIDValidation struct {
+	ID     string `json:"id"`
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason"`
+}
+
+// NationalIDValidationResponse lists the results in the order the IDs were given.
+type NationalIDValidationResponse struct {
+	Results []NationalIDValidation `json:"results"`
+}
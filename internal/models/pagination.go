@@ -0,0 +1,15 @@
+package models
+
+// PaginationQuery represents the common paging query parameters for list endpoints.
+type PaginationQuery struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size"`
+}
+
+// PaginatedResponse wraps a page of results with the paging metadata.
+type PaginatedResponse struct {
+	Data     interface{} `json:"data"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Total    int64       `json:"total"`
+}
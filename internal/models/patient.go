@@ -1,11 +1,19 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"hospital-middleware/pkg/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Patient struct {
 	ID           uint       `json:"id" gorm:"primaryKey"`
 	HospitalID   uint       `json:"hospital_id" gorm:"index;not null"`
-	PatientHN    string     `json:"patient_hn" gorm:"uniqueIndex:idx_hospital_hn;not null"`
+	PatientHN    string     `json:"patient_hn" gorm:"uniqueIndex:idx_hospital_hn,where:deleted_at IS NULL;not null"` // Unique among live patients
 	FirstNameTH  string     `json:"first_name_th" gorm:"not null"`
 	MiddleNameTH string     `json:"middle_name_th"`
 	LastNameTH   string     `json:"last_name_th" gorm:"not null"`
@@ -15,23 +23,365 @@ type Patient struct {
 	DateOfBirth  *time.Time `json:"date_of_birth"` // Use pointer to handle potential nulls if needed
 	NationalID   string     `json:"national_id" gorm:"index"`
 	PassportID   string     `json:"passport_id" gorm:"index"`
-	PhoneNumber  string     `json:"phone_number"`
-	Email        string     `json:"email"`
+	PhoneNumber  string     `json:"phone_number" gorm:"index"` // Indexed as stored, for exact phone_number search
+	Email        string     `json:"email" gorm:"index"`
 	Gender       string     `json:"gender"` // "M", "F"
+	// Clinical demographics, null until recorded
+	BloodType     *string `json:"blood_type" gorm:"index"`
+	Nationality   *string `json:"nationality" gorm:"index"`
+	MaritalStatus *string `json:"marital_status"`
+	// Communication preferences for outreach. Language and method are null until recorded;
+	// DoNotContact overrides the method.
+	PreferredLanguage      *string `json:"preferred_language" gorm:"index"` // See PreferredLanguage*
+	PreferredContactMethod *string `json:"preferred_contact_method"`        // See ContactMethod*
+	DoNotContact           bool    `json:"do_not_contact" gorm:"not null;default:false;index"`
+	// Lifecycle status (see PatientStatus*), changed only through the status endpoint.
+	// Deceased mirrors status deceased as a single flag for clients; DeceasedAt is when it happened.
+	Status     string     `json:"status" gorm:"not null;default:active;index"`
+	Deceased   bool       `json:"deceased" gorm:"not null;default:false;index"`
+	DeceasedAt *time.Time `json:"deceased_at"`
+	// Overall data-sharing consent (see ConsentStatus*): the latest data_sharing Consent record, kept in
+	// step by CreateConsent and UpdatePatientConsent. Unknown until the first decision is recorded.
+	ConsentStatus    string     `json:"consent_status" gorm:"not null;default:unknown;index"`
+	ConsentUpdatedAt *time.Time `json:"consent_updated_at"`
+	ConsentVersion   string     `json:"consent_version"` // Version of the consent form the patient answered
+	// Thai names folded with utils.FoldThai for normalize_thai searches, kept in step by BeforeSave.
+	// Nullable so rows from before the columns existed can be found and backfilled.
+	FirstNameTHFolded  *string `json:"-"`
+	MiddleNameTHFolded *string `json:"-"`
+	LastNameTHFolded   *string `json:"-"`
+	// Registration timestamps. The column defaults backfill rows created before they existed.
+	CreatedAt time.Time      `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP;index"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // Soft-deleted patients are hidden from every query
+	// The hospital's own fields, limited to the keys its config permits
+	Extra ExtraFields `json:"extra,omitempty" gorm:"type:jsonb;not null;default:'{}'"`
+	// Labels of the patient's hospital attached to it, loaded by searches only
+	Labels []PatientLabel `json:"labels,omitempty" gorm:"many2many:patient_label_assignments;joinForeignKey:PatientID;joinReferences:LabelID"`
+	// Tags of the patient, loaded by searches only
+	Tags []Tag `json:"tags,omitempty" gorm:"many2many:patient_tags;joinForeignKey:PatientID;joinReferences:TagID"`
+	// Name of the patient's hospital when a cross_hospital search found it in another consortium hospital
+	SourceHospitalName string `json:"source_hospital_name,omitempty" gorm:"->;-:migration"`
+}
+
+// BeforeSave stores the Thai names in NFC, refreshes their folded forms and keeps Deceased in
+// step with Status.
+func (p *Patient) BeforeSave(tx *gorm.DB) error {
+	p.NormalizeThaiNames()
+	p.Deceased = p.Status == PatientStatusDeceased
+	return nil
+}
+
+// NormalizeThaiNames converts the Thai names to NFC and sets their folded forms.
+func (p *Patient) NormalizeThaiNames() {
+	fold := func(name *string) *string {
+		*name = utils.NormalizeThai(*name)
+		folded := utils.FoldThai(*name)
+		return &folded
+	}
+	p.FirstNameTHFolded = fold(&p.FirstNameTH)
+	p.MiddleNameTHFolded = fold(&p.MiddleNameTH)
+	p.LastNameTHFolded = fold(&p.LastNameTH)
+}
+
+// Patient lifecycle statuses. services.ValidatePatientStatusTransition decides which changes are allowed.
+const (
+	PatientStatusActive      = "active"
+	PatientStatusInactive    = "inactive"
+	PatientStatusDeceased    = "deceased"
+	PatientStatusTransferred = "transferred"
+)
+
+// Preferred languages a patient can be contacted in.
+const (
+	PreferredLanguageThai    = "th"
+	PreferredLanguageEnglish = "en"
+)
+
+// Contact methods a patient can prefer. ContactMethodNone records that the patient has no
+// preference, unlike DoNotContact, which forbids contact altogether.
+const (
+	ContactMethodPhone = "phone"
+	ContactMethodSMS   = "sms"
+	ContactMethodEmail = "email"
+	ContactMethodNone  = "none"
+)
+
+// PatientStatusUpdateRequest changes a patient's lifecycle status.
+type PatientStatusUpdateRequest struct {
+	Status     string     `json:"status" binding:"required,oneof=active inactive deceased transferred"`
+	DeceasedAt *time.Time `json:"deceased_at"` // Only with status deceased; defaults to now
 }
 
 // PatientSearchQuery represents the query parameters for searching patients.
 // Fields are pointers to distinguish between zero values (e.g., empty string) and fields not provided.
 type PatientSearchQuery struct {
-	NationalID   *string `form:"national_id"`
-	PassportID   *string `form:"passport_id"`
-	FirstNameTH  *string `form:"first_name_th"`
-	FirstNameEN  *string `form:"first_name_en"`
-	MiddleNameTH *string `form:"middle_name_th"`
-	MiddleNameEN *string `form:"middle_name_en"`
-	LastNameTH   *string `form:"last_name_th"`
-	LastNameEN   *string `form:"last_name_en"`
-	DateOfBirth  *string `form:"date_of_birth"` // Expecting YYYY-MM-DD format
-	PhoneNumber  *string `form:"phone_number"`
-	Email        *string `form:"email"`
+	NationalID      *string `form:"national_id"` // national_id, passport_id and patient_hn take a comma-separated list
+	PassportID      *string `form:"passport_id"`
+	PatientHN       *string `form:"patient_hn"`
+	PatientHNPrefix *string `form:"patient_hn_prefix"` // Leading characters of the HN
+	FirstNameTH     *string `form:"first_name_th"`
+	FirstNameEN     *string `form:"first_name_en"`
+	MiddleNameTH    *string `form:"middle_name_th"`
+	MiddleNameEN    *string `form:"middle_name_en"`
+	LastNameTH      *string `form:"last_name_th"`
+	LastNameEN      *string `form:"last_name_en"`
+	DateOfBirth     *string `form:"date_of_birth"` // Expecting YYYY-MM-DD format
+	BirthYear       *int    `form:"birth_year"`    // Any date of birth in that year; not with date_of_birth
+	PhoneNumber     *string `form:"phone_number"`
+	PhoneSuffix     *string `form:"phone_suffix" binding:"omitempty,min=4,number"` // Trailing digits, e.g. from caller ID
+	Email           *string `form:"email"`
+	BloodType       *string `form:"blood_type" binding:"omitempty,oneof=A+ A- B+ B- AB+ AB- O+ O- unknown"`
+	Nationality     *string `form:"nationality"`
+	NameMatch       *string `form:"name_match" binding:"omitempty,oneof=exact prefix contains"` // How the name fields match; not a criterion
+	Match           *string `form:"match" binding:"omitempty,oneof=all any"`                    // All or any of the name, birth date and contact criteria; not a criterion
+	CreatedFrom     *string `form:"created_from"`                                               // Registered on or after; YYYY-MM-DD or RFC 3339
+	CreatedTo       *string `form:"created_to"`                                                 // Registered on or before; a date covers the whole day
+	IncludeInactive bool    `form:"include_inactive"`                                           // Also return deceased and transferred patients; not a criterion
+	ExcludeDeceased bool    `form:"exclude_deceased"`                                           // Leave out deceased patients even with include_inactive; not a criterion
+	NormalizeThai   bool    `form:"normalize_thai"`                                             // Match Thai names ignoring tone marks and vowel spelling variants; not a criterion
+	ConsentedOnly   bool    `form:"consented_only"`                                             // Only patients whose consent status is granted; not a criterion
+	LabelID         *uint   `form:"label_id"`                                                   // Only patients with this label
+	CrossHospital   bool    `form:"cross_hospital"`                                             // Also search the other hospitals of the staff's consortiums; admins only, not a criterion
+
+	// Communication preferences. preferred_language is a criterion like blood_type; do_not_contact
+	// only narrows the search, so do_not_contact=false leaves out patients who must not be contacted.
+	PreferredLanguage *string `form:"preferred_language" binding:"omitempty,oneof=th en"`
+	DoNotContact      *bool   `form:"do_not_contact"`
+
+	// The repeatable tag parameter: only patients with every one of these tags
+	Tags []string `form:"tag"`
+
+	// The extra.<key>=value parameters, which binding cannot map; set by BindExtra
+	Extra ExtraFields `form:"-"`
+
+	// Set by the export handler when ENFORCE_CONSENT_ON_EXPORT is on; never bound from the request
+	ExcludeConsentDenied bool `form:"-"`
+}
+
+// Criteria combination modes for PatientSearchQuery.Match. Identifiers, the created range and the
+// hospital scope always narrow the search.
+const (
+	SearchMatchAll = "all" // Default
+	SearchMatchAny = "any"
+)
+
+// MatchMode returns the requested criteria combination mode, defaulting to all.
+func (q *PatientSearchQuery) MatchMode() string {
+	if q.Match == nil || *q.Match == "" {
+		return SearchMatchAll
+	}
+	return *q.Match
+}
+
+// Name match modes for PatientSearchQuery.NameMatch.
+const (
+	NameMatchExact    = "exact"
+	NameMatchPrefix   = "prefix"
+	NameMatchContains = "contains" // Default, the original substring behavior
+)
+
+// NameMatchMode returns the requested name match mode, defaulting to contains.
+func (q *PatientSearchQuery) NameMatchMode() string {
+	if q.NameMatch == nil || *q.NameMatch == "" {
+		return NameMatchContains
+	}
+	return *q.NameMatch
+}
+
+// CriteriaCount returns how many search criteria were provided with a non-empty value.
+func (q *PatientSearchQuery) CriteriaCount() int {
+	count := 0
+	for _, field := range []*string{
+		q.NationalID, q.PassportID, q.PatientHN, q.PatientHNPrefix,
+		q.FirstNameTH, q.FirstNameEN, q.MiddleNameTH, q.MiddleNameEN, q.LastNameTH, q.LastNameEN,
+		q.DateOfBirth, q.PhoneNumber, q.PhoneSuffix, q.Email, q.BloodType, q.Nationality, q.PreferredLanguage, q.CreatedFrom, q.CreatedTo,
+	} {
+		if field != nil && *field != "" {
+			count++
+		}
+	}
+	if q.BirthYear != nil {
+		count++
+	}
+	if q.LabelID != nil {
+		count++
+	}
+	return count + len(q.Tags) + len(q.Extra)
+}
+
+// MinBirthYear is the earliest birth_year a search accepts.
+const MinBirthYear = 1900
+
+// BirthYearRange returns the half-open range [from, before) of dates of birth in the requested
+// birth year, with the year running midnight to midnight in loc. ok is false when no birth year was given.
+func (q *PatientSearchQuery) BirthYearRange(loc *time.Location) (from, before time.Time, ok bool) {
+	if q.BirthYear == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	from = time.Date(*q.BirthYear, time.January, 1, 0, 0, 0, 0, loc)
+	return from, from.AddDate(1, 0, 0), true
+}
+
+// DateOfBirthRange returns the half-open range [from, before) covering the requested date of
+// birth, a YYYY-MM-DD day running midnight to midnight in loc.
+func (q *PatientSearchQuery) DateOfBirthRange(loc *time.Location) (from, before time.Time, err error) {
+	from, err = time.ParseInLocation("2006-01-02", *q.DateOfBirth, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, from.AddDate(0, 0, 1), nil
+}
+
+// MaxIdentifierValues is the most values a comma-separated national_id, passport_id or patient_hn may list.
+const MaxIdentifierValues = 50
+
+// IdentifierValues splits a national_id, passport_id or patient_hn parameter into the values to match.
+// A value without commas is returned untouched; list entries are trimmed and empty ones dropped.
+func IdentifierValues(value string) []string {
+	if !strings.Contains(value, ",") {
+		return []string{value}
+	}
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// TooManyIdentifierValues returns the name of the first identifier parameter listing more than
+// MaxIdentifierValues values, or "" when all are within the cap.
+func (q *PatientSearchQuery) TooManyIdentifierValues() string {
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"national_id", q.NationalID},
+		{"passport_id", q.PassportID},
+		{"patient_hn", q.PatientHN},
+	} {
+		if field.value != nil && len(IdentifierValues(*field.value)) > MaxIdentifierValues {
+			return field.name
+		}
+	}
+	return ""
+}
+
+// ErrInvalidCreatedRange is returned by CreatedRange for unparseable or reversed bounds.
+var ErrInvalidCreatedRange = errors.New("created_from and created_to must be YYYY-MM-DD or RFC 3339 timestamps, with created_from not after created_to")
+
+// CreatedRange parses created_from and created_to into the half-open range [from, before).
+// Either bound is nil when not given. Date-only bounds are days in loc, and a date-only
+// created_to includes that whole day.
+func (q *PatientSearchQuery) CreatedRange(loc *time.Location) (from, before *time.Time, err error) {
+	if q.CreatedFrom != nil && *q.CreatedFrom != "" {
+		t, _, err := parseDateOrTimestamp(*q.CreatedFrom, loc)
+		if err != nil {
+			return nil, nil, ErrInvalidCreatedRange
+		}
+		from = &t
+	}
+	if q.CreatedTo != nil && *q.CreatedTo != "" {
+		t, dateOnly, err := parseDateOrTimestamp(*q.CreatedTo, loc)
+		if err != nil {
+			return nil, nil, ErrInvalidCreatedRange
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		} else {
+			t = t.Add(time.Microsecond) // Include the instant itself, at Postgres timestamp precision
+		}
+		before = &t
+	}
+	if from != nil && before != nil && !from.Before(*before) {
+		return nil, nil, ErrInvalidCreatedRange
+	}
+	return from, before, nil
+}
+
+// parseDateOrTimestamp accepts YYYY-MM-DD, read as midnight in loc, or an RFC 3339 timestamp,
+// and reports which it was.
+func parseDateOrTimestamp(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// PatientSearchResponse wraps search results. Truncated is set when more patients matched than
+// the search may return, in which case Hint suggests narrowing the query. A search asked for by
+// page is never truncated; Page, PageSize and Total describe the page instead.
+type PatientSearchResponse struct {
+	Data      interface{} `json:"data"` // []Patient, or []PatientWithHospital for cross-hospital searches
+	Count     int         `json:"count"`
+	Truncated bool        `json:"truncated"`
+	Hint      string      `json:"hint,omitempty"`
+	Page      int         `json:"page,omitempty"`
+	PageSize  int         `json:"page_size,omitempty"`
+	Total     *int64      `json:"total,omitempty"`
+	// Explain is the PostgreSQL plan of the search, for admins sending X-Debug-Explain outside production
+	Explain json.RawMessage `json:"_explain,omitempty"`
+}
+
+// MaxBatchHNs is the most HNs one POST /patient/search/batch-hn request may look up.
+const MaxBatchHNs = 50
+
+// PatientHNBatchRequest is the body of POST /patient/search/batch-hn. The max must match MaxBatchHNs.
+type PatientHNBatchRequest struct {
+	HNs []string `json:"hospital_number_list" binding:"required,min=1,max=50,dive,required"`
+}
+
+// PatientBulkDeleteResponse reports how many patients a hospital-wide delete removed.
+type PatientBulkDeleteResponse struct {
+	HospitalID uint  `json:"hospital_id"`
+	Deleted    int64 `json:"deleted"`
+}
+
+// PatientUpdateRequest changes a patient's editable details. Omitted fields are left alone;
+// an empty string clears a field.
+type PatientUpdateRequest struct {
+	BloodType              *string `json:"blood_type" binding:"omitempty,oneof=A+ A- B+ B- AB+ AB- O+ O- unknown"`
+	Nationality            *string `json:"nationality" binding:"omitempty,max=100"`
+	MaritalStatus          *string `json:"marital_status" binding:"omitempty,max=50"`
+	PreferredLanguage      *string `json:"preferred_language" binding:"omitempty,oneof=th en"`
+	PreferredContactMethod *string `json:"preferred_contact_method" binding:"omitempty,oneof=phone sms email none"`
+	DoNotContact           *bool   `json:"do_not_contact"`
+	// Extra fields to set; an empty value removes the field. Keys must be permitted by the hospital.
+	Extra ExtraFields `json:"extra"`
+}
+
+// PatientTransferRequest moves a patient to another hospital.
+type PatientTransferRequest struct {
+	TargetHospitalID uint `json:"target_hospital_id" binding:"required"`
+}
+
+// PatientWithHospital is a patient row with its hospital's name, used by cross-hospital search.
+type PatientWithHospital struct {
+	Patient
+	HospitalName string `json:"hospital_name"`
+}
+
+// PatientDetailResponse is the single-patient view, optionally enriched with related records.
+type PatientDetailResponse struct {
+	Patient
+	Allergies *[]Allergy `json:"allergies,omitempty"` // Only present with include=allergies
+}
+
+// Patient update operations, sent on the patient update stream.
+const (
+	PatientUpdateCreated     = "created"
+	PatientUpdateUpdated     = "updated"
+	PatientUpdateDeleted     = "deleted"
+	PatientUpdateTransferred = "transferred" // Sent to both the old and the new hospital
+)
+
+// PatientUpdate tells a hospital's subscribers that one of its patient records changed.
+// PatientID is zero when an operation covered every patient of the hospital.
+type PatientUpdate struct {
+	Operation  string `json:"operation"`
+	PatientID  uint   `json:"patient_id,omitempty"`
+	HospitalID uint   `json:"hospital_id"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// PatientAccessLog records that a patient's record was read: opened on its own or returned by a
+// search. It answers who looked at a patient and when, so it is kept even for API key reads,
+// which have no staff member behind them.
+type PatientAccessLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	StaffID    uint      `json:"staff_id" gorm:"not null;index:idx_patient_access_log_staff,priority:1"` // 0 for API key reads
+	APIKeyID   *uint     `json:"api_key_id,omitempty"`
+	PatientID  uint      `json:"patient_id" gorm:"not null;index:idx_patient_access_log_patient,priority:1"`
+	HospitalID uint      `json:"hospital_id" gorm:"not null"` // The patient's hospital at the time
+	Endpoint   string    `json:"endpoint" gorm:"not null"`    // Method and route, e.g. "GET /api/v1/patient/:id"
+	AccessedAt time.Time `json:"accessed_at" gorm:"not null;index;index:idx_patient_access_log_staff,priority:2;index:idx_patient_access_log_patient,priority:2"`
+}
+
+// TableName overrides GORM's pluralized "patient_access_logs".
+func (PatientAccessLog) TableName() string {
+	return "patient_access_log"
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Content types accepted for patient document uploads.
+const (
+	DocumentContentTypePDF  = "application/pdf"
+	DocumentContentTypeJPEG = "image/jpeg"
+	DocumentContentTypePNG  = "image/png"
+)
+
+// PatientDocument is the metadata of a file (e.g. a scanned consent form) attached to a patient.
+// The bytes themselves live in the blob store under StorageKey.
+type PatientDocument struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PatientID   uint      `json:"patient_id" gorm:"index;not null"`
+	HospitalID  uint      `json:"hospital_id" gorm:"index;not null;default:0"` // Where it was uploaded; backfilled for older documents
+	Filename    string    `json:"filename" gorm:"not null"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	SizeBytes   int64     `json:"size_bytes" gorm:"not null"`
+	SHA256      string    `json:"sha256" gorm:"column:sha256;size:64;not null"`
+	StorageKey  string    `json:"-" gorm:"uniqueIndex;not null"` // Internal blob location, never exposed
+	UploadedBy  uint      `json:"uploaded_by" gorm:"not null"`   // Staff ID of the uploader
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PatientEvent is the envelope published to the event broker when a patient record changes.
+// Event is one of the WebhookEventPatient* names and is also the broker subject. PatientID is
+// zero when the change covered every patient of the hospital, with Deleted giving the count.
+type PatientEvent struct {
+	Event         string    `json:"event"`
+	HospitalID    uint      `json:"hospital_id"`
+	PatientID     uint      `json:"patient_id,omitempty"`
+	ChangedFields []string  `json:"changed_fields,omitempty"` // Patient columns the change wrote
+	Deleted       int64     `json:"deleted,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ExtraFields holds a hospital's own patient fields, such as a referral source or ward code,
+// stored in the patients.extra jsonb column. Each hospital permits its own keys through
+// HospitalConfig.ExtraFieldKeys.
+type ExtraFields map[string]string
+
+// MaxExtraFieldsBytes caps the size of a patient's extra fields encoded as JSON.
+const MaxExtraFieldsBytes = 4096
+
+// ExtraSearchPrefix marks the search parameters that match extra fields, as in extra.ward_code=W3.
+const ExtraSearchPrefix = "extra."
+
+var extraFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// ValidExtraFieldKey reports whether key can name an extra field: up to 40 lowercase letters,
+// digits and underscores, starting with a letter.
+func ValidExtraFieldKey(key string) bool {
+	return extraFieldKeyPattern.MatchString(key)
+}
+
+// Value stores the fields as a JSON object.
+func (f ExtraFields) Value() (driver.Value, error) {
+	if f == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the fields back from a JSON object.
+func (f *ExtraFields) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	case nil:
+		*f = nil
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into ExtraFields", value)
+}
+
+// BindExtra sets q.Extra from the extra.<key> parameters of a search query string.
+// Only the first value of a repeated parameter is used.
+func (q *PatientSearchQuery) BindExtra(form map[string][]string) error {
+	for param, values := range form {
+		key, ok := strings.CutPrefix(param, ExtraSearchPrefix)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if !ValidExtraFieldKey(key) {
+			return fmt.Errorf("%q is not a valid extra field name", key)
+		}
+		if q.Extra == nil {
+			q.Extra = ExtraFields{}
+		}
+		q.Extra[key] = values[0]
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// patientResponseFields holds the JSON field names a patient search result can have: those of
+// PatientWithHospital, which are Patient's plus hospital_name. Fields hidden from JSON, such as
+// the folded Thai names, are not among them, so fields cannot reveal them.
+var patientResponseFields = jsonFieldNames(reflect.TypeOf(PatientWithHospital{}))
+
+// ParsePatientFields parses the fields search parameter, a comma-separated list of the patient
+// fields to return. The result always includes id. An empty parameter gives nil, meaning every field.
+func ParsePatientFields(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	fields := []string{"id"}
+	var unknown []string
+	for _, part := range strings.Split(raw, ",") {
+		name := strings.TrimSpace(part)
+		switch {
+		case name == "" || name == "id":
+		case !patientResponseFields[name]:
+			unknown = append(unknown, name)
+		default:
+			fields = append(fields, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown fields: %s", strings.Join(unknown, ", "))
+	}
+	return fields, nil
+}
+
+// jsonFieldNames returns the JSON names of t's fields, including those of embedded structs.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			for name := range jsonFieldNames(field.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
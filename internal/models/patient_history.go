@@ -0,0 +1,48 @@
+package models
+
+// Patient fields a history import can read from a legacy text export, named as in the patient JSON.
+const (
+	HistoryFieldPatientHN   = "patient_hn"
+	HistoryFieldFirstNameTH = "first_name_th"
+	HistoryFieldLastNameTH  = "last_name_th"
+	HistoryFieldFirstNameEN = "first_name_en"
+	HistoryFieldLastNameEN  = "last_name_en"
+	HistoryFieldDateOfBirth = "date_of_birth"
+	HistoryFieldNationalID  = "national_id"
+	HistoryFieldPhoneNumber = "phone_number"
+	HistoryFieldBloodType   = "blood_type"
+)
+
+// HistoryImportMaxBytes caps a history import upload.
+const HistoryImportMaxBytes = 1 << 20
+
+// PatientHistoryDiff compares the fields read from a history import with the patient record.
+// Every history field is in exactly one of the lists. Nothing is changed until the mismatches
+// are confirmed with PATCH /patient/:id/history/import.
+type PatientHistoryDiff struct {
+	PatientID  uint                   `json:"patient_id"`
+	Matched    []PatientHistoryValue  `json:"matched"`
+	Mismatched []PatientHistoryChange `json:"mismatched"`
+	NotFound   []string               `json:"not_found"`  // Fields the file has no line for
+	Unreadable []PatientHistoryValue  `json:"unreadable"` // Fields whose value could not be understood, as written in the file
+}
+
+// PatientHistoryValue is a field with a single value.
+type PatientHistoryValue struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// PatientHistoryChange is a field whose imported value differs from the record's. Current is ""
+// when the record has no value. Dates are YYYY-MM-DD.
+type PatientHistoryChange struct {
+	Field    string `json:"field" binding:"required"`
+	Current  string `json:"current"`
+	Imported string `json:"imported" binding:"required"`
+}
+
+// PatientHistoryApplyRequest is the body of PATCH /patient/:id/history/import: the mismatches
+// of a diff to apply. Each change only applies while the record still has its current value.
+type PatientHistoryApplyRequest struct {
+	Changes []PatientHistoryChange `json:"changes" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// PatientLabel is a category a hospital defines for its patients, such as "VIP" or
+// "High Risk", so clinicians can pick them out of search results at a glance.
+// Names are unique within a hospital, ignoring case.
+type PatientLabel struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	HospitalID uint      `json:"hospital_id" gorm:"not null;index"`
+	Name       string    `json:"name" gorm:"not null"`
+	Color      string    `json:"color" gorm:"not null"` // Hex, e.g. "#ff0000", for clients to draw the label in
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Only declared so AutoMigrate adds the foreign key; never loaded
+	Hospital *Hospital `json:"-" gorm:"foreignKey:HospitalID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+}
+
+// PatientLabelAssignment attaches a label to a patient. It is the join table behind Patient.Labels.
+type PatientLabelAssignment struct {
+	PatientID uint `json:"patient_id" gorm:"primaryKey"`
+	LabelID   uint `json:"label_id" gorm:"primaryKey;index"`
+}
+
+// PatientLabelCreateRequest is the body of POST /admin/label.
+type PatientLabelCreateRequest struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Color string `json:"color" binding:"required,hexcolor"`
+}
+
+// PatientLabelUpdateRequest is the body of PUT /admin/label/:id. Omitted fields are left unchanged.
+type PatientLabelUpdateRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Color *string `json:"color" binding:"omitempty,hexcolor"`
+}
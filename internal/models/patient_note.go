@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxPatientNoteLength caps a note body, in characters rather than bytes so Thai text gets the same room.
+const MaxPatientNoteLength = 5000
+
+// PatientNote is a free-text clinical note staff attach to a patient.
+// Private notes are visible to their author only.
+type PatientNote struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	PatientID  uint           `json:"patient_id" gorm:"index;not null"`
+	HospitalID uint           `json:"hospital_id" gorm:"index;not null;default:0"` // Where the note was written; backfilled for older notes
+	AuthorID   uint           `json:"author_id" gorm:"not null"`                   // Staff ID of the author
+	Body       string         `json:"body" gorm:"type:text;not null"`
+	Pinned     bool           `json:"pinned" gorm:"not null;default:false"`
+	IsPrivate  bool           `json:"is_private" gorm:"not null;default:false"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"` // Deleted notes stay in the table for the audit trail
+}
+
+// VisibleTo reports whether the staff member may see the note.
+func (n *PatientNote) VisibleTo(staffID uint) bool {
+	return !n.IsPrivate || n.AuthorID == staffID
+}
+
+// PatientNoteCreateRequest represents the input for adding a note.
+type PatientNoteCreateRequest struct {
+	Body      string `json:"body" binding:"required"`
+	Pinned    bool   `json:"pinned"`
+	IsPrivate bool   `json:"is_private"`
+}
+
+// PatientNoteUpdateRequest changes a note. Omitted fields are left as they are.
+type PatientNoteUpdateRequest struct {
+	Body      *string `json:"body"`
+	Pinned    *bool   `json:"pinned"`
+	IsPrivate *bool   `json:"is_private"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Tag marks a cohort of a hospital's patients, such as "diabetes-program" or "vaccination-due".
+// Tags are created the first time a patient is tagged. Names are normalized with NormalizeTagName
+// and unique within a hospital.
+type Tag struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	HospitalID uint      `json:"hospital_id" gorm:"not null;uniqueIndex:idx_tags_hospital_name"`
+	Name       string    `json:"name" gorm:"not null;uniqueIndex:idx_tags_hospital_name"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Only declared so AutoMigrate adds the foreign key; never loaded
+	Hospital *Hospital `json:"-" gorm:"foreignKey:HospitalID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+}
+
+// PatientTag attaches a tag to a patient. It is the join table behind Patient.Tags.
+type PatientTag struct {
+	PatientID uint `json:"patient_id" gorm:"primaryKey"`
+	TagID     uint `json:"tag_id" gorm:"primaryKey;index"`
+}
+
+// TagCount is a tag of a hospital with the number of its patients carrying it.
+type TagCount struct {
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	PatientCount int64  `json:"patient_count"`
+}
+
+// PatientTagRequest is the body of POST /patient/:id/tags.
+type PatientTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MaxTagNameLength is the longest tag name, after normalization.
+const MaxTagNameLength = 50
+
+// NormalizeTagName turns a tag name into lowercase kebab case: letters (Thai included) and digits
+// are kept in lower case and every run of other characters becomes a single hyphen, so
+// "Diabetes Program" and "diabetes_program" are the same tag. A name with no letters or digits
+// normalizes to "".
+func NormalizeTagName(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) { // Thai vowels and tone marks are marks
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
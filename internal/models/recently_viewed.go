@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// MaxRecentlyViewed is how many patients a staff member's recently viewed list keeps.
+const MaxRecentlyViewed = 10
+
+// RecentlyViewed records when a staff member last opened a patient's record.
+// Only the newest MaxRecentlyViewed rows per staff member are kept.
+type RecentlyViewed struct {
+	StaffID    uint      `json:"staff_id" gorm:"primaryKey;autoIncrement:false"`
+	PatientID  uint      `json:"patient_id" gorm:"primaryKey;autoIncrement:false"`
+	HospitalID uint      `json:"hospital_id" gorm:"not null"`
+	ViewedAt   time.Time `json:"viewed_at" gorm:"not null;index"`
+}
+
+// TableName overrides GORM's pluralized "recently_vieweds".
+func (RecentlyViewed) TableName() string {
+	return "recently_viewed"
+}
+
+// RecentlyViewedPatient is one entry of GET /staff/recently-viewed.
+type RecentlyViewedPatient struct {
+	PatientID   uint      `json:"patient_id"`
+	PatientHN   string    `json:"patient_hn"`
+	FirstNameTH string    `json:"first_name_th"`
+	LastNameTH  string    `json:"last_name_th"`
+	FirstNameEN string    `json:"first_name_en"`
+	LastNameEN  string    `json:"last_name_en"`
+	ViewedAt    time.Time `json:"viewed_at"`
+}
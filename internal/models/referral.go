@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Referral priorities.
+const (
+	ReferralPriorityRoutine   = "routine"
+	ReferralPriorityUrgent    = "urgent"
+	ReferralPriorityEmergency = "emergency"
+)
+
+// Referral statuses. A referral starts pending and the target hospital accepts or rejects it;
+// completed marks an accepted referral once the patient has been seen.
+const (
+	ReferralStatusPending   = "pending"
+	ReferralStatusAccepted  = "accepted"
+	ReferralStatusRejected  = "rejected"
+	ReferralStatusCompleted = "completed"
+)
+
+// Referral asks another hospital to take over or share the care of a patient.
+type Referral struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	SourceHospitalID uint      `json:"source_hospital_id" gorm:"index;not null"`
+	TargetHospitalID uint      `json:"target_hospital_id" gorm:"index;not null"`
+	PatientID        uint      `json:"patient_id" gorm:"index;not null"`
+	ReferringStaffID uint      `json:"referring_staff_id" gorm:"not null"`
+	Reason           string    `json:"reason" gorm:"not null"`
+	Priority         string    `json:"priority" gorm:"not null"`
+	Status           string    `json:"status" gorm:"not null;default:pending;index"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ReferralCreateRequest represents the input for referring a patient to another hospital.
+type ReferralCreateRequest struct {
+	PatientID        uint   `json:"patient_id" binding:"required"`
+	TargetHospitalID uint   `json:"target_hospital_id" binding:"required"`
+	Reason           string `json:"reason" binding:"required"`
+	Priority         string `json:"priority" binding:"required,oneof=routine urgent emergency"`
+}
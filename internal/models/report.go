@@ -0,0 +1,88 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MonthlyReportQuery is the query of GET /admin/reports/monthly.
+type MonthlyReportQuery struct {
+	Year  int `form:"year" binding:"required,min=2000,max=9999"`
+	Month int `form:"month" binding:"required,min=1,max=12"`
+}
+
+// MonthlyReport summarizes a hospital's month for hospital management. Months run from midnight
+// on the first in the configured TIMEZONE.
+type MonthlyReport struct {
+	HospitalID       uint  `json:"hospital_id"`
+	Year             int   `json:"year"`
+	Month            int   `json:"month"`
+	NewRegistrations int64 `json:"new_registrations"` // Patients registered in the month, including ones since deleted
+	Admissions       int64 `json:"admissions"`
+	Discharges       int64 `json:"discharges"`
+	// Mean stay of the patients discharged in the month; null when there were none
+	AvgLengthOfStayDays    *float64 `json:"avg_length_of_stay_days"`
+	TotalSearchesPerformed int64    `json:"total_searches_performed"`
+	// How many searches filled each PatientSearchQuery field, most used first
+	TopSearchFieldsUsed []SearchFieldCount `json:"top_search_fields_used"`
+	GeneratedAt         time.Time          `json:"generated_at"`
+}
+
+// SearchFieldCount is how many searches used a search parameter.
+type SearchFieldCount struct {
+	Field string `json:"field"`
+	Count int64  `json:"count"`
+}
+
+// SearchFieldHistogram turns per-parameter search counts into TopSearchFieldsUsed: only
+// PatientSearchQuery fields, most used first and by name among equals.
+func SearchFieldHistogram(counts map[string]int64) []SearchFieldCount {
+	known := searchQueryFields()
+	histogram := []SearchFieldCount{}
+	for field, count := range counts {
+		if known[field] {
+			histogram = append(histogram, SearchFieldCount{Field: field, Count: count})
+		}
+	}
+	sort.Slice(histogram, func(i, j int) bool {
+		if histogram[i].Count != histogram[j].Count {
+			return histogram[i].Count > histogram[j].Count
+		}
+		return histogram[i].Field < histogram[j].Field
+	})
+	return histogram
+}
+
+// Value stores the report as JSON.
+func (r MonthlyReport) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the report back from JSON.
+func (r *MonthlyReport) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	}
+	return fmt.Errorf("cannot scan %T into MonthlyReport", value)
+}
+
+// CachedReport is a generated MonthlyReport kept so it isn't computed on every request. A report
+// generated after its month ended is final; one generated earlier is regenerated when asked for.
+type CachedReport struct {
+	ID          uint          `gorm:"primaryKey"`
+	HospitalID  uint          `gorm:"not null;uniqueIndex:idx_cached_reports_month,priority:1"`
+	Year        int           `gorm:"not null;uniqueIndex:idx_cached_reports_month,priority:2"`
+	Month       int           `gorm:"not null;uniqueIndex:idx_cached_reports_month,priority:3"`
+	Report      MonthlyReport `gorm:"type:jsonb;not null"`
+	GeneratedAt time.Time     `gorm:"not null"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// RetentionCutoffs are the points in time before which records are past their retention period.
+type RetentionCutoffs struct {
+	AsOf                  time.Time `json:"as_of"`                   // When the cutoffs were computed
+	PatientsDeletedBefore time.Time `json:"patients_deleted_before"` // Patients soft-deleted before this are purged
+	TokensRevokedBefore   time.Time `json:"tokens_revoked_before"`   // Revocations made before this are purged once their token expired
+}
+
+// NewRetentionCutoffs returns the cutoffs at now for the retention periods, in days.
+func NewRetentionCutoffs(now time.Time, patientDays, revokedTokenDays int) RetentionCutoffs {
+	return RetentionCutoffs{
+		AsOf:                  now,
+		PatientsDeletedBefore: now.AddDate(0, 0, -patientDays),
+		TokensRevokedBefore:   now.AddDate(0, 0, -revokedTokenDays),
+	}
+}
+
+// RetentionPurgeReport counts the records a retention purge deletes or, in a dry run, would delete.
+type RetentionPurgeReport struct {
+	RetentionCutoffs
+	DryRun        bool  `json:"dry_run"`
+	Patients      int64 `json:"patients"`
+	RevokedTokens int64 `json:"revoked_tokens"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RevokedToken records a JWT that was logged out before it expired.
+// Rows are only needed until ExpiresAt, after which the token is rejected anyway.
+type RevokedToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JTI       string    `json:"jti" gorm:"column:jti;uniqueIndex;not null"` // The token's "jti" claim
+	StaffID   uint      `json:"staff_id" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxSavedSearchesPerStaff caps how many saved searches one staff member may keep.
+const MaxSavedSearchesPerStaff = 20
+
+// SavedSearch is a named patient search a staff member can run again later.
+type SavedSearch struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	StaffID     uint         `json:"staff_id" gorm:"not null;uniqueIndex:idx_saved_search_staff_name"`
+	HospitalID  uint         `json:"hospital_id" gorm:"not null"`
+	Name        string       `json:"name" gorm:"not null;uniqueIndex:idx_saved_search_staff_name"`
+	QueryParams SearchParams `json:"query_params" gorm:"type:jsonb;not null"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// SearchParams holds patient search parameters as they appear in the query string,
+// e.g. {"last_name_en": "Smith", "include_inactive": "true"}. Stored as a jsonb object.
+type SearchParams map[string]string
+
+// Value stores the parameters as a JSON object.
+func (p SearchParams) Value() (driver.Value, error) {
+	if p == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the parameters back from a JSON object.
+func (p *SearchParams) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*p = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into SearchParams", value)
+	}
+	return json.Unmarshal(b, p)
+}
+
+// UnknownFields returns the parameters, sorted, that are not PatientSearchQuery fields or
+// extra.<key> criteria.
+func (p SearchParams) UnknownFields() []string {
+	known := searchQueryFields()
+	var unknown []string
+	for key := range p {
+		if !known[key] && !strings.HasPrefix(key, ExtraSearchPrefix) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// searchQueryFields returns the query parameter names PatientSearchQuery binds.
+func searchQueryFields() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(PatientSearchQuery{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("form"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// SavedSearchCreateRequest is the body of POST /staff/saved-searches.
+type SavedSearchCreateRequest struct {
+	Name        string       `json:"name" binding:"required,max=100"`
+	QueryParams SearchParams `json:"query_params" binding:"required"`
+}
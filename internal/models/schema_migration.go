@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SchemaMigration records a versioned migration file that was applied to the database.
+type SchemaMigration struct {
+	Version   int       `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `json:"name" gorm:"not null"`
+	AppliedAt time.Time `json:"applied_at" gorm:"not null"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SearchHistory records a patient search for later review.
+type SearchHistory struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	StaffID     uint      `json:"staff_id" gorm:"index;not null"`
+	HospitalID  uint      `json:"hospital_id" gorm:"not null"`
+	Query       string    `json:"query" gorm:"type:text;not null"` // The raw query string
+	ResultCount int       `json:"result_count" gorm:"not null"`
+	SearchedAt  time.Time `json:"searched_at" gorm:"index;not null;autoCreateTime"`
+}
+
+// TableName overrides GORM's pluralized "search_histories".
+func (SearchHistory) TableName() string {
+	return "search_history"
+}
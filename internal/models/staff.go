@@ -1,16 +1,70 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Staff roles.
+const (
+	RoleStaff  = "staff"
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer" // Sees that patients exist, with identifiers and phone numbers masked
+
+	// RoleSuperAdmin is a central-office admin who may search and manage every hospital.
+	RoleSuperAdmin = "super_admin"
+)
+
+// ValidRole reports whether role is one of the Role* constants.
+func ValidRole(role string) bool {
+	switch role {
+	case RoleStaff, RoleAdmin, RoleViewer, RoleSuperAdmin:
+		return true
+	}
+	return false
+}
 
 // Staff represents the hospital staff data model.
 type Staff struct {
-	ID           uint      `json:"id" gorm:"primaryKey"`
-	Username     string    `json:"username" gorm:"uniqueIndex;not null"` // Unique username for login
-	PasswordHash string    `json:"-" gorm:"not null"`                    // "-" prevents it from being marshalled into JSON
-	HospitalID   uint      `json:"hospital_id" gorm:"index;not null"`    // ID of the hospital the staff belongs to
-	HospitalName string    `json:"hospital_name" gorm:"not null"`
-	CreatedAt    time.Time `json:"created_at" gorm:"not null"`
-	UpdatedAt    time.Time `json:"updated_at " gorm:"not null"`
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Username     string     `json:"username" gorm:"not null"`          // Login name, in the casing it was created with
+	PasswordHash string     `json:"-" gorm:"not null"`                 // "-" prevents it from being marshalled into JSON
+	HospitalID   uint       `json:"hospital_id" gorm:"index;not null"` // ID of the hospital the staff belongs to
+	HospitalName string     `json:"hospital_name" gorm:"not null"`
+	Role         string     `json:"role" gorm:"not null;default:staff"` // One of the Role* constants
+	TOTPSecret   string     `json:"-"`                                  // Base32 TOTP secret, set once enrollment starts
+	TOTPEnabled  bool       `json:"two_factor_enabled" gorm:"not null;default:false"`
+	TOTPLastStep int64      `json:"-" gorm:"not null;default:0"`            // Newest TOTP time step accepted, so a code can't be replayed
+	IsActive     bool       `json:"is_active" gorm:"not null;default:true"` // Inactive staff cannot log in
+	LastLoginAt  *time.Time `json:"last_login_at"`                          // Set on each successful login
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null"`
+	UpdatedAt    time.Time  `json:"updated_at " gorm:"not null"`
+	// NormalizeUsername(Username), kept in step by BeforeSave. Logins look staff up by it, and its
+	// unique index (see migrateStaffUsernames) stops "Admin" and "admin" from both existing.
+	UsernameNormalized string `json:"-" gorm:"not null;default:''"`
+	// Only declared so AutoMigrate adds the foreign key; never loaded
+	Hospital *Hospital `json:"-" gorm:"foreignKey:HospitalID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+}
+
+// BeforeSave refreshes the normalized username.
+func (s *Staff) BeforeSave(tx *gorm.DB) error {
+	s.UsernameNormalized = NormalizeUsername(s.Username)
+	return nil
+}
+
+// NormalizeUsername returns the form usernames are compared in, so that logins and uniqueness
+// ignore case.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(username)
+}
+
+// StaffSummary is the public identity of a staff member, returned when a create request collides with it.
+type StaffSummary struct {
+	ID           uint   `json:"id"`
+	Username     string `json:"username"`
+	HospitalName string `json:"hospital_name"`
 }
 
 // StaffCreateRequest represents the input for creating a new staff member.
@@ -25,10 +79,60 @@ type StaffLoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 	Hospital string `json:"hospital" binding:"required"` // Hospital Name or ID
+	OTPCode  string `json:"otp_code"`                    // Required once two-factor authentication is enabled
+}
+
+// StaffChangePasswordRequest represents the input for changing the logged-in staff member's password.
+type StaffChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// StaffRoleUpdateRequest changes a staff member's role. Super admins are not appointed this way.
+type StaffRoleUpdateRequest struct {
+	Role string `json:"role" binding:"required,oneof=staff admin viewer"`
 }
 
 // StaffLoginResponse represents the output after successful login.
 type StaffLoginResponse struct {
 	Token string `json:"token"`
-	Staff Staff  `json:"staff"` // Return basic staff info (excluding password)
+	// When the token stops working, so clients can schedule a new login without decoding it
+	ExpiresAt time.Time `json:"expires_at"`
+	ExpiresIn int64     `json:"expires_in"` // Seconds from now
+	Staff     Staff     `json:"staff"`      // Return basic staff info (excluding password)
+}
+
+// TokenVerifyResponse is the minimal view of a valid token returned by GET /auth/verify.
+type TokenVerifyResponse struct {
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	HospitalID uint      `json:"hospital_id"`
+	Role       string    `json:"role"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// TwoFactorEnrollResponse carries a freshly generated TOTP secret for the authenticator app.
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"` // Suitable for rendering as a QR code
+}
+
+// TwoFactorConfirmRequest proves the authenticator app was set up with the enrolled secret.
+type TwoFactorConfirmRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// StaffImportResult summarizes a bulk staff import. Every skipped row has an entry in Errors.
+// In a dry run nothing is saved and Imported counts the rows that would have been.
+type StaffImportResult struct {
+	DryRun   bool               `json:"dry_run"`
+	Imported int                `json:"imported"`
+	Skipped  int                `json:"skipped"`
+	Errors   []StaffImportError `json:"errors"`
+}
+
+// StaffImportError explains why one CSV row was not imported.
+type StaffImportError struct {
+	Row    int    `json:"row"` // Line number in the file; the header is row 1
+	Reason string `json:"reason"`
 }
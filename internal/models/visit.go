@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Visit represents a single patient encounter at a hospital.
+type Visit struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	PatientID     uint       `json:"patient_id" gorm:"index;not null"`
+	HospitalID    uint       `json:"hospital_id" gorm:"not null;uniqueIndex:idx_visits_hospital_number;index:idx_visits_hospital_admitted,priority:1"` // Inherited from the patient
+	VisitNumber   string     `json:"visit_number" gorm:"not null;uniqueIndex:idx_visits_hospital_number"`
+	AdmittedAt    time.Time  `json:"admitted_at" gorm:"not null;index:idx_visits_hospital_admitted,priority:2"`
+	DischargedAt  *time.Time `json:"discharged_at"`
+	Department    string     `json:"department"`
+	AttendingNote string     `json:"attending_note"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// VisitCreateRequest represents the input for recording a new visit.
+type VisitCreateRequest struct {
+	VisitNumber   string     `json:"visit_number" binding:"required"`
+	AdmittedAt    *time.Time `json:"admitted_at"` // Defaults to now when omitted
+	DischargedAt  *time.Time `json:"discharged_at"`
+	Department    string     `json:"department"`
+	AttendingNote string     `json:"attending_note"`
+}
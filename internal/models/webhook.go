@@ -0,0 +1,115 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Webhook events. patient.deleted from a bulk delete carries no patient, only the hospital.
+// webhook.test is only sent on request, by POST /admin/webhook/test, and cannot be subscribed to.
+const (
+	WebhookEventPatientCreated = "patient.created"
+	WebhookEventPatientUpdated = "patient.updated"
+	WebhookEventPatientDeleted = "patient.deleted"
+	WebhookEventTest           = "webhook.test"
+)
+
+// Webhook is an endpoint of a downstream system, such as an appointment system, that is sent
+// a signed POST whenever one of its events happens to a patient of its hospital.
+type Webhook struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	HospitalID uint       `json:"hospital_id" gorm:"not null;index"`
+	URL        string     `json:"url" gorm:"not null"`
+	Secret     string     `json:"-" gorm:"not null"` // HMAC-SHA256 key for the signature header; shown once, on creation
+	Events     StringList `json:"events" gorm:"type:jsonb;not null"`
+	Active     bool       `json:"active" gorm:"not null;default:true"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// StringList is a list of strings stored as a jsonb array.
+type StringList []string
+
+// Value stores the list as a JSON array.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the list back from a JSON array.
+func (l *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case nil:
+		*l = nil
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into StringList", value)
+}
+
+// WebhookCreateRequest is the body of POST /admin/webhooks. A secret is generated when none is given.
+type WebhookCreateRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=patient.created patient.updated patient.deleted"`
+	Active *bool    `json:"active"` // Defaults to true
+}
+
+// WebhookUpdateRequest is the body of PUT /admin/webhooks/:id. Omitted fields are left unchanged.
+type WebhookUpdateRequest struct {
+	URL    *string  `json:"url" binding:"omitempty,url,max=2048"`
+	Secret *string  `json:"secret" binding:"omitempty,min=16,max=255"`
+	Events []string `json:"events" binding:"omitempty,min=1,dive,oneof=patient.created patient.updated patient.deleted"`
+	Active *bool    `json:"active"`
+}
+
+// WebhookTestRequest is the body of POST /admin/webhook/test.
+type WebhookTestRequest struct {
+	WebhookID uint `json:"webhook_id" binding:"required"`
+}
+
+// WebhookTestResponse reports how a webhook's receiver answered a test delivery.
+type WebhookTestResponse struct {
+	WebhookID  uint `json:"webhook_id"`
+	StatusCode int  `json:"status_code"`
+	Delivered  bool `json:"delivered"` // Whether the status was 2xx
+}
+
+// WebhookCreateResponse returns a new webhook with its secret, which is not shown again.
+type WebhookCreateResponse struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookPayload is the JSON body POSTed to a webhook.
+type WebhookPayload struct {
+	Event      string    `json:"event"`
+	Timestamp  time.Time `json:"timestamp"`
+	HospitalID uint      `json:"hospital_id"`
+	// Snapshot after the change, with identifiers masked as for viewers; nil for bulk deletes
+	Patient *Patient `json:"patient,omitempty"`
+	Deleted int64    `json:"deleted,omitempty"` // Patients removed by a bulk delete
+}
+
+// WebhookDeadLetter records a delivery that still failed after every retry.
+type WebhookDeadLetter struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WebhookID  uint      `json:"webhook_id" gorm:"not null;index"`
+	Event      string    `json:"event" gorm:"not null"`
+	Payload    string    `json:"payload" gorm:"type:text;not null"` // The body as sent, for replaying
+	Attempts   int       `json:"attempts" gorm:"not null"`
+	StatusCode int       `json:"status_code"` // Of the last attempt; 0 when no response was received
+	LastError  string    `json:"last_error" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"hospital-middleware/internal/models"
+	"log"
+	"sync"
+)
+
+// AccessLogStore is the part of the repository the patient access log needs.
+// database.PatientRepository satisfies it.
+type AccessLogStore interface {
+	RecordPatientAccess(entries []models.PatientAccessLog) error
+}
+
+// AccessLogger writes patient access log entries in the background, so recording who read a
+// patient never slows the read itself.
+type AccessLogger struct {
+	store AccessLogStore
+	wg    sync.WaitGroup
+}
+
+// NewAccessLogger returns an access logger writing to store.
+func NewAccessLogger(store AccessLogStore) *AccessLogger {
+	return &AccessLogger{store: store}
+}
+
+// Record stores the entries on their own goroutine and returns at once. A failure is logged;
+// the read it records has been answered by then.
+func (l *AccessLogger) Record(entries []models.PatientAccessLog) {
+	if len(entries) == 0 {
+		return
+	}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		if err := l.store.RecordPatientAccess(entries); err != nil {
+			log.Printf("Error recording access log of %s for %d patient(s): %v", entries[0].Endpoint, len(entries), err)
+		}
+	}()
+}
+
+// Wait blocks until every entry recorded so far has been written or has failed.
+func (l *AccessLogger) Wait() {
+	l.wg.Wait()
+}
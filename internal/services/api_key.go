@@ -0,0 +1,135 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API keys look like "hmk_<prefix>_<secret>". The prefix finds the row; only the secret is hashed,
+// which keeps it under bcrypt's 72-byte limit.
+const (
+	apiKeyScheme      = "hmk"
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 24
+)
+
+// API key errors returned by AuthenticateAPIKey and CreateAPIKey.
+var (
+	ErrInvalidAPIKey      = errors.New("invalid API key")
+	ErrAPIKeyExpired      = errors.New("API key has expired")
+	ErrAPIKeyRevoked      = errors.New("API key has been revoked")
+	ErrAPIKeyExpiryInPast = errors.New("expires_at must be in the future")
+)
+
+// APIKeyStore is the part of the repository API key authentication needs.
+// database.PatientRepository satisfies it.
+type APIKeyStore interface {
+	FindAPIKeyByPrefix(prefix string) (*models.APIKey, error)
+	TouchAPIKey(id uint, usedAt time.Time) error
+}
+
+// CreateAPIKey generates a key for the hospital and stores its hash.
+// The returned response is the only place the full key appears.
+func CreateAPIKey(repo database.PatientRepository, hospitalID uint, req models.APIKeyCreateRequest) (*models.APIKeyCreateResponse, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrAPIKeyExpiryInPast
+	}
+
+	prefix, err := randomHex(apiKeyPrefixBytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate API key: %w", err)
+	}
+	secret, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate API key: %w", err)
+	}
+	hash, err := utils.HashPassword(secret)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash API key: %w", err)
+	}
+
+	key := models.APIKey{
+		HospitalID:  hospitalID,
+		Prefix:      prefix,
+		KeyHash:     hash,
+		Description: req.Description,
+		ExpiresAt:   req.ExpiresAt,
+		IsActive:    true,
+	}
+	if err := repo.CreateAPIKey(&key); err != nil {
+		return nil, err
+	}
+	return &models.APIKeyCreateResponse{
+		APIKey: key,
+		Key:    fmt.Sprintf("%s_%s_%s", apiKeyScheme, prefix, secret),
+	}, nil
+}
+
+// AuthenticateAPIKey verifies a key from the X-API-Key header and returns claims for its hospital.
+// API key clients get the staff role: they can work with patients but never reach admin endpoints.
+func AuthenticateAPIKey(store APIKeyStore, rawKey string) (*Claims, error) {
+	prefix, secret, ok := parseAPIKey(rawKey)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := store.FindAPIKeyByPrefix(prefix)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.CheckPasswordHashDummy(secret) // Unknown prefixes take as long as wrong secrets
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if !utils.CheckPasswordHash(secret, key.KeyHash) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	switch {
+	case !key.IsActive:
+		return nil, ErrAPIKeyRevoked
+	case !key.Usable(now):
+		return nil, ErrAPIKeyExpired
+	}
+
+	// Usage tracking is informational, so a failed update does not reject the request
+	if err := store.TouchAPIKey(key.ID, now); err != nil {
+		log.Printf("Error recording use of API key %d: %v", key.ID, err)
+	}
+
+	return &Claims{
+		Username:   fmt.Sprintf("api-key:%d", key.ID),
+		HospitalID: key.HospitalID,
+		Role:       models.RoleStaff,
+		APIKeyID:   key.ID,
+	}, nil
+}
+
+// parseAPIKey splits a key into its prefix and secret.
+func parseAPIKey(rawKey string) (prefix, secret string, ok bool) {
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != apiKeyScheme || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
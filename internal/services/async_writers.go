@@ -0,0 +1,25 @@
+package services
+
+import "net/http"
+
+// AsyncWriters are the writes that requests hand off to finish after they are answered. The server
+// creates them and passes them to the router, so it can wait for them on shutdown.
+type AsyncWriters struct {
+	AccessLog *AccessLogger
+	Webhooks  *WebhookDispatcher
+}
+
+// AsyncWriterStore is the part of the repository the async writers need.
+// database.PatientRepository satisfies it.
+type AsyncWriterStore interface {
+	AccessLogStore
+	WebhookStore
+}
+
+// NewAsyncWriters returns the writers backed by store.
+func NewAsyncWriters(store AsyncWriterStore) *AsyncWriters {
+	return &AsyncWriters{
+		AccessLog: NewAccessLogger(store),
+		Webhooks:  NewWebhookDispatcher(store, &http.Client{Timeout: WebhookTimeout}, WebhookRetryBackoff),
+	}
+}
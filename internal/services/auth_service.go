@@ -1,6 +1,8 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"hospital-middleware/internal/config"
@@ -19,65 +21,199 @@ type Claims struct {
 	UserID     uint   `json:"user_id"`
 	Username   string `json:"username"`
 	HospitalID uint   `json:"hospital_id"`
+	Role       string `json:"role"`
+	// TwoFactorEnrollment marks a short-lived token that may only be used to enroll in two-factor authentication.
+	TwoFactorEnrollment bool `json:"two_factor_enrollment,omitempty"`
+	// APIKeyID is set on claims built from an X-API-Key header, which have no staff member behind them.
+	APIKeyID uint `json:"api_key_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// TwoFactorEnrollmentTokenTTL is the lifetime of the restricted token issued to staff who must enroll first.
+const TwoFactorEnrollmentTokenTTL = 10 * time.Minute
+
+// Authentication errors returned by AuthenticateStaff. Handlers map these to HTTP status codes.
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUnknownHospital    = errors.New("unknown hospital")
+	ErrAccountDisabled    = errors.New("account is disabled")
+
+	// ErrTwoFactorEnrollmentRequired is returned together with an enrollment-only token.
+	ErrTwoFactorEnrollmentRequired = errors.New("two-factor authentication is required; enroll before logging in")
+	ErrTwoFactorCodeRequired       = errors.New("two-factor code required")
+	ErrInvalidTwoFactorCode        = errors.New("invalid two-factor code")
+)
+
 // Package-level variables to store config loaded during initialization
 var (
-	jwtKey    []byte
-	jwtExpiry time.Duration
+	jwtKeys        map[string][]byte // By key ID
+	jwtKeyID       string            // Of the key new tokens are signed with
+	jwtExpiry      time.Duration
+	passwordPolicy config.PasswordPolicy
 )
 
-// InitializeAuthService sets up the JWT secret key and expiry duration.
-func InitializeAuthService(cfg *config.Config) {
-	jwtKey = []byte(cfg.JWTSecret)
+// ErrTokenSelfCheckFailed is returned when a freshly signed token does not validate.
+var ErrTokenSelfCheckFailed = errors.New("JWT self-check failed")
+
+// InitializeAuthService sets up the JWT signing keys and expiry duration, then checks that
+// tokens can be signed and validated so a bad configuration fails at startup rather than
+// on every login. An empty or default secret only logs a warning.
+func InitializeAuthService(cfg *config.Config) error {
+	secrets, keyID := cfg.JWTSigningKeys()
+	jwtKeys = make(map[string][]byte, len(secrets))
+	for id, secret := range secrets {
+		jwtKeys[id] = []byte(secret)
+	}
+	jwtKeyID = keyID
 	jwtExpiry = cfg.JWTExpiry // Store the expiry duration
+	passwordPolicy = cfg.PasswordPolicy
+	if cfg.JWTSecretInsecure() {
+		log.Println("WARNING: JWT_SECRET is empty or set to the default insecure value. Set a strong secret in your environment.")
+	}
+	if err := CheckTokenSigning(); err != nil {
+		return err
+	}
 	log.Printf("Auth service initialized with JWT expiry: %v", jwtExpiry)
+	return nil
+}
+
+// selfCheckUsername marks the throwaway tokens signed by CheckTokenSigning.
+const selfCheckUsername = "jwt-self-check"
+
+// CheckTokenSigning signs a throwaway token and validates it with the current settings.
+// It backs both the startup check and the /ready endpoint.
+func CheckTokenSigning() error {
+	issued, err := issueToken(&models.Staff{Username: selfCheckUsername}, false)
+	if err != nil {
+		return fmt.Errorf("%w: signing: %v", ErrTokenSelfCheckFailed, err)
+	}
+	claims, err := ValidateToken(issued.Token)
+	if err != nil {
+		return fmt.Errorf("%w: validating: %v", ErrTokenSelfCheckFailed, err)
+	}
+	if claims.Username != selfCheckUsername {
+		return fmt.Errorf("%w: claims did not round-trip", ErrTokenSelfCheckFailed)
+	}
+	return nil
 }
 
 // AuthenticateStaff checks staff credentials and generates a JWT token upon success.
-func AuthenticateStaff(loginReq models.StaffLoginRequest) (string, *models.Staff, error) {
-	// 1. Find the staff member by username
-	staff, err := database.FindStaffByUsername(loginReq.Username)
+// When the staff member's hospital requires two-factor authentication and they haven't enrolled yet,
+// it returns ErrTwoFactorEnrollmentRequired along with a token that only works for enrollment.
+func AuthenticateStaff(repo database.PatientRepository, configs *HospitalConfigCache, loginReq models.StaffLoginRequest) (IssuedToken, *models.Staff, error) {
+	// 1. Resolve the hospital before the user, so an unknown hospital is answered the same
+	// whether or not the username exists
+	inputHospitalID, err := repo.GetHospitalIDByName(loginReq.Hospital)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.Printf("Authentication failed: User not found - %s", loginReq.Username)
-			return "", nil, errors.New("invalid username or password")
+		if errors.Is(err, database.ErrHospitalNotFound) {
+			log.Printf("Authentication failed: Unknown hospital '%s' for user %s", loginReq.Hospital, loginReq.Username)
+			utils.CheckPasswordHashDummy(loginReq.Password) // Every failed login costs one bcrypt comparison
+			return IssuedToken{}, nil, ErrUnknownHospital
 		}
-		log.Printf("Database error during login for user %s: %v", loginReq.Username, err)
-		return "", nil, fmt.Errorf("database error during login: %w", err)
+		log.Printf("Error verifying hospital '%s' for user %s: %v", loginReq.Hospital, loginReq.Username, err)
+		return IssuedToken{}, nil, fmt.Errorf("error verifying hospital: %w", err) // Answered as a server error, not a failed login
 	}
 
-	// 2. Check if the provided hospital matches the staff's hospital
-	inputHospitalID, err := database.GetHospitalIDByName(loginReq.Hospital)
+	// 2. Find the staff member by username
+	staff, err := repo.FindStaffByUsername(loginReq.Username)
 	if err != nil {
-		log.Printf("Authentication failed: Hospital not found or mapping error for '%s' for user %s", loginReq.Hospital, loginReq.Username)
-		if errors.Is(err, gorm.ErrRecordNotFound) { // Assuming GetHospitalIDByName returns this for not found
-			return "", nil, errors.New("invalid hospital specified")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Authentication failed: User not found - %s", loginReq.Username)
+			utils.CheckPasswordHashDummy(loginReq.Password) // Take as long as a wrong password would
+			return IssuedToken{}, nil, ErrInvalidCredentials
 		}
-		return "", nil, errors.New("error verifying hospital") // Generic internal error
+		log.Printf("Database error during login for user %s: %v", loginReq.Username, err)
+		return IssuedToken{}, nil, fmt.Errorf("database error during login: %w", err)
 	}
 
+	// 3. Verify the password, then the hospital. A wrong hospital fails like a wrong password,
+	// so it doesn't confirm that the username exists somewhere else.
+	if !utils.CheckPasswordHash(loginReq.Password, staff.PasswordHash) {
+		log.Printf("Authentication failed: Invalid password for user %s", loginReq.Username)
+		return IssuedToken{}, nil, ErrInvalidCredentials // Keep error message generic
+	}
 	if staff.HospitalID != inputHospitalID {
 		log.Printf("Authentication failed: Hospital mismatch for user %s. Expected %d (%s), got %d (%s)",
 			loginReq.Username, staff.HospitalID, staff.HospitalName, inputHospitalID, loginReq.Hospital)
-		return "", nil, errors.New("invalid hospital for this user")
+		return IssuedToken{}, nil, ErrInvalidCredentials
+	}
+	// Checked only after the password so the response doesn't reveal which accounts exist
+	if !staff.IsActive {
+		log.Printf("Authentication failed: Account %s is disabled", loginReq.Username)
+		return IssuedToken{}, nil, ErrAccountDisabled
 	}
 
-	// 3. Verify the password
-	if !utils.CheckPasswordHash(loginReq.Password, staff.PasswordHash) {
-		log.Printf("Authentication failed: Invalid password for user %s", loginReq.Username)
-		return "", nil, errors.New("invalid username or password") // Keep error message generic
+	// 4. Second factor, when the hospital requires it or the staff member opted in
+	hospitalConfig, err := configs.Get(staff.HospitalID)
+	if err != nil {
+		log.Printf("Error loading hospital config %d for user %s: %v", staff.HospitalID, loginReq.Username, err)
+		return IssuedToken{}, nil, fmt.Errorf("error loading hospital settings: %w", err)
 	}
+	if hospitalConfig.TwoFactorRequired || staff.TOTPEnabled {
+		if !staff.TOTPEnabled {
+			log.Printf("Authentication incomplete: user %s must enroll in two-factor authentication", loginReq.Username)
+			issued, err := issueToken(staff, true)
+			if err != nil {
+				return IssuedToken{}, nil, err
+			}
+			staff.PasswordHash = ""
+			return issued, staff, ErrTwoFactorEnrollmentRequired
+		}
+		if loginReq.OTPCode == "" {
+			return IssuedToken{}, nil, ErrTwoFactorCodeRequired
+		}
+		if err := verifyTOTPLogin(repo, staff, loginReq.OTPCode); err != nil {
+			if errors.Is(err, ErrInvalidTwoFactorCode) {
+				log.Printf("Authentication failed: Invalid or reused two-factor code for user %s", loginReq.Username)
+			}
+			return IssuedToken{}, nil, err
+		}
+	}
+
+	// 5. Generate JWT Token
+	issued, err := issueToken(staff, false)
+	if err != nil {
+		return IssuedToken{}, nil, err
+	}
+
+	log.Printf("Authentication successful for user: %s (Hospital ID: %d)", staff.Username, staff.HospitalID)
+	loginAt := time.Now()
+	if err := repo.RecordStaffLogin(staff.ID, loginAt); err != nil {
+		log.Printf("Error recording login time for user %s: %v", staff.Username, err) // Not worth failing the login over
+	} else {
+		staff.LastLoginAt = &loginAt
+	}
+	staff.PasswordHash = "" // Don't return password hash
+	return issued, staff, nil
+}
+
+// IssuedToken is a signed JWT with the expiry from its "exp" claim.
+type IssuedToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
 
-	// 4. Generate JWT Token
+// issueToken signs a JWT for the staff member. Enrollment-only tokens are short-lived.
+func issueToken(staff *models.Staff, enrollmentOnly bool) (IssuedToken, error) {
 	// Use the jwtExpiry stored during InitializeAuthService
-	expirationTime := time.Now().Add(jwtExpiry)
+	expiry := jwtExpiry
+	if enrollmentOnly {
+		expiry = TwoFactorEnrollmentTokenTTL
+	}
+	expirationTime := time.Now().Add(expiry)
+	tokenID, err := newTokenID()
+	if err != nil {
+		log.Printf("Error generating token ID for user %s: %v", staff.Username, err)
+		return IssuedToken{}, fmt.Errorf("could not generate token: %w", err)
+	}
 	claims := &Claims{
-		UserID:     staff.ID,
-		Username:   staff.Username,
-		HospitalID: staff.HospitalID,
+		UserID:              staff.ID,
+		Username:            staff.Username,
+		HospitalID:          staff.HospitalID,
+		Role:                staff.Role,
+		TwoFactorEnrollment: enrollmentOnly,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID, // Lets the token be revoked on logout
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   fmt.Sprintf("%d", staff.ID), // Subject is typically the user ID
@@ -85,15 +221,54 @@ func AuthenticateStaff(loginReq models.StaffLoginRequest) (string, *models.Staff
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+	token.Header["kid"] = jwtKeyID // Tells ValidateToken which key to check the signature with
+	tokenString, err := token.SignedString(jwtKeys[jwtKeyID])
 	if err != nil {
-		log.Printf("Error generating JWT token for user %s: %v", loginReq.Username, err)
-		return "", nil, fmt.Errorf("could not generate token: %w", err)
+		log.Printf("Error generating JWT token for user %s: %v", staff.Username, err)
+		return IssuedToken{}, fmt.Errorf("could not generate token: %w", err)
 	}
+	// exp is stored in whole seconds, so report that rather than expirationTime
+	return IssuedToken{Token: tokenString, ExpiresAt: claims.ExpiresAt.Time}, nil
+}
 
-	log.Printf("Authentication successful for user: %s (Hospital ID: %d)", staff.Username, staff.HospitalID)
-	staff.PasswordHash = "" // Don't return password hash
-	return tokenString, staff, nil
+// newTokenID returns a random value for the token's "jti" claim.
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// CheckPasswordPolicy returns the configured password rules that the password violates.
+func CheckPasswordPolicy(password string) []string {
+	return utils.ValidatePassword(password, passwordPolicy)
+}
+
+// IsAdmin reports whether the token holder has admin privileges. Super admins are admins too.
+func (c *Claims) IsAdmin() bool {
+	return c.Role == models.RoleAdmin || c.IsSuperAdmin()
+}
+
+// IsSuperAdmin reports whether the token holder may act across hospitals.
+func (c *Claims) IsSuperAdmin() bool {
+	return c.Role == models.RoleSuperAdmin
+}
+
+// CanAdministerHospital reports whether the token holder has admin rights over the hospital.
+// Hospital admins administer their own hospital; super admins administer all of them.
+func (c *Claims) CanAdministerHospital(hospitalID uint) bool {
+	return c.IsSuperAdmin() || (c.IsAdmin() && c.HospitalID == hospitalID)
+}
+
+// CanSearchAllHospitals reports whether the token holder may widen patient search beyond their own hospital.
+func (c *Claims) CanSearchAllHospitals() bool {
+	return c.IsSuperAdmin()
+}
+
+// IsViewer reports whether the token holder only gets masked patient identifiers.
+func (c *Claims) IsViewer() bool {
+	return c.Role == models.RoleViewer
 }
 
 // ValidateToken parses and validates a JWT token string.
@@ -105,8 +280,16 @@ func ValidateToken(tokenStr string) (*Claims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		// Return the secret key stored during initialization
-		return jwtKey, nil
+		// Pick the configured key the token names. Tokens from before key IDs carry no kid.
+		keyID, _ := token.Header["kid"].(string)
+		if keyID == "" {
+			keyID = config.DefaultJWTKeyID
+		}
+		key, ok := jwtKeys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", keyID)
+		}
+		return key, nil
 	})
 
 	if err != nil {
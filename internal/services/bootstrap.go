@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// BootstrapAdmin creates the first admin account of a hospital for the seed command.
+// An existing account with the same username is left untouched, so the seed can be rerun safely.
+// It reports whether an account was created.
+func BootstrapAdmin(repo database.PatientRepository, policy config.PasswordPolicy, username, password, hospitalName string) (bool, error) {
+	if _, err := repo.FindStaffByUsername(username); err == nil {
+		return false, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("looking up staff %s: %w", username, err)
+	}
+
+	if violations := utils.ValidatePassword(password, policy); len(violations) > 0 {
+		return false, fmt.Errorf("admin password violates the password policy: %s", strings.Join(violations, "; "))
+	}
+
+	hospitalID, err := repo.GetHospitalIDByName(hospitalName)
+	if err != nil {
+		return false, fmt.Errorf("looking up hospital %q: %w", hospitalName, err)
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return false, fmt.Errorf("hashing admin password: %w", err)
+	}
+
+	admin := &models.Staff{
+		Username:     username,
+		PasswordHash: hashedPassword,
+		HospitalID:   hospitalID,
+		HospitalName: hospitalName,
+		Role:         models.RoleAdmin,
+	}
+	if err := repo.CreateStaff(admin); err != nil {
+		return false, fmt.Errorf("creating admin %s: %w", username, err)
+	}
+	return true, nil
+}
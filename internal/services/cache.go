@@ -0,0 +1,83 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a byte-value store with per-entry expiry, shared by the caching layers.
+// Implementations may drop entries early; callers treat a miss as "ask the database".
+type Cache interface {
+	// Get returns the value stored under key and whether it was found and unexpired.
+	Get(key string) ([]byte, bool, error)
+	// Set stores value under key for ttl. A ttl of zero keeps it until evicted.
+	Set(key string, value []byte, ttl time.Duration) error
+}
+
+// MemoryCacheMaxEntries bounds the in-memory search cache; the least recently used entries go first.
+const MemoryCacheMaxEntries = 1000
+
+// MemoryCache is an in-process LRU Cache.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Front is the most recently used
+	entries  map[string]*list.Element
+	now      func() time.Time
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // Zero for no expiry
+}
+
+// NewMemoryCache returns an LRU cache holding at most capacity entries.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+func (m *MemoryCache) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && !m.now().Before(entry.expiresAt) {
+		m.order.Remove(element)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (m *MemoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = m.now().Add(ttl)
+	}
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryCacheEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		m.order.MoveToFront(element)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{key: key, value: value, expiresAt: expiresAt})
+	for m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"expvar"
+	"hospital-middleware/internal/models"
+	"log"
+	"sync"
+	"time"
+)
+
+// EventPublisher sends patient change events to a message broker.
+type EventPublisher interface {
+	Publish(event models.PatientEvent) error
+	Close() error
+}
+
+// NoopEventPublisher discards every event. It is used when EVENT_BROKER_URL is not set.
+type NoopEventPublisher struct{}
+
+func (NoopEventPublisher) Publish(models.PatientEvent) error { return nil }
+func (NoopEventPublisher) Close() error                      { return nil }
+
+// NewEventPublisher returns the publisher for brokerURL: a NATS publisher for a nats:// URL,
+// or a NoopEventPublisher when brokerURL is empty.
+func NewEventPublisher(brokerURL string) (EventPublisher, error) {
+	if brokerURL == "" {
+		return NoopEventPublisher{}, nil
+	}
+	return NewNATSPublisher(brokerURL)
+}
+
+// EventPublishFailures counts patient events that could not be published. It is exported
+// through expvar as event_publish_failures.
+var EventPublishFailures = expvar.NewInt("event_publish_failures")
+
+var (
+	eventPublisherMu sync.RWMutex
+	eventPublisher   EventPublisher = NoopEventPublisher{}
+)
+
+// SetEventPublisher makes publisher receive every later PublishPatientEvent.
+func SetEventPublisher(publisher EventPublisher) {
+	eventPublisherMu.Lock()
+	defer eventPublisherMu.Unlock()
+	eventPublisher = publisher
+}
+
+// PublishPatientEvent sends event to the configured broker, stamping it with the current time.
+// A failure is logged and counted but never returned, so it cannot fail the request that caused it.
+func PublishPatientEvent(event models.PatientEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	eventPublisherMu.RLock()
+	publisher := eventPublisher
+	eventPublisherMu.RUnlock()
+	if err := publisher.Publish(event); err != nil {
+		eventPublishFailed(event.Event, err)
+	}
+}
+
+func eventPublishFailed(event string, err error) {
+	EventPublishFailures.Add(1)
+	log.Printf("Error publishing %s event: %v", event, err)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"hospital-middleware/internal/database"
+	"sync"
+	"time"
+)
+
+// HospitalExistenceTTL is how long a hospital lookup is trusted before it is repeated.
+const HospitalExistenceTTL = 30 * time.Second
+
+type cachedHospitalExistence struct {
+	exists    bool
+	expiresAt time.Time
+}
+
+// HospitalExistenceCache remembers which hospitals exist, so the auth middleware can check a
+// token's hospital on every request without hitting the database each time.
+type HospitalExistenceCache struct {
+	repo database.PatientRepository
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[uint]cachedHospitalExistence
+}
+
+// NewHospitalExistenceCache creates a cache that repeats lookups older than ttl.
+func NewHospitalExistenceCache(repo database.PatientRepository, ttl time.Duration) *HospitalExistenceCache {
+	return &HospitalExistenceCache{
+		repo:    repo,
+		ttl:     ttl,
+		entries: make(map[uint]cachedHospitalExistence),
+	}
+}
+
+// HospitalExists reports whether the hospital exists, looking it up when the cached answer is
+// missing or expired. Missing hospitals are cached too, so a stale token cannot hammer the database.
+func (c *HospitalExistenceCache) HospitalExists(hospitalID uint) (bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[hospitalID]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.exists, nil
+	}
+
+	exists := true
+	if _, err := c.repo.GetHospitalByID(hospitalID); err != nil {
+		if !errors.Is(err, database.ErrHospitalNotFound) {
+			return false, err
+		}
+		exists = false
+	}
+
+	c.mu.Lock()
+	c.entries[hospitalID] = cachedHospitalExistence{exists: exists, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return exists, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"sync"
+	"time"
+)
+
+// HospitalConfigTTL is how long a loaded hospital config is served from memory.
+const HospitalConfigTTL = time.Minute
+
+type cachedHospitalConfig struct {
+	config    models.HospitalConfig
+	expiresAt time.Time
+}
+
+// HospitalConfigCache keeps recently loaded hospital configs in memory so hot paths
+// like login and patient search don't hit the database on every request.
+type HospitalConfigCache struct {
+	repo database.PatientRepository
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[uint]cachedHospitalConfig
+}
+
+// NewHospitalConfigCache creates a cache that reloads entries older than ttl.
+func NewHospitalConfigCache(repo database.PatientRepository, ttl time.Duration) *HospitalConfigCache {
+	return &HospitalConfigCache{
+		repo:    repo,
+		ttl:     ttl,
+		entries: make(map[uint]cachedHospitalConfig),
+	}
+}
+
+// Get returns the hospital's config, loading it from the repository when missing or expired.
+func (c *HospitalConfigCache) Get(hospitalID uint) (models.HospitalConfig, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[hospitalID]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.config, nil
+	}
+
+	cfg, err := c.repo.GetHospitalConfig(hospitalID)
+	if err != nil {
+		return models.HospitalConfig{}, err
+	}
+
+	c.mu.Lock()
+	c.entries[hospitalID] = cachedHospitalConfig{config: *cfg, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return *cfg, nil
+}
+
+// Invalidate drops the cached config so the next Get reloads it.
+func (c *HospitalConfigCache) Invalidate(hospitalID uint) {
+	c.mu.Lock()
+	delete(c.entries, hospitalID)
+	c.mu.Unlock()
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATS publisher settings. Events wait in a queue of NATSQueueSize while the connection is down;
+// after a failed connect, events fail at once until NATSReconnectWait has passed.
+const (
+	NATSDefaultPort   = "4222"
+	NATSDialTimeout   = 2 * time.Second
+	NATSWriteTimeout  = 2 * time.Second
+	NATSReconnectWait = 5 * time.Second
+	NATSQueueSize     = 1024
+)
+
+var (
+	errEventQueueFull       = errors.New("event queue is full")
+	errEventPublisherClosed = errors.New("event publisher is closed")
+	errBrokerUnavailable    = errors.New("event broker unavailable; waiting to reconnect")
+)
+
+// natsMessage is one queued publish.
+type natsMessage struct {
+	subject string
+	data    []byte
+}
+
+// NATSPublisher publishes events to a NATS server as core NATS messages, speaking the text
+// protocol over plain TCP. It connects on the first event and reconnects after a failure.
+// Events are sent in order by a single background worker, so Publish never waits on the network.
+type NATSPublisher struct {
+	addr              string
+	user, pass, token string
+	queue             chan natsMessage
+	done              chan struct{}
+	closeMu           sync.RWMutex
+	closed            bool
+
+	mu      sync.Mutex // Guards the connection, written by the worker and by PING replies
+	conn    net.Conn
+	writer  *bufio.Writer
+	retryAt time.Time
+}
+
+// NewNATSPublisher returns a publisher for a nats://[user:pass@]host[:port] URL. A user without
+// a password is sent as an auth token. No connection is made until the first event.
+func NewNATSPublisher(brokerURL string) (*NATSPublisher, error) {
+	u, err := url.Parse(brokerURL)
+	if err != nil || u.Scheme != "nats" || u.Hostname() == "" {
+		return nil, fmt.Errorf("EVENT_BROKER_URL must be a nats://host:port URL, got %q", brokerURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = NATSDefaultPort
+	}
+
+	p := &NATSPublisher{
+		addr:  net.JoinHostPort(u.Hostname(), port),
+		queue: make(chan natsMessage, NATSQueueSize),
+		done:  make(chan struct{}),
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			p.user, p.pass = u.User.Username(), pass
+		} else {
+			p.token = u.User.Username()
+		}
+	}
+	go p.run()
+	return p, nil
+}
+
+// Publish queues the event under its event name as subject. It fails only when the queue is full
+// or the publisher is closed; delivery failures are counted by the worker.
+func (p *NATSPublisher) Publish(event models.PatientEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+	if p.closed {
+		return errEventPublisherClosed
+	}
+	select {
+	case p.queue <- natsMessage{subject: event.Event, data: data}:
+		return nil
+	default:
+		return errEventQueueFull
+	}
+}
+
+// Close sends the queued events and disconnects.
+func (p *NATSPublisher) Close() error {
+	p.closeMu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.closeMu.Unlock()
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn != nil {
+		p.disconnect()
+	}
+	return nil
+}
+
+func (p *NATSPublisher) run() {
+	defer close(p.done)
+	for msg := range p.queue {
+		if err := p.send(msg); err != nil {
+			eventPublishFailed(msg.subject, err)
+		}
+	}
+}
+
+// send writes one PUB, connecting first if needed. A write error drops the connection so the
+// next event reconnects.
+func (p *NATSPublisher) send(msg natsMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		if time.Now().Before(p.retryAt) {
+			return errBrokerUnavailable
+		}
+		if err := p.connect(); err != nil {
+			p.retryAt = time.Now().Add(NATSReconnectWait)
+			return fmt.Errorf("connecting to %s: %w", p.addr, err)
+		}
+	}
+
+	p.conn.SetWriteDeadline(time.Now().Add(NATSWriteTimeout))
+	fmt.Fprintf(p.writer, "PUB %s %d\r\n", msg.subject, len(msg.data))
+	p.writer.Write(msg.data)
+	p.writer.WriteString("\r\n")
+	if err := p.writer.Flush(); err != nil {
+		p.disconnect()
+		return err
+	}
+	return nil
+}
+
+// connect dials the server and completes the handshake: INFO from the server, then CONNECT and a
+// PING the server must answer with PONG. Callers hold p.mu.
+func (p *NATSPublisher) connect() error {
+	conn, err := net.DialTimeout("tcp", p.addr, NATSDialTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(NATSDialTimeout))
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	info, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		conn.Close()
+		return fmt.Errorf("expected INFO from server, got %q", strings.TrimSpace(line))
+	}
+	var serverInfo struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if err := json.Unmarshal([]byte(info), &serverInfo); err == nil && serverInfo.TLSRequired {
+		conn.Close()
+		return errors.New("server requires TLS, which is not supported")
+	}
+
+	options, _ := json.Marshal(map[string]interface{}{
+		"verbose":    false,
+		"pedantic":   false,
+		"name":       "hospital-middleware",
+		"lang":       "go",
+		"user":       p.user,
+		"pass":       p.pass,
+		"auth_token": p.token,
+	})
+	fmt.Fprintf(writer, "CONNECT %s\r\nPING\r\n", options)
+	if err := writer.Flush(); err != nil {
+		conn.Close()
+		return err
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return fmt.Errorf("server rejected connection: %s", line)
+		}
+	}
+	conn.SetDeadline(time.Time{})
+
+	p.conn, p.writer = conn, writer
+	go p.readLoop(conn, reader)
+	return nil
+}
+
+// readLoop answers the server's keep-alive PINGs and logs its errors until the connection ends.
+func (p *NATSPublisher) readLoop(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PING":
+			p.mu.Lock()
+			if p.conn == conn {
+				conn.SetWriteDeadline(time.Now().Add(NATSWriteTimeout))
+				p.writer.WriteString("PONG\r\n")
+				p.writer.Flush()
+			}
+			p.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("Event broker %s reported an error: %s", p.addr, line)
+		}
+	}
+
+	p.mu.Lock()
+	if p.conn == conn {
+		p.disconnect()
+	}
+	p.mu.Unlock()
+}
+
+// disconnect drops the current connection. Callers hold p.mu.
+func (p *NATSPublisher) disconnect() {
+	p.conn.Close()
+	p.conn, p.writer = nil, nil
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Errors of history imports. Handlers map ErrHistoryImportNotText to 415, ErrHistoryChangeStale
+// to 409 and the others to 400.
+var (
+	ErrHistoryImportNotText        = errors.New("history import must be UTF-8 text")
+	ErrHistoryChangeNotApplicable  = errors.New("field cannot be changed by a history import")
+	ErrHistoryChangeStale          = errors.New("patient record changed since the diff was made")
+	ErrHistoryChangeInvalidValue   = errors.New("imported value is not valid")
+	ErrHistoryChangeDuplicateField = errors.New("field is listed more than once")
+)
+
+// historyField is a patient field a history import reads: the line labels it is written under
+// and how its values are normalized for comparison. normalize returns false for a value that
+// cannot be understood.
+type historyField struct {
+	name      string
+	label     *regexp.Regexp
+	normalize func(value string) (string, bool)
+	// Whether a mismatch may be applied. A different HN means the file is for another patient.
+	applicable bool
+}
+
+// historyLabel matches a line starting with one of the labels, in any case, followed by a colon.
+// The value is the rest of the line.
+func historyLabel(labels ...string) *regexp.Regexp {
+	quoted := make([]string, len(labels))
+	for i, label := range labels {
+		quoted[i] = regexp.QuoteMeta(label)
+	}
+	return regexp.MustCompile(`(?i)^\s*(?:` + strings.Join(quoted, "|") + `)\s*[:：]\s*(.*?)\s*$`)
+}
+
+// historyFields are the fields read from a history import, in the order diffs list them.
+// Labels are those of the common Thai hospital exports.
+var historyFields = []historyField{
+	{models.HistoryFieldPatientHN, historyLabel("HN"), normalizeHistoryText, false},
+	{models.HistoryFieldFirstNameTH, historyLabel("ชื่อ"), normalizeHistoryThai, true},
+	{models.HistoryFieldLastNameTH, historyLabel("นามสกุล"), normalizeHistoryThai, true},
+	{models.HistoryFieldFirstNameEN, historyLabel("First name", "Firstname"), normalizeHistoryText, true},
+	{models.HistoryFieldLastNameEN, historyLabel("Last name", "Lastname", "Surname"), normalizeHistoryText, true},
+	{models.HistoryFieldDateOfBirth, historyLabel("DOB", "Date of birth", "วันเกิด", "วันเดือนปีเกิด"), normalizeHistoryDate, true},
+	{models.HistoryFieldNationalID, historyLabel("National ID", "เลขบัตรประชาชน", "เลขประจำตัวประชาชน"), normalizeHistoryDigits, true},
+	{models.HistoryFieldPhoneNumber, historyLabel("Phone", "Tel", "โทร", "โทรศัพท์"), normalizeHistoryDigits, true},
+	{models.HistoryFieldBloodType, historyLabel("Blood type", "Blood group", "หมู่เลือด", "กรุ๊ปเลือด"), normalizeHistoryBloodType, true},
+}
+
+// ParsePatientHistory reads the labelled lines of a legacy plain-text history export, such as
+// "HN: 000123" or "นามสกุล: ใจดี", and returns the value of each history field found, as written.
+// When a field appears on several lines the first one counts. Other lines are ignored.
+func ParsePatientHistory(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Byte order mark, as Windows editors write it
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return nil, ErrHistoryImportNotText
+	}
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), models.HistoryImportMaxBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, field := range historyFields {
+			if _, seen := values[field.name]; seen {
+				continue
+			}
+			if match := field.label.FindStringSubmatch(line); match != nil && match[1] != "" {
+				values[field.name] = match[1]
+				break
+			}
+		}
+	}
+	return values, scanner.Err()
+}
+
+// DiffPatientHistory compares the values read by ParsePatientHistory with the patient record.
+// Values are normalized before comparing: Thai names to NFC, English names ignoring case, dates
+// (Buddhist or Gregorian years) to YYYY-MM-DD and identifiers to their digits. Dates of birth
+// are read in loc.
+func DiffPatientHistory(patient *models.Patient, values map[string]string, loc *time.Location) models.PatientHistoryDiff {
+	diff := models.PatientHistoryDiff{
+		PatientID:  patient.ID,
+		Matched:    []models.PatientHistoryValue{},
+		Mismatched: []models.PatientHistoryChange{},
+		NotFound:   []string{},
+		Unreadable: []models.PatientHistoryValue{},
+	}
+	for _, field := range historyFields {
+		raw, found := values[field.name]
+		if !found {
+			diff.NotFound = append(diff.NotFound, field.name)
+			continue
+		}
+		imported, ok := field.normalize(raw)
+		if !ok {
+			diff.Unreadable = append(diff.Unreadable, models.PatientHistoryValue{Field: field.name, Value: raw})
+			continue
+		}
+		current := historyCurrentValue(patient, field.name, loc)
+		if historyValuesEqual(field.name, current, imported) {
+			diff.Matched = append(diff.Matched, models.PatientHistoryValue{Field: field.name, Value: current})
+		} else {
+			diff.Mismatched = append(diff.Mismatched, models.PatientHistoryChange{Field: field.name, Current: current, Imported: imported})
+		}
+	}
+	return diff
+}
+
+// PatientHistoryUpdates turns confirmed changes into the column updates for UpdatePatientFields.
+// A change is refused when its field cannot be applied, its value is invalid, or the record no
+// longer has the value the diff showed as current; the error wraps one of the ErrHistoryChange
+// errors and names the field.
+func PatientHistoryUpdates(patient *models.Patient, changes []models.PatientHistoryChange, loc *time.Location) (map[string]interface{}, error) {
+	updates := make(map[string]interface{}, len(changes))
+	for _, change := range changes {
+		field, ok := historyFieldByName(change.Field)
+		if !ok || !field.applicable {
+			return nil, fmt.Errorf("%s: %w", change.Field, ErrHistoryChangeNotApplicable)
+		}
+		if _, dup := updates[field.name]; dup {
+			return nil, fmt.Errorf("%s: %w", field.name, ErrHistoryChangeDuplicateField)
+		}
+		imported, ok := field.normalize(change.Imported)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", field.name, ErrHistoryChangeInvalidValue)
+		}
+		if !historyValuesEqual(field.name, historyCurrentValue(patient, field.name, loc), change.Current) {
+			return nil, fmt.Errorf("%s: %w", field.name, ErrHistoryChangeStale)
+		}
+
+		switch field.name {
+		case models.HistoryFieldDateOfBirth:
+			dateOfBirth, _ := time.ParseInLocation("2006-01-02", imported, loc) // Normalized, so it parses
+			updates[field.name] = dateOfBirth
+		case models.HistoryFieldBloodType:
+			updates[field.name] = &imported
+		case models.HistoryFieldFirstNameTH, models.HistoryFieldLastNameTH:
+			// Updates with a map skip BeforeSave, so the folded form is kept in step here
+			updates[field.name] = imported
+			updates[field.name+"_folded"] = utils.FoldThai(imported)
+		default:
+			updates[field.name] = imported
+		}
+	}
+	return updates, nil
+}
+
+// historyFieldByName returns the history field of the given name.
+func historyFieldByName(name string) (historyField, bool) {
+	for _, field := range historyFields {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return historyField{}, false
+}
+
+// historyCurrentValue returns the patient's value of a history field as a diff shows it.
+func historyCurrentValue(patient *models.Patient, field string, loc *time.Location) string {
+	switch field {
+	case models.HistoryFieldPatientHN:
+		return patient.PatientHN
+	case models.HistoryFieldFirstNameTH:
+		return patient.FirstNameTH
+	case models.HistoryFieldLastNameTH:
+		return patient.LastNameTH
+	case models.HistoryFieldFirstNameEN:
+		return patient.FirstNameEN
+	case models.HistoryFieldLastNameEN:
+		return patient.LastNameEN
+	case models.HistoryFieldDateOfBirth:
+		if patient.DateOfBirth == nil {
+			return ""
+		}
+		return patient.DateOfBirth.In(loc).Format("2006-01-02")
+	case models.HistoryFieldNationalID:
+		return patient.NationalID
+	case models.HistoryFieldPhoneNumber:
+		return patient.PhoneNumber
+	case models.HistoryFieldBloodType:
+		if patient.BloodType == nil {
+			return ""
+		}
+		return *patient.BloodType
+	}
+	return ""
+}
+
+// historyValuesEqual compares two values of a field. English names ignore case; everything else
+// must match exactly once normalized.
+func historyValuesEqual(field, a, b string) bool {
+	switch field {
+	case models.HistoryFieldFirstNameEN, models.HistoryFieldLastNameEN:
+		return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	case models.HistoryFieldFirstNameTH, models.HistoryFieldLastNameTH:
+		return utils.NormalizeThai(strings.TrimSpace(a)) == utils.NormalizeThai(strings.TrimSpace(b))
+	}
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
+}
+
+func normalizeHistoryText(value string) (string, bool) {
+	value = strings.Join(strings.Fields(value), " ")
+	return value, value != ""
+}
+
+func normalizeHistoryThai(value string) (string, bool) {
+	value, ok := normalizeHistoryText(value)
+	return utils.NormalizeThai(value), ok
+}
+
+// normalizeHistoryDigits drops the spaces and dashes identifiers are often written with, as in
+// "1-2345-67890-12-3" or "081-234 5678".
+func normalizeHistoryDigits(value string) (string, bool) {
+	value = strings.NewReplacer(" ", "", "-", "").Replace(value)
+	if value == "" {
+		return "", false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return value, true
+}
+
+// historyBloodTypes are the blood types a patient record accepts, other than unknown.
+var historyBloodTypes = map[string]bool{"A+": true, "A-": true, "B+": true, "B-": true, "AB+": true, "AB-": true, "O+": true, "O-": true}
+
+func normalizeHistoryBloodType(value string) (string, bool) {
+	value = strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+	return value, historyBloodTypes[value]
+}
+
+// historyDatePattern matches D/M/Y dates with slashes, dashes or dots, the way Thai hospitals
+// write them. Years past 2400 are Buddhist Era years.
+var historyDatePattern = regexp.MustCompile(`^(\d{1,2})[/.-](\d{1,2})[/.-](\d{4})$`)
+
+// buddhistEraOffset is how many years the Buddhist Era runs ahead of the Gregorian calendar.
+const buddhistEraOffset = 543
+
+// normalizeHistoryDate reads a date written as YYYY-MM-DD or D/M/YYYY, in either calendar, and
+// returns it as a Gregorian YYYY-MM-DD.
+func normalizeHistoryDate(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	var year, month, day int
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		year, month, day = t.Year(), int(t.Month()), t.Day()
+	} else if match := historyDatePattern.FindStringSubmatch(value); match != nil {
+		day, _ = strconv.Atoi(match[1])
+		month, _ = strconv.Atoi(match[2])
+		year, _ = strconv.Atoi(match[3])
+	} else {
+		return "", false
+	}
+	if year > 2400 {
+		year -= buddhistEraOffset
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day || int(date.Month()) != month { // Rejects dates such as 31/02
+		return "", false
+	}
+	return date.Format("2006-01-02"), true
+}
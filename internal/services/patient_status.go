@@ -0,0 +1,34 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+)
+
+// ErrInvalidPatientStatusTransition is returned for a status change the lifecycle does not allow.
+var ErrInvalidPatientStatusTransition = errors.New("invalid patient status transition")
+
+// patientStatusTransitions lists the statuses each status may move to. An active patient can
+// become inactive, deceased or transferred; inactive and transferred patients can come back.
+// Deceased is final.
+var patientStatusTransitions = map[string][]string{
+	models.PatientStatusActive:      {models.PatientStatusInactive, models.PatientStatusDeceased, models.PatientStatusTransferred},
+	models.PatientStatusInactive:    {models.PatientStatusActive, models.PatientStatusDeceased},
+	models.PatientStatusTransferred: {models.PatientStatusActive},
+	models.PatientStatusDeceased:    {},
+}
+
+// ValidatePatientStatusTransition reports whether a patient may move from one status to another.
+// Patients saved before statuses existed have an empty status and count as active.
+func ValidatePatientStatusTransition(from, to string) error {
+	if from == "" {
+		from = models.PatientStatusActive
+	}
+	for _, allowed := range patientStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", ErrInvalidPatientStatusTransition, from, to)
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Patient summary limits. Longer lists end with a line counting the rest, so the summary stays
+// on a single printed page.
+const (
+	SummaryMaxVisits    = 10
+	SummaryMaxAllergies = 12
+)
+
+// summaryThaiFont is the family name the configured Thai font is registered under.
+const summaryThaiFont = "thai"
+
+// PatientSummary is what a patient summary PDF shows. Dates are printed in Location.
+type PatientSummary struct {
+	Patient     models.Patient
+	Allergies   []models.Allergy
+	Visits      []models.Visit // Most recent first
+	TotalVisits int64
+	GeneratedAt time.Time
+	GeneratedBy string
+	Location    *time.Location
+}
+
+// SummaryRenderer renders one-page patient summary PDFs. Thai text is set in the TrueType font
+// it was created with, which is embedded in every PDF; everything else uses Helvetica.
+type SummaryRenderer struct {
+	font []byte
+}
+
+// NewSummaryRenderer loads the TrueType font at fontPath and checks that it can be embedded.
+func NewSummaryRenderer(fontPath string) (*SummaryRenderer, error) {
+	font, err := os.ReadFile(fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading summary font: %w", err)
+	}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(summaryThaiFont, "", font)
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("loading summary font %s: %w", fontPath, err)
+	}
+	return &SummaryRenderer{font: font}, nil
+}
+
+// Render renders the summary as a PDF. It gives up with ctx's error once ctx is done; the
+// rendering itself cannot be interrupted and is left to finish in the background.
+func (r *SummaryRenderer) Render(ctx context.Context, summary PatientSummary) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		pdf []byte
+		err error
+	}
+	done := make(chan result, 1) // Buffered so an abandoned render does not block forever
+	go func() {
+		pdf, err := r.render(summary)
+		done <- result{pdf, err}
+	}()
+	select {
+	case res := <-done:
+		return res.pdf, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *SummaryRenderer) render(summary PatientSummary) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(summary.GeneratedAt)
+	pdf.SetModificationDate(summary.GeneratedAt)
+	pdf.SetTitle("Patient summary "+summary.Patient.PatientHN, true)
+	pdf.AddUTF8FontFromBytes(summaryThaiFont, "", r.font)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+	w := &summaryWriter{pdf: pdf, latin: pdf.UnicodeTranslatorFromDescriptor("")}
+	patient := summary.Patient
+	loc := summary.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	w.line(16, "B", "Patient summary")
+	w.line(9, "", fmt.Sprintf("Generated %s by %s", summary.GeneratedAt.In(loc).Format("2006-01-02 15:04"), summary.GeneratedBy))
+	pdf.Ln(4)
+
+	w.heading("Demographics")
+	w.field("HN", patient.PatientHN)
+	w.field("Name (Thai)", joinName(patient.FirstNameTH, patient.MiddleNameTH, patient.LastNameTH))
+	w.field("Name (English)", joinName(patient.FirstNameEN, patient.MiddleNameEN, patient.LastNameEN))
+	if patient.DateOfBirth != nil {
+		w.field("Date of birth", patient.DateOfBirth.In(loc).Format("2006-01-02"))
+	}
+	w.field("Gender", patient.Gender)
+	w.field("National ID", patient.NationalID)
+	w.field("Passport ID", patient.PassportID)
+	w.field("Phone", patient.PhoneNumber)
+	w.field("Email", patient.Email)
+	w.field("Blood type", stringValue(patient.BloodType))
+	w.field("Status", patient.Status)
+	pdf.Ln(3)
+
+	w.heading("Allergies")
+	if len(summary.Allergies) == 0 {
+		w.line(10, "", "No known allergies recorded")
+	}
+	for i, allergy := range summary.Allergies {
+		if i == SummaryMaxAllergies {
+			w.line(10, "", fmt.Sprintf("and %d more", len(summary.Allergies)-SummaryMaxAllergies))
+			break
+		}
+		text := fmt.Sprintf("%s (%s)", allergy.Substance, allergy.Severity)
+		if allergy.Reaction != "" {
+			text += ": " + allergy.Reaction
+		}
+		w.line(10, "", text)
+	}
+	pdf.Ln(3)
+
+	w.heading("Recent visits")
+	if len(summary.Visits) == 0 {
+		w.line(10, "", "No visits recorded")
+	}
+	for i, visit := range summary.Visits {
+		if i == SummaryMaxVisits {
+			break
+		}
+		text := fmt.Sprintf("%s  %s", visit.AdmittedAt.In(loc).Format("2006-01-02"), visit.VisitNumber)
+		if visit.Department != "" {
+			text += "  " + visit.Department
+		}
+		if visit.DischargedAt != nil {
+			text += "  (discharged " + visit.DischargedAt.In(loc).Format("2006-01-02") + ")"
+		}
+		w.line(10, "", text)
+	}
+	if shown := int64(min(len(summary.Visits), SummaryMaxVisits)); summary.TotalVisits > shown {
+		w.line(10, "", fmt.Sprintf("and %d earlier", summary.TotalVisits-shown))
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// summaryWriter writes lines of mixed Thai and Latin text, switching to the Thai font for Thai
+// runs since Helvetica has no Thai glyphs.
+type summaryWriter struct {
+	pdf   *gofpdf.Fpdf
+	latin func(string) string // UTF-8 to the code page of the core fonts
+}
+
+func (w *summaryWriter) heading(text string) {
+	w.line(12, "B", text)
+	w.pdf.Ln(1)
+}
+
+// field writes a "Label: value" line, skipping empty values.
+func (w *summaryWriter) field(label, value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	w.pdf.SetFont("Helvetica", "B", 10)
+	w.pdf.Write(5.5, w.latin(label+": "))
+	w.write(10, "", value)
+	w.pdf.Ln(5.5)
+}
+
+func (w *summaryWriter) line(size float64, style, text string) {
+	w.write(size, style, text)
+	w.pdf.Ln(size * 0.55)
+}
+
+func (w *summaryWriter) write(size float64, style, text string) {
+	height := size * 0.55
+	for _, run := range splitThaiRuns(text) {
+		if run.thai {
+			w.pdf.SetFont(summaryThaiFont, "", size)
+			w.pdf.Write(height, run.text)
+		} else {
+			w.pdf.SetFont("Helvetica", style, size)
+			w.pdf.Write(height, w.latin(run.text))
+		}
+	}
+}
+
+// textRun is a stretch of text that is all Thai or all not.
+type textRun struct {
+	text string
+	thai bool
+}
+
+// splitThaiRuns splits text where it changes between Thai and other characters. Spaces stay with
+// the run before them.
+func splitThaiRuns(text string) []textRun {
+	var runs []textRun
+	for _, r := range text {
+		thai := r >= 0x0E00 && r <= 0x0E7F
+		if r == ' ' && len(runs) > 0 {
+			thai = runs[len(runs)-1].thai
+		}
+		if len(runs) == 0 || runs[len(runs)-1].thai != thai {
+			runs = append(runs, textRun{thai: thai})
+		}
+		runs[len(runs)-1].text += string(r)
+	}
+	return runs
+}
+
+func joinName(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
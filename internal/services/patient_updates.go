@@ -0,0 +1,72 @@
+package services
+
+import (
+	"hospital-middleware/internal/models"
+	"sync"
+)
+
+// patientUpdateBuffer is how many updates a subscriber can fall behind before further ones are dropped.
+const patientUpdateBuffer = 32
+
+// patientUpdateHub fans a hospital's patient updates out to its subscribers.
+type patientUpdateHub struct {
+	mu          sync.Mutex
+	subscribers map[chan models.PatientUpdate]struct{}
+}
+
+// patientUpdateHubs holds a *patientUpdateHub per hospital ID, created on first subscription.
+var patientUpdateHubs sync.Map
+
+// SubscribePatientUpdates returns a channel receiving the hospital's patient updates and a function
+// that ends the subscription. The channel is closed when the subscription ends or on shutdown.
+func SubscribePatientUpdates(hospitalID uint) (<-chan models.PatientUpdate, func()) {
+	value, _ := patientUpdateHubs.LoadOrStore(hospitalID, &patientUpdateHub{subscribers: make(map[chan models.PatientUpdate]struct{})})
+	hub := value.(*patientUpdateHub)
+
+	ch := make(chan models.PatientUpdate, patientUpdateBuffer)
+	hub.mu.Lock()
+	hub.subscribers[ch] = struct{}{}
+	hub.mu.Unlock()
+
+	unsubscribe := func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		if _, ok := hub.subscribers[ch]; ok {
+			delete(hub.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// PublishPatientUpdate sends an update to every subscriber of its hospital. It never blocks:
+// a subscriber whose buffer is full misses the update.
+func PublishPatientUpdate(update models.PatientUpdate) {
+	value, ok := patientUpdateHubs.Load(update.HospitalID)
+	if !ok {
+		return
+	}
+	hub := value.(*patientUpdateHub)
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for ch := range hub.subscribers {
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
+
+// ClosePatientUpdates ends every subscription, so open update streams finish and the server can shut down.
+func ClosePatientUpdates() {
+	patientUpdateHubs.Range(func(_, value interface{}) bool {
+		hub := value.(*patientUpdateHub)
+		hub.mu.Lock()
+		for ch := range hub.subscribers {
+			delete(hub.subscribers, ch)
+			close(ch)
+		}
+		hub.mu.Unlock()
+		return true
+	})
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redis cache settings. Commands run with RedisTimeout; after a failed connect, commands fail at
+// once until RedisReconnectWait has passed, so a Redis outage does not slow every search.
+const (
+	RedisDefaultPort   = "6379"
+	RedisTimeout       = time.Second
+	RedisReconnectWait = 5 * time.Second
+)
+
+var errRedisUnavailable = errors.New("redis unavailable; waiting to reconnect")
+
+// RedisCache is a Cache stored in Redis, speaking RESP over one plain TCP connection.
+// It connects on first use and reconnects after a failure.
+type RedisCache struct {
+	addr               string
+	user, pass, dbName string
+
+	mu      sync.Mutex // One command at a time on the connection
+	conn    net.Conn
+	reader  *bufio.Reader
+	retryAt time.Time
+}
+
+// NewRedisCache returns a cache for a redis://[[user]:password@]host[:port][/db] URL.
+// No connection is made until the first command.
+func NewRedisCache(redisURL string) (*RedisCache, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil || u.Scheme != "redis" || u.Hostname() == "" {
+		return nil, fmt.Errorf("REDIS_URL must be a redis://host:port URL, got %q", redisURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = RedisDefaultPort
+	}
+	r := &RedisCache{
+		addr:   net.JoinHostPort(u.Hostname(), port),
+		dbName: strings.Trim(u.Path, "/"),
+	}
+	if u.User != nil {
+		r.user = u.User.Username()
+		r.pass, _ = u.User.Password()
+	}
+	return r, nil
+}
+
+func (r *RedisCache) Get(key string) ([]byte, bool, error) {
+	value, err := r.do("GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if value == nil {
+		return nil, false, nil
+	}
+	return value, true, nil
+}
+
+func (r *RedisCache) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(args...)
+	return err
+}
+
+// Close drops the connection.
+func (r *RedisCache) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn != nil {
+		r.disconnect()
+	}
+	return nil
+}
+
+// do runs one command and returns its reply: the bytes of a bulk or simple string, or nil for a
+// null bulk string. Any I/O error drops the connection so the next command reconnects.
+func (r *RedisCache) do(args ...string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		if time.Now().Before(r.retryAt) {
+			return nil, errRedisUnavailable
+		}
+		if err := r.connect(); err != nil {
+			r.retryAt = time.Now().Add(RedisReconnectWait)
+			return nil, fmt.Errorf("connecting to redis at %s: %w", r.addr, err)
+		}
+	}
+	reply, err := r.command(args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		r.disconnect()
+	}
+	return reply, err
+}
+
+// connect dials the server, authenticates and selects the database. Callers hold r.mu.
+func (r *RedisCache) connect() error {
+	conn, err := net.DialTimeout("tcp", r.addr, RedisTimeout)
+	if err != nil {
+		return err
+	}
+	r.conn, r.reader = conn, bufio.NewReader(conn)
+	if r.pass != "" {
+		args := []string{"AUTH", r.pass}
+		if r.user != "" {
+			args = []string{"AUTH", r.user, r.pass}
+		}
+		if _, err := r.command(args...); err != nil {
+			r.disconnect()
+			return err
+		}
+	}
+	if r.dbName != "" && r.dbName != "0" {
+		if _, err := r.command("SELECT", r.dbName); err != nil {
+			r.disconnect()
+			return err
+		}
+	}
+	return nil
+}
+
+// command writes args as a RESP array and reads the reply. Callers hold r.mu.
+func (r *RedisCache) command(args ...string) ([]byte, error) {
+	r.conn.SetDeadline(time.Now().Add(RedisTimeout))
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(r.conn, b.String()); err != nil {
+		return nil, err
+	}
+
+	line, err := r.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply from redis")
+	}
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length %q from redis", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2) // Value and its trailing CRLF
+		if _, err := io.ReadFull(r.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	default:
+		return nil, fmt.Errorf("unexpected reply %q from redis", line)
+	}
+}
+
+// disconnect drops the current connection. Callers hold r.mu.
+func (r *RedisCache) disconnect() {
+	r.conn.Close()
+	r.conn, r.reader = nil, nil
+}
+
+// redisError is an error reply from the server; the connection stays usable after one.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// SearchCache caches patient search results per hospital for a short TTL, for dashboards that
+// poll the same search. Each hospital has a generation token, stored in the cache so every
+// instance sharing it sees the same one; entries are keyed under the current generation, and
+// Invalidate replaces the token, orphaning every cached search of the hospital at once.
+type SearchCache struct {
+	cache Cache
+	ttl   time.Duration
+}
+
+// NewSearchCache returns a search cache storing results in cache for ttl.
+func NewSearchCache(cache Cache, ttl time.Duration) *SearchCache {
+	return &SearchCache{cache: cache, ttl: ttl}
+}
+
+// generationCounter keeps tokens made in the same nanosecond apart.
+var generationCounter atomic.Uint64
+
+// Get returns the cached results of the search, if any. limit is part of the key because
+// hospitals' result limits can change. Cache errors are logged and reported as a miss.
+func (s *SearchCache) Get(hospitalID uint, query *models.PatientSearchQuery, limit int) ([]models.Patient, bool) {
+	key, err := s.key(hospitalID, query, limit)
+	if err != nil {
+		log.Printf("Error reading search cache of hospital %d: %v", hospitalID, err)
+		return nil, false
+	}
+	value, found, err := s.cache.Get(key)
+	if err != nil {
+		log.Printf("Error reading search cache of hospital %d: %v", hospitalID, err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	var patients []models.Patient
+	if err := json.Unmarshal(value, &patients); err != nil {
+		log.Printf("Error decoding cached search of hospital %d: %v", hospitalID, err)
+		return nil, false
+	}
+	return patients, true
+}
+
+// Set caches the results of the search.
+func (s *SearchCache) Set(hospitalID uint, query *models.PatientSearchQuery, limit int, patients []models.Patient) {
+	key, err := s.key(hospitalID, query, limit)
+	if err == nil {
+		var value []byte
+		if value, err = json.Marshal(patients); err == nil {
+			err = s.cache.Set(key, value, s.ttl)
+		}
+	}
+	if err != nil {
+		log.Printf("Error writing search cache of hospital %d: %v", hospitalID, err)
+	}
+}
+
+// Invalidate drops every cached search of the hospital. It is a no-op on a nil SearchCache,
+// so callers need not check whether caching is on.
+func (s *SearchCache) Invalidate(hospitalID uint) {
+	if s == nil {
+		return
+	}
+	if err := s.cache.Set(searchGenerationKey(hospitalID), newGeneration(), 0); err != nil {
+		log.Printf("Error invalidating search cache of hospital %d: %v", hospitalID, err)
+	}
+}
+
+// key builds the cache key from the hospital's generation and the query encoded as JSON, which
+// lists the criteria in a fixed order whatever order the request gave them in.
+func (s *SearchCache) key(hospitalID uint, query *models.PatientSearchQuery, limit int) (string, error) {
+	generationKey := searchGenerationKey(hospitalID)
+	generation, found, err := s.cache.Get(generationKey)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		// Never assume a starting value: a token evicted from the cache must not revive old entries
+		generation = newGeneration()
+		if err := s.cache.Set(generationKey, generation, 0); err != nil {
+			return "", err
+		}
+	}
+	normalized, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("search:%d:%s:%d:%s", hospitalID, generation, limit, normalized), nil
+}
+
+func searchGenerationKey(hospitalID uint) string {
+	return fmt.Sprintf("search:%d:generation", hospitalID)
+}
+
+func newGeneration() []byte {
+	return []byte(strconv.FormatInt(time.Now().UnixNano(), 36) + "." + strconv.FormatUint(generationCounter.Add(1), 36))
+}
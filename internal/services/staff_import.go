@@ -0,0 +1,320 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Bulk staff import limits.
+const (
+	StaffImportMaxRows     = 500
+	staffImportHashWorkers = 8
+	staffImportBatchSize   = 50
+)
+
+// staffImportColumns is the required CSV header.
+var staffImportColumns = []string{"username", "password", "hospital", "role"}
+
+// Whole-file import errors. Handlers map these to 400; problems with single rows are
+// reported in the result instead.
+var (
+	ErrStaffImportTooManyRows = fmt.Errorf("a staff import may contain at most %d rows", StaffImportMaxRows)
+	ErrStaffImportInvalidCSV  = errors.New("invalid staff import CSV")
+)
+
+// staffImportRow is one data row of an import, with the line it came from.
+type staffImportRow struct {
+	line     int
+	username string
+	password string
+	hospital string
+	role     string
+}
+
+// ImportStaffCSV creates staff accounts from a CSV with the columns username,password,hospital,role.
+// Each row is validated on its own, so a bad row is skipped and reported without stopping the rest.
+// Only super admins may import into other hospitals or create super_admin accounts. A dry run
+// validates and inserts exactly the same way, but every insert is rolled back.
+func ImportStaffCSV(repo database.PatientRepository, importer *Claims, r io.Reader, dryRun bool) (*models.StaffImportResult, error) {
+	rows, result, err := parseStaffImportCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	result.DryRun = dryRun
+
+	valid, err := validateStaffImportRows(repo, importer, rows, result)
+	if err != nil {
+		return nil, err
+	}
+
+	staff := hashStaffImportPasswords(valid, result)
+	insertStaffImportBatches(repo, staff, result, dryRun)
+
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Row < result.Errors[j].Row })
+	result.Skipped = len(result.Errors)
+	if dryRun {
+		log.Printf("Staff import dry run by %s: %d would be imported, %d skipped", importer.Username, result.Imported, result.Skipped)
+		return result, nil
+	}
+	log.Printf("Staff import by %s: %d imported, %d skipped", importer.Username, result.Imported, result.Skipped)
+	return result, nil
+}
+
+// parseStaffImportCSV reads the header and data rows. Rows with the wrong number of
+// columns are recorded as errors in the returned result.
+func parseStaffImportCSV(r io.Reader) ([]staffImportRow, *models.StaffImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Column counts are checked per row
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("%w: file is empty", ErrStaffImportInvalidCSV)
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrStaffImportInvalidCSV, err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\uFEFF") // Spreadsheet exports often start with a BOM
+	}
+	if !staffImportHeaderValid(header) {
+		return nil, nil, fmt.Errorf("%w: header must be %s", ErrStaffImportInvalidCSV, strings.Join(staffImportColumns, ","))
+	}
+
+	result := &models.StaffImportResult{Errors: []models.StaffImportError{}}
+	var rows []staffImportRow
+	for count := 0; ; count++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrStaffImportInvalidCSV, err)
+		}
+		if count == StaffImportMaxRows {
+			return nil, nil, ErrStaffImportTooManyRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(staffImportColumns) {
+			result.Errors = append(result.Errors, models.StaffImportError{
+				Row:    line,
+				Reason: fmt.Sprintf("expected %d columns, got %d", len(staffImportColumns), len(record)),
+			})
+			continue
+		}
+		rows = append(rows, staffImportRow{
+			line:     line,
+			username: strings.TrimSpace(record[0]),
+			password: record[1], // Passwords are taken verbatim
+			hospital: strings.TrimSpace(record[2]),
+			role:     strings.TrimSpace(record[3]),
+		})
+	}
+	return rows, result, nil
+}
+
+func staffImportHeaderValid(header []string) bool {
+	if len(header) != len(staffImportColumns) {
+		return false
+	}
+	for i, column := range staffImportColumns {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), column) {
+			return false
+		}
+	}
+	return true
+}
+
+// pendingStaff is a validated row waiting to be hashed and saved.
+type pendingStaff struct {
+	line  int
+	staff models.Staff
+}
+
+// validateStaffImportRows checks every row and returns the ones that may be imported, with their
+// hospital resolved. Rejected rows are added to result. An error means the database failed.
+func validateStaffImportRows(repo database.PatientRepository, importer *Claims, rows []staffImportRow, result *models.StaffImportResult) ([]pendingStaff, error) {
+	reject := func(line int, reason string) {
+		result.Errors = append(result.Errors, models.StaffImportError{Row: line, Reason: reason})
+	}
+
+	hospitalIDs := make(map[string]uint)
+	firstLine := make(map[string]int)
+	var candidates []pendingStaff
+	for _, row := range rows {
+		if row.username == "" || row.password == "" || row.hospital == "" {
+			reject(row.line, "username, password and hospital are required")
+			continue
+		}
+		normalized := models.NormalizeUsername(row.username)
+		if line, seen := firstLine[normalized]; seen {
+			reject(row.line, fmt.Sprintf("duplicate username (also on row %d)", line))
+			continue
+		}
+		firstLine[normalized] = row.line
+
+		role := row.role
+		if role == "" {
+			role = models.RoleStaff
+		}
+		if !models.ValidRole(role) {
+			reject(row.line, fmt.Sprintf("invalid role %q", row.role))
+			continue
+		}
+		if role == models.RoleSuperAdmin && !importer.IsSuperAdmin() {
+			reject(row.line, "only a super admin can import super_admin accounts")
+			continue
+		}
+
+		hospitalID, known := hospitalIDs[row.hospital]
+		if !known {
+			id, err := repo.GetHospitalIDByName(row.hospital)
+			if err != nil && !errors.Is(err, database.ErrHospitalNotFound) {
+				return nil, fmt.Errorf("looking up hospital %q: %w", row.hospital, err)
+			}
+			hospitalID = id // Zero when the hospital does not exist
+			hospitalIDs[row.hospital] = hospitalID
+		}
+		if hospitalID == 0 {
+			reject(row.line, fmt.Sprintf("unknown hospital %q", row.hospital))
+			continue
+		}
+		if hospitalID != importer.HospitalID && !importer.IsSuperAdmin() {
+			reject(row.line, "cannot import staff into another hospital")
+			continue
+		}
+
+		if violations := CheckPasswordPolicy(row.password); len(violations) > 0 {
+			reject(row.line, "password policy violated: "+strings.Join(violations, "; "))
+			continue
+		}
+
+		candidates = append(candidates, pendingStaff{
+			line: row.line,
+			staff: models.Staff{
+				Username:     row.username,
+				PasswordHash: row.password, // Plain text until hashStaffImportPasswords replaces it
+				HospitalID:   hospitalID,
+				HospitalName: row.hospital,
+				Role:         role,
+			},
+		})
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// One query for every remaining username instead of one per row
+	usernames := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		usernames[i] = candidate.staff.Username
+	}
+	existing, err := repo.FindExistingUsernames(usernames)
+	if err != nil {
+		return nil, fmt.Errorf("checking existing usernames: %w", err)
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, username := range existing {
+		taken[username] = true
+	}
+
+	valid := candidates[:0]
+	for _, candidate := range candidates {
+		if taken[models.NormalizeUsername(candidate.staff.Username)] {
+			reject(candidate.line, "duplicate username")
+			continue
+		}
+		valid = append(valid, candidate)
+	}
+	return valid, nil
+}
+
+// hashStaffImportPasswords replaces each plain-text password with its bcrypt hash using a
+// fixed pool of workers, since bcrypt dominates the cost of an import. Rows that fail to
+// hash are moved to result.
+func hashStaffImportPasswords(pending []pendingStaff, result *models.StaffImportResult) []pendingStaff {
+	failed := make([]bool, len(pending))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < staffImportHashWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				hash, err := utils.HashPassword(pending[i].staff.PasswordHash)
+				if err != nil {
+					log.Printf("Error hashing imported password for %s: %v", pending[i].staff.Username, err)
+					failed[i] = true
+					continue
+				}
+				pending[i].staff.PasswordHash = hash
+			}
+		}()
+	}
+	for i := range pending {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	hashed := pending[:0]
+	for i, p := range pending {
+		if failed[i] {
+			result.Errors = append(result.Errors, models.StaffImportError{Row: p.line, Reason: "failed to process password"})
+			continue
+		}
+		hashed = append(hashed, p)
+	}
+	return hashed
+}
+
+// insertStaffImportBatches saves staff in batches. If a batch fails, for example because a
+// username was taken since validation, its rows are retried one by one so only the
+// offending rows are skipped. In a dry run every insert is rolled back.
+func insertStaffImportBatches(repo database.PatientRepository, pending []pendingStaff, result *models.StaffImportResult, dryRun bool) {
+	createBatch := repo.CreateStaffBatch
+	createOne := repo.CreateStaff
+	if dryRun {
+		createBatch = repo.RehearseStaffBatch
+		createOne = func(staff *models.Staff) error { return repo.RehearseStaffBatch([]models.Staff{*staff}) }
+	}
+
+	for start := 0; start < len(pending); start += staffImportBatchSize {
+		end := start + staffImportBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+
+		staff := make([]models.Staff, len(batch))
+		for i, p := range batch {
+			staff[i] = p.staff
+		}
+		err := createBatch(staff)
+		if err == nil {
+			result.Imported += len(batch)
+			continue
+		}
+
+		log.Printf("Staff import batch of %d failed, retrying rows individually: %v", len(batch), err)
+		for _, p := range batch {
+			single := p.staff
+			if err := createOne(&single); err != nil {
+				log.Printf("Error importing staff %s from row %d: %v", single.Username, p.line, err)
+				result.Errors = append(result.Errors, models.StaffImportError{Row: p.line, Reason: "could not be saved"})
+				continue
+			}
+			result.Imported++
+		}
+	}
+}
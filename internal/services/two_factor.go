@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"log"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// TOTPIssuer is the name authenticator apps show next to the account.
+const TOTPIssuer = "Hospital Middleware"
+
+// totpPeriod and totpSkew match the defaults totp.Generate and totp.Validate use: 30-second
+// steps, accepting codes from one step either side of the current one to allow for clock drift.
+const (
+	totpPeriod = 30
+	totpSkew   = 1
+)
+
+// matchTOTPStep returns the time step whose code matches, trying the current step first.
+// Knowing the step, rather than just that the code is valid, is what lets callers refuse a replay.
+func matchTOTPStep(code, secret string, now time.Time) (int64, bool) {
+	opts := totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+	current := now.Unix() / totpPeriod
+	for _, offset := range []int64{0, -1, 1} {
+		step := current + offset*totpSkew
+		if ok, _ := totp.ValidateCustom(code, secret, time.Unix(step*totpPeriod, 0).UTC(), opts); ok {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// verifyTOTPLogin checks a login code and claims its time step, so the same code (or an older
+// one) can't be used again even while it is still inside the validity window.
+func verifyTOTPLogin(repo database.PatientRepository, staff *models.Staff, code string) error {
+	step, ok := matchTOTPStep(code, staff.TOTPSecret, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	claimed, err := repo.ClaimStaffTOTPStep(staff.ID, step)
+	if err != nil {
+		return fmt.Errorf("recording two-factor code use for %s: %w", staff.Username, err)
+	}
+	if !claimed {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// Two-factor enrollment errors. Handlers map these to HTTP status codes.
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotStarted     = errors.New("two-factor enrollment has not been started")
+)
+
+// StartTwoFactorEnrollment generates a new TOTP secret for the staff member.
+// The secret only takes effect once ConfirmTwoFactorEnrollment verifies a code from it.
+func StartTwoFactorEnrollment(repo database.PatientRepository, username string) (*models.TwoFactorEnrollResponse, error) {
+	staff, err := repo.FindStaffByUsername(username)
+	if err != nil {
+		return nil, fmt.Errorf("loading staff %s: %w", username, err)
+	}
+	if staff.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: TOTPIssuer, AccountName: staff.Username})
+	if err != nil {
+		return nil, fmt.Errorf("generating TOTP secret: %w", err)
+	}
+	if err := repo.SetStaffTOTPSecret(staff.ID, key.Secret()); err != nil {
+		return nil, fmt.Errorf("saving TOTP secret for %s: %w", username, err)
+	}
+
+	log.Printf("Two-factor enrollment started for user %s", staff.Username)
+	return &models.TwoFactorEnrollResponse{Secret: key.Secret(), OTPAuthURL: key.URL()}, nil
+}
+
+// ConfirmTwoFactorEnrollment enables two-factor authentication if code matches the enrolled secret.
+func ConfirmTwoFactorEnrollment(repo database.PatientRepository, username, code string) error {
+	staff, err := repo.FindStaffByUsername(username)
+	if err != nil {
+		return fmt.Errorf("loading staff %s: %w", username, err)
+	}
+	if staff.TOTPEnabled {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	if staff.TOTPSecret == "" {
+		return ErrTwoFactorNotStarted
+	}
+	step, ok := matchTOTPStep(code, staff.TOTPSecret, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	if err := repo.EnableStaffTOTP(staff.ID, step); err != nil {
+		return fmt.Errorf("enabling two-factor authentication for %s: %w", username, err)
+	}
+
+	log.Printf("Two-factor authentication enabled for user %s", staff.Username)
+	return nil
+}
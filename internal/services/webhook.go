@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Headers sent with every webhook delivery. The signature is computed by utils.SignPayload, which
+// documents how receivers verify it. LegacyWebhookSignatureHeader carries the same value under its
+// old name, for receivers that have not moved to the new one yet.
+const (
+	WebhookSignatureHeader       = "X-Hospital-Signature-256"
+	LegacyWebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader           = "X-Webhook-Event"
+)
+
+// Webhook delivery settings. A delivery is retried after WebhookRetryBackoff, then twice that,
+// before it is recorded as a dead letter.
+const (
+	WebhookMaxAttempts  = 3
+	WebhookRetryBackoff = time.Second
+	WebhookTimeout      = 10 * time.Second
+	webhookSecretBytes  = 24
+)
+
+// WebhookStore is the part of the repository webhook delivery needs.
+// database.PatientRepository satisfies it.
+type WebhookStore interface {
+	ListActiveWebhooks(hospitalID uint, event string) ([]models.Webhook, error)
+	CreateWebhookDeadLetter(entry *models.WebhookDeadLetter) error
+}
+
+// WebhookDispatcher delivers webhook payloads in the background, so a slow or failing
+// receiver never holds up the request that caused the event.
+type WebhookDispatcher struct {
+	store   WebhookStore
+	client  *http.Client
+	backoff time.Duration
+	wg      sync.WaitGroup
+}
+
+// NewWebhookDispatcher returns a dispatcher that posts with client and waits backoff, doubling
+// each time, between attempts.
+func NewWebhookDispatcher(store WebhookStore, client *http.Client, backoff time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{store: store, client: client, backoff: backoff}
+}
+
+// Dispatch sends the payload to every active webhook of its hospital subscribed to its event.
+// It returns at once; the lookup and deliveries run on their own goroutine.
+func (d *WebhookDispatcher) Dispatch(payload models.WebhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error encoding %s webhook payload for hospital %d: %v", payload.Event, payload.HospitalID, err)
+		return
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		webhooks, err := d.store.ListActiveWebhooks(payload.HospitalID, payload.Event)
+		if err != nil {
+			log.Printf("Error loading %s webhooks of hospital %d: %v", payload.Event, payload.HospitalID, err)
+			return
+		}
+		for _, webhook := range webhooks {
+			d.wg.Add(1)
+			go func(webhook models.Webhook) {
+				defer d.wg.Done()
+				d.deliver(webhook, payload.Event, body)
+			}(webhook)
+		}
+	}()
+}
+
+// Wait blocks until every delivery dispatched so far has succeeded or been dead-lettered.
+func (d *WebhookDispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Drain is Wait bounded by ctx. It returns ctx's error if deliveries are still pending when ctx
+// is done; those are lost along with their dead letters.
+func (d *WebhookDispatcher) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver posts body to the webhook, retrying with exponential backoff, and records a dead letter
+// once every attempt has failed.
+func (d *WebhookDispatcher) deliver(webhook models.Webhook, event string, body []byte) {
+	var statusCode int
+	var lastErr error
+	delay := d.backoff
+	for attempt := 1; attempt <= WebhookMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		statusCode, lastErr = d.post(webhook, event, body)
+		if lastErr == nil {
+			return
+		}
+		log.Printf("Webhook %d delivery of %s failed (attempt %d of %d): %v", webhook.ID, event, attempt, WebhookMaxAttempts, lastErr)
+	}
+
+	entry := &models.WebhookDeadLetter{
+		WebhookID:  webhook.ID,
+		Event:      event,
+		Payload:    string(body),
+		Attempts:   WebhookMaxAttempts,
+		StatusCode: statusCode,
+		LastError:  lastErr.Error(),
+	}
+	if err := d.store.CreateWebhookDeadLetter(entry); err != nil {
+		log.Printf("Error recording dead letter for webhook %d: %v", webhook.ID, err)
+	}
+}
+
+// post makes one delivery attempt. Any 2xx response counts as delivered.
+func (d *WebhookDispatcher) post(webhook models.Webhook, event string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event)
+	signature := utils.SignPayload(webhook.Secret, body)
+	req.Header.Set(WebhookSignatureHeader, signature)
+	req.Header.Set(LegacyWebhookSignatureHeader, signature)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // Drain so the connection can be reused
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SendTest posts a webhook.test payload to the webhook once, synchronously, and returns the status
+// the receiver answered with. Unlike Dispatch it does not retry or record a dead letter. The error
+// is set when no response arrived, and also for a non-2xx status.
+func (d *WebhookDispatcher) SendTest(webhook models.Webhook) (int, error) {
+	body, err := json.Marshal(models.WebhookPayload{
+		Event:      models.WebhookEventTest,
+		Timestamp:  time.Now().UTC(),
+		HospitalID: webhook.HospitalID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return d.post(webhook, models.WebhookEventTest, body)
+}
+
+// NewWebhookSecret generates a signing secret for a webhook created without one.
+func NewWebhookSecret() (string, error) {
+	return randomHex(webhookSecretBytes)
+}
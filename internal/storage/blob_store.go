@@ -0,0 +1,21 @@
+// Package storage holds the byte stores used for uploaded files.
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when no blob exists for a key.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores opaque file contents under string keys.
+// Keys are slash-separated paths chosen by the caller, e.g. "patients/12/3f9a...".
+type BlobStore interface {
+	// Put writes everything from r under key and returns the number of bytes stored.
+	Put(key string, r io.Reader) (int64, error)
+	// Get opens the blob stored under key. The caller must close it.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the blob. Deleting a missing key is not an error.
+	Delete(key string) error
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalDiskStore is a BlobStore that keeps each blob as a file under a root directory.
+type LocalDiskStore struct {
+	root string
+}
+
+// Compile-time check that LocalDiskStore satisfies BlobStore.
+var _ BlobStore = (*LocalDiskStore)(nil)
+
+// NewLocalDiskStore creates the root directory if needed and returns a store rooted there.
+func NewLocalDiskStore(root string) (*LocalDiskStore, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolving blob root %s: %w", root, err)
+	}
+	if err := os.MkdirAll(absRoot, 0o750); err != nil {
+		return nil, fmt.Errorf("creating blob root %s: %w", absRoot, err)
+	}
+	return &LocalDiskStore{root: absRoot}, nil
+}
+
+// Put writes the blob to a temporary file first and renames it into place,
+// so readers never observe a partially written file.
+func (s *LocalDiskStore) Put(key string, r io.Reader) (int64, error) {
+	path, err := s.pathFor(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename succeeds
+
+	written, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// Get opens the file stored under key.
+func (s *LocalDiskStore) Get(key string) (io.ReadCloser, error) {
+	path, err := s.pathFor(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return f, err
+}
+
+// Delete removes the file stored under key.
+func (s *LocalDiskStore) Delete(key string) error {
+	path, err := s.pathFor(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// pathFor maps a key to a file path, rejecting keys that would escape the root.
+func (s *LocalDiskStore) pathFor(key string) (string, error) {
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if key == "" || !strings.HasPrefix(path, s.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return path, nil
+}
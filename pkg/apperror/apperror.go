@@ -0,0 +1,220 @@
+// Package apperror defines the errors handlers report to clients, each tied to the HTTP status it
+// is answered with and a machine-readable Code, and HandleError, which writes them as
+// ErrorResponse JSON.
+package apperror
+
+import (
+	"errors"
+	"hospital-middleware/pkg/i18n"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorResponse is the body of every error response. The message keeps the "error" key clients
+// read before codes existed.
+type ErrorResponse struct {
+	Code    Code         `json:"code"`
+	Message string       `json:"error"`
+	Details []FieldError `json:"details,omitempty"` // Only for validation failures
+}
+
+// FieldError explains what is wrong with one request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// HTTPError is implemented by every error of this package. Error returns the message shown to the client.
+type HTTPError interface {
+	error
+	StatusCode() int
+	ErrorCode() Code
+}
+
+// NotFoundError reports a missing resource, answered with 404.
+type NotFoundError struct {
+	Code    Code
+	Message string
+}
+
+// ConflictError reports a clash with the current state, such as a duplicate, answered with 409.
+type ConflictError struct {
+	Code    Code
+	Message string
+}
+
+// ValidationError reports an invalid request, answered with 400. Fields optionally explains, per
+// request field, what is wrong with it.
+type ValidationError struct {
+	Code    Code
+	Message string
+	Fields  map[string]string
+}
+
+// UnauthorizedError reports missing or bad credentials, answered with 401.
+type UnauthorizedError struct {
+	Code    Code
+	Message string
+}
+
+// ForbiddenError reports an action the caller may not take, answered with 403.
+type ForbiddenError struct {
+	Code    Code
+	Message string
+}
+
+// UnavailableError reports a temporary failure, such as the database being unreachable, answered
+// with 503 and a Retry-After header telling the client when to try again.
+type UnavailableError struct {
+	Code       Code
+	Message    string
+	RetryAfter time.Duration
+}
+
+// StatusError reports a failure answered with a status none of the other types covers, such as
+// 412, 413, 415, 422, 502 or 504.
+type StatusError struct {
+	Status  int
+	Code    Code
+	Message string
+}
+
+// InternalError reports a server-side failure, answered with 500 and CodeInternal. The message
+// should not reveal the cause, which callers log themselves.
+type InternalError struct{ Message string }
+
+func (e *NotFoundError) Error() string     { return e.Message }
+func (e *ConflictError) Error() string     { return e.Message }
+func (e *ValidationError) Error() string   { return e.Message }
+func (e *UnauthorizedError) Error() string { return e.Message }
+func (e *ForbiddenError) Error() string    { return e.Message }
+func (e *UnavailableError) Error() string  { return e.Message }
+func (e *StatusError) Error() string       { return e.Message }
+func (e *InternalError) Error() string     { return e.Message }
+
+func (e *NotFoundError) StatusCode() int     { return http.StatusNotFound }
+func (e *ConflictError) StatusCode() int     { return http.StatusConflict }
+func (e *ValidationError) StatusCode() int   { return http.StatusBadRequest }
+func (e *UnauthorizedError) StatusCode() int { return http.StatusUnauthorized }
+func (e *ForbiddenError) StatusCode() int    { return http.StatusForbidden }
+func (e *UnavailableError) StatusCode() int  { return http.StatusServiceUnavailable }
+func (e *StatusError) StatusCode() int       { return e.Status }
+func (e *InternalError) StatusCode() int     { return http.StatusInternalServerError }
+
+func (e *NotFoundError) ErrorCode() Code     { return e.Code }
+func (e *ConflictError) ErrorCode() Code     { return e.Code }
+func (e *ValidationError) ErrorCode() Code   { return e.Code }
+func (e *UnauthorizedError) ErrorCode() Code { return e.Code }
+func (e *ForbiddenError) ErrorCode() Code    { return e.Code }
+func (e *UnavailableError) ErrorCode() Code  { return e.Code }
+func (e *StatusError) ErrorCode() Code       { return e.Code }
+func (e *InternalError) ErrorCode() Code     { return CodeInternal }
+
+// NotFound returns a NotFoundError with the given code and message.
+func NotFound(code Code, message string) error { return &NotFoundError{Code: code, Message: message} }
+
+// Conflict returns a ConflictError with the given code and message.
+func Conflict(code Code, message string) error { return &ConflictError{Code: code, Message: message} }
+
+// Validation returns a ValidationError with the given code and message.
+func Validation(code Code, message string) error {
+	return &ValidationError{Code: code, Message: message}
+}
+
+// Unauthorized returns an UnauthorizedError with the given code and message.
+func Unauthorized(code Code, message string) error {
+	return &UnauthorizedError{Code: code, Message: message}
+}
+
+// Forbidden returns a ForbiddenError with the given code and message.
+func Forbidden(code Code, message string) error { return &ForbiddenError{Code: code, Message: message} }
+
+// Unavailable returns an UnavailableError with the given code and message that asks the client
+// to retry after retryAfter.
+func Unavailable(code Code, message string, retryAfter time.Duration) error {
+	return &UnavailableError{Code: code, Message: message, RetryAfter: retryAfter}
+}
+
+// New returns a StatusError answered with status and the given code and message. Prefer the
+// constructors above for the statuses they cover.
+func New(status int, code Code, message string) error {
+	return &StatusError{Status: status, Code: code, Message: message}
+}
+
+// Internal returns an InternalError with the given message.
+func Internal(message string) error { return &InternalError{Message: message} }
+
+// internalMessage is shown for errors that are not HTTPErrors, whose text may reveal internals.
+const internalMessage = "Internal server error"
+
+// Response returns the error response for code, with message translated into the language the
+// request asks for (see Localize). Handlers writing an error response themselves build it with
+// Response so it is translated like the ones HandleError writes.
+func Response(c *gin.Context, code Code, message string) ErrorResponse {
+	return ErrorResponse{Code: code, Message: Localize(c, code, message)}
+}
+
+// Localize returns the translation of code's message into the language the request asks for with
+// the lang query parameter or Accept-Language. English requests, and codes without a translation,
+// get message itself.
+func Localize(c *gin.Context, code Code, message string) string {
+	lang := i18n.RequestLanguage(c.Request)
+	if lang == i18n.English {
+		return message
+	}
+	if translated, ok := i18n.Message(string(code), lang); ok {
+		return translated
+	}
+	return message
+}
+
+// HandleError writes err as a JSON error response. An HTTPError anywhere in err's chain picks the
+// status, code and message, which is translated as by Localize; anything else is logged and
+// answered with a generic 500.
+func HandleError(c *gin.Context, err error) {
+	var httpErr HTTPError
+	if !errors.As(err, &httpErr) {
+		log.Printf("Unhandled error on %s %s: %v", c.Request.Method, c.FullPath(), err)
+		httpErr = &InternalError{Message: internalMessage}
+	}
+
+	body := Response(c, httpErr.ErrorCode(), httpErr.Error())
+	var validationErr *ValidationError
+	if errors.As(httpErr, &validationErr) {
+		body.Details = fieldErrors(validationErr.Fields)
+	}
+	var unavailableErr *UnavailableError
+	if errors.As(httpErr, &unavailableErr) {
+		c.Header("Retry-After", strconv.Itoa(RetryAfterSeconds(unavailableErr.RetryAfter)))
+	}
+	c.JSON(httpErr.StatusCode(), body)
+}
+
+// RetryAfterSeconds rounds a wait up to the whole seconds sent in a Retry-After header. It is at
+// least one, since Retry-After: 0 invites an immediate retry.
+func RetryAfterSeconds(retryAfter time.Duration) int {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// fieldErrors lists fields sorted by field name, or returns nil when there are none.
+func fieldErrors(fields map[string]string) []FieldError {
+	if len(fields) == 0 {
+		return nil
+	}
+	details := make([]FieldError, 0, len(fields))
+	for field, message := range fields {
+		details = append(details, FieldError{Field: field, Message: message})
+	}
+	sort.Slice(details, func(i, j int) bool { return details[i].Field < details[j].Field })
+	return details
+}
@@ -0,0 +1,268 @@
+package apperror
+
+// Code is a machine-readable error code, sent as "code" in every error response so clients can
+// tell errors apart without matching on messages, which may change. A code never changes meaning
+// once released; retired codes are not reused.
+//
+// Codes are grouped by area. Within a group they are numbered in the order they were added.
+//
+//	REQUEST_001      400  The request body is malformed or fails validation; see details
+//	REQUEST_002      400  A query parameter is missing or invalid
+//	REQUEST_003      400  A path parameter is invalid, such as a non-numeric ID
+//	REQUEST_004      415  The Content-Type is not accepted by the endpoint
+//	REQUEST_005      400  A multipart upload has no "file" field
+//	REQUEST_006      400  The uploaded file could not be read
+//	REQUEST_007      413  The uploaded file is too large
+//	REQUEST_008      404  No route matches the path
+//	REQUEST_009      405  The route does not support the method
+//	INTERNAL_001     500  The server failed; the message and request ID identify where
+//	INTERNAL_002     503  The database is temporarily unavailable; retry after Retry-After
+//	RATE_LIMITED     429  Too many requests; retry after retry_after_seconds
+//	ACCOUNT_LOCKED   429  Too many failed logins; retry after retry_after_seconds
+//	AUTH_001         401  No credentials were sent
+//	AUTH_002         401  The Authorization header is not a bearer token or API key
+//	AUTH_003         401  The token is invalid or expired
+//	AUTH_004         401  The token was revoked by logging out
+//	AUTH_005         401  The API key is invalid, expired or revoked
+//	AUTH_006         401  The credentials belong to a hospital that no longer exists
+//	AUTH_007         401  The username, password or hospital is wrong
+//	AUTH_008         401  A two-factor code is required to log in
+//	AUTH_009     401/403  The account must enroll in two-factor authentication first
+//	AUTH_010         403  The account is disabled
+//	AUTH_011         400  The two-factor code is wrong or enrollment was not started
+//	AUTH_012         409  Two-factor authentication is already enabled
+//	AUTH_013         401  The current password given for a password change is wrong
+//	AUTH_014         401  The staff account of the token no longer exists
+//	AUTH_015         400  The token cannot be revoked
+//	AUTH_016         403  The action requires an admin
+//	AUTH_017         403  The action is only available to staff accounts, not API keys
+//	AUTH_018         403  Admins may only manage the hospitals they administer
+//	AUTH_019         403  The action requires a super admin
+//	STAFF_001        409  The username is already taken
+//	STAFF_002        400  The hospital named in the request does not exist
+//	STAFF_003        404  The staff member does not exist in the caller's hospital
+//	STAFF_004        400  The password violates the password policy; see violations
+//	STAFF_005        409  The hospital's last admin cannot be demoted
+//	STAFF_006        400  The staff import file is not a valid import CSV
+//	PATIENT_001      404  The patient does not exist in the caller's hospital
+//	PATIENT_002      409  The patient was moved to another hospital by another request
+//	PATIENT_003      412  The patient changed since it was fetched (If-Match)
+//	PATIENT_004      400  A patient update has no fields to update
+//	PATIENT_005      400  The patient's extra fields are not permitted or too large
+//	PATIENT_006      409  The patient's status was changed by another request
+//	PATIENT_007      422  The status cannot change from the current status to the requested one
+//	PATIENT_008      400  deceased_at is invalid for the status or the patient
+//	PATIENT_009      400  The patient is already in the target hospital
+//	PATIENT_010      409  The patient changed since the history file was imported
+//	PATIENT_011      400  A history change cannot be applied
+//	PATIENT_012      415  The history file is not a UTF-8 .txt file
+//	PATIENT_013      503  Patient summaries are not configured on this server
+//	PATIENT_014      504  Rendering the patient summary took too long
+//	PATIENT_015      409  Several patients of the caller's hospital share the national ID
+//	SEARCH_001       400  Too few search criteria were given
+//	SEARCH_002       400  Two search criteria cannot be combined
+//	SEARCH_003       400  A search criterion is invalid or unknown
+//	SEARCH_004       400  The fields or include parameter names something unknown
+//	SEARCH_005       400  The hospitals scope of a cross-hospital search is invalid
+//	SEARCH_006       403  Cross-hospital search is not enabled for the caller's hospital
+//	SEARCH_007       404  The saved search does not exist
+//	SEARCH_008       409  A saved search with the name already exists
+//	SEARCH_009       409  The caller already has the maximum number of saved searches
+//	SEARCH_010       422  The saved search uses parameters that are no longer valid
+//	HOSPITAL_001     404  The hospital does not exist
+//	HOSPITAL_002     404  The hospital feature is unknown
+//	HOSPITAL_003     400  An extra field key in the hospital config is invalid
+//	HOSPITAL_004     400  Deleting every patient of a hospital was not confirmed
+//	HOSPITAL_005     403  Deleting every patient of a hospital is disabled in production
+//	ADMISSION_001    404  The admission does not exist
+//	ADMISSION_002    409  The admission is already discharged
+//	ADMISSION_003    409  The patient is already admitted
+//	ADMISSION_004    400  discharged_at is before admitted_at
+//	ALLERGY_001      404  The allergy does not exist
+//	ALLERGY_002      409  The patient already has an allergy to the substance
+//	APIKEY_001       404  The API key does not exist
+//	APIKEY_002       400  The API key expiry is in the past
+//	DIAGNOSIS_001    400  The ICD-10 code is unknown
+//	DOCUMENT_001     404  The document does not exist
+//	DOCUMENT_002     415  The document type is not accepted
+//	LABEL_001        404  The label does not exist
+//	LABEL_002        409  A label with the name already exists
+//	LABEL_003        404  The patient does not have the label
+//	NOTE_001         404  The note does not exist
+//	NOTE_002         400  The note body is empty or too long
+//	NOTE_003         403  Only the author, or an admin for deletion, may change the note
+//	REFERRAL_001     404  The referral does not exist
+//	REFERRAL_002     409  The referral was already accepted or rejected
+//	REFERRAL_003     403  Only the target hospital may accept or reject the referral
+//	REFERRAL_004     400  A patient cannot be referred to their own hospital
+//	TAG_001          404  The tag does not exist
+//	TAG_002          404  The patient does not have the tag
+//	TAG_003          400  The tag name is invalid
+//	VISIT_001        400  discharged_at is before admitted_at
+//	VISIT_002        409  The hospital already has a visit with the visit number
+//	WEBHOOK_001      404  The webhook does not exist
+//	WEBHOOK_002      400  The webhook URL is not an http or https URL
+//	WEBHOOK_003      502  The webhook receiver could not be reached
+type Code string
+
+// Request errors, shared by every endpoint.
+const (
+	CodeInvalidBody          Code = "REQUEST_001"
+	CodeInvalidQuery         Code = "REQUEST_002"
+	CodeInvalidPathParam     Code = "REQUEST_003"
+	CodeUnsupportedMediaType Code = "REQUEST_004"
+	CodeFileRequired         Code = "REQUEST_005"
+	CodeFileUnreadable       Code = "REQUEST_006"
+	CodeFileTooLarge         Code = "REQUEST_007"
+	CodeRouteNotFound        Code = "REQUEST_008"
+	CodeMethodNotAllowed     Code = "REQUEST_009"
+)
+
+// Server-side failures. CodeInternal covers every failure clients can only report, so they are not
+// told apart; CodeDatabaseUnavailable marks the transient ones worth retrying.
+const (
+	CodeInternal            Code = "INTERNAL_001"
+	CodeDatabaseUnavailable Code = "INTERNAL_002"
+)
+
+// Codes of 429 responses. They predate the numbered codes and keep their original names.
+const (
+	CodeRateLimited   Code = "RATE_LIMITED"
+	CodeAccountLocked Code = "ACCOUNT_LOCKED"
+)
+
+// Authentication and authorization errors.
+const (
+	CodeAuthRequired                Code = "AUTH_001"
+	CodeInvalidAuthHeader           Code = "AUTH_002"
+	CodeInvalidToken                Code = "AUTH_003"
+	CodeTokenRevoked                Code = "AUTH_004"
+	CodeInvalidAPIKey               Code = "AUTH_005"
+	CodeHospitalNoLongerValid       Code = "AUTH_006"
+	CodeInvalidCredentials          Code = "AUTH_007"
+	CodeTwoFactorCodeRequired       Code = "AUTH_008"
+	CodeTwoFactorEnrollmentRequired Code = "AUTH_009"
+	CodeAccountDisabled             Code = "AUTH_010"
+	CodeInvalidTwoFactorCode        Code = "AUTH_011"
+	CodeTwoFactorAlreadyEnabled     Code = "AUTH_012"
+	CodeCurrentPasswordIncorrect    Code = "AUTH_013"
+	CodeStaffAccountGone            Code = "AUTH_014"
+	CodeTokenNotRevocable           Code = "AUTH_015"
+	CodeAdminRequired               Code = "AUTH_016"
+	CodeStaffAccountRequired        Code = "AUTH_017"
+	CodeHospitalAccessDenied        Code = "AUTH_018"
+	CodeSuperAdminRequired          Code = "AUTH_019"
+)
+
+// Staff account errors.
+const (
+	CodeUsernameTaken         Code = "STAFF_001"
+	CodeUnknownHospital       Code = "STAFF_002"
+	CodeStaffNotFound         Code = "STAFF_003"
+	CodePasswordPolicy        Code = "STAFF_004"
+	CodeLastAdmin             Code = "STAFF_005"
+	CodeInvalidStaffImportCSV Code = "STAFF_006"
+)
+
+// Patient errors.
+const (
+	CodePatientNotFound         Code = "PATIENT_001"
+	CodePatientMoved            Code = "PATIENT_002"
+	CodePatientChanged          Code = "PATIENT_003"
+	CodeNoFieldsToUpdate        Code = "PATIENT_004"
+	CodeInvalidExtraFields      Code = "PATIENT_005"
+	CodePatientStatusChanged    Code = "PATIENT_006"
+	CodeInvalidStatusTransition Code = "PATIENT_007"
+	CodeInvalidDeceasedAt       Code = "PATIENT_008"
+	CodeAlreadyInHospital       Code = "PATIENT_009"
+	CodeHistoryStale            Code = "PATIENT_010"
+	CodeInvalidHistoryChange    Code = "PATIENT_011"
+	CodeUnsupportedHistoryFile  Code = "PATIENT_012"
+	CodeSummaryUnavailable      Code = "PATIENT_013"
+	CodeSummaryTimeout          Code = "PATIENT_014"
+	CodeNationalIDNotUnique     Code = "PATIENT_015"
+)
+
+// Patient search and saved search errors.
+const (
+	CodeTooFewCriteria          Code = "SEARCH_001"
+	CodeConflictingCriteria     Code = "SEARCH_002"
+	CodeInvalidCriterion        Code = "SEARCH_003"
+	CodeInvalidFields           Code = "SEARCH_004"
+	CodeInvalidHospitalScope    Code = "SEARCH_005"
+	CodeCrossHospitalDisabled   Code = "SEARCH_006"
+	CodeSavedSearchNotFound     Code = "SEARCH_007"
+	CodeSavedSearchNameTaken    Code = "SEARCH_008"
+	CodeSavedSearchLimitReached Code = "SEARCH_009"
+	CodeSavedSearchInvalid      Code = "SEARCH_010"
+)
+
+// Hospital errors.
+const (
+	CodeHospitalNotFound       Code = "HOSPITAL_001"
+	CodeUnknownFeature         Code = "HOSPITAL_002"
+	CodeInvalidExtraFieldKey   Code = "HOSPITAL_003"
+	CodeBulkDeleteNotConfirmed Code = "HOSPITAL_004"
+	CodeBulkDeleteDisabled     Code = "HOSPITAL_005"
+)
+
+// Errors of the resources attached to patients and hospitals.
+const (
+	CodeAdmissionNotFound        Code = "ADMISSION_001"
+	CodeAdmissionDischarged      Code = "ADMISSION_002"
+	CodeAlreadyAdmitted          Code = "ADMISSION_003"
+	CodeAdmissionDischargeBefore Code = "ADMISSION_004"
+	CodeAllergyNotFound          Code = "ALLERGY_001"
+	CodeAllergyExists            Code = "ALLERGY_002"
+	CodeAPIKeyNotFound           Code = "APIKEY_001"
+	CodeAPIKeyExpiryInPast       Code = "APIKEY_002"
+	CodeUnknownICD10Code         Code = "DIAGNOSIS_001"
+	CodeDocumentNotFound         Code = "DOCUMENT_001"
+	CodeUnsupportedDocumentType  Code = "DOCUMENT_002"
+	CodeLabelNotFound            Code = "LABEL_001"
+	CodeLabelNameTaken           Code = "LABEL_002"
+	CodeLabelNotAssigned         Code = "LABEL_003"
+	CodeNoteNotFound             Code = "NOTE_001"
+	CodeInvalidNoteBody          Code = "NOTE_002"
+	CodeNoteNotAuthor            Code = "NOTE_003"
+	CodeReferralNotFound         Code = "REFERRAL_001"
+	CodeReferralDecided          Code = "REFERRAL_002"
+	CodeReferralNotTarget        Code = "REFERRAL_003"
+	CodeReferralToOwnHospital    Code = "REFERRAL_004"
+	CodeTagNotFound              Code = "TAG_001"
+	CodeTagNotAssigned           Code = "TAG_002"
+	CodeInvalidTagName           Code = "TAG_003"
+	CodeVisitDischargeBefore     Code = "VISIT_001"
+	CodeVisitNumberTaken         Code = "VISIT_002"
+	CodeWebhookNotFound          Code = "WEBHOOK_001"
+	CodeInvalidWebhookURL        Code = "WEBHOOK_002"
+	CodeWebhookUnreachable       Code = "WEBHOOK_003"
+)
+
+// Codes lists every code, for checking that codes are unique and documented.
+var Codes = []Code{
+	CodeInvalidBody, CodeInvalidQuery, CodeInvalidPathParam, CodeUnsupportedMediaType, CodeFileRequired,
+	CodeFileUnreadable, CodeFileTooLarge, CodeRouteNotFound, CodeMethodNotAllowed,
+	CodeInternal, CodeDatabaseUnavailable,
+	CodeRateLimited, CodeAccountLocked,
+	CodeAuthRequired, CodeInvalidAuthHeader, CodeInvalidToken, CodeTokenRevoked, CodeInvalidAPIKey,
+	CodeHospitalNoLongerValid, CodeInvalidCredentials, CodeTwoFactorCodeRequired, CodeTwoFactorEnrollmentRequired,
+	CodeAccountDisabled, CodeInvalidTwoFactorCode, CodeTwoFactorAlreadyEnabled, CodeCurrentPasswordIncorrect,
+	CodeStaffAccountGone, CodeTokenNotRevocable, CodeAdminRequired, CodeStaffAccountRequired, CodeHospitalAccessDenied,
+	CodeSuperAdminRequired,
+	CodeUsernameTaken, CodeUnknownHospital, CodeStaffNotFound, CodePasswordPolicy, CodeLastAdmin, CodeInvalidStaffImportCSV,
+	CodePatientNotFound, CodePatientMoved, CodePatientChanged, CodeNoFieldsToUpdate, CodeInvalidExtraFields,
+	CodePatientStatusChanged, CodeInvalidStatusTransition, CodeInvalidDeceasedAt, CodeAlreadyInHospital,
+	CodeHistoryStale, CodeInvalidHistoryChange, CodeUnsupportedHistoryFile, CodeSummaryUnavailable, CodeSummaryTimeout,
+	CodeNationalIDNotUnique,
+	CodeTooFewCriteria, CodeConflictingCriteria, CodeInvalidCriterion, CodeInvalidFields, CodeInvalidHospitalScope,
+	CodeCrossHospitalDisabled, CodeSavedSearchNotFound, CodeSavedSearchNameTaken, CodeSavedSearchLimitReached,
+	CodeSavedSearchInvalid,
+	CodeHospitalNotFound, CodeUnknownFeature, CodeInvalidExtraFieldKey, CodeBulkDeleteNotConfirmed, CodeBulkDeleteDisabled,
+	CodeAdmissionNotFound, CodeAdmissionDischarged, CodeAlreadyAdmitted, CodeAdmissionDischargeBefore,
+	CodeAllergyNotFound, CodeAllergyExists, CodeAPIKeyNotFound, CodeAPIKeyExpiryInPast, CodeUnknownICD10Code,
+	CodeDocumentNotFound, CodeUnsupportedDocumentType, CodeLabelNotFound, CodeLabelNameTaken, CodeLabelNotAssigned,
+	CodeNoteNotFound, CodeInvalidNoteBody, CodeNoteNotAuthor, CodeReferralNotFound, CodeReferralDecided,
+	CodeReferralNotTarget, CodeReferralToOwnHospital, CodeTagNotFound, CodeTagNotAssigned, CodeInvalidTagName,
+	CodeVisitDischargeBefore, CodeVisitNumberTaken, CodeWebhookNotFound, CodeInvalidWebhookURL, CodeWebhookUnreachable,
+}
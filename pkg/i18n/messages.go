@@ -0,0 +1,146 @@
+// Package i18n holds the translations of error messages and picks the language a request asks
+// for. Messages are keyed by error code, so it does not depend on the packages that send them.
+package i18n
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Supported languages.
+const (
+	English = "en"
+	Thai    = "th"
+)
+
+// LangParam is the query parameter that picks the language of error messages. It takes
+// precedence over the Accept-Language header.
+const LangParam = "lang"
+
+// Messages maps error codes to their message per language. The English entry is the text the
+// translations were made from; English responses keep the message the error was created with,
+// which may be more specific. Codes without an entry are always answered in English.
+var Messages = map[string]map[string]string{
+	"AUTH_001": {
+		English: "Authorization header required",
+		Thai:    "ไม่พบข้อมูลยืนยันตัวตน กรุณาเข้าสู่ระบบ",
+	},
+	"AUTH_002": {
+		English: "Invalid authorization header format",
+		Thai:    "รูปแบบข้อมูลยืนยันตัวตนไม่ถูกต้อง",
+	},
+	"AUTH_003": {
+		English: "Token is invalid or expired",
+		Thai:    "โทเค็นไม่ถูกต้องหรือหมดอายุแล้ว กรุณาเข้าสู่ระบบใหม่",
+	},
+	"AUTH_004": {
+		English: "Token has been revoked",
+		Thai:    "โทเค็นถูกยกเลิกแล้ว กรุณาเข้าสู่ระบบใหม่",
+	},
+	"AUTH_005": {
+		English: "API key is invalid, expired or revoked",
+		Thai:    "API key ไม่ถูกต้อง หมดอายุ หรือถูกยกเลิกแล้ว",
+	},
+	"AUTH_006": {
+		English: "Hospital no longer valid",
+		Thai:    "โรงพยาบาลของบัญชีนี้ไม่มีอยู่ในระบบแล้ว",
+	},
+	"AUTH_007": {
+		English: "Invalid username, password or hospital",
+		Thai:    "ชื่อผู้ใช้ รหัสผ่าน หรือโรงพยาบาลไม่ถูกต้อง",
+	},
+	"AUTH_008": {
+		English: "Two-factor code required",
+		Thai:    "กรุณากรอกรหัสยืนยันตัวตนแบบสองขั้นตอน",
+	},
+	"AUTH_009": {
+		English: "Two-factor enrollment required",
+		Thai:    "กรุณาตั้งค่าการยืนยันตัวตนแบบสองขั้นตอนก่อนใช้งาน",
+	},
+	"AUTH_010": {
+		English: "Account is disabled",
+		Thai:    "บัญชีนี้ถูกระงับการใช้งาน",
+	},
+	"AUTH_011": {
+		English: "Invalid two-factor code",
+		Thai:    "รหัสยืนยันตัวตนแบบสองขั้นตอนไม่ถูกต้อง",
+	},
+	"AUTH_012": {
+		English: "Two-factor authentication is already enabled",
+		Thai:    "เปิดใช้การยืนยันตัวตนแบบสองขั้นตอนอยู่แล้ว",
+	},
+	"AUTH_013": {
+		English: "Current password is incorrect",
+		Thai:    "รหัสผ่านปัจจุบันไม่ถูกต้อง",
+	},
+	"AUTH_014": {
+		English: "Staff account no longer exists",
+		Thai:    "ไม่พบบัญชีผู้ใช้นี้ในระบบแล้ว",
+	},
+	"AUTH_015": {
+		English: "Token cannot be revoked; it will expire on its own",
+		Thai:    "ไม่สามารถยกเลิกโทเค็นนี้ได้ โทเค็นจะหมดอายุเอง",
+	},
+	"AUTH_016": {
+		English: "Admin privileges required",
+		Thai:    "ต้องใช้สิทธิ์ผู้ดูแลระบบ",
+	},
+	"AUTH_017": {
+		English: "Only available to staff accounts",
+		Thai:    "ใช้ได้เฉพาะบัญชีเจ้าหน้าที่เท่านั้น",
+	},
+	"AUTH_018": {
+		English: "Access to this hospital is not permitted",
+		Thai:    "ไม่มีสิทธิ์เข้าถึงข้อมูลของโรงพยาบาลนี้",
+	},
+	"AUTH_019": {
+		English: "Super admin privileges required",
+		Thai:    "ต้องใช้สิทธิ์ผู้ดูแลระบบส่วนกลาง",
+	},
+	"RATE_LIMITED": {
+		English: "Too many requests",
+		Thai:    "มีการเรียกใช้งานมากเกินไป กรุณารอสักครู่แล้วลองใหม่",
+	},
+	"ACCOUNT_LOCKED": {
+		English: "Account temporarily locked after too many failed logins",
+		Thai:    "บัญชีถูกล็อกชั่วคราวเนื่องจากเข้าสู่ระบบไม่สำเร็จหลายครั้ง กรุณารอสักครู่แล้วลองใหม่",
+	},
+}
+
+// Message returns the message for code in lang, if there is one.
+func Message(code, lang string) (string, bool) {
+	message, ok := Messages[code][lang]
+	return message, ok
+}
+
+// RequestLanguage returns the supported language r asks for with the lang query parameter or,
+// without one, its Accept-Language header, or English when it asks for none of them.
+func RequestLanguage(r *http.Request) string {
+	if r == nil {
+		return English
+	}
+	if lang := r.URL.Query().Get(LangParam); lang != "" {
+		return supported(lang)
+	}
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil {
+		return English
+	}
+	for _, tag := range tags { // Sorted by preference
+		if base, _ := tag.Base(); base.String() == Thai || base.String() == English {
+			return base.String()
+		}
+	}
+	return English
+}
+
+// supported returns the supported language of a language tag such as "th-TH", or English.
+func supported(lang string) string {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(lang)), "-")
+	if base == Thai {
+		return Thai
+	}
+	return English
+}
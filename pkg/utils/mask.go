@@ -0,0 +1,14 @@
+package utils
+
+import "strings"
+
+// MaskAllButLast replaces every character except the last visible ones with '*'.
+// Values no longer than visible are masked entirely so short values don't leak whole.
+func MaskAllButLast(value string, visible int) string {
+	runes := []rune(value)
+	if len(runes) <= visible {
+		return strings.Repeat("*", len(runes))
+	}
+	masked := len(runes) - visible
+	return strings.Repeat("*", masked) + string(runes[masked:])
+}
@@ -0,0 +1,42 @@
+package utils
+
+import "strings"
+
+// Reasons ValidateThaiNationalID gives for rejecting an ID.
+const (
+	NationalIDReasonLength   = "must be 13 digits"
+	NationalIDReasonChecksum = "check digit does not match"
+)
+
+// nationalIDSeparators are the characters national IDs are commonly printed with, as in
+// 1-1037-00123-45-6. They are ignored when validating.
+var nationalIDSeparators = strings.NewReplacer("-", "", " ", "")
+
+// NationalIDDigits returns the national ID without surrounding space and separators, the form
+// patients' national IDs are stored in.
+func NationalIDDigits(id string) string {
+	return nationalIDSeparators.Replace(strings.TrimSpace(id))
+}
+
+// ValidateThaiNationalID checks a 13-digit Thai national ID against its check digit: the first
+// twelve digits are weighted 13 down to 2 and the last digit is (11 - sum mod 11) mod 10.
+// It returns the reason the ID is invalid, or "" when it is valid.
+func ValidateThaiNationalID(id string) string {
+	digits := NationalIDDigits(id)
+	if len(digits) != 13 {
+		return NationalIDReasonLength
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return NationalIDReasonLength
+		}
+		if i < 12 {
+			sum += int(digits[i]-'0') * (13 - i)
+		}
+	}
+	if int(digits[12]-'0') != (11-sum%11)%10 {
+		return NationalIDReasonChecksum
+	}
+	return ""
+}
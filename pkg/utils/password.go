@@ -2,13 +2,18 @@ package utils
 
 import (
 	"log"
+	"sync"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// BcryptCost is the work factor used by HashPassword.
+// Unit tests lower it to bcrypt.MinCost to keep hashing fast.
+var BcryptCost = bcrypt.DefaultCost
+
 // HashPassword generates a bcrypt hash of the password.
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost)
 	if err != nil {
 		log.Printf("Error hashing password: %v", err)
 		return "", err
@@ -21,3 +26,24 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil // Returns true if password matches hash, false otherwise
 }
+
+// dummyPasswordHash is compared against by CheckPasswordHashDummy. It is generated on first use so it
+// has the same cost as real hashes, including when tests lower BcryptCost.
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// CheckPasswordHashDummy spends as long as CheckPasswordHash and always fails. Call it when there is
+// no account to check against, so response timing doesn't reveal which usernames exist.
+func CheckPasswordHashDummy(password string) bool {
+	dummyPasswordHashOnce.Do(func() {
+		var err error
+		dummyPasswordHash, err = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), BcryptCost)
+		if err != nil {
+			log.Printf("Error generating dummy password hash: %v", err)
+		}
+	})
+	bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+	return false
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"fmt"
+	"hospital-middleware/internal/config"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ValidatePassword checks a password against the policy and returns every rule it violates.
+// An empty result means the password is acceptable.
+func ValidatePassword(password string, policy config.PasswordPolicy) []string {
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSpecial = true
+		}
+	}
+
+	violations := []string{}
+	if policy.MinLength > 0 && utf8.RuneCountInString(password) < policy.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", policy.MinLength))
+	}
+	if policy.RequireUppercase && !hasUpper {
+		violations = append(violations, "must contain at least one uppercase letter")
+	}
+	if policy.RequireLowercase && !hasLower {
+		violations = append(violations, "must contain at least one lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		violations = append(violations, "must contain at least one digit")
+	}
+	if policy.RequireSpecialChar && !hasSpecial {
+		violations = append(violations, "must contain at least one special character")
+	}
+	return violations
+}
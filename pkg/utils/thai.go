@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// thaiToneMarks drops the tone marks and maitaikhu, so names match however they were marked.
+var thaiToneMarks = strings.NewReplacer(
+	"\u0e47", "", // Maitaikhu
+	"\u0e48", "", // Mai ek
+	"\u0e49", "", // Mai tho
+	"\u0e4a", "", // Mai tri
+	"\u0e4b", "", // Mai chattawa
+)
+
+// thaiVowelVariants maps vowels commonly typed as two characters to their single-character form.
+// Applied after tone marks are dropped, since those are often typed between the two.
+var thaiVowelVariants = strings.NewReplacer(
+	"\u0e4d\u0e32", "\u0e33", // Nikhahit + sara aa -> sara am
+	"\u0e40\u0e40", "\u0e41", // Sara e twice -> sara ae
+)
+
+// NormalizeThai returns s in Unicode NFC, so the same Thai text typed with its combining marks
+// in a different order compares equal.
+func NormalizeThai(s string) string {
+	return norm.NFC.String(s)
+}
+
+// FoldThai normalizes s and removes the spelling differences that are easy to get wrong when
+// typing a name: tone marks are dropped and two-character vowel spellings are merged.
+// Only for matching; folded text is not meant to be shown.
+func FoldThai(s string) string {
+	return thaiVowelVariants.Replace(thaiToneMarks.Replace(NormalizeThai(s)))
+}
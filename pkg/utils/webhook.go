@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignPayload returns the signature sent with a webhook delivery: "sha256=" followed by the
+// lowercase hex HMAC-SHA256 digest of body, keyed with the webhook's secret.
+//
+// To validate a delivery, the receiver computes the same digest over the raw request body, exactly
+// as received and before any JSON parsing, using the secret it was given when the webhook was
+// created. It then compares the result with the X-Hospital-Signature-256 header using a
+// constant-time comparison, such as hmac.Equal in Go or secure_compare in Ruby, and never a plain
+// ==. A delivery whose signature does not match should be rejected.
+//
+// For example, with the secret "It's a Secret to Everybody" and the body "Hello, World!", the
+// header is:
+//
+//	X-Hospital-Signature-256: sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
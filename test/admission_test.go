@@ -0,0 +1,113 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// cleanupAdmissions removes all admissions recorded for a patient once the test ends.
+func cleanupAdmissions(t *testing.T, patientID uint) {
+	t.Cleanup(func() {
+		log.Printf("Cleaning up admissions for patient ID: %d", patientID)
+		if err := testDB.Where("patient_id = ?", patientID).Delete(&models.Admission{}).Error; err != nil {
+			log.Printf("Error cleaning up admissions for patient %d: %v", patientID, err)
+		}
+	})
+}
+
+func TestAdmissionHandlers_AdmitAndDischarge(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupAdmissions(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_admit"), "password123", "Hospital A")
+	admissionsURL := fmt.Sprintf("/api/v1/patient/%d/admissions", testPatient.ID)
+
+	// 1. Admit the patient
+	rr := performRequest(testRouter, "POST", admissionsURL, gin.H{"ward": "ICU", "bed_number": "3"}, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var admission models.Admission
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &admission))
+	assert.Equal(t, models.AdmissionStatusAdmitted, admission.Status)
+	assert.Equal(t, testPatient.HospitalID, admission.HospitalID)
+	assert.NotZero(t, admission.AdmittingStaffID)
+
+	// 2. A second admission is refused while the first is active
+	rr = performRequest(testRouter, "POST", admissionsURL, gin.H{"ward": "Surgery"}, authToken)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// 3. Discharge, then discharging again conflicts
+	dischargeURL := fmt.Sprintf("%s/%d/discharge", admissionsURL, admission.ID)
+	rr = performRequest(testRouter, "PUT", dischargeURL, gin.H{"discharge_reason": "Recovered"}, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var discharged models.Admission
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &discharged))
+	assert.Equal(t, models.AdmissionStatusDischarged, discharged.Status)
+	assert.NotNil(t, discharged.DischargedAt)
+	rr = performRequest(testRouter, "PUT", dischargeURL, gin.H{"discharge_reason": "Recovered"}, authToken)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// 4. Once discharged the patient can be admitted again
+	rr = performRequest(testRouter, "POST", admissionsURL, gin.H{"ward": "Surgery"}, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	rr = performRequest(testRouter, "GET", admissionsURL, nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var page struct {
+		Data  []models.Admission `json:"data"`
+		Total int64              `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, int64(2), page.Total)
+	if assert.Len(t, page.Data, 2) {
+		assert.Equal(t, "Surgery", page.Data[0].Ward, "Expected newest admission first")
+	}
+}
+
+func TestAdmissionHandlers_WardCensus(t *testing.T) {
+	ward := uniqueUsername("ward")
+	inWard := createTestPatient(1)
+	seedPatient(t, inWard)
+	cleanupAdmissions(t, inWard.ID)
+	dischargedPatient := createTestPatient(1)
+	seedPatient(t, dischargedPatient)
+	cleanupAdmissions(t, dischargedPatient.ID)
+	otherHospital := createTestPatient(2)
+	seedPatient(t, otherHospital)
+	cleanupAdmissions(t, otherHospital.ID)
+
+	tokenA := getAuthToken(t, uniqueUsername("staff_ward_a"), "password123", "Hospital A")
+	tokenB := getAuthToken(t, uniqueUsername("staff_ward_b"), "password123", "Hospital B")
+	admit := func(patient *models.Patient, token string) models.Admission {
+		rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/admissions", patient.ID), gin.H{"ward": ward}, token)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var admission models.Admission
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &admission))
+		return admission
+	}
+	admit(inWard, tokenA)
+	gone := admit(dischargedPatient, tokenA)
+	admit(otherHospital, tokenB)
+	rr := performRequest(testRouter, "PUT", fmt.Sprintf("/api/v1/patient/%d/admissions/%d/discharge", dischargedPatient.ID, gone.ID),
+		gin.H{"discharge_reason": "Transferred home"}, tokenA)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// Only Hospital A's still-admitted patient is in its census of the ward
+	rr = performRequest(testRouter, "GET", "/api/v1/ward/"+ward+"/current", nil, tokenA)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var census []models.WardCensusEntry
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &census))
+	if assert.Len(t, census, 1) {
+		assert.Equal(t, inWard.ID, census[0].Patient.ID)
+		assert.Equal(t, inWard.PatientHN, census[0].Patient.PatientHN)
+		assert.Nil(t, census[0].Admission.DischargedAt)
+	}
+}
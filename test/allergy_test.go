@@ -0,0 +1,115 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cleanupAllergies removes all allergies recorded for a patient once the test ends.
+func cleanupAllergies(t *testing.T, patientID uint) {
+	t.Cleanup(func() {
+		log.Printf("Cleaning up allergies for patient ID: %d", patientID)
+		if err := testDB.Where("patient_id = ?", patientID).Delete(&models.Allergy{}).Error; err != nil {
+			log.Printf("Error cleaning up allergies for patient %d: %v", patientID, err)
+		}
+	})
+}
+
+func TestAllergyHandlers_UpsertIsCaseInsensitive(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupAllergies(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_allergy"), "password123", "Hospital A")
+	allergiesURL := fmt.Sprintf("/api/v1/patient/%d/allergies", testPatient.ID)
+
+	// 1. First entry is created
+	rr := performRequest(testRouter, "POST", allergiesURL, models.AllergyRequest{Substance: "Penicillin", Reaction: "Rash", Severity: "mild"}, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	// 2. Same substance with different casing updates the existing entry
+	rr = performRequest(testRouter, "POST", allergiesURL, models.AllergyRequest{Substance: "PENICILLIN", Reaction: "Anaphylaxis", Severity: "severe"}, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// 3. Only one allergy exists, with the updated severity
+	rr = performRequest(testRouter, "GET", allergiesURL, nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var allergies []models.Allergy
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &allergies))
+	if assert.Len(t, allergies, 1) {
+		assert.Equal(t, "severe", allergies[0].Severity)
+		assert.Equal(t, "Anaphylaxis", allergies[0].Reaction)
+	}
+}
+
+func TestAllergyHandlers_InvalidSeverity(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupAllergies(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_allergy_bad"), "password123", "Hospital A")
+
+	body := map[string]string{"substance": "Latex", "severity": "catastrophic"}
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/allergies", testPatient.ID), body, authToken)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAllergyHandlers_UpdateAndDelete(t *testing.T) {
+	testPatient := createTestPatient(2)
+	seedPatient(t, testPatient)
+	cleanupAllergies(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_allergy_crud"), "password123", "Hospital B")
+	allergiesURL := fmt.Sprintf("/api/v1/patient/%d/allergies", testPatient.ID)
+
+	rr := performRequest(testRouter, "POST", allergiesURL, models.AllergyRequest{Substance: "Peanuts", Severity: "moderate"}, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created models.Allergy
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+
+	itemURL := fmt.Sprintf("%s/%d", allergiesURL, created.ID)
+	rr = performRequest(testRouter, "PUT", itemURL, models.AllergyRequest{Substance: "Peanuts", Severity: "severe"}, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"severity":"severe"`)
+
+	rr = performRequest(testRouter, "DELETE", itemURL, nil, authToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = performRequest(testRouter, "DELETE", itemURL, nil, authToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetPatientHandler_IncludeAllergies(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupAllergies(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_patient_get"), "password123", "Hospital A")
+
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/allergies", testPatient.ID),
+		models.AllergyRequest{Substance: "Aspirin", Severity: "moderate"}, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	// Without include, allergies are not embedded
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d", testPatient.ID), nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "allergies")
+
+	// With include=allergies they are
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d?include=allergies", testPatient.ID), nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var detail struct {
+		ID        uint             `json:"id"`
+		Allergies []models.Allergy `json:"allergies"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &detail))
+	assert.Equal(t, testPatient.ID, detail.ID)
+	if assert.Len(t, detail.Allergies, 1) {
+		assert.Equal(t, "Aspirin", detail.Allergies[0].Substance)
+	}
+}
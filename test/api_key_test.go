@@ -0,0 +1,94 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// createAPIKey issues an API key through the admin endpoint and returns it with its ID.
+func createAPIKey(t *testing.T, adminToken string, body gin.H) (uint, string) {
+	t.Helper()
+	rr := performRequest(testRouter, "POST", "/api/v1/admin/api-keys", body, adminToken)
+	if !assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var created models.APIKeyCreateResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	t.Cleanup(func() { testDB.Delete(&models.APIKey{}, created.ID) })
+	return created.ID, created.Key
+}
+
+// getWithAPIKey sends a GET authenticated with an X-API-Key header.
+func getWithAPIKey(path, apiKey string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("X-API-Key", apiKey)
+	rr := httptest.NewRecorder()
+	testRouter.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAPIKey_AuthenticateAndRevoke(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_apikey"), "password123", "Hospital A")
+	keyID, apiKey := createAPIKey(t, adminToken, gin.H{"description": "billing"})
+	patientURL := fmt.Sprintf("/api/v1/patient/%d", patient.ID)
+
+	// The key works for its own hospital and records when it was used
+	rr := getWithAPIKey(patientURL, apiKey)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var stored models.APIKey
+	assert.NoError(t, testDB.First(&stored, keyID).Error)
+	assert.NotNil(t, stored.LastUsedAt)
+	assert.NotContains(t, stored.KeyHash, apiKey)
+
+	// Patients of other hospitals stay out of reach
+	other := createTestPatient(2)
+	seedPatient(t, other)
+	rr = getWithAPIKey(fmt.Sprintf("/api/v1/patient/%d", other.ID), apiKey)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Revoked keys are rejected
+	rr = performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/admin/api-keys/%d", keyID), nil, adminToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = getWithAPIKey(patientURL, apiKey)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "API key has been revoked")
+}
+
+func TestAPIKey_ExpiredRejected(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_apikey_exp"), "password123", "Hospital A")
+	keyID, apiKey := createAPIKey(t, adminToken, gin.H{"description": "nightly export", "expires_at": time.Now().Add(time.Hour)})
+
+	// Move the expiry into the past rather than waiting for it
+	assert.NoError(t, testDB.Model(&models.APIKey{}).Where("id = ?", keyID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	rr := getWithAPIKey(fmt.Sprintf("/api/v1/patient/%d", patient.ID), apiKey)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "API key has expired")
+}
+
+func TestAPIKey_RevokeOtherHospitalKeyNotFound(t *testing.T) {
+	adminA := getAdminAuthToken(t, uniqueUsername("admin_apikey_a"), "password123", "Hospital A")
+	adminB := getAdminAuthToken(t, uniqueUsername("admin_apikey_b"), "password123", "Hospital B")
+	keyID, apiKey := createAPIKey(t, adminA, gin.H{"description": "billing"})
+
+	rr := performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/admin/api-keys/%d", keyID), nil, adminB)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Still usable
+	rr = getWithAPIKey("/api/v1/icd10?q=dengue", apiKey)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
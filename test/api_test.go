@@ -1,3 +1,5 @@
+//go:build integration
+
 package test
 
 import (
@@ -9,15 +11,16 @@ import (
 	"hospital-middleware/internal/database"
 	"hospital-middleware/internal/models"
 	"hospital-middleware/internal/services"
+	"hospital-middleware/internal/storage"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time" // Import time for unique usernames
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm" // Import gorm
 )
@@ -27,68 +30,93 @@ var testRouter *gin.Engine
 // var testToken string // Store token for authenticated tests
 var testDB *gorm.DB // Make DB instance accessible
 
+// testConfig is the configuration the test database was opened with.
+var testConfig *config.Config
+
 // Helper function to generate unique usernames for tests
 func uniqueUsername(prefix string) string {
 	return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
 }
 
+// newTestConfig builds the application config for the test run programmatically,
+// so no .env file is needed.
+func newTestConfig(container *postgresContainer) *config.Config {
+	return &config.Config{
+		DBHost:      container.Host,
+		DBPort:      container.Port,
+		DBUser:      containerDBUser,
+		DBPassword:  containerDBPassword,
+		DBName:      containerDBName,
+		DBSSLMode:   "disable",
+		AutoMigrate: true, // Migrate the schema the way the server does
+		Timezone:    config.DefaultTimezone,
+		JWTSecret:   "integration_test_secret_key_not_for_production",
+		JWTExpiry:   time.Hour,
+		ServerPort:  "8080",
+		AppEnv:      config.AppEnvTest,
+
+		DocumentMaxBytes:       1 << 20,
+		DefaultPageSize:        20,
+		MaxPageSize:            100,
+		SearchMaxResults:       1000,
+		NationalIDValidateMax:  500,
+		AccessLogSearchResults: true,
+	}
+}
+
 // Setup runs before all tests in the package
 func TestMain(m *testing.M) {
 	log.Println("Setting up test environment...")
 
-	// Load .env file from the parent directory (project root)
-	// Adjust path if your test file is nested deeper
-	err := godotenv.Load("../.env")
-	if err != nil {
-		// Try loading from current directory if ../.env fails (e.g., running tests from root)
-		err = godotenv.Load(".env")
-		if err != nil {
-			log.Printf("Warning: Could not load .env file: %v. Relying on environment variables.", err)
-		} else {
-			log.Println("Test environment variables loaded from ./ .env")
-		}
-	} else {
-		log.Println("Test environment variables loaded from ../.env")
-	}
-
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
 
-	// Load test configuration
-	cfg, err := config.Load()
+	// Start a dedicated PostgreSQL container for this run
+	container, err := startPostgresContainer()
 	if err != nil {
-		log.Fatalf("Failed to load config for testing: %v", err)
+		log.Fatalf("Failed to start test database container: %v", err)
 	}
-	log.Printf("Test Config Loaded: DB_HOST=%s, DB_PORT=%s, DB_NAME=%s, DB_USER=%s", cfg.DBHost, cfg.DBPort, cfg.DBName, cfg.DBUser)
 
-	// Connect to the test database
+	cfg := newTestConfig(container)
+	testConfig = cfg
+	log.Printf("Test Config: DB_HOST=%s, DB_PORT=%s, DB_NAME=%s, DB_USER=%s", cfg.DBHost, cfg.DBPort, cfg.DBName, cfg.DBUser)
+
+	// Connect migrates exactly as a server start does: AutoMigrate, then the migration files
 	if err := database.Connect(cfg); err != nil {
+		container.Terminate()
 		log.Fatalf("Failed to connect to test database: %v", err)
 	}
 	testDB = database.GetDB() // Store DB instance
 
-	// --- Optional: Clean database before starting test suite ---
-	// log.Println("Cleaning test database before suite...")
-	// CleanTestData(testDB) // Implement or uncomment this if needed
-	// ---------------------------------------------------------
-
 	// Initialize services
-	services.InitializeAuthService(cfg)
+	if err := services.InitializeAuthService(cfg); err != nil {
+		container.Terminate()
+		log.Fatalf("Failed to initialize auth service: %v", err)
+	}
+
+	// Documents go to a temporary directory that is removed after the run
+	blobDir, err := os.MkdirTemp("", "integration-blobs-*")
+	if err != nil {
+		container.Terminate()
+		log.Fatalf("Failed to create document storage directory: %v", err)
+	}
+	blobs, err := storage.NewLocalDiskStore(blobDir)
+	if err != nil {
+		container.Terminate()
+		log.Fatalf("Failed to initialize document storage: %v", err)
+	}
 
 	// Setup router
-	testRouter = api.SetupRouter()
+	repo := database.NewPostgresRepository()
+	testRouter = api.SetupRouter(repo, blobs, cfg, services.NewAsyncWriters(repo))
 
 	// Run tests
 	exitCode := m.Run()
 
-	// --- Optional: Clean database after test suite ---
-	// log.Println("Cleaning test database after suite...")
-	// CleanTestData(testDB) // Implement or uncomment this if needed
-	// ----------------------------------------------------
-
-	// Teardown (optional)
+	// Teardown: the whole database goes away with the container
 	log.Println("Tearing down test environment...")
-	// Close DB connection if necessary (GORM might handle pooling)
+	container.Terminate()
+	os.RemoveAll(blobDir)
 
 	os.Exit(exitCode)
 }
@@ -189,6 +217,10 @@ func TestCreateStaffHandler_DuplicateUsername(t *testing.T) {
 
 	assert.Equal(t, http.StatusConflict, rrDuplicate.Code)
 	assert.Contains(t, rrDuplicate.Body.String(), "Username already exists")
+
+	// The public route does not say who holds the username
+	assert.NotContains(t, rrDuplicate.Body.String(), "existing_staff")
+	assert.NotContains(t, rrDuplicate.Body.String(), "password")
 }
 
 func TestCreateStaffHandler_BadData(t *testing.T) {
@@ -202,6 +234,59 @@ func TestCreateStaffHandler_BadData(t *testing.T) {
 	assert.Contains(t, rr.Body.String(), "Invalid request body") // Or more specific binding error
 }
 
+func TestCreateStaff_RejectsUnknownHospitalID(t *testing.T) {
+	username := uniqueUsername("orphan")
+	t.Cleanup(func() {
+		testDB.Unscoped().Where("username = ?", username).Delete(&models.Staff{})
+	})
+
+	err := database.CreateStaff(&models.Staff{Username: username, PasswordHash: "x", HospitalID: 999999, HospitalName: "Nowhere"})
+
+	assert.True(t, database.IsForeignKeyViolation(err), "expected a foreign key violation, got %v", err)
+}
+
+func TestCreateStaffHandler_RejectsCaseVariantUsername(t *testing.T) {
+	username := uniqueUsername("Case_Create")
+	t.Cleanup(func() {
+		testDB.Unscoped().Where("username_normalized = ?", strings.ToLower(username)).Delete(&models.Staff{})
+	})
+	staffData := models.StaffCreateRequest{Username: username, Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(testRouter, "POST", "/api/v1/staff/create", staffData, "")
+	if !assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+
+	staffData.Username = strings.ToLower(username)
+	rr = performRequest(testRouter, "POST", "/api/v1/staff/create", staffData, "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), username, "the conflict names the existing account in its own casing")
+
+	// The unique index holds even when the handler's check is bypassed
+	err := database.CreateStaff(&models.Staff{Username: strings.ToUpper(username), PasswordHash: "x", HospitalID: 1, HospitalName: "Hospital A"})
+	assert.True(t, database.IsUniqueViolation(err), "expected a unique violation, got %v", err)
+}
+
+func TestLoginStaffHandler_IgnoresUsernameCase(t *testing.T) {
+	username := uniqueUsername("Case_Login")
+	t.Cleanup(func() {
+		testDB.Unscoped().Where("username = ?", username).Delete(&models.Staff{})
+	})
+	staffData := models.StaffCreateRequest{Username: username, Password: "password123", Hospital: "Hospital A"}
+	if rr := performRequest(testRouter, "POST", "/api/v1/staff/create", staffData, ""); rr.Code != http.StatusCreated {
+		t.Fatalf("creating staff: %d %s", rr.Code, rr.Body.String())
+	}
+
+	for _, typed := range []string{username, strings.ToLower(username), strings.ToUpper(username)} {
+		loginData := models.StaffLoginRequest{Username: typed, Password: "password123", Hospital: "Hospital A"}
+		rr := performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
+		if assert.Equal(t, http.StatusOK, rr.Code, typed) {
+			var loginResponse models.StaffLoginResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &loginResponse))
+			assert.Equal(t, username, loginResponse.Staff.Username, "the display casing is kept")
+		}
+	}
+}
+
 func TestLoginStaffHandler_Success(t *testing.T) {
 	// 1. Ensure the user exists (create if necessary)
 	username := uniqueUsername("testuser_login") // Use unique username
@@ -291,7 +376,7 @@ func TestLoginStaffHandler_WrongHospital(t *testing.T) {
 	username := uniqueUsername("testuser_wronghosp")
 	password := "password123"
 	correctHospital := "Hospital B"
-	wrongHospital := "Hospital C" // Non-existent or wrong hospital
+	wrongHospital := "Hospital A" // Known hospital, but not the one this user belongs to
 	staffData := models.StaffCreateRequest{Username: username, Password: password, Hospital: correctHospital}
 
 	t.Cleanup(func() {
@@ -317,8 +402,39 @@ func TestLoginStaffHandler_WrongHospital(t *testing.T) {
 	rrLogin := performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
 
 	assert.Equal(t, http.StatusUnauthorized, rrLogin.Code)
-	// The error might be "invalid hospital specified" or "invalid hospital for this user" depending on implementation
-	assert.Contains(t, rrLogin.Body.String(), "error verifying hospital")
+	assert.Contains(t, rrLogin.Body.String(), "invalid username or password")
+}
+
+func TestLoginStaffHandler_UnknownHospital(t *testing.T) {
+	// 1. Create user
+	username := uniqueUsername("testuser_unknownhosp")
+	password := "password123"
+	staffData := models.StaffCreateRequest{Username: username, Password: password, Hospital: "Hospital B"}
+
+	t.Cleanup(func() {
+		log.Printf("Cleaning up staff: %s", username)
+		err := testDB.Unscoped().Where("username = ?", username).Delete(&models.Staff{}).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			log.Printf("Error cleaning up staff %s: %v", username, err)
+		}
+	})
+
+	rrCreate := performRequest(testRouter, "POST", "/api/v1/staff/create", staffData, "")
+	assert.Equal(t, http.StatusCreated, rrCreate.Code, "Setup: User creation for unknown hospital test failed")
+	if rrCreate.Code != http.StatusCreated {
+		t.FailNow()
+	}
+
+	// 2. Attempt login with a hospital that doesn't exist at all
+	loginData := models.StaffLoginRequest{
+		Username: username,
+		Password: password,
+		Hospital: "Hospital C",
+	}
+	rrLogin := performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusBadRequest, rrLogin.Code)
+	assert.Contains(t, rrLogin.Body.String(), "unknown hospital")
 }
 
 // --- Auth Required Tests ---
@@ -360,6 +476,48 @@ func getAuthToken(t *testing.T, username, password, hospital string) string {
 	return loginResponse.Token
 }
 
+// createIsolatedHospital adds a hospital with a unique code so a test can count rows without
+// interference from data other tests put in the seeded hospitals. Its staff are removed with it.
+func createIsolatedHospital(t *testing.T, codePrefix string) models.Hospital {
+	t.Helper()
+	code := fmt.Sprintf("%s%d", codePrefix, time.Now().UnixNano()%1000000)
+	hospital := models.Hospital{Name: "Test Hospital " + code, Code: code}
+	if err := testDB.Create(&hospital).Error; err != nil {
+		t.Fatalf("Setup failed: Could not create hospital %s: %v", code, err)
+	}
+	t.Cleanup(func() {
+		testDB.Unscoped().Where("hospital_id = ?", hospital.ID).Delete(&models.Staff{})
+		testDB.Delete(&hospital)
+	})
+	return hospital
+}
+
+// Helper to get a token for an admin.
+func getAdminAuthToken(t *testing.T, username, password, hospital string) string {
+	return getRoleAuthToken(t, username, password, hospital, models.RoleAdmin)
+}
+
+// Helper to get a token for a given role. Staff are always created with the "staff" role,
+// so the helper updates the role directly in the DB before logging in again.
+func getRoleAuthToken(t *testing.T, username, password, hospital, role string) string {
+	getAuthToken(t, username, password, hospital) // Creates the user and registers cleanup
+
+	if err := testDB.Model(&models.Staff{}).Where("username = ?", username).Update("role", role).Error; err != nil {
+		t.Fatalf("Setup failed: Could not give %s the %s role: %v", username, role, err)
+	}
+
+	loginData := models.StaffLoginRequest{Username: username, Password: password, Hospital: hospital}
+	rrLogin := performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
+	if rrLogin.Code != http.StatusOK {
+		t.Fatalf("Setup failed: Could not log in %s %s: %s", role, username, rrLogin.Body.String())
+	}
+	var loginResponse models.StaffLoginResponse
+	if err := json.Unmarshal(rrLogin.Body.Bytes(), &loginResponse); err != nil {
+		t.Fatalf("Setup failed: Could not decode %s login response: %v", role, err)
+	}
+	return loginResponse.Token
+}
+
 func TestSearchPatientHandler_Unauthorized(t *testing.T) {
 	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?first_name_en=Test", nil, "") // No token
 
@@ -377,9 +535,17 @@ func TestSearchPatientHandler_Authorized_NoResults(t *testing.T) {
 	// Use query parameters matching the PatientSearchQuery struct fields (e.g., first_name_en)
 	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?first_name_en=NoSuchPatientXYZ123", nil, authToken)
 
-	assert.Equal(t, http.StatusOK, rr.Code) // Should return 200 OK with empty list
-	bodyString := rr.Body.String()
-	assert.True(t, bodyString == "[]" || bodyString == "null", "Expected empty array '[]' or 'null', got: %s", bodyString) // Allow [] or null
+	assert.Equal(t, http.StatusOK, rr.Code) // Should return 200 OK with an empty list
+	assert.JSONEq(t, `{"data":[],"count":0,"truncated":false}`, rr.Body.String())
+}
+
+func TestSearchPatientHandler_RequiresACriterion(t *testing.T) {
+	authToken := getAuthToken(t, uniqueUsername("testuser_search_empty"), "password123", "Hospital A")
+
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search", nil, authToken)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "at least one search criterion required")
 }
 
 // --- Helper for Cleaning ---
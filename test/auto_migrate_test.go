@@ -0,0 +1,71 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// connectWithoutMigrating connects to dbName of the test container with AUTO_MIGRATE off and
+// reconnects to the test database after the test.
+func connectWithoutMigrating(t *testing.T, dbName string) error {
+	t.Helper()
+	cfg := *testConfig
+	cfg.DBName = dbName
+	cfg.AutoMigrate = false
+	t.Cleanup(func() {
+		if err := database.Open(testConfig); err != nil {
+			t.Fatalf("Failed to reopen the test database: %v", err)
+		}
+	})
+	return database.Connect(&cfg)
+}
+
+func TestConnect_AutoMigrateOffAcceptsMigratedDatabase(t *testing.T) {
+	assert.NoError(t, connectWithoutMigrating(t, testConfig.DBName))
+}
+
+func TestConnect_AutoMigrateOffRejectsUnmigratedDatabase(t *testing.T) {
+	empty := fmt.Sprintf("unmigrated_%d", time.Now().UnixNano())
+	if err := testDB.Exec("CREATE DATABASE " + empty).Error; err != nil {
+		t.Fatalf("Failed to create database %s: %v", empty, err)
+	}
+	t.Cleanup(func() { testDB.Exec("DROP DATABASE IF EXISTS " + empty + " WITH (FORCE)") })
+
+	err := connectWithoutMigrating(t, empty)
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "AUTO_MIGRATE is off")
+		assert.Contains(t, err.Error(), "patients")
+		assert.Contains(t, err.Error(), "seed command")
+	}
+	var tables int64
+	database.GetDB().Raw("SELECT count(*) FROM information_schema.tables WHERE table_schema = 'public'").Scan(&tables)
+	assert.Zero(t, tables, "nothing was migrated")
+}
+
+func TestRollbackMigration_StopsAtBaseline(t *testing.T) {
+	// Put back whatever the test rolls back, for the tests that follow
+	t.Cleanup(func() {
+		if _, err := database.ApplyMigrations(testDB); err != nil {
+			t.Fatalf("Failed to reapply migrations: %v", err)
+		}
+	})
+
+	var err error
+	for i := 0; i < 100 && err == nil; i++ {
+		_, err = database.RollbackMigration(testDB, false)
+	}
+
+	assert.ErrorIs(t, err, database.ErrIrreversibleMigration)
+	var versions []int
+	testDB.Model(&models.SchemaMigration{}).Pluck("version", &versions)
+	assert.Equal(t, []int{1}, versions, "the baseline stays applied")
+	assert.True(t, testDB.Migrator().HasIndex("allergies", "idx_allergies_patient_substance"))
+}
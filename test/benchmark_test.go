@@ -0,0 +1,253 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Benchmarks run against a dedicated hospital so seeded rows never show up in the
+// functional tests. Run with:
+//
+//	go test -tags integration ./test/ -run '^$' -bench SearchPatients -benchmem
+const benchSeedCount = 10000
+
+var (
+	benchSeedOnce   sync.Once
+	benchHospitalID uint
+	benchSeedErr    error
+)
+
+// Name pools for generated patients. A small pool gives realistic partial-match hit counts.
+var (
+	benchFirstNamesEN = []string{"Somchai", "Somying", "Ekachai", "Duangjai", "Thongchai", "Arunee", "Wichai", "Supa", "Kasem", "Chanpen"}
+	benchLastNamesEN  = []string{"Jaidii", "Ngam", "Sukjai", "Chan", "Sabai", "Deengam", "Jaiyen", "Sri", "Munkong", "Suk"}
+)
+
+// seedBenchmarkPatients inserts benchSeedCount patients into the benchmark hospital once per run.
+func seedBenchmarkPatients(b *testing.B) uint {
+	b.Helper()
+	benchSeedOnce.Do(func() {
+		hospital := models.Hospital{Name: "Benchmark Hospital", Code: "BENCH"}
+		if benchSeedErr = testDB.Where(models.Hospital{Code: hospital.Code}).FirstOrCreate(&hospital).Error; benchSeedErr != nil {
+			return
+		}
+		benchHospitalID = hospital.ID
+
+		var existing int64
+		testDB.Model(&models.Patient{}).Where("hospital_id = ?", benchHospitalID).Count(&existing)
+		if existing >= benchSeedCount {
+			return // Already seeded by a previous run against the same database
+		}
+
+		log.Printf("Seeding %d benchmark patients into hospital %d...", benchSeedCount, benchHospitalID)
+		baseDOB := time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)
+		patients := make([]models.Patient, 0, benchSeedCount)
+		for i := 0; i < benchSeedCount; i++ {
+			dob := baseDOB.AddDate(0, 0, i%15000)
+			patients = append(patients, models.Patient{
+				HospitalID:  benchHospitalID,
+				PatientHN:   fmt.Sprintf("BENCH%06d", i),
+				FirstNameTH: "ทดสอบ",
+				LastNameTH:  "นามสกุล",
+				FirstNameEN: benchFirstNamesEN[i%len(benchFirstNamesEN)],
+				LastNameEN:  benchLastNamesEN[(i/len(benchFirstNamesEN))%len(benchLastNamesEN)],
+				DateOfBirth: &dob,
+				NationalID:  fmt.Sprintf("9%012d", i),
+				PhoneNumber: fmt.Sprintf("08%08d", i),
+				Email:       fmt.Sprintf("bench%d@example.com", i),
+				Gender:      "F",
+			})
+		}
+		benchSeedErr = testDB.CreateInBatches(patients, 1000).Error
+	})
+	if benchSeedErr != nil {
+		b.Fatalf("Failed to seed benchmark patients: %v", benchSeedErr)
+	}
+	return benchHospitalID
+}
+
+func strPtr(s string) *string { return &s }
+
+// runParallelSearch runs SearchPatients concurrently with the given query.
+func runParallelSearch(b *testing.B, query *models.PatientSearchQuery) {
+	hospitalID := seedBenchmarkPatients(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := database.SearchPatients(query, hospitalID, 0); err != nil {
+				b.Errorf("SearchPatients failed: %v", err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkSearchPatients_ByNationalID(b *testing.B) {
+	runParallelSearch(b, &models.PatientSearchQuery{NationalID: strPtr(fmt.Sprintf("9%012d", benchSeedCount/2))})
+}
+
+func BenchmarkSearchPatients_ByNameEN(b *testing.B) {
+	// Partial match on a pooled name: roughly a tenth of the hospital's patients
+	runParallelSearch(b, &models.PatientSearchQuery{FirstNameEN: strPtr("Somch")})
+}
+
+func BenchmarkSearchPatients_MultiField(b *testing.B) {
+	runParallelSearch(b, &models.PatientSearchQuery{
+		FirstNameEN: strPtr("Wichai"),
+		LastNameEN:  strPtr("Jaiyen"),
+		DateOfBirth: strPtr("1960-01-07"),
+	})
+}
+
+// BenchmarkSearchPatients_Pagination compares returning every match, as a search without page
+// or page_size does up to the result limit, against fetching one page plus a total count.
+func BenchmarkSearchPatients_Pagination(b *testing.B) {
+	hospitalID := seedBenchmarkPatients(b)
+	query := &models.PatientSearchQuery{LastNameEN: strPtr("a")} // Broad match across most rows
+
+	b.Run("FullScan", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := database.SearchPatients(query, hospitalID, 0); err != nil {
+					b.Errorf("SearchPatients failed: %v", err)
+					return
+				}
+			}
+		})
+	})
+
+	b.Run("Page20", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, _, err := database.SearchPatientsPage(query, hospitalID, 0, 20); err != nil {
+					b.Errorf("SearchPatientsPage failed: %v", err)
+					return
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkExportPatients compares loading every patient of the hospital into one slice, as the
+// JSON export does, with the row-at-a-time cursor behind format=ndjson. Per-op allocations are
+// similar; the difference is that the cursor never holds more than one row.
+func BenchmarkExportPatients(b *testing.B) {
+	hospitalID := seedBenchmarkPatients(b)
+	query := &models.PatientSearchQuery{}
+
+	b.Run("Slice", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := database.SearchPatients(query, hospitalID, 0); err != nil {
+				b.Fatalf("SearchPatients failed: %v", err)
+			}
+		}
+	})
+
+	b.Run("Stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			err := database.StreamPatients(context.Background(), query, hospitalID, func(*models.Patient) error { return nil })
+			if err != nil {
+				b.Fatalf("StreamPatients failed: %v", err)
+			}
+		}
+	})
+}
+
+// Phone suffix search gets its own, larger hospital so the index-vs-scan gap is visible.
+const benchPhoneSeedCount = 100000
+
+var (
+	benchPhoneSeedOnce   sync.Once
+	benchPhoneHospitalID uint
+	benchPhoneSeedErr    error
+)
+
+// seedPhoneBenchmarkPatients inserts benchPhoneSeedCount patients with distinct phone numbers once per run.
+func seedPhoneBenchmarkPatients(b *testing.B) uint {
+	b.Helper()
+	benchPhoneSeedOnce.Do(func() {
+		hospital := models.Hospital{Name: "Phone Benchmark Hospital", Code: "BENCHPHONE"}
+		if benchPhoneSeedErr = testDB.Where(models.Hospital{Code: hospital.Code}).FirstOrCreate(&hospital).Error; benchPhoneSeedErr != nil {
+			return
+		}
+		benchPhoneHospitalID = hospital.ID
+
+		var existing int64
+		testDB.Model(&models.Patient{}).Where("hospital_id = ?", benchPhoneHospitalID).Count(&existing)
+		if existing >= benchPhoneSeedCount {
+			return
+		}
+
+		log.Printf("Seeding %d phone benchmark patients into hospital %d...", benchPhoneSeedCount, benchPhoneHospitalID)
+		patients := make([]models.Patient, 0, benchPhoneSeedCount)
+		for i := 0; i < benchPhoneSeedCount; i++ {
+			patients = append(patients, models.Patient{
+				HospitalID:  benchPhoneHospitalID,
+				PatientHN:   fmt.Sprintf("BPHONE%06d", i),
+				FirstNameTH: "ทดสอบ",
+				LastNameTH:  "นามสกุล",
+				FirstNameEN: benchFirstNamesEN[i%len(benchFirstNamesEN)],
+				LastNameEN:  benchLastNamesEN[(i/len(benchFirstNamesEN))%len(benchLastNamesEN)],
+				PhoneNumber: fmt.Sprintf("09%08d", i*7919%100000000), // Spread suffixes instead of counting up
+				Gender:      "M",
+			})
+		}
+		benchPhoneSeedErr = testDB.CreateInBatches(patients, 1000).Error
+	})
+	if benchPhoneSeedErr != nil {
+		b.Fatalf("Failed to seed phone benchmark patients: %v", benchPhoneSeedErr)
+	}
+	return benchPhoneHospitalID
+}
+
+// BenchmarkSearchPatients_PhoneSuffix compares phone_suffix search, which matches on the
+// reversed number through idx_patients_phone_reversed, with a plain leading-wildcard LIKE.
+func BenchmarkSearchPatients_PhoneSuffix(b *testing.B) {
+	hospitalID := seedPhoneBenchmarkPatients(b)
+	suffix := "7919" // Ends the numbers of patients 1, 10001, 20001, ...: ten rows in all
+
+	b.Run("ReversedIndex", func(b *testing.B) {
+		query := &models.PatientSearchQuery{PhoneSuffix: strPtr(suffix)}
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := database.SearchPatients(query, hospitalID, 0); err != nil {
+					b.Errorf("SearchPatients failed: %v", err)
+					return
+				}
+			}
+		})
+	})
+
+	b.Run("LikeScan", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				var patients []models.Patient
+				err := testDB.Where("hospital_id = ? AND phone_number LIKE '%' || ?", hospitalID, suffix).Find(&patients).Error
+				if err != nil {
+					b.Errorf("LIKE scan failed: %v", err)
+					return
+				}
+			}
+		})
+	})
+}
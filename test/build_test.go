@@ -0,0 +1,89 @@
+//go:build integration
+
+package test
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMakeBuild_BinaryServesHealth builds the server with make build and checks that the binary
+// starts against the test database, answers /health and reports the version it was built with.
+func TestMakeBuild_BinaryServesHealth(t *testing.T) {
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make is not installed")
+	}
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(t.TempDir(), "hospital-api")
+	build := exec.Command("make", "build", "BINARY="+binary, "VERSION=1.2.3-test")
+	build.Dir = root
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("make build failed: %v\n%s", err, output)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	var logs bytes.Buffer
+	server := exec.Command(binary)
+	server.Dir = t.TempDir() // Keeps the repository's .env out of the configuration
+	server.Env = append(os.Environ(),
+		"APP_ENV=test",
+		"SERVER_PORT="+strconv.Itoa(port),
+		"DB_HOST="+testConfig.DBHost,
+		"DB_PORT="+testConfig.DBPort,
+		"DB_USER="+testConfig.DBUser,
+		"DB_PASSWORD="+testConfig.DBPassword,
+		"DB_NAME="+testConfig.DBName,
+		"DB_SSLMODE="+testConfig.DBSSLMode,
+		"AUTO_MIGRATE=false",
+		"JWT_SECRET="+testConfig.JWTSecret,
+		"DOCUMENT_STORAGE_PATH="+filepath.Join(server.Dir, "documents"),
+	)
+	server.Stdout = &logs
+	server.Stderr = &logs
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	stopped := false
+	stop := func() {
+		if !stopped {
+			stopped = true
+			server.Process.Kill()
+			server.Wait()
+		}
+	}
+	defer stop()
+
+	healthURL := "http://127.0.0.1:" + strconv.Itoa(port) + "/health"
+	deadline := time.Now().Add(30 * time.Second)
+	status := 0
+	for time.Now().Before(deadline) {
+		if resp, err := http.Get(healthURL); err == nil {
+			status = resp.StatusCode
+			resp.Body.Close()
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	stop() // The logs may only be read once the process is gone
+
+	assert.Equal(t, http.StatusOK, status, "server output:\n%s", logs.String())
+	assert.Contains(t, logs.String(), "version 1.2.3-test")
+}
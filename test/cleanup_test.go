@@ -0,0 +1,96 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"hospital-middleware/internal/background"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedClock is a background.Clock that always reports the same instant.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+// seedRow inserts a row directly, failing the test on error.
+func seedRow(t *testing.T, row interface{}) {
+	t.Helper()
+	if err := testDB.Create(row).Error; err != nil {
+		t.Fatalf("Failed to seed %T: %v", row, err)
+	}
+}
+
+// runSchedulerUntil runs the scheduler with the given tasks until done reports true, then stops it.
+func runSchedulerUntil(t *testing.T, clock background.Clock, done func() bool, tasks ...background.Task) {
+	t.Helper()
+	scheduler := background.NewScheduler(clock)
+	for _, task := range tasks {
+		scheduler.Register(task)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(stopped)
+	}()
+
+	assert.Eventually(t, done, 2*time.Second, 5*time.Millisecond)
+	cancel()
+	<-stopped
+}
+
+func TestPurgeExpiredRevokedTokens_RemovesOnlyExpired(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	expired := models.RevokedToken{JTI: uniqueUsername("expired_jti"), StaffID: 1, ExpiresAt: now.Add(-time.Minute)}
+	live := models.RevokedToken{JTI: uniqueUsername("live_jti"), StaffID: 1, ExpiresAt: now.Add(time.Hour)}
+	seedRow(t, &expired)
+	seedRow(t, &live)
+	t.Cleanup(func() {
+		testDB.Where("jti IN ?", []string{expired.JTI, live.JTI}).Delete(&models.RevokedToken{})
+	})
+
+	exists := func(jti string) bool {
+		var count int64
+		testDB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count)
+		return count > 0
+	}
+	runSchedulerUntil(t, fixedClock{now: now}, func() bool { return !exists(expired.JTI) },
+		background.PurgeExpiredRevokedTokens(database.NewPostgresRepository(), time.Millisecond))
+
+	assert.False(t, exists(expired.JTI), "expired revocation should be purged")
+	assert.True(t, exists(live.JTI), "revocation of an unexpired token must be kept")
+}
+
+func TestPurgeOldSearchHistory_RemovesOnlyOlderThanRetention(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	const retentionDays = 30
+	staffID := uint(time.Now().UnixNano() % 1_000_000_000) // Keeps these rows apart from other tests' history
+	old := models.SearchHistory{StaffID: staffID, HospitalID: 1, Query: "old", SearchedAt: now.AddDate(0, 0, -retentionDays-1)}
+	recent := models.SearchHistory{StaffID: staffID, HospitalID: 1, Query: "recent", SearchedAt: now.AddDate(0, 0, -retentionDays+1)}
+	seedRow(t, &old)
+	seedRow(t, &recent)
+	t.Cleanup(func() {
+		testDB.Where("staff_id = ?", staffID).Delete(&models.SearchHistory{})
+	})
+
+	exists := func(id uint) bool {
+		var count int64
+		testDB.Model(&models.SearchHistory{}).Where("id = ?", id).Count(&count)
+		return count > 0
+	}
+	runSchedulerUntil(t, fixedClock{now: now}, func() bool { return !exists(old.ID) },
+		background.PurgeOldSearchHistory(database.NewPostgresRepository(), retentionDays, time.Millisecond))
+
+	assert.False(t, exists(old.ID), "search history past the retention window should be purged")
+	assert.True(t, exists(recent.ID), "search history inside the retention window must be kept")
+}
@@ -0,0 +1,161 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cleanupConsents removes all consents recorded for a patient once the test ends.
+func cleanupConsents(t *testing.T, patientID uint) {
+	t.Cleanup(func() {
+		if err := testDB.Where("patient_id = ?", patientID).Delete(&models.Consent{}).Error; err != nil {
+			log.Printf("Error cleaning up consents for patient %d: %v", patientID, err)
+		}
+	})
+}
+
+func TestConsentHandlers_GrantThenRevoke(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupConsents(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_consent"), "password123", "Hospital A")
+	consentURL := fmt.Sprintf("/api/v1/patient/%d/consent", testPatient.ID)
+
+	granted, revoked := true, false
+	rr := performRequest(testRouter, "POST", consentURL, models.ConsentRequest{ConsentType: models.ConsentTypeResearch, Granted: &granted}, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	rr = performRequest(testRouter, "POST", consentURL, models.ConsentRequest{ConsentType: models.ConsentTypeResearch, Granted: &revoked}, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	// Both decisions are kept, the revocation first
+	rr = performRequest(testRouter, "GET", consentURL, nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var consents []models.Consent
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &consents))
+	if assert.Len(t, consents, 2) {
+		assert.False(t, consents[0].Granted)
+		assert.NotNil(t, consents[0].RevokedAt)
+		assert.True(t, consents[1].Granted)
+		assert.NotNil(t, consents[1].GrantedAt)
+	}
+}
+
+func TestSearchPatientHandler_CrossHospitalRespectsDataSharing(t *testing.T) {
+	lastName := fmt.Sprintf("Sharing%d", time.Now().UnixNano())
+	shared := createTestPatient(2)
+	shared.LastNameEN = lastName
+	seedPatient(t, shared)
+	refused := createTestPatient(2)
+	refused.LastNameEN = lastName
+	seedPatient(t, refused)
+	cleanupConsents(t, refused.ID)
+
+	// The patient first agrees, then changes their mind
+	hospitalBToken := getAuthToken(t, uniqueUsername("staff_consent_b"), "password123", "Hospital B")
+	consentURL := fmt.Sprintf("/api/v1/patient/%d/consent", refused.ID)
+	for _, decision := range []bool{true, false} {
+		decision := decision
+		rr := performRequest(testRouter, "POST", consentURL, models.ConsentRequest{ConsentType: models.ConsentTypeDataSharing, Granted: &decision}, hospitalBToken)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	search := func(hospital string) []uint {
+		token := getRoleAuthToken(t, uniqueUsername("super_consent"), "password123", hospital, models.RoleSuperAdmin)
+		rr := performRequest(testRouter, "GET", "/api/v1/patient/search?hospital_id=all&last_name_en="+lastName, nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var results []models.PatientWithHospital
+		assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+		var ids []uint
+		for _, p := range results {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	// The latest decision is also the patient's consent status
+	var stored models.Patient
+	assert.NoError(t, testDB.First(&stored, refused.ID).Error)
+	assert.Equal(t, models.ConsentStatusDenied, stored.ConsentStatus)
+	assert.NotNil(t, stored.ConsentUpdatedAt)
+
+	// Another hospital only finds the patient who still shares data
+	assert.ElementsMatch(t, []uint{shared.ID}, search("Hospital A"))
+	// The patient's own hospital still finds both
+	assert.ElementsMatch(t, []uint{shared.ID, refused.ID}, search("Hospital B"))
+}
+
+func TestUpdatePatientConsent_SearchAndExportFiltering(t *testing.T) {
+	// 1. One patient per consent status, in a hospital of their own
+	hospital := createIsolatedHospital(t, "CNS")
+	patients := map[string]*models.Patient{}
+	for _, status := range []string{models.ConsentStatusGranted, models.ConsentStatusDenied, models.ConsentStatusUnknown} {
+		patient := createTestPatient(hospital.ID)
+		seedPatient(t, patient)
+		patients[status] = patient
+	}
+	t.Cleanup(func() {
+		for _, patient := range patients {
+			testDB.Where("patient_id = ?", patient.ID).Delete(&models.AuditLog{})
+			testDB.Where("patient_id = ?", patient.ID).Delete(&models.Consent{})
+		}
+	})
+	unknown := patients[models.ConsentStatusUnknown]
+	assert.Equal(t, models.ConsentStatusUnknown, unknown.ConsentStatus, "patients start with unknown consent")
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_consent"), "password123", hospital.Name)
+
+	// 2. Admins set the status; the change is audited
+	for _, status := range []string{models.ConsentStatusGranted, models.ConsentStatusDenied} {
+		rr := performRequest(testRouter, "PUT", fmt.Sprintf("/api/v1/patient/%d/consent", patients[status].ID),
+			models.PatientConsentUpdateRequest{ConsentStatus: status, ConsentVersion: "v3"}, adminToken)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var stored models.Patient
+	assert.NoError(t, testDB.First(&stored, patients[models.ConsentStatusDenied].ID).Error)
+	assert.Equal(t, models.ConsentStatusDenied, stored.ConsentStatus)
+	assert.Equal(t, "v3", stored.ConsentVersion)
+	assert.NotNil(t, stored.ConsentUpdatedAt)
+	var audits int64
+	testDB.Model(&models.AuditLog{}).Where("patient_id = ? AND action = ?", stored.ID, models.AuditActionConsentUpdated).Count(&audits)
+	assert.Equal(t, int64(1), audits)
+	// ...and recorded as a witnessed data sharing decision in the consent history
+	var consents []models.Consent
+	assert.NoError(t, testDB.Where("patient_id = ?", stored.ID).Find(&consents).Error)
+	if assert.Len(t, consents, 1) {
+		assert.Equal(t, models.ConsentTypeDataSharing, consents[0].ConsentType)
+		assert.False(t, consents[0].Granted)
+		assert.NotZero(t, consents[0].StaffWitnessID)
+	}
+
+	// 3. consented_only narrows a search to granted consent
+	staffToken := getAuthToken(t, uniqueUsername("staff_consent_search"), "password123", hospital.Name)
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?patient_hn_prefix=TESTHN&consented_only=true", nil, staffToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var results []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, patients[models.ConsentStatusGranted].ID, results[0].ID)
+	}
+
+	// 4. Export enforcement leaves out only denied consent
+	hnPrefix := "TESTHN"
+	for enforce, want := range map[bool]int{false: 3, true: 2} {
+		found, err := database.SearchPatients(&models.PatientSearchQuery{PatientHNPrefix: &hnPrefix, ExcludeConsentDenied: enforce}, hospital.ID, 0)
+		assert.NoError(t, err)
+		assert.Len(t, found, want, "enforce=%t", enforce)
+		for _, patient := range found {
+			if enforce {
+				assert.NotEqual(t, models.ConsentStatusDenied, patient.ConsentStatus)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// joinConsortium adds the hospitals to a consortium, removing the memberships once the test ends.
+func joinConsortium(t *testing.T, consortiumID uint, hospitals ...models.Hospital) {
+	t.Helper()
+	for _, hospital := range hospitals {
+		membership := models.ConsortiumMembership{ConsortiumID: consortiumID, HospitalID: hospital.ID}
+		if err := testDB.Create(&membership).Error; err != nil {
+			t.Fatalf("Setup failed: Could not add hospital %d to consortium %d: %v", hospital.ID, consortiumID, err)
+		}
+	}
+	t.Cleanup(func() {
+		testDB.Where("consortium_id = ?", consortiumID).Delete(&models.ConsortiumMembership{})
+	})
+}
+
+// enableFeature switches a feature on for the hospital through the admin endpoint.
+func enableFeature(t *testing.T, adminToken string, hospital models.Hospital, feature string) {
+	t.Helper()
+	rr := performRequest(testRouter, "PUT", fmt.Sprintf("/api/v1/admin/hospital/%d/features/%s", hospital.ID, feature), gin.H{"enabled": true}, adminToken)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Setup failed: Could not enable %s for hospital %d: %d %s", feature, hospital.ID, rr.Code, rr.Body.String())
+	}
+}
+
+func TestConsortiumHospitalIDs(t *testing.T) {
+	north := createIsolatedHospital(t, "CN")
+	shared := createIsolatedHospital(t, "CS")
+	south := createIsolatedHospital(t, "CT")
+	lone := createIsolatedHospital(t, "CL")
+	first := uint(time.Now().UnixNano() % 1000000000)
+	joinConsortium(t, first, north, shared)
+	joinConsortium(t, first+1, shared, south)
+
+	tests := []struct {
+		name     string
+		hospital models.Hospital
+		want     []uint
+	}{
+		{"one consortium", north, []uint{north.ID, shared.ID}},
+		{"two consortiums", shared, []uint{north.ID, shared.ID, south.ID}},
+		{"no consortium", lone, []uint{lone.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := database.ConsortiumHospitalIDs(tt.hospital.ID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestSearchPatientHandler_CrossHospitalWithinConsortium(t *testing.T) {
+	home := createIsolatedHospital(t, "CH")
+	partner := createIsolatedHospital(t, "CP")
+	outsider := createIsolatedHospital(t, "CO")
+	joinConsortium(t, uint(time.Now().UnixNano()%1000000000), home, partner)
+
+	prefix := fmt.Sprintf("XH%d", time.Now().UnixNano()%1000000)
+	seed := func(hospital models.Hospital, consent string) *models.Patient {
+		patient := createTestPatient(hospital.ID)
+		patient.PatientHN = fmt.Sprintf("%s-%d-%s", prefix, hospital.ID, consent)
+		patient.ConsentStatus = consent
+		seedPatient(t, patient)
+		return patient
+	}
+	own := seed(home, models.ConsentStatusUnknown)
+	shared := seed(partner, models.ConsentStatusGranted)
+	seed(partner, models.ConsentStatusUnknown)  // Not consented to data sharing
+	seed(outsider, models.ConsentStatusGranted) // Not in the consortium
+
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_consortium"), "password123", home.Name)
+	enableFeature(t, adminToken, home, models.FeatureCrossHospitalSearch)
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?cross_hospital=true&patient_hn_prefix="+prefix, nil, adminToken)
+
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var results []models.Patient
+	if !assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results)) {
+		t.FailNow()
+	}
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, own.ID, results[0].ID)
+		assert.Empty(t, results[0].SourceHospitalName)
+		assert.Equal(t, shared.ID, results[1].ID)
+		assert.Equal(t, partner.Name, results[1].SourceHospitalName)
+	}
+
+	// A refusal recorded through the consent history takes the patient out of consortium results
+	cleanupConsents(t, shared.ID)
+	refused := false
+	partnerToken := getAuthToken(t, uniqueUsername("staff_consortium_consent"), "password123", partner.Name)
+	rr = performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/consent", shared.ID),
+		models.ConsentRequest{ConsentType: models.ConsentTypeDataSharing, Granted: &refused}, partnerToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/search?cross_hospital=true&patient_hn_prefix="+prefix, nil, adminToken)
+	if !assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results)) {
+		t.FailNow()
+	}
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, own.ID, results[0].ID)
+	}
+
+	// Without the flag the search stays in the admin's hospital
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/search?patient_hn_prefix="+prefix, nil, adminToken)
+	if !assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results)) {
+		t.FailNow()
+	}
+	assert.Len(t, results, 1)
+}
+
+func TestSearchPatientHandler_CrossHospitalFeatureIsPerHospital(t *testing.T) {
+	early := createIsolatedHospital(t, "FE")
+	late := createIsolatedHospital(t, "FL")
+	joinConsortium(t, uint(time.Now().UnixNano()%1000000000), early, late)
+	earlyToken := getAdminAuthToken(t, uniqueUsername("admin_early"), "password123", early.Name)
+	lateToken := getAdminAuthToken(t, uniqueUsername("admin_late"), "password123", late.Name)
+	prefix := fmt.Sprintf("FF%d", time.Now().UnixNano()%1000000)
+	for _, hospital := range []models.Hospital{early, late} {
+		patient := createTestPatient(hospital.ID)
+		patient.PatientHN = fmt.Sprintf("%s-%d", prefix, hospital.ID)
+		patient.ConsentStatus = models.ConsentStatusGranted
+		seedPatient(t, patient)
+	}
+	searchURL := "/api/v1/patient/search?cross_hospital=true&patient_hn_prefix=" + prefix
+
+	// Off everywhere until switched on
+	rr := performRequest(testRouter, "GET", searchURL, nil, earlyToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+
+	enableFeature(t, earlyToken, early, models.FeatureCrossHospitalSearch)
+	assert.True(t, database.IsFeatureEnabled(early.ID, models.FeatureCrossHospitalSearch))
+	assert.False(t, database.IsFeatureEnabled(late.ID, models.FeatureCrossHospitalSearch))
+
+	rr = performRequest(testRouter, "GET", searchURL, nil, earlyToken)
+	if assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		var results []models.Patient
+		assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+		assert.Len(t, results, 2)
+	}
+	rr = performRequest(testRouter, "GET", searchURL, nil, lateToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+
+	// Switching it off again takes effect at once on this server
+	rr = performRequest(testRouter, "PUT", fmt.Sprintf("/api/v1/admin/hospital/%d/features/%s", early.ID, models.FeatureCrossHospitalSearch), gin.H{"enabled": false}, earlyToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"cross_hospital_search":false`)
+	rr = performRequest(testRouter, "GET", searchURL, nil, earlyToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+}
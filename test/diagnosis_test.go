@@ -0,0 +1,85 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// cleanupDiagnoses removes all diagnoses recorded for a patient once the test ends.
+func cleanupDiagnoses(t *testing.T, patientID uint) {
+	t.Cleanup(func() {
+		log.Printf("Cleaning up diagnoses for patient ID: %d", patientID)
+		if err := testDB.Where("patient_id = ?", patientID).Delete(&models.PatientDiagnosis{}).Error; err != nil {
+			log.Printf("Error cleaning up diagnoses for patient %d: %v", patientID, err)
+		}
+	})
+}
+
+func TestDiagnosisHandlers_RecordAndList(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupDiagnoses(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_diag"), "password123", "Hospital A")
+	diagnosesURL := fmt.Sprintf("/api/v1/patient/%d/diagnoses", testPatient.ID)
+
+	// 1. A code from the reference table is accepted
+	rr := performRequest(testRouter, "POST", diagnosesURL, gin.H{"icd10_code": "i10", "diagnosis_type": "primary"}, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var diagnosis models.PatientDiagnosis
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &diagnosis))
+	assert.Equal(t, "I10", diagnosis.ICD10Code)
+	assert.Equal(t, testPatient.HospitalID, diagnosis.HospitalID)
+
+	// 2. An unknown code is rejected by the foreign key
+	rr = performRequest(testRouter, "POST", diagnosesURL, gin.H{"icd10_code": "Q99.99", "diagnosis_type": "working"}, authToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	// 3. The list carries the code's description
+	rr = performRequest(testRouter, "GET", diagnosesURL, nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var page struct {
+		Data  []models.PatientDiagnosis `json:"data"`
+		Total int64                     `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, int64(1), page.Total)
+	if assert.Len(t, page.Data, 1) && assert.NotNil(t, page.Data[0].ICD10) {
+		assert.Equal(t, "Essential (primary) hypertension", page.Data[0].ICD10.Description)
+	}
+}
+
+func TestSearchICD10(t *testing.T) {
+	authToken := getAuthToken(t, uniqueUsername("staff_icd10"), "password123", "Hospital A")
+
+	search := func(q string) []models.ICD10Code {
+		rr := performRequest(testRouter, "GET", "/api/v1/icd10?q="+q, nil, authToken)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var codes []models.ICD10Code
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &codes))
+		return codes
+	}
+	codesOf := func(codes []models.ICD10Code) []string {
+		var out []string
+		for _, code := range codes {
+			out = append(out, code.Code)
+		}
+		return out
+	}
+
+	// By code prefix, case-insensitively
+	assert.Equal(t, []string{"A90", "A91"}, codesOf(search("a9")))
+	// By words of the description
+	assert.Contains(t, codesOf(search("dengue")), "A90")
+	assert.Contains(t, codesOf(search("diabetes%20mellitus")), "E11.9")
+	// LIKE wildcards in q are literal
+	assert.Empty(t, search("%25"))
+}
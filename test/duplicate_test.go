@@ -0,0 +1,77 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListDuplicatePatients_ClustersByNationalIDAndName(t *testing.T) {
+	hospital := createIsolatedHospital(t, "Dup")
+	first := createTestPatient(hospital.ID)
+	first.FirstNameEN = "Somchai"
+	seedPatient(t, first)
+	sameNationalID := createTestPatient(hospital.ID)
+	sameNationalID.FirstNameEN = "Somsak"
+	sameNationalID.NationalID = first.NationalID
+	seedPatient(t, sameNationalID)
+	sameNameAndBirthDate := createTestPatient(hospital.ID)
+	sameNameAndBirthDate.FirstNameEN = " SOMCHAI "
+	seedPatient(t, sameNameAndBirthDate)
+	unrelated := createTestPatient(hospital.ID)
+	unrelated.FirstNameEN = "Malee"
+	seedPatient(t, unrelated)
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_dup"), "password123", hospital.Name)
+
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/duplicates", nil, adminToken)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response struct {
+		Data  []models.DuplicateCluster `json:"data"`
+		Total int64                     `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, int64(2), response.Total)
+	if assert.Len(t, response.Data, 2) {
+		byNationalID := response.Data[0]
+		assert.Equal(t, models.DuplicateMatchNationalID, byNationalID.MatchType)
+		assert.Equal(t, first.NationalID, byNationalID.MatchKey)
+		assert.Equal(t, []uint{first.ID, sameNationalID.ID}, clusterPatientIDs(byNationalID))
+		assert.Equal(t, []uint{first.ID, sameNationalID.ID}, byNationalID.PatientIDs)
+
+		byName := response.Data[1]
+		assert.Equal(t, models.DuplicateMatchNameAndBirthDate, byName.MatchType)
+		assert.Equal(t, "somchai patient 1990-05-15", byName.MatchKey)
+		assert.Equal(t, []uint{first.ID, sameNameAndBirthDate.ID}, clusterPatientIDs(byName))
+	}
+
+	// Paginated by cluster
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/duplicates?page=2&page_size=1", nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	if assert.Len(t, response.Data, 1) {
+		assert.Equal(t, models.DuplicateMatchNameAndBirthDate, response.Data[0].MatchType)
+	}
+
+	// The admin report takes the hospital explicitly
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/admin/patient/duplicates?hospital_id=%d", hospital.ID), nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, int64(2), response.Total)
+	rr = performRequest(testRouter, "GET", "/api/v1/admin/patient/duplicates?hospital_id=1", nil, adminToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func clusterPatientIDs(cluster models.DuplicateCluster) []uint {
+	ids := make([]uint, len(cluster.Patients))
+	for i, patient := range cluster.Patients {
+		ids[i] = patient.ID
+	}
+	return ids
+}
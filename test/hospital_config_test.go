@@ -0,0 +1,119 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func hospitalConfigBody(minCriteria int, twoFactor bool) models.HospitalConfigUpdateRequest {
+	zero, prefix := 0, ""
+	return models.HospitalConfigUpdateRequest{
+		MaxSearchResults:  &zero,
+		HNPrefix:          &prefix,
+		HNPaddingLength:   &zero,
+		SearchMinCriteria: &minCriteria,
+		TwoFactorRequired: &twoFactor,
+		ExtraFieldKeys:    []string{},
+	}
+}
+
+// resetHospitalConfig restores the defaults through the API so the router's cache is invalidated too.
+func resetHospitalConfig(t *testing.T, hospitalID uint, adminToken string) {
+	t.Cleanup(func() {
+		path := fmt.Sprintf("/api/v1/admin/hospital/%d/config", hospitalID)
+		rr := performRequest(testRouter, "PUT", path, hospitalConfigBody(0, false), adminToken)
+		if rr.Code != http.StatusOK {
+			t.Errorf("Cleanup failed: could not reset hospital %d config: %s", hospitalID, rr.Body.String())
+		}
+	})
+}
+
+func TestHospitalConfigHandlers_GetAndUpdate(t *testing.T) {
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_config"), "password123", "Hospital A")
+	resetHospitalConfig(t, 1, adminToken)
+	path := "/api/v1/admin/hospital/1/config"
+
+	body := hospitalConfigBody(2, false)
+	prefix, padding := "HA-", 8
+	body.HNPrefix, body.HNPaddingLength = &prefix, &padding
+	rr := performRequest(testRouter, "PUT", path, body, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = performRequest(testRouter, "GET", path, nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var cfg models.HospitalConfig
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &cfg))
+	assert.Equal(t, "HA-", cfg.HNPrefix)
+	assert.Equal(t, 8, cfg.HNPaddingLength)
+	assert.Equal(t, 2, cfg.SearchMinCriteria)
+
+	// The new minimum applies to search straight away
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/search?first_name_en=Test", nil, adminToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/search?first_name_en=Test&last_name_en=Patient", nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Admins cannot touch another hospital, and regular staff cannot use admin routes
+	rr = performRequest(testRouter, "GET", "/api/v1/admin/hospital/2/config", nil, adminToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	staffToken := getAuthToken(t, uniqueUsername("staff_config"), "password123", "Hospital A")
+	rr = performRequest(testRouter, "GET", path, nil, staffToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestHospitalConfigHandlers_TwoFactorRequired(t *testing.T) {
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_2fa"), "password123", "Hospital B")
+	resetHospitalConfig(t, 2, adminToken)
+	username := uniqueUsername("staff_2fa")
+	getAuthToken(t, username, "password123", "Hospital B")
+
+	rr := performRequest(testRouter, "PUT", "/api/v1/admin/hospital/2/config", hospitalConfigBody(0, true), adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Password alone now only yields an enrollment token
+	loginData := models.StaffLoginRequest{Username: username, Password: "password123", Hospital: "Hospital B"}
+	rr = performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	var denied struct {
+		EnrollmentToken string `json:"enrollment_token"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &denied))
+
+	rr = performRequest(testRouter, "POST", "/api/v1/staff/2fa/enroll", nil, denied.EnrollmentToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var enrollment models.TwoFactorEnrollResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &enrollment))
+
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	assert.NoError(t, err)
+	rr = performRequest(testRouter, "POST", "/api/v1/staff/2fa/confirm", models.TwoFactorConfirmRequest{Code: code}, denied.EnrollmentToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Enrolled: a code is now required, and a valid one logs in
+	rr = performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "two_factor_required")
+
+	// The code used to confirm enrollment can't be replayed to log in
+	loginData.OTPCode = code
+	rr = performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// A code from the next time step (still inside the skew window) logs in, once
+	loginData.OTPCode, err = totp.GenerateCode(enrollment.Secret, time.Now().Add(30*time.Second))
+	assert.NoError(t, err)
+	rr = performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
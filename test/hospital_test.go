@@ -0,0 +1,23 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListHospitalsHandler_ReturnsSeededHospitals(t *testing.T) {
+	// Public endpoint: no token
+	rr := performRequest(testRouter, "GET", "/api/v1/hospitals", nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var hospitals []models.HospitalSummary
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &hospitals))
+	assert.Contains(t, hospitals, models.HospitalSummary{ID: 1, Name: "Hospital A", Code: "HA"})
+	assert.Contains(t, hospitals, models.HospitalSummary{ID: 2, Name: "Hospital B", Code: "HB"})
+}
@@ -0,0 +1,37 @@
+//go:build integration
+
+package test
+
+import (
+	"hospital-middleware/internal/api"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthRequired_DeletedHospitalInvalidatesTokens(t *testing.T) {
+	hospital := createIsolatedHospital(t, "DEL")
+	token := getAuthToken(t, uniqueUsername("staff_deleted_hospital"), "password123", hospital.Name)
+	cfg := *testConfig
+	cfg.ValidateHospitalOnRequest = true
+	repo := database.NewPostgresRepository()
+	validatingRouter := api.SetupRouter(repo, nil, &cfg, services.NewAsyncWriters(repo))
+
+	// Staff go first, as the foreign key requires; the token itself stays unexpired and unrevoked
+	if !assert.NoError(t, testDB.Unscoped().Where("hospital_id = ?", hospital.ID).Delete(&models.Staff{}).Error) ||
+		!assert.NoError(t, testDB.Delete(&hospital).Error) {
+		t.FailNow()
+	}
+
+	rr := performRequest(validatingRouter, "GET", "/api/v1/patient/search?first_name_en=Test", nil, token)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "hospital no longer valid")
+
+	// Without VALIDATE_HOSPITAL_ON_REQUEST the token is still accepted
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/search?first_name_en=Test", nil, token)
+	assert.NotEqual(t, http.StatusUnauthorized, rr.Code)
+}
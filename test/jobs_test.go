@@ -0,0 +1,184 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/jobs"
+	"hospital-middleware/internal/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cleanupJobs deletes the jobs of jobType when the test ends.
+func cleanupJobs(t *testing.T, jobType string) {
+	t.Cleanup(func() { testDB.Where("type = ?", jobType).Delete(&models.Job{}) })
+}
+
+func loadJob(t *testing.T, id uint) models.Job {
+	t.Helper()
+	var job models.Job
+	if err := testDB.First(&job, id).Error; err != nil {
+		t.Fatalf("Failed to load job %d: %v", id, err)
+	}
+	return job
+}
+
+func TestClaimJob_HandsEachJobOutOnce(t *testing.T) {
+	jobType := uniqueUsername("claim")
+	cleanupJobs(t, jobType)
+	repo := database.NewPostgresRepository()
+	for i := 0; i < 5; i++ {
+		_, err := jobs.Enqueue(repo, jobType, map[string]int{"n": i}, 0)
+		assert.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	claimed := make(map[uint]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				now := time.Now()
+				job, err := database.ClaimJob([]string{jobType}, now, now.Add(-time.Hour))
+				if !assert.NoError(t, err) || job == nil {
+					return
+				}
+				mu.Lock()
+				claimed[job.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, 5)
+	for id, times := range claimed {
+		assert.Equal(t, 1, times, "job %d claimed more than once", id)
+		job := loadJob(t, id)
+		assert.Equal(t, models.JobStatusRunning, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+		assert.NotNil(t, job.LockedAt)
+	}
+}
+
+func TestClaimJob_SkipsFutureAndReclaimsStale(t *testing.T) {
+	jobType := uniqueUsername("stale")
+	cleanupJobs(t, jobType)
+	now := time.Now()
+	future := models.Job{Type: jobType, Payload: models.JobPayload(`{}`), Status: models.JobStatusQueued, MaxAttempts: 3, RunAt: now.Add(time.Hour)}
+	lockedAt := now.Add(-2 * time.Hour)
+	stale := models.Job{Type: jobType, Payload: models.JobPayload(`{}`), Status: models.JobStatusRunning, Attempts: 1, MaxAttempts: 3, RunAt: lockedAt, LockedAt: &lockedAt}
+	seedRow(t, &future)
+	seedRow(t, &stale)
+
+	job, err := database.ClaimJob([]string{jobType}, now, now.Add(-time.Hour))
+	if assert.NoError(t, err) && assert.NotNil(t, job) {
+		assert.Equal(t, stale.ID, job.ID)
+		assert.Equal(t, 2, job.Attempts, "the abandoned attempt counts")
+	}
+	job, err = database.ClaimJob([]string{jobType}, now, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Nil(t, job, "the future job is not due yet")
+}
+
+func TestJobPool_RunsRetriesAndFailsJobs(t *testing.T) {
+	okType, flakyType, brokenType := uniqueUsername("ok"), uniqueUsername("flaky"), uniqueUsername("broken")
+	for _, jobType := range []string{okType, flakyType, brokenType} {
+		cleanupJobs(t, jobType)
+	}
+	repo := database.NewPostgresRepository()
+
+	var mu sync.Mutex
+	var received []string
+	flakyRuns := 0
+	pool := jobs.NewPool(repo, 2, 5*time.Millisecond, time.Millisecond)
+	pool.Register(okType, func(_ context.Context, payload json.RawMessage) error {
+		var body struct{ Name string }
+		if err := json.Unmarshal(payload, &body); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, body.Name)
+		return nil
+	})
+	pool.Register(flakyType, func(context.Context, json.RawMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if flakyRuns++; flakyRuns < 2 {
+			return errors.New("receiver busy")
+		}
+		return nil
+	})
+	pool.Register(brokenType, func(context.Context, json.RawMessage) error { return errors.New("always broken") })
+
+	okJob, err := jobs.Enqueue(repo, okType, map[string]string{"name": "export"}, 0)
+	assert.NoError(t, err)
+	flakyJob, err := jobs.Enqueue(repo, flakyType, nil, 3)
+	assert.NoError(t, err)
+	brokenJob, err := jobs.Enqueue(repo, brokenType, nil, 2)
+	assert.NoError(t, err)
+
+	pool.Start()
+	finished := func(id uint) bool {
+		status := loadJob(t, id).Status
+		return status == models.JobStatusSucceeded || status == models.JobStatusFailed
+	}
+	assert.Eventually(t, func() bool { return finished(okJob.ID) && finished(flakyJob.ID) && finished(brokenJob.ID) }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	assert.Equal(t, []string{"export"}, received)
+	ok, flaky, broken := loadJob(t, okJob.ID), loadJob(t, flakyJob.ID), loadJob(t, brokenJob.ID)
+	assert.Equal(t, models.JobStatusSucceeded, ok.Status)
+	assert.NotNil(t, ok.FinishedAt)
+	assert.Equal(t, models.JobStatusSucceeded, flaky.Status)
+	assert.Equal(t, 2, flaky.Attempts)
+	assert.Equal(t, models.JobStatusFailed, broken.Status)
+	assert.Equal(t, 2, broken.Attempts)
+	assert.Equal(t, "always broken", broken.LastError)
+
+	listed, total, err := database.ListJobs(models.JobStatusFailed, 0, 100)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, total, int64(1))
+	ids := make([]uint, len(listed))
+	for i, job := range listed {
+		ids[i] = job.ID
+	}
+	assert.Contains(t, ids, broken.ID)
+	assert.NotContains(t, ids, ok.ID)
+}
+
+func TestJobPool_ShutdownReturnsUnfinishedJobsToTheQueue(t *testing.T) {
+	jobType := uniqueUsername("slow")
+	cleanupJobs(t, jobType)
+	repo := database.NewPostgresRepository()
+	started := make(chan struct{})
+	pool := jobs.NewPool(repo, 1, 5*time.Millisecond, time.Millisecond)
+	pool.Register(jobType, func(ctx context.Context, _ json.RawMessage) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	job, err := jobs.Enqueue(repo, jobType, nil, 0)
+	assert.NoError(t, err)
+
+	pool.Start()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.Shutdown(ctx), context.DeadlineExceeded)
+
+	released := loadJob(t, job.ID)
+	assert.Equal(t, models.JobStatusQueued, released.Status)
+	assert.Equal(t, 0, released.Attempts, "an interrupted attempt is not counted")
+	assert.Nil(t, released.LockedAt)
+}
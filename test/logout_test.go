@@ -0,0 +1,24 @@
+//go:build integration
+
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogout_RevokesToken(t *testing.T) {
+	token := getAuthToken(t, uniqueUsername("logout_user"), "password123", "Hospital A")
+
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?first_name_en=Nobody", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = performRequest(testRouter, "POST", "/api/v1/staff/logout", nil, token)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/search?first_name_en=Nobody", nil, token)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "revoked")
+}
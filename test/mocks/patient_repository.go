@@ -0,0 +1,665 @@
+// Package mocks provides testify-based test doubles for the database layer.
+package mocks
+
+import (
+	"context"
+	"encoding/json"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPatientRepository is a testify mock implementing database.PatientRepository.
+type MockPatientRepository struct {
+	mock.Mock
+}
+
+// Compile-time check that the mock stays in sync with the interface.
+var _ database.PatientRepository = (*MockPatientRepository)(nil)
+
+func (m *MockPatientRepository) CreateStaff(staff *models.Staff) error {
+	args := m.Called(staff)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) FindStaffByUsername(username string) (*models.Staff, error) {
+	args := m.Called(username)
+	staff, _ := args.Get(0).(*models.Staff)
+	return staff, args.Error(1)
+}
+
+func (m *MockPatientRepository) FindExistingUsernames(usernames []string) ([]string, error) {
+	args := m.Called(usernames)
+	existing, _ := args.Get(0).([]string)
+	return existing, args.Error(1)
+}
+
+func (m *MockPatientRepository) CreateStaffBatch(staff []models.Staff) error {
+	args := m.Called(staff)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) RehearseStaffBatch(staff []models.Staff) error {
+	args := m.Called(staff)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) RecordStaffLogin(staffID uint, at time.Time) error {
+	args := m.Called(staffID, at)
+	return args.Error(0)
+}
+
+// StreamStaffByHospital feeds the []models.Staff given to Return through fn, like the real cursor.
+func (m *MockPatientRepository) StreamStaffByHospital(hospitalID uint, limit int, fn func(*models.Staff) error) error {
+	args := m.Called(hospitalID, limit, fn)
+	staff, _ := args.Get(0).([]models.Staff)
+	for i := range staff {
+		if err := fn(&staff[i]); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockPatientRepository) UpdateStaffPassword(staffID uint, passwordHash string) error {
+	args := m.Called(staffID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) UpdateStaffRole(staffID, hospitalID uint, role string) (*models.Staff, error) {
+	args := m.Called(staffID, hospitalID, role)
+	staff, _ := args.Get(0).(*models.Staff)
+	return staff, args.Error(1)
+}
+
+func (m *MockPatientRepository) SetStaffTOTPSecret(staffID uint, secret string) error {
+	args := m.Called(staffID, secret)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) EnableStaffTOTP(staffID uint, step int64) error {
+	args := m.Called(staffID, step)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ClaimStaffTOTPStep(staffID uint, step int64) (bool, error) {
+	args := m.Called(staffID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPatientRepository) CreatePatient(patient *models.Patient) error {
+	args := m.Called(patient)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) GetPatientByID(id uint) (*models.Patient, error) {
+	args := m.Called(id)
+	patient, _ := args.Get(0).(*models.Patient)
+	return patient, args.Error(1)
+}
+
+func (m *MockPatientRepository) GetPatientByNationalID(nationalID string, hospitalID uint) (*models.Patient, error) {
+	args := m.Called(nationalID, hospitalID)
+	patient, _ := args.Get(0).(*models.Patient)
+	return patient, args.Error(1)
+}
+
+func (m *MockPatientRepository) GetPatientsByHNList(hns []string, hospitalID uint) ([]models.Patient, error) {
+	args := m.Called(hns, hospitalID)
+	patients, _ := args.Get(0).([]models.Patient)
+	return patients, args.Error(1)
+}
+
+func (m *MockPatientRepository) SoftDeletePatientsByHospital(hospitalID uint) (int64, error) {
+	args := m.Called(hospitalID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPatientRepository) UpdatePatientFields(patientID, hospitalID uint, updates map[string]interface{}, audit *models.AuditLog) error {
+	args := m.Called(patientID, hospitalID, updates, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) UpdatePatientStatus(patient *models.Patient, previousStatus string, audit *models.AuditLog) error {
+	args := m.Called(patient, previousStatus, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) TransferPatient(patient *models.Patient, targetHospitalID uint, audit *models.AuditLog) error {
+	args := m.Called(patient, targetHospitalID, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) SearchPatients(query *models.PatientSearchQuery, hospitalID uint, limit int) ([]models.Patient, error) {
+	args := m.Called(query, hospitalID, limit)
+	patients, _ := args.Get(0).([]models.Patient)
+	return patients, args.Error(1)
+}
+
+func (m *MockPatientRepository) ExplainSearchPatients(query *models.PatientSearchQuery, hospitalID uint, limit int) (json.RawMessage, error) {
+	args := m.Called(query, hospitalID, limit)
+	plan, _ := args.Get(0).(json.RawMessage)
+	return plan, args.Error(1)
+}
+
+func (m *MockPatientRepository) SearchPatientsAcrossHospitals(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint, limit int) ([]models.PatientWithHospital, error) {
+	args := m.Called(query, hospitalIDs, viewerHospitalID, limit)
+	patients, _ := args.Get(0).([]models.PatientWithHospital)
+	return patients, args.Error(1)
+}
+
+func (m *MockPatientRepository) SearchPatientsPage(query *models.PatientSearchQuery, hospitalID uint, offset, limit int) ([]models.Patient, int64, error) {
+	args := m.Called(query, hospitalID, offset, limit)
+	patients, _ := args.Get(0).([]models.Patient)
+	return patients, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) SearchPatientsAcrossHospitalsPage(query *models.PatientSearchQuery, hospitalIDs []uint, viewerHospitalID uint, offset, limit int) ([]models.PatientWithHospital, int64, error) {
+	args := m.Called(query, hospitalIDs, viewerHospitalID, offset, limit)
+	patients, _ := args.Get(0).([]models.PatientWithHospital)
+	return patients, args.Get(1).(int64), args.Error(2)
+}
+
+// StreamPatients feeds the []models.Patient given to Return through fn, like the real cursor,
+// and returns fn's error if it stops early.
+func (m *MockPatientRepository) StreamPatients(ctx context.Context, query *models.PatientSearchQuery, hospitalID uint, fn func(*models.Patient) error) error {
+	args := m.Called(ctx, query, hospitalID, fn)
+	patients, _ := args.Get(0).([]models.Patient)
+	for i := range patients {
+		if err := fn(&patients[i]); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockPatientRepository) GetHospitalIDByName(hospitalName string) (uint, error) {
+	args := m.Called(hospitalName)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockPatientRepository) GetHospitalByID(id uint) (*models.Hospital, error) {
+	args := m.Called(id)
+	hospital, _ := args.Get(0).(*models.Hospital)
+	return hospital, args.Error(1)
+}
+
+func (m *MockPatientRepository) ListHospitals() ([]models.Hospital, error) {
+	args := m.Called()
+	hospitals, _ := args.Get(0).([]models.Hospital)
+	return hospitals, args.Error(1)
+}
+
+func (m *MockPatientRepository) IsFeatureEnabled(hospitalID uint, feature string) bool {
+	args := m.Called(hospitalID, feature)
+	return args.Bool(0)
+}
+
+func (m *MockPatientRepository) SetHospitalFeature(hospitalID uint, feature string, enabled bool) (models.HospitalFeatures, error) {
+	args := m.Called(hospitalID, feature, enabled)
+	features, _ := args.Get(0).(models.HospitalFeatures)
+	return features, args.Error(1)
+}
+
+func (m *MockPatientRepository) GetHospitalConfig(hospitalID uint) (*models.HospitalConfig, error) {
+	args := m.Called(hospitalID)
+	cfg, _ := args.Get(0).(*models.HospitalConfig)
+	return cfg, args.Error(1)
+}
+
+func (m *MockPatientRepository) SaveHospitalConfig(cfg *models.HospitalConfig) error {
+	args := m.Called(cfg)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) CreateVisit(visit *models.Visit) error {
+	args := m.Called(visit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListVisitsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.Visit, int64, error) {
+	args := m.Called(patientID, hospitalID, offset, limit)
+	visits, _ := args.Get(0).([]models.Visit)
+	return visits, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) ListVisitsByDate(hospitalID uint, start, end time.Time) ([]models.Visit, error) {
+	args := m.Called(hospitalID, start, end)
+	visits, _ := args.Get(0).([]models.Visit)
+	return visits, args.Error(1)
+}
+
+func (m *MockPatientRepository) CreateAdmission(admission *models.Admission) error {
+	args := m.Called(admission)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) GetAdmission(patientID, admissionID uint) (*models.Admission, error) {
+	args := m.Called(patientID, admissionID)
+	admission, _ := args.Get(0).(*models.Admission)
+	return admission, args.Error(1)
+}
+
+func (m *MockPatientRepository) DischargeAdmission(admission *models.Admission) error {
+	args := m.Called(admission)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListAdmissionsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.Admission, int64, error) {
+	args := m.Called(patientID, hospitalID, offset, limit)
+	admissions, _ := args.Get(0).([]models.Admission)
+	return admissions, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) ListCurrentWardAdmissions(hospitalID uint, ward string) ([]models.WardCensusEntry, error) {
+	args := m.Called(hospitalID, ward)
+	entries, _ := args.Get(0).([]models.WardCensusEntry)
+	return entries, args.Error(1)
+}
+
+func (m *MockPatientRepository) CreateReferral(referral *models.Referral) error {
+	args := m.Called(referral)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) GetReferral(id uint) (*models.Referral, error) {
+	args := m.Called(id)
+	referral, _ := args.Get(0).(*models.Referral)
+	return referral, args.Error(1)
+}
+
+func (m *MockPatientRepository) UpdateReferralStatus(referral *models.Referral, from, to string) error {
+	args := m.Called(referral, from, to)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) CreatePatientDiagnosis(diagnosis *models.PatientDiagnosis) error {
+	args := m.Called(diagnosis)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListPatientDiagnoses(patientID, hospitalID uint, offset, limit int) ([]models.PatientDiagnosis, int64, error) {
+	args := m.Called(patientID, hospitalID, offset, limit)
+	diagnoses, _ := args.Get(0).([]models.PatientDiagnosis)
+	return diagnoses, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) SearchICD10Codes(q string, limit int) ([]models.ICD10Code, error) {
+	args := m.Called(q, limit)
+	codes, _ := args.Get(0).([]models.ICD10Code)
+	return codes, args.Error(1)
+}
+
+func (m *MockPatientRepository) UpsertAllergy(allergy *models.Allergy) (bool, error) {
+	args := m.Called(allergy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPatientRepository) ListAllergiesByPatient(patientID uint) ([]models.Allergy, error) {
+	args := m.Called(patientID)
+	allergies, _ := args.Get(0).([]models.Allergy)
+	return allergies, args.Error(1)
+}
+
+func (m *MockPatientRepository) GetAllergyByID(patientID, allergyID uint) (*models.Allergy, error) {
+	args := m.Called(patientID, allergyID)
+	allergy, _ := args.Get(0).(*models.Allergy)
+	return allergy, args.Error(1)
+}
+
+func (m *MockPatientRepository) UpdateAllergy(allergy *models.Allergy) error {
+	args := m.Called(allergy)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) DeleteAllergy(patientID, allergyID uint) error {
+	args := m.Called(patientID, allergyID)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) CreateConsent(consent *models.Consent, audit *models.AuditLog) error {
+	args := m.Called(consent, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) UpdatePatientConsent(consent *models.Consent, version string, audit *models.AuditLog) error {
+	args := m.Called(consent, version, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListConsentsByPatient(patientID uint) ([]models.Consent, error) {
+	args := m.Called(patientID)
+	consents, _ := args.Get(0).([]models.Consent)
+	return consents, args.Error(1)
+}
+
+func (m *MockPatientRepository) CreatePatientNote(note *models.PatientNote, audit *models.AuditLog) error {
+	args := m.Called(note, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListPatientNotes(patientID, viewerID uint, offset, limit int) ([]models.PatientNote, int64, error) {
+	args := m.Called(patientID, viewerID, offset, limit)
+	notes, _ := args.Get(0).([]models.PatientNote)
+	return notes, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) GetPatientNote(patientID, noteID uint) (*models.PatientNote, error) {
+	args := m.Called(patientID, noteID)
+	note, _ := args.Get(0).(*models.PatientNote)
+	return note, args.Error(1)
+}
+
+func (m *MockPatientRepository) UpdatePatientNote(note *models.PatientNote, audit *models.AuditLog) error {
+	args := m.Called(note, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) DeletePatientNote(note *models.PatientNote, audit *models.AuditLog) error {
+	args := m.Called(note, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) CreatePatientDocument(doc *models.PatientDocument, audit *models.AuditLog) error {
+	args := m.Called(doc, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListPatientDocuments(patientID uint, offset, limit int) ([]models.PatientDocument, int64, error) {
+	args := m.Called(patientID, offset, limit)
+	docs, _ := args.Get(0).([]models.PatientDocument)
+	return docs, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) GetPatientDocument(patientID, documentID uint) (*models.PatientDocument, error) {
+	args := m.Called(patientID, documentID)
+	doc, _ := args.Get(0).(*models.PatientDocument)
+	return doc, args.Error(1)
+}
+
+func (m *MockPatientRepository) DeletePatientDocument(doc *models.PatientDocument, audit *models.AuditLog) error {
+	args := m.Called(doc, audit)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListAuditLogsByPatient(patientID, hospitalID uint, offset, limit int) ([]models.AuditLog, int64, error) {
+	args := m.Called(patientID, hospitalID, offset, limit)
+	entries, _ := args.Get(0).([]models.AuditLog)
+	return entries, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) RevokeToken(token *models.RevokedToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) IsTokenRevoked(jti string) (bool, error) {
+	args := m.Called(jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPatientRepository) DeleteExpiredRevokedTokens(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPatientRepository) CreateJob(job *models.Job) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ClaimJob(types []string, now, staleBefore time.Time) (*models.Job, error) {
+	args := m.Called(types, now, staleBefore)
+	job, _ := args.Get(0).(*models.Job)
+	return job, args.Error(1)
+}
+
+func (m *MockPatientRepository) CompleteJob(id uint, now time.Time) error {
+	args := m.Called(id, now)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) RetryJob(id uint, lastError string, runAt time.Time) error {
+	args := m.Called(id, lastError, runAt)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) FailJob(id uint, lastError string, now time.Time) error {
+	args := m.Called(id, lastError, now)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ReleaseJob(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListJobs(status string, offset, limit int) ([]models.Job, int64, error) {
+	args := m.Called(status, offset, limit)
+	jobs, _ := args.Get(0).([]models.Job)
+	return jobs, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) GenerateMonthlyReport(hospitalID uint, start time.Time) (*models.MonthlyReport, error) {
+	args := m.Called(hospitalID, start)
+	report, _ := args.Get(0).(*models.MonthlyReport)
+	return report, args.Error(1)
+}
+
+func (m *MockPatientRepository) GetCachedReport(hospitalID uint, year, month int) (*models.CachedReport, error) {
+	args := m.Called(hospitalID, year, month)
+	cached, _ := args.Get(0).(*models.CachedReport)
+	return cached, args.Error(1)
+}
+
+func (m *MockPatientRepository) SaveCachedReport(cached *models.CachedReport) error {
+	args := m.Called(cached)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) CreateAPIKey(key *models.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) FindAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	args := m.Called(prefix)
+	key, _ := args.Get(0).(*models.APIKey)
+	return key, args.Error(1)
+}
+
+func (m *MockPatientRepository) TouchAPIKey(id uint, usedAt time.Time) error {
+	args := m.Called(id, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) RevokeAPIKey(id, hospitalID uint) error {
+	args := m.Called(id, hospitalID)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) RecordSearch(entry *models.SearchHistory) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) DeleteSearchHistoryBefore(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPatientRepository) CreateSavedSearch(search *models.SavedSearch) error {
+	args := m.Called(search)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListSavedSearches(staffID uint) ([]models.SavedSearch, error) {
+	args := m.Called(staffID)
+	searches, _ := args.Get(0).([]models.SavedSearch)
+	return searches, args.Error(1)
+}
+
+func (m *MockPatientRepository) GetSavedSearch(id, staffID uint) (*models.SavedSearch, error) {
+	args := m.Called(id, staffID)
+	search, _ := args.Get(0).(*models.SavedSearch)
+	return search, args.Error(1)
+}
+
+func (m *MockPatientRepository) DeleteSavedSearch(id, staffID uint) error {
+	args := m.Called(id, staffID)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) CreateWebhook(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListWebhooks(hospitalID uint) ([]models.Webhook, error) {
+	args := m.Called(hospitalID)
+	webhooks, _ := args.Get(0).([]models.Webhook)
+	return webhooks, args.Error(1)
+}
+
+func (m *MockPatientRepository) GetWebhook(id, hospitalID uint) (*models.Webhook, error) {
+	args := m.Called(id, hospitalID)
+	webhook, _ := args.Get(0).(*models.Webhook)
+	return webhook, args.Error(1)
+}
+
+func (m *MockPatientRepository) UpdateWebhook(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) DeleteWebhook(id, hospitalID uint) error {
+	args := m.Called(id, hospitalID)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListActiveWebhooks(hospitalID uint, event string) ([]models.Webhook, error) {
+	args := m.Called(hospitalID, event)
+	webhooks, _ := args.Get(0).([]models.Webhook)
+	return webhooks, args.Error(1)
+}
+
+func (m *MockPatientRepository) CreateWebhookDeadLetter(entry *models.WebhookDeadLetter) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) RecordPatientView(view *models.RecentlyViewed) error {
+	args := m.Called(view)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListRecentlyViewed(staffID, hospitalID uint) ([]models.RecentlyViewedPatient, error) {
+	args := m.Called(staffID, hospitalID)
+	entries, _ := args.Get(0).([]models.RecentlyViewedPatient)
+	return entries, args.Error(1)
+}
+
+func (m *MockPatientRepository) RecordPatientAccess(entries []models.PatientAccessLog) error {
+	args := m.Called(entries)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListPatientAccessLog(patientID uint, offset, limit int) ([]models.PatientAccessLog, int64, error) {
+	args := m.Called(patientID, offset, limit)
+	entries, _ := args.Get(0).([]models.PatientAccessLog)
+	return entries, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) ListStaffAccessLog(staffID, hospitalID uint, offset, limit int) ([]models.PatientAccessLog, int64, error) {
+	args := m.Called(staffID, hospitalID, offset, limit)
+	entries, _ := args.Get(0).([]models.PatientAccessLog)
+	return entries, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) DeletePatientAccessLogBefore(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPatientRepository) CountRetentionPurge(cutoffs models.RetentionCutoffs) (models.RetentionPurgeReport, error) {
+	args := m.Called(cutoffs)
+	return args.Get(0).(models.RetentionPurgeReport), args.Error(1)
+}
+
+func (m *MockPatientRepository) PurgeSoftDeletedPatients(before time.Time, limit int) (int64, []string, error) {
+	args := m.Called(before, limit)
+	keys, _ := args.Get(1).([]string)
+	return args.Get(0).(int64), keys, args.Error(2)
+}
+
+func (m *MockPatientRepository) PurgeRevokedTokens(cutoffs models.RetentionCutoffs, limit int) (int64, error) {
+	args := m.Called(cutoffs, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPatientRepository) ListDuplicateClusters(hospitalID uint, offset, limit int) ([]models.DuplicateCluster, int64, error) {
+	args := m.Called(hospitalID, offset, limit)
+	clusters, _ := args.Get(0).([]models.DuplicateCluster)
+	return clusters, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockPatientRepository) CreatePatientLabel(label *models.PatientLabel) error {
+	args := m.Called(label)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListPatientLabels(hospitalID uint) ([]models.PatientLabel, error) {
+	args := m.Called(hospitalID)
+	labels, _ := args.Get(0).([]models.PatientLabel)
+	return labels, args.Error(1)
+}
+
+func (m *MockPatientRepository) GetPatientLabel(id, hospitalID uint) (*models.PatientLabel, error) {
+	args := m.Called(id, hospitalID)
+	label, _ := args.Get(0).(*models.PatientLabel)
+	return label, args.Error(1)
+}
+
+func (m *MockPatientRepository) UpdatePatientLabel(label *models.PatientLabel) error {
+	args := m.Called(label)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) DeletePatientLabel(id, hospitalID uint) error {
+	args := m.Called(id, hospitalID)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) AssignPatientLabel(patientID, labelID uint) error {
+	args := m.Called(patientID, labelID)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) UnassignPatientLabel(patientID, labelID uint) error {
+	args := m.Called(patientID, labelID)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) AddPatientTag(patientID, hospitalID uint, name string) (*models.Tag, error) {
+	args := m.Called(patientID, hospitalID, name)
+	tag, _ := args.Get(0).(*models.Tag)
+	return tag, args.Error(1)
+}
+
+func (m *MockPatientRepository) RemovePatientTag(patientID, hospitalID uint, name string) error {
+	args := m.Called(patientID, hospitalID, name)
+	return args.Error(0)
+}
+
+func (m *MockPatientRepository) ListTagCounts(hospitalID uint) ([]models.TagCount, error) {
+	args := m.Called(hospitalID)
+	counts, _ := args.Get(0).([]models.TagCount)
+	return counts, args.Error(1)
+}
+
+func (m *MockPatientRepository) DeleteTag(hospitalID uint, name string) error {
+	args := m.Called(hospitalID, name)
+	return args.Error(0)
+}
@@ -0,0 +1,113 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/background"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/jobs"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// accessLogOf returns the access log of the patient, oldest first.
+func accessLogOf(patientID uint) []models.PatientAccessLog {
+	var entries []models.PatientAccessLog
+	testDB.Where("patient_id = ?", patientID).Order("accessed_at, id").Find(&entries)
+	return entries
+}
+
+func TestPatientAccessLog_SearchThenGet(t *testing.T) {
+	hospital := createIsolatedHospital(t, "PAL")
+	found, other := createTestPatient(hospital.ID), createTestPatient(hospital.ID)
+	found.FirstNameEN, other.FirstNameEN = "Accessed", "Untouched"
+	seedPatient(t, found)
+	seedPatient(t, other)
+	t.Cleanup(func() {
+		testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.PatientAccessLog{})
+		testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.SearchHistory{})
+	})
+	username := uniqueUsername("staff_access_log")
+	token := getAuthToken(t, username, "password123", hospital.Name)
+	var staff models.Staff
+	if !assert.NoError(t, testDB.Where("username = ?", username).First(&staff).Error) {
+		t.FailNow()
+	}
+
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?first_name_en=Accessed", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Eventually(t, func() bool { return len(accessLogOf(found.ID)) == 1 }, 5*time.Second, 10*time.Millisecond)
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d", found.ID), nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Eventually(t, func() bool { return len(accessLogOf(found.ID)) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	entries := accessLogOf(found.ID)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "GET /api/v1/patient/search", entries[0].Endpoint)
+		assert.Equal(t, "GET /api/v1/patient/:id", entries[1].Endpoint)
+		for _, entry := range entries {
+			assert.Equal(t, staff.ID, entry.StaffID)
+			assert.Equal(t, hospital.ID, entry.HospitalID)
+			assert.WithinDuration(t, time.Now(), entry.AccessedAt, time.Minute)
+		}
+	}
+	assert.Empty(t, accessLogOf(other.ID), "patients the search did not return were not accessed")
+
+	// Admins read it back per patient and per staff member, newest first
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_access_log"), "password123", hospital.Name)
+	var page struct {
+		Data  []models.PatientAccessLog `json:"data"`
+		Total int64                     `json:"total"`
+	}
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/admin/patient/%d/access-log", found.ID), nil, adminToken)
+	if assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) && assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page)) {
+		assert.Equal(t, int64(2), page.Total)
+		assert.Equal(t, entries[1].ID, page.Data[0].ID)
+	}
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/admin/staff/%d/access-log?page_size=1", staff.ID), nil, adminToken)
+	if assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) && assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page)) {
+		assert.Equal(t, int64(2), page.Total)
+		assert.Len(t, page.Data, 1)
+	}
+	rr = performRequest(testRouter, "GET", "/api/v1/admin/staff/1/access-log", nil, adminToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "staff of other hospitals are not found")
+}
+
+func TestPatientAccessLog_PurgedByJob(t *testing.T) {
+	cleanupJobs(t, background.PurgeAccessLogJob)
+	hospital := createIsolatedHospital(t, "PAP")
+	t.Cleanup(func() { testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.PatientAccessLog{}) })
+	now := time.Now()
+	old := models.PatientAccessLog{StaffID: 1, PatientID: 1, HospitalID: hospital.ID, Endpoint: "GET /api/v1/patient/:id", AccessedAt: now.AddDate(0, 0, -31)}
+	recent := models.PatientAccessLog{StaffID: 1, PatientID: 1, HospitalID: hospital.ID, Endpoint: "GET /api/v1/patient/:id", AccessedAt: now.AddDate(0, 0, -29)}
+	seedRow(t, &old)
+	seedRow(t, &recent)
+
+	repo := database.NewPostgresRepository()
+	runSchedulerUntil(t, fixedClock{now: now}, func() bool {
+		var queued int64
+		testDB.Model(&models.Job{}).Where("type = ?", background.PurgeAccessLogJob).Count(&queued)
+		return queued > 0
+	}, background.EnqueuePatientAccessLogPurge(repo, 30, time.Hour))
+
+	pool := jobs.NewPool(repo, 1, 10*time.Millisecond, time.Millisecond)
+	pool.Register(background.PurgeAccessLogJob, background.PurgePatientAccessLog(repo))
+	pool.Start()
+	assert.Eventually(t, func() bool {
+		var succeeded int64
+		testDB.Model(&models.Job{}).Where("type = ? AND status = ?", background.PurgeAccessLogJob, models.JobStatusSucceeded).Count(&succeeded)
+		return succeeded > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	var remaining []uint
+	testDB.Model(&models.PatientAccessLog{}).Where("hospital_id = ?", hospital.ID).Pluck("id", &remaining)
+	assert.Equal(t, []uint{recent.ID}, remaining)
+}
@@ -0,0 +1,45 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchHNSearch(t *testing.T) {
+	hospital := createIsolatedHospital(t, "BHN")
+	otherHospital := createIsolatedHospital(t, "BHNO")
+	token := getAuthToken(t, uniqueUsername("staff_batch_hn"), "password123", hospital.Name)
+	t.Cleanup(func() { testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.PatientAccessLog{}) })
+
+	first, second, third := createTestPatient(hospital.ID), createTestPatient(hospital.ID), createTestPatient(hospital.ID)
+	for _, patient := range []*models.Patient{first, second, third} {
+		seedPatient(t, patient)
+	}
+	// The same HN in another hospital is a different patient
+	elsewhere := createTestPatient(otherHospital.ID)
+	elsewhere.PatientHN = "BATCHHN-ELSEWHERE-" + first.PatientHN
+	seedPatient(t, elsewhere)
+
+	hns := []string{first.PatientHN, third.PatientHN, "BATCHHN-MISSING", elsewhere.PatientHN}
+	rr := performRequest(testRouter, "POST", "/api/v1/patient/search/batch-hn", map[string]interface{}{"hospital_number_list": hns}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var raw map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+	assert.Len(t, raw, 4)
+	assert.Equal(t, "null", string(raw["BATCHHN-MISSING"]), "a missing HN is present with null")
+	assert.Equal(t, "null", string(raw[elsewhere.PatientHN]), "patients of other hospitals are not found")
+	var results map[string]*models.Patient
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &results))
+	if assert.NotNil(t, results[first.PatientHN]) && assert.NotNil(t, results[third.PatientHN]) {
+		assert.Equal(t, first.ID, results[first.PatientHN].ID)
+		assert.Equal(t, third.ID, results[third.PatientHN].ID)
+	}
+	assert.NotContains(t, raw, second.PatientHN)
+}
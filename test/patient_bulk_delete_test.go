@@ -0,0 +1,77 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteHospitalPatientsHandler_OnlyAffectsOneHospital(t *testing.T) {
+	wiped := createIsolatedHospital(t, "DELA")
+	kept := createIsolatedHospital(t, "DELB")
+	for i := 0; i < 3; i++ {
+		seedPatient(t, createTestPatient(wiped.ID))
+	}
+	for i := 0; i < 2; i++ {
+		seedPatient(t, createTestPatient(kept.ID))
+	}
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_bulk_delete"), "password123", wiped.Name)
+
+	countPatients := func(hospitalID uint) int64 {
+		var count int64
+		testDB.Model(&models.Patient{}).Where("hospital_id = ?", hospitalID).Count(&count)
+		return count
+	}
+
+	// Without confirm=true nothing happens
+	rr := performRequest(testRouter, "DELETE", "/api/v1/patient", nil, adminToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, int64(3), countPatients(wiped.ID))
+
+	// A hospital admin cannot target another hospital
+	rr = performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/patient?confirm=true&hospital_id=%d", kept.ID), nil, adminToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = performRequest(testRouter, "DELETE", "/api/v1/patient?confirm=true", nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response models.PatientBulkDeleteResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, models.PatientBulkDeleteResponse{HospitalID: wiped.ID, Deleted: 3}, response)
+
+	assert.Equal(t, int64(0), countPatients(wiped.ID))
+	assert.Equal(t, int64(2), countPatients(kept.ID))
+
+	// Soft-deleted: the rows remain but are hidden from the API
+	var remaining int64
+	testDB.Unscoped().Model(&models.Patient{}).Where("hospital_id = ?", wiped.ID).Count(&remaining)
+	assert.Equal(t, int64(3), remaining)
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/export", nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+}
+
+// The deleted rows keep their HNs, so re-seeding the same patients only works because the HN index
+// covers live patients alone.
+func TestDeleteHospitalPatientsHandler_HNsCanBeReseeded(t *testing.T) {
+	hospital := createIsolatedHospital(t, "DELR")
+	patient := createTestPatient(hospital.ID)
+	seedPatient(t, patient)
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_bulk_reseed"), "password123", hospital.Name)
+
+	rr := performRequest(testRouter, "DELETE", "/api/v1/patient?confirm=true", nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	reseeded := createTestPatient(hospital.ID)
+	reseeded.PatientHN = patient.PatientHN
+	assert.NoError(t, testDB.Create(reseeded).Error, "a deleted patient's HN is free again")
+
+	duplicate := createTestPatient(hospital.ID)
+	duplicate.PatientHN = patient.PatientHN
+	assert.Error(t, testDB.Create(duplicate).Error, "live patients still need distinct HNs")
+}
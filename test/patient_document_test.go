@@ -0,0 +1,93 @@
+//go:build integration
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Smallest content recognised as a PNG by http.DetectContentType.
+var samplePNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func uploadDocument(path, filename string, content []byte, token string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	testRouter.ServeHTTP(rr, req)
+	return rr
+}
+
+func cleanupDocuments(t *testing.T, patientID uint) {
+	t.Cleanup(func() {
+		log.Printf("Cleaning up documents for patient ID: %d", patientID)
+		testDB.Where("patient_id = ?", patientID).Delete(&models.PatientDocument{})
+		testDB.Where("patient_id = ?", patientID).Delete(&models.AuditLog{})
+	})
+}
+
+func TestPatientDocumentHandlers_UploadListDownloadDelete(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupDocuments(t, testPatient.ID)
+	staffToken := getAuthToken(t, uniqueUsername("staff_docs"), "password123", "Hospital A")
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_docs"), "password123", "Hospital A")
+	basePath := fmt.Sprintf("/api/v1/patient/%d/documents", testPatient.ID)
+
+	// Upload
+	rr := uploadDocument(basePath, "consent.png", samplePNG, staffToken)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var uploaded models.PatientDocument
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &uploaded))
+	assert.Equal(t, models.DocumentContentTypePNG, uploaded.ContentType)
+	assert.Len(t, uploaded.SHA256, 64)
+	assert.Equal(t, uint(1), uploaded.HospitalID)
+
+	// List
+	rr = performRequest(testRouter, "GET", basePath, nil, staffToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var page struct {
+		Data  []models.PatientDocument `json:"data"`
+		Total int64                    `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, int64(1), page.Total)
+
+	// Download
+	downloadPath := fmt.Sprintf("%s/%d/download", basePath, uploaded.ID)
+	rr = performRequest(testRouter, "GET", downloadPath, nil, staffToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, samplePNG, rr.Body.Bytes())
+	assert.Equal(t, `attachment; filename=consent.png`, rr.Header().Get("Content-Disposition"))
+
+	// Staff from another hospital cannot download it
+	otherToken := getAuthToken(t, uniqueUsername("staff_docs_b"), "password123", "Hospital B")
+	rr = performRequest(testRouter, "GET", downloadPath, nil, otherToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Only admins delete
+	deletePath := fmt.Sprintf("%s/%d", basePath, uploaded.ID)
+	rr = performRequest(testRouter, "DELETE", deletePath, nil, staffToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = performRequest(testRouter, "DELETE", deletePath, nil, adminToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = performRequest(testRouter, "GET", downloadPath, nil, staffToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
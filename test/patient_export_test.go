@@ -0,0 +1,58 @@
+//go:build integration
+
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportPatientsHandler_NDJSONStreamsEveryRow(t *testing.T) {
+	const rows = 3000
+	hospital := createIsolatedHospital(t, "NDJ")
+	patients := make([]models.Patient, rows)
+	for i := range patients {
+		patients[i] = models.Patient{
+			HospitalID:  hospital.ID,
+			PatientHN:   fmt.Sprintf("%s-%05d", hospital.Code, i),
+			FirstNameTH: "ทดสอบ",
+			LastNameTH:  "นามสกุล",
+			FirstNameEN: "Stream",
+			LastNameEN:  "Patient",
+			Gender:      "F",
+		}
+	}
+	if err := testDB.CreateInBatches(patients, 1000).Error; err != nil {
+		t.Fatalf("Failed to seed export patients: %v", err)
+	}
+	t.Cleanup(func() {
+		testDB.Unscoped().Where("hospital_id = ?", hospital.ID).Delete(&models.Patient{})
+	})
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_ndjson"), "password123", hospital.Name)
+
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/export?format=ndjson", nil, adminToken)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	scanner := bufio.NewScanner(rr.Body)
+	count := 0
+	var lastID uint
+	for scanner.Scan() {
+		var patient models.Patient
+		if !assert.NoError(t, json.Unmarshal(scanner.Bytes(), &patient), "line %d is not valid JSON", count+1) {
+			break
+		}
+		assert.Equal(t, hospital.ID, patient.HospitalID)
+		assert.Greater(t, patient.ID, lastID, "rows must come in ID order")
+		lastID = patient.ID
+		count++
+	}
+	assert.NoError(t, scanner.Err())
+	assert.Equal(t, rows, count)
+}
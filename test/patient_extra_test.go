@@ -0,0 +1,64 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatientExtraFields_UpdateAndSearch(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	other := createTestPatient(1)
+	seedPatient(t, other)
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_extra"), "password123", "Hospital A")
+	resetHospitalConfig(t, 1, adminToken)
+	staffToken := getAuthToken(t, uniqueUsername("staff_extra"), "password123", "Hospital A")
+
+	body := hospitalConfigBody(0, false)
+	body.ExtraFieldKeys = []string{"ward_code", "referral_source"}
+	rr := performRequest(testRouter, "PUT", "/api/v1/admin/hospital/1/config", body, adminToken)
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+
+	path := fmt.Sprintf("/api/v1/patient/%d", patient.ID)
+	rr = performRequest(testRouter, "PATCH", path, gin.H{"extra": gin.H{"ward_code": "W3", "referral_source": "clinic"}}, staffToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	// Later updates merge into the stored fields
+	rr = performRequest(testRouter, "PATCH", path, gin.H{"extra": gin.H{"referral_source": ""}}, staffToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var stored models.Patient
+	testDB.First(&stored, patient.ID)
+	assert.Equal(t, models.ExtraFields{"ward_code": "W3"}, stored.Extra)
+
+	rr = performRequest(testRouter, "PATCH", path, gin.H{"extra": gin.H{"shoe_size": "42"}}, staffToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/search?extra.ward_code=W3", nil, staffToken)
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var results []models.Patient
+	if !assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results)) {
+		t.FailNow()
+	}
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, patient.ID, results[0].ID)
+		assert.Equal(t, "W3", results[0].Extra["ward_code"])
+	}
+
+	// Other patients keep an empty object
+	var untouched models.Patient
+	testDB.First(&untouched, other.ID)
+	assert.Empty(t, untouched.Extra)
+	raw, _ := json.Marshal(untouched)
+	assert.NotContains(t, string(raw), `"extra"`)
+}
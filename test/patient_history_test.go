@@ -0,0 +1,59 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatientHistoryImport_DiffThenApply(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	t.Cleanup(func() {
+		testDB.Where("patient_id = ?", patient.ID).Delete(&models.AuditLog{})
+	})
+	token := getAuthToken(t, uniqueUsername("staff_history"), "password123", "Hospital A")
+	importURL := fmt.Sprintf("/api/v1/patient/%d/history/import", patient.ID)
+	history := fmt.Sprintf("HN: %s\nชื่อ: %s\nนามสกุล: ประวัติเก่า\nหมู่เลือด: AB+\n", patient.PatientHN, patient.FirstNameTH)
+
+	rr := uploadDocument(importURL, "history.txt", []byte(history), token)
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var diff models.PatientHistoryDiff
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &diff))
+	assert.Len(t, diff.Matched, 2)
+	assert.Equal(t, []models.PatientHistoryChange{
+		{Field: models.HistoryFieldLastNameTH, Current: patient.LastNameTH, Imported: "ประวัติเก่า"},
+		{Field: models.HistoryFieldBloodType, Current: "", Imported: "AB+"},
+	}, diff.Mismatched)
+
+	// The upload alone changes nothing
+	var unchanged models.Patient
+	assert.NoError(t, testDB.First(&unchanged, patient.ID).Error)
+	assert.Equal(t, patient.LastNameTH, unchanged.LastNameTH)
+
+	rr = performRequest(testRouter, "PATCH", importURL, gin.H{"changes": diff.Mismatched}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var reloaded models.Patient
+	assert.NoError(t, testDB.First(&reloaded, patient.ID).Error)
+	assert.Equal(t, "ประวัติเก่า", reloaded.LastNameTH)
+	if assert.NotNil(t, reloaded.BloodType) {
+		assert.Equal(t, "AB+", *reloaded.BloodType)
+	}
+	var audits int64
+	testDB.Model(&models.AuditLog{}).Where("patient_id = ? AND action = ?", patient.ID, models.AuditActionHistoryImported).Count(&audits)
+	assert.Equal(t, int64(1), audits)
+
+	// The same diff cannot be applied twice
+	rr = performRequest(testRouter, "PATCH", importURL, gin.H{"changes": diff.Mismatched}, token)
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+}
@@ -0,0 +1,109 @@
+//go:build integration
+
+package test
+
+import (
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// enableAutoGenerateHN reopens the database with AUTO_GENERATE_HN on for the rest of the test.
+func enableAutoGenerateHN(t *testing.T) {
+	t.Helper()
+	cfg := *testConfig
+	cfg.AutoGenerateHN = true
+	if !assert.NoError(t, database.Open(&cfg)) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		if err := database.Open(testConfig); err != nil {
+			t.Fatalf("Failed to reopen the test database: %v", err)
+		}
+	})
+}
+
+// createPatientCleanedUp creates a patient through the repository and removes it after the test.
+func createPatientCleanedUp(t *testing.T, patient *models.Patient) error {
+	t.Helper()
+	err := database.CreatePatient(patient)
+	if err == nil {
+		t.Cleanup(func() { testDB.Unscoped().Delete(&models.Patient{}, patient.ID) })
+	}
+	return err
+}
+
+func TestCreatePatient_GeneratesHN(t *testing.T) {
+	enableAutoGenerateHN(t)
+	hospital := createIsolatedHospital(t, "AHN")
+
+	first := createTestPatient(hospital.ID)
+	first.PatientHN = ""
+	if assert.NoError(t, createPatientCleanedUp(t, first)) {
+		assert.Equal(t, hospital.Code+"-000001", first.PatientHN)
+	}
+
+	// Deleted patients keep their HN, and HNs that merely start with the code don't count
+	deleted := createTestPatient(hospital.ID)
+	deleted.PatientHN = hospital.Code + "-000007"
+	seedPatient(t, deleted)
+	testDB.Delete(deleted)
+	seedPatient(t, &models.Patient{HospitalID: hospital.ID, PatientHN: hospital.Code + "-X99", FirstNameTH: "ก", LastNameTH: "ข", FirstNameEN: "A", LastNameEN: "B"})
+
+	second := createTestPatient(hospital.ID)
+	second.PatientHN = ""
+	if assert.NoError(t, createPatientCleanedUp(t, second)) {
+		assert.Equal(t, hospital.Code+"-000008", second.PatientHN)
+	}
+}
+
+func TestCreatePatient_KeepsClientHN(t *testing.T) {
+	enableAutoGenerateHN(t)
+	hospital := createIsolatedHospital(t, "CHN")
+
+	patient := createTestPatient(hospital.ID)
+	supplied := patient.PatientHN
+	if assert.NoError(t, createPatientCleanedUp(t, patient)) {
+		assert.Equal(t, supplied, patient.PatientHN)
+	}
+
+	unknown := createTestPatient(999999)
+	unknown.PatientHN = ""
+	assert.ErrorIs(t, createPatientCleanedUp(t, unknown), database.ErrHospitalNotFound)
+	assert.Empty(t, unknown.PatientHN)
+}
+
+func TestCreatePatient_RetriesHNCollision(t *testing.T) {
+	enableAutoGenerateHN(t)
+	hospital := createIsolatedHospital(t, "RHN")
+
+	// Another create takes the first HN but hasn't committed, so it is not yet visible
+	other := testDB.Begin()
+	taken := createTestPatient(hospital.ID)
+	taken.PatientHN = hospital.Code + "-000001"
+	if !assert.NoError(t, other.Create(taken).Error) {
+		other.Rollback()
+		t.FailNow()
+	}
+	t.Cleanup(func() { testDB.Unscoped().Delete(&models.Patient{}, taken.ID) })
+
+	patient := createTestPatient(hospital.ID)
+	patient.PatientHN = ""
+	created := make(chan error, 1)
+	go func() { created <- createPatientCleanedUp(t, patient) }()
+
+	// Commit once the insert of the same HN waits on the uncommitted row, so it then collides
+	assert.Eventually(t, func() bool {
+		var waiting int64
+		testDB.Raw(`SELECT count(*) FROM pg_stat_activity WHERE wait_event_type = 'Lock' AND query LIKE 'INSERT INTO "patients"%'`).Scan(&waiting)
+		return waiting > 0
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.NoError(t, other.Commit().Error)
+
+	if assert.NoError(t, <-created) {
+		assert.Equal(t, hospital.Code+"-000002", patient.PatientHN)
+	}
+}
@@ -0,0 +1,113 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// createLabel creates a label through the admin API and returns it.
+func createLabel(t *testing.T, token, name string) models.PatientLabel {
+	t.Helper()
+	rr := performRequest(testRouter, "POST", "/api/v1/admin/label", gin.H{"name": name, "color": "#ffd700"}, token)
+	if !assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var label models.PatientLabel
+	if !assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &label)) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		testDB.Where("label_id = ?", label.ID).Delete(&models.PatientLabelAssignment{})
+		testDB.Delete(&models.PatientLabel{}, label.ID)
+	})
+	return label
+}
+
+func TestPatientLabels_AssignAndSearch(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	other := createTestPatient(1)
+	seedPatient(t, other)
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_label"), "password123", "Hospital A")
+	staffToken := getAuthToken(t, uniqueUsername("staff_label"), "password123", "Hospital A")
+	label := createLabel(t, adminToken, uniqueUsername("VIP"))
+
+	// Names are unique per hospital, ignoring case
+	rr := performRequest(testRouter, "POST", "/api/v1/admin/label", gin.H{"name": label.Name + " ", "color": "#000000"}, adminToken)
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+
+	assignURL := fmt.Sprintf("/api/v1/patient/%d/labels/%d", patient.ID, label.ID)
+	rr = performRequest(testRouter, "POST", assignURL, nil, staffToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	rr = performRequest(testRouter, "POST", assignURL, nil, staffToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code, "assigning twice is a no-op")
+
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/search?label_id=%d", label.ID), nil, staffToken)
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var results []models.Patient
+	if !assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results)) {
+		t.FailNow()
+	}
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, patient.ID, results[0].ID)
+		if assert.Len(t, results[0].Labels, 1) {
+			assert.Equal(t, label.Name, results[0].Labels[0].Name)
+		}
+	}
+
+	rr = performRequest(testRouter, "DELETE", assignURL, nil, staffToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	rr = performRequest(testRouter, "DELETE", assignURL, nil, staffToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/search?label_id=%d", label.ID), nil, staffToken)
+	if !assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results)) {
+		t.FailNow()
+	}
+	assert.Empty(t, results)
+}
+
+func TestPatientLabels_LabelOfAnotherHospitalCannotBeAssigned(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	otherAdminToken := getAdminAuthToken(t, uniqueUsername("admin_label_b"), "password123", "Hospital B")
+	foreign := createLabel(t, otherAdminToken, uniqueUsername("High Risk"))
+	staffToken := getAuthToken(t, uniqueUsername("staff_label_a"), "password123", "Hospital A")
+
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/labels/%d", patient.ID, foreign.ID), nil, staffToken)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Label not found")
+	var count int64
+	testDB.Model(&models.PatientLabelAssignment{}).Where("patient_id = ?", patient.ID).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestPatientLabels_DeleteRemovesAssignments(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_label_del"), "password123", "Hospital A")
+	label := createLabel(t, adminToken, uniqueUsername("Allergic to Penicillin"))
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/labels/%d", patient.ID, label.ID), nil, adminToken)
+	if !assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+
+	rr = performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/admin/label/%d", label.ID), nil, adminToken)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	var count int64
+	testDB.Model(&models.PatientLabelAssignment{}).Where("label_id = ?", label.ID).Count(&count)
+	assert.Zero(t, count)
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/admin/label/%d", label.ID), nil, adminToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,71 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// uniqueNationalID returns a national ID with a valid check digit that no other test uses.
+func uniqueNationalID() string {
+	digits := fmt.Sprintf("%012d", time.Now().UnixNano()%1_000_000_000_000)
+	sum := 0
+	for i, d := range digits {
+		sum += int(d-'0') * (13 - i)
+	}
+	return fmt.Sprintf("%s%d", digits, (11-sum%11)%10)
+}
+
+func TestGetPatientByNationalID(t *testing.T) {
+	hospital := createIsolatedHospital(t, "NID")
+	otherHospital := createIsolatedHospital(t, "NIDO")
+	token := getAuthToken(t, uniqueUsername("staff_nid"), "password123", hospital.Name)
+
+	patient := createTestPatient(hospital.ID)
+	patient.NationalID = uniqueNationalID()
+	seedPatient(t, patient)
+	elsewhere := createTestPatient(otherHospital.ID)
+	elsewhere.NationalID = uniqueNationalID()
+	seedPatient(t, elsewhere)
+	t.Cleanup(func() { testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.PatientAccessLog{}) })
+
+	t.Run("found", func(t *testing.T) {
+		nid := patient.NationalID
+		rr := performRequest(testRouter, "GET", "/api/v1/patient/by-national-id/"+nid[:1]+"-"+nid[1:5]+"-"+nid[5:10]+"-"+nid[10:12]+"-"+nid[12:], nil, token)
+
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var found models.Patient
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &found))
+		assert.Equal(t, patient.ID, found.ID)
+		assert.Equal(t, patient.NationalID, found.NationalID)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rr := performRequest(testRouter, "GET", "/api/v1/patient/by-national-id/"+uniqueNationalID(), nil, token)
+		assert.Equal(t, http.StatusNotFound, rr.Code, rr.Body.String())
+
+		rr = performRequest(testRouter, "GET", "/api/v1/patient/by-national-id/"+elsewhere.NationalID, nil, token)
+		assert.Equal(t, http.StatusNotFound, rr.Code, "patients of other hospitals are not found")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		rr := performRequest(testRouter, "GET", "/api/v1/patient/by-national-id/NID123", nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	})
+
+	t.Run("shared by several patients", func(t *testing.T) {
+		twin := createTestPatient(hospital.ID)
+		twin.NationalID = patient.NationalID
+		seedPatient(t, twin)
+
+		rr := performRequest(testRouter, "GET", "/api/v1/patient/by-national-id/"+patient.NationalID, nil, token)
+		assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+	})
+}
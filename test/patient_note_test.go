@@ -0,0 +1,192 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cleanupNotesAndAudit removes a patient's notes and audit entries once the test ends.
+func cleanupNotesAndAudit(t *testing.T, patientID uint) {
+	t.Cleanup(func() {
+		log.Printf("Cleaning up notes and audit entries for patient ID: %d", patientID)
+		testDB.Unscoped().Where("patient_id = ?", patientID).Delete(&models.PatientNote{})
+		testDB.Where("patient_id = ?", patientID).Delete(&models.AuditLog{})
+	})
+}
+
+func createNote(t *testing.T, patientID uint, body string, pinned bool, token string) models.PatientNote {
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/notes", patientID),
+		models.PatientNoteCreateRequest{Body: body, Pinned: pinned}, token)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Setup failed: could not create note: %d %s", rr.Code, rr.Body.String())
+	}
+	var note models.PatientNote
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &note))
+	return note
+}
+
+func TestPatientNoteHandlers_ListPinnedFirstThenNewest(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupNotesAndAudit(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_notes"), "password123", "Hospital A")
+
+	first := createNote(t, testPatient.ID, "first note", false, authToken)
+	pinned := createNote(t, testPatient.ID, "patient prefers Thai-language documents", true, authToken)
+	latest := createNote(t, testPatient.ID, "latest note", false, authToken)
+
+	rr := performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d/notes?page=1&page_size=10", testPatient.ID), nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var page struct {
+		Data  []models.PatientNote `json:"data"`
+		Total int64                `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, int64(3), page.Total)
+	if assert.Len(t, page.Data, 3) {
+		assert.Equal(t, pinned.ID, page.Data[0].ID, "Pinned note should come first")
+		assert.Equal(t, latest.ID, page.Data[1].ID)
+		assert.Equal(t, first.ID, page.Data[2].ID)
+	}
+}
+
+func TestPatientNoteHandlers_BodyTooLarge(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupNotesAndAudit(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_notes_big"), "password123", "Hospital A")
+
+	body := models.PatientNoteCreateRequest{Body: strings.Repeat("x", models.MaxPatientNoteLength+1)}
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/notes", testPatient.ID), body, authToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// The limit counts characters, so a full-length Thai note fits despite taking three bytes per character
+	body = models.PatientNoteCreateRequest{Body: strings.Repeat("ก", models.MaxPatientNoteLength)}
+	rr = performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/notes", testPatient.ID), body, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
+
+func TestPatientNoteHandlers_UpdateByAuthorOnly(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupNotesAndAudit(t, testPatient.ID)
+	authorToken := getAuthToken(t, uniqueUsername("staff_note_editor"), "password123", "Hospital A")
+	otherToken := getAuthToken(t, uniqueUsername("staff_note_bystander"), "password123", "Hospital A")
+	note := createNote(t, testPatient.ID, "BP 120/80", false, authorToken)
+	notePath := fmt.Sprintf("/api/v1/patient/%d/notes/%d", testPatient.ID, note.ID)
+
+	rr := performRequest(testRouter, "PUT", notePath, map[string]interface{}{"body": "BP 130/85"}, otherToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = performRequest(testRouter, "PUT", notePath, map[string]interface{}{"body": "BP 130/85", "pinned": true}, authorToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var updated models.PatientNote
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+	assert.Equal(t, "BP 130/85", updated.Body)
+	assert.True(t, updated.Pinned)
+	assert.Equal(t, uint(1), updated.HospitalID)
+}
+
+func TestPatientNoteHandlers_PrivateNotesVisibleToAuthorOnly(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupNotesAndAudit(t, testPatient.ID)
+	authorToken := getAuthToken(t, uniqueUsername("staff_note_private"), "password123", "Hospital A")
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_note_private"), "password123", "Hospital A")
+
+	createNote(t, testPatient.ID, "shared note", false, authorToken)
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/notes", testPatient.ID),
+		models.PatientNoteCreateRequest{Body: "private reminder", IsPrivate: true}, authorToken)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var private models.PatientNote
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &private))
+
+	listTotal := func(token string) int64 {
+		rr := performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d/notes", testPatient.ID), nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var page struct {
+			Total int64 `json:"total"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		return page.Total
+	}
+	assert.Equal(t, int64(2), listTotal(authorToken))
+	assert.Equal(t, int64(1), listTotal(adminToken), "even admins do not see someone else's private note")
+
+	// Not visible means not found, for editing and deleting too
+	privatePath := fmt.Sprintf("/api/v1/patient/%d/notes/%d", testPatient.ID, private.ID)
+	rr = performRequest(testRouter, "DELETE", privatePath, nil, adminToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = performRequest(testRouter, "PUT", privatePath, map[string]interface{}{"is_private": false}, authorToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, int64(2), listTotal(adminToken), "once shared, the note is listed for everyone")
+}
+
+func TestPatientNoteHandlers_CrossHospitalIsolation(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupNotesAndAudit(t, testPatient.ID)
+	hospitalAToken := getAuthToken(t, uniqueUsername("staff_note_hospA"), "password123", "Hospital A")
+	hospitalBToken := getAuthToken(t, uniqueUsername("staff_note_hospB"), "password123", "Hospital B")
+	note := createNote(t, testPatient.ID, "hospital A only", false, hospitalAToken)
+	notePath := fmt.Sprintf("/api/v1/patient/%d/notes/%d", testPatient.ID, note.ID)
+
+	rr := performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d/notes", testPatient.ID), nil, hospitalBToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = performRequest(testRouter, "PUT", notePath, map[string]interface{}{"body": "changed"}, hospitalBToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = performRequest(testRouter, "DELETE", notePath, nil, hospitalBToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestPatientNoteHandlers_DeleteRestrictedToAuthorOrAdmin(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupNotesAndAudit(t, testPatient.ID)
+	authorToken := getAuthToken(t, uniqueUsername("staff_note_author"), "password123", "Hospital A")
+	otherToken := getAuthToken(t, uniqueUsername("staff_note_other"), "password123", "Hospital A")
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_note"), "password123", "Hospital A")
+
+	authorNote := createNote(t, testPatient.ID, "author's note", false, authorToken)
+	adminDeletesNote := createNote(t, testPatient.ID, "another note", false, authorToken)
+
+	// Another regular staff member cannot delete it
+	rr := performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/patient/%d/notes/%d", testPatient.ID, authorNote.ID), nil, otherToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// The author can
+	rr = performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/patient/%d/notes/%d", testPatient.ID, authorNote.ID), nil, authorToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// An admin can delete someone else's note
+	rr = performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/patient/%d/notes/%d", testPatient.ID, adminDeletesNote.ID), nil, adminToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Both creations and deletions are in the audit trail
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d/audit", testPatient.ID), nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var page struct {
+		Data []models.AuditLog `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	actions := map[string]int{}
+	for _, entry := range page.Data {
+		actions[entry.Action]++
+	}
+	assert.Equal(t, 2, actions[models.AuditActionNoteCreated])
+	assert.Equal(t, 2, actions[models.AuditActionNoteDeleted])
+
+	// The audit trail itself is admin-only
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d/audit", testPatient.ID), nil, authorToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
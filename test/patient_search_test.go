@@ -1,13 +1,17 @@
+//go:build integration
+
 // hospital/test/patient_search_test.go
 package test
 
 import (
 	"encoding/json"
 	"fmt"
+	"hospital-middleware/internal/database"
 	"hospital-middleware/internal/models"
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,8 +69,26 @@ func createTestPatient(hospitalID uint) *models.Patient {
 	}
 }
 
+// decodeSearchResults unmarshals the data of a search response envelope into results.
+func decodeSearchResults(body []byte, results interface{}) error {
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return err
+	}
+	return json.Unmarshal(response.Data, results)
+}
+
 // --- Patient Search Test Cases ---
 
+func TestPatientSearchIndexes(t *testing.T) {
+	migrator := testDB.Migrator()
+	for _, index := range []string{"idx_patients_phone_number", "idx_patients_email"} {
+		assert.True(t, migrator.HasIndex(&models.Patient{}, index), "missing index %s", index)
+	}
+}
+
 func TestSearchPatientHandler_FoundByNationalID(t *testing.T) {
 	// 1. Seed Patient Data for Hospital A (ID 1)
 	testPatient := createTestPatient(1)
@@ -88,7 +110,7 @@ func TestSearchPatientHandler_FoundByNationalID(t *testing.T) {
 
 	// 4. Assertions
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.Len(t, results, 1, "Expected exactly one patient result")
 	if len(results) == 1 {
@@ -98,6 +120,413 @@ func TestSearchPatientHandler_FoundByNationalID(t *testing.T) {
 	}
 }
 
+func TestSearchPatientHandler_FoundByPhoneSuffix(t *testing.T) {
+	// 1. Seed a patient whose number ends in a suffix no other test generates
+	suffix := fmt.Sprintf("%09d", time.Now().UnixNano()%1000000000)
+	testPatient := createTestPatient(1)
+	testPatient.PhoneNumber = "+66" + suffix
+	seedPatient(t, testPatient)
+	other := createTestPatient(1)
+	other.PhoneNumber = suffix + "0" // Contains the digits, but not at the end
+	seedPatient(t, other)
+
+	authToken := getAuthToken(t, uniqueUsername("staff_hospA_phone"), "password123", "Hospital A")
+
+	// 2. Search by the last digits only
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?phone_suffix="+suffix, nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var results []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, testPatient.PatientHN, results[0].PatientHN)
+	}
+}
+
+func TestSearchPatientHandler_NameMatchModes(t *testing.T) {
+	// 1. Seed names that are the exact base, start with it, and merely contain it
+	base := fmt.Sprintf("Nm%d", time.Now().UnixNano())
+	for _, firstName := range []string{base, base + "na", "Jo" + base} {
+		patient := createTestPatient(1)
+		patient.FirstNameEN = firstName
+		seedPatient(t, patient)
+	}
+	authToken := getAuthToken(t, uniqueUsername("staff_hospA_namematch"), "password123", "Hospital A")
+
+	// 2. Each mode narrows the same data differently; no mode keeps the original substring match
+	for mode, want := range map[string]int{"": 3, "contains": 3, "prefix": 2, "exact": 1} {
+		t.Run("mode="+mode, func(t *testing.T) {
+			query := url.Values{}
+			query.Add("first_name_en", base)
+			if mode != "" {
+				query.Add("name_match", mode)
+			}
+			rr := performRequest(testRouter, "GET", "/api/v1/patient/search?"+query.Encode(), nil, authToken)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var results []models.Patient
+			assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+			assert.Len(t, results, want)
+		})
+	}
+}
+
+func TestSearchPatientHandler_LikeWildcardsMatchLiterally(t *testing.T) {
+	// 1. Names containing the LIKE wildcards and the escape character, plus names they would match as wildcards
+	base := fmt.Sprintf("Wc%d", time.Now().UnixNano())
+	for _, firstName := range []string{base + "100%", base + "1000", base + "a_b", base + "axb", base + `c\d`} {
+		patient := createTestPatient(1)
+		patient.FirstNameEN = firstName
+		seedPatient(t, patient)
+	}
+	authToken := getAuthToken(t, uniqueUsername("staff_hospA_wildcards"), "password123", "Hospital A")
+
+	// 2. Every name match mode treats "%", "_" and "\" as plain characters
+	for _, mode := range []string{"contains", "prefix", "exact"} {
+		for _, term := range []string{base + "100%", base + "a_b", base + `c\d`} {
+			t.Run(mode+"/"+term, func(t *testing.T) {
+				query := url.Values{}
+				query.Add("first_name_en", term)
+				query.Add("name_match", mode)
+				rr := performRequest(testRouter, "GET", "/api/v1/patient/search?"+query.Encode(), nil, authToken)
+				assert.Equal(t, http.StatusOK, rr.Code)
+
+				var results []models.Patient
+				assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+				if assert.Len(t, results, 1, "wildcards must not match other names") {
+					assert.Equal(t, term, results[0].FirstNameEN)
+				}
+			})
+		}
+	}
+
+	// 3. A bare wildcard is not a match-everything pattern
+	for _, term := range []string{"%", "_", base + "%"} {
+		query := url.Values{}
+		query.Add("first_name_en", term)
+		query.Add("name_match", "prefix")
+		rr := performRequest(testRouter, "GET", "/api/v1/patient/search?"+query.Encode(), nil, authToken)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var results []models.Patient
+		assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+		for _, patient := range results {
+			assert.Contains(t, patient.FirstNameEN, term)
+		}
+	}
+}
+
+func TestSearchPatientHandler_NationalIDList(t *testing.T) {
+	first := createTestPatient(1)
+	seedPatient(t, first)
+	second := createTestPatient(1)
+	seedPatient(t, second)
+	otherHospital := createTestPatient(2)
+	seedPatient(t, otherHospital)
+	authToken := getAuthToken(t, uniqueUsername("staff_hospA_idlist"), "password123", "Hospital A")
+
+	// One unknown ID and one from another hospital, neither of which may be returned
+	ids := []string{first.NationalID, fmt.Sprintf("MISSING%d", time.Now().UnixNano()), otherHospital.NationalID, second.NationalID}
+	query := url.Values{}
+	query.Add("national_id", strings.Join(ids, ","))
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?"+query.Encode(), nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var results []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+	if assert.Len(t, results, 2) {
+		assert.ElementsMatch(t, []uint{first.ID, second.ID}, []uint{results[0].ID, results[1].ID})
+	}
+}
+
+func TestSearchPatientHandler_BirthYearBoundaries(t *testing.T) {
+	hospital := createIsolatedHospital(t, "BYEAR")
+	byDate := map[string]*models.Patient{}
+	for _, date := range []string{"1979-12-31", "1980-01-01", "1980-06-15", "1980-12-31", "1981-01-01"} {
+		dob, _ := time.Parse("2006-01-02", date)
+		patient := createTestPatient(hospital.ID)
+		patient.DateOfBirth = &dob
+		seedPatient(t, patient)
+		byDate[date] = patient
+	}
+	want := []uint{byDate["1980-01-01"].ID, byDate["1980-06-15"].ID, byDate["1980-12-31"].ID}
+
+	staffToken := getAuthToken(t, uniqueUsername("staff_birth_year"), "password123", hospital.Name)
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?birth_year=1980", nil, staffToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var results []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+	var ids []uint
+	for _, p := range results {
+		ids = append(ids, p.ID)
+	}
+	assert.ElementsMatch(t, want, ids)
+
+	// Export accepts the same filter
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_birth_year"), "password123", hospital.Name)
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/export?birth_year=1980", nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var exported []models.Patient
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &exported))
+	ids = nil
+	for _, p := range exported {
+		ids = append(ids, p.ID)
+	}
+	assert.ElementsMatch(t, want, ids)
+}
+
+func TestSearchPatientHandler_MatchMode(t *testing.T) {
+	hospital := createIsolatedHospital(t, "MATCH")
+	phone := fmt.Sprintf("09%08d", time.Now().UnixNano()%100000000)
+	email := fmt.Sprintf("match.%d@example.com", time.Now().UnixNano())
+	seed := func(hospitalID uint, withPhone, withEmail bool) uint {
+		patient := createTestPatient(hospitalID)
+		if withPhone {
+			patient.PhoneNumber = phone
+		}
+		if withEmail {
+			patient.Email = email
+		}
+		seedPatient(t, patient)
+		return patient.ID
+	}
+	phoneOnly := seed(hospital.ID, true, false)
+	emailOnly := seed(hospital.ID, false, true)
+	both := seed(hospital.ID, true, true)
+	seed(hospital.ID, false, false)
+	seed(1, true, true) // Another hospital's patient stays out even with match=any
+	authToken := getAuthToken(t, uniqueUsername("staff_match"), "password123", hospital.Name)
+
+	for match, want := range map[string][]uint{
+		"":    {both},
+		"all": {both},
+		"any": {phoneOnly, emailOnly, both},
+	} {
+		t.Run("match="+match, func(t *testing.T) {
+			query := url.Values{}
+			query.Add("phone_number", phone)
+			query.Add("email", email)
+			if match != "" {
+				query.Add("match", match)
+			}
+			rr := performRequest(testRouter, "GET", "/api/v1/patient/search?"+query.Encode(), nil, authToken)
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+			var results []models.Patient
+			assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+			var ids []uint
+			for _, p := range results {
+				ids = append(ids, p.ID)
+			}
+			assert.ElementsMatch(t, want, ids)
+		})
+	}
+}
+
+// seedMiddleNamePatients seeds a patient with a distinctive English and Thai middle name, plus one
+// without a middle name and one with a different middle name, and returns the first.
+func seedMiddleNamePatients(t *testing.T, middleNameEN, middleNameTH string) *models.Patient {
+	for _, middleName := range []string{"", "Unrelated"} {
+		other := createTestPatient(1)
+		other.MiddleNameEN = middleName
+		seedPatient(t, other)
+	}
+	patient := createTestPatient(1)
+	patient.MiddleNameEN = middleNameEN
+	patient.MiddleNameTH = middleNameTH
+	seedPatient(t, patient)
+	return patient
+}
+
+func TestSearchPatientHandler_MiddleNameOnly(t *testing.T) {
+	middleName := fmt.Sprintf("Mid%d", time.Now().UnixNano())
+	patient := seedMiddleNamePatients(t, middleName, "กลาง"+middleName)
+	authToken := getAuthToken(t, uniqueUsername("staff_hospA_middle"), "password123", "Hospital A")
+
+	for _, field := range []string{"middle_name_en", "middle_name_th"} {
+		t.Run(field, func(t *testing.T) {
+			value := middleName
+			if field == "middle_name_th" {
+				value = "กลาง" + middleName
+			}
+			query := url.Values{}
+			query.Add(field, value)
+			query.Add("name_match", "exact")
+			rr := performRequest(testRouter, "GET", "/api/v1/patient/search?"+query.Encode(), nil, authToken)
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+			var results []models.Patient
+			assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, patient.ID, results[0].ID)
+			}
+		})
+	}
+}
+
+func TestSearchPatients_MiddleNameOnly(t *testing.T) {
+	middleName := fmt.Sprintf("Mid%d", time.Now().UnixNano())
+	patient := seedMiddleNamePatients(t, middleName, "")
+
+	for _, mode := range []string{models.NameMatchExact, models.NameMatchPrefix, models.NameMatchContains} {
+		t.Run(mode, func(t *testing.T) {
+			// Only the middle name is set: no first or last name criteria are combined with it
+			query := &models.PatientSearchQuery{MiddleNameEN: &middleName, NameMatch: &mode}
+			results, err := database.SearchPatients(query, 1, 0)
+			assert.NoError(t, err)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, patient.ID, results[0].ID)
+			}
+		})
+	}
+}
+
+func TestSearchPatientHandler_ThaiNormalization(t *testing.T) {
+	// 1. Seed "ปู่" typed with the tone mark before the vowel; it renders the same as the NFC form
+	lastName := fmt.Sprintf("Thai%d", time.Now().UnixNano())
+	patient := createTestPatient(1)
+	patient.FirstNameTH = "\u0e1b\u0e48\u0e39"
+	patient.LastNameEN = lastName
+	seedPatient(t, patient)
+	authToken := getAuthToken(t, uniqueUsername("staff_hospA_thai"), "password123", "Hospital A")
+
+	search := func(firstNameTH string, normalizeThai bool) []models.Patient {
+		query := url.Values{}
+		query.Add("first_name_th", firstNameTH)
+		query.Add("last_name_en", lastName)
+		query.Add("name_match", "exact")
+		if normalizeThai {
+			query.Add("normalize_thai", "true")
+		}
+		rr := performRequest(testRouter, "GET", "/api/v1/patient/search?"+query.Encode(), nil, authToken)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var results []models.Patient
+		assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+		return results
+	}
+
+	// 2. The NFC spelling, with different code points, finds it
+	assert.Len(t, search("\u0e1b\u0e39\u0e48", false), 1)
+	// 3. Without the tone mark it only matches with normalize_thai
+	assert.Empty(t, search("\u0e1b\u0e39", false))
+	assert.Len(t, search("\u0e1b\u0e39", true), 1)
+}
+
+func TestSearchPatientHandler_CreatedRange(t *testing.T) {
+	// 1. Seed patients registered at controlled times in the configured zone, where date-only bounds
+	// name their days; the unique last name keeps other rows out
+	bangkok, err := time.LoadLocation(testConfig.Timezone)
+	assert.NoError(t, err)
+	lastName := fmt.Sprintf("Reg%d", time.Now().UnixNano())
+	for _, created := range []time.Time{
+		time.Date(2001, 3, 31, 23, 59, 0, 0, bangkok),
+		time.Date(2001, 4, 1, 0, 0, 0, 0, bangkok),
+		time.Date(2001, 4, 7, 18, 30, 0, 0, bangkok),
+		time.Date(2001, 4, 8, 0, 0, 0, 0, bangkok),
+	} {
+		patient := createTestPatient(1)
+		patient.LastNameEN = lastName
+		patient.CreatedAt = created
+		seedPatient(t, patient)
+	}
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_hospA_created"), "password123", "Hospital A")
+
+	// 2. Search and export apply the same range; a date-only created_to covers that whole day
+	for name, tc := range map[string]struct {
+		query string
+		want  int
+	}{
+		"week":           {"created_from=2001-04-01&created_to=2001-04-07", 2},
+		"from only":      {"created_from=2001-04-07", 2},
+		"to only":        {"created_to=2001-03-31", 1},
+		"timestamp":      {"created_from=2001-03-31T17:00:00Z&created_to=2001-04-07T11:30:00Z", 2},
+		"single instant": {"created_from=2001-04-07T17:00:00Z&created_to=2001-04-07T17:00:00Z", 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, path := range []string{"/api/v1/patient/search", "/api/v1/patient/export"} {
+				rr := performRequest(testRouter, "GET", path+"?last_name_en="+lastName+"&"+tc.query, nil, adminToken)
+				assert.Equal(t, http.StatusOK, rr.Code, path)
+
+				var results []models.Patient
+				assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+				assert.Len(t, results, tc.want, path)
+			}
+		})
+	}
+
+	// 3. Reversed ranges are rejected
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?created_from=2001-04-08&created_to=2001-04-01", nil, adminToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestSearchPatientHandler_Paged(t *testing.T) {
+	lastName := fmt.Sprintf("Paged%d", time.Now().UnixNano())
+	for i := 0; i < 5; i++ {
+		patient := createTestPatient(1)
+		patient.LastNameEN = lastName
+		seedPatient(t, patient)
+	}
+	authToken := getAuthToken(t, uniqueUsername("staff_search_paged"), "password123", "Hospital A")
+
+	seen := map[uint]bool{}
+	for page := 1; page <= 3; page++ {
+		rr := performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/search?last_name_en=%s&page=%d&page_size=2", lastName, page), nil, authToken)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var response struct {
+			Data  []models.Patient `json:"data"`
+			Total int64            `json:"total"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, int64(5), response.Total, "page %d", page)
+		for _, patient := range response.Data {
+			seen[patient.ID] = true
+		}
+		if page < 3 {
+			assert.Contains(t, rr.Header().Get("Link"), `rel="next"`, "page %d", page)
+		} else {
+			assert.NotContains(t, rr.Header().Get("Link"), `rel="next"`)
+		}
+	}
+	assert.Len(t, seen, 5, "the pages hold every match once")
+}
+
+func TestSearchPatientHandler_PatientHNPrefix(t *testing.T) {
+	// 1. A hospital of its own, since every seeded test patient elsewhere has an HN starting with "HN"
+	hospital := createIsolatedHospital(t, "HNP")
+	for _, hn := range []string{"HN001", "HN002", "XY001"} {
+		patient := createTestPatient(hospital.ID)
+		patient.PatientHN = hn
+		seedPatient(t, patient)
+	}
+	other := createTestPatient(2)
+	other.PatientHN = fmt.Sprintf("HN_OTHER_%d", time.Now().UnixNano())
+	seedPatient(t, other)
+	authToken := getAuthToken(t, uniqueUsername("staff_hn_prefix"), "password123", hospital.Name)
+
+	// 2. Prefix match within the caller's hospital only; the exact parameter still needs the full HN
+	for query, want := range map[string]int{
+		"patient_hn_prefix=HN":   2,
+		"patient_hn_prefix=HN00": 2,
+		"patient_hn_prefix=XY":   1,
+		"patient_hn_prefix=N0":   0, // Not a substring search
+		"patient_hn_prefix=H%25": 0, // "%" is matched literally
+		"patient_hn=HN001":       1,
+		"patient_hn=HN":          0,
+	} {
+		t.Run(query, func(t *testing.T) {
+			rr := performRequest(testRouter, "GET", "/api/v1/patient/search?"+query, nil, authToken)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var results []models.Patient
+			assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+			assert.Len(t, results, want)
+			for _, patient := range results {
+				assert.Equal(t, hospital.ID, patient.HospitalID)
+			}
+		})
+	}
+}
+
 func TestSearchPatientHandler_FoundByPassportID(t *testing.T) {
 	// 1. Seed Patient Data for Hospital B (ID 2)
 	testPatient := createTestPatient(2)
@@ -119,7 +548,7 @@ func TestSearchPatientHandler_FoundByPassportID(t *testing.T) {
 
 	// 4. Assertions
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.Len(t, results, 1)
 	if len(results) == 1 {
@@ -151,7 +580,7 @@ func TestSearchPatientHandler_FoundByNameTH(t *testing.T) {
 
 	// 4. Assertions
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.GreaterOrEqual(t, len(results), 1, "Expected at least one result for partial name match")
 	found := false
@@ -188,7 +617,7 @@ func TestSearchPatientHandler_FoundByNameEN(t *testing.T) {
 
 	// 4. Assertions
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.GreaterOrEqual(t, len(results), 1)
 	found := false
@@ -226,7 +655,7 @@ func TestSearchPatientHandler_FoundByDOB(t *testing.T) {
 
 	// 4. Assertions
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.GreaterOrEqual(t, len(results), 1) // DOB might not be unique
 	found := false
@@ -242,6 +671,44 @@ func TestSearchPatientHandler_FoundByDOB(t *testing.T) {
 	assert.True(t, found, "Seeded patient not found in results for DOB search")
 }
 
+func TestSearchPatientHandler_FoundByDOBInConfiguredTimezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cfg := *testConfig
+	cfg.Timezone = newYork.String()
+	if !assert.NoError(t, database.Open(&cfg)) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		if err := database.Open(testConfig); err != nil {
+			t.Fatalf("Failed to reopen the test database: %v", err)
+		}
+	})
+
+	// Midnight in New York is already the next morning in UTC
+	dob := time.Date(1987, 3, 9, 0, 0, 0, 0, newYork)
+	testPatient := createTestPatient(1)
+	testPatient.DateOfBirth = &dob
+	seedPatient(t, testPatient)
+	authToken := getAuthToken(t, uniqueUsername("staff_hospA_dob_tz"), "password123", "Hospital A")
+
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?date_of_birth=1987-03-09", nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var results []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+	found := false
+	for _, p := range results {
+		if p.ID == testPatient.ID {
+			found = true
+			break
+		}
+	}
+	assert.True(t, found, "Patient born at local midnight not found by their local date of birth")
+}
+
 func TestSearchPatientHandler_FoundByPhoneNumber(t *testing.T) {
 	// 1. Seed Patient Data (Hospital B)
 	testPatient := createTestPatient(2)
@@ -263,7 +730,7 @@ func TestSearchPatientHandler_FoundByPhoneNumber(t *testing.T) {
 
 	// 4. Assertions
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.Len(t, results, 1) // Expect exact match for phone
 	if len(results) == 1 {
@@ -293,7 +760,7 @@ func TestSearchPatientHandler_FoundByEmail(t *testing.T) {
 
 	// 4. Assertions
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.Len(t, results, 1) // Expect exact match for email
 	if len(results) == 1 {
@@ -334,7 +801,7 @@ func TestSearchPatientHandler_FoundByMultipleFields(t *testing.T) {
 
 	// 4. Assertions
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.Len(t, results, 1, "Expected only the one patient matching all criteria")
 	if len(results) == 1 {
@@ -367,7 +834,7 @@ func TestSearchPatientHandler_NotFoundWrongHospital(t *testing.T) {
 
 	// 4. Assertions - Expect empty results because staff is from wrong hospital
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.Len(t, results, 0, "Expected zero results when searching from wrong hospital")
 }
@@ -395,7 +862,90 @@ func TestSearchPatientHandler_NotFoundWrongHospital_ViceVersa(t *testing.T) {
 
 	// 4. Assertions - Expect empty results because staff is from wrong hospital
 	var results []models.Patient
-	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	err := decodeSearchResults(rr.Body.Bytes(), &results)
 	assert.NoError(t, err)
 	assert.Len(t, results, 0, "Expected zero results when staff from Hospital A searches for patient in Hospital B")
 }
+
+func TestSearchPatientHandler_ViewerSeesMaskedIdentifiers(t *testing.T) {
+	testPatient := createTestPatient(1)
+	testPatient.NationalID = "1103700123456"
+	testPatient.PhoneNumber = "0812345678"
+	seedPatient(t, testPatient)
+
+	viewerToken := getRoleAuthToken(t, uniqueUsername("viewer_search"), "password123", "Hospital A", models.RoleViewer)
+	staffToken := getAuthToken(t, uniqueUsername("staff_unmasked"), "password123", "Hospital A")
+	path := "/api/v1/patient/search?national_id=" + testPatient.NationalID
+
+	rr := performRequest(testRouter, "GET", path, nil, viewerToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var masked []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &masked))
+	if assert.Len(t, masked, 1) {
+		assert.Equal(t, "*********3456", masked[0].NationalID)
+		assert.Equal(t, "******5678", masked[0].PhoneNumber)
+	}
+
+	rr = performRequest(testRouter, "GET", path, nil, staffToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var full []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &full))
+	if assert.Len(t, full, 1) {
+		assert.Equal(t, "1103700123456", full[0].NationalID)
+		assert.Equal(t, "0812345678", full[0].PhoneNumber)
+	}
+}
+
+func TestSearchPatientHandler_CrossHospitalScope(t *testing.T) {
+	patientA := createTestPatient(1)
+	patientA.LastNameEN = "Crosshospital"
+	seedPatient(t, patientA)
+	patientB := createTestPatient(2)
+	patientB.LastNameEN = "Crosshospital"
+	seedPatient(t, patientB)
+	path := "/api/v1/patient/search?last_name_en=Crosshospital&hospital_id=all"
+
+	// Regular staff keep their own hospital scope even when asking for all
+	staffToken := getAuthToken(t, uniqueUsername("staff_scope"), "password123", "Hospital A")
+	rr := performRequest(testRouter, "GET", path, nil, staffToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var scoped []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &scoped))
+	for _, p := range scoped {
+		assert.Equal(t, uint(1), p.HospitalID)
+	}
+
+	// Super admins see both, labelled with the hospital name
+	superToken := getRoleAuthToken(t, uniqueUsername("super_scope"), "password123", "Hospital A", models.RoleSuperAdmin)
+	rr = performRequest(testRouter, "GET", path, nil, superToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var widened []models.PatientWithHospital
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &widened))
+	names := map[uint]string{}
+	for _, p := range widened {
+		names[p.ID] = p.HospitalName
+	}
+	assert.Equal(t, "Hospital A", names[patientA.ID])
+	assert.Equal(t, "Hospital B", names[patientB.ID])
+}
+
+func TestSearchPatientHandler_TruncatesAtHospitalLimit(t *testing.T) {
+	hospital := createIsolatedHospital(t, "TRUNC")
+	for i := 0; i < 3; i++ {
+		seedPatient(t, createTestPatient(hospital.ID))
+	}
+	seedRow(t, &models.HospitalConfig{HospitalID: hospital.ID, MaxSearchResults: 2})
+	t.Cleanup(func() {
+		testDB.Delete(&models.HospitalConfig{}, hospital.ID)
+	})
+	token := getAuthToken(t, uniqueUsername("staff_truncate"), "password123", hospital.Name)
+
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?last_name_en=Patient", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response models.PatientSearchResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.True(t, response.Truncated)
+	assert.Equal(t, 2, response.Count)
+	assert.NotEmpty(t, response.Hint)
+}
@@ -0,0 +1,75 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePatientStatusHandler_Transitions(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	t.Cleanup(func() {
+		testDB.Where("patient_id = ?", patient.ID).Delete(&models.AuditLog{})
+	})
+	assert.Equal(t, models.PatientStatusActive, patient.Status, "new patients start active")
+	token := getAuthToken(t, uniqueUsername("staff_status"), "password123", "Hospital A")
+	path := fmt.Sprintf("/api/v1/patient/%d/status", patient.ID)
+
+	// createTestPatient is born on 1990-05-15
+	rr := performRequest(testRouter, "PATCH", path, gin.H{"status": "deceased", "deceased_at": "1980-01-01T00:00:00Z"}, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	rr = performRequest(testRouter, "PATCH", path, gin.H{"status": "deceased"}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var stored models.Patient
+	assert.NoError(t, testDB.First(&stored, patient.ID).Error)
+	assert.Equal(t, models.PatientStatusDeceased, stored.Status)
+	assert.True(t, stored.Deceased)
+	assert.NotNil(t, stored.DeceasedAt)
+	var audit models.AuditLog
+	assert.NoError(t, testDB.Where("patient_id = ? AND action = ?", patient.ID, models.AuditActionPatientStatusChanged).First(&audit).Error)
+	assert.Equal(t, "from=active to=deceased", audit.Details)
+
+	// There is no way back from deceased
+	rr = performRequest(testRouter, "PATCH", path, gin.H{"status": "active"}, token)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.NoError(t, testDB.First(&stored, patient.ID).Error)
+	assert.Equal(t, models.PatientStatusDeceased, stored.Status)
+}
+
+func TestSearchPatientHandler_HidesDeceasedAndTransferred(t *testing.T) {
+	hn := uniqueUsername("STATUS")
+	statuses := []string{models.PatientStatusActive, models.PatientStatusInactive, models.PatientStatusDeceased, models.PatientStatusTransferred}
+	for i, status := range statuses {
+		patient := createTestPatient(1)
+		patient.PatientHN = fmt.Sprintf("%s_%d", hn, i)
+		patient.Status = status
+		seedPatient(t, patient)
+	}
+	token := getAuthToken(t, uniqueUsername("staff_status_search"), "password123", "Hospital A")
+
+	search := func(query string) []string {
+		rr := performRequest(testRouter, "GET", "/api/v1/patient/search?patient_hn_prefix="+hn+query, nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var patients []models.Patient
+		assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &patients))
+		found := make([]string, 0, len(patients))
+		for _, p := range patients {
+			found = append(found, p.Status)
+		}
+		return found
+	}
+
+	assert.ElementsMatch(t, []string{models.PatientStatusActive, models.PatientStatusInactive}, search(""))
+	assert.ElementsMatch(t, statuses, search("&include_inactive=true"))
+	assert.ElementsMatch(t, []string{models.PatientStatusActive, models.PatientStatusInactive, models.PatientStatusTransferred},
+		search("&include_inactive=true&exclude_deceased=true"))
+}
@@ -0,0 +1,104 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// addTag tags the patient through the API and returns the tag, which is deleted with its
+// assignments when the test ends.
+func addTag(t *testing.T, token string, patientID uint, name string) models.Tag {
+	t.Helper()
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/tags", patientID), gin.H{"name": name}, token)
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var tag models.Tag
+	if !assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tag)) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		testDB.Where("tag_id = ?", tag.ID).Delete(&models.PatientTag{})
+		testDB.Delete(&models.Tag{}, tag.ID)
+	})
+	return tag
+}
+
+func TestPatientTags_AddAndSearch(t *testing.T) {
+	both := createTestPatient(1)
+	seedPatient(t, both)
+	one := createTestPatient(1)
+	seedPatient(t, one)
+	staffToken := getAuthToken(t, uniqueUsername("staff_tag"), "password123", "Hospital A")
+	cohort := models.NormalizeTagName(uniqueUsername("cohort"))
+
+	program := addTag(t, staffToken, both.ID, cohort+" Program")
+	assert.Equal(t, cohort+"-program", program.Name)
+	again := addTag(t, staffToken, one.ID, cohort+"_program")
+	assert.Equal(t, program.ID, again.ID, "names differing only in case and separators are the same tag")
+	due := addTag(t, staffToken, both.ID, cohort+"-due")
+	addTag(t, staffToken, both.ID, cohort+"-due") // Tagging twice is a no-op
+
+	// Several tags must all be present
+	rr := performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/search?tag=%s&tag=%s", program.Name, due.Name), nil, staffToken)
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var results []models.Patient
+	if !assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results)) {
+		t.FailNow()
+	}
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, both.ID, results[0].ID)
+		assert.Len(t, results[0].Tags, 2)
+	}
+
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/tags", nil, staffToken)
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var list struct {
+		Data []models.TagCount `json:"data"`
+	}
+	if !assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list)) {
+		t.FailNow()
+	}
+	counts := make(map[string]int64)
+	for _, tag := range list.Data {
+		counts[tag.Name] = tag.PatientCount
+	}
+	assert.Equal(t, int64(2), counts[program.Name])
+	assert.Equal(t, int64(1), counts[due.Name])
+
+	removeURL := fmt.Sprintf("/api/v1/patient/%d/tags/%s", both.ID, due.Name)
+	rr = performRequest(testRouter, "DELETE", removeURL, nil, staffToken)
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	rr = performRequest(testRouter, "DELETE", removeURL, nil, staffToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestPatientTags_DeleteRemovesFromPatients(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_tag_del"), "password123", "Hospital A")
+	tag := addTag(t, adminToken, patient.ID, uniqueUsername("vaccination-due"))
+
+	rr := performRequest(testRouter, "DELETE", "/api/v1/admin/tag/"+tag.Name, nil, adminToken)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	var count int64
+	testDB.Model(&models.PatientTag{}).Where("tag_id = ?", tag.ID).Count(&count)
+	assert.Zero(t, count)
+	testDB.Model(&models.Tag{}).Where("id = ?", tag.ID).Count(&count)
+	assert.Zero(t, count)
+	rr = performRequest(testRouter, "DELETE", "/api/v1/admin/tag/"+tag.Name, nil, adminToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
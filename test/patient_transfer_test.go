@@ -0,0 +1,52 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferPatientHandler_MovesPatientAndAudits(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	t.Cleanup(func() {
+		testDB.Where("patient_id = ?", patient.ID).Delete(&models.AuditLog{})
+	})
+	token := getRoleAuthToken(t, uniqueUsername("transfer_super"), "password123", "Hospital A", models.RoleSuperAdmin)
+
+	path := fmt.Sprintf("/api/v1/patient/%d/transfer", patient.ID)
+	rr := performRequest(testRouter, "POST", path, models.PatientTransferRequest{TargetHospitalID: 2}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var stored models.Patient
+	assert.NoError(t, testDB.First(&stored, patient.ID).Error)
+	assert.Equal(t, uint(2), stored.HospitalID)
+
+	var audit models.AuditLog
+	assert.NoError(t, testDB.Where("patient_id = ? AND action = ?", patient.ID, models.AuditActionPatientTransferred).First(&audit).Error)
+	assert.Equal(t, uint(2), audit.HospitalID)
+	assert.Equal(t, "from_hospital_id=1 to_hospital_id=2", audit.Details)
+
+	// A second request for the same move is a no-op and rejected
+	rr = performRequest(testRouter, "POST", path, models.PatientTransferRequest{TargetHospitalID: 2}, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestTransferPatientHandler_HospitalAdminCannotMoveAcrossHospitals(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	token := getAdminAuthToken(t, uniqueUsername("transfer_admin"), "password123", "Hospital A")
+
+	path := fmt.Sprintf("/api/v1/patient/%d/transfer", patient.ID)
+	rr := performRequest(testRouter, "POST", path, models.PatientTransferRequest{TargetHospitalID: 2}, token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	var stored models.Patient
+	assert.NoError(t, testDB.First(&stored, patient.ID).Error)
+	assert.Equal(t, uint(1), stored.HospitalID, "patient must stay put")
+}
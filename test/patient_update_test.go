@@ -0,0 +1,184 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePatientHandler_Demographics(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	t.Cleanup(func() {
+		testDB.Where("patient_id = ?", patient.ID).Delete(&models.AuditLog{})
+	})
+	assert.Nil(t, patient.BloodType, "existing rows start without a blood type")
+	token := getAuthToken(t, uniqueUsername("staff_update"), "password123", "Hospital A")
+	nationality := uniqueUsername("Nationality")
+
+	rr := performRequest(testRouter, "PATCH", fmt.Sprintf("/api/v1/patient/%d", patient.ID), gin.H{
+		"blood_type": "B+", "nationality": nationality, "marital_status": "married",
+	}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var stored models.Patient
+	assert.NoError(t, testDB.First(&stored, patient.ID).Error)
+	if assert.NotNil(t, stored.BloodType) && assert.NotNil(t, stored.Nationality) && assert.NotNil(t, stored.MaritalStatus) {
+		assert.Equal(t, "B+", *stored.BloodType)
+		assert.Equal(t, nationality, *stored.Nationality)
+		assert.Equal(t, "married", *stored.MaritalStatus)
+	}
+
+	// Both new search parameters match exactly
+	query := url.Values{}
+	query.Add("blood_type", "B+")
+	query.Add("nationality", nationality)
+	rr = performRequest(testRouter, "GET", "/api/v1/patient/search?"+query.Encode(), nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var results []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, patient.ID, results[0].ID)
+	}
+
+	// Clearing a field stores null again
+	rr = performRequest(testRouter, "PATCH", fmt.Sprintf("/api/v1/patient/%d", patient.ID), gin.H{"blood_type": ""}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var cleared map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &cleared))
+	assert.Nil(t, cleared["blood_type"])
+	var reloaded models.Patient
+	assert.NoError(t, testDB.First(&reloaded, patient.ID).Error)
+	assert.Nil(t, reloaded.BloodType)
+}
+
+func TestGetPatientHandler_ETagChangesOnUpdate(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	t.Cleanup(func() {
+		testDB.Where("patient_id = ?", patient.ID).Delete(&models.AuditLog{})
+	})
+	token := getAuthToken(t, uniqueUsername("staff_etag"), "password123", "Hospital A")
+	patientURL := fmt.Sprintf("/api/v1/patient/%d", patient.ID)
+	get := func(etag string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", patientURL, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rr := httptest.NewRecorder()
+		testRouter.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	rr = get(etag)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.Bytes())
+
+	rr = performRequest(testRouter, "PATCH", patientURL, gin.H{"marital_status": "single"}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = get(etag)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+}
+
+func TestUpdatePatientHandler_IfMatchPreventsLostUpdate(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	t.Cleanup(func() {
+		testDB.Where("patient_id = ?", patient.ID).Delete(&models.AuditLog{})
+	})
+	token := getAuthToken(t, uniqueUsername("staff_ifmatch"), "password123", "Hospital A")
+	patientURL := fmt.Sprintf("/api/v1/patient/%d", patient.ID)
+	patch := func(body, etag string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PATCH", patientURL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-Match", etag)
+		rr := httptest.NewRecorder()
+		testRouter.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := performRequest(testRouter, "GET", patientURL, nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+
+	// Two clients read the same version; the first write wins
+	rr = patch(`{"marital_status":"single"}`, etag)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = patch(`{"marital_status":"married"}`, etag)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code, rr.Body.String())
+
+	var reloaded models.Patient
+	assert.NoError(t, testDB.First(&reloaded, patient.ID).Error)
+	if assert.NotNil(t, reloaded.MaritalStatus) {
+		assert.Equal(t, "single", *reloaded.MaritalStatus)
+	}
+}
+
+func TestUpdatePatientHandler_ContactPreferences(t *testing.T) {
+	reachable := createTestPatient(1)
+	seedPatient(t, reachable)
+	optedOut := createTestPatient(1)
+	seedPatient(t, optedOut)
+	t.Cleanup(func() {
+		testDB.Where("patient_id IN ?", []uint{reachable.ID, optedOut.ID}).Delete(&models.AuditLog{})
+	})
+	token := getAuthToken(t, uniqueUsername("staff_contact"), "password123", "Hospital A")
+	nationality := uniqueUsername("Nationality")
+
+	for _, update := range []struct {
+		patient *models.Patient
+		body    gin.H
+	}{
+		{reachable, gin.H{"nationality": nationality, "preferred_language": "th", "preferred_contact_method": "phone"}},
+		{optedOut, gin.H{"nationality": nationality, "preferred_language": "th", "preferred_contact_method": "none", "do_not_contact": true}},
+	} {
+		rr := performRequest(testRouter, "PATCH", fmt.Sprintf("/api/v1/patient/%d", update.patient.ID), update.body, token)
+		if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+			t.FailNow()
+		}
+	}
+
+	var stored models.Patient
+	assert.NoError(t, testDB.First(&stored, optedOut.ID).Error)
+	assert.True(t, stored.DoNotContact)
+	if assert.NotNil(t, stored.PreferredContactMethod) {
+		assert.Equal(t, models.ContactMethodNone, *stored.PreferredContactMethod)
+	}
+
+	query := url.Values{}
+	query.Add("nationality", nationality)
+	query.Add("preferred_language", "th")
+	query.Add("do_not_contact", "false")
+	rr := performRequest(testRouter, "GET", "/api/v1/patient/search?"+query.Encode(), nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var results []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, reachable.ID, results[0].ID)
+	}
+
+	// The flag can be lifted again
+	rr = performRequest(testRouter, "PATCH", fmt.Sprintf("/api/v1/patient/%d", optedOut.ID), gin.H{"do_not_contact": false}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NoError(t, testDB.First(&stored, optedOut.ID).Error)
+	assert.False(t, stored.DoNotContact)
+}
@@ -0,0 +1,92 @@
+//go:build integration
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+// Credentials for the throwaway PostgreSQL container. They never leave the test run.
+const (
+	containerDBUser     = "hospital_test"
+	containerDBPassword = "hospital_test_password"
+	containerDBName     = "hospital_test_db"
+)
+
+// defaultPostgresImage is used unless TEST_POSTGRES_IMAGE overrides it.
+const defaultPostgresImage = "postgres:16-alpine"
+
+// containerStartTimeout bounds pulling the image and waiting for the database to accept connections.
+const containerStartTimeout = 2 * time.Minute
+
+// postgresContainer is a PostgreSQL instance started for a single test run.
+type postgresContainer struct {
+	container *postgres.PostgresContainer
+	Host      string
+	Port      string
+}
+
+// startPostgresContainer launches a disposable PostgreSQL container through testcontainers-go and
+// waits until it accepts connections. testcontainers' reaper (Ryuk) removes the container if the
+// test process dies before calling Terminate, so crashed runs don't leave databases behind.
+func startPostgresContainer() (started *postgresContainer, err error) {
+	// testcontainers panics rather than returning an error when it finds no Docker host
+	defer func() {
+		if r := recover(); r != nil {
+			started, err = nil, fmt.Errorf("failed to start postgres container: no usable Docker daemon: %v", r)
+		}
+	}()
+
+	image := os.Getenv("TEST_POSTGRES_IMAGE")
+	if image == "" {
+		image = defaultPostgresImage
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), containerStartTimeout)
+	defer cancel()
+
+	log.Printf("Starting PostgreSQL test container (%s)...", image)
+	container, err := postgres.Run(ctx, image,
+		postgres.WithDatabase(containerDBName),
+		postgres.WithUsername(containerDBUser),
+		postgres.WithPassword(containerDBPassword),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		if container != nil {
+			testcontainers.TerminateContainer(container)
+		}
+		return nil, fmt.Errorf("failed to start postgres container (is Docker running?): %w", err)
+	}
+	started = &postgresContainer{container: container}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		started.Terminate()
+		return nil, fmt.Errorf("failed to resolve container host: %w", err)
+	}
+	port, err := container.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		started.Terminate()
+		return nil, fmt.Errorf("failed to resolve container port: %w", err)
+	}
+	started.Host, started.Port = host, port.Port()
+
+	log.Printf("PostgreSQL test container %s ready on %s:%s", container.GetContainerID()[:12], started.Host, started.Port)
+	return started, nil
+}
+
+// Terminate stops and removes the container.
+func (p *postgresContainer) Terminate() {
+	log.Printf("Terminating PostgreSQL test container %s...", p.container.GetContainerID())
+	if err := testcontainers.TerminateContainer(p.container); err != nil {
+		log.Printf("Error terminating test container %s: %v", p.container.GetContainerID(), err)
+	}
+}
@@ -0,0 +1,75 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listRecentlyViewed returns the patient IDs of the caller's recently viewed list, in order.
+func listRecentlyViewed(t *testing.T, token string) []uint {
+	t.Helper()
+	rr := performRequest(testRouter, "GET", "/api/v1/staff/recently-viewed", nil, token)
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var resp struct {
+		Data []models.RecentlyViewedPatient `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	ids := make([]uint, len(resp.Data))
+	for i, entry := range resp.Data {
+		ids[i] = entry.PatientID
+	}
+	return ids
+}
+
+func TestRecentlyViewed_OrderingAndCap(t *testing.T) {
+	token := getAuthToken(t, uniqueUsername("recent_viewer"), "password123", "Hospital A")
+	var patients []*models.Patient
+	for i := 0; i < models.MaxRecentlyViewed+2; i++ {
+		patient := createTestPatient(1)
+		seedPatient(t, patient)
+		patients = append(patients, patient)
+		rr := performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d", patient.ID), nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	// Newest first, with the two oldest evicted
+	var want []uint
+	for i := len(patients) - 1; i >= 2; i-- {
+		want = append(want, patients[i].ID)
+	}
+	assert.Equal(t, want, listRecentlyViewed(t, token))
+	var stored int64
+	testDB.Model(&models.RecentlyViewed{}).Where("patient_id IN ?", []uint{patients[0].ID, patients[1].ID}).Count(&stored)
+	assert.Zero(t, stored, "evicted views are deleted")
+
+	// Viewing a listed patient again moves it to the top without growing the list
+	rr := performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d", patients[5].ID), nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	ids := listRecentlyViewed(t, token)
+	assert.Len(t, ids, models.MaxRecentlyViewed)
+	assert.Equal(t, patients[5].ID, ids[0])
+}
+
+func TestRecentlyViewed_OtherHospitalNotAdded(t *testing.T) {
+	token := getAuthToken(t, uniqueUsername("recent_viewer_other"), "password123", "Hospital A")
+	own := createTestPatient(1)
+	seedPatient(t, own)
+	other := createTestPatient(2)
+	seedPatient(t, other)
+
+	rr := performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d", own.ID), nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/patient/%d", other.ID), nil, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	assert.Equal(t, []uint{own.ID}, listRecentlyViewed(t, token))
+}
@@ -0,0 +1,79 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferralHandlers_Lifecycle(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	sourceToken := getAuthToken(t, uniqueUsername("staff_refer_a"), "password123", "Hospital A")
+	targetToken := getAuthToken(t, uniqueUsername("staff_refer_b"), "password123", "Hospital B")
+
+	// 1. Hospital B cannot refer Hospital A's patient
+	rr := performRequest(testRouter, "POST", "/api/v1/referral", gin.H{
+		"patient_id": testPatient.ID, "target_hospital_id": 1, "reason": "Consult", "priority": "routine",
+	}, targetToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Body.String())
+
+	// 2. Hospital A refers its patient to Hospital B
+	rr = performRequest(testRouter, "POST", "/api/v1/referral", gin.H{
+		"patient_id": testPatient.ID, "target_hospital_id": 2, "reason": "Cardiology consult", "priority": "urgent",
+	}, sourceToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var referral models.Referral
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &referral))
+	t.Cleanup(func() { testDB.Delete(&models.Referral{}, referral.ID) })
+	assert.Equal(t, models.ReferralStatusPending, referral.Status)
+	assert.Equal(t, uint(1), referral.SourceHospitalID)
+	referralURL := fmt.Sprintf("/api/v1/referral/%d", referral.ID)
+
+	// 3. Both hospitals can read it
+	for _, token := range []string{sourceToken, targetToken} {
+		rr = performRequest(testRouter, "GET", referralURL, nil, token)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	// 4. Only the target hospital decides, and only once
+	rr = performRequest(testRouter, "PUT", referralURL+"/accept", nil, sourceToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = performRequest(testRouter, "PUT", referralURL+"/accept", nil, targetToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = performRequest(testRouter, "PUT", referralURL+"/reject", nil, targetToken)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = performRequest(testRouter, "GET", referralURL, nil, sourceToken)
+	var stored models.Referral
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stored))
+	assert.Equal(t, models.ReferralStatusAccepted, stored.Status)
+}
+
+func TestReferralHandlers_Reject(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	sourceToken := getAuthToken(t, uniqueUsername("staff_refer_rej_a"), "password123", "Hospital A")
+	targetToken := getAuthToken(t, uniqueUsername("staff_refer_rej_b"), "password123", "Hospital B")
+
+	rr := performRequest(testRouter, "POST", "/api/v1/referral", gin.H{
+		"patient_id": testPatient.ID, "target_hospital_id": 2, "reason": "No beds", "priority": "emergency",
+	}, sourceToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var referral models.Referral
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &referral))
+	t.Cleanup(func() { testDB.Delete(&models.Referral{}, referral.ID) })
+
+	rr = performRequest(testRouter, "PUT", fmt.Sprintf("/api/v1/referral/%d/reject", referral.ID), nil, targetToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var rejected models.Referral
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rejected))
+	assert.Equal(t, models.ReferralStatusRejected, rejected.Status)
+}
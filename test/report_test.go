@@ -0,0 +1,137 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// getMonthlyReport requests the hospital's report for a month of 2024 and decodes it.
+func getMonthlyReport(t *testing.T, token string, month int) models.MonthlyReport {
+	t.Helper()
+	rr := performRequest(testRouter, "GET", fmt.Sprintf("/api/v1/admin/reports/monthly?year=2024&month=%d", month), nil, token)
+	var report models.MonthlyReport
+	if assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	}
+	return report
+}
+
+func TestMonthlyReport_CountsEachMonth(t *testing.T) {
+	bangkok, err := time.LoadLocation(testConfig.Timezone)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, bangkok)
+	}
+	hospital := createIsolatedHospital(t, "RPT")
+	t.Cleanup(func() {
+		testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.Admission{})
+		testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.SearchHistory{})
+		testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.CachedReport{})
+	})
+
+	// 1. Registrations either side of midnight on 1 May in the configured zone
+	patients := make([]*models.Patient, 4)
+	for i, createdAt := range []time.Time{at(time.April, 30, 23, 30), at(time.May, 1, 0, 30), at(time.May, 20, 9, 0), at(time.June, 2, 9, 0)} {
+		patients[i] = createTestPatient(hospital.ID)
+		patients[i].CreatedAt = createdAt
+		seedPatient(t, patients[i])
+	}
+	testDB.Delete(patients[2]) // Deleted patients were still registered in May
+
+	// 2. Stays of two, four, one and a still-running number of days
+	discharged := func(month time.Month, day int) *time.Time {
+		dischargedAt := at(month, day, 10, 0)
+		return &dischargedAt
+	}
+	for _, admission := range []models.Admission{
+		{PatientID: patients[0].ID, AdmittedAt: at(time.April, 10, 10, 0), DischargedAt: discharged(time.April, 12), Status: models.AdmissionStatusDischarged},
+		{PatientID: patients[1].ID, AdmittedAt: at(time.April, 28, 10, 0), DischargedAt: discharged(time.May, 2), Status: models.AdmissionStatusDischarged},
+		{PatientID: patients[2].ID, AdmittedAt: at(time.May, 10, 10, 0), DischargedAt: discharged(time.May, 11), Status: models.AdmissionStatusDischarged},
+		{PatientID: patients[3].ID, AdmittedAt: at(time.May, 20, 10, 0), Status: models.AdmissionStatusAdmitted},
+	} {
+		admission.HospitalID, admission.AdmittingStaffID, admission.Ward = hospital.ID, 1, "Ward R"
+		seedRow(t, &admission)
+	}
+
+	// 3. Searches; empty parameters and ones that aren't search fields don't count
+	for _, search := range []struct {
+		query string
+		at    time.Time
+	}{
+		{"first_name_en=Ann&page=2", at(time.April, 5, 9, 0)},
+		{"first_name_en=Bea&last_name_en=Chan", at(time.May, 3, 9, 0)},
+		{"tag=vip&tag=elderly&first_name_en=", at(time.May, 4, 9, 0)},
+		{"first_name_en=Dao&national_id=1103700123458", at(time.May, 31, 23, 0)},
+		{"last_name_en=Early", at(time.June, 1, 0, 5)},
+	} {
+		seedRow(t, &models.SearchHistory{StaffID: 1, HospitalID: hospital.ID, Query: search.query, SearchedAt: search.at})
+	}
+	token := getAdminAuthToken(t, uniqueUsername("admin_report"), "password123", hospital.Name)
+
+	april := getMonthlyReport(t, token, 4)
+	assert.Equal(t, hospital.ID, april.HospitalID)
+	assert.Equal(t, int64(1), april.NewRegistrations)
+	assert.Equal(t, int64(2), april.Admissions)
+	assert.Equal(t, int64(1), april.Discharges)
+	if assert.NotNil(t, april.AvgLengthOfStayDays) {
+		assert.InDelta(t, 2.0, *april.AvgLengthOfStayDays, 0.001)
+	}
+	assert.Equal(t, int64(1), april.TotalSearchesPerformed)
+	assert.Equal(t, []models.SearchFieldCount{{Field: "first_name_en", Count: 1}}, april.TopSearchFieldsUsed)
+
+	may := getMonthlyReport(t, token, 5)
+	assert.Equal(t, int64(2), may.NewRegistrations)
+	assert.Equal(t, int64(2), may.Admissions)
+	assert.Equal(t, int64(2), may.Discharges)
+	if assert.NotNil(t, may.AvgLengthOfStayDays) {
+		assert.InDelta(t, 2.5, *may.AvgLengthOfStayDays, 0.001)
+	}
+	assert.Equal(t, int64(3), may.TotalSearchesPerformed)
+	assert.Equal(t, []models.SearchFieldCount{
+		{Field: "first_name_en", Count: 2},
+		{Field: "last_name_en", Count: 1},
+		{Field: "national_id", Count: 1},
+		{Field: "tag", Count: 1},
+	}, may.TopSearchFieldsUsed)
+
+	march := getMonthlyReport(t, token, 3)
+	assert.Zero(t, march.NewRegistrations)
+	assert.Nil(t, march.AvgLengthOfStayDays, "no discharges, no average")
+	assert.Equal(t, []models.SearchFieldCount{}, march.TopSearchFieldsUsed)
+}
+
+func TestMonthlyReport_CachesFinishedMonths(t *testing.T) {
+	hospital := createIsolatedHospital(t, "RPC")
+	t.Cleanup(func() { testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.CachedReport{}) })
+	token := getAdminAuthToken(t, uniqueUsername("admin_report_cache"), "password123", hospital.Name)
+
+	first := getMonthlyReport(t, token, 4)
+	var cached models.CachedReport
+	if !assert.NoError(t, testDB.Where("hospital_id = ? AND year = 2024 AND month = 4", hospital.ID).First(&cached).Error) {
+		t.FailNow()
+	}
+	assert.WithinDuration(t, first.GeneratedAt, cached.GeneratedAt, time.Millisecond)
+
+	// A report generated after its month ended is served from the cache
+	cached.Report.NewRegistrations = 42
+	assert.NoError(t, testDB.Save(&cached).Error)
+	assert.Equal(t, int64(42), getMonthlyReport(t, token, 4).NewRegistrations)
+
+	// One generated while the month was still running is generated again
+	cached.GeneratedAt = time.Date(2024, time.April, 20, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, testDB.Save(&cached).Error)
+	assert.Zero(t, getMonthlyReport(t, token, 4).NewRegistrations)
+	assert.NoError(t, testDB.First(&cached, cached.ID).Error)
+	assert.True(t, cached.GeneratedAt.After(time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Zero(t, cached.Report.NewRegistrations)
+}
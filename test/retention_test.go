@@ -0,0 +1,129 @@
+//go:build integration
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/background"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/storage"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// seedDeletedPatient seeds a patient of the hospital soft-deleted at deletedAt.
+func seedDeletedPatient(t *testing.T, hospitalID uint, deletedAt time.Time) *models.Patient {
+	t.Helper()
+	patient := createTestPatient(hospitalID)
+	patient.DeletedAt = gorm.DeletedAt{Time: deletedAt, Valid: true}
+	seedRow(t, patient)
+	t.Cleanup(func() { testDB.Unscoped().Delete(&models.Patient{}, patient.ID) })
+	return patient
+}
+
+func patientExists(id uint) bool {
+	var count int64
+	testDB.Unscoped().Model(&models.Patient{}).Where("id = ?", id).Count(&count)
+	return count > 0
+}
+
+func revokedTokenExists(jti string) bool {
+	var count int64
+	testDB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count)
+	return count > 0
+}
+
+func TestRetentionPurge_RespectsCutoffs(t *testing.T) {
+	hospital := createIsolatedHospital(t, "RET")
+	// Postgres keeps microseconds, so the cutoffs must not carry nanoseconds to land exactly on a row
+	cutoffs := models.NewRetentionCutoffs(time.Now().Truncate(time.Microsecond), 2557, 30)
+
+	pastRetention := seedDeletedPatient(t, hospital.ID, cutoffs.PatientsDeletedBefore.Add(-time.Microsecond))
+	alsoPast := seedDeletedPatient(t, hospital.ID, cutoffs.PatientsDeletedBefore.AddDate(-1, 0, 0))
+	atCutoff := seedDeletedPatient(t, hospital.ID, cutoffs.PatientsDeletedBefore)
+	recentlyDeleted := seedDeletedPatient(t, hospital.ID, cutoffs.AsOf.AddDate(0, 0, -1))
+	active := createTestPatient(hospital.ID)
+	seedPatient(t, active)
+
+	documentKey := fmt.Sprintf("patients/%d/retention-test", pastRetention.ID)
+	seedRow(t, &models.Visit{PatientID: pastRetention.ID, HospitalID: hospital.ID, VisitNumber: documentKey, AdmittedAt: cutoffs.AsOf.AddDate(-10, 0, 0)})
+	seedRow(t, &models.PatientDocument{PatientID: pastRetention.ID, HospitalID: hospital.ID, Filename: "scan.pdf",
+		ContentType: models.DocumentContentTypePDF, SizeBytes: 4, SHA256: "0", StorageKey: documentKey, UploadedBy: 1})
+	seedRow(t, &models.Visit{PatientID: atCutoff.ID, HospitalID: hospital.ID, VisitNumber: documentKey + "-kept", AdmittedAt: cutoffs.AsOf.AddDate(-10, 0, 0)})
+	t.Cleanup(func() {
+		testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.Visit{})
+		testDB.Where("hospital_id = ?", hospital.ID).Delete(&models.PatientDocument{})
+	})
+	blobs, err := storage.NewLocalDiskStore(t.TempDir())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = blobs.Put(documentKey, bytes.NewReader([]byte("scan")))
+	assert.NoError(t, err)
+
+	jti := fmt.Sprintf("retention-%d", time.Now().UnixNano())
+	tokens := []models.RevokedToken{
+		{JTI: jti + "-past", StaffID: 1, CreatedAt: cutoffs.TokensRevokedBefore.Add(-time.Microsecond), ExpiresAt: cutoffs.AsOf.Add(-time.Microsecond)},
+		{JTI: jti + "-at-cutoff", StaffID: 1, CreatedAt: cutoffs.TokensRevokedBefore, ExpiresAt: cutoffs.AsOf.Add(-time.Hour)},
+		{JTI: jti + "-still-valid", StaffID: 1, CreatedAt: cutoffs.TokensRevokedBefore.AddDate(0, 0, -1), ExpiresAt: cutoffs.AsOf},
+	}
+	for i := range tokens {
+		seedRow(t, &tokens[i])
+	}
+	t.Cleanup(func() { testDB.Where("jti LIKE ?", jti+"%").Delete(&models.RevokedToken{}) })
+
+	report, err := database.CountRetentionPurge(cutoffs)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, report.Patients, int64(2))
+	assert.GreaterOrEqual(t, report.RevokedTokens, int64(1))
+
+	// A batch of one makes the job go round its loop
+	payload, _ := json.Marshal(cutoffs)
+	purge := background.RetentionPurge(database.NewPostgresRepository(), blobs, 1)
+	assert.NoError(t, purge(context.Background(), payload))
+
+	assert.False(t, patientExists(pastRetention.ID), "deleted a microsecond before the cutoff")
+	assert.False(t, patientExists(alsoPast.ID))
+	assert.True(t, patientExists(atCutoff.ID), "deleted exactly at the cutoff")
+	assert.True(t, patientExists(recentlyDeleted.ID))
+	assert.True(t, patientExists(active.ID))
+
+	var visits, documents int64
+	testDB.Model(&models.Visit{}).Where("patient_id = ?", pastRetention.ID).Count(&visits)
+	testDB.Model(&models.PatientDocument{}).Where("patient_id = ?", pastRetention.ID).Count(&documents)
+	assert.Zero(t, visits, "records of purged patients are deleted with them")
+	assert.Zero(t, documents)
+	_, err = blobs.Get(documentKey)
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	testDB.Model(&models.Visit{}).Where("patient_id = ?", atCutoff.ID).Count(&visits)
+	assert.Equal(t, int64(1), visits)
+
+	assert.False(t, revokedTokenExists(jti+"-past"))
+	assert.True(t, revokedTokenExists(jti+"-at-cutoff"), "revoked exactly at the cutoff")
+	assert.True(t, revokedTokenExists(jti+"-still-valid"), "the revocation of an unexpired token is kept")
+}
+
+func TestRetentionDryRun_Endpoint(t *testing.T) {
+	hospital := createIsolatedHospital(t, "RDR")
+	seedDeletedPatient(t, hospital.ID, time.Now().AddDate(-8, 0, 0))
+	superToken := getRoleAuthToken(t, uniqueUsername("super_retention"), "password123", hospital.Name, models.RoleSuperAdmin)
+
+	rr := performRequest(testRouter, "GET", "/api/v1/admin/retention/dry-run", nil, superToken)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var report models.RetentionPurgeReport
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.True(t, report.DryRun)
+	assert.GreaterOrEqual(t, report.Patients, int64(1))
+	var count int64
+	testDB.Unscoped().Model(&models.Patient{}).Where("hospital_id = ?", hospital.ID).Count(&count)
+	assert.Equal(t, int64(1), count, "a dry run deletes nothing")
+}
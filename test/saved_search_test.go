@@ -0,0 +1,93 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// createSavedSearch saves a search through the API and returns its ID.
+func createSavedSearch(t *testing.T, token, name string, params gin.H) uint {
+	t.Helper()
+	rr := performRequest(testRouter, "POST", "/api/v1/staff/saved-searches", gin.H{"name": name, "query_params": params}, token)
+	if !assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var created models.SavedSearch
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	t.Cleanup(func() { testDB.Delete(&models.SavedSearch{}, created.ID) })
+	return created.ID
+}
+
+func TestSavedSearch_CreateListRunDelete(t *testing.T) {
+	patient := createTestPatient(1)
+	seedPatient(t, patient)
+	token := getAuthToken(t, uniqueUsername("saved_search"), "password123", "Hospital A")
+
+	searchID := createSavedSearch(t, token, "By HN", gin.H{"patient_hn": patient.PatientHN})
+
+	// Listed with its parameters as saved
+	rr := performRequest(testRouter, "GET", "/api/v1/staff/saved-searches", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var list struct {
+		Data []models.SavedSearch `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, "By HN", list.Data[0].Name)
+		assert.Equal(t, patient.PatientHN, list.Data[0].QueryParams["patient_hn"])
+	}
+
+	// Running it finds the patient
+	runURL := fmt.Sprintf("/api/v1/staff/saved-searches/%d/run", searchID)
+	rr = performRequest(testRouter, "GET", runURL, nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var results []models.Patient
+	assert.NoError(t, decodeSearchResults(rr.Body.Bytes(), &results))
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, patient.ID, results[0].ID)
+	}
+
+	// Another staff member can neither run nor delete it
+	other := getAuthToken(t, uniqueUsername("saved_search_other"), "password123", "Hospital A")
+	rr = performRequest(testRouter, "GET", runURL, nil, other)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/staff/saved-searches/%d", searchID), nil, other)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/staff/saved-searches/%d", searchID), nil, token)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = performRequest(testRouter, "GET", runURL, nil, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestSavedSearch_LimitPerStaff(t *testing.T) {
+	token := getAuthToken(t, uniqueUsername("saved_search_limit"), "password123", "Hospital A")
+	for i := 0; i < models.MaxSavedSearchesPerStaff; i++ {
+		createSavedSearch(t, token, fmt.Sprintf("search %d", i), gin.H{"last_name_en": "Smith"})
+	}
+
+	rr := performRequest(testRouter, "POST", "/api/v1/staff/saved-searches",
+		gin.H{"name": "one too many", "query_params": gin.H{"last_name_en": "Smith"}}, token)
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+
+	// The limit is per staff member
+	other := getAuthToken(t, uniqueUsername("saved_search_limit_other"), "password123", "Hospital A")
+	createSavedSearch(t, other, "search 0", gin.H{"last_name_en": "Smith"})
+}
+
+func TestSavedSearch_DuplicateName(t *testing.T) {
+	token := getAuthToken(t, uniqueUsername("saved_search_dup"), "password123", "Hospital A")
+	createSavedSearch(t, token, "Smiths", gin.H{"last_name_en": "Smith"})
+
+	rr := performRequest(testRouter, "POST", "/api/v1/staff/saved-searches",
+		gin.H{"name": "Smiths", "query_params": gin.H{"first_name_en": "John"}}, token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
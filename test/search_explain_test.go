@@ -0,0 +1,35 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchPatients_ExplainPlan(t *testing.T) {
+	hospital := createIsolatedHospital(t, "EXP")
+	seedPatient(t, createTestPatient(hospital.ID))
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_explain"), "password123", hospital.Name)
+
+	req, _ := http.NewRequest("GET", "/api/v1/patient/search?first_name_en=Test&national_id=NID1,NID2", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	req.Header.Set("X-Debug-Explain", "true")
+	rr := httptest.NewRecorder()
+	testRouter.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp struct {
+		Explain []struct {
+			Plan map[string]interface{} `json:"Plan"`
+		} `json:"_explain"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Explain, 1, rr.Body.String()) {
+		assert.NotEmpty(t, resp.Explain[0].Plan["Node Type"])
+	}
+}
@@ -0,0 +1,51 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/csv"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportStaffHandler_ExportsOwnHospitalAsCSV(t *testing.T) {
+	// A hospital of its own so staff created by other tests do not change the row count
+	hospital := createIsolatedHospital(t, "EXP")
+	code := hospital.Code
+
+	adminToken := getAdminAuthToken(t, uniqueUsername("export_admin"), "password123", hospital.Name)
+	for i := 0; i < 4; i++ { // Five accounts in all, counting the admin
+		seedRow(t, &models.Staff{
+			Username:     uniqueUsername(fmt.Sprintf("export_staff%d", i)),
+			PasswordHash: "not-a-real-hash",
+			HospitalID:   hospital.ID,
+			HospitalName: hospital.Name,
+			Role:         models.RoleStaff,
+			IsActive:     true,
+		})
+	}
+
+	rr := performRequest(testRouter, "GET", "/api/v1/admin/staff/export", nil, adminToken)
+
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		return
+	}
+	assert.Equal(t, fmt.Sprintf(`attachment; filename="staff_%s_%s.csv"`, code, time.Now().Format("2006-01-02")), rr.Header().Get("Content-Disposition"))
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"id", "username", "hospital_name", "role", "created_at", "last_login_at", "is_active"}, records[0])
+	assert.Len(t, records, 6, "header plus five staff")
+	for _, record := range records[1:] {
+		assert.Equal(t, hospital.Name, record[2])
+		assert.NotContains(t, strings.Join(record, ","), "not-a-real-hash")
+	}
+	assert.NotEmpty(t, records[1][5], "the admin logged in, so last_login_at is set")
+}
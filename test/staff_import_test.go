@@ -0,0 +1,81 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportStaffHandler_ImportsValidRowsAndReportsTheRest(t *testing.T) {
+	adminToken := getAdminAuthToken(t, uniqueUsername("import_admin"), "password123", "Hospital A")
+
+	existing := uniqueUsername("import_existing")
+	getAuthToken(t, existing, "password123", "Hospital A") // Already has an account
+	fresh1 := uniqueUsername("import_fresh1")
+	fresh2 := uniqueUsername("import_fresh2")
+	t.Cleanup(func() {
+		testDB.Where("username IN ?", []string{fresh1, fresh2}).Delete(&models.Staff{})
+	})
+
+	csv := "username,password,hospital,role\n" +
+		fmt.Sprintf("%s,password123,Hospital A,staff\n", fresh1) +
+		fmt.Sprintf("%s,password123,Hospital A,staff\n", existing) +
+		fmt.Sprintf("%s,password123,Nowhere General,staff\n", uniqueUsername("import_lost")) +
+		fmt.Sprintf("%s,password123,Hospital A,viewer\n", fresh1) +
+		fmt.Sprintf("%s,password123,Hospital A,viewer\n", fresh2)
+	rr := uploadDocument("/api/v1/admin/staff/import", "staff.csv", []byte(csv), adminToken)
+
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		return
+	}
+	var result models.StaffImportResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 3, result.Skipped)
+	assert.Equal(t, []models.StaffImportError{
+		{Row: 3, Reason: "duplicate username"},
+		{Row: 4, Reason: `unknown hospital "Nowhere General"`},
+		{Row: 5, Reason: "duplicate username (also on row 2)"},
+	}, result.Errors)
+
+	// Imported staff can log in with the password from the file
+	loginData := models.StaffLoginRequest{Username: fresh2, Password: "password123", Hospital: "Hospital A"}
+	rrLogin := performRequest(testRouter, "POST", "/api/v1/staff/login", loginData, "")
+	assert.Equal(t, http.StatusOK, rrLogin.Code)
+	var login models.StaffLoginResponse
+	assert.NoError(t, json.Unmarshal(rrLogin.Body.Bytes(), &login))
+	assert.Equal(t, models.RoleViewer, login.Staff.Role)
+}
+
+func TestImportStaffHandler_DryRunSavesNothing(t *testing.T) {
+	adminToken := getAdminAuthToken(t, uniqueUsername("import_admin"), "password123", "Hospital A")
+
+	usernames := []string{uniqueUsername("dry_run1"), uniqueUsername("dry_run2")}
+	t.Cleanup(func() {
+		testDB.Where("username IN ?", usernames).Delete(&models.Staff{})
+	})
+
+	csv := "username,password,hospital,role\n" +
+		fmt.Sprintf("%s,password123,Hospital A,staff\n", usernames[0]) +
+		fmt.Sprintf("%s,password123,Hospital A,viewer\n", usernames[1])
+	rr := uploadDocument("/api/v1/admin/staff/import?dry_run=true", "staff.csv", []byte(csv), adminToken)
+
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		return
+	}
+	var result models.StaffImportResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 2, result.Imported)
+	assert.Empty(t, result.Errors)
+
+	var count int64
+	testDB.Model(&models.Staff{}).Where("username IN ?", usernames).Count(&count)
+	assert.Zero(t, count, "a dry run must not save any staff")
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package test
+
+import (
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// staffID looks up the ID of a staff member created through the API.
+func staffID(t *testing.T, username string) uint {
+	var staff models.Staff
+	if err := testDB.Where("username = ?", username).First(&staff).Error; err != nil {
+		t.Fatalf("Setup failed: Could not load staff %s: %v", username, err)
+	}
+	return staff.ID
+}
+
+func TestUpdateStaffRoleHandler_PromoteAndLastAdminGuard(t *testing.T) {
+	hospital := createIsolatedHospital(t, "ROLE")
+	adminName := uniqueUsername("role_admin")
+	adminToken := getAdminAuthToken(t, adminName, "password123", hospital.Name)
+	nurseName := uniqueUsername("role_nurse")
+	getAuthToken(t, nurseName, "password123", hospital.Name)
+	adminURL := fmt.Sprintf("/api/v1/staff/%d/role", staffID(t, adminName))
+	nurseURL := fmt.Sprintf("/api/v1/staff/%d/role", staffID(t, nurseName))
+
+	// The only admin cannot step down
+	rr := performRequest(testRouter, "PUT", adminURL, gin.H{"role": "staff"}, adminToken)
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+
+	// Once the nurse is promoted there is another admin, so stepping down works
+	rr = performRequest(testRouter, "PUT", nurseURL, gin.H{"role": "admin"}, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = performRequest(testRouter, "PUT", adminURL, gin.H{"role": "staff"}, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var nurse models.Staff
+	assert.NoError(t, testDB.Where("username = ?", nurseName).First(&nurse).Error)
+	assert.Equal(t, models.RoleAdmin, nurse.Role)
+}
+
+func TestUpdateStaffRoleHandler_OtherHospitalStaffNotFound(t *testing.T) {
+	adminToken := getAdminAuthToken(t, uniqueUsername("role_admin_a"), "password123", "Hospital A")
+	otherName := uniqueUsername("role_staff_b")
+	getAuthToken(t, otherName, "password123", "Hospital B")
+
+	rr := performRequest(testRouter, "PUT", fmt.Sprintf("/api/v1/staff/%d/role", staffID(t, otherName)), gin.H{"role": "admin"}, adminToken)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	var other models.Staff
+	assert.NoError(t, testDB.Where("username = ?", otherName).First(&other).Error)
+	assert.Equal(t, models.RoleStaff, other.Role)
+}
@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestCreateAdmissionHandler_Admits(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("CreateAdmission", mock.MatchedBy(func(a *models.Admission) bool {
+		return a.PatientID == 10 && a.HospitalID == 1 && a.AdmittingStaffID == 3 && a.Ward == "ICU" &&
+			a.BedNumber == "12" && a.Status == models.AdmissionStatusAdmitted && !a.AdmittedAt.IsZero()
+	})).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/admissions", gin.H{"ward": " ICU ", "bed_number": "12"}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	repo.AssertExpectations(t)
+}
+
+func TestCreateAdmissionHandler_AlreadyAdmitted(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("CreateAdmission", mock.Anything).Return(&pgconn.PgError{Code: "23505"})
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/admissions", gin.H{"ward": "ICU"}, token)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestCreateAdmissionHandler_RequiresWard(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	for _, body := range []gin.H{{}, {"ward": "   "}} {
+		rr := performRequest(router, "POST", "/api/v1/patient/10/admissions", body, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	}
+	repo.AssertNotCalled(t, "CreateAdmission", mock.Anything)
+}
+
+func TestDischargeAdmissionHandler_Discharges(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	admittedAt := time.Now().Add(-24 * time.Hour)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetAdmission", uint(10), uint(5)).Return(&models.Admission{
+		ID: 5, PatientID: 10, HospitalID: 1, Ward: "ICU", AdmittedAt: admittedAt, Status: models.AdmissionStatusAdmitted,
+	}, nil)
+	repo.On("DischargeAdmission", mock.MatchedBy(func(a *models.Admission) bool {
+		return a.ID == 5 && a.DischargedAt != nil && a.DischargeReason == "Recovered"
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Admission).Status = models.AdmissionStatusDischarged
+	}).Return(nil)
+
+	rr := performRequest(router, "PUT", "/api/v1/patient/10/admissions/5/discharge", gin.H{"discharge_reason": "Recovered"}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var admission models.Admission
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &admission))
+	assert.Equal(t, models.AdmissionStatusDischarged, admission.Status)
+	repo.AssertExpectations(t)
+}
+
+func TestDischargeAdmissionHandler_Rejections(t *testing.T) {
+	admittedAt := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		admission *models.Admission
+		err       error
+		body      gin.H
+		want      int
+	}{
+		{"unknown admission", nil, gorm.ErrRecordNotFound, gin.H{"discharge_reason": "Recovered"}, http.StatusNotFound},
+		{"already discharged", &models.Admission{ID: 5, AdmittedAt: admittedAt, Status: models.AdmissionStatusDischarged}, nil,
+			gin.H{"discharge_reason": "Recovered"}, http.StatusConflict},
+		{"before admission", &models.Admission{ID: 5, AdmittedAt: admittedAt, Status: models.AdmissionStatusAdmitted}, nil,
+			gin.H{"discharge_reason": "Recovered", "discharged_at": "2024-02-28T08:00:00Z"}, http.StatusBadRequest},
+		{"missing reason", nil, nil, gin.H{}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, repo := newTestRouter()
+			staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+			token := loginToken(t, router, repo, staff, "password123")
+			repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+			repo.On("GetAdmission", uint(10), uint(5)).Return(tt.admission, tt.err).Maybe()
+
+			rr := performRequest(router, "PUT", "/api/v1/patient/10/admissions/5/discharge", tt.body, token)
+
+			assert.Equal(t, tt.want, rr.Code, rr.Body.String())
+			repo.AssertNotCalled(t, "DischargeAdmission", mock.Anything)
+		})
+	}
+}
+
+func TestListAdmissionsHandler_Paginates(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("ListAdmissionsByPatient", uint(10), uint(1), 0, 5).Return([]models.Admission{{ID: 1}}, int64(1), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/admissions?page_size=5", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var page models.PaginatedResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, int64(1), page.Total)
+	repo.AssertExpectations(t)
+}
+
+func TestWardCensusHandler_ScopedToHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("ListCurrentWardAdmissions", uint(2), "ICU").Return([]models.WardCensusEntry{{
+		Admission: models.Admission{ID: 5, PatientID: 10, HospitalID: 2, Ward: "ICU", Status: models.AdmissionStatusAdmitted},
+		Patient:   models.Patient{ID: 10, HospitalID: 2, PatientHN: "HN010"},
+	}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/ward/ICU/current", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var census []models.WardCensusEntry
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &census))
+	if assert.Len(t, census, 1) {
+		assert.Equal(t, "HN010", census[0].Patient.PatientHN)
+		assert.Equal(t, uint(5), census[0].Admission.ID)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestWardCensusHandler_EmptyWard(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("ListCurrentWardAdmissions", uint(1), "Maternity").Return(nil, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/ward/Maternity/current", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+}
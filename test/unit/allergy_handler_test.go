@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUpsertAllergyHandler_RejectsUnknownSeverity(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/allergies", gin.H{"substance": "Latex", "severity": "extreme"}, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "UpsertAllergy", mock.Anything)
+}
+
+func TestUpsertAllergyHandler_RejectsBlankSubstance(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/allergies", gin.H{"substance": "   ", "severity": "mild"}, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "UpsertAllergy", mock.Anything)
+}
+
+func TestUpdateAllergyHandler_RejectsBlankSubstance(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "PUT", "/api/v1/patient/10/allergies/4", gin.H{"substance": "\t ", "severity": "mild"}, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "UpdateAllergy", mock.Anything)
+}
+
+func TestUpsertAllergyHandler_UpdatesExisting(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("UpsertAllergy", mock.MatchedBy(func(a *models.Allergy) bool {
+		return a.PatientID == 10 && a.Substance == "Latex" && a.NotedBy == 3
+	})).Return(false, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/allergies", gin.H{"substance": " Latex ", "severity": "severe"}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestGetPatientHandler_RejectsUnknownInclude(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10?include=secrets", nil, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
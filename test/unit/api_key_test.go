@@ -0,0 +1,177 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// testAPIKeySecret is the secret half of the keys built by storedAPIKey.
+const testAPIKeySecret = "0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// storedAPIKey returns the row for the key "hmk_<prefix>_<testAPIKeySecret>".
+func storedAPIKey(t *testing.T, id uint, prefix string, hospitalID uint) *models.APIKey {
+	t.Helper()
+	hash, err := utils.HashPassword(testAPIKeySecret)
+	assert.NoError(t, err)
+	return &models.APIKey{ID: id, HospitalID: hospitalID, Prefix: prefix, KeyHash: hash, Description: "billing", IsActive: true}
+}
+
+// performAPIKeyRequest sends a request authenticated with an X-API-Key header.
+func performAPIKeyRequest(router *gin.Engine, method, path, apiKey string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("X-API-Key", apiKey)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAPIKeyAuth_ActsForItsHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindAPIKeyByPrefix", "a1b2c3").Return(storedAPIKey(t, 7, "a1b2c3", 1), nil)
+	repo.On("TouchAPIKey", uint(7), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, FirstNameEN: "Somchai"}, nil)
+
+	rr := performAPIKeyRequest(router, "GET", "/api/v1/patient/10", "hmk_a1b2c3_"+testAPIKeySecret)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "Somchai")
+	repo.AssertExpectations(t)
+}
+
+func TestAPIKeyAuth_OtherHospitalPatientNotFound(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindAPIKeyByPrefix", "a1b2c3").Return(storedAPIKey(t, 7, "a1b2c3", 2), nil)
+	repo.On("TouchAPIKey", uint(7), mock.Anything).Return(nil)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	rr := performAPIKeyRequest(router, "GET", "/api/v1/patient/10", "hmk_a1b2c3_"+testAPIKeySecret)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAPIKeyAuth_Rejected(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name   string
+		key    string
+		mutate func(key *models.APIKey)
+		error  string
+	}{
+		{"expired", "hmk_a1b2c3_" + testAPIKeySecret, func(key *models.APIKey) { key.ExpiresAt = &past }, "API key has expired"},
+		{"revoked", "hmk_a1b2c3_" + testAPIKeySecret, func(key *models.APIKey) { key.IsActive = false }, "API key has been revoked"},
+		{"revoked before expiry", "hmk_a1b2c3_" + testAPIKeySecret, func(key *models.APIKey) { key.IsActive, key.ExpiresAt = false, &future }, "API key has been revoked"},
+		{"wrong secret", "hmk_a1b2c3_wrong", func(key *models.APIKey) {}, "invalid API key"},
+		{"malformed", "not-an-api-key", nil, "invalid API key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, repo := newTestRouter()
+			if tt.mutate != nil {
+				key := storedAPIKey(t, 7, "a1b2c3", 1)
+				tt.mutate(key)
+				repo.On("FindAPIKeyByPrefix", "a1b2c3").Return(key, nil)
+			}
+
+			rr := performAPIKeyRequest(router, "GET", "/api/v1/patient/10", tt.key)
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			assert.JSONEq(t, `{"code":"AUTH_005","error":"`+tt.error+`"}`, rr.Body.String())
+			repo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything)
+			repo.AssertNotCalled(t, "GetPatientByID", mock.Anything)
+		})
+	}
+}
+
+func TestAPIKeyAuth_UnknownPrefix(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindAPIKeyByPrefix", "ffffff").Return(nil, gorm.ErrRecordNotFound)
+
+	rr := performAPIKeyRequest(router, "GET", "/api/v1/patient/10", "hmk_ffffff_"+testAPIKeySecret)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid API key")
+}
+
+func TestAPIKeyAuth_NoAdminEndpoints(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindAPIKeyByPrefix", "a1b2c3").Return(storedAPIKey(t, 7, "a1b2c3", 1), nil)
+	repo.On("TouchAPIKey", uint(7), mock.Anything).Return(nil)
+
+	rr := performAPIKeyRequest(router, "GET", "/api/v1/admin/staff/export", "hmk_a1b2c3_"+testAPIKeySecret)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestCreateAPIKeyHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	var stored *models.APIKey
+	repo.On("CreateAPIKey", mock.MatchedBy(func(key *models.APIKey) bool {
+		return key.HospitalID == 1 && key.Description == "billing" && key.IsActive
+	})).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*models.APIKey)
+		stored.ID = 4
+	}).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/admin/api-keys", gin.H{"description": "billing"}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var resp struct {
+		ID     uint   `json:"id"`
+		Key    string `json:"key"`
+		Prefix string `json:"prefix"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, uint(4), resp.ID)
+	assert.True(t, strings.HasPrefix(resp.Key, "hmk_"+resp.Prefix+"_"), resp.Key)
+	assert.NotContains(t, rr.Body.String(), stored.KeyHash, "the hash is never returned")
+
+	// Only the hash is stored, and it verifies the secret in the returned key
+	secret := strings.TrimPrefix(resp.Key, "hmk_"+resp.Prefix+"_")
+	assert.NotContains(t, stored.KeyHash, secret)
+	assert.True(t, utils.CheckPasswordHash(secret, stored.KeyHash))
+}
+
+func TestCreateAPIKeyHandler_Validation(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	for _, body := range []gin.H{
+		{},
+		{"description": "billing", "expires_at": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+	} {
+		rr := performRequest(router, "POST", "/api/v1/admin/api-keys", body, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	}
+	repo.AssertNotCalled(t, "CreateAPIKey", mock.Anything)
+
+	staffToken := importAdminToken(t, router, repo, models.RoleStaff)
+	rr := performRequest(router, "POST", "/api/v1/admin/api-keys", gin.H{"description": "billing"}, staffToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestRevokeAPIKeyHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("RevokeAPIKey", uint(4), uint(1)).Return(nil)
+	repo.On("RevokeAPIKey", uint(5), uint(1)).Return(gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "DELETE", "/api/v1/admin/api-keys/4", nil, token)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Another hospital's key looks missing
+	rr = performRequest(router, "DELETE", "/api/v1/admin/api-keys/5", nil, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hospital-middleware/pkg/apperror"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// handleError runs apperror.HandleError on err and returns the recorded response.
+func handleError(err error) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rr)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	apperror.HandleError(c, err)
+	return rr
+}
+
+func TestHandleError_StatusPerType(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   apperror.Code
+	}{
+		{apperror.NotFound(apperror.CodePatientNotFound, "Request failed"), http.StatusNotFound, apperror.CodePatientNotFound},
+		{apperror.Conflict(apperror.CodePatientMoved, "Request failed"), http.StatusConflict, apperror.CodePatientMoved},
+		{apperror.Validation(apperror.CodeInvalidQuery, "Request failed"), http.StatusBadRequest, apperror.CodeInvalidQuery},
+		{apperror.Unauthorized(apperror.CodeInvalidToken, "Request failed"), http.StatusUnauthorized, apperror.CodeInvalidToken},
+		{apperror.Forbidden(apperror.CodeAdminRequired, "Request failed"), http.StatusForbidden, apperror.CodeAdminRequired},
+		{apperror.Internal("Request failed"), http.StatusInternalServerError, apperror.CodeInternal},
+		{apperror.New(http.StatusUnprocessableEntity, apperror.CodeInvalidStatusTransition, "Request failed"), http.StatusUnprocessableEntity, apperror.CodeInvalidStatusTransition},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%T", tt.err), func(t *testing.T) {
+			rr := handleError(tt.err)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.JSONEq(t, `{"code":"`+string(tt.wantCode)+`","error":"Request failed"}`, rr.Body.String())
+		})
+	}
+}
+
+func TestHandleError_WrappedError(t *testing.T) {
+	err := fmt.Errorf("loading patient 7: %w", apperror.NotFound(apperror.CodePatientNotFound, "Patient not found"))
+
+	rr := handleError(err)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"code":"PATIENT_001","error":"Patient not found"}`, rr.Body.String(), "the wrapping context is not shown")
+	var notFound *apperror.NotFoundError
+	assert.True(t, errors.As(err, &notFound))
+}
+
+func TestHandleError_UntypedErrorIsHidden(t *testing.T) {
+	rr := handleError(errors.New(`pq: relation "patients" does not exist`))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.JSONEq(t, `{"code":"INTERNAL_001","error":"Internal server error"}`, rr.Body.String())
+}
+
+func TestHandleError_UnavailableSetsRetryAfter(t *testing.T) {
+	rr := handleError(apperror.Unavailable(apperror.CodeDatabaseUnavailable, "Try again", 1500*time.Millisecond))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"), "rounded up to whole seconds")
+	assert.JSONEq(t, `{"code":"INTERNAL_002","error":"Try again"}`, rr.Body.String())
+
+	rr = handleError(apperror.Internal("Request failed"))
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestHandleError_ValidationFields(t *testing.T) {
+	rr := handleError(&apperror.ValidationError{
+		Code:    apperror.CodeInvalidBody,
+		Message: "Invalid request body",
+		Fields:  map[string]string{"status": "is required", "blood_type": "must be one of: A+, B+"},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var body apperror.ErrorResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, apperror.ErrorResponse{
+		Code:    apperror.CodeInvalidBody,
+		Message: "Invalid request body",
+		Details: []apperror.FieldError{ // Sorted by field
+			{Field: "blood_type", Message: "must be one of: A+, B+"},
+			{Field: "status", Message: "is required"},
+		},
+	}, body)
+}
@@ -0,0 +1,248 @@
+package unit
+
+import (
+	"bytes"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/utils"
+	"hospital-middleware/test/mocks"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// captureLog collects log output until the test ends.
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+// restoreAuthService puts the shared test configuration back once the test ends.
+func restoreAuthService(t *testing.T) {
+	t.Cleanup(func() { assert.NoError(t, services.InitializeAuthService(testConfig)) })
+}
+
+func TestInitializeAuthService_EmptySecretSignsButWarns(t *testing.T) {
+	restoreAuthService(t)
+	logs := captureLog(t)
+	cfg := *testConfig
+	cfg.JWTSecret = ""
+
+	err := services.InitializeAuthService(&cfg)
+
+	assert.NoError(t, err, "an empty HMAC key still signs and validates")
+	assert.True(t, cfg.JWTSecretInsecure())
+	assert.Contains(t, logs.String(), "WARNING: JWT_SECRET is empty or set to the default insecure value")
+	assert.NoError(t, services.CheckTokenSigning())
+}
+
+func TestInitializeAuthService_StrongSecretDoesNotWarn(t *testing.T) {
+	restoreAuthService(t)
+	logs := captureLog(t)
+
+	assert.NoError(t, services.InitializeAuthService(testConfig))
+	assert.False(t, testConfig.JWTSecretInsecure())
+	assert.NotContains(t, logs.String(), "WARNING")
+}
+
+func TestInitializeAuthService_FailsWhenTokensCannotValidate(t *testing.T) {
+	restoreAuthService(t)
+	cfg := *testConfig
+	cfg.JWTExpiry = 0 // Every token is already expired when issued
+
+	err := services.InitializeAuthService(&cfg)
+
+	assert.ErrorIs(t, err, services.ErrTokenSelfCheckFailed)
+}
+
+func TestReadyHandler(t *testing.T) {
+	router, _ := newTestRouter()
+
+	rr := performRequest(router, "GET", "/ready", nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"READY","checks":{"jwt_signing":"ok"}}`, rr.Body.String())
+
+	restoreAuthService(t)
+	cfg := *testConfig
+	cfg.JWTExpiry = 0
+	_ = services.InitializeAuthService(&cfg) // Fails the self-check but leaves the settings in place
+
+	rr = performRequest(router, "GET", "/ready", nil, "")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.JSONEq(t, `{"status":"NOT READY","checks":{"jwt_signing":"failed"}}`, rr.Body.String())
+}
+
+// loginTimingRepo knows one staff member, "known", at Hospital A, and Hospital B with no such staff.
+func loginTimingRepo(t testing.TB) *mocks.MockPatientRepository {
+	hash, err := utils.HashPassword("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := new(mocks.MockPatientRepository)
+	repo.On("FindStaffByUsername", "known").Return(&models.Staff{ID: 1, Username: "known", PasswordHash: hash, HospitalID: 1, IsActive: true}, nil)
+	repo.On("FindStaffByUsername", "unknown").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	repo.On("GetHospitalIDByName", "Hospital B").Return(uint(2), nil)
+	repo.On("GetHospitalIDByName", "Hospital Z").Return(uint(0), database.ErrHospitalNotFound)
+	return repo
+}
+
+// fastestLogin returns the quickest of several failed login attempts, which is the least noisy measure.
+func fastestLogin(t *testing.T, repo *mocks.MockPatientRepository, req models.StaffLoginRequest, wantErr error) time.Duration {
+	fastest := time.Duration(math.MaxInt64)
+	for i := 0; i < 20; i++ {
+		start := time.Now()
+		_, _, err := services.AuthenticateStaff(repo, nil, req)
+		elapsed := time.Since(start)
+		assert.ErrorIs(t, err, wantErr)
+		if elapsed < fastest {
+			fastest = elapsed
+		}
+	}
+	return fastest
+}
+
+func TestAuthenticateStaff_FailuresTakeAsLongAsWrongPassword(t *testing.T) {
+	repo := loginTimingRepo(t)
+	wrongPassword := fastestLogin(t, repo, models.StaffLoginRequest{Username: "known", Password: "wrong-password", Hospital: "Hospital A"}, services.ErrInvalidCredentials)
+
+	tests := []struct {
+		name    string
+		req     models.StaffLoginRequest
+		wantErr error
+	}{
+		{"unknown user", models.StaffLoginRequest{Username: "unknown", Password: "wrong-password", Hospital: "Hospital A"}, services.ErrInvalidCredentials},
+		// The right password at the wrong hospital must not confirm the account exists
+		{"hospital mismatch", models.StaffLoginRequest{Username: "known", Password: "password123", Hospital: "Hospital B"}, services.ErrInvalidCredentials},
+		{"unknown hospital", models.StaffLoginRequest{Username: "known", Password: "password123", Hospital: "Hospital Z"}, services.ErrUnknownHospital},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elapsed := fastestLogin(t, repo, tt.req, tt.wantErr)
+
+			// Without a bcrypt comparison these return orders of magnitude faster
+			assert.Greater(t, elapsed, wrongPassword/2, "%s %v, wrong password %v", tt.name, elapsed, wrongPassword)
+		})
+	}
+}
+
+func BenchmarkAuthenticateStaff_UnknownUser(b *testing.B) {
+	benchmarkFailedLogin(b, "unknown")
+}
+
+func BenchmarkAuthenticateStaff_WrongPassword(b *testing.B) {
+	benchmarkFailedLogin(b, "known")
+}
+
+func benchmarkFailedLogin(b *testing.B, username string) {
+	repo := loginTimingRepo(b)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	req := models.StaffLoginRequest{Username: username, Password: "wrong-password", Hospital: "Hospital A"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		services.AuthenticateStaff(repo, nil, req)
+	}
+}
+
+// rotationConfig returns the test configuration with JWT_KEYS set, signing with keyID.
+func rotationConfig(keys map[string]string, keyID string) *config.Config {
+	cfg := *testConfig
+	cfg.JWTKeys = keys
+	cfg.JWTKeyID = keyID
+	return &cfg
+}
+
+// tokenKeyID returns the kid header of a token without checking its signature.
+func tokenKeyID(t *testing.T, token string) interface{} {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &services.Claims{})
+	assert.NoError(t, err)
+	if parsed == nil {
+		return nil
+	}
+	return parsed.Header["kid"]
+}
+
+func TestValidateToken_RotatedOutKeyStillConfigured(t *testing.T) {
+	restoreAuthService(t)
+	oldSecret := strings.Repeat("o", config.MinJWTSecretLength)
+	newSecret := strings.Repeat("n", config.MinJWTSecretLength)
+	router, repo := newTestRouter()
+
+	assert.NoError(t, services.InitializeAuthService(rotationConfig(map[string]string{"2024-06": oldSecret}, "2024-06")))
+	issued := loginToken(t, router, repo, hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A"), "password123")
+	assert.Equal(t, "2024-06", tokenKeyID(t, issued))
+
+	// Rotated: new tokens are signed with the new key, old ones still validate
+	assert.NoError(t, services.InitializeAuthService(rotationConfig(map[string]string{"2024-06": oldSecret, "2025-01": newSecret}, "2025-01")))
+	claims, err := services.ValidateToken(issued)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "nurse", claims.Username)
+	}
+	rr := performRequest(router, "GET", "/api/v1/auth/verify", nil, issued)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rotated := loginToken(t, router, repo, hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A"), "password123")
+	assert.Equal(t, "2025-01", tokenKeyID(t, rotated))
+
+	// Once the old key is removed, its tokens are rejected
+	assert.NoError(t, services.InitializeAuthService(rotationConfig(map[string]string{"2025-01": newSecret}, "2025-01")))
+	_, err = services.ValidateToken(issued)
+	assert.Error(t, err)
+	_, err = services.ValidateToken(rotated)
+	assert.NoError(t, err)
+}
+
+func TestValidateToken_KeyIDMustMatchKey(t *testing.T) {
+	restoreAuthService(t)
+	keys := map[string]string{"a": strings.Repeat("a", config.MinJWTSecretLength), "b": strings.Repeat("b", config.MinJWTSecretLength)}
+	assert.NoError(t, services.InitializeAuthService(rotationConfig(keys, "a")))
+
+	// Signed with key a but claiming to be key b, or naming a key that is not configured
+	for _, kid := range []string{"b", "c"} {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &services.Claims{Username: "forged"})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString([]byte(keys["a"]))
+		assert.NoError(t, err)
+		_, err = services.ValidateToken(signed)
+		assert.Error(t, err, kid)
+	}
+}
+
+func TestValidateToken_SingleSecretAndTokensWithoutKeyID(t *testing.T) {
+	restoreAuthService(t)
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	legacy := func(secret string) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &services.Claims{Username: "legacy"}).SignedString([]byte(secret))
+		assert.NoError(t, err)
+		return signed
+	}
+
+	// Without JWT_KEYS, JWT_SECRET signs as the default key and validates tokens from before key IDs
+	assert.Equal(t, config.DefaultJWTKeyID, tokenKeyID(t, loginToken(t, router, repo, staff, "password123")))
+	_, err := services.ValidateToken(legacy(testConfig.JWTSecret))
+	assert.NoError(t, err)
+
+	// With JWT_KEYS, they validate against the key listed as the default
+	newSecret := strings.Repeat("n", config.MinJWTSecretLength)
+	assert.NoError(t, services.InitializeAuthService(rotationConfig(map[string]string{config.DefaultJWTKeyID: testConfig.JWTSecret, "2025-01": newSecret}, "2025-01")))
+	_, err = services.ValidateToken(legacy(testConfig.JWTSecret))
+	assert.NoError(t, err)
+	assert.NoError(t, services.InitializeAuthService(rotationConfig(map[string]string{"2025-01": newSecret}, "2025-01")))
+	_, err = services.ValidateToken(legacy(testConfig.JWTSecret))
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// signedTestToken signs claims with secret, bypassing the login flow.
+func signedTestToken(t *testing.T, claims *services.Claims, secret string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	assert.NoError(t, err)
+	return token
+}
+
+// verifyRequest calls GET /auth/verify with the given Authorization header.
+func verifyRequest(router http.Handler, authHeader string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/api/v1/auth/verify", nil)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestVerifyTokenHandler_ValidToken(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "verifier", "password123", 2, "Hospital B")
+	staff.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := verifyRequest(router, "Bearer "+token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	var resp models.TokenVerifyResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, uint(9), resp.UserID)
+	assert.Equal(t, "verifier", resp.Username)
+	assert.Equal(t, uint(2), resp.HospitalID)
+	assert.Equal(t, models.RoleAdmin, resp.Role)
+	assert.WithinDuration(t, time.Now().Add(testConfig.JWTExpiry), resp.ExpiresAt, time.Minute)
+	// Verification stays off the database
+	repo.AssertNotCalled(t, "IsTokenRevoked", mock.Anything)
+}
+
+func TestVerifyTokenHandler_InvalidTokens(t *testing.T) {
+	router, _ := newTestRouter()
+	claims := func(expiresAt time.Time, enrollmentOnly bool) *services.Claims {
+		return &services.Claims{
+			UserID: 9, Username: "verifier", HospitalID: 2, Role: models.RoleStaff,
+			TwoFactorEnrollment: enrollmentOnly,
+			RegisteredClaims:    jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(expiresAt)},
+		}
+	}
+	valid := signedTestToken(t, claims(time.Now().Add(time.Hour), false), testConfig.JWTSecret)
+
+	tests := []struct {
+		name       string
+		authHeader string
+		code       apperror.Code
+		error      string
+	}{
+		{"missing header", "", apperror.CodeAuthRequired, "Bearer token required"},
+		{"not bearer", "Basic " + valid, apperror.CodeAuthRequired, "Bearer token required"},
+		{"garbage", "Bearer not-a-token", apperror.CodeInvalidToken, "invalid token"},
+		{"tampered", "Bearer " + valid + "x", apperror.CodeInvalidToken, "invalid token"},
+		{"wrong secret", "Bearer " + signedTestToken(t, claims(time.Now().Add(time.Hour), false), "some_other_secret_that_is_long_enough"), apperror.CodeInvalidToken, "invalid token"},
+		{"expired", "Bearer " + signedTestToken(t, claims(time.Now().Add(-time.Minute), false), testConfig.JWTSecret), apperror.CodeInvalidToken, "token is expired"},
+		{"enrollment only", "Bearer " + signedTestToken(t, claims(time.Now().Add(time.Hour), true), testConfig.JWTSecret), apperror.CodeTwoFactorEnrollmentRequired, "Two-factor enrollment required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := verifyRequest(router, tt.authHeader)
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			assert.JSONEq(t, `{"code":"`+string(tt.code)+`","error":"`+tt.error+`"}`, rr.Body.String())
+		})
+	}
+}
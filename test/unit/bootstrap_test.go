@@ -0,0 +1,64 @@
+package unit
+
+import (
+	"errors"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/utils"
+	"hospital-middleware/test/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestBootstrapAdmin_CreatesAdmin(t *testing.T) {
+	repo := new(mocks.MockPatientRepository)
+	repo.On("FindStaffByUsername", "root").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	repo.On("CreateStaff", mock.MatchedBy(func(s *models.Staff) bool {
+		return s.Username == "root" && s.HospitalID == 1 && s.Role == models.RoleAdmin &&
+			utils.CheckPasswordHash("Sup3r$ecret!", s.PasswordHash)
+	})).Return(nil)
+
+	created, err := services.BootstrapAdmin(repo, strictPolicy, "root", "Sup3r$ecret!", "Hospital A")
+
+	assert.NoError(t, err)
+	assert.True(t, created)
+	repo.AssertExpectations(t)
+}
+
+func TestBootstrapAdmin_ExistingUsernameUnchanged(t *testing.T) {
+	repo := new(mocks.MockPatientRepository)
+	repo.On("FindStaffByUsername", "root").Return(&models.Staff{ID: 1, Username: "root"}, nil)
+
+	created, err := services.BootstrapAdmin(repo, strictPolicy, "root", "weak", "Hospital A")
+
+	assert.NoError(t, err)
+	assert.False(t, created)
+	repo.AssertNotCalled(t, "CreateStaff", mock.Anything)
+}
+
+func TestBootstrapAdmin_WeakPassword(t *testing.T) {
+	repo := new(mocks.MockPatientRepository)
+	repo.On("FindStaffByUsername", "root").Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := services.BootstrapAdmin(repo, strictPolicy, "root", "weak", "Hospital A")
+
+	assert.ErrorContains(t, err, "password policy")
+	repo.AssertNotCalled(t, "CreateStaff", mock.Anything)
+}
+
+func TestBootstrapAdmin_UnknownHospital(t *testing.T) {
+	repo := new(mocks.MockPatientRepository)
+	repo.On("FindStaffByUsername", "root").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Nowhere").Return(uint(0), database.ErrHospitalNotFound)
+
+	_, err := services.BootstrapAdmin(repo, config.PasswordPolicy{}, "root", "password123", "Nowhere")
+
+	assert.True(t, errors.Is(err, database.ErrHospitalNotFound))
+	repo.AssertNotCalled(t, "CreateStaff", mock.Anything)
+}
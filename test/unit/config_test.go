@@ -0,0 +1,317 @@
+package unit
+
+import (
+	"hospital-middleware/internal/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigLoad_APIBasePath(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "/api/v1", false},
+		{"/hospital", "/hospital", false},
+		{"/hospital/api/", "/hospital/api", false},
+		{"/", "/", false},
+		{"hospital", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if tt.value != "" {
+				t.Setenv("API_BASE_PATH", tt.value)
+			}
+
+			cfg, err := config.Load()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.APIBasePath)
+		})
+	}
+}
+
+func TestConfigLoad_AppEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		appEnv   string
+		ginMode  string
+		want     string
+		wantMode string
+		wantErr  bool
+	}{
+		{"default", "", "", config.AppEnvDevelopment, "debug", false},
+		{"production", "production", "", config.AppEnvProduction, "release", false},
+		{"test", "test", "", config.AppEnvTest, "test", false},
+		{"GIN_MODE fallback", "", "release", config.AppEnvProduction, "release", false},
+		{"APP_ENV wins over GIN_MODE", "development", "release", config.AppEnvDevelopment, "debug", false},
+		{"unknown", "staging", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.appEnv != "" {
+				t.Setenv("APP_ENV", tt.appEnv)
+			}
+			if tt.ginMode != "" {
+				t.Setenv("GIN_MODE", tt.ginMode)
+			}
+
+			cfg, err := config.Load()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.AppEnv)
+			assert.Equal(t, tt.wantMode, cfg.GinMode())
+		})
+	}
+}
+
+func TestConfigLoad_AutoMigrate(t *testing.T) {
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.True(t, cfg.AutoMigrate, "servers migrate on start unless told otherwise")
+
+	t.Setenv("AUTO_MIGRATE", "false")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.False(t, cfg.AutoMigrate)
+}
+
+// validConfig returns a configuration that passes config.Validate.
+func validConfig() *config.Config {
+	return &config.Config{
+		DBHost:     "db",
+		JWTSecret:  "0123456789abcdef0123456789abcdef",
+		JWTExpiry:  time.Hour,
+		ServerPort: "8080",
+		DBSSLMode:  config.DBSSLModeDisable,
+		Timezone:   config.DefaultTimezone,
+	}
+}
+
+func TestValidate_ValidConfig(t *testing.T) {
+	assert.Empty(t, config.Validate(validConfig()))
+}
+
+func TestValidate_EachRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(cfg *config.Config)
+		problem string
+	}{
+		{"short JWT secret", func(cfg *config.Config) { cfg.JWTSecret = "a_very_secret_key" }, "JWT_SECRET must be at least 32 characters long, got 17"},
+		{"empty JWT secret", func(cfg *config.Config) { cfg.JWTSecret = "" }, "JWT_SECRET must be at least 32 characters long, got 0"},
+		{"non-numeric port", func(cfg *config.Config) { cfg.ServerPort = "http" }, `SERVER_PORT must be a port number between 1 and 65535, got "http"`},
+		{"port zero", func(cfg *config.Config) { cfg.ServerPort = "0" }, `SERVER_PORT must be a port number between 1 and 65535, got "0"`},
+		{"port too large", func(cfg *config.Config) { cfg.ServerPort = "65536" }, `SERVER_PORT must be a port number between 1 and 65535, got "65536"`},
+		{"empty DB host", func(cfg *config.Config) { cfg.DBHost = " " }, "DB_HOST must not be empty"},
+		{"zero JWT expiry", func(cfg *config.Config) { cfg.JWTExpiry = 0 }, "JWT_EXPIRY_HOURS must be positive, got 0s"},
+		{"negative JWT expiry", func(cfg *config.Config) { cfg.JWTExpiry = -time.Hour }, "JWT_EXPIRY_HOURS must be positive, got -1h0m0s"},
+		{"unknown time zone", func(cfg *config.Config) { cfg.Timezone = "Asia/Atlantis" }, `TIMEZONE must be an IANA time zone such as Asia/Bangkok, got "Asia/Atlantis"`},
+		{"empty time zone", func(cfg *config.Config) { cfg.Timezone = "" }, `TIMEZONE must be an IANA time zone such as Asia/Bangkok, got ""`},
+		{"unknown SSL mode", func(cfg *config.Config) { cfg.DBSSLMode = "prefer" }, `DB_SSLMODE must be one of disable, require, verify-ca or verify-full, got "prefer"`},
+		{"SSL cert without key", func(cfg *config.Config) { cfg.DBSSLCert = "/certs/client.crt" }, "DB_SSL_CERT and DB_SSL_KEY must be set together"},
+		{"SSL key without cert", func(cfg *config.Config) { cfg.DBSSLKey = "/certs/client.key" }, "DB_SSL_CERT and DB_SSL_KEY must be set together"},
+		{"unknown search cache", func(cfg *config.Config) { cfg.SearchCache = "memcached" }, `SEARCH_CACHE must be memory, redis or empty, got "memcached"`},
+		{"redis search cache without URL", func(cfg *config.Config) { cfg.SearchCache = config.SearchCacheRedis }, `REDIS_URL must be a redis://host:port URL when SEARCH_CACHE is redis, got ""`},
+		{"non-NATS event broker", func(cfg *config.Config) { cfg.EventBrokerURL = "amqp://broker:5672" }, `EVENT_BROKER_URL must be a nats://host:port URL, got "amqp://broker:5672"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(cfg)
+			assert.Equal(t, []string{tt.problem}, config.Validate(cfg))
+		})
+	}
+}
+
+func TestValidate_BoundaryValues(t *testing.T) {
+	cfg := validConfig()
+	cfg.JWTSecret = strings.Repeat("x", config.MinJWTSecretLength)
+	cfg.ServerPort = "65535"
+	assert.Empty(t, config.Validate(cfg))
+
+	cfg.ServerPort = "1"
+	assert.Empty(t, config.Validate(cfg))
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	cfg := &config.Config{ServerPort: "abc"}
+
+	problems := config.Validate(cfg)
+
+	assert.Len(t, problems, 6)
+	assert.Contains(t, problems, "DB_HOST must not be empty")
+	assert.Contains(t, problems, `SERVER_PORT must be a port number between 1 and 65535, got "abc"`)
+
+	cfg = validConfig()
+	cfg.JWTSecret = "short"
+	cfg.DBHost = ""
+	assert.Equal(t, []string{
+		"JWT_SECRET must be at least 32 characters long, got 5",
+		"DB_HOST must not be empty",
+	}, config.Validate(cfg))
+}
+
+func TestConfigLoad_DBReplicaDSN(t *testing.T) {
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.DBReplicaDSN, "without a replica everything reads from the primary")
+
+	dsn := "host=replica user=hospital_user password=secret dbname=hospital_db port=5432 sslmode=disable"
+	t.Setenv("DB_REPLICA_DSN", dsn)
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, dsn, cfg.DBReplicaDSN)
+}
+
+func TestConfigLoad_Timezone(t *testing.T) {
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Bangkok", cfg.Timezone, "the default keeps the original Bangkok behavior")
+
+	t.Setenv("TIMEZONE", "Europe/Berlin")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", cfg.Timezone)
+}
+
+func TestConfigLoad_AutoGenerateHN(t *testing.T) {
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.False(t, cfg.AutoGenerateHN, "clients supply HNs unless told otherwise")
+
+	t.Setenv("AUTO_GENERATE_HN", "true")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.True(t, cfg.AutoGenerateHN)
+}
+
+func TestConfigLoad_AccessLog(t *testing.T) {
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.True(t, cfg.AccessLogSearchResults, "search results are logged unless told otherwise")
+	assert.Equal(t, 1825, cfg.AccessLogRetentionDays)
+
+	t.Setenv("ACCESS_LOG_SEARCH_RESULTS", "false")
+	t.Setenv("ACCESS_LOG_RETENTION_DAYS", "400")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.False(t, cfg.AccessLogSearchResults)
+	assert.Equal(t, 400, cfg.AccessLogRetentionDays)
+
+	t.Setenv("ACCESS_LOG_RETENTION_DAYS", "0")
+	_, err = config.Load()
+	assert.Error(t, err)
+}
+
+func TestConfigLoad_DBSSL(t *testing.T) {
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, config.DBSSLModeDisable, cfg.DBSSLMode)
+	assert.Empty(t, cfg.DBSSLCert)
+
+	t.Setenv("DB_SSLMODE", "verify-full")
+	t.Setenv("DB_SSL_CERT", "/certs/client.crt")
+	t.Setenv("DB_SSL_KEY", "/certs/client.key")
+	t.Setenv("DB_SSL_ROOT_CERT", "/certs/root.crt")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, config.DBSSLModeVerifyFull, cfg.DBSSLMode)
+	assert.Equal(t, "/certs/client.crt", cfg.DBSSLCert)
+	assert.Equal(t, "/certs/client.key", cfg.DBSSLKey)
+	assert.Equal(t, "/certs/root.crt", cfg.DBSSLRootCert)
+}
+
+func TestConfigLoad_SearchCache(t *testing.T) {
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, config.SearchCacheNone, cfg.SearchCache, "caching is opt-in")
+	assert.Equal(t, 15*time.Second, cfg.SearchCacheTTL)
+
+	t.Setenv("SEARCH_CACHE", "redis")
+	t.Setenv("SEARCH_CACHE_TTL_SECONDS", "5")
+	t.Setenv("REDIS_URL", "redis://cache:6379/1")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, config.SearchCacheRedis, cfg.SearchCache)
+	assert.Equal(t, 5*time.Second, cfg.SearchCacheTTL)
+	assert.Equal(t, "redis://cache:6379/1", cfg.RedisURL)
+}
+
+func TestConfigLoad_JWTKeys(t *testing.T) {
+	t.Setenv("JWT_KEYS", `{"2024-06": "old-secret", "2025-01": "new-secret"}`)
+	t.Setenv("JWT_KEY_ID", "2025-01")
+
+	cfg, err := config.Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"2024-06": "old-secret", "2025-01": "new-secret"}, cfg.JWTKeys)
+	keys, keyID := cfg.JWTSigningKeys()
+	assert.Equal(t, cfg.JWTKeys, keys)
+	assert.Equal(t, "2025-01", keyID)
+
+	for _, raw := range []string{`["old-secret"]`, `{"": "secret"}`, `{"a": 1}`} {
+		t.Setenv("JWT_KEYS", raw)
+		_, err = config.Load()
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestConfig_JWTSigningKeysWithoutJWTKeys(t *testing.T) {
+	cfg := validConfig()
+
+	keys, keyID := cfg.JWTSigningKeys()
+
+	assert.Equal(t, map[string]string{config.DefaultJWTKeyID: cfg.JWTSecret}, keys)
+	assert.Equal(t, config.DefaultJWTKeyID, keyID)
+}
+
+func TestValidate_JWTKeys(t *testing.T) {
+	cfg := validConfig()
+	cfg.JWTSecret = "" // Not used with JWT_KEYS
+	cfg.JWTKeys = map[string]string{"2024-06": strings.Repeat("o", config.MinJWTSecretLength), "2025-01": "short"}
+	cfg.JWTKeyID = "2025-02"
+
+	assert.Equal(t, []string{
+		`JWT_KEY_ID must name one of the JWT_KEYS, got "2025-02"`,
+		`JWT_KEYS secret "2025-01" must be at least 32 characters long, got 5`,
+	}, config.Validate(cfg))
+
+	cfg.JWTKeys["2025-01"] = strings.Repeat("n", config.MinJWTSecretLength)
+	cfg.JWTKeyID = "2025-01"
+	assert.Empty(t, config.Validate(cfg))
+	assert.False(t, cfg.JWTSecretInsecure())
+}
+
+func TestConfigLoad_Retention(t *testing.T) {
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 2557, cfg.PatientRetentionDays, "seven years")
+	assert.Equal(t, 30, cfg.RevokedTokenRetentionDays)
+	assert.Equal(t, 500, cfg.RetentionPurgeBatchSize)
+
+	t.Setenv("PATIENT_RETENTION_DAYS", "3650")
+	t.Setenv("REVOKED_TOKEN_RETENTION_DAYS", "7")
+	t.Setenv("RETENTION_PURGE_BATCH_SIZE", "100")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 3650, cfg.PatientRetentionDays)
+	assert.Equal(t, 7, cfg.RevokedTokenRetentionDays)
+	assert.Equal(t, 100, cfg.RetentionPurgeBatchSize)
+
+	t.Setenv("RETENTION_PURGE_BATCH_SIZE", "0")
+	_, err = config.Load()
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRecordConsentHandler_Grant(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	var saved *models.Consent
+	repo.On("CreateConsent", mock.AnythingOfType("*models.Consent"), mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionConsentRecorded && a.PatientID == 10 && a.StaffID == 3
+	})).Run(func(args mock.Arguments) { saved = args.Get(0).(*models.Consent) }).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/consent", gin.H{"consent_type": "treatment", "granted": true}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	if assert.NotNil(t, saved) {
+		assert.True(t, saved.Granted)
+		assert.Equal(t, uint(1), saved.HospitalID)
+		assert.Equal(t, uint(3), saved.StaffWitnessID)
+		assert.NotNil(t, saved.GrantedAt)
+		assert.Nil(t, saved.RevokedAt)
+	}
+}
+
+func TestRecordConsentHandler_Revoke(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	var saved *models.Consent
+	repo.On("CreateConsent", mock.AnythingOfType("*models.Consent"), mock.Anything).
+		Run(func(args mock.Arguments) { saved = args.Get(0).(*models.Consent) }).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/consent", gin.H{"consent_type": "data_sharing", "granted": false}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	if assert.NotNil(t, saved) {
+		assert.False(t, saved.Granted)
+		assert.Nil(t, saved.GrantedAt)
+		assert.NotNil(t, saved.RevokedAt)
+	}
+}
+
+func TestRecordConsentHandler_Validation(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	for name, body := range map[string]gin.H{
+		"unknown type":    {"consent_type": "marketing", "granted": true},
+		"missing granted": {"consent_type": "research"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := performRequest(router, "POST", "/api/v1/patient/10/consent", body, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+	repo.AssertNotCalled(t, "CreateConsent", mock.Anything, mock.Anything)
+}
+
+func TestListConsentsHandler_OtherHospitalPatient(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/consent", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "ListConsentsByPatient", mock.Anything)
+}
+
+func TestUpdatePatientConsentHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, ConsentStatus: models.ConsentStatusUnknown}, nil)
+	repo.On("UpdatePatientConsent", mock.MatchedBy(func(c *models.Consent) bool {
+		// Recorded as a witnessed data sharing refusal, like POST /consent would
+		return c.PatientID == 10 && c.HospitalID == 1 && c.ConsentType == models.ConsentTypeDataSharing &&
+			!c.Granted && c.RevokedAt != nil && c.StaffWitnessID == 1
+	}), "2024-v2", mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionConsentUpdated && a.PatientID == 10 && a.StaffID == 1 &&
+			a.Details == "from=unknown to=denied version=2024-v2"
+	})).Return(nil)
+
+	rr := performRequest(router, "PUT", "/api/v1/patient/10/consent", gin.H{"consent_status": "denied", "consent_version": " 2024-v2 "}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"consent_status":"denied"`)
+	assert.Contains(t, rr.Body.String(), `"consent_version":"2024-v2"`)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdatePatientConsentHandler_AdminOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "PUT", "/api/v1/patient/10/consent", gin.H{"consent_status": "granted"}, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "UpdatePatientConsent", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdatePatientConsentHandler_Validation(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	for name, body := range map[string]gin.H{
+		"missing status": {"consent_version": "v1"},
+		"unknown status": {"consent_status": "maybe"},
+		// Consent history can't go back to undecided
+		"reset to unknown": {"consent_status": "unknown"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := performRequest(router, "PUT", "/api/v1/patient/10/consent", body, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+	repo.AssertNotCalled(t, "UpdatePatientConsent", mock.Anything, mock.Anything, mock.Anything)
+}
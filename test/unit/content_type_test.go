@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// performRawRequest sends body with the given Content-Type, or none when contentType is empty.
+func performRawRequest(router http.Handler, method, path, body, contentType string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRequireJSONContentType_RejectsMissingContentType(t *testing.T) {
+	router, repo := newTestRouter()
+
+	rr := performRawRequest(router, "POST", "/api/v1/staff/create",
+		`{"username":"newuser","password":"Password123!","hospital":"Hospital A"}`, "")
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	var body map[string]string
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "Content-Type must be application/json", body["error"])
+	repo.AssertNotCalled(t, "FindStaffByUsername")
+}
+
+func TestRequireJSONContentType_RejectsOtherContentTypes(t *testing.T) {
+	router, _ := newTestRouter()
+
+	for _, contentType := range []string{"text/plain", "application/x-www-form-urlencoded", "multipart/form-data; boundary=x", "not a media type"} {
+		rr := performRawRequest(router, "POST", "/api/v1/staff/login", `{}`, contentType)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code, contentType)
+	}
+}
+
+func TestRequireJSONContentType_AcceptsJSONWithCharset(t *testing.T) {
+	router, _ := newTestRouter()
+
+	// An incomplete body gets past the content type check and fails validation instead
+	rr := performRawRequest(router, "POST", "/api/v1/staff/login", `{}`, "application/json; charset=utf-8")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRequireJSONContentType_IgnoresGETRequests(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 1, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	req, _ := http.NewRequest("GET", "/api/v1/patient/search", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "text/plain")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	// Reaches the handler, which wants at least one criterion
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "at least one search criterion required")
+}
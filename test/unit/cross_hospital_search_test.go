@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchPatientHandler_NonSuperAdminCannotWidenScope(t *testing.T) {
+	for _, role := range []string{models.RoleStaff, models.RoleAdmin, models.RoleViewer} {
+		for _, scope := range []string{"all", "1,2", "2"} {
+			t.Run(role+"/"+scope, func(t *testing.T) {
+				router, repo := newTestRouter()
+				staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+				staff.Role = role
+				token := loginToken(t, router, repo, staff, "password123")
+				repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{{ID: 1, HospitalID: 1}}, nil)
+
+				rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&hospital_id="+scope, nil, token)
+
+				assert.Equal(t, http.StatusOK, rr.Code)
+				repo.AssertCalled(t, "SearchPatients", mock.Anything, uint(1), searchLimit)
+				repo.AssertNotCalled(t, "SearchPatientsAcrossHospitals", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				assert.NotContains(t, rr.Body.String(), "hospital_name")
+			})
+		}
+	}
+}
+
+func TestSearchPatientHandler_SuperAdminSearchesAllHospitals(t *testing.T) {
+	router, repo := newTestRouter()
+	superAdmin := hashedStaff(t, 1, "central", "password123", 1, "Hospital A")
+	superAdmin.Role = models.RoleSuperAdmin
+	token := loginToken(t, router, repo, superAdmin, "password123")
+	repo.On("SearchPatientsAcrossHospitals", mock.Anything, []uint(nil), uint(1), searchLimit).Return([]models.PatientWithHospital{
+		{Patient: models.Patient{ID: 1, HospitalID: 1}, HospitalName: "Hospital A"},
+		{Patient: models.Patient{ID: 2, HospitalID: 2}, HospitalName: "Hospital B"},
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&hospital_id=all", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var results []models.PatientWithHospital
+	decodeSearchResponse(t, rr.Body.Bytes(), &results)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "Hospital A", results[0].HospitalName)
+		assert.Equal(t, "Hospital B", results[1].HospitalName)
+	}
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_SuperAdminHospitalList(t *testing.T) {
+	router, repo := newTestRouter()
+	superAdmin := hashedStaff(t, 1, "central", "password123", 1, "Hospital A")
+	superAdmin.Role = models.RoleSuperAdmin
+	token := loginToken(t, router, repo, superAdmin, "password123")
+	repo.On("SearchPatientsAcrossHospitals", mock.Anything, []uint{2, 3}, uint(1), searchLimit).Return([]models.PatientWithHospital{}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&hospital_id=2,%203", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data":[],"count":0,"truncated":false}`, rr.Body.String())
+	repo.AssertExpectations(t)
+}
+
+func TestSearchPatientHandler_SuperAdminPaged(t *testing.T) {
+	router, repo := newTestRouter()
+	superAdmin := hashedStaff(t, 1, "central", "password123", 1, "Hospital A")
+	superAdmin.Role = models.RoleSuperAdmin
+	token := loginToken(t, router, repo, superAdmin, "password123")
+	repo.On("SearchPatientsAcrossHospitalsPage", mock.Anything, []uint(nil), uint(1), 0, 20).Return([]models.PatientWithHospital{
+		{Patient: models.Patient{ID: 2, HospitalID: 2}, HospitalName: "Hospital B"},
+	}, int64(1), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&hospital_id=all&page=1", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"total":1`)
+	assert.Contains(t, rr.Header().Get("Link"), `rel="last"`)
+	repo.AssertNotCalled(t, "SearchPatientsAcrossHospitals", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_SuperAdminInvalidScope(t *testing.T) {
+	router, repo := newTestRouter()
+	superAdmin := hashedStaff(t, 1, "central", "password123", 1, "Hospital A")
+	superAdmin.Role = models.RoleSuperAdmin
+	token := loginToken(t, router, repo, superAdmin, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&hospital_id=1,abc", nil, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "SearchPatientsAcrossHospitals", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_ConsortiumSearchRequiresAdmin(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&cross_hospital=true", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_ConsortiumSearch(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("IsFeatureEnabled", uint(1), models.FeatureCrossHospitalSearch).Return(true)
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.CrossHospital && q.CriteriaCount() == 1
+	}), uint(1), searchLimit).Return([]models.Patient{
+		{ID: 1, HospitalID: 1},
+		{ID: 2, HospitalID: 3, SourceHospitalName: "Hospital C"},
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&cross_hospital=true", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var results []models.Patient
+	decodeSearchResponse(t, rr.Body.Bytes(), &results)
+	if assert.Len(t, results, 2) {
+		assert.Empty(t, results[0].SourceHospitalName)
+		assert.Equal(t, "Hospital C", results[1].SourceHospitalName)
+	}
+	repo.AssertExpectations(t)
+}
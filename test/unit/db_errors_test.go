@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"deadline exceeded", fmt.Errorf("searching patients: %w", context.DeadlineExceeded), true},
+		{"bad connection", driver.ErrBadConn, true},
+		{"network error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"statement timeout", &pgconn.PgError{Code: "57014"}, true},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"server shutting down", &pgconn.PgError{Code: "57P01"}, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"cancelled by the client", context.Canceled, false},
+		{"other error", errors.New("something broke"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, database.IsTransient(tt.err))
+		})
+	}
+}
+
+func TestSearchPatientHandler_TransientDatabaseError(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return(nil, fmt.Errorf("searching patients: %w", context.DeadlineExceeded))
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Anyone", nil, token)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"code":"INTERNAL_002","error":"Database temporarily unavailable, please retry"}`, rr.Body.String())
+}
+
+func TestSearchPatientHandler_FatalDatabaseError(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return(nil, &pgconn.PgError{Code: "42601", Message: "syntax error"})
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Anyone", nil, token)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"code":"INTERNAL_001","error":"Database error during patient search"}`, rr.Body.String())
+}
+
+func TestCreateStaffHandler_TransientDatabaseError(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "someone").Return(nil, context.DeadlineExceeded)
+
+	staffData := models.StaffCreateRequest{Username: "someone", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+	repo.AssertNotCalled(t, "CreateStaff", mock.Anything)
+}
+
+func TestLoginStaffHandler_TransientDatabaseError(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	repo.On("FindStaffByUsername", "someone").Return(nil, fmt.Errorf("finding staff: %w", context.DeadlineExceeded))
+
+	loginData := models.StaffLoginRequest{Username: "someone", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code, "an outage is not reported as wrong credentials")
+	assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+}
@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateDiagnosisHandler_Records(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "doctor", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("CreatePatientDiagnosis", mock.MatchedBy(func(d *models.PatientDiagnosis) bool {
+		return d.PatientID == 10 && d.HospitalID == 1 && d.StaffID == 3 && d.ICD10Code == "E11.9" &&
+			d.DiagnosisType == models.DiagnosisTypePrimary && !d.DiagnosedAt.IsZero()
+	})).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/diagnoses", gin.H{"icd10_code": " e11.9 ", "diagnosis_type": "primary"}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	repo.AssertExpectations(t)
+}
+
+func TestCreateDiagnosisHandler_UnknownCode(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "doctor", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("CreatePatientDiagnosis", mock.Anything).Return(&pgconn.PgError{Code: "23503"})
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/diagnoses", gin.H{"icd10_code": "ZZZ", "diagnosis_type": "working"}, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Unknown ICD-10 code")
+}
+
+func TestCreateDiagnosisHandler_InvalidType(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "doctor", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	for _, body := range []gin.H{{"icd10_code": "I10"}, {"icd10_code": "I10", "diagnosis_type": "final"}, {"icd10_code": "  ", "diagnosis_type": "primary"}} {
+		rr := performRequest(router, "POST", "/api/v1/patient/10/diagnoses", body, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	}
+	repo.AssertNotCalled(t, "CreatePatientDiagnosis", mock.Anything)
+}
+
+func TestCreateDiagnosisHandler_OtherHospitalPatient(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "doctor", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/diagnoses", gin.H{"icd10_code": "I10", "diagnosis_type": "primary"}, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "CreatePatientDiagnosis", mock.Anything)
+}
+
+func TestListDiagnosesHandler_Paginates(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "doctor", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("ListPatientDiagnoses", uint(10), uint(1), 5, 5).Return([]models.PatientDiagnosis{
+		{ID: 7, PatientID: 10, ICD10Code: "I10", ICD10: &models.ICD10Code{Code: "I10", Description: "Essential (primary) hypertension"}},
+	}, int64(6), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/diagnoses?page=2&page_size=5", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var page struct {
+		Data  []models.PatientDiagnosis `json:"data"`
+		Total int64                     `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, int64(6), page.Total)
+	if assert.Len(t, page.Data, 1) && assert.NotNil(t, page.Data[0].ICD10) {
+		assert.Equal(t, "Essential (primary) hypertension", page.Data[0].ICD10.Description)
+	}
+}
+
+func TestSearchICD10Handler(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "doctor", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("SearchICD10Codes", "dengue", mock.AnythingOfType("int")).Return([]models.ICD10Code{
+		{Code: "A90", Description: "Dengue fever [classical dengue]"},
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/icd10?q=dengue", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var codes []models.ICD10Code
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &codes))
+	assert.Equal(t, []models.ICD10Code{{Code: "A90", Description: "Dengue fever [classical dengue]"}}, codes)
+
+	rr = performRequest(router, "GET", "/api/v1/icd10?q=%20", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = performRequest(router, "GET", "/api/v1/icd10?q=dengue", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
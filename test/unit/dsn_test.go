@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/database"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key as PEM files into dir and
+// returns their paths.
+func writeTestCertificate(t *testing.T, dir string) (certPath, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "hospital_user"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath = filepath.Join(dir, "client.crt")
+	keyPath = filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certPath, keyPath
+}
+
+func dsnTestConfig() *config.Config {
+	return &config.Config{
+		DBHost:     "db.internal",
+		DBPort:     "5432",
+		DBUser:     "hospital_user",
+		DBPassword: `it's a secret\`,
+		DBName:     "hospital_db",
+		DBSSLMode:  config.DBSSLModeDisable,
+		Timezone:   config.DefaultTimezone,
+	}
+}
+
+func TestDSN_WithoutSSL(t *testing.T) {
+	dsn := database.DSN(dsnTestConfig())
+
+	parsed, err := pgconn.ParseConfig(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", parsed.Host)
+	assert.Equal(t, uint16(5432), parsed.Port)
+	assert.Equal(t, "hospital_user", parsed.User)
+	assert.Equal(t, `it's a secret\`, parsed.Password)
+	assert.Equal(t, "hospital_db", parsed.Database)
+	assert.Equal(t, config.DefaultTimezone, parsed.RuntimeParams["TimeZone"])
+	assert.Nil(t, parsed.TLSConfig)
+	assert.NotContains(t, dsn, "sslcert")
+	assert.NotContains(t, dsn, "sslrootcert")
+}
+
+func TestDSN_VerifyFullWithCertificates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "certs with spaces")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+	certPath, keyPath := writeTestCertificate(t, dir)
+	cfg := dsnTestConfig()
+	cfg.DBSSLMode = config.DBSSLModeVerifyFull
+	cfg.DBSSLCert = certPath
+	cfg.DBSSLKey = keyPath
+	cfg.DBSSLRootCert = certPath // Self-signed, so it is its own CA
+
+	parsed, err := pgconn.ParseConfig(database.DSN(cfg))
+
+	require.NoError(t, err)
+	require.NotNil(t, parsed.TLSConfig)
+	assert.False(t, parsed.TLSConfig.InsecureSkipVerify)
+	assert.Equal(t, "db.internal", parsed.TLSConfig.ServerName)
+	assert.Len(t, parsed.TLSConfig.Certificates, 1, "client certificate")
+	assert.NotNil(t, parsed.TLSConfig.RootCAs)
+	assert.Empty(t, parsed.Fallbacks, "verify-full must not fall back to a plain connection")
+}
+
+func TestDSN_RequireSkipsVerification(t *testing.T) {
+	cfg := dsnTestConfig()
+	cfg.DBSSLMode = config.DBSSLModeRequire
+
+	parsed, err := pgconn.ParseConfig(database.DSN(cfg))
+
+	require.NoError(t, err)
+	require.NotNil(t, parsed.TLSConfig)
+	assert.True(t, parsed.TLSConfig.InsecureSkipVerify)
+	assert.Empty(t, parsed.Fallbacks)
+}
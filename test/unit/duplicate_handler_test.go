@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListDuplicatePatientsHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	clusters := []models.DuplicateCluster{{
+		MatchType: models.DuplicateMatchNationalID,
+		MatchKey:  "1234567890123",
+		Patients:  []models.Patient{{ID: 10, HospitalID: 1}, {ID: 11, HospitalID: 1}},
+	}}
+	repo.On("ListDuplicateClusters", uint(1), 5, 5).Return(clusters, int64(6), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/duplicates?page=2&page_size=5", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"match_type":"national_id"`)
+	assert.Contains(t, rr.Body.String(), `"total":6`)
+	repo.AssertExpectations(t)
+}
+
+func TestListDuplicatePatientsHandler_AdminOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/duplicates", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "ListDuplicateClusters", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListDuplicatePatientsHandler_HospitalParam(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		query    string
+		wantCode int
+		searched uint // Hospital whose duplicates are listed, when allowed
+	}{
+		{"own hospital", models.RoleAdmin, "?hospital_id=1", http.StatusOK, 1},
+		{"default", models.RoleAdmin, "", http.StatusOK, 1},
+		{"another hospital", models.RoleAdmin, "?hospital_id=2", http.StatusForbidden, 0},
+		{"super admin, another hospital", models.RoleSuperAdmin, "?hospital_id=2", http.StatusOK, 2},
+		{"not an ID", models.RoleSuperAdmin, "?hospital_id=two", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, repo := newTestRouter()
+			token := importAdminToken(t, router, repo, tt.role)
+			repo.On("ListDuplicateClusters", mock.Anything, 0, mock.Anything).Return([]models.DuplicateCluster{}, int64(0), nil)
+
+			rr := performRequest(router, "GET", "/api/v1/admin/patient/duplicates"+tt.query, nil, token)
+
+			assert.Equal(t, tt.wantCode, rr.Code, rr.Body.String())
+			if tt.searched != 0 {
+				repo.AssertCalled(t, "ListDuplicateClusters", tt.searched, 0, mock.Anything)
+			} else {
+				repo.AssertNotCalled(t, "ListDuplicateClusters", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
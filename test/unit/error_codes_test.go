@@ -0,0 +1,361 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// repoPath returns a path relative to the repository root.
+func repoPath(elem ...string) string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(append([]string{filepath.Dir(file), "..", ".."}, elem...)...)
+}
+
+// documentedCodes reads the code table in the doc comment of apperror.Code, mapping each code to
+// the statuses it is documented with.
+func documentedCodes(t *testing.T) map[apperror.Code][]int {
+	t.Helper()
+	source, err := os.ReadFile(repoPath("pkg", "apperror", "errors.go"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	row := regexp.MustCompile(`(?m)^//\t([A-Z0-9_]+)\s+([0-9/]+)\s+\S`)
+	documented := make(map[apperror.Code][]int)
+	for _, match := range row.FindAllStringSubmatch(string(source), -1) {
+		for _, status := range strings.Split(match[2], "/") {
+			n, _ := strconv.Atoi(status)
+			documented[apperror.Code(match[1])] = append(documented[apperror.Code(match[1])], n)
+		}
+	}
+	return documented
+}
+
+// codeConstants maps the name of every Code constant, such as CodePatientNotFound, to its value.
+func codeConstants(t *testing.T) map[string]apperror.Code {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), repoPath("pkg", "apperror", "errors.go"), nil, 0)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	constants := make(map[string]apperror.Code)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for i, name := range value.Names {
+				literal := value.Values[i].(*ast.BasicLit)
+				unquoted, _ := strconv.Unquote(literal.Value)
+				constants[name.Name] = apperror.Code(unquoted)
+			}
+		}
+	}
+	return constants
+}
+
+func TestErrorCodes_UniqueAndDocumented(t *testing.T) {
+	documented := documentedCodes(t)
+	constants := codeConstants(t)
+
+	seen := make(map[apperror.Code]bool)
+	for _, code := range apperror.Codes {
+		assert.False(t, seen[code], "%s is listed twice", code)
+		seen[code] = true
+		assert.Contains(t, documented, code, "%s is not documented", code)
+	}
+	for code := range documented {
+		assert.True(t, seen[code], "%s is documented but not in apperror.Codes", code)
+	}
+	values := make(map[apperror.Code]string)
+	for name, code := range constants {
+		if other, taken := values[code]; taken {
+			t.Errorf("%s and %s share the code %s", name, other, code)
+		}
+		values[code] = name
+		assert.True(t, seen[code], "%s is not in apperror.Codes", name)
+	}
+}
+
+// errorPath is one place in the API code that answers with an error status.
+type errorPath struct {
+	position string
+	status   int
+	code     string // Name of the Code constant, or empty when none was found
+}
+
+// constructorStatus is the status each apperror constructor answers with.
+var constructorStatus = map[string]int{
+	"Validation":   http.StatusBadRequest,
+	"Unauthorized": http.StatusUnauthorized,
+	"Forbidden":    http.StatusForbidden,
+	"NotFound":     http.StatusNotFound,
+	"Conflict":     http.StatusConflict,
+	"Unavailable":  http.StatusServiceUnavailable,
+}
+
+// errorPathExemptions are functions whose error statuses are not error responses.
+var errorPathExemptions = map[string]string{
+	"ReadyHandler": "readiness probe reporting its checks",
+}
+
+// findErrorPaths lists every apperror constructor call and every c.JSON or c.AbortWithStatusJSON
+// with a status of 400 or more in the Go files of dir.
+func findErrorPaths(t *testing.T, dir string) []errorPath {
+	t.Helper()
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var paths []errorPath
+	for _, name := range files {
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || errorPathExemptions[fn.Name.Name] != "" {
+				continue
+			}
+			codeParams := codeParameters(fn)
+			ast.Inspect(fn.Body, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				position := fmt.Sprintf("%s (%s)", fset.Position(call.Pos()), fn.Name.Name)
+				switch {
+				case isSelector(call.Fun, "apperror", "Internal"):
+					paths = append(paths, errorPath{position, http.StatusInternalServerError, "CodeInternal"})
+				case selectorIn(call.Fun, "apperror", constructorStatus) != "":
+					path := errorPath{position: position, status: constructorStatus[selectorIn(call.Fun, "apperror", constructorStatus)]}
+					if len(call.Args) >= 2 {
+						path.code = codeConstantName(call.Args[0])
+					}
+					paths = append(paths, path)
+				case isMethod(call.Fun, "JSON") || isMethod(call.Fun, "AbortWithStatusJSON"):
+					status := httpStatus(call.Args[0])
+					if status < 400 {
+						return true
+					}
+					code := responseCode(call.Args[1])
+					if ident, ok := codeValue(call.Args[1]).(*ast.Ident); ok && codeParams[ident.Name] {
+						return true // The caller picks the code
+					}
+					paths = append(paths, errorPath{position, status, code})
+				}
+				return true
+			})
+			ast.Inspect(fn.Body, func(node ast.Node) bool {
+				if lit, ok := node.(*ast.CompositeLit); ok && isSelector(lit.Type, "apperror", "ValidationError") {
+					paths = append(paths, errorPath{fmt.Sprintf("%s (%s)", fset.Position(lit.Pos()), fn.Name.Name), http.StatusBadRequest, keyedCode(lit)})
+				}
+				return true
+			})
+		}
+	}
+	return paths
+}
+
+func isSelector(expr ast.Expr, pkg, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	return ok && ident.Name == pkg
+}
+
+func selectorIn(expr ast.Expr, pkg string, names map[string]int) string {
+	for name := range names {
+		if isSelector(expr, pkg, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+func isMethod(expr ast.Expr, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == name
+}
+
+// codeConstantName returns the name of an apperror.Code constant expression, or "".
+func codeConstantName(expr ast.Expr) string {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || !isSelector(sel, "apperror", sel.Sel.Name) || !strings.HasPrefix(sel.Sel.Name, "Code") {
+		return ""
+	}
+	return sel.Sel.Name
+}
+
+// codeParameters returns the names of fn's parameters of type apperror.Code.
+func codeParameters(fn *ast.FuncDecl) map[string]bool {
+	params := make(map[string]bool)
+	for _, field := range fn.Type.Params.List {
+		if isSelector(field.Type, "apperror", "Code") {
+			for _, name := range field.Names {
+				params[name.Name] = true
+			}
+		}
+	}
+	return params
+}
+
+// codeValue returns the code given to apperror.Response, or for a Code field, anywhere in a
+// response literal.
+func codeValue(expr ast.Expr) ast.Expr {
+	if call, ok := expr.(*ast.CallExpr); ok && isSelector(call.Fun, "apperror", "Response") && len(call.Args) == 3 {
+		return call.Args[1]
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Code" {
+			return kv.Value
+		}
+		if nested := codeValue(kv.Value); nested != nil {
+			return nested
+		}
+	}
+	return nil
+}
+
+func responseCode(expr ast.Expr) string {
+	if value := codeValue(expr); value != nil {
+		return codeConstantName(value)
+	}
+	return ""
+}
+
+func keyedCode(lit *ast.CompositeLit) string { return responseCode(lit) }
+
+// httpStatus returns the value of an http.StatusX expression, or 0 when it is not one.
+func httpStatus(expr ast.Expr) int {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || !isSelector(sel, "http", sel.Sel.Name) {
+		return 0
+	}
+	for status := 100; status < 600; status++ {
+		text := http.StatusText(status)
+		if text != "" && "Status"+strings.NewReplacer(" ", "", "-", "", "'", "").Replace(text) == sel.Sel.Name {
+			return status
+		}
+	}
+	return 0
+}
+
+func TestErrorCodes_EveryErrorPathHasADocumentedCode(t *testing.T) {
+	documented := documentedCodes(t)
+	constants := codeConstants(t)
+
+	var paths []errorPath
+	for _, dir := range []string{"handlers", "middleware"} {
+		paths = append(paths, findErrorPaths(t, repoPath("internal", "api", dir))...)
+	}
+	assert.Greater(t, len(paths), 250, "the source scan found too few error paths")
+
+	for _, path := range paths {
+		if path.code == "" {
+			t.Errorf("%s: %d response without an apperror.Code constant", path.position, path.status)
+			continue
+		}
+		code, ok := constants[path.code]
+		if !ok {
+			t.Errorf("%s: unknown code constant %s", path.position, path.code)
+			continue
+		}
+		assert.Contains(t, documented[code], path.status, "%s: %s (%s) is not documented for status %d", path.position, path.code, code, path.status)
+	}
+}
+
+func TestErrorCodes_NoUntypedErrorBodies(t *testing.T) {
+	for _, dir := range []string{"handlers", "middleware"} {
+		files, err := filepath.Glob(repoPath("internal", "api", dir, "*.go"))
+		assert.NoError(t, err)
+		for _, name := range files {
+			source, err := os.ReadFile(name)
+			assert.NoError(t, err)
+			assert.NotContains(t, string(source), `"error":`, "%s builds an error body by hand; use apperror.Response", name)
+		}
+	}
+}
+
+// TestErrorCodes_InResponseBodies checks that codes reach clients, for error paths of each kind.
+func TestErrorCodes_InResponseBodies(t *testing.T) {
+	router, repo := newTestRouter()
+	staffToken := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(404)).Return(nil, gorm.ErrRecordNotFound)
+
+	tests := []struct {
+		name       string
+		send       func() *httptest.ResponseRecorder
+		wantStatus int
+		wantCode   apperror.Code
+	}{
+		{"no route", func() *httptest.ResponseRecorder {
+			return performRequest(router, "GET", "/api/v1/nowhere", nil, "")
+		}, http.StatusNotFound, apperror.CodeRouteNotFound},
+		{"wrong method", func() *httptest.ResponseRecorder {
+			return performRequest(router, "DELETE", "/api/v1/staff/login", nil, "")
+		}, http.StatusMethodNotAllowed, apperror.CodeMethodNotAllowed},
+		{"no credentials", func() *httptest.ResponseRecorder {
+			return performRequest(router, "GET", "/api/v1/patient/1", nil, "")
+		}, http.StatusUnauthorized, apperror.CodeAuthRequired},
+		{"bad token", func() *httptest.ResponseRecorder {
+			return performRequest(router, "GET", "/api/v1/patient/1", nil, "garbage")
+		}, http.StatusUnauthorized, apperror.CodeInvalidToken},
+		{"not admin", func() *httptest.ResponseRecorder {
+			return performRequest(router, "GET", "/api/v1/admin/hospital/1/config", nil, staffToken)
+		}, http.StatusForbidden, apperror.CodeAdminRequired},
+		{"bad id", func() *httptest.ResponseRecorder {
+			return performRequest(router, "GET", "/api/v1/patient/abc/visits", nil, staffToken)
+		}, http.StatusBadRequest, apperror.CodeInvalidPathParam},
+		{"missing patient", func() *httptest.ResponseRecorder {
+			return performRequest(router, "GET", "/api/v1/patient/404/allergies", nil, staffToken)
+		}, http.StatusNotFound, apperror.CodePatientNotFound},
+		{"malformed body", func() *httptest.ResponseRecorder {
+			return performRawRequest(router, "POST", "/api/v1/staff/login", "{", "application/json")
+		}, http.StatusBadRequest, apperror.CodeInvalidBody},
+		{"not JSON", func() *httptest.ResponseRecorder {
+			return performRawRequest(router, "POST", "/api/v1/staff/login", "username=a", "text/plain")
+		}, http.StatusUnsupportedMediaType, apperror.CodeUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := tt.send()
+
+			assert.Equal(t, tt.wantStatus, rr.Code, rr.Body.String())
+			var body apperror.ErrorResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCode, body.Code)
+			assert.NotEmpty(t, body.Message)
+		})
+	}
+}
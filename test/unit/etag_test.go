@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"hospital-middleware/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// getPatientIfNoneMatch fetches a patient, sending etag in If-None-Match when it is not empty.
+func getPatientIfNoneMatch(router http.Handler, token, etag string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/api/v1/patient/10", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestGetPatientHandler_ETag(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	updatedAt := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, FirstNameEN: "Somchai", UpdatedAt: updatedAt}, nil).Twice()
+
+	// 1. First request gets the full body and a tag
+	rr := getPatientIfNoneMatch(router, token, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Somchai")
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// 2. Unchanged patient: 304 with no body
+	rr = getPatientIfNoneMatch(router, token, etag)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+	assert.Equal(t, etag, rr.Header().Get("ETag"))
+
+	// 3. Once the patient is updated the old tag no longer matches
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, FirstNameEN: "Somchai", UpdatedAt: updatedAt.Add(time.Minute)}, nil).Once()
+	rr = getPatientIfNoneMatch(router, token, etag)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Somchai")
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+}
+
+func TestGetPatientHandler_ETagSkipsErrors(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := getPatientIfNoneMatch(router, token, "*")
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Empty(t, rr.Header().Get("ETag"))
+	assert.Contains(t, rr.Body.String(), "Patient not found")
+}
+
+// patchPatientIfMatch updates a patient's blood type, sending etag in If-Match.
+func patchPatientIfMatch(router http.Handler, token, etag string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("PATCH", "/api/v1/patient/10", strings.NewReader(`{"blood_type":"O+"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", etag)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestUpdatePatientHandler_IfMatch(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	updatedAt := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, FirstNameEN: "Somchai", UpdatedAt: updatedAt}, nil).Twice()
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.Anything, mock.Anything).Return(nil).Once()
+
+	etag := getPatientIfNoneMatch(router, token, "").Header().Get("ETag")
+
+	// 1. The tag the client read: the update goes through
+	rr := patchPatientIfMatch(router, token, etag)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// 2. The patient has changed since: 412 with the current tag, nothing written
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, FirstNameEN: "Somchai", UpdatedAt: updatedAt.Add(time.Minute)}, nil)
+	rr = patchPatientIfMatch(router, token, etag)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("ETag"))
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+	repo.AssertNumberOfCalls(t, "UpdatePatientFields", 1)
+}
+
+func TestUpdatePatientHandler_IfMatchWeakTagNeverMatches(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	etag := getPatientIfNoneMatch(router, token, "").Header().Get("ETag")
+	rr := patchPatientIfMatch(router, token, "W/"+etag)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	repo.AssertNotCalled(t, "UpdatePatientFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdatePatientStatusHandler_IfMatchMismatch(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, Status: models.PatientStatusActive}, nil)
+
+	req, _ := http.NewRequest("PATCH", "/api/v1/patient/10/status", strings.NewReader(`{"status":"inactive"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", `"stale"`)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	repo.AssertNotCalled(t, "UpdatePatientStatus", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,227 @@
+package unit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPublisher keeps every event published to it, or fails them all with err.
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []models.PatientEvent
+	err    error
+}
+
+func (p *recordingPublisher) Publish(event models.PatientEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+// useEventPublisher makes publisher receive the test's events and restores the no-op one afterwards.
+func useEventPublisher(t *testing.T, publisher services.EventPublisher) {
+	t.Helper()
+	services.SetEventPublisher(publisher)
+	t.Cleanup(func() { services.SetEventPublisher(services.NoopEventPublisher{}) })
+}
+
+func TestUpdatePatientHandler_PublishesEvent(t *testing.T) {
+	publisher := &recordingPublisher{}
+	useEventPublisher(t, publisher)
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.Anything, mock.Anything).Return(nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{"blood_type": "O+", "nationality": "Thai"}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Len(t, publisher.events, 1)
+	event := publisher.events[0]
+	assert.Equal(t, models.WebhookEventPatientUpdated, event.Event)
+	assert.Equal(t, uint(1), event.HospitalID)
+	assert.Equal(t, uint(10), event.PatientID)
+	assert.Equal(t, []string{"blood_type", "nationality"}, event.ChangedFields)
+	assert.False(t, event.Timestamp.IsZero())
+}
+
+func TestDeleteHospitalPatientsHandler_PublishesEvent(t *testing.T) {
+	publisher := &recordingPublisher{}
+	useEventPublisher(t, publisher)
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("SoftDeletePatientsByHospital", uint(1)).Return(int64(3), nil)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient?confirm=true", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, models.PatientEvent{
+		Event:      models.WebhookEventPatientDeleted,
+		HospitalID: 1,
+		Deleted:    3,
+		Timestamp:  publisher.events[0].Timestamp,
+	}, publisher.events[0])
+}
+
+func TestPublishPatientEvent_FailureDoesNotFailRequest(t *testing.T) {
+	useEventPublisher(t, &recordingPublisher{err: errors.New("broker down")})
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.Anything, mock.Anything).Return(nil)
+	failures := services.EventPublishFailures.Value()
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{"blood_type": "O+"}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, failures+1, services.EventPublishFailures.Value())
+}
+
+// natsPublish is one PUB received by fakeNATSServer.
+type natsPublish struct {
+	subject string
+	data    []byte
+}
+
+// fakeNATSServer accepts NATS clients on a local port, completes their handshake and sends every
+// message they publish on the returned channel.
+func fakeNATSServer(t *testing.T) (string, <-chan natsPublish) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	published := make(chan natsPublish, 8)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				fmt.Fprint(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n")
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					fields := strings.Fields(line)
+					switch {
+					case len(fields) == 1 && fields[0] == "PING":
+						fmt.Fprint(conn, "PONG\r\n")
+					case len(fields) == 3 && fields[0] == "PUB":
+						var size int
+						fmt.Sscan(fields[2], &size)
+						data := make([]byte, size+2) // Payload and its trailing CRLF
+						if _, err := io.ReadFull(reader, data); err != nil {
+							return
+						}
+						published <- natsPublish{subject: fields[1], data: data[:size]}
+					}
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().String(), published
+}
+
+func TestNATSPublisher_PublishesEnvelope(t *testing.T) {
+	addr, published := fakeNATSServer(t)
+	publisher, err := services.NewNATSPublisher("nats://" + addr)
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	require.NoError(t, publisher.Publish(models.PatientEvent{
+		Event:         models.WebhookEventPatientUpdated,
+		HospitalID:    2,
+		PatientID:     42,
+		ChangedFields: []string{"status"},
+		Timestamp:     time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+	}))
+
+	select {
+	case msg := <-published:
+		assert.Equal(t, models.WebhookEventPatientUpdated, msg.subject)
+		var envelope map[string]interface{}
+		require.NoError(t, json.Unmarshal(msg.data, &envelope))
+		assert.Equal(t, map[string]interface{}{
+			"event":          "patient.updated",
+			"hospital_id":    float64(2),
+			"patient_id":     float64(42),
+			"changed_fields": []interface{}{"status"},
+			"timestamp":      "2024-05-01T08:00:00Z",
+		}, envelope)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not published")
+	}
+}
+
+func TestMetrics_AtRootForAdmins(t *testing.T) {
+	router, repo := newTestRouter()
+
+	rr := performRequest(router, "GET", "/metrics", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	staffToken := importAdminToken(t, router, repo, models.RoleStaff)
+	rr = performRequest(router, "GET", "/metrics", nil, staffToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	adminToken := importAdminToken(t, router, repo, models.RoleAdmin)
+	rr = performRequest(router, "GET", "/metrics", nil, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"event_publish_failures"`)
+
+	rr = performRequest(router, "GET", "/api/v1/admin/metrics", nil, adminToken)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestNATSPublisher_UnreachableBrokerCountsFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close() // Nothing listens there any more
+	publisher, err := services.NewNATSPublisher("nats://" + addr)
+	require.NoError(t, err)
+	failures := services.EventPublishFailures.Value()
+
+	// Queued without error; the failure shows up once the worker has tried to send it
+	assert.NoError(t, publisher.Publish(models.PatientEvent{Event: models.WebhookEventPatientUpdated, HospitalID: 1, PatientID: 1}))
+	require.NoError(t, publisher.Close())
+
+	assert.Equal(t, failures+1, services.EventPublishFailures.Value())
+	assert.Error(t, publisher.Publish(models.PatientEvent{Event: models.WebhookEventPatientUpdated}), "publishing after Close")
+}
+
+func TestNewEventPublisher(t *testing.T) {
+	publisher, err := services.NewEventPublisher("")
+	assert.NoError(t, err)
+	assert.Equal(t, services.NoopEventPublisher{}, publisher)
+
+	_, err = services.NewEventPublisher("http://broker:4222")
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/api/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotFoundHandler_DescribesTheRequest(t *testing.T) {
+	router, _ := newTestRouter()
+
+	rr := performRequest(router, "GET", "/api/v1/foo", nil, "")
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	var body map[string]string
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "resource not found", body["error"])
+	assert.Equal(t, "GET", body["method"])
+	assert.Equal(t, "/api/v1/foo", body["path"])
+	assert.NotEmpty(t, body["request_id"])
+	assert.Equal(t, body["request_id"], rr.Header().Get(middleware.RequestIDHeader))
+}
+
+func TestNotFoundHandler_KeepsClientRequestID(t *testing.T) {
+	router, _ := newTestRouter()
+	req, _ := http.NewRequest("GET", "/api/v1/foo", nil)
+	req.Header.Set(middleware.RequestIDHeader, "trace-1234")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Contains(t, rr.Body.String(), `"request_id":"trace-1234"`)
+	assert.Equal(t, "trace-1234", rr.Header().Get(middleware.RequestIDHeader))
+}
+
+func TestNotFoundHandler_ReplacesMalformedRequestID(t *testing.T) {
+	router, _ := newTestRouter()
+	req, _ := http.NewRequest("GET", "/api/v1/foo", nil)
+	req.Header.Set(middleware.RequestIDHeader, "not a valid id\t")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	id := rr.Header().Get(middleware.RequestIDHeader)
+	assert.Len(t, id, 32)
+	assert.NotContains(t, rr.Body.String(), "not a valid id")
+}
+
+func TestMethodNotAllowedHandler_ListsAllowedMethods(t *testing.T) {
+	router, _ := newTestRouter()
+
+	rr := performRequest(router, "DELETE", "/api/v1/staff/login", nil, "")
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, "POST", rr.Header().Get("Allow"))
+	var body struct {
+		Error          string   `json:"error"`
+		Method         string   `json:"method"`
+		Path           string   `json:"path"`
+		AllowedMethods []string `json:"allowed_methods"`
+		RequestID      string   `json:"request_id"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "method not allowed", body.Error)
+	assert.Equal(t, "DELETE", body.Method)
+	assert.Equal(t, "/api/v1/staff/login", body.Path)
+	assert.Equal(t, []string{"POST"}, body.AllowedMethods)
+	assert.NotEmpty(t, body.RequestID)
+}
@@ -0,0 +1,909 @@
+// Package unit contains handler tests that run against mocked repositories,
+// so they need no database connection and finish in milliseconds.
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/api"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/internal/storage"
+	"hospital-middleware/pkg/utils"
+	"hospital-middleware/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// testConfig is the configuration used for every unit test. No DB fields are needed.
+var testConfig = &config.Config{
+	AppEnv:                 config.AppEnvTest,
+	JWTSecret:              "unit_test_secret_key_that_is_long_enough",
+	JWTExpiry:              time.Hour,
+	DocumentMaxBytes:       4 * 1024,
+	DefaultPageSize:        20,
+	MaxPageSize:            100,
+	SearchMaxResults:       1000,
+	NationalIDValidateMax:  500,
+	AccessLogSearchResults: true,
+}
+
+// searchLimit is the limit a search passes to the repository under testConfig: the result cap
+// plus the one extra row that tells whether results were truncated.
+const searchLimit = 1001
+
+// testBlobs is a throwaway local-disk blob store shared by all unit tests.
+var testBlobs *storage.LocalDiskStore
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	utils.BcryptCost = bcrypt.MinCost // Keep password hashing cheap in unit tests
+	if err := services.InitializeAuthService(testConfig); err != nil {
+		panic(err)
+	}
+
+	blobDir, err := os.MkdirTemp("", "unit-blobs-*")
+	if err != nil {
+		panic(err)
+	}
+	testBlobs, err = storage.NewLocalDiskStore(blobDir)
+	if err != nil {
+		panic(err)
+	}
+
+	exitCode := m.Run()
+	os.RemoveAll(blobDir)
+	os.Exit(exitCode)
+}
+
+// newTestRouter builds the real router wired to a fresh mock repository.
+func newTestRouter() (*gin.Engine, *mocks.MockPatientRepository) {
+	return newTestRouterWithConfig(testConfig)
+}
+
+// newTestRouterWithConfig is newTestRouter with a custom configuration.
+// Token revocation checks, search history, patient views, access logging and login time recording
+// are stubbed to succeed, and no hospital has webhooks; tests that care about them build the router
+// from their own mock instead.
+func newTestRouterWithConfig(cfg *config.Config) (*gin.Engine, *mocks.MockPatientRepository) {
+	repo := new(mocks.MockPatientRepository)
+	repo.On("IsTokenRevoked", mock.Anything).Return(false, nil).Maybe()
+	repo.On("RecordSearch", mock.Anything).Return(nil).Maybe()
+	repo.On("RecordPatientView", mock.Anything).Return(nil).Maybe()
+	repo.On("RecordPatientAccess", mock.Anything).Return(nil).Maybe()
+	repo.On("RecordStaffLogin", mock.Anything, mock.Anything).Return(nil).Maybe()
+	repo.On("ListActiveWebhooks", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	return api.SetupRouter(repo, testBlobs, cfg, services.NewAsyncWriters(repo)), repo
+}
+
+// performRequest sends a JSON request to the router and records the response.
+func performRequest(router *gin.Engine, method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		req, _ = http.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req, _ = http.NewRequest(method, path, nil)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// decodeSearchResponse decodes a search response envelope, unmarshalling its data into results.
+func decodeSearchResponse(t *testing.T, body []byte, results interface{}) models.PatientSearchResponse {
+	t.Helper()
+	var response models.PatientSearchResponse
+	var raw struct {
+		Data json.RawMessage `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &response), string(body))
+	assert.NoError(t, json.Unmarshal(body, &raw))
+	assert.NoError(t, json.Unmarshal(raw.Data, results))
+	return response
+}
+
+// hashedStaff returns a staff record whose password hash matches password.
+func hashedStaff(t *testing.T, id uint, username, password string, hospitalID uint, hospitalName string) *models.Staff {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	return &models.Staff{
+		ID:           id,
+		Username:     username,
+		PasswordHash: hash,
+		HospitalID:   hospitalID,
+		HospitalName: hospitalName,
+		IsActive:     true,
+	}
+}
+
+// loginToken logs in through the router using mocked staff data and returns the JWT.
+func loginToken(t *testing.T, router *gin.Engine, repo *mocks.MockPatientRepository, staff *models.Staff, password string) string {
+	repo.On("FindStaffByUsername", staff.Username).Return(staff, nil).Once()
+	repo.On("GetHospitalIDByName", staff.HospitalName).Return(staff.HospitalID, nil).Once()
+	defaults := models.DefaultHospitalConfig(staff.HospitalID)
+	repo.On("GetHospitalConfig", staff.HospitalID).Return(&defaults, nil).Maybe()
+
+	loginData := models.StaffLoginRequest{Username: staff.Username, Password: password, Hospital: staff.HospitalName}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Setup failed: login returned %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp models.StaffLoginResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Setup failed: could not decode login response: %v", err)
+	}
+	return resp.Token
+}
+
+// --- Staff Handler Tests ---
+
+func TestCreateStaffHandler_Success(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "newstaff").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	repo.On("CreateStaff", mock.AnythingOfType("*models.Staff")).
+		Run(func(args mock.Arguments) { args.Get(0).(*models.Staff).ID = 42 }).
+		Return(nil)
+
+	staffData := models.StaffCreateRequest{Username: "newstaff", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created models.Staff
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, uint(42), created.ID)
+	assert.Equal(t, uint(1), created.HospitalID)
+	assert.Equal(t, "Hospital A", created.HospitalName)
+	assert.NotContains(t, rr.Body.String(), "password")
+	repo.AssertExpectations(t)
+}
+
+func TestSetupRouter_CustomAPIBasePath(t *testing.T) {
+	cfg := *testConfig
+	cfg.APIBasePath = "/hospital"
+	router, repo := newTestRouterWithConfig(&cfg)
+	repo.On("FindStaffByUsername", "newstaff").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	repo.On("CreateStaff", mock.AnythingOfType("*models.Staff")).Return(nil)
+
+	staffData := models.StaffCreateRequest{Username: "newstaff", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/hospital/staff/create", staffData, "")
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	// The default prefix is gone, while the health check stays at the root
+	rr = performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = performRequest(router, "GET", "/health", nil, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+// routeTableOutput builds a router for appEnv and returns what Gin printed meanwhile.
+func routeTableOutput(t *testing.T, appEnv string) string {
+	var out bytes.Buffer
+	previous := gin.DefaultWriter
+	gin.DefaultWriter = &out
+	t.Cleanup(func() {
+		gin.DefaultWriter = previous
+		gin.SetMode(gin.TestMode)
+	})
+
+	cfg := *testConfig
+	cfg.AppEnv = appEnv
+	newTestRouterWithConfig(&cfg)
+	return out.String()
+}
+
+func TestSetupRouter_ProductionDoesNotPrintRoutes(t *testing.T) {
+	assert.NotContains(t, routeTableOutput(t, config.AppEnvProduction), "/api/v1/staff/create")
+	assert.Equal(t, gin.ReleaseMode, gin.Mode())
+}
+
+func TestSetupRouter_DevelopmentPrintsRoutes(t *testing.T) {
+	assert.Contains(t, routeTableOutput(t, config.AppEnvDevelopment), "/api/v1/staff/create")
+	assert.Equal(t, gin.DebugMode, gin.Mode())
+}
+
+func TestCreateStaffHandler_DuplicateUsername(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "existing").Return(&models.Staff{
+		ID: 7, Username: "existing", PasswordHash: "$2a$secret", HospitalID: 2, HospitalName: "Hospital B",
+	}, nil)
+
+	staffData := models.StaffCreateRequest{Username: "existing", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "Username already exists", resp["error"])
+	assert.NotContains(t, resp, "existing_staff", "unauthenticated callers must not learn who holds a username")
+	assert.NotContains(t, rr.Body.String(), "Hospital B")
+	assert.NotContains(t, rr.Body.String(), "$2a$secret")
+	repo.AssertNotCalled(t, "CreateStaff", mock.Anything)
+}
+
+func TestCreateStaffHandler_AdminRouteNamesExistingStaff(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 1, "hradmin", "password123", 2, "Hospital B")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+	repo.On("FindStaffByUsername", "existing").Return(&models.Staff{
+		ID: 7, Username: "existing", PasswordHash: "$2a$secret", HospitalID: 2, HospitalName: "Hospital B",
+	}, nil)
+
+	staffData := models.StaffCreateRequest{Username: "existing", Password: "password123", Hospital: "Hospital B"}
+	rr := performRequest(router, "POST", "/api/v1/admin/staff", staffData, token)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	var resp struct {
+		ExistingStaff models.StaffSummary `json:"existing_staff"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, models.StaffSummary{ID: 7, Username: "existing", HospitalName: "Hospital B"}, resp.ExistingStaff)
+	assert.NotContains(t, rr.Body.String(), "$2a$secret")
+}
+
+func TestCreateStaffHandler_AdminRouteHidesOtherHospitalsStaff(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 1, "hradmin", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+	repo.On("FindStaffByUsername", "existing").Return(&models.Staff{
+		ID: 7, Username: "existing", HospitalID: 2, HospitalName: "Hospital B",
+	}, nil)
+
+	staffData := models.StaffCreateRequest{Username: "existing", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/admin/staff", staffData, token)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "existing_staff")
+	assert.NotContains(t, rr.Body.String(), "Hospital B")
+}
+
+func TestCreateStaffHandler_DatabaseError(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "someone").Return(nil, errors.New("connection refused"))
+
+	staffData := models.StaffCreateRequest{Username: "someone", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Database error checking username")
+}
+
+func TestCreateStaffHandler_HospitalForeignKeyViolation(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "someone").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(999), nil)
+	repo.On("CreateStaff", mock.AnythingOfType("*models.Staff")).Return(&pgconn.PgError{Code: "23503"})
+
+	staffData := models.StaffCreateRequest{Username: "someone", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"code":"STAFF_002","error":"invalid hospital"}`, rr.Body.String())
+}
+
+func TestCreateStaffHandler_ConcurrentDuplicateUsername(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "Someone").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	// Another request created "someone" between the check and the insert
+	repo.On("CreateStaff", mock.AnythingOfType("*models.Staff")).Return(&pgconn.PgError{Code: "23505"})
+
+	staffData := models.StaffCreateRequest{Username: "Someone", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.JSONEq(t, `{"code":"STAFF_001","error":"Username already exists"}`, rr.Body.String())
+}
+
+func TestNormalizeUsername_IgnoresCase(t *testing.T) {
+	staff := &models.Staff{Username: "Admin"}
+	assert.NoError(t, staff.BeforeSave(nil))
+
+	assert.Equal(t, "admin", staff.UsernameNormalized)
+	assert.Equal(t, "Admin", staff.Username, "the display casing is kept")
+	assert.Equal(t, models.NormalizeUsername("ADMIN"), staff.UsernameNormalized)
+}
+
+func TestCreateStaffHandler_InvalidHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "someone").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital Z").Return(uint(0), fmt.Errorf("%w: Hospital Z", database.ErrHospitalNotFound))
+
+	staffData := models.StaffCreateRequest{Username: "someone", Password: "password123", Hospital: "Hospital Z"}
+	rr := performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid hospital specified")
+	repo.AssertNotCalled(t, "CreateStaff", mock.Anything)
+}
+
+func TestCreateStaffHandler_BadData(t *testing.T) {
+	router, repo := newTestRouter()
+
+	rr := performRequest(router, "POST", "/api/v1/staff/create", gin.H{"username": "only_username"}, "")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid request body")
+	repo.AssertNotCalled(t, "FindStaffByUsername", mock.Anything)
+}
+
+func TestLoginStaffHandler_Success(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "loginuser", "password123", 2, "Hospital B")
+
+	token := loginToken(t, router, repo, staff, "password123")
+
+	assert.NotEmpty(t, token)
+	claims, err := services.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), claims.UserID)
+	assert.Equal(t, uint(2), claims.HospitalID)
+}
+
+func TestLoginStaffHandler_ReportsExpiry(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "loginuser", "password123", 2, "Hospital B")
+	repo.On("FindStaffByUsername", staff.Username).Return(staff, nil)
+	repo.On("GetHospitalIDByName", staff.HospitalName).Return(staff.HospitalID, nil)
+	defaults := models.DefaultHospitalConfig(staff.HospitalID)
+	repo.On("GetHospitalConfig", staff.HospitalID).Return(&defaults, nil).Maybe()
+
+	loginData := models.StaffLoginRequest{Username: staff.Username, Password: "password123", Hospital: staff.HospitalName}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp models.StaffLoginResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	// testConfig issues tokens for an hour
+	assert.InDelta(t, testConfig.JWTExpiry.Seconds(), resp.ExpiresIn, 2)
+	claims, err := services.ValidateToken(resp.Token)
+	assert.NoError(t, err)
+	assert.True(t, claims.ExpiresAt.Time.Equal(resp.ExpiresAt), "expires_at %v should match exp %v", resp.ExpiresAt, claims.ExpiresAt.Time)
+
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+	_, err = time.Parse(time.RFC3339, raw["expires_at"].(string))
+	assert.NoError(t, err, "expires_at should be RFC 3339")
+}
+
+func TestLoginStaffHandler_WrongPassword(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "loginuser", "correctpassword", 1, "Hospital A")
+	repo.On("FindStaffByUsername", "loginuser").Return(staff, nil)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+
+	loginData := models.StaffLoginRequest{Username: "loginuser", Password: "wrongpassword", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid username or password")
+}
+
+func TestLoginStaffHandler_DisabledAccount(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "loginuser", "correctpassword", 1, "Hospital A")
+	staff.IsActive = false
+	repo.On("FindStaffByUsername", "loginuser").Return(staff, nil)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+
+	loginData := models.StaffLoginRequest{Username: "loginuser", Password: "correctpassword", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "account is disabled")
+	repo.AssertNotCalled(t, "RecordStaffLogin", mock.Anything, mock.Anything)
+}
+
+func TestLoginStaffHandler_UnknownUser(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "ghost").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+
+	loginData := models.StaffLoginRequest{Username: "ghost", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid username or password")
+}
+
+func TestLoginStaffHandler_WrongHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "loginuser", "password123", 2, "Hospital B")
+	repo.On("FindStaffByUsername", "loginuser").Return(staff, nil)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+
+	loginData := models.StaffLoginRequest{Username: "loginuser", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid username or password", "a wrong hospital fails like a wrong password")
+
+	// ...but only once the password is right; a wrong one is checked first
+	loginData.Password = "wrongpassword"
+	rr = performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid username or password")
+}
+
+func TestLoginStaffHandler_UnknownHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "loginuser", "password123", 2, "Hospital B")
+	repo.On("FindStaffByUsername", "loginuser").Return(staff, nil)
+	repo.On("FindStaffByUsername", "ghost").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital C").Return(uint(0), fmt.Errorf("%w: Hospital C", database.ErrHospitalNotFound))
+
+	// The answer is the same whether or not the username exists
+	for _, username := range []string{"loginuser", "ghost"} {
+		loginData := models.StaffLoginRequest{Username: username, Password: "password123", Hospital: "Hospital C"}
+		rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, username)
+		assert.Contains(t, rr.Body.String(), "unknown hospital", username)
+	}
+	repo.AssertNotCalled(t, "FindStaffByUsername", mock.Anything)
+}
+
+func TestLoginStaffHandler_HospitalLookupFails(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "loginuser", "password123", 2, "Hospital B")
+	repo.On("FindStaffByUsername", "loginuser").Return(staff, nil)
+	repo.On("GetHospitalIDByName", "Hospital B").Return(uint(0), errors.New("permission denied for table hospitals"))
+
+	loginData := models.StaffLoginRequest{Username: "loginuser", Password: "password123", Hospital: "Hospital B"}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "a database failure is not a bad login")
+	assert.Contains(t, rr.Body.String(), `"code":"INTERNAL_001"`)
+	assert.NotContains(t, rr.Body.String(), "permission denied")
+}
+
+func TestCreateStaffHandler_HospitalLookupFails(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "someone").Return(nil, gorm.ErrRecordNotFound)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(0), errors.New("permission denied for table hospitals"))
+
+	staffData := models.StaffCreateRequest{Username: "someone", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "Invalid hospital specified")
+	repo.AssertNotCalled(t, "CreateStaff", mock.Anything)
+}
+
+// --- Patient Search Handler Tests ---
+
+func TestSearchPatientHandler_Unauthorized(t *testing.T) {
+	router, repo := newTestRouter()
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test", nil, "")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Authorization header required")
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_ScopedToStaffHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	patients := []models.Patient{{ID: 1, HospitalID: 2, FirstNameEN: "Somchai", NationalID: "1234567890123"}}
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.NationalID != nil && *q.NationalID == "1234567890123"
+	}), uint(2), searchLimit).Return(patients, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?national_id=1234567890123", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var results []models.Patient
+	response := decodeSearchResponse(t, rr.Body.Bytes(), &results)
+	assert.Equal(t, 1, response.Count)
+	assert.False(t, response.Truncated)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Somchai", results[0].FirstNameEN)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchPatientHandler_RecordsSearchHistory(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.Anything, uint(2), searchLimit).Return([]models.Patient{{ID: 1, HospitalID: 2}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertCalled(t, "RecordSearch", mock.MatchedBy(func(entry *models.SearchHistory) bool {
+		return entry.StaffID == 9 && entry.HospitalID == 2 && entry.Query == "first_name_en=Somchai" && entry.ResultCount == 1
+	}))
+}
+
+func TestSearchPatientHandler_PhoneSuffix(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.PhoneSuffix != nil && *q.PhoneSuffix == "5678"
+	}), uint(1), searchLimit).Return([]models.Patient{{ID: 1, HospitalID: 1, PhoneNumber: "0812345678"}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?phone_suffix=5678", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchPatientHandler_PhoneSuffixValidation(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	for name, query := range map[string]string{
+		"too short":          "phone_suffix=678",
+		"not digits":         "phone_suffix=56%2578",
+		"combined with full": "phone_number=0812345678&phone_suffix=5678",
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := performRequest(router, "GET", "/api/v1/patient/search?"+query, nil, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_IdentifierList(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.NationalID != nil && *q.NationalID == "111,222,333"
+	}), uint(1), searchLimit).Return([]models.Patient{{ID: 1, HospitalID: 1, NationalID: "111"}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?national_id=111,222,333", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchPatientHandler_IdentifierListCap(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	values := make([]string, models.MaxIdentifierValues+1)
+	for i := range values {
+		values[i] = fmt.Sprintf("ID%d", i)
+	}
+	for _, field := range []string{"national_id", "passport_id", "patient_hn"} {
+		t.Run(field, func(t *testing.T) {
+			rr := performRequest(router, "GET", "/api/v1/patient/search?"+field+"="+strings.Join(values, ","), nil, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), field)
+		})
+	}
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIdentifierValues(t *testing.T) {
+	assert.Equal(t, []string{" NID 1 "}, models.IdentifierValues(" NID 1 "), "single values are used as given")
+	assert.Equal(t, []string{"A", "B", "C"}, models.IdentifierValues("A, B,,C,"))
+}
+
+func TestSearchPatientHandler_BirthYear(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		from, before, ok := q.BirthYearRange(time.UTC)
+		return ok && from.Equal(time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)) && before.Equal(time.Date(1991, 1, 1, 0, 0, 0, 0, time.UTC))
+	}), uint(1), searchLimit).Return([]models.Patient{}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?birth_year=1990", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	repo.AssertExpectations(t)
+}
+
+func TestPatientSearchQuery_DateRangesFollowTimezone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	dob, year := "1990-05-15", 1990
+	q := &models.PatientSearchQuery{DateOfBirth: &dob, BirthYear: &year}
+
+	from, before, err := q.DateOfBirthRange(newYork)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(1990, 5, 15, 4, 0, 0, 0, time.UTC), from.UTC(), "midnight in New York (EDT)")
+	assert.Equal(t, time.Date(1990, 5, 16, 4, 0, 0, 0, time.UTC), before.UTC())
+
+	from, before, ok := q.BirthYearRange(newYork)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(1990, 1, 1, 5, 0, 0, 0, time.UTC), from.UTC(), "midnight in New York (EST)")
+	assert.Equal(t, time.Date(1991, 1, 1, 5, 0, 0, 0, time.UTC), before.UTC())
+
+	bad := "15/05/1990"
+	q.DateOfBirth = &bad
+	_, _, err = q.DateOfBirthRange(newYork)
+	assert.Error(t, err)
+
+	createdFrom, createdTo := "2024-04-01", "2024-04-07T12:00:00Z"
+	q.CreatedFrom, q.CreatedTo = &createdFrom, &createdTo
+	createdAfter, createdBefore, err := q.CreatedRange(newYork)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 1, 4, 0, 0, 0, time.UTC), createdAfter.UTC(), "a date is midnight in New York (EDT)")
+	assert.Equal(t, time.Date(2024, 4, 7, 12, 0, 0, 1000, time.UTC), createdBefore.UTC(), "a timestamp keeps its own offset")
+}
+
+func TestSearchPatientHandler_BirthYearValidation(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	for name, query := range map[string]string{
+		"before 1900":        "birth_year=1899",
+		"in the future":      fmt.Sprintf("birth_year=%d", time.Now().Year()+1),
+		"not a number":       "birth_year=nineteen",
+		"with date_of_birth": "birth_year=1990&date_of_birth=1990-05-15",
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := performRequest(router, "GET", "/api/v1/patient/search?"+query, nil, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_CreatedRange(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		from, before, err := q.CreatedRange(time.UTC) // testConfig leaves TIMEZONE unset
+		return err == nil &&
+			from.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) &&
+			before.Equal(time.Date(2024, 4, 8, 0, 0, 0, 0, time.UTC)) // The whole of April 7th
+	}), uint(1), searchLimit).Return([]models.Patient{}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?created_from=2024-04-01&created_to=2024-04-07", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchPatientHandler_CreatedRangeValidation(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	for name, query := range map[string]string{
+		"reversed":        "created_from=2024-04-08&created_to=2024-04-01",
+		"bad from":        "created_from=01/04/2024",
+		"bad to":          "created_to=yesterday",
+		"reversed stamps": "created_from=2024-04-01T12:00:00Z&created_to=2024-04-01T11:59:59Z",
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := performRequest(router, "GET", "/api/v1/patient/search?"+query, nil, token)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), "created_from")
+		})
+	}
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_Fields(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{
+		{ID: 1, HospitalID: 1, PatientHN: "HN001", FirstNameEN: "Somchai", LastNameEN: "Jaidee", NationalID: "1234567890123"},
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?last_name_en=Jaidee&fields=id,first_name_en", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var results []map[string]interface{}
+	decodeSearchResponse(t, rr.Body.Bytes(), &results)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "first_name_en": "Somchai"}, results[0])
+	}
+
+	// id is always returned, and the full record is the default
+	rr = performRequest(router, "GET", "/api/v1/patient/search?last_name_en=Jaidee&fields=patient_hn", nil, token)
+	assert.JSONEq(t, `{"data":[{"id":1,"patient_hn":"HN001"}],"count":1,"truncated":false}`, rr.Body.String())
+	rr = performRequest(router, "GET", "/api/v1/patient/search?last_name_en=Jaidee", nil, token)
+	assert.Contains(t, rr.Body.String(), `"national_id":"1234567890123"`)
+}
+
+func TestSearchPatientHandler_UnknownFields(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	// Columns hidden from JSON are not selectable either
+	rr := performRequest(router, "GET", "/api/v1/patient/search?last_name_en=Jaidee&fields=first_name_en,first_name_th_folded,FirstNameTHFolded", nil, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unknown fields: FirstNameTHFolded, first_name_th_folded")
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExportPatientsHandler_AppliesFiltersWithoutCap(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 9, "exporter", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.CreatedFrom != nil && *q.CreatedFrom == "2024-04-01"
+	}), uint(1), 0).Return([]models.Patient{{ID: 1, HospitalID: 1}, {ID: 2, HospitalID: 1}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/export?created_from=2024-04-01", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var patients []models.Patient
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &patients))
+	assert.Len(t, patients, 2)
+	repo.AssertExpectations(t)
+}
+
+func TestExportPatientsHandler_RequiresAdmin(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/patient/export", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_PatientHNPrefix(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.PatientHNPrefix != nil && *q.PatientHNPrefix == "HN" && q.PatientHN == nil
+	}), uint(1), searchLimit).Return([]models.Patient{{ID: 1, HospitalID: 1, PatientHN: "HN001"}, {ID: 2, HospitalID: 1, PatientHN: "HN002"}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?patient_hn_prefix=HN", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+
+	rr = performRequest(router, "GET", "/api/v1/patient/search?patient_hn=HN001&patient_hn_prefix=HN", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestSearchPatientHandler_NameMatchMode(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.NameMatchMode() == models.NameMatchPrefix
+	}), uint(1), searchLimit).Return([]models.Patient{}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=An&name_match=prefix", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchPatientHandler_RejectsUnknownMatchMode(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?phone_number=0812345678&email=a@example.com&match=either", nil, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_RejectsUnknownNameMatchMode(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=An&name_match=fuzzy", nil, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_NoResults(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Nobody", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data":[],"count":0,"truncated":false}`, rr.Body.String())
+}
+
+func TestSearchPatientHandler_RequiresACriterion(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	// Modifiers alone are not criteria
+	for _, query := range []string{"", "?first_name_en=", "?name_match=prefix&include_inactive=true&normalize_thai=true"} {
+		rr := performRequest(router, "GET", "/api/v1/patient/search"+query, nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		assert.Contains(t, rr.Body.String(), "at least one search criterion required")
+	}
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_TruncatesAtHardCap(t *testing.T) {
+	cfg := *testConfig
+	cfg.SearchMaxResults = 2
+	router, repo := newTestRouterWithConfig(&cfg)
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.Anything, uint(1), 3).Return([]models.Patient{{ID: 1}, {ID: 2}, {ID: 3}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?last_name_en=a", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var results []models.Patient
+	response := decodeSearchResponse(t, rr.Body.Bytes(), &results)
+	assert.True(t, response.Truncated)
+	assert.Equal(t, 2, response.Count)
+	assert.Contains(t, response.Hint, "narrow")
+	assert.Len(t, results, 2)
+	repo.AssertCalled(t, "RecordSearch", mock.MatchedBy(func(entry *models.SearchHistory) bool { return entry.ResultCount == 2 }))
+}
+
+func TestSearchPatientHandler_ExactlyAtCapIsNotTruncated(t *testing.T) {
+	cfg := *testConfig
+	cfg.SearchMaxResults = 2
+	router, repo := newTestRouterWithConfig(&cfg)
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.Anything, uint(1), 3).Return([]models.Patient{{ID: 1}, {ID: 2}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?last_name_en=a", nil, token)
+
+	var results []models.Patient
+	response := decodeSearchResponse(t, rr.Body.Bytes(), &results)
+	assert.False(t, response.Truncated)
+	assert.Empty(t, response.Hint)
+	assert.Len(t, results, 2)
+}
+
+func TestSearchPatientHandler_DatabaseError(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return(nil, errors.New("connection reset"))
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Anyone", nil, token)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Database error during patient search")
+}
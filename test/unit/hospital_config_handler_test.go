@@ -0,0 +1,281 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/test/mocks"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func intPtr(i int) *int          { return &i }
+func boolPtr(b bool) *bool       { return &b }
+func stringPtr(s string) *string { return &s }
+
+// --- Hospital Config Handler Tests ---
+
+func TestGetHospitalConfigHandler_Admin(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 4, "chief", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	stored := &models.HospitalConfig{HospitalID: 1, MaxSearchResults: 50, HNPrefix: "HA", HNPaddingLength: 6}
+	repo.On("GetHospitalConfig", uint(1)).Return(stored, nil)
+	token := loginToken(t, router, repo, admin, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/admin/hospital/1/config", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var cfg models.HospitalConfig
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &cfg))
+	assert.Equal(t, 50, cfg.MaxSearchResults)
+	assert.Equal(t, "HA", cfg.HNPrefix)
+	assert.Equal(t, 6, cfg.HNPaddingLength)
+}
+
+func TestGetHospitalConfigHandler_OtherHospitalForbidden(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 4, "chief", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/admin/hospital/2/config", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "GetHospitalConfig", uint(2))
+}
+
+func TestGetHospitalConfigHandler_NonAdminForbidden(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/admin/hospital/1/config", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestUpdateHospitalConfigHandler_RequiresEveryField(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 4, "chief", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+
+	rr := performRequest(router, "PUT", "/api/v1/admin/hospital/1/config", map[string]interface{}{"max_search_results": 10}, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "SaveHospitalConfig", mock.Anything)
+}
+
+func TestUpdateHospitalConfigHandler_InvalidatesCachedConfig(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 4, "chief", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+
+	defaults := models.DefaultHospitalConfig(1)
+	updated := &models.HospitalConfig{HospitalID: 1, SearchMinCriteria: 2}
+	repo.On("GetHospitalConfig", uint(1)).Return(&defaults, nil).Twice() // Login, then the existence check in PUT
+	repo.On("GetHospitalConfig", uint(1)).Return(updated, nil).Once()    // Reload after invalidation
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{}, nil)
+	repo.On("SaveHospitalConfig", mock.MatchedBy(func(cfg *models.HospitalConfig) bool {
+		return cfg.HospitalID == 1 && cfg.SearchMinCriteria == 2
+	})).Return(nil)
+	token := loginToken(t, router, repo, admin, "password123")
+
+	// Served from the config cached at login: one criterion is enough
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	body := models.HospitalConfigUpdateRequest{
+		MaxSearchResults:  intPtr(0),
+		HNPrefix:          stringPtr(""),
+		HNPaddingLength:   intPtr(0),
+		SearchMinCriteria: intPtr(2),
+		TwoFactorRequired: boolPtr(false),
+	}
+	rr = performRequest(router, "PUT", "/api/v1/admin/hospital/1/config", body, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Applies immediately rather than after the cache TTL
+	rr = performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "At least 2 search criteria are required")
+	repo.AssertExpectations(t)
+}
+
+func TestSearchPatientHandler_AppliesMaxSearchResults(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	repo.On("GetHospitalConfig", uint(1)).Return(&models.HospitalConfig{HospitalID: 1, MaxSearchResults: 25}, nil)
+	repo.On("SearchPatients", mock.Anything, uint(1), 26).Return([]models.Patient{}, nil)
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Anyone", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+// --- Hospital Config Cache Tests ---
+
+func TestHospitalConfigCache_ServesFromMemoryUntilInvalidated(t *testing.T) {
+	repo := new(mocks.MockPatientRepository)
+	cfg := &models.HospitalConfig{HospitalID: 1, MaxSearchResults: 10}
+	repo.On("GetHospitalConfig", uint(1)).Return(cfg, nil)
+	cache := services.NewHospitalConfigCache(repo, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		got, err := cache.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, got.MaxSearchResults)
+	}
+	repo.AssertNumberOfCalls(t, "GetHospitalConfig", 1)
+
+	cache.Invalidate(1)
+	_, err := cache.Get(1)
+	assert.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "GetHospitalConfig", 2)
+}
+
+func TestHospitalConfigCache_ReloadsAfterTTL(t *testing.T) {
+	repo := new(mocks.MockPatientRepository)
+	repo.On("GetHospitalConfig", uint(1)).Return(&models.HospitalConfig{HospitalID: 1}, nil)
+	cache := services.NewHospitalConfigCache(repo, 10*time.Millisecond)
+
+	_, _ = cache.Get(1)
+	time.Sleep(20 * time.Millisecond)
+	_, _ = cache.Get(1)
+
+	repo.AssertNumberOfCalls(t, "GetHospitalConfig", 2)
+}
+
+// --- Two-Factor Login Tests ---
+
+func TestLoginStaffHandler_TwoFactorRequiredNotEnrolled(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "newbie", "password123", 1, "Hospital A")
+	repo.On("FindStaffByUsername", "newbie").Return(staff, nil)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	repo.On("GetHospitalConfig", uint(1)).Return(&models.HospitalConfig{HospitalID: 1, TwoFactorRequired: true}, nil)
+
+	loginData := models.StaffLoginRequest{Username: "newbie", Password: "password123", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	var resp struct {
+		EnrollmentToken string `json:"enrollment_token"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.EnrollmentToken)
+
+	// The enrollment token does not unlock patient data
+	rr = performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Any", nil, resp.EnrollmentToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// ...but it does unlock enrollment
+	repo.On("SetStaffTOTPSecret", uint(5), mock.AnythingOfType("string")).Return(nil)
+	rr = performRequest(router, "POST", "/api/v1/staff/2fa/enroll", nil, resp.EnrollmentToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "otpauth://totp/")
+}
+
+func TestLoginStaffHandler_TwoFactorCode(t *testing.T) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: services.TOTPIssuer, AccountName: "secure"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		code       string
+		wantStatus int
+	}{
+		{"missing code", "", http.StatusUnauthorized},
+		{"wrong code", "000000", http.StatusUnauthorized},
+		{"valid code", "", http.StatusOK}, // Generated below
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, repo := newTestRouter()
+			staff := hashedStaff(t, 6, "secure", "password123", 1, "Hospital A")
+			staff.TOTPSecret = key.Secret()
+			staff.TOTPEnabled = true
+			repo.On("FindStaffByUsername", "secure").Return(staff, nil)
+			repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+			repo.On("GetHospitalConfig", uint(1)).Return(&models.HospitalConfig{HospitalID: 1, TwoFactorRequired: true}, nil)
+
+			code := tt.code
+			if tt.wantStatus == http.StatusOK {
+				code, _ = totp.GenerateCode(key.Secret(), time.Now())
+				repo.On("ClaimStaffTOTPStep", uint(6), mock.AnythingOfType("int64")).Return(true, nil)
+			}
+			loginData := models.StaffLoginRequest{Username: "secure", Password: "password123", Hospital: "Hospital A", OTPCode: code}
+			rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+			assert.Equal(t, tt.wantStatus, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestLoginStaffHandler_RejectsReplayedTwoFactorCode(t *testing.T) {
+	router, repo := newTestRouter()
+	key, _ := totp.Generate(totp.GenerateOpts{Issuer: services.TOTPIssuer, AccountName: "secure"})
+	staff := hashedStaff(t, 6, "secure", "password123", 1, "Hospital A")
+	staff.TOTPSecret = key.Secret()
+	staff.TOTPEnabled = true
+	repo.On("FindStaffByUsername", "secure").Return(staff, nil)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	repo.On("GetHospitalConfig", uint(1)).Return(&models.HospitalConfig{HospitalID: 1, TwoFactorRequired: true}, nil)
+
+	code, _ := totp.GenerateCode(key.Secret(), time.Now())
+	// The step was already claimed by an earlier login with the same code
+	repo.On("ClaimStaffTOTPStep", uint(6), mock.AnythingOfType("int64")).Return(false, nil)
+
+	loginData := models.StaffLoginRequest{Username: "secure", Password: "password123", Hospital: "Hospital A", OTPCode: code}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, rr.Body.String())
+	repo.AssertNotCalled(t, "RecordStaffLogin", mock.Anything, mock.Anything)
+}
+
+func TestLoginStaffHandler_TwoFactorClaimFailure(t *testing.T) {
+	router, repo := newTestRouter()
+	key, _ := totp.Generate(totp.GenerateOpts{Issuer: services.TOTPIssuer, AccountName: "secure"})
+	staff := hashedStaff(t, 6, "secure", "password123", 1, "Hospital A")
+	staff.TOTPSecret = key.Secret()
+	staff.TOTPEnabled = true
+	repo.On("FindStaffByUsername", "secure").Return(staff, nil)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	repo.On("GetHospitalConfig", uint(1)).Return(&models.HospitalConfig{HospitalID: 1, TwoFactorRequired: true}, nil)
+	repo.On("ClaimStaffTOTPStep", uint(6), mock.AnythingOfType("int64")).Return(false, errors.New("connection reset"))
+
+	code, _ := totp.GenerateCode(key.Secret(), time.Now())
+	loginData := models.StaffLoginRequest{Username: "secure", Password: "password123", Hospital: "Hospital A", OTPCode: code}
+	rr := performRequest(router, "POST", "/api/v1/staff/login", loginData, "")
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, rr.Body.String())
+}
+
+func TestConfirmTwoFactorHandler_EnablesWithValidCode(t *testing.T) {
+	router, repo := newTestRouter()
+	key, _ := totp.Generate(totp.GenerateOpts{Issuer: services.TOTPIssuer, AccountName: "enroller"})
+	staff := hashedStaff(t, 7, "enroller", "password123", 1, "Hospital A")
+	stored := *staff
+	stored.TOTPSecret = key.Secret()
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("FindStaffByUsername", "enroller").Return(&stored, nil)
+	repo.On("EnableStaffTOTP", uint(7), mock.AnythingOfType("int64")).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/staff/2fa/confirm", models.TwoFactorConfirmRequest{Code: "000000"}, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "EnableStaffTOTP", mock.Anything, mock.Anything)
+
+	code, _ := totp.GenerateCode(key.Secret(), time.Now())
+	rr = performRequest(router, "POST", "/api/v1/staff/2fa/confirm", models.TwoFactorConfirmRequest{Code: code}, token)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	repo.AssertExpectations(t)
+}
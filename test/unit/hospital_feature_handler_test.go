@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchPatientHandler_CrossHospitalFeaturePerHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	adminA := hashedStaff(t, 1, "admin_a", "password123", 1, "Hospital A")
+	adminA.Role = models.RoleAdmin
+	tokenA := loginToken(t, router, repo, adminA, "password123")
+	adminB := hashedStaff(t, 2, "admin_b", "password123", 2, "Hospital B")
+	adminB.Role = models.RoleAdmin
+	tokenB := loginToken(t, router, repo, adminB, "password123")
+	repo.On("IsFeatureEnabled", uint(1), models.FeatureCrossHospitalSearch).Return(true)
+	repo.On("IsFeatureEnabled", uint(2), models.FeatureCrossHospitalSearch).Return(false)
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{{ID: 1, HospitalID: 1}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&cross_hospital=true", nil, tokenB)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "not enabled for this hospital")
+
+	rr = performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&cross_hospital=true", nil, tokenA)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	repo.AssertNumberOfCalls(t, "SearchPatients", 1)
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, uint(2), mock.Anything)
+}
+
+func TestSetHospitalFeatureHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("SetHospitalFeature", uint(1), models.FeatureCrossHospitalSearch, true).
+		Return(models.HospitalFeatures{models.FeatureCrossHospitalSearch: true}, nil)
+
+	rr := performRequest(router, "PUT", "/api/v1/admin/hospital/1/features/cross_hospital_search", gin.H{"enabled": true}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp models.HospitalFeaturesResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, models.HospitalFeaturesResponse{HospitalID: 1, Features: models.HospitalFeatures{"cross_hospital_search": true}}, resp)
+	repo.AssertExpectations(t)
+}
+
+func TestSetHospitalFeatureHandler_Errors(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("SetHospitalFeature", uint(1), models.FeatureCrossHospitalSearch, false).Return(nil, database.ErrHospitalNotFound)
+
+	tests := []struct {
+		name string
+		path string
+		body gin.H
+		code int
+	}{
+		{"unknown feature", "/api/v1/admin/hospital/1/features/time_travel", gin.H{"enabled": true}, http.StatusNotFound},
+		{"missing enabled", "/api/v1/admin/hospital/1/features/cross_hospital_search", gin.H{}, http.StatusBadRequest},
+		{"other hospital", "/api/v1/admin/hospital/2/features/cross_hospital_search", gin.H{"enabled": true}, http.StatusForbidden},
+		{"hospital gone", "/api/v1/admin/hospital/1/features/cross_hospital_search", gin.H{"enabled": false}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := performRequest(router, "PUT", tt.path, tt.body, token)
+			assert.Equal(t, tt.code, rr.Code, rr.Body.String())
+		})
+	}
+	repo.AssertNumberOfCalls(t, "SetHospitalFeature", 1)
+}
+
+func TestSetHospitalFeatureHandler_StaffForbidden(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "PUT", "/api/v1/admin/hospital/1/features/cross_hospital_search", gin.H{"enabled": true}, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "SetHospitalFeature", mock.Anything, mock.Anything, mock.Anything)
+}
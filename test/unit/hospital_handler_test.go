@@ -0,0 +1,23 @@
+package unit
+
+import (
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListHospitalsHandler_PublicSummaryOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("ListHospitals").Return([]models.Hospital{
+		{ID: 1, Name: "Hospital A", Code: "HA", CreatedAt: time.Now()},
+		{ID: 2, Name: "Hospital B", Code: "HB", CreatedAt: time.Now()},
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/hospitals", nil, "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"id":1,"name":"Hospital A","code":"HA"},{"id":2,"name":"Hospital B","code":"HB"}]`, rr.Body.String())
+}
@@ -0,0 +1,40 @@
+package unit
+
+import (
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthRequired_RejectsDeletedHospital(t *testing.T) {
+	cfg := *testConfig
+	cfg.ValidateHospitalOnRequest = true
+	router, repo := newTestRouterWithConfig(&cfg)
+	token := loginToken(t, router, repo, hashedStaff(t, 9, "orphan", "password123", 1, "Hospital A"), "password123")
+	repo.On("GetHospitalByID", uint(1)).Return(nil, fmt.Errorf("%w: id 1", database.ErrHospitalNotFound))
+
+	for i := 0; i < 2; i++ {
+		rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Body.String(), "hospital no longer valid")
+	}
+	repo.AssertNumberOfCalls(t, "GetHospitalByID", 1) // The second request is answered from the cache
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthRequired_HospitalValidationOff(t *testing.T) {
+	router, repo := newTestRouter()
+	token := loginToken(t, router, repo, hashedStaff(t, 9, "orphan", "password123", 1, "Hospital A"), "password123")
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertNotCalled(t, "GetHospitalByID", mock.Anything)
+}
@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/apperror"
+	"hospital-middleware/pkg/i18n"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// performLocalizedRequest is performRequest with an Accept-Language header.
+func performLocalizedRequest(router *gin.Engine, method, path string, body interface{}, token, acceptLanguage string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		req, _ = http.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req, _ = http.NewRequest(method, path, nil)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept-Language", acceptLanguage)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func decodeErrorResponse(t *testing.T, rr *httptest.ResponseRecorder) apperror.ErrorResponse {
+	t.Helper()
+	var resp apperror.ErrorResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp), rr.Body.String())
+	return resp
+}
+
+func TestI18nMessages_KnownCodesInEveryLanguage(t *testing.T) {
+	known := make(map[string]bool, len(apperror.Codes))
+	for _, code := range apperror.Codes {
+		known[string(code)] = true
+	}
+	for code, messages := range i18n.Messages {
+		assert.True(t, known[code], "%s is not an error code", code)
+		assert.NotEmpty(t, messages[i18n.English], "%s has no English message", code)
+		assert.NotEmpty(t, messages[i18n.Thai], "%s has no Thai message", code)
+	}
+	for _, code := range apperror.Codes {
+		if strings.HasPrefix(string(code), "AUTH_") {
+			_, ok := i18n.Message(string(code), i18n.Thai)
+			assert.True(t, ok, "authentication error %s has no Thai message", code)
+		}
+	}
+}
+
+func TestRequestLanguage(t *testing.T) {
+	tests := []struct {
+		query, acceptLanguage, want string
+	}{
+		{"", "", i18n.English},
+		{"", "th", i18n.Thai},
+		{"", "th-TH,th;q=0.9,en;q=0.8", i18n.Thai},
+		{"", "en-US,en;q=0.9,th;q=0.8", i18n.English},
+		{"", "fr-FR,fr;q=0.9,th;q=0.5", i18n.Thai},
+		{"", "ja", i18n.English},
+		{"", "not a language;;", i18n.English},
+		{"lang=th", "en", i18n.Thai},
+		{"lang=TH-th", "", i18n.Thai},
+		{"lang=en", "th", i18n.English},
+		{"lang=ja", "th", i18n.English},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+		assert.Equal(t, tt.want, i18n.RequestLanguage(req), "lang %q, Accept-Language %q", tt.query, tt.acceptLanguage)
+	}
+}
+
+func TestLocalizedErrors_InvalidCredentials(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	repo.On("FindStaffByUsername", "ghost").Return(nil, gorm.ErrRecordNotFound)
+	loginData := models.StaffLoginRequest{Username: "ghost", Password: "password123", Hospital: "Hospital A"}
+
+	rr := performLocalizedRequest(router, "POST", "/api/v1/staff/login", loginData, "", "th")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, apperror.ErrorResponse{Code: apperror.CodeInvalidCredentials, Message: "ชื่อผู้ใช้ รหัสผ่าน หรือโรงพยาบาลไม่ถูกต้อง"}, decodeErrorResponse(t, rr))
+
+	rr = performLocalizedRequest(router, "POST", "/api/v1/staff/login", loginData, "", "en")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, apperror.ErrorResponse{Code: apperror.CodeInvalidCredentials, Message: "invalid username or password"}, decodeErrorResponse(t, rr))
+}
+
+func TestLocalizedErrors_HospitalMismatch(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindStaffByUsername", "loginuser").Return(hashedStaff(t, 5, "loginuser", "password123", 2, "Hospital B"), nil)
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil)
+	loginData := models.StaffLoginRequest{Username: "loginuser", Password: "password123", Hospital: "Hospital A"}
+
+	rr := performLocalizedRequest(router, "POST", "/api/v1/staff/login", loginData, "", "th-TH,th;q=0.9")
+	assert.Equal(t, "ชื่อผู้ใช้ รหัสผ่าน หรือโรงพยาบาลไม่ถูกต้อง", decodeErrorResponse(t, rr).Message)
+
+	rr = performLocalizedRequest(router, "POST", "/api/v1/staff/login", loginData, "", "en")
+	assert.Equal(t, "invalid username or password", decodeErrorResponse(t, rr).Message)
+}
+
+func TestLocalizedErrors_ExpiredToken(t *testing.T) {
+	router, repo := newTestRouter()
+	expired := signedTestToken(t, &services.Claims{
+		UserID: 9, Username: "expired", HospitalID: 1, Role: models.RoleStaff,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))},
+	}, testConfig.JWTSecret)
+
+	rr := performLocalizedRequest(router, "GET", "/api/v1/patient/1", nil, expired, "th")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, apperror.ErrorResponse{Code: apperror.CodeInvalidToken, Message: "โทเค็นไม่ถูกต้องหรือหมดอายุแล้ว กรุณาเข้าสู่ระบบใหม่"}, decodeErrorResponse(t, rr))
+
+	rr = performLocalizedRequest(router, "GET", "/api/v1/patient/1", nil, expired, "en")
+	assert.Equal(t, apperror.ErrorResponse{Code: apperror.CodeInvalidToken, Message: "token is expired"}, decodeErrorResponse(t, rr))
+
+	rr = performLocalizedRequest(router, "GET", "/api/v1/patient/1?lang=en", nil, expired, "th")
+	assert.Equal(t, "token is expired", decodeErrorResponse(t, rr).Message, "lang takes precedence over Accept-Language")
+
+	rr = performLocalizedRequest(router, "GET", "/api/v1/patient/1?lang=th", nil, "", "")
+	assert.Equal(t, apperror.ErrorResponse{Code: apperror.CodeAuthRequired, Message: "ไม่พบข้อมูลยืนยันตัวตน กรุณาเข้าสู่ระบบ"}, decodeErrorResponse(t, rr))
+	repo.AssertExpectations(t)
+}
+
+func TestLocalizedErrors_EnglishWithoutTranslation(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performLocalizedRequest(router, "GET", "/api/v1/patient/abc", nil, token, "th")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	resp := decodeErrorResponse(t, rr)
+	assert.Equal(t, apperror.CodeInvalidPathParam, resp.Code)
+	_, translated := i18n.Message(string(resp.Code), i18n.Thai)
+	assert.False(t, translated)
+	assert.NotEmpty(t, resp.Message, "the English message is kept")
+}
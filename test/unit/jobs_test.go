@@ -0,0 +1,310 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hospital-middleware/internal/jobs"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// memoryJobStore is a jobs.Store keeping jobs in memory.
+type memoryJobStore struct {
+	mu   sync.Mutex
+	jobs []*models.Job
+}
+
+func (s *memoryJobStore) CreateJob(job *models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.ID = uint(len(s.jobs) + 1)
+	stored := *job
+	s.jobs = append(s.jobs, &stored)
+	return nil
+}
+
+func (s *memoryJobStore) ClaimJob(types []string, now, staleBefore time.Time) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		due := job.Status == models.JobStatusQueued && !job.RunAt.After(now)
+		stale := job.Status == models.JobStatusRunning && job.LockedAt.Before(staleBefore)
+		if slices.Contains(types, job.Type) && (due || stale) {
+			job.Status = models.JobStatusRunning
+			job.Attempts++
+			job.LockedAt = &now
+			claimed := *job
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryJobStore) update(id uint, fn func(*models.Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.jobs[id-1])
+	return nil
+}
+
+func (s *memoryJobStore) CompleteJob(id uint, now time.Time) error {
+	return s.update(id, func(job *models.Job) {
+		job.Status, job.LockedAt, job.LastError, job.FinishedAt = models.JobStatusSucceeded, nil, "", &now
+	})
+}
+
+func (s *memoryJobStore) RetryJob(id uint, lastError string, runAt time.Time) error {
+	return s.update(id, func(job *models.Job) {
+		job.Status, job.LockedAt, job.LastError, job.RunAt = models.JobStatusQueued, nil, lastError, runAt
+	})
+}
+
+func (s *memoryJobStore) FailJob(id uint, lastError string, now time.Time) error {
+	return s.update(id, func(job *models.Job) {
+		job.Status, job.LockedAt, job.LastError, job.FinishedAt = models.JobStatusFailed, nil, lastError, &now
+	})
+}
+
+func (s *memoryJobStore) ReleaseJob(id uint) error {
+	return s.update(id, func(job *models.Job) {
+		job.Status, job.LockedAt, job.Attempts = models.JobStatusQueued, nil, max(job.Attempts-1, 0)
+	})
+}
+
+func (s *memoryJobStore) get(id uint) models.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.jobs[id-1]
+}
+
+// finished reports whether the job has succeeded or failed for good.
+func (s *memoryJobStore) finished(id uint) bool {
+	status := s.get(id).Status
+	return status == models.JobStatusSucceeded || status == models.JobStatusFailed
+}
+
+// startPool starts a pool polling every millisecond with a millisecond of backoff.
+func startPool(store jobs.Store, workers int, handlers map[string]jobs.Handler) *jobs.Pool {
+	pool := jobs.NewPool(store, workers, time.Millisecond, time.Millisecond)
+	for jobType, handler := range handlers {
+		pool.Register(jobType, handler)
+	}
+	pool.Start()
+	return pool
+}
+
+func TestEnqueue(t *testing.T) {
+	store := &memoryJobStore{}
+
+	job, err := jobs.Enqueue(store, "export", map[string]int{"hospital_id": 3}, 0)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, models.JobStatusQueued, job.Status)
+		assert.Equal(t, jobs.DefaultMaxAttempts, job.MaxAttempts)
+		assert.JSONEq(t, `{"hospital_id":3}`, string(job.Payload))
+		assert.WithinDuration(t, time.Now(), job.RunAt, time.Second)
+	}
+	_, err = jobs.Enqueue(store, "export", func() {}, 0)
+	assert.Error(t, err, "payloads must encode as JSON")
+}
+
+func TestJobPool_RunsJobsWithTheirPayload(t *testing.T) {
+	store := &memoryJobStore{}
+	payloads := make(chan string, 2)
+	pool := startPool(store, 2, map[string]jobs.Handler{
+		"export": func(_ context.Context, payload json.RawMessage) error {
+			payloads <- string(payload)
+			return nil
+		},
+	})
+	first, _ := jobs.Enqueue(store, "export", map[string]int{"n": 1}, 0)
+	second, _ := jobs.Enqueue(store, "export", map[string]int{"n": 2}, 0)
+	other, _ := jobs.Enqueue(store, "unregistered", nil, 0)
+
+	assert.Eventually(t, func() bool { return store.finished(first.ID) && store.finished(second.ID) }, time.Second, time.Millisecond)
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	assert.ElementsMatch(t, []string{`{"n":1}`, `{"n":2}`}, []string{<-payloads, <-payloads})
+	assert.Equal(t, models.JobStatusSucceeded, store.get(first.ID).Status)
+	assert.NotNil(t, store.get(first.ID).FinishedAt)
+	assert.Equal(t, models.JobStatusQueued, store.get(other.ID).Status, "jobs without a handler stay queued")
+}
+
+func TestJobPool_RetriesUntilMaxAttempts(t *testing.T) {
+	store := &memoryJobStore{}
+	var mu sync.Mutex
+	runs := map[string]int{}
+	pool := startPool(store, 1, map[string]jobs.Handler{
+		"flaky": func(context.Context, json.RawMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if runs["flaky"]++; runs["flaky"] < 3 {
+				return errors.New("receiver busy")
+			}
+			return nil
+		},
+		"broken": func(context.Context, json.RawMessage) error {
+			mu.Lock()
+			runs["broken"]++
+			mu.Unlock()
+			panic("nil map")
+		},
+	})
+	flaky, _ := jobs.Enqueue(store, "flaky", nil, 3)
+	broken, _ := jobs.Enqueue(store, "broken", nil, 2)
+
+	assert.Eventually(t, func() bool { return store.finished(flaky.ID) && store.finished(broken.ID) }, time.Second, time.Millisecond)
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	assert.Equal(t, models.JobStatusSucceeded, store.get(flaky.ID).Status)
+	assert.Equal(t, 3, store.get(flaky.ID).Attempts)
+	assert.Equal(t, models.JobStatusFailed, store.get(broken.ID).Status)
+	assert.Equal(t, 2, store.get(broken.ID).Attempts)
+	assert.Equal(t, "panic: nil map", store.get(broken.ID).LastError, "a panicking handler fails its attempt, not the worker")
+	assert.Equal(t, map[string]int{"flaky": 3, "broken": 2}, runs)
+}
+
+func TestJobPool_BacksOffExponentially(t *testing.T) {
+	store := &memoryJobStore{}
+	failed := make(chan struct{}, 2)
+	pool := jobs.NewPool(store, 1, time.Millisecond, 10*time.Minute)
+	pool.Register("flaky", func(context.Context, json.RawMessage) error {
+		failed <- struct{}{}
+		return errors.New("receiver busy")
+	})
+	first, _ := jobs.Enqueue(store, "flaky", nil, 5)
+	third, _ := jobs.Enqueue(store, "flaky", nil, 5)
+	store.update(third.ID, func(job *models.Job) { job.Attempts = 2 })
+
+	pool.Start()
+	waitFor(t, failed, "first failure")
+	waitFor(t, failed, "second failure")
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), store.get(first.ID).RunAt, 5*time.Second)
+	assert.WithinDuration(t, time.Now().Add(40*time.Minute), store.get(third.ID).RunAt, 5*time.Second, "the third attempt waits four times as long")
+	assert.Equal(t, "receiver busy", store.get(first.ID).LastError)
+}
+
+func TestJobPool_FailsReclaimedJobsOutOfAttempts(t *testing.T) {
+	store := &memoryJobStore{}
+	abandonedAt := time.Now().Add(-2 * jobs.JobLease)
+	job, _ := jobs.Enqueue(store, "export", nil, 2)
+	store.update(job.ID, func(job *models.Job) {
+		job.Status, job.Attempts, job.LockedAt = models.JobStatusRunning, 2, &abandonedAt
+	})
+	pool := startPool(store, 1, map[string]jobs.Handler{
+		"export": func(context.Context, json.RawMessage) error {
+			t.Error("a job out of attempts must not run again")
+			return nil
+		},
+	})
+
+	assert.Eventually(t, func() bool { return store.finished(job.ID) }, time.Second, time.Millisecond)
+	assert.NoError(t, pool.Shutdown(context.Background()))
+	assert.Equal(t, models.JobStatusFailed, store.get(job.ID).Status)
+}
+
+func TestJobPool_ShutdownWaitsForRunningJobs(t *testing.T) {
+	store := &memoryJobStore{}
+	started, release := make(chan struct{}), make(chan struct{})
+	pool := startPool(store, 1, map[string]jobs.Handler{
+		"export": func(context.Context, json.RawMessage) error {
+			close(started)
+			<-release
+			return nil
+		},
+	})
+	job, _ := jobs.Enqueue(store, "export", nil, 0)
+	waitFor(t, started, "job to start")
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- pool.Shutdown(context.Background()) }()
+	select {
+	case <-stopped:
+		t.Fatal("Shutdown returned while a job was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+
+	assert.NoError(t, <-stopped)
+	assert.Equal(t, models.JobStatusSucceeded, store.get(job.ID).Status)
+}
+
+func TestJobPool_ShutdownDeadlineReturnsJobsToQueue(t *testing.T) {
+	store := &memoryJobStore{}
+	started := make(chan struct{})
+	pool := startPool(store, 1, map[string]jobs.Handler{
+		"export": func(ctx context.Context, _ json.RawMessage) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	job, _ := jobs.Enqueue(store, "export", nil, 0)
+	waitFor(t, started, "job to start")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.Shutdown(ctx), context.DeadlineExceeded)
+
+	released := store.get(job.ID)
+	assert.Equal(t, models.JobStatusQueued, released.Status)
+	assert.Equal(t, 0, released.Attempts, "an interrupted attempt is not counted")
+	assert.Nil(t, released.LockedAt)
+}
+
+func TestJobPool_WithoutHandlers(t *testing.T) {
+	pool := jobs.NewPool(&memoryJobStore{}, 2, time.Millisecond, time.Millisecond)
+	assert.NoError(t, pool.Shutdown(context.Background()), "a pool that never started stops at once")
+	pool.Start()
+	assert.NoError(t, pool.Shutdown(context.Background()))
+}
+
+func TestListJobsHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleSuperAdmin)
+	listed := []models.Job{{ID: 4, Type: "export", Status: models.JobStatusFailed, Attempts: 5, MaxAttempts: 5, LastError: "disk full"}}
+	repo.On("ListJobs", models.JobStatusFailed, 0, 20).Return(listed, int64(1), nil)
+	repo.On("ListJobs", "", 0, 20).Return(nil, int64(0), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/admin/jobs?status=failed", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var page struct {
+		Data  []models.Job `json:"data"`
+		Total int64        `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, int64(1), page.Total)
+	if assert.Len(t, page.Data, 1) {
+		assert.Equal(t, "disk full", page.Data[0].LastError)
+	}
+
+	rr = performRequest(router, "GET", "/api/v1/admin/jobs", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"data":[]`)
+
+	rr = performRequest(router, "GET", "/api/v1/admin/jobs?status=done", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, apperror.CodeInvalidQuery, decodeErrorResponse(t, rr).Code)
+}
+
+func TestListJobsHandler_SuperAdminsOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	rr := performRequest(router, "GET", "/api/v1/admin/jobs", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "AUTH_019")
+	repo.AssertNotCalled(t, "ListJobs", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,49 @@
+package unit
+
+import (
+	"hospital-middleware/internal/api"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/test/mocks"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLogoutHandler_RevokesToken(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "leaver", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	var revoked *models.RevokedToken
+	repo.On("RevokeToken", mock.AnythingOfType("*models.RevokedToken")).
+		Run(func(args mock.Arguments) { revoked = args.Get(0).(*models.RevokedToken) }).
+		Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/staff/logout", nil, token)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	if assert.NotNil(t, revoked) {
+		assert.NotEmpty(t, revoked.JTI)
+		assert.Equal(t, uint(9), revoked.StaffID)
+		assert.WithinDuration(t, time.Now().Add(testConfig.JWTExpiry), revoked.ExpiresAt, time.Minute)
+	}
+}
+
+func TestAuthRequired_RejectsRevokedToken(t *testing.T) {
+	repo := new(mocks.MockPatientRepository)
+	repo.On("RecordStaffLogin", mock.Anything, mock.Anything).Return(nil)
+	router := api.SetupRouter(repo, testBlobs, testConfig, services.NewAsyncWriters(repo))
+	staff := hashedStaff(t, 9, "leaver", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("IsTokenRevoked", mock.AnythingOfType("string")).Return(true, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "revoked")
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
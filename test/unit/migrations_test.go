@@ -0,0 +1,56 @@
+package unit
+
+import (
+	"hospital-middleware/internal/database"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations_VersionedWithUpAndDown(t *testing.T) {
+	migrations, err := database.LoadMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "versions start at 1 without gaps")
+		assert.NotEmpty(t, migration.Up, "migration %d has an up file", migration.Version)
+		assert.NotEmpty(t, migration.Down, "migration %d has a down file", migration.Version)
+	}
+}
+
+// The tables belong to AutoMigrate, so the migration files must not keep a second copy of them
+// that could drift from the models.
+func TestLoadMigrations_LeaveTablesToAutoMigrate(t *testing.T) {
+	migrations, err := database.LoadMigrations()
+	require.NoError(t, err)
+
+	for _, migration := range migrations {
+		assert.NotContains(t, strings.ToUpper(migration.Up), "CREATE TABLE", "migration %d_%s", migration.Version, migration.Name)
+		assert.NotContains(t, strings.ToUpper(migration.Down), "DROP TABLE", "migration %d_%s", migration.Version, migration.Name)
+	}
+}
+
+func TestLoadMigrations_DownDropsEveryIndexUpCreates(t *testing.T) {
+	migrations, err := database.LoadMigrations()
+	require.NoError(t, err)
+	createIndex := regexp.MustCompile(`(?i)CREATE (?:UNIQUE )?INDEX IF NOT EXISTS "?(\w+)"?`)
+
+	for _, migration := range migrations {
+		for _, match := range createIndex.FindAllStringSubmatch(migration.Up, -1) {
+			assert.Contains(t, migration.Down, "DROP INDEX IF EXISTS "+match[1]+";", "migration %d_%s", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestLoadMigrations_OnlyBaselineIsIrreversible(t *testing.T) {
+	migrations, err := database.LoadMigrations()
+	require.NoError(t, err)
+
+	for _, migration := range migrations {
+		assert.Equal(t, migration.Version == 1, migration.Irreversible, "migration %d_%s", migration.Version, migration.Name)
+	}
+}
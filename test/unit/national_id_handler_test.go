@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"hospital-middleware/pkg/utils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestValidateThaiNationalID(t *testing.T) {
+	cases := map[string]string{
+		"1103700123458":     "",
+		"3100600123450":     "",
+		"1-1037-00123-45-8": "",
+		" 1234567890121 ":   "",
+		"1103700123456":     utils.NationalIDReasonChecksum,
+		"1000000000000":     utils.NationalIDReasonChecksum,
+		"110370012345":      utils.NationalIDReasonLength,
+		"11037001234580":    utils.NationalIDReasonLength,
+		"11037OO123458":     utils.NationalIDReasonLength,
+		"":                  utils.NationalIDReasonLength,
+	}
+	for id, want := range cases {
+		assert.Equal(t, want, utils.ValidateThaiNationalID(id), id)
+	}
+}
+
+func TestValidateNationalIDsHandler_MixedIDs(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	body := map[string]interface{}{"ids": []string{"1103700123458", "1103700123456", "12345", "1-1037-00123-45-8"}}
+	rr := performRequest(router, "POST", "/api/v1/utils/validate-national-ids", body, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp models.NationalIDValidationResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, []models.NationalIDValidation{
+		{ID: "1103700123458", Valid: true},
+		{ID: "1103700123456", Valid: false, Reason: utils.NationalIDReasonChecksum},
+		{ID: "12345", Valid: false, Reason: utils.NationalIDReasonLength},
+		{ID: "1-1037-00123-45-8", Valid: true},
+	}, resp.Results)
+}
+
+func TestValidateNationalIDsHandler_TooManyIDs(t *testing.T) {
+	cfg := *testConfig
+	cfg.NationalIDValidateMax = 2
+	router, repo := newTestRouterWithConfig(&cfg)
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	body := map[string]interface{}{"ids": []string{"1103700123458", "3100600123450", "1234567890121"}}
+	rr := performRequest(router, "POST", "/api/v1/utils/validate-national-ids", body, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "at most 2")
+
+	body["ids"] = []string{"1103700123458", "3100600123450"}
+	rr = performRequest(router, "POST", "/api/v1/utils/validate-national-ids", body, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestValidateNationalIDsHandler_RequiresIDsAndAuth(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "POST", "/api/v1/utils/validate-national-ids", map[string]interface{}{}, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"ids"`)
+
+	rr = performRequest(router, "POST", "/api/v1/utils/validate-national-ids", map[string]interface{}{"ids": []string{"1103700123458"}}, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestGetPatientByNationalIDHandler_Found(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByNationalID", "1103700123458", uint(1)).
+		Return(&models.Patient{ID: 10, HospitalID: 1, NationalID: "1103700123458", FirstNameEN: "Somchai"}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/by-national-id/1-1037-00123-45-8", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp models.PatientDetailResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, uint(10), resp.ID)
+	assert.Equal(t, "Somchai", resp.FirstNameEN)
+	repo.AssertCalled(t, "RecordPatientView", mock.MatchedBy(func(view *models.RecentlyViewed) bool {
+		return view.PatientID == 10
+	}))
+}
+
+func TestGetPatientByNationalIDHandler_NotFound(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByNationalID", "1103700123458", uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/by-national-id/1103700123458", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, apperror.CodePatientNotFound, decodeErrorResponse(t, rr).Code)
+}
+
+func TestGetPatientByNationalIDHandler_SharedNationalID(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByNationalID", "1103700123458", uint(1)).Return(nil, database.ErrNationalIDNotUnique)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/by-national-id/1103700123458", nil, token)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Equal(t, apperror.CodeNationalIDNotUnique, decodeErrorResponse(t, rr).Code)
+}
+
+func TestGetPatientByNationalIDHandler_InvalidFormat(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	for _, nid := range []string{"1103700123456", "12345", "11037OO123458"} {
+		rr := performRequest(router, "GET", "/api/v1/patient/by-national-id/"+nid, nil, token)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, nid)
+		assert.Equal(t, apperror.CodeInvalidPathParam, decodeErrorResponse(t, rr).Code, nid)
+	}
+	repo.AssertNotCalled(t, "GetPatientByNationalID", mock.Anything, mock.Anything)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/by-national-id/1103700123458", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
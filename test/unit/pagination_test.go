@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListEndpoints_PageSizeDefaultAndClamp(t *testing.T) {
+	cfg := *testConfig
+	cfg.DefaultPageSize = 5
+	cfg.MaxPageSize = 10
+
+	tests := []struct {
+		name         string
+		query        string
+		wantPageSize int
+		wantOffset   int
+	}{
+		{"default when unspecified", "", 5, 0},
+		{"default when zero", "?page_size=0", 5, 0},
+		{"requested size within max", "?page_size=7&page=2", 7, 7},
+		{"clamped to max, not rejected", "?page_size=500&page=3", 10, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, repo := newTestRouterWithConfig(&cfg)
+			staff := hashedStaff(t, 3, "lister", "password123", 1, "Hospital A")
+			token := loginToken(t, router, repo, staff, "password123")
+			repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+			repo.On("ListPatientNotes", uint(10), uint(3), tt.wantOffset, tt.wantPageSize).Return([]models.PatientNote{}, int64(0), nil)
+
+			rr := performRequest(router, "GET", "/api/v1/patient/10/notes"+tt.query, nil, token)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			var page models.PaginatedResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+			assert.Equal(t, tt.wantPageSize, page.PageSize)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConfigLoad_PageSizeValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		defaultSize string
+		maxSize     string
+		wantErr     bool
+	}{
+		{"valid", "20", "100", false},
+		{"zero default", "0", "100", true},
+		{"negative max", "20", "-1", true},
+		{"not a number", "twenty", "100", true},
+		{"default above max", "50", "10", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DEFAULT_PAGE_SIZE", tt.defaultSize)
+			t.Setenv("MAX_PAGE_SIZE", tt.maxSize)
+
+			cfg, err := config.Load()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 20, cfg.DefaultPageSize)
+			assert.Equal(t, 100, cfg.MaxPageSize)
+		})
+	}
+}
+
+// paginationLinks requests a page of patient 10's notes, whose total is 25, and returns the Link relations.
+func paginationLinks(t *testing.T, query string, offset, pageSize int) map[string]string {
+	t.Helper()
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "lister", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("ListPatientNotes", uint(10), uint(3), offset, pageSize).Return([]models.PatientNote{}, int64(25), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/notes"+query, nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	links := map[string]string{}
+	for _, link := range strings.Split(rr.Header().Get("Link"), ", ") {
+		target, rel, ok := strings.Cut(link, "; rel=")
+		if assert.True(t, ok, link) {
+			links[strings.Trim(rel, `"`)] = strings.Trim(target, "<>")
+		}
+	}
+	return links
+}
+
+func TestListEndpoints_LinkHeaderMiddlePage(t *testing.T) {
+	links := paginationLinks(t, "?page=2&page_size=10", 10, 10)
+
+	assert.Equal(t, map[string]string{
+		"first": "/api/v1/patient/10/notes?page=1&page_size=10",
+		"prev":  "/api/v1/patient/10/notes?page=1&page_size=10",
+		"next":  "/api/v1/patient/10/notes?page=3&page_size=10",
+		"last":  "/api/v1/patient/10/notes?page=3&page_size=10",
+	}, links)
+}
+
+func TestListEndpoints_LinkHeaderFirstPage(t *testing.T) {
+	links := paginationLinks(t, "?page_size=10", 0, 10)
+
+	assert.NotContains(t, links, "prev")
+	assert.Equal(t, "/api/v1/patient/10/notes?page=2&page_size=10", links["next"])
+	assert.Equal(t, "/api/v1/patient/10/notes?page=1&page_size=10", links["first"])
+	assert.Equal(t, "/api/v1/patient/10/notes?page=3&page_size=10", links["last"])
+}
+
+func TestListEndpoints_LinkHeaderLastPage(t *testing.T) {
+	links := paginationLinks(t, "?page=3&page_size=10", 20, 10)
+
+	assert.NotContains(t, links, "next")
+	assert.Equal(t, "/api/v1/patient/10/notes?page=2&page_size=10", links["prev"])
+	assert.Equal(t, "/api/v1/patient/10/notes?page=3&page_size=10", links["last"])
+}
+
+func TestListEndpoints_LinkHeaderKeepsQuery(t *testing.T) {
+	// Unrelated parameters survive, and a clamped page_size is replaced by the size actually used
+	cfg := *testConfig
+	cfg.MaxPageSize = 10
+	router, repo := newTestRouterWithConfig(&cfg)
+	staff := hashedStaff(t, 3, "lister", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("ListPatientNotes", uint(10), uint(3), 10, 10).Return([]models.PatientNote{}, int64(25), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/notes?page=2&page_size=500&sort=desc", nil, token)
+
+	assert.Contains(t, rr.Header().Get("Link"), `</api/v1/patient/10/notes?page=3&page_size=10&sort=desc>; rel="next"`)
+}
+
+func TestSearchPatientHandler_Paged(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatientsPage", mock.Anything, uint(1), 10, 10).Return([]models.Patient{{ID: 11, HospitalID: 1}}, int64(25), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test&page=2&page_size=10", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response struct {
+		Count     int   `json:"count"`
+		Truncated bool  `json:"truncated"`
+		Page      int   `json:"page"`
+		PageSize  int   `json:"page_size"`
+		Total     int64 `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Count)
+	assert.False(t, response.Truncated, "a page is never truncated")
+	assert.Equal(t, 2, response.Page)
+	assert.Equal(t, 10, response.PageSize)
+	assert.Equal(t, int64(25), response.Total)
+	assert.Contains(t, rr.Header().Get("Link"), `</api/v1/patient/search?first_name_en=Test&page=3&page_size=10>; rel="next"`)
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_UnpagedKeepsEnvelope(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Test", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data":[],"count":0,"truncated":false}`, rr.Body.String())
+	assert.Empty(t, rr.Header().Get("Link"))
+	repo.AssertNotCalled(t, "SearchPatientsPage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,182 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/utils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// strictPolicy enables every password rule.
+var strictPolicy = config.PasswordPolicy{
+	MinLength:          10,
+	RequireUppercase:   true,
+	RequireLowercase:   true,
+	RequireDigit:       true,
+	RequireSpecialChar: true,
+}
+
+// withPasswordPolicy makes the auth service enforce policy until the test ends.
+func withPasswordPolicy(t *testing.T, policy config.PasswordPolicy) {
+	cfg := *testConfig
+	cfg.PasswordPolicy = policy
+	assert.NoError(t, services.InitializeAuthService(&cfg))
+	t.Cleanup(func() { assert.NoError(t, services.InitializeAuthService(testConfig)) })
+}
+
+// --- ValidatePassword Tests ---
+
+func TestValidatePassword_EachRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    config.PasswordPolicy
+		password  string
+		violation string
+	}{
+		{"min length", config.PasswordPolicy{MinLength: 8}, "short", "must be at least 8 characters long"},
+		{"uppercase", config.PasswordPolicy{RequireUppercase: true}, "lowercase only", "must contain at least one uppercase letter"},
+		{"lowercase", config.PasswordPolicy{RequireLowercase: true}, "UPPERCASE ONLY", "must contain at least one lowercase letter"},
+		{"digit", config.PasswordPolicy{RequireDigit: true}, "NoDigitsHere", "must contain at least one digit"},
+		{"special char", config.PasswordPolicy{RequireSpecialChar: true}, "NoSpecial123", "must contain at least one special character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, []string{tt.violation}, utils.ValidatePassword(tt.password, tt.policy))
+		})
+	}
+}
+
+func TestValidatePassword_EachRuleSatisfied(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   config.PasswordPolicy
+		password string
+	}{
+		{"min length", config.PasswordPolicy{MinLength: 8}, "longenough"},
+		{"min length counts characters not bytes", config.PasswordPolicy{MinLength: 4}, "สวัสดี"},
+		{"uppercase", config.PasswordPolicy{RequireUppercase: true}, "hasUpper"},
+		{"lowercase", config.PasswordPolicy{RequireLowercase: true}, "HASlOWER"},
+		{"digit", config.PasswordPolicy{RequireDigit: true}, "has1digit"},
+		{"special char", config.PasswordPolicy{RequireSpecialChar: true}, "has-special"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Empty(t, utils.ValidatePassword(tt.password, tt.policy))
+		})
+	}
+}
+
+func TestValidatePassword_CombinedViolations(t *testing.T) {
+	violations := utils.ValidatePassword("abc", strictPolicy)
+
+	assert.Equal(t, []string{
+		"must be at least 10 characters long",
+		"must contain at least one uppercase letter",
+		"must contain at least one digit",
+		"must contain at least one special character",
+	}, violations)
+}
+
+func TestValidatePassword_CombinedSatisfied(t *testing.T) {
+	assert.Empty(t, utils.ValidatePassword("Str0ng!Passw0rd", strictPolicy))
+}
+
+func TestValidatePassword_ZeroPolicyAcceptsAnything(t *testing.T) {
+	assert.Empty(t, utils.ValidatePassword("x", config.PasswordPolicy{}))
+}
+
+// --- Password Policy Handler Tests ---
+
+func TestCreateStaffHandler_PasswordPolicyViolated(t *testing.T) {
+	withPasswordPolicy(t, strictPolicy)
+	router, repo := newTestRouter()
+
+	staffData := models.StaffCreateRequest{Username: "weakling", Password: "password", Hospital: "Hospital A"}
+	rr := performRequest(router, "POST", "/api/v1/staff/create", staffData, "")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var resp struct {
+		Code       string   `json:"code"`
+		Error      string   `json:"error"`
+		Violations []string `json:"violations"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "STAFF_004", resp.Code)
+	assert.Equal(t, "password policy violated", resp.Error)
+	assert.ElementsMatch(t, []string{
+		"must be at least 10 characters long",
+		"must contain at least one uppercase letter",
+		"must contain at least one digit",
+		"must contain at least one special character",
+	}, resp.Violations)
+	repo.AssertNotCalled(t, "CreateStaff", mock.Anything)
+}
+
+func TestChangePasswordHandler_Success(t *testing.T) {
+	withPasswordPolicy(t, strictPolicy)
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 6, "changer", "password123", 1, "Hospital A")
+	stored := *staff // Login clears the hash on the returned record
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("FindStaffByUsername", "changer").Return(&stored, nil).Once()
+	repo.On("UpdateStaffPassword", uint(6), mock.MatchedBy(func(hash string) bool {
+		return utils.CheckPasswordHash("Str0ng!Passw0rd", hash)
+	})).Return(nil)
+
+	body := models.StaffChangePasswordRequest{CurrentPassword: "password123", NewPassword: "Str0ng!Passw0rd"}
+	rr := performRequest(router, "POST", "/api/v1/staff/change-password", body, token)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestChangePasswordHandler_PasswordPolicyViolated(t *testing.T) {
+	withPasswordPolicy(t, strictPolicy)
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 6, "changer", "password123", 1, "Hospital A")
+	stored := *staff // Login clears the hash on the returned record
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("FindStaffByUsername", "changer").Return(&stored, nil).Once()
+
+	body := models.StaffChangePasswordRequest{CurrentPassword: "password123", NewPassword: "alllowercase!"}
+	rr := performRequest(router, "POST", "/api/v1/staff/change-password", body, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "password policy violated")
+	assert.Contains(t, rr.Body.String(), "must contain at least one uppercase letter")
+	assert.Contains(t, rr.Body.String(), "must contain at least one digit")
+	repo.AssertNotCalled(t, "UpdateStaffPassword", mock.Anything, mock.Anything)
+}
+
+func TestChangePasswordHandler_WrongCurrentPassword(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 6, "changer", "password123", 1, "Hospital A")
+	stored := *staff // Login clears the hash on the returned record
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("FindStaffByUsername", "changer").Return(&stored, nil).Once()
+
+	body := models.StaffChangePasswordRequest{CurrentPassword: "not-my-password", NewPassword: "Str0ng!Passw0rd"}
+	rr := performRequest(router, "POST", "/api/v1/staff/change-password", body, token)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Current password is incorrect")
+	repo.AssertNotCalled(t, "UpdateStaffPassword", mock.Anything, mock.Anything)
+}
+
+func TestChangePasswordHandler_Unauthorized(t *testing.T) {
+	router, _ := newTestRouter()
+
+	body := models.StaffChangePasswordRequest{CurrentPassword: "password123", NewPassword: "Str0ng!Passw0rd"}
+	rr := performRequest(router, "POST", "/api/v1/staff/change-password", body, "")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"errors"
+	"hospital-middleware/internal/api"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/test/mocks"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// newAccessLogRouter is newTestRouterWithConfig with the access log entries the router records
+// sent to the returned channel.
+func newAccessLogRouter(cfg *config.Config) (*gin.Engine, *mocks.MockPatientRepository, <-chan []models.PatientAccessLog) {
+	repo := new(mocks.MockPatientRepository)
+	repo.On("IsTokenRevoked", mock.Anything).Return(false, nil).Maybe()
+	repo.On("RecordSearch", mock.Anything).Return(nil).Maybe()
+	repo.On("RecordPatientView", mock.Anything).Return(nil).Maybe()
+	repo.On("RecordStaffLogin", mock.Anything, mock.Anything).Return(nil).Maybe()
+	recorded := make(chan []models.PatientAccessLog, 10)
+	repo.On("RecordPatientAccess", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		recorded <- args.Get(0).([]models.PatientAccessLog)
+	}).Maybe()
+	return api.SetupRouter(repo, testBlobs, cfg, services.NewAsyncWriters(repo)), repo, recorded
+}
+
+func nextAccessLog(t *testing.T, recorded <-chan []models.PatientAccessLog) []models.PatientAccessLog {
+	t.Helper()
+	select {
+	case entries := <-recorded:
+		return entries
+	case <-time.After(5 * time.Second):
+		t.Fatal("no access log entries were recorded")
+		return nil
+	}
+}
+
+func TestGetPatientHandler_RecordsAccess(t *testing.T) {
+	router, repo, recorded := newAccessLogRouter(testConfig)
+	token := loginToken(t, router, repo, hashedStaff(t, 9, "reader", "password123", 2, "Hospital B"), "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	entries := nextAccessLog(t, recorded)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, uint(9), entries[0].StaffID)
+		assert.Nil(t, entries[0].APIKeyID)
+		assert.Equal(t, uint(10), entries[0].PatientID)
+		assert.Equal(t, uint(2), entries[0].HospitalID)
+		assert.Equal(t, "GET /api/v1/patient/:id", entries[0].Endpoint)
+		assert.WithinDuration(t, time.Now(), entries[0].AccessedAt, time.Minute)
+	}
+}
+
+func TestSearchPatientHandler_RecordsAccessOfEachResult(t *testing.T) {
+	router, repo, recorded := newAccessLogRouter(testConfig)
+	token := loginToken(t, router, repo, hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B"), "password123")
+	repo.On("SearchPatients", mock.Anything, uint(2), searchLimit).Return([]models.Patient{{ID: 1, HospitalID: 2}, {ID: 4, HospitalID: 2}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	entries := nextAccessLog(t, recorded)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, []uint{1, 4}, []uint{entries[0].PatientID, entries[1].PatientID})
+		assert.Equal(t, "GET /api/v1/patient/search", entries[1].Endpoint)
+	}
+}
+
+func TestSearchPatientHandler_SearchAccessLoggingOff(t *testing.T) {
+	cfg := *testConfig
+	cfg.AccessLogSearchResults = false
+	router, repo, recorded := newAccessLogRouter(&cfg)
+	token := loginToken(t, router, repo, hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B"), "password123")
+	repo.On("SearchPatients", mock.Anything, uint(2), searchLimit).Return([]models.Patient{{ID: 1, HospitalID: 2}}, nil)
+	repo.On("GetPatientByID", uint(1)).Return(&models.Patient{ID: 1, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = performRequest(router, "GET", "/api/v1/patient/1", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	entries := nextAccessLog(t, recorded)
+	assert.Equal(t, "GET /api/v1/patient/:id", entries[0].Endpoint, "opening a patient is still logged")
+	assert.Empty(t, recorded)
+}
+
+func TestListPatientAccessLogHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientByID", uint(11)).Return(&models.Patient{ID: 11, HospitalID: 2}, nil)
+	repo.On("ListPatientAccessLog", uint(10), 20, 20).Return([]models.PatientAccessLog{{ID: 3, StaffID: 4, PatientID: 10, Endpoint: "GET /api/v1/patient/:id"}}, int64(21), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/admin/patient/10/access-log?page=2", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"endpoint":"GET /api/v1/patient/:id"`)
+	assert.Contains(t, rr.Body.String(), `"total":21`)
+
+	rr = performRequest(router, "GET", "/api/v1/admin/patient/11/access-log", nil, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "patients of other hospitals are not found")
+}
+
+func TestListStaffAccessLogHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("ListStaffAccessLog", uint(4), uint(1), 0, 20).Return(nil, int64(0), nil)
+	repo.On("ListStaffAccessLog", uint(5), uint(1), 0, 20).Return(nil, int64(0), gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "GET", "/api/v1/admin/staff/4/access-log", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"data":[]`)
+
+	rr = performRequest(router, "GET", "/api/v1/admin/staff/5/access-log", nil, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	router, repo = newTestRouter()
+	staffToken := importAdminToken(t, router, repo, models.RoleStaff)
+	rr = performRequest(router, "GET", "/api/v1/admin/staff/4/access-log", nil, staffToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestAccessLogger(t *testing.T) {
+	repo := new(mocks.MockPatientRepository)
+	logger := services.NewAccessLogger(repo)
+	entries := []models.PatientAccessLog{{StaffID: 1, PatientID: 2, Endpoint: "GET /api/v1/patient/:id"}}
+	repo.On("RecordPatientAccess", entries).Return(errors.New("connection refused")).Once()
+
+	logger.Record(nil)
+	logger.Record(entries)
+	logger.Wait()
+
+	repo.AssertNumberOfCalls(t, "RecordPatientAccess", 1)
+}
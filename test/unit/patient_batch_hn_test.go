@@ -0,0 +1,68 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatchHNSearchHandler_MapsMissingHNsToNull(t *testing.T) {
+	router, repo, recorded := newAccessLogRouter(testConfig)
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	hns := []string{"HN001", "HN002", "HN404"}
+	repo.On("GetPatientsByHNList", hns, uint(1)).Return([]models.Patient{
+		{ID: 2, HospitalID: 1, PatientHN: "HN002", FirstNameEN: "Malee"},
+		{ID: 1, HospitalID: 1, PatientHN: "HN001", FirstNameEN: "Somchai"},
+	}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/search/batch-hn", map[string]interface{}{"hospital_number_list": hns}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var raw map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+	assert.Len(t, raw, 3)
+	assert.Equal(t, "null", string(raw["HN404"]))
+	var results map[string]*models.Patient
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &results))
+	if assert.NotNil(t, results["HN001"]) && assert.NotNil(t, results["HN002"]) {
+		assert.Equal(t, "Somchai", results["HN001"].FirstNameEN)
+		assert.Equal(t, "Malee", results["HN002"].FirstNameEN)
+	}
+
+	entries := nextAccessLog(t, recorded)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "POST /api/v1/patient/search/batch-hn", entries[0].Endpoint)
+	}
+}
+
+func TestBatchHNSearchHandler_ValidatesList(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	tooMany := make([]string, models.MaxBatchHNs+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("HN%03d", i)
+	}
+
+	for name, body := range map[string]interface{}{
+		"missing":  map[string]interface{}{},
+		"empty":    map[string]interface{}{"hospital_number_list": []string{}},
+		"blank HN": map[string]interface{}{"hospital_number_list": []string{"HN001", ""}},
+		"too many": map[string]interface{}{"hospital_number_list": tooMany},
+	} {
+		rr := performRequest(router, "POST", "/api/v1/patient/search/batch-hn", body, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+	}
+	repo.AssertNotCalled(t, "GetPatientsByHNList", mock.Anything, mock.Anything)
+
+	repo.On("GetPatientsByHNList", tooMany[:models.MaxBatchHNs], uint(1)).Return(nil, nil)
+	rr := performRequest(router, "POST", "/api/v1/patient/search/batch-hn", map[string]interface{}{"hospital_number_list": tooMany[:models.MaxBatchHNs]}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = performRequest(router, "POST", "/api/v1/patient/search/batch-hn", map[string]interface{}{"hospital_number_list": []string{"HN001"}}, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
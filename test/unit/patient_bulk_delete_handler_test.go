@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeleteHospitalPatientsHandler_RequiresConfirm(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	for _, query := range []string{"", "?confirm=false", "?confirm=1"} {
+		rr := performRequest(router, "DELETE", "/api/v1/patient"+query, nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+	repo.AssertNotCalled(t, "SoftDeletePatientsByHospital", mock.Anything)
+}
+
+func TestDeleteHospitalPatientsHandler_DisabledInProduction(t *testing.T) {
+	cfg := *testConfig
+	cfg.AppEnv = config.AppEnvProduction
+	router, repo := newTestRouterWithConfig(&cfg)
+	token := importAdminToken(t, router, repo, models.RoleSuperAdmin)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient?confirm=true", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"HOSPITAL_005"`)
+	repo.AssertNotCalled(t, "SoftDeletePatientsByHospital", mock.Anything)
+}
+
+func TestDeleteHospitalPatientsHandler_DefaultsToOwnHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("SoftDeletePatientsByHospital", uint(1)).Return(int64(4), nil)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient?confirm=true", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"hospital_id":1,"deleted":4}`, rr.Body.String())
+}
+
+func TestDeleteHospitalPatientsHandler_OtherHospitalNeedsSuperAdmin(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient?confirm=true&hospital_id=2", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "SoftDeletePatientsByHospital", mock.Anything)
+
+	router, repo = newTestRouter()
+	token = importAdminToken(t, router, repo, models.RoleSuperAdmin)
+	repo.On("SoftDeletePatientsByHospital", uint(2)).Return(int64(0), nil)
+
+	rr = performRequest(router, "DELETE", "/api/v1/patient?confirm=true&hospital_id=2", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteHospitalPatientsHandler_RequiresAdmin(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient?confirm=true", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
@@ -0,0 +1,211 @@
+package unit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/models"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// samplePDF is the smallest content http.DetectContentType recognises as a PDF.
+var samplePDF = []byte("%PDF-1.4\n% consent form\n")
+
+// performUpload sends content as the multipart "file" field.
+func performUpload(router *gin.Engine, path, filename string, content []byte, token string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", filename)
+	part.Write(content)
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestUploadPatientDocumentHandler_StoresBlobAndMetadata(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "uploader", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	var saved *models.PatientDocument
+	repo.On("CreatePatientDocument", mock.AnythingOfType("*models.PatientDocument"), mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionDocumentUploaded && a.StaffID == 3
+	})).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*models.PatientDocument)
+		saved.ID = 77
+	}).Return(nil)
+
+	rr := performUpload(router, "/api/v1/patient/10/documents", "../consent.pdf", samplePDF, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	sum := sha256.Sum256(samplePDF)
+	var doc models.PatientDocument
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	assert.Equal(t, uint(77), doc.ID)
+	assert.Equal(t, "consent.pdf", doc.Filename, "Directory components are stripped from the filename")
+	assert.Equal(t, models.DocumentContentTypePDF, doc.ContentType)
+	assert.Equal(t, int64(len(samplePDF)), doc.SizeBytes)
+	assert.Equal(t, hex.EncodeToString(sum[:]), doc.SHA256)
+	assert.Equal(t, uint(3), doc.UploadedBy)
+	assert.Equal(t, uint(1), doc.HospitalID)
+	assert.NotContains(t, rr.Body.String(), saved.StorageKey, "Storage key must not leak")
+
+	stored, err := testBlobs.Get(saved.StorageKey)
+	if assert.NoError(t, err) {
+		defer stored.Close()
+		content, _ := io.ReadAll(stored)
+		assert.Equal(t, samplePDF, content)
+	}
+}
+
+func TestUploadPatientDocumentHandler_UnsupportedType(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "uploader", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	// A PDF extension does not help when the bytes are plain text
+	rr := performUpload(router, "/api/v1/patient/10/documents", "notes.pdf", []byte("just some text"), token)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	repo.AssertNotCalled(t, "CreatePatientDocument", mock.Anything, mock.Anything)
+}
+
+func TestUploadPatientDocumentHandler_TooLarge(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "uploader", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	content := append(append([]byte{}, samplePDF...), bytes.Repeat([]byte("x"), int(testConfig.DocumentMaxBytes))...)
+	rr := performUpload(router, "/api/v1/patient/10/documents", "big.pdf", content, token)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	repo.AssertNotCalled(t, "CreatePatientDocument", mock.Anything, mock.Anything)
+}
+
+func TestDownloadPatientDocumentHandler_SetsContentDisposition(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "reader", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	key := "patients/10/download-test"
+	_, err := testBlobs.Put(key, bytes.NewReader(samplePDF))
+	assert.NoError(t, err)
+	t.Cleanup(func() { testBlobs.Delete(key) })
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientDocument", uint(10), uint(5)).Return(&models.PatientDocument{
+		ID: 5, PatientID: 10, Filename: "consent form.pdf", ContentType: models.DocumentContentTypePDF,
+		SizeBytes: int64(len(samplePDF)), StorageKey: key,
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/documents/5/download", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, models.DocumentContentTypePDF, rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="consent form.pdf"`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, samplePDF, rr.Body.Bytes())
+}
+
+func TestDownloadPatientDocumentHandler_OtherHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "reader", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/documents/5/download", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "GetPatientDocument", mock.Anything, mock.Anything)
+}
+
+func TestDownloadPatientDocumentHandler_UnknownDocument(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "reader", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientDocument", uint(10), uint(5)).Return(nil, gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/documents/5/download", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Document not found")
+}
+
+func TestDeletePatientDocumentHandler_AdminOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient/10/documents/5", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "DeletePatientDocument", mock.Anything, mock.Anything)
+}
+
+func TestDeletePatientDocumentHandler_AdminRemovesBlob(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 4, "chief", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+
+	key := "patients/10/delete-test"
+	_, err := testBlobs.Put(key, strings.NewReader("blob"))
+	assert.NoError(t, err)
+
+	doc := &models.PatientDocument{ID: 5, PatientID: 10, Filename: "scan.png", StorageKey: key}
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientDocument", uint(10), uint(5)).Return(doc, nil)
+	repo.On("DeletePatientDocument", doc, mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionDocumentDeleted && a.StaffID == 4
+	})).Return(nil)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient/10/documents/5", nil, token)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	repo.AssertExpectations(t)
+	_, err = testBlobs.Get(key)
+	assert.Error(t, err, "Blob should be gone after deletion")
+}
+
+func TestLocalDiskStore_RejectsKeysOutsideRoot(t *testing.T) {
+	_, err := testBlobs.Put("../escape", strings.NewReader("nope"))
+	assert.Error(t, err)
+
+	_, err = testBlobs.Get("")
+	assert.Error(t, err)
+}
+
+func TestConfigLoad_DocumentStorageSettings(t *testing.T) {
+	t.Setenv("DOCUMENT_STORAGE_DIR", "/legacy/documents")
+	cfg, err := config.Load()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/legacy/documents", cfg.DocumentStorageDir, "the older variable still works on its own")
+		assert.Equal(t, int64(20<<20), cfg.DocumentMaxBytes)
+	}
+
+	t.Setenv("DOCUMENT_STORAGE_PATH", "/srv/documents")
+	cfg, err = config.Load()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/srv/documents", cfg.DocumentStorageDir, "DOCUMENT_STORAGE_PATH takes precedence")
+	}
+}
@@ -0,0 +1,272 @@
+package unit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const patientExportPath = "/api/v1/patient/export"
+
+// streamRecorder is a ResponseWriter that keeps only counts, recording how many lines had
+// been written each time the handler flushed.
+type streamRecorder struct {
+	header    http.Header
+	code      int
+	lines     int
+	flushedAt []int
+	onFlush   func()
+}
+
+func newStreamRecorder() *streamRecorder {
+	return &streamRecorder{header: http.Header{}, code: http.StatusOK}
+}
+
+func (r *streamRecorder) Header() http.Header  { return r.header }
+func (r *streamRecorder) WriteHeader(code int) { r.code = code }
+
+func (r *streamRecorder) Write(p []byte) (int, error) {
+	r.lines += bytes.Count(p, []byte("\n"))
+	return len(p), nil
+}
+
+func (r *streamRecorder) Flush() {
+	r.flushedAt = append(r.flushedAt, r.lines)
+	if r.onFlush != nil {
+		r.onFlush()
+	}
+}
+
+// manyPatients returns n patients of Hospital A with realistic field sizes.
+func manyPatients(n int) []models.Patient {
+	patients := make([]models.Patient, n)
+	for i := range patients {
+		patients[i] = models.Patient{
+			ID:          uint(i + 1),
+			HospitalID:  1,
+			PatientHN:   fmt.Sprintf("HN%06d", i),
+			FirstNameEN: "Somchai",
+			LastNameEN:  "Jaidii",
+			FirstNameTH: "สมชาย",
+			LastNameTH:  "ใจดี",
+			NationalID:  fmt.Sprintf("1%012d", i),
+			PhoneNumber: fmt.Sprintf("08%08d", i),
+			Email:       fmt.Sprintf("patient%d@example.com", i),
+		}
+	}
+	return patients
+}
+
+// exportRequest builds an authenticated export request with the given context.
+func exportRequest(ctx context.Context, query, token string) *http.Request {
+	req, _ := http.NewRequestWithContext(ctx, "GET", patientExportPath+query, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestExportPatientsHandler_NDJSON(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("StreamPatients", mock.Anything, mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.CreatedFrom != nil && *q.CreatedFrom == "2024-04-01"
+	}), uint(1), mock.Anything).Return(manyPatients(3), nil)
+
+	rr := performRequest(router, "GET", patientExportPath+"?format=ndjson&created_from=2024-04-01", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	scanner := bufio.NewScanner(rr.Body)
+	var ids []uint
+	for scanner.Scan() {
+		var patient models.Patient
+		if assert.NoError(t, json.Unmarshal(scanner.Bytes(), &patient), scanner.Text()) {
+			ids = append(ids, patient.ID)
+		}
+	}
+	assert.Equal(t, []uint{1, 2, 3}, ids)
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExportPatientsHandler_NDJSONEmpty(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("StreamPatients", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(nil, nil)
+
+	rr := performRequest(router, "GET", patientExportPath+"?format=ndjson", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestExportPatientsHandler_NDJSONErrorBeforeFirstRow(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("StreamPatients", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(nil, errors.New("connection refused"))
+
+	rr := performRequest(router, "GET", patientExportPath+"?format=ndjson", nil, token)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Database error during patient export")
+}
+
+func TestExportPatientsHandler_BirthYear(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.BirthYear != nil && *q.BirthYear == 1990
+	}), uint(1), 0).Return([]models.Patient{}, nil)
+
+	rr := performRequest(router, "GET", patientExportPath+"?birth_year=1990", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = performRequest(router, "GET", patientExportPath+"?birth_year=1899", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNumberOfCalls(t, "SearchPatients", 1)
+}
+
+func TestExportPatientsHandler_UnknownFormat(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	rr := performRequest(router, "GET", patientExportPath+"?format=xml", nil, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "StreamPatients", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExportPatientsHandler_NDJSONFlushesWhileStreaming(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("StreamPatients", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(manyPatients(1200), nil)
+
+	w := newStreamRecorder()
+	router.ServeHTTP(w, exportRequest(context.Background(), "?format=ndjson", token))
+
+	assert.Equal(t, http.StatusOK, w.code)
+	assert.Equal(t, 1200, w.lines)
+	// Rows reach the client in batches as they are read, not all at the end
+	assert.Equal(t, []int{500, 1000, 1200}, w.flushedAt)
+}
+
+func TestExportPatientsHandler_NDJSONStopsOnClientDisconnect(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	var streamErr error
+	repo.On("StreamPatients", mock.Anything, mock.Anything, uint(1), mock.Anything).
+		Run(func(args mock.Arguments) {
+			// Replay the cursor to capture the error the handler hands back to it
+			fn := args.Get(3).(func(*models.Patient) error)
+			for _, patient := range manyPatients(2000) {
+				patient := patient
+				if streamErr = fn(&patient); streamErr != nil {
+					return
+				}
+			}
+		}).Return(nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := newStreamRecorder()
+	w.onFlush = cancel // The client hangs up after the first batch
+	router.ServeHTTP(w, exportRequest(ctx, "?format=ndjson", token))
+
+	assert.ErrorIs(t, streamErr, context.Canceled, "the cursor must be told to stop")
+	assert.Equal(t, 500, w.lines)
+}
+
+func TestExportPatientsHandler_NDJSONMemoryStaysBounded(t *testing.T) {
+	if testing.Short() {
+		t.Skip("forces a GC per flush")
+	}
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	const rows = 20000
+	repo.On("StreamPatients", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(manyPatients(rows), nil)
+
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	baseline := stats.HeapAlloc
+	var peak uint64
+	w := newStreamRecorder()
+	w.onFlush = func() {
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > peak {
+			peak = stats.HeapAlloc
+		}
+	}
+	router.ServeHTTP(w, exportRequest(context.Background(), "?format=ndjson", token))
+
+	assert.Equal(t, rows, w.lines)
+	// The rows serialize to several megabytes; live memory must not grow with them
+	growth := int64(peak) - int64(baseline)
+	assert.Less(t, growth, int64(1<<20), "heap grew by %d bytes while streaming", growth)
+}
+
+func TestExportPatientsHandler_EnforceConsent(t *testing.T) {
+	for _, enforce := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enforce=%t", enforce), func(t *testing.T) {
+			cfg := *testConfig
+			cfg.EnforceConsentOnExport = enforce
+			router, repo := newTestRouterWithConfig(&cfg)
+			token := importAdminToken(t, router, repo, models.RoleAdmin)
+			repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+				return q.ExcludeConsentDenied == enforce
+			}), uint(1), 0).Return([]models.Patient{}, nil)
+			repo.On("StreamPatients", mock.Anything, mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+				return q.ExcludeConsentDenied == enforce
+			}), uint(1), mock.Anything).Return(nil, nil)
+
+			rr := performRequest(router, "GET", patientExportPath+"?created_from=2024-04-01", nil, token)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			rr = performRequest(router, "GET", patientExportPath+"?format=ndjson&created_from=2024-04-01", nil, token)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportPatientsHandler_ConsentCannotBeBypassed(t *testing.T) {
+	// The enforcement flag is not a query parameter, so clients cannot turn it off
+	cfg := *testConfig
+	cfg.EnforceConsentOnExport = true
+	router, repo := newTestRouterWithConfig(&cfg)
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.ExcludeConsentDenied && q.ConsentedOnly
+	}), uint(1), 0).Return([]models.Patient{}, nil)
+
+	rr := performRequest(router, "GET", patientExportPath+"?created_from=2024-04-01&consented_only=true&ExcludeConsentDenied=false", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestExportPatientsHandler_ContactPreferences(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	language, method := models.PreferredLanguageEnglish, models.ContactMethodEmail
+	patients := []models.Patient{{ID: 1, HospitalID: 1, PreferredLanguage: &language, PreferredContactMethod: &method, DoNotContact: true}}
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.DoNotContact != nil && *q.DoNotContact
+	}), uint(1), 0).Return(patients, nil)
+	repo.On("StreamPatients", mock.Anything, mock.Anything, uint(1), mock.Anything).Return(patients, nil)
+
+	for _, query := range []string{"?do_not_contact=true", "?format=ndjson"} {
+		rr := performRequest(router, "GET", patientExportPath+query, nil, token)
+
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), `"preferred_language":"en","preferred_contact_method":"email","do_not_contact":true`, query)
+	}
+}
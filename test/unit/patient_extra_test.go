@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/test/mocks"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// extraFieldsToken logs in a staff member of hospital 1, whose config permits the given extra field keys.
+func extraFieldsToken(t *testing.T, router *gin.Engine, repo *mocks.MockPatientRepository, keys ...string) string {
+	t.Helper()
+	cfg := models.DefaultHospitalConfig(1)
+	cfg.ExtraFieldKeys = keys
+	repo.On("GetHospitalConfig", uint(1)).Return(&cfg, nil) // Registered before login's default so it wins
+	return loginToken(t, router, repo, hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A"), "password123")
+}
+
+func TestUpdatePatientHandler_MergesExtraFields(t *testing.T) {
+	router, repo := newTestRouter()
+	token := extraFieldsToken(t, router, repo, "ward_code", "referral_source", "insurance_tier")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, Extra: models.ExtraFields{"ward_code": "W1", "insurance_tier": "gold"}}, nil)
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.MatchedBy(func(updates map[string]interface{}) bool {
+		extra, _ := updates["extra"].(models.ExtraFields)
+		return len(updates) == 1 && len(extra) == 2 && extra["ward_code"] == "W3" && extra["referral_source"] == "clinic"
+	}), mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Details == "fields=extra"
+	})).Return(nil)
+
+	// An empty value removes insurance_tier
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{"extra": gin.H{"ward_code": "W3", "referral_source": " clinic ", "insurance_tier": ""}}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var patient models.Patient
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &patient))
+	assert.Equal(t, models.ExtraFields{"ward_code": "W3", "referral_source": "clinic"}, patient.Extra)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdatePatientHandler_ExtraFieldErrors(t *testing.T) {
+	router, repo := newTestRouter()
+	token := extraFieldsToken(t, router, repo, "ward_code")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	tests := []struct {
+		name    string
+		extra   gin.H
+		wantErr string
+	}{
+		{"key not permitted", gin.H{"ward_code": "W3", "shoe_size": "42", "eye_color": "brown"}, "Extra fields not permitted for this hospital: eye_color, shoe_size"},
+		{"too large", gin.H{"ward_code": strings.Repeat("x", models.MaxExtraFieldsBytes)}, "at most 4096 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{"extra": tt.extra}, token)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.wantErr)
+		})
+	}
+	repo.AssertNotCalled(t, "UpdatePatientFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_ExtraFields(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("SearchPatients", &models.PatientSearchQuery{Extra: models.ExtraFields{"ward_code": "W3"}}, uint(1), searchLimit).
+		Return([]models.Patient{{ID: 10, HospitalID: 1, Extra: models.ExtraFields{"ward_code": "W3"}}}, nil)
+
+	// An extra field is enough of a criterion on its own
+	rr := performRequest(router, "GET", "/api/v1/patient/search?extra.ward_code=W3", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"extra":{"ward_code":"W3"}`)
+
+	rr = performRequest(router, "GET", "/api/v1/patient/search?extra.Ward-Code=W3", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNumberOfCalls(t, "SearchPatients", 1)
+}
+
+func TestUpdateHospitalConfigHandler_ExtraFieldKeys(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	body := gin.H{
+		"max_search_results":  0,
+		"hn_prefix":           "",
+		"hn_padding_length":   0,
+		"search_min_criteria": 1,
+		"two_factor_required": false,
+	}
+
+	body["extra_field_keys"] = []string{"ward_code", "Referral Source"}
+	rr := performRequest(router, "PUT", "/api/v1/admin/hospital/1/config", body, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Referral Source")
+	repo.AssertNotCalled(t, "SaveHospitalConfig", mock.Anything)
+
+	body["extra_field_keys"] = []string{"ward_code", "referral_source"}
+	repo.On("SaveHospitalConfig", mock.MatchedBy(func(cfg *models.HospitalConfig) bool {
+		return len(cfg.ExtraFieldKeys) == 2 && cfg.ExtraFieldKeys[1] == "referral_source"
+	})).Return(nil)
+	rr = performRequest(router, "PUT", "/api/v1/admin/hospital/1/config", body, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,337 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// sampleHistory is a legacy export in the mixed Thai and English layout hospitals send.
+const sampleHistory = "\ufeffประวัติผู้ป่วย\r\n" +
+	"HN: 000123\r\n" +
+	"ชื่อ: สมชาย\r\n" +
+	"นามสกุล : ใจดี\r\n" +
+	"First name: SOMCHAI\r\n" +
+	"Surname: Jaidee\r\n" +
+	"วันเกิด: 15/03/2533\r\n" +
+	"เลขบัตรประชาชน: 1-1037-00123-45-6\r\n" +
+	"Tel: 081-234-5678\r\n" +
+	"หมู่เลือด: o+\r\n" +
+	"ชื่อ: ไม่ใช่ชื่อนี้\r\n" +
+	"Notes: hypertension since 2015\r\n"
+
+// historyPatient is the record sampleHistory is compared against.
+func historyPatient() *models.Patient {
+	dob := time.Date(1990, 3, 15, 0, 0, 0, 0, time.UTC)
+	bloodType := "A+"
+	return &models.Patient{
+		ID:          10,
+		HospitalID:  1,
+		PatientHN:   "000123",
+		FirstNameTH: "สมชาย",
+		LastNameTH:  "ใจดีมาก",
+		FirstNameEN: "Somchai",
+		LastNameEN:  "Jaidee",
+		DateOfBirth: &dob,
+		NationalID:  "1103700123456",
+		BloodType:   &bloodType,
+	}
+}
+
+func TestParsePatientHistory(t *testing.T) {
+	values, err := services.ParsePatientHistory(strings.NewReader(sampleHistory))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		models.HistoryFieldPatientHN:   "000123",
+		models.HistoryFieldFirstNameTH: "สมชาย",
+		models.HistoryFieldLastNameTH:  "ใจดี",
+		models.HistoryFieldFirstNameEN: "SOMCHAI",
+		models.HistoryFieldLastNameEN:  "Jaidee",
+		models.HistoryFieldDateOfBirth: "15/03/2533",
+		models.HistoryFieldNationalID:  "1-1037-00123-45-6",
+		models.HistoryFieldPhoneNumber: "081-234-5678",
+		models.HistoryFieldBloodType:   "o+",
+	}, values, "the first ชื่อ line counts and unlabelled lines are ignored")
+}
+
+func TestParsePatientHistory_LabelVariants(t *testing.T) {
+	tests := []struct {
+		line  string
+		field string
+		value string
+	}{
+		{"DOB: 1990-03-15", models.HistoryFieldDateOfBirth, "1990-03-15"},
+		{"date of birth :  15/3/1990 ", models.HistoryFieldDateOfBirth, "15/3/1990"},
+		{"วันเดือนปีเกิด：15/03/2533", models.HistoryFieldDateOfBirth, "15/03/2533"},
+		{"  hn:HN-77", models.HistoryFieldPatientHN, "HN-77"},
+		{"Last name: Jaidee", models.HistoryFieldLastNameEN, "Jaidee"},
+		{"โทรศัพท์: 02 123 4567", models.HistoryFieldPhoneNumber, "02 123 4567"},
+		{"Blood group: AB-", models.HistoryFieldBloodType, "AB-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			values, err := services.ParsePatientHistory(strings.NewReader(tt.line))
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]string{tt.field: tt.value}, values)
+		})
+	}
+
+	values, err := services.ParsePatientHistory(strings.NewReader("HN:\nชื่อเล่น: ต้น\nHNX: 1\n"))
+	assert.NoError(t, err)
+	assert.Empty(t, values, "empty values and other labels are not fields")
+}
+
+func TestParsePatientHistory_RejectsBinary(t *testing.T) {
+	_, err := services.ParsePatientHistory(strings.NewReader("HN: 1\n\xff\xfe"))
+	assert.True(t, errors.Is(err, services.ErrHistoryImportNotText))
+
+	_, err = services.ParsePatientHistory(strings.NewReader("HN: 1\x00\n"))
+	assert.True(t, errors.Is(err, services.ErrHistoryImportNotText))
+}
+
+func TestDiffPatientHistory(t *testing.T) {
+	values, err := services.ParsePatientHistory(strings.NewReader(sampleHistory))
+	assert.NoError(t, err)
+
+	diff := services.DiffPatientHistory(historyPatient(), values, time.UTC)
+
+	assert.Equal(t, uint(10), diff.PatientID)
+	assert.Equal(t, []models.PatientHistoryValue{
+		{Field: models.HistoryFieldPatientHN, Value: "000123"},
+		{Field: models.HistoryFieldFirstNameTH, Value: "สมชาย"},
+		{Field: models.HistoryFieldFirstNameEN, Value: "Somchai"},
+		{Field: models.HistoryFieldLastNameEN, Value: "Jaidee"},
+		{Field: models.HistoryFieldDateOfBirth, Value: "1990-03-15"},
+		{Field: models.HistoryFieldNationalID, Value: "1103700123456"},
+	}, diff.Matched, "English names ignore case and the Buddhist Era date matches")
+	assert.Equal(t, []models.PatientHistoryChange{
+		{Field: models.HistoryFieldLastNameTH, Current: "ใจดีมาก", Imported: "ใจดี"},
+		{Field: models.HistoryFieldPhoneNumber, Current: "", Imported: "0812345678"},
+		{Field: models.HistoryFieldBloodType, Current: "A+", Imported: "O+"},
+	}, diff.Mismatched)
+	assert.Empty(t, diff.NotFound)
+	assert.Empty(t, diff.Unreadable)
+}
+
+func TestDiffPatientHistory_NotFoundAndUnreadable(t *testing.T) {
+	values := map[string]string{
+		models.HistoryFieldPatientHN:   "000123",
+		models.HistoryFieldDateOfBirth: "31/02/2533",
+		models.HistoryFieldBloodType:   "Z",
+		models.HistoryFieldNationalID:  "unknown",
+	}
+
+	diff := services.DiffPatientHistory(historyPatient(), values, time.UTC)
+
+	assert.Equal(t, []models.PatientHistoryValue{{Field: models.HistoryFieldPatientHN, Value: "000123"}}, diff.Matched)
+	assert.Empty(t, diff.Mismatched)
+	assert.Equal(t, []string{
+		models.HistoryFieldFirstNameTH, models.HistoryFieldLastNameTH, models.HistoryFieldFirstNameEN,
+		models.HistoryFieldLastNameEN, models.HistoryFieldPhoneNumber,
+	}, diff.NotFound)
+	assert.Equal(t, []models.PatientHistoryValue{
+		{Field: models.HistoryFieldDateOfBirth, Value: "31/02/2533"},
+		{Field: models.HistoryFieldNationalID, Value: "unknown"},
+		{Field: models.HistoryFieldBloodType, Value: "Z"},
+	}, diff.Unreadable)
+}
+
+func TestDiffPatientHistory_DateOfBirthInLocation(t *testing.T) {
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	assert.NoError(t, err)
+	patient := historyPatient()
+	dob := time.Date(1990, 3, 15, 0, 0, 0, 0, bangkok) // 14 March in UTC
+	patient.DateOfBirth = &dob
+
+	diff := services.DiffPatientHistory(patient, map[string]string{models.HistoryFieldDateOfBirth: "1990-03-15"}, bangkok)
+
+	assert.Equal(t, []models.PatientHistoryValue{{Field: models.HistoryFieldDateOfBirth, Value: "1990-03-15"}}, diff.Matched)
+}
+
+func TestPatientHistoryUpdates(t *testing.T) {
+	updates, err := services.PatientHistoryUpdates(historyPatient(), []models.PatientHistoryChange{
+		{Field: models.HistoryFieldLastNameTH, Current: "ใจดีมาก", Imported: "ใจดี"},
+		{Field: models.HistoryFieldDateOfBirth, Current: "1990-03-15", Imported: "16/03/2533"},
+		{Field: models.HistoryFieldBloodType, Current: "A+", Imported: "o+"},
+		{Field: models.HistoryFieldPhoneNumber, Current: "", Imported: "081-234-5678"},
+	}, time.UTC)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ใจดี", updates["last_name_th"])
+	assert.NotEmpty(t, updates["last_name_th_folded"])
+	assert.Equal(t, time.Date(1990, 3, 16, 0, 0, 0, 0, time.UTC), updates["date_of_birth"])
+	if bloodType, _ := updates["blood_type"].(*string); assert.NotNil(t, bloodType) {
+		assert.Equal(t, "O+", *bloodType)
+	}
+	assert.Equal(t, "0812345678", updates["phone_number"])
+	assert.Len(t, updates, 5)
+}
+
+func TestPatientHistoryUpdates_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []models.PatientHistoryChange
+		err     error
+	}{
+		{"HN", []models.PatientHistoryChange{{Field: models.HistoryFieldPatientHN, Current: "000123", Imported: "000124"}}, services.ErrHistoryChangeNotApplicable},
+		{"unknown field", []models.PatientHistoryChange{{Field: "allergies", Imported: "penicillin"}}, services.ErrHistoryChangeNotApplicable},
+		{"invalid value", []models.PatientHistoryChange{{Field: models.HistoryFieldBloodType, Current: "A+", Imported: "C"}}, services.ErrHistoryChangeInvalidValue},
+		{"stale", []models.PatientHistoryChange{{Field: models.HistoryFieldBloodType, Current: "B+", Imported: "O+"}}, services.ErrHistoryChangeStale},
+		{"duplicate", []models.PatientHistoryChange{
+			{Field: models.HistoryFieldBloodType, Current: "A+", Imported: "O+"},
+			{Field: models.HistoryFieldBloodType, Current: "A+", Imported: "O-"},
+		}, services.ErrHistoryChangeDuplicateField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := services.PatientHistoryUpdates(historyPatient(), tt.changes, time.UTC)
+			assert.True(t, errors.Is(err, tt.err), "got %v", err)
+		})
+	}
+}
+
+func TestImportPatientHistoryHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(historyPatient(), nil)
+
+	rr := performUpload(router, "/api/v1/patient/10/history/import", "history.TXT", []byte(sampleHistory), token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var diff models.PatientHistoryDiff
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &diff))
+	assert.Len(t, diff.Matched, 6)
+	assert.Len(t, diff.Mismatched, 3)
+	assert.Contains(t, rr.Body.String(), `"not_found":[]`)
+	repo.AssertNotCalled(t, "UpdatePatientFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportPatientHistoryHandler_ViewerSeesMaskedIdentifiers(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleViewer)
+	patient := historyPatient()
+	patient.PhoneNumber = "0899999999"
+	repo.On("GetPatientByID", uint(10)).Return(patient, nil)
+
+	rr := performUpload(router, "/api/v1/patient/10/history/import", "history.txt", []byte(sampleHistory), token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), "0899999999")
+	var diff models.PatientHistoryDiff
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &diff))
+	for _, matched := range diff.Matched {
+		assert.NotEqual(t, models.HistoryFieldNationalID, matched.Field, "a matching national ID would confirm a guess")
+	}
+	for _, mismatched := range diff.Mismatched {
+		switch mismatched.Field {
+		case models.HistoryFieldNationalID:
+			assert.Equal(t, "*********3456", mismatched.Current)
+		case models.HistoryFieldPhoneNumber:
+			assert.Equal(t, "******9999", mismatched.Current)
+		}
+	}
+}
+
+func TestApplyPatientHistoryHandler_ViewerConfirmsMaskedCurrent(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleViewer)
+	repo.On("GetPatientByID", uint(10)).Return(historyPatient(), nil)
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.MatchedBy(func(updates map[string]interface{}) bool {
+		return updates["national_id"] == "1103700123464"
+	}), mock.Anything).Return(nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10/history/import", gin.H{"changes": []gin.H{
+		{"field": "national_id", "current": "*********3456", "imported": "1103700123464"},
+	}}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), "1103700123456")
+	repo.AssertExpectations(t)
+}
+
+func TestImportPatientHistoryHandler_RejectsUpload(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(historyPatient(), nil)
+
+	rr := performUpload(router, "/api/v1/patient/10/history/import", "history.pdf", []byte(sampleHistory), token)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	rr = performUpload(router, "/api/v1/patient/10/history/import", "history.txt", samplePDF[:4], token)
+	assert.Equal(t, http.StatusOK, rr.Code, "ASCII content is text whatever it says")
+
+	rr = performUpload(router, "/api/v1/patient/10/history/import", "history.txt", []byte{0x89, 'P', 'N', 'G', 0}, token)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	rr = performUpload(router, "/api/v1/patient/10/history/import", "history.txt", make([]byte, models.HistoryImportMaxBytes+1), token)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestImportPatientHistoryHandler_OtherHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	patient := historyPatient()
+	patient.HospitalID = 2
+	repo.On("GetPatientByID", uint(10)).Return(patient, nil)
+
+	rr := performUpload(router, "/api/v1/patient/10/history/import", "history.txt", []byte(sampleHistory), token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestApplyPatientHistoryHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	updated := historyPatient()
+	updated.LastNameTH = "ใจดี"
+	repo.On("GetPatientByID", uint(10)).Return(historyPatient(), nil).Once()
+	repo.On("GetPatientByID", uint(10)).Return(updated, nil).Once()
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.MatchedBy(func(updates map[string]interface{}) bool {
+		return updates["last_name_th"] == "ใจดี" && updates["phone_number"] == "0812345678" && len(updates) == 3
+	}), mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionHistoryImported && a.StaffID == 1 && a.Details == "fields=last_name_th,phone_number"
+	})).Return(nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10/history/import", gin.H{"changes": []gin.H{
+		{"field": "phone_number", "current": "", "imported": "081-234-5678"},
+		{"field": "last_name_th", "current": "ใจดีมาก", "imported": "ใจดี"},
+	}}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"last_name_th":"ใจดี"`)
+	repo.AssertExpectations(t)
+}
+
+func TestApplyPatientHistoryHandler_Errors(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(historyPatient(), nil)
+
+	tests := []struct {
+		name string
+		body gin.H
+		code int
+	}{
+		{"no changes", gin.H{"changes": []gin.H{}}, http.StatusBadRequest},
+		{"missing imported value", gin.H{"changes": []gin.H{{"field": "blood_type"}}}, http.StatusBadRequest},
+		{"HN", gin.H{"changes": []gin.H{{"field": "patient_hn", "current": "000123", "imported": "9"}}}, http.StatusBadRequest},
+		{"stale", gin.H{"changes": []gin.H{{"field": "blood_type", "current": "B+", "imported": "O+"}}}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := performRequest(router, "PATCH", "/api/v1/patient/10/history/import", tt.body, token)
+			assert.Equal(t, tt.code, rr.Code, rr.Body.String())
+		})
+	}
+	repo.AssertNotCalled(t, "UpdatePatientFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestCreatePatientLabelHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("CreatePatientLabel", mock.MatchedBy(func(label *models.PatientLabel) bool {
+		return label.HospitalID == 1 && label.Name == "High Risk" && label.Color == "#ff0000"
+	})).Run(func(args mock.Arguments) { args.Get(0).(*models.PatientLabel).ID = 4 }).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/admin/label", gin.H{"name": "  High Risk ", "color": "#FF0000"}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var label models.PatientLabel
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &label))
+	assert.Equal(t, uint(4), label.ID)
+	assert.Equal(t, "High Risk", label.Name)
+	repo.AssertExpectations(t)
+}
+
+func TestCreatePatientLabelHandler_Rejected(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("CreatePatientLabel", mock.Anything).Return(&pgconn.PgError{Code: "23505"})
+
+	tests := []struct {
+		name string
+		body gin.H
+		code int
+	}{
+		{"color is not hex", gin.H{"name": "VIP", "color": "red"}, http.StatusBadRequest},
+		{"blank name", gin.H{"name": "   ", "color": "#ff0"}, http.StatusBadRequest},
+		{"name taken", gin.H{"name": "vip", "color": "#ff0"}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := performRequest(router, "POST", "/api/v1/admin/label", tt.body, token)
+			assert.Equal(t, tt.code, rr.Code, rr.Body.String())
+		})
+	}
+	repo.AssertNumberOfCalls(t, "CreatePatientLabel", 1)
+}
+
+func TestCreatePatientLabelHandler_RequiresAdmin(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "POST", "/api/v1/admin/label", gin.H{"name": "VIP", "color": "#ffd700"}, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "CreatePatientLabel", mock.Anything)
+}
+
+func TestAssignPatientLabelHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientLabel", uint(4), uint(1)).Return(&models.PatientLabel{ID: 4, HospitalID: 1, Name: "VIP"}, nil)
+	repo.On("AssignPatientLabel", uint(10), uint(4)).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/labels/4", nil, token)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	repo.AssertExpectations(t)
+}
+
+func TestAssignPatientLabelHandler_LabelOfAnotherHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	// Label 9 belongs to another hospital, so it is not found in hospital 1
+	repo.On("GetPatientLabel", uint(9), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/labels/9", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Label not found")
+	repo.AssertNotCalled(t, "AssignPatientLabel", mock.Anything, mock.Anything)
+}
+
+func TestUnassignPatientLabelHandler_NotAssigned(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("UnassignPatientLabel", uint(10), uint(4)).Return(gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient/10/labels/4", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Patient does not have this label")
+}
+
+func TestSearchPatientHandler_LabelFilter(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	labelID := uint(4)
+	repo.On("SearchPatients", &models.PatientSearchQuery{LabelID: &labelID}, uint(1), searchLimit).
+		Return([]models.Patient{{ID: 10, HospitalID: 1, Labels: []models.PatientLabel{{ID: 4, HospitalID: 1, Name: "VIP", Color: "#ffd700"}}}}, nil)
+
+	// A label is enough of a criterion on its own
+	rr := performRequest(router, "GET", "/api/v1/patient/search?label_id=4", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"labels":[{"id":4,"hospital_id":1,"name":"VIP","color":"#ffd700"`)
+	repo.AssertExpectations(t)
+}
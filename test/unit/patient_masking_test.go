@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func maskingTestPatient() models.Patient {
+	return models.Patient{
+		ID:          10,
+		HospitalID:  1,
+		FirstNameEN: "Somchai",
+		NationalID:  "1103700123456",
+		PassportID:  "AA1234567",
+		PhoneNumber: "0812345678",
+	}
+}
+
+func TestMaskAllButLast(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"1103700123456", "*********3456"},
+		{"0812345678", "******5678"},
+		{"1234", "****"},
+		{"12", "**"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, utils.MaskAllButLast(tt.value, 4), "value %q", tt.value)
+	}
+}
+
+func TestSearchPatientHandler_MaskingByRole(t *testing.T) {
+	tests := []struct {
+		role           string
+		wantNationalID string
+		wantPassportID string
+		wantPhone      string
+	}{
+		{models.RoleViewer, "*********3456", "*****4567", "******5678"},
+		{models.RoleStaff, "1103700123456", "AA1234567", "0812345678"},
+		{models.RoleAdmin, "1103700123456", "AA1234567", "0812345678"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			router, repo := newTestRouter()
+			staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+			staff.Role = tt.role
+			token := loginToken(t, router, repo, staff, "password123")
+			repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{maskingTestPatient()}, nil)
+
+			rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			var results []models.Patient
+			decodeSearchResponse(t, rr.Body.Bytes(), &results)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, tt.wantNationalID, results[0].NationalID)
+				assert.Equal(t, tt.wantPassportID, results[0].PassportID)
+				assert.Equal(t, tt.wantPhone, results[0].PhoneNumber)
+				assert.Equal(t, "Somchai", results[0].FirstNameEN, "Names are not masked")
+			}
+		})
+	}
+}
+
+func TestGetPatientHandler_ViewerGetsMaskedFields(t *testing.T) {
+	router, repo := newTestRouter()
+	viewer := hashedStaff(t, 9, "viewer", "password123", 1, "Hospital A")
+	viewer.Role = models.RoleViewer
+	token := loginToken(t, router, repo, viewer, "password123")
+	patient := maskingTestPatient()
+	repo.On("GetPatientByID", uint(10)).Return(&patient, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var detail models.PatientDetailResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &detail))
+	assert.Equal(t, "*********3456", detail.NationalID)
+	assert.Equal(t, "******5678", detail.PhoneNumber)
+	assert.NotContains(t, rr.Body.String(), "1103700123456")
+}
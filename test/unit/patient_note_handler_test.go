@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeletePatientNoteHandler_NonAuthorForbidden(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientNote", uint(10), uint(5)).Return(&models.PatientNote{ID: 5, PatientID: 10, AuthorID: 99}, nil)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient/10/notes/5", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "DeletePatientNote", mock.Anything, mock.Anything)
+}
+
+func TestDeletePatientNoteHandler_AdminAllowedAndAudited(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 4, "chief", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+
+	note := &models.PatientNote{ID: 5, PatientID: 10, AuthorID: 99}
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientNote", uint(10), uint(5)).Return(note, nil)
+	repo.On("DeletePatientNote", note, mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionNoteDeleted && a.StaffID == 4 && a.PatientID == 10
+	})).Return(nil)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient/10/notes/5", nil, token)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestListPatientAuditHandler_AdminOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/audit", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestListPatientNotesHandler_ScopedToViewer(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("ListPatientNotes", uint(10), uint(3), 0, 20).Return([]models.PatientNote{{ID: 5, PatientID: 10, AuthorID: 3, IsPrivate: true}}, int64(1), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/notes", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestDeletePatientNoteHandler_OthersPrivateNoteNotFound(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 4, "chief", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientNote", uint(10), uint(5)).Return(&models.PatientNote{ID: 5, PatientID: 10, AuthorID: 99, IsPrivate: true}, nil)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient/10/notes/5", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "DeletePatientNote", mock.Anything, mock.Anything)
+}
+
+func TestUpdatePatientNoteHandler_NonAuthorForbidden(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 4, "chief", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientNote", uint(10), uint(5)).Return(&models.PatientNote{ID: 5, PatientID: 10, AuthorID: 99}, nil)
+
+	rr := performRequest(router, "PUT", "/api/v1/patient/10/notes/5", map[string]string{"body": "edited"}, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "UpdatePatientNote", mock.Anything, mock.Anything)
+}
+
+func TestUpdatePatientNoteHandler_AuthorUpdatesAndAudits(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetPatientNote", uint(10), uint(5)).Return(&models.PatientNote{ID: 5, PatientID: 10, AuthorID: 3, Body: "old", Pinned: true}, nil)
+	repo.On("UpdatePatientNote", mock.MatchedBy(func(n *models.PatientNote) bool {
+		return n.Body == "new" && n.Pinned && n.IsPrivate // Pinned is untouched when omitted
+	}), mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionNoteUpdated && a.StaffID == 3
+	})).Return(nil)
+
+	rr := performRequest(router, "PUT", "/api/v1/patient/10/notes/5", map[string]interface{}{"body": " new ", "is_private": true}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestValidatePatientStatusTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{models.PatientStatusActive, models.PatientStatusInactive, true},
+		{models.PatientStatusActive, models.PatientStatusDeceased, true},
+		{models.PatientStatusActive, models.PatientStatusTransferred, true},
+		{"", models.PatientStatusDeceased, true}, // Legacy rows count as active
+		{models.PatientStatusInactive, models.PatientStatusActive, true},
+		{models.PatientStatusTransferred, models.PatientStatusActive, true},
+		{models.PatientStatusActive, models.PatientStatusActive, false},
+		{models.PatientStatusDeceased, models.PatientStatusActive, false},
+		{models.PatientStatusDeceased, models.PatientStatusInactive, false},
+		{models.PatientStatusDeceased, models.PatientStatusTransferred, false},
+		{models.PatientStatusTransferred, models.PatientStatusDeceased, false},
+	}
+	for _, tt := range tests {
+		err := services.ValidatePatientStatusTransition(tt.from, tt.to)
+		if tt.allowed {
+			assert.NoError(t, err, "%q -> %q", tt.from, tt.to)
+		} else {
+			assert.ErrorIs(t, err, services.ErrInvalidPatientStatusTransition, "%q -> %q", tt.from, tt.to)
+		}
+	}
+}
+
+func TestUpdatePatientStatusHandler_Deceased(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, Status: models.PatientStatusActive}, nil)
+	diedAt := time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)
+	repo.On("UpdatePatientStatus", mock.MatchedBy(func(p *models.Patient) bool {
+		return p.Status == models.PatientStatusDeceased && p.Deceased && p.DeceasedAt != nil && p.DeceasedAt.Equal(diedAt)
+	}), models.PatientStatusActive, mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionPatientStatusChanged && a.StaffID == 3 && a.Details == "from=active to=deceased"
+	})).Return(nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10/status", gin.H{"status": "deceased", "deceased_at": diedAt}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var patient models.Patient
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &patient))
+	assert.Equal(t, models.PatientStatusDeceased, patient.Status)
+	assert.True(t, patient.Deceased)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdatePatientStatusHandler_DeceasedBeforeBirth(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	dob := time.Date(1990, 5, 15, 0, 0, 0, 0, time.UTC)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, Status: models.PatientStatusActive, DateOfBirth: &dob}, nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10/status", gin.H{"status": "deceased", "deceased_at": dob.Add(-time.Hour)}, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "date_of_birth")
+	repo.AssertNotCalled(t, "UpdatePatientStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdatePatientStatusHandler_InvalidTransition(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	deceasedAt := time.Now().Add(-time.Hour)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, Status: models.PatientStatusDeceased, DeceasedAt: &deceasedAt}, nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10/status", gin.H{"status": "active"}, token)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), "deceased to active")
+	repo.AssertNotCalled(t, "UpdatePatientStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdatePatientStatusHandler_BadRequests(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	for name, body := range map[string]gin.H{
+		"unknown status":               {"status": "missing"},
+		"deceased_at without deceased": {"status": "inactive", "deceased_at": time.Now().Add(-time.Hour)},
+		"deceased_at in the future":    {"status": "deceased", "deceased_at": time.Now().Add(time.Hour)},
+	} {
+		rr := performRequest(router, "PATCH", "/api/v1/patient/10/status", body, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+	}
+	repo.AssertNotCalled(t, "UpdatePatientStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdatePatientStatusHandler_OtherHospitalPatient(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2, Status: models.PatientStatusActive}, nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10/status", gin.H{"status": "inactive"}, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestSearchPatientHandler_IncludeInactive(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.IncludeInactive && q.CriteriaCount() == 1
+	}), uint(1), mock.Anything).Return([]models.Patient{{ID: 1, HospitalID: 1, Status: models.PatientStatusDeceased}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?patient_hn=HN001&include_inactive=true", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchPatientHandler_ExcludeDeceased(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.IncludeInactive && q.ExcludeDeceased && q.CriteriaCount() == 1
+	}), uint(1), mock.Anything).Return([]models.Patient{{ID: 1, HospitalID: 1, Status: models.PatientStatusTransferred}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?patient_hn=HN001&include_inactive=true&exclude_deceased=true", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"deceased":false`)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,171 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/test/mocks"
+	"io"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ledongthuc/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// summaryTestFont returns the DejaVu Sans bundled with gofpdf, a TrueType font that is always
+// at hand when the module builds. It has no Thai glyphs, which only affects how Thai text looks.
+func summaryTestFont(t *testing.T) string {
+	t.Helper()
+	dir, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "github.com/jung-kurt/gofpdf").Output()
+	if err != nil {
+		t.Skipf("gofpdf module directory not found: %v", err)
+	}
+	return filepath.Join(strings.TrimSpace(string(dir)), "font", "DejaVuSansCondensed.ttf")
+}
+
+// newSummaryRouter builds a test router that renders summaries with the given timeout.
+func newSummaryRouter(t *testing.T, timeout time.Duration) (*gin.Engine, *mocks.MockPatientRepository) {
+	cfg := *testConfig
+	cfg.SummaryFontPath = summaryTestFont(t)
+	cfg.SummaryTimeout = timeout
+	return newTestRouterWithConfig(&cfg)
+}
+
+// pdfText returns the page count and the text of a PDF.
+func pdfText(t *testing.T, body []byte) (int, string) {
+	t.Helper()
+	reader, err := pdf.NewReader(bytes.NewReader(body), int64(len(body)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	plain, err := reader.GetPlainText()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	text, err := io.ReadAll(plain)
+	assert.NoError(t, err)
+	return reader.NumPage(), string(text)
+}
+
+func summaryPatient() *models.Patient {
+	dob := time.Date(1985, 7, 1, 0, 0, 0, 0, time.UTC)
+	return &models.Patient{
+		ID: 10, HospitalID: 1, PatientHN: "HN-1001",
+		FirstNameTH: "สมหญิง", LastNameTH: "รักดี", FirstNameEN: "Somying", LastNameEN: "Rakdee",
+		DateOfBirth: &dob, NationalID: "1103700123456", Gender: "F",
+	}
+}
+
+func TestGetPatientSummaryHandler_RendersPDF(t *testing.T) {
+	router, repo := newSummaryRouter(t, 10*time.Second)
+	repo.On("GetPatientByID", uint(10)).Return(summaryPatient(), nil)
+	repo.On("ListAllergiesByPatient", uint(10)).Return([]models.Allergy{
+		{Substance: "Penicillin", Severity: "severe", Reaction: "Anaphylaxis"},
+	}, nil)
+	visits := make([]models.Visit, services.SummaryMaxVisits)
+	for i := range visits {
+		visits[i] = models.Visit{VisitNumber: "V-" + string(rune('A'+i)), AdmittedAt: time.Date(2025, 1, 20-i, 9, 0, 0, 0, time.UTC), Department: "OPD"}
+	}
+	repo.On("ListVisitsByPatient", uint(10), uint(1), 0, services.SummaryMaxVisits).Return(visits, int64(12), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/summary.pdf", nil, importAdminToken(t, router, repo, models.RoleStaff))
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `inline; filename=patient_HN-1001_summary.pdf`, rr.Header().Get("Content-Disposition"))
+	assert.Contains(t, rr.Body.String(), "/FontFile2", "the TrueType font is embedded")
+
+	pages, text := pdfText(t, rr.Body.Bytes())
+	assert.Equal(t, 1, pages)
+	for _, want := range []string{"HN-1001", "Somying Rakdee", "1985-07-01", "1103700123456", "Penicillin (severe): Anaphylaxis", "2025-01-20", "V-J", "and 2 earlier"} {
+		assert.Contains(t, text, want)
+	}
+}
+
+func TestGetPatientSummaryHandler_MasksForViewers(t *testing.T) {
+	router, repo := newSummaryRouter(t, 10*time.Second)
+	repo.On("GetPatientByID", uint(10)).Return(summaryPatient(), nil)
+	repo.On("ListAllergiesByPatient", uint(10)).Return(nil, nil)
+	repo.On("ListVisitsByPatient", uint(10), uint(1), 0, services.SummaryMaxVisits).Return(nil, int64(0), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/summary.pdf", nil, importAdminToken(t, router, repo, models.RoleViewer))
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	_, text := pdfText(t, rr.Body.Bytes())
+	assert.NotContains(t, text, "1103700123456")
+	assert.Contains(t, text, "3456")
+	assert.Contains(t, text, "No known allergies recorded")
+	assert.Contains(t, text, "No visits recorded")
+}
+
+func TestGetPatientSummaryHandler_Timeout(t *testing.T) {
+	router, repo := newSummaryRouter(t, time.Nanosecond)
+	repo.On("GetPatientByID", uint(10)).Return(summaryPatient(), nil)
+	repo.On("ListAllergiesByPatient", uint(10)).Return(nil, nil)
+	repo.On("ListVisitsByPatient", uint(10), uint(1), 0, services.SummaryMaxVisits).Return(nil, int64(0), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/summary.pdf", nil, importAdminToken(t, router, repo, models.RoleStaff))
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code, rr.Body.String())
+}
+
+func TestGetPatientSummaryHandler_NotConfigured(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/summary.pdf", nil, token)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	repo.AssertNotCalled(t, "GetPatientByID", mock.Anything)
+}
+
+func TestSummaryRenderer(t *testing.T) {
+	_, err := services.NewSummaryRenderer(filepath.Join(t.TempDir(), "missing.ttf"))
+	assert.Error(t, err)
+
+	renderer, err := services.NewSummaryRenderer(summaryTestFont(t))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = renderer.Render(ctx, services.PatientSummary{Patient: *summaryPatient()})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	body, err := renderer.Render(context.Background(), services.PatientSummary{Patient: *summaryPatient(), GeneratedBy: "doctor"})
+	if assert.NoError(t, err) {
+		pages, text := pdfText(t, body)
+		assert.Equal(t, 1, pages)
+		assert.Contains(t, text, "HN-1001")
+		assert.Contains(t, text, "by doctor")
+	}
+}
+
+func TestConfigLoad_SummarySettings(t *testing.T) {
+	cfg, err := config.Load()
+	if assert.NoError(t, err) {
+		assert.Empty(t, cfg.SummaryFontPath, "summaries are off by default")
+		assert.Equal(t, 10*time.Second, cfg.SummaryTimeout)
+	}
+
+	t.Setenv("SUMMARY_FONT_PATH", "/usr/share/fonts/Sarabun-Regular.ttf")
+	t.Setenv("SUMMARY_TIMEOUT_SECONDS", "3")
+	cfg, err = config.Load()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/usr/share/fonts/Sarabun-Regular.ttf", cfg.SummaryFontPath)
+		assert.Equal(t, 3*time.Second, cfg.SummaryTimeout)
+	}
+
+	t.Setenv("SUMMARY_TIMEOUT_SECONDS", "0")
+	_, err = config.Load()
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"hospital-middleware/internal/models"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestNormalizeTagName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Diabetes Program", "diabetes-program"},
+		{"  vaccination_due!! ", "vaccination-due"},
+		{"COVID-19 -- Booster", "covid-19-booster"},
+		{"เบาหวาน กลุ่ม 2", "เบาหวาน-กลุ่ม-2"},
+		{"--- ", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, models.NormalizeTagName(tt.in), tt.in)
+	}
+}
+
+func TestAddPatientTagHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("AddPatientTag", uint(10), uint(1), "diabetes-program").Return(&models.Tag{ID: 7, HospitalID: 1, Name: "diabetes-program"}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/tags", gin.H{"name": " Diabetes Program "}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"name":"diabetes-program"`)
+	repo.AssertExpectations(t)
+}
+
+func TestAddPatientTagHandler_InvalidName(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	for _, name := range []string{"", " ?! ", strings.Repeat("a", models.MaxTagNameLength+1)} {
+		rr := performRequest(router, "POST", "/api/v1/patient/10/tags", gin.H{"name": name}, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+	}
+	repo.AssertNotCalled(t, "AddPatientTag", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddPatientTagHandler_PatientOfAnotherHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/tags", gin.H{"name": "vip"}, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "AddPatientTag", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRemovePatientTagHandler_NotTagged(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("RemovePatientTag", uint(10), uint(1), "vaccination-due").Return(gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "DELETE", "/api/v1/patient/10/tags/Vaccination_Due", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Patient does not have this tag")
+}
+
+func TestListTagsHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("ListTagCounts", uint(1)).Return([]models.TagCount{{ID: 7, Name: "diabetes-program", PatientCount: 3}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/tags", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"data":[{"id":7,"name":"diabetes-program","patient_count":3}],"count":1}`, rr.Body.String())
+}
+
+func TestDeleteTagHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "DELETE", "/api/v1/admin/tag/vip", nil, token)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	router, repo = newTestRouter()
+	token = importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("DeleteTag", uint(1), "vip").Return(nil)
+	repo.On("DeleteTag", uint(1), "unknown").Return(gorm.ErrRecordNotFound)
+
+	rr = performRequest(router, "DELETE", "/api/v1/admin/tag/VIP", nil, token)
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	rr = performRequest(router, "DELETE", "/api/v1/admin/tag/unknown", nil, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchPatientHandler_TagFilter(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("SearchPatients", &models.PatientSearchQuery{Tags: []string{"diabetes-program", "vaccination-due"}}, uint(1), searchLimit).
+		Return([]models.Patient{{ID: 10, HospitalID: 1, Tags: []models.Tag{{ID: 7, HospitalID: 1, Name: "diabetes-program"}}}}, nil)
+
+	// Tags are normalized, and are enough of a criterion on their own
+	rr := performRequest(router, "GET", "/api/v1/patient/search?tag=Diabetes+Program&tag=vaccination_due", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"tags":[{"id":7,"hospital_id":1,"name":"diabetes-program"`)
+
+	rr = performRequest(router, "GET", "/api/v1/patient/search?tag=---", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNumberOfCalls(t, "SearchPatients", 1)
+}
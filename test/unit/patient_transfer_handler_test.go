@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTransferPatientHandler_SuperAdminMovesPatient(t *testing.T) {
+	router, repo := newTestRouter()
+	superAdmin := hashedStaff(t, 1, "central", "password123", 1, "Hospital A")
+	superAdmin.Role = models.RoleSuperAdmin
+	token := loginToken(t, router, repo, superAdmin, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetHospitalByID", uint(2)).Return(&models.Hospital{ID: 2, Name: "Hospital B"}, nil)
+	repo.On("TransferPatient", mock.AnythingOfType("*models.Patient"), uint(2), mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionPatientTransferred && a.HospitalID == 2 && a.StaffID == 1 &&
+			a.Details == "from_hospital_id=1 to_hospital_id=2"
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Patient).HospitalID = 2
+	}).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/transfer", models.PatientTransferRequest{TargetHospitalID: 2}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var patient models.Patient
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &patient))
+	assert.Equal(t, uint(2), patient.HospitalID)
+	repo.AssertExpectations(t)
+}
+
+func TestTransferPatientHandler_HospitalAdminCannotTransferOut(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 2, "admin", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/transfer", models.PatientTransferRequest{TargetHospitalID: 2}, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "TransferPatient", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferPatientHandler_HospitalAdminCannotSeeOtherHospitalsPatient(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := hashedStaff(t, 2, "admin", "password123", 1, "Hospital A")
+	admin.Role = models.RoleAdmin
+	token := loginToken(t, router, repo, admin, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/transfer", models.PatientTransferRequest{TargetHospitalID: 1}, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "TransferPatient", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferPatientHandler_RejectsSameHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	superAdmin := hashedStaff(t, 1, "central", "password123", 1, "Hospital A")
+	superAdmin.Role = models.RoleSuperAdmin
+	token := loginToken(t, router, repo, superAdmin, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/transfer", models.PatientTransferRequest{TargetHospitalID: 2}, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "TransferPatient", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferPatientHandler_UnknownTargetHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	superAdmin := hashedStaff(t, 1, "central", "password123", 1, "Hospital A")
+	superAdmin.Role = models.RoleSuperAdmin
+	token := loginToken(t, router, repo, superAdmin, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetHospitalByID", uint(99)).Return(nil, fmt.Errorf("%w: id 99", database.ErrHospitalNotFound))
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/transfer", models.PatientTransferRequest{TargetHospitalID: 99}, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Target hospital not found")
+	repo.AssertNotCalled(t, "TransferPatient", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferPatientHandler_RequiresAdmin(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/transfer", models.PatientTransferRequest{TargetHospitalID: 2}, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
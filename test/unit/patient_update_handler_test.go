@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/apperror"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUpdatePatientHandler_SetsDemographics(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	nationality := "Lao"
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, Nationality: &nationality}, nil)
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.MatchedBy(func(updates map[string]interface{}) bool {
+		bloodType, _ := updates["blood_type"].(*string)
+		cleared, hasNationality := updates["nationality"].(*string)
+		_, hasMaritalStatus := updates["marital_status"]
+		return bloodType != nil && *bloodType == "AB-" && hasNationality && cleared == nil && !hasMaritalStatus
+	}), mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Action == models.AuditActionPatientUpdated && a.StaffID == 3 && a.Details == "fields=blood_type,nationality"
+	})).Return(nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{"blood_type": "AB-", "nationality": ""}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var patient models.Patient
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &patient))
+	if assert.NotNil(t, patient.BloodType) {
+		assert.Equal(t, "AB-", *patient.BloodType)
+	}
+	assert.Nil(t, patient.Nationality)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdatePatientHandler_FieldErrors(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{"blood_type": "C+"}, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var body apperror.ErrorResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, apperror.CodeInvalidBody, body.Code)
+	assert.Equal(t, "Invalid request body", body.Message)
+	assert.Equal(t, []apperror.FieldError{{Field: "blood_type", Message: "must be one of: A+, A-, B+, B-, AB+, AB-, O+, O-, unknown"}}, body.Details)
+
+	rr = performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{}, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "No fields to update")
+	repo.AssertNotCalled(t, "UpdatePatientFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_BloodTypeAndNationality(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.BloodType != nil && *q.BloodType == "O+" && q.Nationality != nil && *q.Nationality == "Thai" && q.CriteriaCount() == 2
+	}), uint(1), mock.Anything).Return([]models.Patient{{ID: 1, HospitalID: 1}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?blood_type=O%2B&nationality=Thai", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = performRequest(router, "GET", "/api/v1/patient/search?blood_type=Z", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNumberOfCalls(t, "SearchPatients", 1)
+}
+
+func TestUpdatePatientHandler_SetsContactPreferences(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.MatchedBy(func(updates map[string]interface{}) bool {
+		language, _ := updates["preferred_language"].(*string)
+		method, _ := updates["preferred_contact_method"].(*string)
+		return language != nil && *language == models.PreferredLanguageThai && method != nil && *method == models.ContactMethodSMS &&
+			updates["do_not_contact"] == true
+	}), mock.MatchedBy(func(a *models.AuditLog) bool {
+		return a.Details == "fields=preferred_language,preferred_contact_method,do_not_contact"
+	})).Return(nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{"preferred_language": "th", "preferred_contact_method": "sms", "do_not_contact": true}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"preferred_language":"th","preferred_contact_method":"sms","do_not_contact":true`)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdatePatientHandler_ContactPreferenceErrors(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	tests := []struct {
+		name    string
+		body    gin.H
+		details []apperror.FieldError
+	}{
+		{"language", gin.H{"preferred_language": "fr"}, []apperror.FieldError{{Field: "preferred_language", Message: "must be one of: th, en"}}},
+		{"language in upper case", gin.H{"preferred_language": "TH"}, []apperror.FieldError{{Field: "preferred_language", Message: "must be one of: th, en"}}},
+		{"contact method", gin.H{"preferred_contact_method": "fax"}, []apperror.FieldError{{Field: "preferred_contact_method", Message: "must be one of: phone, sms, email, none"}}},
+		{"both", gin.H{"preferred_language": "jp", "preferred_contact_method": "line"}, []apperror.FieldError{
+			{Field: "preferred_contact_method", Message: "must be one of: phone, sms, email, none"},
+			{Field: "preferred_language", Message: "must be one of: th, en"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := performRequest(router, "PATCH", "/api/v1/patient/10", tt.body, token)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			var body apperror.ErrorResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, "Invalid request body", body.Message)
+			assert.Equal(t, tt.details, body.Details)
+		})
+	}
+	repo.AssertNotCalled(t, "UpdatePatientFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchPatientHandler_ContactPreferences(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.PreferredLanguage != nil && *q.PreferredLanguage == "en" && q.DoNotContact != nil && !*q.DoNotContact && q.CriteriaCount() == 1
+	}), uint(1), mock.Anything).Return([]models.Patient{{ID: 1, HospitalID: 1}}, nil)
+
+	// preferred_language counts as a criterion; do_not_contact only narrows
+	rr := performRequest(router, "GET", "/api/v1/patient/search?preferred_language=en&do_not_contact=false", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = performRequest(router, "GET", "/api/v1/patient/search?preferred_language=de", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = performRequest(router, "GET", "/api/v1/patient/search?do_not_contact=false", nil, token)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "do_not_contact alone is not enough criteria")
+	repo.AssertNumberOfCalls(t, "SearchPatients", 1)
+}
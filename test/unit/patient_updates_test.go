@@ -0,0 +1,106 @@
+package unit
+
+import (
+	"bufio"
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// subscribeToPatientUpdates opens the update stream on server and sends each event's data on the
+// returned channel. The stream is closed when the test ends.
+func subscribeToPatientUpdates(t *testing.T, server *httptest.Server, token string) <-chan string {
+	t.Helper()
+	req, err := http.NewRequest("GET", server.URL+"/api/v1/patient/updates", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	// Returns once the headers are flushed, which is after the subscription is registered
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan string, 8)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data:"); ok {
+				events <- data
+			}
+		}
+	}()
+	return events
+}
+
+func TestPatientUpdatesHandler_ReceivesStatusChange(t *testing.T) {
+	router, repo := newTestRouter()
+	server := httptest.NewServer(router)
+	// Registered first so it runs after the stream is closed; Close waits for open connections
+	t.Cleanup(server.Close)
+	nurse := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, nurse, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, Status: models.PatientStatusActive}, nil)
+	repo.On("UpdatePatientStatus", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	events := subscribeToPatientUpdates(t, server, token)
+
+	changed := make(chan int, 1)
+	go func() {
+		rr := performRequest(router, "PATCH", "/api/v1/patient/10/status", gin.H{"status": "inactive"}, token)
+		changed <- rr.Code
+	}()
+
+	select {
+	case data := <-events:
+		var update models.PatientUpdate
+		assert.NoError(t, json.Unmarshal([]byte(data), &update))
+		assert.Equal(t, models.PatientUpdate{Operation: models.PatientUpdateUpdated, PatientID: 10, HospitalID: 1}, update)
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("no patient update received within 500ms")
+	}
+	assert.Equal(t, http.StatusOK, <-changed)
+}
+
+func TestPatientUpdates_OnlyOwnHospital(t *testing.T) {
+	hospitalA, unsubscribeA := services.SubscribePatientUpdates(1)
+	defer unsubscribeA()
+	hospitalB, unsubscribeB := services.SubscribePatientUpdates(2)
+	defer unsubscribeB()
+
+	services.PublishPatientUpdate(models.PatientUpdate{Operation: models.PatientUpdateDeleted, PatientID: 7, HospitalID: 2})
+
+	select {
+	case update := <-hospitalB:
+		assert.Equal(t, uint(7), update.PatientID)
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("hospital 2 did not receive its update")
+	}
+	select {
+	case update := <-hospitalA:
+		t.Fatalf("hospital 1 received another hospital's update: %+v", update)
+	default:
+	}
+}
+
+func TestPatientUpdates_UnsubscribeClosesChannel(t *testing.T) {
+	updates, unsubscribe := services.SubscribePatientUpdates(1)
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+
+	_, open := <-updates
+	assert.False(t, open)
+	// Publishing with no subscribers left must not block or panic
+	services.PublishPatientUpdate(models.PatientUpdate{Operation: models.PatientUpdateUpdated, PatientID: 1, HospitalID: 1})
+}
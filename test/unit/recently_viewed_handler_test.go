@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetPatientHandler_RecordsView(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertCalled(t, "RecordPatientView", mock.MatchedBy(func(view *models.RecentlyViewed) bool {
+		return view.StaffID == 3 && view.PatientID == 10 && view.HospitalID == 1 && time.Since(view.ViewedAt) < time.Minute
+	}))
+}
+
+func TestGetPatientHandler_OtherHospitalNotRecorded(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "RecordPatientView", mock.Anything)
+}
+
+func TestGetPatientHandler_APIKeyViewNotRecorded(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindAPIKeyByPrefix", "a1b2c3").Return(storedAPIKey(t, 7, "a1b2c3", 1), nil)
+	repo.On("TouchAPIKey", uint(7), mock.Anything).Return(nil)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+
+	rr := performAPIKeyRequest(router, "GET", "/api/v1/patient/10", "hmk_a1b2c3_"+testAPIKeySecret)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	repo.AssertNotCalled(t, "RecordPatientView", mock.Anything)
+}
+
+func TestListRecentlyViewedHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	now := time.Now()
+	repo.On("ListRecentlyViewed", uint(3), uint(1)).Return([]models.RecentlyViewedPatient{
+		{PatientID: 11, PatientHN: "HN011", FirstNameEN: "Malee", LastNameEN: "Dee", ViewedAt: now},
+		{PatientID: 10, PatientHN: "HN010", FirstNameEN: "Somchai", LastNameEN: "Jaidee", ViewedAt: now.Add(-time.Hour)},
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/staff/recently-viewed", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Data  []models.RecentlyViewedPatient `json:"data"`
+		Count int                            `json:"count"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Count)
+	assert.Equal(t, uint(11), resp.Data[0].PatientID)
+	assert.Equal(t, "Somchai", resp.Data[1].FirstNameEN)
+}
@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func pendingReferral() *models.Referral {
+	return &models.Referral{
+		ID: 4, SourceHospitalID: 1, TargetHospitalID: 2, PatientID: 10, ReferringStaffID: 3,
+		Reason: "Cardiology consult", Priority: models.ReferralPriorityUrgent, Status: models.ReferralStatusPending,
+	}
+}
+
+func TestCreateReferralHandler_Creates(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "doctor", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("GetHospitalByID", uint(2)).Return(&models.Hospital{ID: 2, Name: "Hospital B"}, nil)
+	repo.On("CreateReferral", mock.MatchedBy(func(r *models.Referral) bool {
+		return r.SourceHospitalID == 1 && r.TargetHospitalID == 2 && r.PatientID == 10 && r.ReferringStaffID == 3 &&
+			r.Reason == "Cardiology consult" && r.Priority == models.ReferralPriorityUrgent && r.Status == models.ReferralStatusPending
+	})).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/referral", gin.H{
+		"patient_id": 10, "target_hospital_id": 2, "reason": " Cardiology consult ", "priority": "urgent",
+	}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	repo.AssertExpectations(t)
+}
+
+func TestCreateReferralHandler_PatientOfAnotherHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "doctor", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 3}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/referral", gin.H{
+		"patient_id": 10, "target_hospital_id": 2, "reason": "Consult", "priority": "routine",
+	}, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "CreateReferral", mock.Anything)
+}
+
+func TestCreateReferralHandler_InvalidRequests(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "doctor", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil).Maybe()
+	repo.On("GetHospitalByID", uint(9)).Return(nil, database.ErrHospitalNotFound).Maybe()
+
+	tests := []struct {
+		name string
+		body gin.H
+		want int
+	}{
+		{"unknown priority", gin.H{"patient_id": 10, "target_hospital_id": 2, "reason": "Consult", "priority": "soon"}, http.StatusBadRequest},
+		{"blank reason", gin.H{"patient_id": 10, "target_hospital_id": 2, "reason": "  ", "priority": "routine"}, http.StatusBadRequest},
+		{"own hospital", gin.H{"patient_id": 10, "target_hospital_id": 1, "reason": "Consult", "priority": "routine"}, http.StatusBadRequest},
+		{"unknown hospital", gin.H{"patient_id": 10, "target_hospital_id": 9, "reason": "Consult", "priority": "routine"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := performRequest(router, "POST", "/api/v1/referral", tt.body, token)
+			assert.Equal(t, tt.want, rr.Code, rr.Body.String())
+		})
+	}
+	repo.AssertNotCalled(t, "CreateReferral", mock.Anything)
+}
+
+func TestGetReferralHandler_VisibleToBothHospitals(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("GetReferral", uint(4)).Return(pendingReferral(), nil)
+
+	for hospitalID, want := range map[uint]int{1: http.StatusOK, 2: http.StatusOK, 3: http.StatusNotFound} {
+		staff := hashedStaff(t, 20+hospitalID, "viewer", "password123", hospitalID, "Hospital")
+		token := loginToken(t, router, repo, staff, "password123")
+
+		rr := performRequest(router, "GET", "/api/v1/referral/4", nil, token)
+		assert.Equal(t, want, rr.Code, "hospital %d", hospitalID)
+	}
+}
+
+func TestAcceptReferralHandler_TargetHospitalOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("GetReferral", uint(4)).Return(pendingReferral(), nil)
+
+	for hospitalID, want := range map[uint]int{1: http.StatusForbidden, 3: http.StatusNotFound} {
+		staff := hashedStaff(t, 20+hospitalID, "decider", "password123", hospitalID, "Hospital")
+		token := loginToken(t, router, repo, staff, "password123")
+
+		rr := performRequest(router, "PUT", "/api/v1/referral/4/accept", nil, token)
+		assert.Equal(t, want, rr.Code, "hospital %d", hospitalID)
+	}
+	repo.AssertNotCalled(t, "UpdateReferralStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAcceptReferralHandler_Accepts(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "receiver", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetReferral", uint(4)).Return(pendingReferral(), nil)
+	repo.On("UpdateReferralStatus", mock.Anything, models.ReferralStatusPending, models.ReferralStatusAccepted).Return(nil)
+
+	rr := performRequest(router, "PUT", "/api/v1/referral/4/accept", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var referral models.Referral
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &referral))
+	assert.Equal(t, models.ReferralStatusAccepted, referral.Status)
+}
+
+func TestRejectReferralHandler_AlreadyDecided(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 5, "receiver", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	accepted := pendingReferral()
+	accepted.Status = models.ReferralStatusAccepted
+	repo.On("GetReferral", uint(4)).Return(accepted, nil)
+	repo.On("GetReferral", uint(5)).Return(pendingReferral(), nil)
+	repo.On("UpdateReferralStatus", mock.Anything, models.ReferralStatusPending, models.ReferralStatusRejected).Return(gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "PUT", "/api/v1/referral/4/reject", nil, token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Decided by another request between loading and updating
+	rr = performRequest(router, "PUT", "/api/v1/referral/5/reject", nil, token)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func decodeMonthlyReport(t *testing.T, body []byte) models.MonthlyReport {
+	t.Helper()
+	var report models.MonthlyReport
+	assert.NoError(t, json.Unmarshal(body, &report))
+	return report
+}
+
+func TestGetMonthlyReportHandler_ServesFinalCachedReport(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetCachedReport", uint(1), 2024, 5).Return(&models.CachedReport{
+		Report:      models.MonthlyReport{HospitalID: 1, Year: 2024, Month: 5, NewRegistrations: 17},
+		GeneratedAt: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/admin/reports/monthly?year=2024&month=5", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, int64(17), decodeMonthlyReport(t, rr.Body.Bytes()).NewRegistrations)
+	repo.AssertNotCalled(t, "GenerateMonthlyReport", mock.Anything, mock.Anything)
+}
+
+func TestGetMonthlyReportHandler_RegeneratesStaleOrMissingReports(t *testing.T) {
+	for name, cached := range map[string]struct {
+		report *models.CachedReport
+		err    error
+	}{
+		"generated during the month": {&models.CachedReport{GeneratedAt: time.Date(2024, time.May, 31, 12, 0, 0, 0, time.UTC)}, nil},
+		"not cached":                 {nil, gorm.ErrRecordNotFound},
+		"cache unreadable":           {nil, errors.New("relation does not exist")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			router, repo := newTestRouter()
+			token := importAdminToken(t, router, repo, models.RoleAdmin)
+			generated := &models.MonthlyReport{HospitalID: 1, Year: 2024, Month: 5, Admissions: 3, TopSearchFieldsUsed: []models.SearchFieldCount{}, GeneratedAt: time.Now()}
+			repo.On("GetCachedReport", uint(1), 2024, 5).Return(cached.report, cached.err)
+			repo.On("GenerateMonthlyReport", uint(1), mock.MatchedBy(func(start time.Time) bool {
+				return start.Year() == 2024 && start.Month() == time.May && start.Day() == 1 && start.Hour() == 0
+			})).Return(generated, nil)
+			repo.On("SaveCachedReport", mock.MatchedBy(func(c *models.CachedReport) bool {
+				return c.HospitalID == 1 && c.Year == 2024 && c.Month == 5 && c.Report.Admissions == 3 && c.GeneratedAt.Equal(generated.GeneratedAt)
+			})).Return(nil)
+
+			rr := performRequest(router, "GET", "/api/v1/admin/reports/monthly?year=2024&month=5", nil, token)
+
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			assert.Equal(t, int64(3), decodeMonthlyReport(t, rr.Body.Bytes()).Admissions)
+			repo.AssertCalled(t, "SaveCachedReport", mock.Anything)
+		})
+	}
+}
+
+func TestGetMonthlyReportHandler_InvalidMonths(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	next := time.Now().AddDate(0, 2, 0)
+
+	for _, query := range []string{"", "?year=2024", "?year=2024&month=13", "?year=24&month=5", "?year=2024&month=may",
+		"?year=" + next.Format("2006") + "&month=" + next.Format("1")} {
+		rr := performRequest(router, "GET", "/api/v1/admin/reports/monthly"+query, nil, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+	repo.AssertNotCalled(t, "GenerateMonthlyReport", mock.Anything, mock.Anything)
+}
+
+func TestGetMonthlyReportHandler_OwnHospitalOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	admin := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	rr := performRequest(router, "GET", "/api/v1/admin/reports/monthly?year=2024&month=5&hospital_id=2", nil, admin)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	router, repo = newTestRouter()
+	staff := importAdminToken(t, router, repo, models.RoleStaff)
+	rr = performRequest(router, "GET", "/api/v1/admin/reports/monthly?year=2024&month=5", nil, staff)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	router, repo = newTestRouter()
+	superAdmin := importAdminToken(t, router, repo, models.RoleSuperAdmin)
+	repo.On("GetCachedReport", uint(2), 2024, 5).Return(&models.CachedReport{
+		Report:      models.MonthlyReport{HospitalID: 2},
+		GeneratedAt: time.Date(2024, time.June, 2, 0, 0, 0, 0, time.UTC),
+	}, nil)
+	rr = performRequest(router, "GET", "/api/v1/admin/reports/monthly?year=2024&month=5&hospital_id=2", nil, superAdmin)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, uint(2), decodeMonthlyReport(t, rr.Body.Bytes()).HospitalID)
+}
+
+func TestSearchFieldHistogram(t *testing.T) {
+	histogram := models.SearchFieldHistogram(map[string]int64{
+		"last_name_en": 4, "first_name_th": 9, "page": 30, "extra.ward": 2, "blood_type": 4, "tag": 1,
+	})
+
+	assert.Equal(t, []models.SearchFieldCount{
+		{Field: "first_name_th", Count: 9},
+		{Field: "blood_type", Count: 4},
+		{Field: "last_name_en", Count: 4},
+		{Field: "tag", Count: 1},
+	}, histogram)
+	assert.Equal(t, []models.SearchFieldCount{}, models.SearchFieldHistogram(nil))
+}
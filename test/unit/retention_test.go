@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"hospital-middleware/internal/background"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/test/mocks"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRetentionDryRunHandler_ReportsWithoutDeleting(t *testing.T) {
+	cfg := *testConfig
+	cfg.PatientRetentionDays = 2557
+	cfg.RevokedTokenRetentionDays = 30
+	router, repo := newTestRouterWithConfig(&cfg)
+	token := importAdminToken(t, router, repo, models.RoleSuperAdmin)
+	var cutoffs models.RetentionCutoffs
+	repo.On("CountRetentionPurge", mock.Anything).Run(func(args mock.Arguments) {
+		cutoffs = args.Get(0).(models.RetentionCutoffs)
+	}).Return(models.RetentionPurgeReport{Patients: 4, RevokedTokens: 12}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/admin/retention/dry-run", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var report models.RetentionPurgeReport
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.True(t, report.DryRun)
+	assert.Equal(t, int64(4), report.Patients)
+	assert.Equal(t, int64(12), report.RevokedTokens)
+	assert.WithinDuration(t, time.Now(), cutoffs.AsOf, time.Minute)
+	assert.True(t, cutoffs.PatientsDeletedBefore.Equal(cutoffs.AsOf.AddDate(0, 0, -2557)))
+	assert.True(t, cutoffs.TokensRevokedBefore.Equal(cutoffs.AsOf.AddDate(0, 0, -30)))
+	repo.AssertNotCalled(t, "PurgeSoftDeletedPatients", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "PurgeRevokedTokens", mock.Anything, mock.Anything)
+}
+
+func TestRetentionDryRunHandler_SuperAdminsOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	rr := performRequest(router, "GET", "/api/v1/admin/retention/dry-run", nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "CountRetentionPurge", mock.Anything)
+}
+
+func TestRetentionPurge_QueuedWithCutoffs(t *testing.T) {
+	now := time.Date(2025, 5, 1, 3, 0, 0, 0, time.UTC)
+	repo := new(mocks.MockPatientRepository)
+	queued := make(chan *models.Job, 1)
+	repo.On("CreateJob", mock.Anything).Run(func(args mock.Arguments) {
+		select {
+		case queued <- args.Get(0).(*models.Job):
+		default: // Later runs of the task
+		}
+	}).Return(nil)
+
+	scheduler := background.NewScheduler(fixedClock{now: now})
+	scheduler.Register(background.EnqueueRetentionPurge(repo, 2557, 30, time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+	var job *models.Job
+	select {
+	case job = <-queued:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the retention purge job to be queued")
+	}
+	cancel()
+	waitFor(t, done, "scheduler to stop")
+
+	assert.Equal(t, background.RetentionPurgeJob, job.Type)
+	var cutoffs models.RetentionCutoffs
+	assert.NoError(t, json.Unmarshal(job.Payload, &cutoffs))
+	assert.True(t, cutoffs.AsOf.Equal(now))
+	// 2557 days are seven years with their two leap days
+	assert.True(t, cutoffs.PatientsDeletedBefore.Equal(time.Date(2018, 5, 1, 3, 0, 0, 0, time.UTC)))
+	assert.True(t, cutoffs.TokensRevokedBefore.Equal(time.Date(2025, 4, 1, 3, 0, 0, 0, time.UTC)))
+}
+
+func TestRetentionPurge_DeletesInBatchesUntilDone(t *testing.T) {
+	cutoffs := models.NewRetentionCutoffs(time.Date(2025, 5, 1, 3, 0, 0, 0, time.UTC), 2557, 30)
+	payload, _ := json.Marshal(cutoffs)
+	repo := new(mocks.MockPatientRepository)
+	before := mock.MatchedBy(cutoffs.PatientsDeletedBefore.Equal)
+	repo.On("PurgeSoftDeletedPatients", before, 2).Return(int64(2), []string{"patients/1/a"}, nil).Once()
+	repo.On("PurgeSoftDeletedPatients", before, 2).Return(int64(2), nil, nil).Once()
+	repo.On("PurgeSoftDeletedPatients", before, 2).Return(int64(1), []string{"patients/5/b"}, nil).Once()
+	repo.On("PurgeRevokedTokens", mock.Anything, 2).Return(int64(1), nil).Once()
+	for _, key := range []string{"patients/1/a", "patients/5/b"} {
+		_, err := testBlobs.Put(key, bytes.NewReader([]byte("scan")))
+		assert.NoError(t, err)
+	}
+
+	purge := background.RetentionPurge(repo, testBlobs, 2)
+	assert.NoError(t, purge(context.Background(), payload))
+
+	repo.AssertExpectations(t)
+	repo.AssertNumberOfCalls(t, "PurgeSoftDeletedPatients", 3)
+	for _, key := range []string{"patients/1/a", "patients/5/b"} {
+		_, err := testBlobs.Get(key)
+		assert.Error(t, err, "the documents of purged patients are deleted")
+	}
+}
+
+func TestRetentionPurge_StopsOnErrorAndShutdown(t *testing.T) {
+	payload, _ := json.Marshal(models.NewRetentionCutoffs(time.Now(), 2557, 30))
+
+	repo := new(mocks.MockPatientRepository)
+	repo.On("PurgeSoftDeletedPatients", mock.Anything, 2).Return(int64(0), nil, errors.New("connection refused"))
+	assert.Error(t, background.RetentionPurge(repo, testBlobs, 2)(context.Background(), payload), "a failed batch fails the attempt, so it is retried")
+	repo.AssertNotCalled(t, "PurgeRevokedTokens", mock.Anything, mock.Anything)
+
+	repo = new(mocks.MockPatientRepository)
+	ctx, cancel := context.WithCancel(context.Background())
+	repo.On("PurgeSoftDeletedPatients", mock.Anything, 2).Run(func(mock.Arguments) { cancel() }).Return(int64(2), nil, nil)
+	assert.ErrorIs(t, background.RetentionPurge(repo, testBlobs, 2)(ctx, payload), context.Canceled)
+	repo.AssertNumberOfCalls(t, "PurgeSoftDeletedPatients", 1)
+
+	assert.Error(t, background.RetentionPurge(repo, testBlobs, 2)(context.Background(), json.RawMessage(`"yesterday"`)))
+}
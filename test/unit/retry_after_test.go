@@ -0,0 +1,58 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/api/middleware"
+	"hospital-middleware/pkg/apperror"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// retryAfterRouter serves a route that always answers with AbortWithRetryAfter.
+func retryAfterRouter(code apperror.Code, wait time.Duration) *gin.Engine {
+	router := gin.New()
+	router.GET("/limited", func(c *gin.Context) {
+		middleware.AbortWithRetryAfter(c, code, "Too many attempts", wait)
+	})
+	return router
+}
+
+func TestAbortWithRetryAfter_HeaderAndBody(t *testing.T) {
+	for _, code := range []apperror.Code{apperror.CodeRateLimited, apperror.CodeAccountLocked} {
+		t.Run(string(code), func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			retryAfterRouter(code, 90*time.Second).ServeHTTP(rr, httptest.NewRequest("GET", "/limited", nil))
+
+			assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+			assert.Equal(t, "90", rr.Header().Get("Retry-After"))
+			var body map[string]interface{}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, "Too many attempts", body["error"])
+			assert.Equal(t, string(code), body["code"])
+			assert.Equal(t, float64(90), body["retry_after_seconds"])
+		})
+	}
+}
+
+func TestAbortWithRetryAfter_RoundsUpToWholeSeconds(t *testing.T) {
+	for wait, want := range map[time.Duration]string{
+		1500 * time.Millisecond: "2",
+		10 * time.Millisecond:   "1",
+		0:                       "1",
+		-time.Second:            "1",
+	} {
+		rr := httptest.NewRecorder()
+		retryAfterRouter(apperror.CodeRateLimited, wait).ServeHTTP(rr, httptest.NewRequest("GET", "/limited", nil))
+
+		assert.Equal(t, want, rr.Header().Get("Retry-After"), "wait %v", wait)
+		var body middleware.RetryLaterResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, want, strconv.Itoa(body.RetryAfterSeconds))
+	}
+}
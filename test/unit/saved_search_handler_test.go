@@ -0,0 +1,182 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestCreateSavedSearchHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("CreateSavedSearch", mock.MatchedBy(func(s *models.SavedSearch) bool {
+		return s.StaffID == 9 && s.HospitalID == 2 && s.Name == "Smiths" &&
+			s.QueryParams["last_name_en"] == "Smith" && s.QueryParams["include_inactive"] == "true"
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.SavedSearch).ID = 3
+	}).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/staff/saved-searches", gin.H{
+		"name":         " Smiths ",
+		"query_params": gin.H{"last_name_en": "Smith", "include_inactive": "true"},
+	}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var created models.SavedSearch
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, uint(3), created.ID)
+	assert.Equal(t, models.SearchParams{"last_name_en": "Smith", "include_inactive": "true"}, created.QueryParams)
+	repo.AssertExpectations(t)
+}
+
+func TestCreateSavedSearchHandler_Validation(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	tests := []struct {
+		name  string
+		body  gin.H
+		error string
+	}{
+		{"missing name", gin.H{"query_params": gin.H{"last_name_en": "Smith"}}, "Invalid request body"},
+		{"blank name", gin.H{"name": "  ", "query_params": gin.H{"last_name_en": "Smith"}}, "name must not be blank"},
+		{"unknown field", gin.H{"name": "x", "query_params": gin.H{"last_name_en": "Smith", "surname": "Smith", "hospital_id": "all"}}, "Unknown search parameters: hospital_id, surname"},
+		{"unbindable form tag", gin.H{"name": "x", "query_params": gin.H{"last_name_en": "Smith", "-": "true"}}, "Unknown search parameters: -"},
+		{"bad value", gin.H{"name": "x", "query_params": gin.H{"birth_year": "nineteen"}}, "Invalid query parameters"},
+		{"failed binding rule", gin.H{"name": "x", "query_params": gin.H{"blood_type": "Z"}}, "Invalid query parameters"},
+		{"conflicting fields", gin.H{"name": "x", "query_params": gin.H{"patient_hn": "HN1", "patient_hn_prefix": "HN"}}, "cannot be combined"},
+		{"no criteria", gin.H{"name": "x", "query_params": gin.H{"include_inactive": "true"}}, "at least one search criterion required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := performRequest(router, "POST", "/api/v1/staff/saved-searches", tt.body, token)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+			assert.Contains(t, rr.Body.String(), tt.error)
+		})
+	}
+	repo.AssertNotCalled(t, "CreateSavedSearch", mock.Anything)
+}
+
+func TestCreateSavedSearchHandler_LimitReached(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("CreateSavedSearch", mock.Anything).Return(database.ErrSavedSearchLimit)
+
+	rr := performRequest(router, "POST", "/api/v1/staff/saved-searches", gin.H{
+		"name":         "one too many",
+		"query_params": gin.H{"last_name_en": "Smith"},
+	}, token)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "At most 20 saved searches")
+}
+
+func TestListSavedSearchesHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("ListSavedSearches", uint(9)).Return([]models.SavedSearch{
+		{ID: 1, StaffID: 9, Name: "Smiths", QueryParams: models.SearchParams{"last_name_en": "Smith"}},
+		{ID: 2, StaffID: 9, Name: "Walk-ins", QueryParams: models.SearchParams{"created_from": "2024-01-01"}},
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/staff/saved-searches", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Data  []models.SavedSearch `json:"data"`
+		Count int                  `json:"count"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Count)
+	assert.Equal(t, "Smiths", resp.Data[0].Name)
+	assert.Equal(t, "Smith", resp.Data[0].QueryParams["last_name_en"])
+}
+
+func TestDeleteSavedSearchHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("DeleteSavedSearch", uint(3), uint(9)).Return(nil)
+	repo.On("DeleteSavedSearch", uint(4), uint(9)).Return(gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "DELETE", "/api/v1/staff/saved-searches/3", nil, token)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Other staff members' searches look missing
+	rr = performRequest(router, "DELETE", "/api/v1/staff/saved-searches/4", nil, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRunSavedSearchHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetSavedSearch", uint(3), uint(9)).Return(&models.SavedSearch{
+		ID: 3, StaffID: 9, HospitalID: 2, Name: "Smiths",
+		QueryParams: models.SearchParams{"last_name_en": "Smith", "name_match": "exact"},
+	}, nil)
+	repo.On("SearchPatients", mock.MatchedBy(func(q *models.PatientSearchQuery) bool {
+		return q.LastNameEN != nil && *q.LastNameEN == "Smith" && q.NameMatchMode() == models.NameMatchExact
+	}), uint(2), searchLimit).Return([]models.Patient{{ID: 1, HospitalID: 2, LastNameEN: "Smith"}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/staff/saved-searches/3/run", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var results []models.Patient
+	response := decodeSearchResponse(t, rr.Body.Bytes(), &results)
+	assert.Equal(t, 1, response.Count)
+	assert.Equal(t, "Smith", results[0].LastNameEN)
+	repo.AssertCalled(t, "RecordSearch", mock.MatchedBy(func(entry *models.SearchHistory) bool {
+		return entry.StaffID == 9 && entry.Query == "last_name_en=Smith&name_match=exact" && entry.ResultCount == 1
+	}))
+}
+
+func TestRunSavedSearchHandler_NotFound(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetSavedSearch", uint(4), uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "GET", "/api/v1/staff/saved-searches/4/run", nil, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunSavedSearchHandler_StaleParameters(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 9, "searcher", "password123", 2, "Hospital B")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetSavedSearch", uint(3), uint(9)).Return(&models.SavedSearch{
+		ID: 3, StaffID: 9, QueryParams: models.SearchParams{"surname": "Smith"},
+	}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/staff/saved-searches/3/run", nil, token)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), "surname")
+	repo.AssertNotCalled(t, "SearchPatients", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSavedSearches_RequireStaffAccount(t *testing.T) {
+	router, repo := newTestRouter()
+	repo.On("FindAPIKeyByPrefix", "a1b2c3").Return(storedAPIKey(t, 7, "a1b2c3", 1), nil)
+	repo.On("TouchAPIKey", uint(7), mock.Anything).Return(nil)
+
+	rr := performAPIKeyRequest(router, "GET", "/api/v1/staff/saved-searches", "hmk_a1b2c3_"+testAPIKeySecret)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "ListSavedSearches", mock.Anything)
+}
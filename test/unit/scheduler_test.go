@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hospital-middleware/internal/background"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/test/mocks"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fixedClock is a background.Clock that always reports the same instant.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+// waitFor fails the test if the channel is not signalled within a second.
+func waitFor(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+// signalOnce returns a channel and a mock Run callback that closes it on the first call.
+func signalOnce() (chan struct{}, func(mock.Arguments)) {
+	ch := make(chan struct{})
+	var fired atomic.Bool
+	return ch, func(mock.Arguments) {
+		if fired.CompareAndSwap(false, true) {
+			close(ch)
+		}
+	}
+}
+
+func TestScheduler_RunsCleanupTasksWithClockTime(t *testing.T) {
+	now := time.Date(2025, 5, 1, 3, 0, 0, 0, time.UTC)
+	repo := new(mocks.MockPatientRepository)
+
+	tokensPurged, onTokens := signalOnce()
+	repo.On("DeleteExpiredRevokedTokens", now).Run(onTokens).Return(int64(2), nil)
+	historyPurged, onHistory := signalOnce()
+	repo.On("DeleteSearchHistoryBefore", now.AddDate(0, 0, -30)).Run(onHistory).Return(int64(5), nil)
+
+	scheduler := background.NewScheduler(fixedClock{now: now})
+	scheduler.Register(background.PurgeExpiredRevokedTokens(repo, time.Millisecond))
+	scheduler.Register(background.PurgeOldSearchHistory(repo, 30, time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, tokensPurged, "revoked token purge")
+	waitFor(t, historyPurged, "search history purge")
+	cancel()
+	waitFor(t, done, "scheduler to stop")
+
+	repo.AssertExpectations(t)
+}
+
+func TestScheduler_RepeatsAfterTaskError(t *testing.T) {
+	var runs atomic.Int32
+	repeated := make(chan struct{})
+	task := background.Task{
+		Name:     "flaky",
+		Interval: time.Millisecond,
+		Run: func(time.Time) error {
+			if runs.Add(1) == 3 {
+				close(repeated)
+			}
+			return errors.New("boom")
+		},
+	}
+
+	scheduler := background.NewScheduler(background.SystemClock{})
+	scheduler.Register(task)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, repeated, "task to run again after failing")
+	cancel()
+	waitFor(t, done, "scheduler to stop")
+
+	stoppedAt := runs.Load()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, stoppedAt, runs.Load(), "task must not run after the scheduler stops")
+}
+
+func TestPatientAccessLogPurge_QueuedAndRunAsJob(t *testing.T) {
+	now := time.Date(2025, 5, 1, 3, 0, 0, 0, time.UTC)
+	cutoff := now.AddDate(0, 0, -400)
+	repo := new(mocks.MockPatientRepository)
+	queued := make(chan *models.Job, 1)
+	repo.On("CreateJob", mock.Anything).Run(func(args mock.Arguments) {
+		select {
+		case queued <- args.Get(0).(*models.Job):
+		default: // Later runs of the task
+		}
+	}).Return(nil)
+
+	scheduler := background.NewScheduler(fixedClock{now: now})
+	scheduler.Register(background.EnqueuePatientAccessLogPurge(repo, 400, time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+	var job *models.Job
+	select {
+	case job = <-queued:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the purge job to be queued")
+	}
+	cancel()
+	waitFor(t, done, "scheduler to stop")
+
+	assert.Equal(t, background.PurgeAccessLogJob, job.Type)
+	repo.On("DeletePatientAccessLogBefore", mock.MatchedBy(cutoff.Equal)).Return(int64(3), nil)
+	purge := background.PurgePatientAccessLog(repo)
+	assert.NoError(t, purge(context.Background(), json.RawMessage(job.Payload)))
+	repo.AssertExpectations(t)
+
+	assert.Error(t, purge(context.Background(), json.RawMessage(`"yesterday"`)))
+	repo.On("DeletePatientAccessLogBefore", mock.Anything).Return(int64(0), errors.New("connection refused"))
+	assert.Error(t, purge(context.Background(), json.RawMessage(`{"before":"2024-01-01T00:00:00Z"}`)), "a failed purge fails the attempt, so it is retried")
+}
@@ -0,0 +1,242 @@
+package unit
+
+import (
+	"bufio"
+	"fmt"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// searchCacheConfig is testConfig with the in-memory search cache on.
+func searchCacheConfig() *config.Config {
+	cfg := *testConfig
+	cfg.SearchCache = config.SearchCacheMemory
+	cfg.SearchCacheTTL = time.Minute
+	return &cfg
+}
+
+func TestSearchPatientHandler_CachesRepeatedSearch(t *testing.T) {
+	router, repo := newTestRouterWithConfig(searchCacheConfig())
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{{ID: 10, HospitalID: 1, FirstNameEN: "Somchai"}}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai&last_name_en=Jaidee", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "miss", rr.Header().Get("Cache-Status"))
+
+	// The same criteria in another order are the same search
+	rr = performRequest(router, "GET", "/api/v1/patient/search?last_name_en=Jaidee&first_name_en=Somchai", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "hit", rr.Header().Get("Cache-Status"))
+	assert.Contains(t, rr.Body.String(), `"first_name_en":"Somchai"`)
+
+	rr = performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+	assert.Equal(t, "miss", rr.Header().Get("Cache-Status"), "different criteria")
+	repo.AssertNumberOfCalls(t, "SearchPatients", 2)
+}
+
+func TestSearchPatientHandler_WriteInvalidatesCache(t *testing.T) {
+	router, repo := newTestRouterWithConfig(searchCacheConfig())
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{{ID: 10, HospitalID: 1}}, nil)
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.Anything, mock.Anything).Return(nil)
+	search := "/api/v1/patient/search?first_name_en=Somchai"
+
+	performRequest(router, "GET", search, nil, token)
+	rr := performRequest(router, "GET", search, nil, token)
+	assert.Equal(t, "hit", rr.Header().Get("Cache-Status"))
+
+	rr = performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{"blood_type": "O+"}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = performRequest(router, "GET", search, nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "miss", rr.Header().Get("Cache-Status"))
+	repo.AssertNumberOfCalls(t, "SearchPatients", 2)
+}
+
+func TestSearchPatientHandler_NoCacheByDefault(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{}, nil)
+
+	performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+	rr := performRequest(router, "GET", "/api/v1/patient/search?first_name_en=Somchai", nil, token)
+
+	assert.Empty(t, rr.Header().Get("Cache-Status"))
+	repo.AssertNumberOfCalls(t, "SearchPatients", 2)
+}
+
+func TestSearchCache_InvalidateIsPerHospital(t *testing.T) {
+	cache := services.NewSearchCache(services.NewMemoryCache(10), time.Minute)
+	name := "Somchai"
+	query := &models.PatientSearchQuery{FirstNameEN: &name}
+	cache.Set(1, query, 5, []models.Patient{{ID: 1}})
+	cache.Set(2, query, 5, []models.Patient{{ID: 2}})
+
+	cache.Invalidate(1)
+
+	_, found := cache.Get(1, query, 5)
+	assert.False(t, found)
+	patients, found := cache.Get(2, query, 5)
+	assert.True(t, found)
+	assert.Equal(t, []models.Patient{{ID: 2}}, patients)
+	_, found = cache.Get(2, query, 6)
+	assert.False(t, found, "another limit is another entry")
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := services.NewMemoryCache(2)
+	cache.Set("a", []byte("1"), 0)
+	cache.Set("b", []byte("2"), 0)
+	cache.Get("a") // b is now the least recently used
+	cache.Set("c", []byte("3"), 0)
+
+	_, found, _ := cache.Get("b")
+	assert.False(t, found)
+	value, found, _ := cache.Get("a")
+	assert.True(t, found)
+	assert.Equal(t, []byte("1"), value)
+	_, found, _ = cache.Get("c")
+	assert.True(t, found)
+}
+
+func TestMemoryCache_Expires(t *testing.T) {
+	cache := services.NewMemoryCache(10)
+	cache.Set("a", []byte("1"), 20*time.Millisecond)
+
+	_, found, _ := cache.Get("a")
+	assert.True(t, found)
+	time.Sleep(30 * time.Millisecond)
+	_, found, _ = cache.Get("a")
+	assert.False(t, found)
+}
+
+// fakeRedisServer serves GET, SET (ignoring expiry) and AUTH from a map on a local port, and
+// returns its address and the commands it received.
+func fakeRedisServer(t *testing.T) (string, func() []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	values := map[string]string{}
+	var received []string
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					args, err := readRESPArray(reader)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					received = append(received, strings.Join(args, " "))
+					switch strings.ToUpper(args[0]) {
+					case "GET":
+						if value, ok := values[args[1]]; ok {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+						} else {
+							fmt.Fprint(conn, "$-1\r\n")
+						}
+					case "SET":
+						values[args[1]] = args[2]
+						fmt.Fprint(conn, "+OK\r\n")
+					case "AUTH":
+						fmt.Fprint(conn, "+OK\r\n")
+					default:
+						fmt.Fprint(conn, "-ERR unknown command\r\n")
+					}
+					mu.Unlock()
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), received...)
+	}
+}
+
+func readRESPArray(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func TestRedisCache_GetAndSet(t *testing.T) {
+	addr, received := fakeRedisServer(t)
+	cache, err := services.NewRedisCache("redis://:secret@" + addr)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	_, found, err := cache.Get("missing")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, cache.Set("search:1:key with spaces", []byte(`[{"id":1}]`), 15*time.Second))
+	value, found, err := cache.Get("search:1:key with spaces")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, `[{"id":1}]`, string(value))
+
+	assert.Equal(t, []string{
+		"AUTH secret",
+		"GET missing",
+		`SET search:1:key with spaces [{"id":1}] PX 15000`,
+		"GET search:1:key with spaces",
+	}, received())
+}
+
+func TestRedisCache_UnreachableIsAnError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+	cache, err := services.NewRedisCache("redis://" + addr)
+	require.NoError(t, err)
+
+	_, _, err = cache.Get("key")
+	assert.Error(t, err)
+	// A search cache over it treats the error as a miss
+	_, found := services.NewSearchCache(cache, time.Minute).Get(1, &models.PatientSearchQuery{}, 5)
+	assert.False(t, found)
+}
@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/config"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testSearchPlan = json.RawMessage(`[{"Plan":{"Node Type":"Index Scan","Relation Name":"patients"}}]`)
+
+func performExplainRequest(router *gin.Engine, path, token, explain string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if explain != "" {
+		req.Header.Set("X-Debug-Explain", explain)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// explainRouter returns a router for appEnv with one patient matching the search, and a token
+// for the given role.
+func explainRouter(t *testing.T, appEnv, role string) (*gin.Engine, *mocks.MockPatientRepository, string) {
+	t.Helper()
+	cfg := *testConfig
+	cfg.AppEnv = appEnv
+	router, repo := newTestRouterWithConfig(&cfg)
+	token := importAdminToken(t, router, repo, role)
+	repo.On("SearchPatients", mock.Anything, uint(1), searchLimit).Return([]models.Patient{{ID: 10, HospitalID: 1}}, nil)
+	repo.On("ExplainSearchPatients", mock.Anything, uint(1), searchLimit).Return(testSearchPlan, nil)
+	return router, repo, token
+}
+
+func TestSearchPatientHandler_ExplainForAdmins(t *testing.T) {
+	router, repo, token := explainRouter(t, config.AppEnvDevelopment, models.RoleAdmin)
+
+	rr := performExplainRequest(router, "/api/v1/patient/search?first_name_en=Test", token, "true")
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.JSONEq(t, string(testSearchPlan), string(resp["_explain"]))
+	assert.Equal(t, "1", string(resp["count"]), "the search results are still returned")
+	repo.AssertCalled(t, "ExplainSearchPatients", mock.MatchedBy(func(query *models.PatientSearchQuery) bool {
+		return query.FirstNameEN != nil && *query.FirstNameEN == "Test"
+	}), uint(1), searchLimit)
+}
+
+func TestSearchPatientHandler_ExplainOmitted(t *testing.T) {
+	tests := []struct {
+		name, appEnv, role, header string
+	}{
+		{"without the header", config.AppEnvDevelopment, models.RoleAdmin, ""},
+		{"with the header off", config.AppEnvDevelopment, models.RoleAdmin, "false"},
+		{"for staff", config.AppEnvDevelopment, models.RoleStaff, "true"},
+		{"for viewers", config.AppEnvDevelopment, models.RoleViewer, "true"},
+		{"in production", config.AppEnvProduction, models.RoleAdmin, "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, repo, token := explainRouter(t, tt.appEnv, tt.role)
+
+			rr := performExplainRequest(router, "/api/v1/patient/search?first_name_en=Test", token, tt.header)
+
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			assert.NotContains(t, rr.Body.String(), "_explain")
+			repo.AssertNotCalled(t, "ExplainSearchPatients", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const staffExportPath = "/api/v1/admin/staff/export"
+
+func TestExportStaffHandler_StreamsCSV(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetHospitalByID", uint(1)).Return(&models.Hospital{ID: 1, Name: "Hospital A", Code: "HA"}, nil)
+
+	created := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	lastLogin := created.Add(48 * time.Hour)
+	staff := make([]models.Staff, 5)
+	for i := range staff {
+		staff[i] = models.Staff{
+			ID:           uint(i + 1),
+			Username:     fmt.Sprintf("nurse%d", i+1),
+			PasswordHash: "$2a$10$secret",
+			HospitalID:   1,
+			HospitalName: "Hospital A",
+			Role:         models.RoleStaff,
+			IsActive:     i != 4,
+			CreatedAt:    created,
+		}
+	}
+	staff[0].LastLoginAt = &lastLogin
+	repo.On("StreamStaffByHospital", uint(1), 10000, mock.Anything).Return(staff, nil)
+
+	rr := performRequest(router, "GET", staffExportPath, nil, token)
+
+	if !assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String()) {
+		return
+	}
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, fmt.Sprintf(`attachment; filename="staff_HA_%s.csv"`, time.Now().Format("2006-01-02")), rr.Header().Get("Content-Disposition"))
+	assert.NotContains(t, rr.Body.String(), "secret")
+
+	records, err := csv.NewReader(strings.NewReader(rr.Body.String())).ReadAll()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"id", "username", "hospital_name", "role", "created_at", "last_login_at", "is_active"}, records[0])
+	if assert.Len(t, records, 6) {
+		assert.Equal(t, []string{"1", "nurse1", "Hospital A", "staff", "2024-03-01T08:30:00Z", "2024-03-03T08:30:00Z", "true"}, records[1])
+		assert.Equal(t, "", records[2][5], "never logged in")
+		assert.Equal(t, "false", records[5][6])
+	}
+}
+
+func TestExportStaffHandler_EmptyHospitalSendsHeaderOnly(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetHospitalByID", uint(1)).Return(&models.Hospital{ID: 1, Name: "Hospital A", Code: "HA"}, nil)
+	repo.On("StreamStaffByHospital", uint(1), 10000, mock.Anything).Return([]models.Staff{}, nil)
+
+	rr := performRequest(router, "GET", staffExportPath, nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "id,username,hospital_name,role,created_at,last_login_at,is_active\n", rr.Body.String())
+}
+
+func TestExportStaffHandler_DatabaseErrorBeforeFirstRow(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetHospitalByID", uint(1)).Return(&models.Hospital{ID: 1, Name: "Hospital A", Code: "HA"}, nil)
+	repo.On("StreamStaffByHospital", uint(1), 10000, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	rr := performRequest(router, "GET", staffExportPath, nil, token)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Empty(t, rr.Header().Get("Content-Disposition"))
+	assert.Contains(t, rr.Body.String(), "Database error during staff export")
+}
+
+func TestExportStaffHandler_RequiresAdmin(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performRequest(router, "GET", staffExportPath, nil, token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	repo.AssertNotCalled(t, "StreamStaffByHospital", mock.Anything, mock.Anything, mock.Anything)
+}
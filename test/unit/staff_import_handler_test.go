@@ -0,0 +1,300 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
+	"hospital-middleware/test/mocks"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const staffImportPath = "/api/v1/admin/staff/import"
+
+// importAdminToken logs in an admin of Hospital A (ID 1) and stubs that hospital's lookup for the import.
+func importAdminToken(t *testing.T, router *gin.Engine, repo *mocks.MockPatientRepository, role string) string {
+	t.Helper()
+	admin := hashedStaff(t, 1, "importer", "password123", 1, "Hospital A")
+	admin.Role = role
+	token := loginToken(t, router, repo, admin, "password123")
+	repo.On("GetHospitalIDByName", "Hospital A").Return(uint(1), nil).Maybe()
+	return token
+}
+
+// decodeImportResult fails the test unless the response is a 200 import result.
+func decodeImportResult(t *testing.T, code int, body []byte) models.StaffImportResult {
+	t.Helper()
+	var result models.StaffImportResult
+	if !assert.Equal(t, http.StatusOK, code, string(body)) {
+		t.FailNow()
+	}
+	assert.NoError(t, json.Unmarshal(body, &result))
+	return result
+}
+
+func TestImportStaffHandler_ValidCSV(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("FindExistingUsernames", []string{"nurse1", "doctor1", "clerk1"}).Return([]string{}, nil)
+	var saved []models.Staff
+	repo.On("CreateStaffBatch", mock.Anything).
+		Run(func(args mock.Arguments) { saved = args.Get(0).([]models.Staff) }).
+		Return(nil)
+
+	csv := "username,password,hospital,role\n" +
+		"nurse1,password123,Hospital A,staff\n" +
+		"doctor1,password456,Hospital A,admin\n" +
+		"clerk1,password789,Hospital A,\n"
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte(csv), token)
+
+	result := decodeImportResult(t, rr.Code, rr.Body.Bytes())
+	assert.False(t, result.DryRun)
+	assert.Equal(t, 3, result.Imported)
+	assert.Equal(t, 0, result.Skipped)
+	assert.Empty(t, result.Errors)
+	if assert.Len(t, saved, 3) {
+		assert.Equal(t, models.RoleAdmin, saved[1].Role)
+		assert.Equal(t, models.RoleStaff, saved[2].Role, "an empty role defaults to staff")
+		assert.Equal(t, uint(1), saved[0].HospitalID)
+		assert.True(t, utils.CheckPasswordHash("password123", saved[0].PasswordHash), "passwords must be stored hashed")
+	}
+}
+
+func TestImportStaffHandler_DuplicateUsernames(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("FindExistingUsernames", []string{"nurse1", "taken"}).Return([]string{"taken"}, nil)
+	repo.On("CreateStaffBatch", mock.MatchedBy(func(staff []models.Staff) bool {
+		return len(staff) == 1 && staff[0].Username == "nurse1"
+	})).Return(nil)
+
+	csv := "username,password,hospital,role\n" +
+		"nurse1,password123,Hospital A,staff\n" +
+		"nurse1,password456,Hospital A,staff\n" +
+		"taken,password123,Hospital A,staff\n"
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte(csv), token)
+
+	result := decodeImportResult(t, rr.Code, rr.Body.Bytes())
+	assert.Equal(t, 1, result.Imported)
+	assert.Equal(t, 2, result.Skipped)
+	if assert.Len(t, result.Errors, 2) {
+		assert.Equal(t, models.StaffImportError{Row: 3, Reason: "duplicate username (also on row 2)"}, result.Errors[0])
+		assert.Equal(t, models.StaffImportError{Row: 4, Reason: "duplicate username"}, result.Errors[1])
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestImportStaffHandler_CaseVariantUsernames(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	// The repository reports taken usernames normalized
+	repo.On("FindExistingUsernames", []string{"Nurse1", "Taken"}).Return([]string{"taken"}, nil)
+	repo.On("CreateStaffBatch", mock.MatchedBy(func(staff []models.Staff) bool {
+		return len(staff) == 1 && staff[0].Username == "Nurse1"
+	})).Return(nil)
+
+	csv := "username,password,hospital,role\n" +
+		"Nurse1,password123,Hospital A,staff\n" +
+		"NURSE1,password456,Hospital A,staff\n" +
+		"Taken,password123,Hospital A,staff\n"
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte(csv), token)
+
+	result := decodeImportResult(t, rr.Code, rr.Body.Bytes())
+	assert.Equal(t, 1, result.Imported)
+	if assert.Len(t, result.Errors, 2) {
+		assert.Equal(t, models.StaffImportError{Row: 3, Reason: "duplicate username (also on row 2)"}, result.Errors[0])
+		assert.Equal(t, models.StaffImportError{Row: 4, Reason: "duplicate username"}, result.Errors[1])
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestImportStaffHandler_InvalidHospitalNames(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetHospitalIDByName", "Nowhere General").Return(uint(0), database.ErrHospitalNotFound).Once()
+	repo.On("FindExistingUsernames", []string{"nurse1", "nurse3"}).Return([]string{}, nil)
+	repo.On("CreateStaffBatch", mock.MatchedBy(func(staff []models.Staff) bool { return len(staff) == 2 })).Return(nil)
+
+	csv := "username,password,hospital,role\n" +
+		"nurse1,password123,Hospital A,staff\n" +
+		"nurse2,password123,Nowhere General,staff\n" +
+		"nurse3,password123,Hospital A,viewer\n" +
+		"nurse4,password123,Nowhere General,staff\n"
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte(csv), token)
+
+	result := decodeImportResult(t, rr.Code, rr.Body.Bytes())
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 2, result.Skipped)
+	for _, rowErr := range result.Errors {
+		assert.Equal(t, `unknown hospital "Nowhere General"`, rowErr.Reason)
+	}
+	repo.AssertExpectations(t) // The unknown hospital is looked up once, not per row
+}
+
+func TestImportStaffHandler_FailedRowDoesNotBlockOthers(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("FindExistingUsernames", mock.Anything).Return([]string{}, nil)
+	// The batch fails, so each row is retried alone and only the conflicting one is lost
+	repo.On("CreateStaffBatch", mock.Anything).Return(errors.New("duplicate key value violates unique constraint"))
+	repo.On("CreateStaff", mock.MatchedBy(func(s *models.Staff) bool { return s.Username == "racer" })).
+		Return(errors.New("duplicate key value violates unique constraint"))
+	repo.On("CreateStaff", mock.AnythingOfType("*models.Staff")).Return(nil)
+
+	csv := "username,password,hospital,role\n" +
+		"nurse1,password123,Hospital A,staff\n" +
+		"nurse2,password123,Hospital A,chief\n" +
+		"racer,password123,Hospital A,staff\n" +
+		"nurse3,password123,Hospital A,staff\n" +
+		"too,few\n"
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte(csv), token)
+
+	result := decodeImportResult(t, rr.Code, rr.Body.Bytes())
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 3, result.Skipped)
+	assert.Equal(t, []models.StaffImportError{
+		{Row: 3, Reason: `invalid role "chief"`},
+		{Row: 4, Reason: "could not be saved"},
+		{Row: 6, Reason: "expected 4 columns, got 2"},
+	}, result.Errors)
+}
+
+func TestImportStaffHandler_InsertsInBatchesOf50(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("FindExistingUsernames", mock.Anything).Return([]string{}, nil)
+	var batchSizes []int
+	repo.On("CreateStaffBatch", mock.Anything).
+		Run(func(args mock.Arguments) { batchSizes = append(batchSizes, len(args.Get(0).([]models.Staff))) }).
+		Return(nil)
+
+	var csv strings.Builder
+	csv.WriteString("username,password,hospital,role\n")
+	for i := 0; i < 120; i++ {
+		fmt.Fprintf(&csv, "bulk%d,password123,Hospital A,staff\n", i)
+	}
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte(csv.String()), token)
+
+	result := decodeImportResult(t, rr.Code, rr.Body.Bytes())
+	assert.Equal(t, 120, result.Imported)
+	assert.Equal(t, []int{50, 50, 20}, batchSizes)
+}
+
+func TestImportStaffHandler_DryRun(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("FindExistingUsernames", []string{"nurse1", "racer", "taken"}).Return([]string{"taken"}, nil)
+	// Constraint failures from the rolled-back inserts are reported like real ones
+	repo.On("RehearseStaffBatch", mock.MatchedBy(func(staff []models.Staff) bool { return len(staff) == 2 })).
+		Return(errors.New("duplicate key value violates unique constraint"))
+	repo.On("RehearseStaffBatch", mock.MatchedBy(func(staff []models.Staff) bool { return staff[0].Username == "racer" })).
+		Return(errors.New("duplicate key value violates unique constraint"))
+	repo.On("RehearseStaffBatch", mock.Anything).Return(nil)
+
+	csv := "username,password,hospital,role\n" +
+		"nurse1,password123,Hospital A,staff\n" +
+		"racer,password123,Hospital A,staff\n" +
+		"taken,password123,Hospital A,staff\n"
+	rr := performUpload(router, staffImportPath+"?dry_run=true", "staff.csv", []byte(csv), token)
+
+	result := decodeImportResult(t, rr.Code, rr.Body.Bytes())
+	assert.True(t, result.DryRun)
+	assert.Contains(t, rr.Body.String(), `"dry_run":true`)
+	assert.Equal(t, 1, result.Imported)
+	assert.Equal(t, []models.StaffImportError{
+		{Row: 3, Reason: "could not be saved"},
+		{Row: 4, Reason: "duplicate username"},
+	}, result.Errors)
+	repo.AssertNotCalled(t, "CreateStaffBatch", mock.Anything)
+	repo.AssertNotCalled(t, "CreateStaff", mock.Anything)
+}
+
+func TestImportStaffHandler_RejectsInvalidDryRun(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	csv := "username,password,hospital,role\nnurse1,password123,Hospital A,staff\n"
+	rr := performUpload(router, staffImportPath+"?dry_run=maybe", "staff.csv", []byte(csv), token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "FindExistingUsernames", mock.Anything)
+}
+
+func TestImportStaffHandler_RejectsMoreThan500Rows(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	var csv strings.Builder
+	csv.WriteString("username,password,hospital,role\n")
+	for i := 0; i < 501; i++ {
+		fmt.Fprintf(&csv, "bulk%d,password123,Hospital A,staff\n", i)
+	}
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte(csv.String()), token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "at most 500 rows")
+	repo.AssertNotCalled(t, "CreateStaffBatch", mock.Anything)
+}
+
+func TestImportStaffHandler_RejectsBadHeader(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte("user,pass\nnurse1,password123\n"), token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "username,password,hospital,role")
+}
+
+func TestImportStaffHandler_HospitalAdminScope(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetHospitalIDByName", "Hospital B").Return(uint(2), nil)
+
+	csv := "username,password,hospital,role\n" +
+		"other,password123,Hospital B,staff\n" +
+		"boss,password123,Hospital A,super_admin\n"
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte(csv), token)
+
+	result := decodeImportResult(t, rr.Code, rr.Body.Bytes())
+	assert.Equal(t, 0, result.Imported)
+	assert.Equal(t, []models.StaffImportError{
+		{Row: 2, Reason: "cannot import staff into another hospital"},
+		{Row: 3, Reason: "only a super admin can import super_admin accounts"},
+	}, result.Errors)
+	repo.AssertNotCalled(t, "CreateStaffBatch", mock.Anything)
+}
+
+func TestImportStaffHandler_SuperAdminImportsAnyHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleSuperAdmin)
+	repo.On("GetHospitalIDByName", "Hospital B").Return(uint(2), nil)
+	repo.On("FindExistingUsernames", []string{"other"}).Return([]string{}, nil)
+	repo.On("CreateStaffBatch", mock.MatchedBy(func(staff []models.Staff) bool {
+		return len(staff) == 1 && staff[0].HospitalID == 2
+	})).Return(nil)
+
+	csv := "username,password,hospital,role\nother,password123,Hospital B,staff\n"
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte(csv), token)
+
+	result := decodeImportResult(t, rr.Code, rr.Body.Bytes())
+	assert.Equal(t, 1, result.Imported)
+	repo.AssertExpectations(t)
+}
+
+func TestImportStaffHandler_RequiresAdmin(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleStaff)
+
+	rr := performUpload(router, staffImportPath, "staff.csv", []byte("username,password,hospital,role\n"), token)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestUpdateStaffRoleHandler_Promotes(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("UpdateStaffRole", uint(5), uint(1), models.RoleAdmin).
+		Return(&models.Staff{ID: 5, Username: "nurse", HospitalID: 1, Role: models.RoleAdmin}, nil)
+
+	rr := performRequest(router, "PUT", "/api/v1/staff/5/role", gin.H{"role": "admin"}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"role":"admin"`)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateStaffRoleHandler_OtherHospitalStaff(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	// The repository only looks in the admin's hospital, so staff elsewhere are not found
+	repo.On("UpdateStaffRole", uint(8), uint(1), models.RoleAdmin).Return(nil, gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "PUT", "/api/v1/staff/8/role", gin.H{"role": "admin"}, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUpdateStaffRoleHandler_LastAdmin(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("UpdateStaffRole", uint(1), uint(1), models.RoleStaff).Return(nil, database.ErrLastAdmin)
+
+	rr := performRequest(router, "PUT", "/api/v1/staff/1/role", gin.H{"role": "staff"}, token)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.JSONEq(t, `{"code":"STAFF_005","error":"cannot remove last admin"}`, rr.Body.String())
+}
+
+func TestUpdateStaffRoleHandler_Rejected(t *testing.T) {
+	for name, tc := range map[string]struct {
+		role string
+		body gin.H
+		want int
+	}{
+		"unknown role":        {models.RoleAdmin, gin.H{"role": "owner"}, http.StatusBadRequest},
+		"super admin":         {models.RoleAdmin, gin.H{"role": "super_admin"}, http.StatusBadRequest},
+		"not an admin caller": {models.RoleStaff, gin.H{"role": "admin"}, http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			router, repo := newTestRouter()
+			token := importAdminToken(t, router, repo, tc.role)
+
+			rr := performRequest(router, "PUT", "/api/v1/staff/5/role", tc.body, token)
+
+			assert.Equal(t, tc.want, rr.Code)
+			repo.AssertNotCalled(t, "UpdateStaffRole", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package unit
+
+import (
+	"hospital-middleware/internal/models"
+	"hospital-middleware/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// "ปู่" (pu) with the vowel before the tone mark (NFC order) and after it, as some keyboards type it.
+// "\u0e1b\u0e39\u0e48" with the vowel before the tone mark (NFC order) and after it, as some keyboards type it.
+// Both render identically.
+const (
+	puNFC       = "\u0e1b\u0e39\u0e48"
+	puToneFirst = "\u0e1b\u0e48\u0e39"
+)
+
+func TestNormalizeThai_CombiningMarkOrder(t *testing.T) {
+	assert.NotEqual(t, puNFC, puToneFirst)
+	assert.Equal(t, puNFC, utils.NormalizeThai(puToneFirst))
+	assert.Equal(t, puNFC, utils.NormalizeThai(puNFC))
+	assert.Equal(t, "Somchai", utils.NormalizeThai("Somchai"))
+}
+
+func TestFoldThai(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"tone mark dropped", "\u0e01\u0e49\u0e2d\u0e07", "\u0e01\u0e2d\u0e07"},         // ก้อง, กอง
+		{"different tone mark", "\u0e15\u0e4a\u0e2d\u0e07", "\u0e15\u0e48\u0e2d\u0e07"}, // ต๊อง, ต่อง
+		{"combining mark order", puNFC, puToneFirst},
+		{"sara am typed as nikhahit and sara aa", "\u0e19\u0e49\u0e33\u0e1d\u0e19", "\u0e19\u0e4d\u0e49\u0e32\u0e1d\u0e19"}, // น้ำฝน
+		{"sara ae typed as two sara e", "\u0e41\u0e2a\u0e07", "\u0e40\u0e40\u0e2a\u0e07"},                                   // แสง
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, utils.FoldThai(tt.a), utils.FoldThai(tt.b))
+		})
+	}
+	// Consonants and other vowels still count
+	assert.NotEqual(t, utils.FoldThai("\u0e01\u0e2d\u0e07"), utils.FoldThai("\u0e01\u0e49\u0e32\u0e07"))
+}
+
+func TestPatientNormalizeThaiNames(t *testing.T) {
+	patient := &models.Patient{FirstNameTH: puToneFirst, LastNameTH: "\u0e01\u0e49\u0e2d\u0e07"}
+	patient.NormalizeThaiNames()
+
+	assert.Equal(t, puNFC, patient.FirstNameTH)
+	if assert.NotNil(t, patient.FirstNameTHFolded) && assert.NotNil(t, patient.MiddleNameTHFolded) && assert.NotNil(t, patient.LastNameTHFolded) {
+		assert.Equal(t, "\u0e1b\u0e39", *patient.FirstNameTHFolded)
+		assert.Equal(t, "", *patient.MiddleNameTHFolded)
+		assert.Equal(t, "\u0e01\u0e2d\u0e07", *patient.LastNameTHFolded)
+	}
+}
@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"encoding/json"
+	"hospital-middleware/internal/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestCreateVisitHandler_InheritsPatientHospital(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("CreateVisit", mock.MatchedBy(func(v *models.Visit) bool {
+		return v.PatientID == 10 && v.HospitalID == 1 && v.VisitNumber == "VN001" && !v.AdmittedAt.IsZero()
+	})).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/visits", gin.H{"visit_number": "VN001"}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestCreateVisitHandler_DuplicateVisitNumber(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("CreateVisit", mock.Anything).Return(&pgconn.PgError{Code: "23505"})
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/visits", gin.H{"visit_number": "VN001"}, token)
+
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"code":"VISIT_002"`)
+}
+
+func TestCreateVisitHandler_OtherHospitalPatient(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 2}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/10/visits", gin.H{"visit_number": "VN001"}, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	repo.AssertNotCalled(t, "CreateVisit", mock.Anything)
+}
+
+func TestCreateVisitHandler_UnknownPatient(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(99)).Return(nil, gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "POST", "/api/v1/patient/99/visits", gin.H{"visit_number": "VN001"}, token)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestListPatientVisitsHandler_Paginates(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1}, nil)
+	repo.On("ListVisitsByPatient", uint(10), uint(1), 5, 5).Return([]models.Visit{{ID: 1}}, int64(6), nil)
+
+	rr := performRequest(router, "GET", "/api/v1/patient/10/visits?page=2&page_size=5", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var page models.PaginatedResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, 2, page.Page)
+	assert.Equal(t, 5, page.PageSize)
+	assert.Equal(t, int64(6), page.Total)
+	repo.AssertExpectations(t)
+}
+
+func TestListDailyVisitsHandler_DayInConfiguredTimezone(t *testing.T) {
+	cfg := *testConfig
+	cfg.Timezone = "Asia/Bangkok"
+	router, repo := newTestRouterWithConfig(&cfg)
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("ListVisitsByDate", uint(1), mock.MatchedBy(func(from time.Time) bool {
+		return from.Equal(time.Date(2001, 3, 13, 17, 0, 0, 0, time.UTC)) // Midnight in Bangkok
+	}), mock.MatchedBy(func(before time.Time) bool {
+		return before.Equal(time.Date(2001, 3, 14, 17, 0, 0, 0, time.UTC))
+	})).Return([]models.Visit{}, nil)
+
+	rr := performRequest(router, "GET", "/api/v1/visits?date=2001-03-14", nil, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	repo.AssertExpectations(t)
+}
+
+func TestListDailyVisitsHandler_RequiresDate(t *testing.T) {
+	router, repo := newTestRouter()
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+
+	rr := performRequest(router, "GET", "/api/v1/visits", nil, token)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	repo.AssertNotCalled(t, "ListVisitsByDate", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,318 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"hospital-middleware/internal/api"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/utils"
+	"hospital-middleware/test/mocks"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// webhookDelivery is one request received by a webhookReceiver.
+type webhookDelivery struct {
+	Event           string
+	Signature       string
+	LegacySignature string
+	Body            []byte
+}
+
+// webhookReceiver starts an httptest server that records deliveries and answers each with the
+// next of statuses, repeating the last one.
+func webhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, chan webhookDelivery) {
+	t.Helper()
+	deliveries := make(chan webhookDelivery, 10)
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- webhookDelivery{
+			Event:           r.Header.Get(services.WebhookEventHeader),
+			Signature:       r.Header.Get(services.WebhookSignatureHeader),
+			LegacySignature: r.Header.Get(services.LegacyWebhookSignatureHeader),
+			Body:            body,
+		}
+		mu.Lock()
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, deliveries
+}
+
+// nextDelivery waits for the receiver's next delivery.
+func nextDelivery(t *testing.T, deliveries chan webhookDelivery) webhookDelivery {
+	t.Helper()
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+		return webhookDelivery{}
+	}
+}
+
+func TestPatientUpdate_DeliversSignedWebhook(t *testing.T) {
+	server, deliveries := webhookReceiver(t, http.StatusOK)
+	// Built from its own mock, since newTestRouter answers that no hospital has webhooks
+	repo := new(mocks.MockPatientRepository)
+	repo.On("IsTokenRevoked", mock.Anything).Return(false, nil).Maybe()
+	repo.On("RecordStaffLogin", mock.Anything, mock.Anything).Return(nil).Maybe()
+	router := api.SetupRouter(repo, testBlobs, testConfig, services.NewAsyncWriters(repo))
+	staff := hashedStaff(t, 3, "nurse", "password123", 1, "Hospital A")
+	token := loginToken(t, router, repo, staff, "password123")
+	repo.On("GetPatientByID", uint(10)).Return(&models.Patient{ID: 10, HospitalID: 1, FirstNameEN: "Somchai", NationalID: "1234567890123"}, nil)
+	repo.On("UpdatePatientFields", uint(10), uint(1), mock.Anything, mock.Anything).Return(nil)
+	repo.On("ListActiveWebhooks", uint(1), models.WebhookEventPatientUpdated).
+		Return([]models.Webhook{{ID: 5, HospitalID: 1, URL: server.URL, Secret: "receiver_shared_secret", Active: true}}, nil)
+
+	rr := performRequest(router, "PATCH", "/api/v1/patient/10", gin.H{"blood_type": "O+"}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	delivery := nextDelivery(t, deliveries)
+	assert.Equal(t, models.WebhookEventPatientUpdated, delivery.Event)
+	assert.Equal(t, utils.SignPayload("receiver_shared_secret", delivery.Body), delivery.Signature)
+	assert.Equal(t, delivery.Signature, delivery.LegacySignature, "the old header carries the same signature")
+	assert.NotEqual(t, utils.SignPayload("some_other_secret", delivery.Body), delivery.Signature)
+	var payload models.WebhookPayload
+	assert.NoError(t, json.Unmarshal(delivery.Body, &payload))
+	assert.Equal(t, models.WebhookEventPatientUpdated, payload.Event)
+	assert.Equal(t, uint(1), payload.HospitalID)
+	assert.WithinDuration(t, time.Now(), payload.Timestamp, time.Minute)
+	if assert.NotNil(t, payload.Patient) {
+		assert.Equal(t, uint(10), payload.Patient.ID)
+		if assert.NotNil(t, payload.Patient.BloodType) {
+			assert.Equal(t, "O+", *payload.Patient.BloodType)
+		}
+		assert.Equal(t, "*********0123", payload.Patient.NationalID, "identifiers are masked")
+	}
+}
+
+func TestWebhookDispatcher_RetriesThenSucceeds(t *testing.T) {
+	server, deliveries := webhookReceiver(t, http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusNoContent)
+	repo := new(mocks.MockPatientRepository)
+	repo.On("ListActiveWebhooks", uint(1), models.WebhookEventPatientDeleted).
+		Return([]models.Webhook{{ID: 5, URL: server.URL, Secret: "receiver_shared_secret"}}, nil)
+	dispatcher := services.NewWebhookDispatcher(repo, server.Client(), time.Millisecond)
+
+	dispatcher.Dispatch(models.WebhookPayload{Event: models.WebhookEventPatientDeleted, HospitalID: 1, Deleted: 3})
+	dispatcher.Wait()
+
+	assert.Len(t, deliveries, services.WebhookMaxAttempts)
+	repo.AssertNotCalled(t, "CreateWebhookDeadLetter", mock.Anything)
+}
+
+func TestWebhookDispatcher_DrainWaitsForRetries(t *testing.T) {
+	server, deliveries := webhookReceiver(t, http.StatusServiceUnavailable, http.StatusNoContent)
+	repo := new(mocks.MockPatientRepository)
+	repo.On("ListActiveWebhooks", uint(1), models.WebhookEventPatientDeleted).
+		Return([]models.Webhook{{ID: 5, URL: server.URL, Secret: "receiver_shared_secret"}}, nil)
+	dispatcher := services.NewWebhookDispatcher(repo, server.Client(), 20*time.Millisecond)
+
+	dispatcher.Dispatch(models.WebhookPayload{Event: models.WebhookEventPatientDeleted, HospitalID: 1, Deleted: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, dispatcher.Drain(ctx))
+	assert.Len(t, deliveries, 2, "the retry is sent before Drain returns")
+}
+
+func TestWebhookDispatcher_DrainStopsAtDeadline(t *testing.T) {
+	server, _ := webhookReceiver(t, http.StatusServiceUnavailable)
+	repo := new(mocks.MockPatientRepository)
+	repo.On("ListActiveWebhooks", uint(1), models.WebhookEventPatientDeleted).
+		Return([]models.Webhook{{ID: 5, URL: server.URL, Secret: "receiver_shared_secret"}}, nil)
+	repo.On("CreateWebhookDeadLetter", mock.Anything).Return(nil).Maybe()
+	dispatcher := services.NewWebhookDispatcher(repo, server.Client(), 200*time.Millisecond)
+	t.Cleanup(dispatcher.Wait) // The retries outlive the test otherwise
+
+	dispatcher.Dispatch(models.WebhookPayload{Event: models.WebhookEventPatientDeleted, HospitalID: 1, Deleted: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, dispatcher.Drain(ctx), context.DeadlineExceeded)
+}
+
+func TestWebhookDispatcher_DeadLettersAfterMaxAttempts(t *testing.T) {
+	server, deliveries := webhookReceiver(t, http.StatusBadGateway)
+	repo := new(mocks.MockPatientRepository)
+	repo.On("ListActiveWebhooks", uint(1), models.WebhookEventPatientUpdated).
+		Return([]models.Webhook{{ID: 5, URL: server.URL, Secret: "receiver_shared_secret"}}, nil)
+	var deadLetter *models.WebhookDeadLetter
+	repo.On("CreateWebhookDeadLetter", mock.Anything).Run(func(args mock.Arguments) {
+		deadLetter = args.Get(0).(*models.WebhookDeadLetter)
+	}).Return(nil).Once()
+	dispatcher := services.NewWebhookDispatcher(repo, server.Client(), time.Millisecond)
+
+	start := time.Now()
+	dispatcher.Dispatch(models.WebhookPayload{Event: models.WebhookEventPatientUpdated, HospitalID: 1, Patient: &models.Patient{ID: 10}})
+	dispatcher.Wait()
+
+	assert.Len(t, deliveries, services.WebhookMaxAttempts)
+	assert.GreaterOrEqual(t, time.Since(start), 3*time.Millisecond, "waits 1ms, then 2ms, between attempts")
+	if assert.NotNil(t, deadLetter) {
+		assert.Equal(t, uint(5), deadLetter.WebhookID)
+		assert.Equal(t, models.WebhookEventPatientUpdated, deadLetter.Event)
+		assert.Equal(t, services.WebhookMaxAttempts, deadLetter.Attempts)
+		assert.Equal(t, http.StatusBadGateway, deadLetter.StatusCode)
+		assert.Contains(t, deadLetter.LastError, "502")
+		assert.Contains(t, deadLetter.Payload, `"id":10`)
+	}
+}
+
+func TestCreateWebhookHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("CreateWebhook", mock.MatchedBy(func(w *models.Webhook) bool {
+		return w.HospitalID == 1 && w.URL == "https://appointments.example/hooks" && w.Active &&
+			len(w.Events) == 2 && len(w.Secret) == 48
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Webhook).ID = 5
+	}).Return(nil)
+
+	rr := performRequest(router, "POST", "/api/v1/admin/webhooks", gin.H{
+		"url":    "https://appointments.example/hooks",
+		"events": []string{models.WebhookEventPatientUpdated, models.WebhookEventPatientDeleted},
+	}, token)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var created models.WebhookCreateResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, uint(5), created.ID)
+	assert.Len(t, created.Secret, 48, "the generated secret is returned once")
+	repo.AssertExpectations(t)
+}
+
+func TestCreateWebhookHandler_Validation(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+
+	for _, body := range []gin.H{
+		{"events": []string{models.WebhookEventPatientUpdated}},
+		{"url": "https://appointments.example/hooks"},
+		{"url": "https://appointments.example/hooks", "events": []string{}},
+		{"url": "https://appointments.example/hooks", "events": []string{"patient.viewed"}},
+		{"url": "ftp://appointments.example/hooks", "events": []string{models.WebhookEventPatientUpdated}},
+		{"url": "https://appointments.example/hooks", "events": []string{models.WebhookEventPatientUpdated}, "secret": "short"},
+	} {
+		rr := performRequest(router, "POST", "/api/v1/admin/webhooks", body, token)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	}
+	repo.AssertNotCalled(t, "CreateWebhook", mock.Anything)
+
+	staffToken := importAdminToken(t, router, repo, models.RoleStaff)
+	rr := performRequest(router, "POST", "/api/v1/admin/webhooks", gin.H{"url": "https://appointments.example/hooks", "events": []string{models.WebhookEventPatientUpdated}}, staffToken)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestListAndGetWebhookHandlers(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	webhook := models.Webhook{ID: 5, HospitalID: 1, URL: "https://appointments.example/hooks", Secret: "receiver_shared_secret", Events: models.StringList{models.WebhookEventPatientUpdated}, Active: true}
+	repo.On("ListWebhooks", uint(1)).Return([]models.Webhook{webhook}, nil)
+	repo.On("GetWebhook", uint(5), uint(1)).Return(&webhook, nil)
+	repo.On("GetWebhook", uint(6), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "GET", "/api/v1/admin/webhooks", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"events":["patient.updated"]`)
+	assert.NotContains(t, rr.Body.String(), "receiver_shared_secret")
+
+	rr = performRequest(router, "GET", "/api/v1/admin/webhooks/5", nil, token)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "receiver_shared_secret")
+
+	// Another hospital's webhook looks missing
+	rr = performRequest(router, "GET", "/api/v1/admin/webhooks/6", nil, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUpdateWebhookHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetWebhook", uint(5), uint(1)).Return(&models.Webhook{ID: 5, HospitalID: 1, URL: "https://appointments.example/hooks", Secret: "receiver_shared_secret", Events: models.StringList{models.WebhookEventPatientUpdated}, Active: true}, nil)
+	repo.On("UpdateWebhook", mock.MatchedBy(func(w *models.Webhook) bool {
+		return !w.Active && w.URL == "https://appointments.example/hooks" && w.Secret == "receiver_shared_secret" &&
+			len(w.Events) == 1 && w.Events[0] == models.WebhookEventPatientDeleted
+	})).Return(nil)
+
+	rr := performRequest(router, "PUT", "/api/v1/admin/webhooks/5", gin.H{"active": false, "events": []string{models.WebhookEventPatientDeleted}}, token)
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteWebhookHandler(t *testing.T) {
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("DeleteWebhook", uint(5), uint(1)).Return(nil)
+	repo.On("DeleteWebhook", uint(6), uint(1)).Return(gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "DELETE", "/api/v1/admin/webhooks/5", nil, token)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = performRequest(router, "DELETE", "/api/v1/admin/webhooks/6", nil, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestSignPayload(t *testing.T) {
+	// The example from the verification instructions on utils.SignPayload
+	assert.Equal(t, "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+		utils.SignPayload("It's a Secret to Everybody", []byte("Hello, World!")))
+	assert.NotEqual(t, utils.SignPayload("It's a Secret to Everybody", []byte("Hello, World!")),
+		utils.SignPayload("It's a Secret to Everybody", []byte("Hello, World")))
+}
+
+func TestTestWebhookHandler(t *testing.T) {
+	server, deliveries := webhookReceiver(t, http.StatusNoContent, http.StatusTeapot)
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetWebhook", uint(5), uint(1)).Return(&models.Webhook{ID: 5, HospitalID: 1, URL: server.URL, Secret: "receiver_shared_secret"}, nil)
+	repo.On("GetWebhook", uint(6), uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+	rr := performRequest(router, "POST", "/api/v1/admin/webhook/test", gin.H{"webhook_id": 5}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"webhook_id":5,"status_code":204,"delivered":true}`, rr.Body.String())
+	delivery := nextDelivery(t, deliveries)
+	assert.Equal(t, models.WebhookEventTest, delivery.Event)
+	assert.Equal(t, utils.SignPayload("receiver_shared_secret", delivery.Body), delivery.Signature)
+
+	// The remote status is reported even when it is not 2xx, and nothing is retried
+	rr = performRequest(router, "POST", "/api/v1/admin/webhook/test", gin.H{"webhook_id": 5}, token)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `{"webhook_id":5,"status_code":418,"delivered":false}`, rr.Body.String())
+	nextDelivery(t, deliveries)
+	assert.Empty(t, deliveries)
+	repo.AssertNotCalled(t, "CreateWebhookDeadLetter", mock.Anything)
+
+	rr = performRequest(router, "POST", "/api/v1/admin/webhook/test", gin.H{"webhook_id": 6}, token)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestTestWebhookHandler_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close() // Nothing listens at its URL any more
+	router, repo := newTestRouter()
+	token := importAdminToken(t, router, repo, models.RoleAdmin)
+	repo.On("GetWebhook", uint(5), uint(1)).Return(&models.Webhook{ID: 5, HospitalID: 1, URL: server.URL, Secret: "receiver_shared_secret"}, nil)
+
+	rr := performRequest(router, "POST", "/api/v1/admin/webhook/test", gin.H{"webhook_id": 5}, token)
+
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+	assert.Contains(t, rr.Body.String(), "could not be reached")
+}
@@ -0,0 +1,152 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/models"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cleanupVisits removes all visits recorded for a patient once the test ends.
+func cleanupVisits(t *testing.T, patientID uint) {
+	t.Cleanup(func() {
+		log.Printf("Cleaning up visits for patient ID: %d", patientID)
+		if err := testDB.Where("patient_id = ?", patientID).Delete(&models.Visit{}).Error; err != nil {
+			log.Printf("Error cleaning up visits for patient %d: %v", patientID, err)
+		}
+	})
+}
+
+func TestVisitHandlers_CreateAndListNewestFirst(t *testing.T) {
+	// 1. Seed a patient in Hospital A and get a token for the same hospital
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupVisits(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_visits"), "password123", "Hospital A")
+
+	// 2. Record two visits, the second one later than the first
+	visitsURL := fmt.Sprintf("/api/v1/patient/%d/visits", testPatient.ID)
+	older := time.Now().Add(-48 * time.Hour).UTC().Truncate(time.Second)
+	newer := time.Now().Add(-1 * time.Hour).UTC().Truncate(time.Second)
+	for i, admitted := range []time.Time{older, newer} {
+		body := models.VisitCreateRequest{
+			VisitNumber: fmt.Sprintf("VN%d_%d", time.Now().UnixNano(), i),
+			AdmittedAt:  &admitted,
+			Department:  "OPD",
+		}
+		rr := performRequest(testRouter, "POST", visitsURL, body, authToken)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	// 3. List visits and check ordering
+	rr := performRequest(testRouter, "GET", visitsURL+"?page=1&page_size=10", nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var page struct {
+		Data  []models.Visit `json:"data"`
+		Total int64          `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, int64(2), page.Total)
+	if assert.Len(t, page.Data, 2) {
+		assert.True(t, page.Data[0].AdmittedAt.After(page.Data[1].AdmittedAt), "Expected newest visit first")
+		assert.Equal(t, testPatient.HospitalID, page.Data[0].HospitalID)
+	}
+}
+
+func TestVisitHandlers_DuplicateVisitNumber(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupVisits(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_visit_dup"), "password123", "Hospital A")
+	visitsURL := fmt.Sprintf("/api/v1/patient/%d/visits", testPatient.ID)
+	body := models.VisitCreateRequest{VisitNumber: fmt.Sprintf("VND%d", time.Now().UnixNano())}
+
+	rr := performRequest(testRouter, "POST", visitsURL, body, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	rr = performRequest(testRouter, "POST", visitsURL, body, authToken)
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+}
+
+// The count and the page are built from the same query; a shared GORM chain would add the page's
+// clauses to the count, or the count's to the page.
+func TestVisitHandlers_ListPagesKeepTheTotal(t *testing.T) {
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupVisits(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_visit_pages"), "password123", "Hospital A")
+	visitsURL := fmt.Sprintf("/api/v1/patient/%d/visits", testPatient.ID)
+	for i := 0; i < 3; i++ {
+		admitted := time.Now().Add(-time.Duration(i) * time.Hour).UTC().Truncate(time.Second)
+		body := models.VisitCreateRequest{VisitNumber: fmt.Sprintf("VNP%d_%d", time.Now().UnixNano(), i), AdmittedAt: &admitted}
+		rr := performRequest(testRouter, "POST", visitsURL, body, authToken)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	var seen []uint
+	for pageNumber := 1; pageNumber <= 2; pageNumber++ {
+		rr := performRequest(testRouter, "GET", fmt.Sprintf("%s?page=%d&page_size=2", visitsURL, pageNumber), nil, authToken)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var page struct {
+			Data  []models.Visit `json:"data"`
+			Total int64          `json:"total"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		assert.Equal(t, int64(3), page.Total, "page %d", pageNumber)
+		for _, visit := range page.Data {
+			seen = append(seen, visit.ID)
+		}
+	}
+	assert.Len(t, seen, 3, "the two pages hold every visit once")
+}
+
+func TestVisitHandlers_RejectOtherHospitalPatient(t *testing.T) {
+	// Patient belongs to Hospital A, staff belongs to Hospital B
+	testPatient := createTestPatient(1)
+	seedPatient(t, testPatient)
+	cleanupVisits(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_visits_other"), "password123", "Hospital B")
+
+	body := models.VisitCreateRequest{VisitNumber: fmt.Sprintf("VN%d", time.Now().UnixNano())}
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/visits", testPatient.ID), body, authToken)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	var count int64
+	testDB.Model(&models.Visit{}).Where("patient_id = ?", testPatient.ID).Count(&count)
+	assert.Equal(t, int64(0), count, "No visit should be created for another hospital's patient")
+}
+
+func TestVisitHandlers_DailyListing(t *testing.T) {
+	testPatient := createTestPatient(2)
+	seedPatient(t, testPatient)
+	cleanupVisits(t, testPatient.ID)
+	authToken := getAuthToken(t, uniqueUsername("staff_visits_daily"), "password123", "Hospital B")
+
+	bangkok, err := time.LoadLocation(testConfig.Timezone)
+	assert.NoError(t, err)
+	// Early on the 14th in the configured zone, still the 13th in UTC
+	admitted := time.Date(2001, 3, 14, 1, 30, 0, 0, bangkok)
+	visitNumber := fmt.Sprintf("VN%d", time.Now().UnixNano())
+	body := models.VisitCreateRequest{VisitNumber: visitNumber, AdmittedAt: &admitted}
+	rr := performRequest(testRouter, "POST", fmt.Sprintf("/api/v1/patient/%d/visits", testPatient.ID), body, authToken)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	// Same day includes the visit, next day does not
+	rr = performRequest(testRouter, "GET", "/api/v1/visits?date=2001-03-14", nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), visitNumber)
+
+	rr = performRequest(testRouter, "GET", "/api/v1/visits?date=2001-03-15", nil, authToken)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), visitNumber)
+
+	rr = performRequest(testRouter, "GET", "/api/v1/visits?date=14-03-2001", nil, authToken)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
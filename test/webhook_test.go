@@ -0,0 +1,97 @@
+//go:build integration
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"hospital-middleware/internal/database"
+	"hospital-middleware/internal/models"
+	"hospital-middleware/internal/services"
+	"hospital-middleware/pkg/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// createWebhook registers a webhook through the admin endpoint and returns it with its secret.
+func createWebhook(t *testing.T, adminToken string, body gin.H) models.WebhookCreateResponse {
+	t.Helper()
+	rr := performRequest(testRouter, "POST", "/api/v1/admin/webhooks", body, adminToken)
+	if !assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String()) {
+		t.FailNow()
+	}
+	var created models.WebhookCreateResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	t.Cleanup(func() { testDB.Delete(&models.Webhook{}, created.ID) })
+	return created
+}
+
+func TestWebhook_DeliveredOnPatientUpdate(t *testing.T) {
+	type delivery struct {
+		signature string
+		body      []byte
+	}
+	deliveries := make(chan delivery, 5)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- delivery{signature: r.Header.Get(services.WebhookSignatureHeader), body: body}
+	}))
+	defer receiver.Close()
+
+	hospital := createIsolatedHospital(t, "Webhook")
+	patient := createTestPatient(hospital.ID)
+	seedPatient(t, patient)
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_webhook"), "password123", hospital.Name)
+	webhook := createWebhook(t, adminToken, gin.H{"url": receiver.URL, "events": []string{models.WebhookEventPatientUpdated}})
+
+	rr := performRequest(testRouter, "PATCH", fmt.Sprintf("/api/v1/patient/%d", patient.ID), gin.H{"blood_type": "B-"}, adminToken)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	select {
+	case d := <-deliveries:
+		assert.Equal(t, utils.SignPayload(webhook.Secret, d.body), d.signature)
+		var payload models.WebhookPayload
+		assert.NoError(t, json.Unmarshal(d.body, &payload))
+		assert.Equal(t, models.WebhookEventPatientUpdated, payload.Event)
+		if assert.NotNil(t, payload.Patient) {
+			assert.Equal(t, patient.ID, payload.Patient.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
+
+func TestListActiveWebhooks_FiltersByEventAndActive(t *testing.T) {
+	hospital := createIsolatedHospital(t, "WebhookFilter")
+	adminToken := getAdminAuthToken(t, uniqueUsername("admin_webhook_filter"), "password123", hospital.Name)
+	updates := createWebhook(t, adminToken, gin.H{"url": "https://a.example/hook", "events": []string{models.WebhookEventPatientUpdated}})
+	both := createWebhook(t, adminToken, gin.H{"url": "https://b.example/hook", "events": []string{models.WebhookEventPatientUpdated, models.WebhookEventPatientDeleted}})
+	createWebhook(t, adminToken, gin.H{"url": "https://c.example/hook", "events": []string{models.WebhookEventPatientUpdated}, "active": false})
+
+	webhooks, err := database.ListActiveWebhooks(hospital.ID, models.WebhookEventPatientUpdated)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uint{updates.ID, both.ID}, webhookIDs(webhooks))
+
+	webhooks, err = database.ListActiveWebhooks(hospital.ID, models.WebhookEventPatientDeleted)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{both.ID}, webhookIDs(webhooks))
+
+	// Other hospitals' admins cannot see or remove them
+	otherAdmin := getAdminAuthToken(t, uniqueUsername("admin_webhook_other"), "password123", "Hospital A")
+	rr := performRequest(testRouter, "DELETE", fmt.Sprintf("/api/v1/admin/webhooks/%d", both.ID), nil, otherAdmin)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func webhookIDs(webhooks []models.Webhook) []uint {
+	ids := make([]uint, len(webhooks))
+	for i, webhook := range webhooks {
+		ids[i] = webhook.ID
+	}
+	return ids
+}